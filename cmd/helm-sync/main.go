@@ -19,12 +19,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"k8s.io/klog/v2/klogr"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/helm"
 	"kpt.dev/configsync/pkg/reconcilermanager"
+	"kpt.dev/configsync/pkg/trigger"
 	"kpt.dev/configsync/pkg/util"
 	utillog "kpt.dev/configsync/pkg/util/log"
 )
@@ -35,7 +37,7 @@ var (
 	flChart = flag.String("chart", os.Getenv(reconcilermanager.HelmChart),
 		"the name of the helm chart being synced")
 	flVersion = flag.String("version", os.Getenv(reconcilermanager.HelmChartVersion),
-		"the version of the helm chart being synced")
+		"the version of the helm chart being synced, which can be a semantic version constraint (defaults to the latest version)")
 	flAuth = flag.String("auth", util.EnvString(reconcilermanager.HelmAuthType, string(configsync.AuthNone)),
 		fmt.Sprintf("the authentication type for access to the Helm repository. Must be one of %s, %s, %s or %s. Defaults to %s",
 			configsync.AuthGCPServiceAccount, configsync.AuthToken, configsync.AuthGCENode, configsync.AuthNone, configsync.AuthNone))
@@ -43,6 +45,12 @@ var (
 		"the name of helm release")
 	flNamespace = flag.String("namespace", os.Getenv(reconcilermanager.HelmReleaseNamespace),
 		"the targe namespace of helm release")
+	flValuesYAML = flag.String("values-yaml", os.Getenv(reconcilermanager.HelmValuesYAML),
+		"the inline helm values in YAML format, which take precedence over --values-files")
+	flValuesFiles = flag.String("values-files", os.Getenv(reconcilermanager.HelmValuesFiles),
+		"a comma-separated list of values files inside the helm chart, in increasing order of precedence")
+	flIncludeCRDs = flag.Bool("include-crds", util.EnvBool(reconcilermanager.HelmIncludeCRDs, false),
+		"include CRDs in the rendered helm chart")
 	flRoot = flag.String("root", util.EnvString("HELM_SYNC_ROOT", util.EnvString("HOME", "")+"/helm"),
 		"the root directory for helm-sync operations, under which --dest will be created")
 	flDest = flag.String("dest", util.EnvString("HELM_SYNC_DEST", ""),
@@ -57,6 +65,10 @@ var (
 		"exit after the first sync")
 	flMaxSyncFailures = flag.Int("max-sync-failures", util.EnvInt("HELM_SYNC_MAX_SYNC_FAILURES", 0),
 		"the number of consecutive failures allowed before aborting (the first sync must succeed, -1 will retry forever after the initial sync)")
+	flSyncTriggerAddr = flag.String("sync-trigger-addr", util.EnvString("HELM_SYNC_TRIGGER_ADDR", trigger.SidecarAddr),
+		"the address on which to serve the sync trigger endpoint, which starts a sync without waiting for --wait (defaults to a Pod-local address, \"\" disables the endpoint)")
+	flNotifyURL = flag.String("notify-url", util.EnvString("HELM_SYNC_NOTIFY_URL", trigger.ReconcilerURL),
+		"the URL to call once a new chart version is rendered (defaults to the sync trigger endpoint of the reconciler, \"\" disables the notification)")
 	flUsername = flag.String("username", util.EnvString("HELM_SYNC_USERNAME", ""),
 		"the username to use for helm authantication")
 	flPassword = flag.String("password", util.EnvString("HELM_SYNC_PASSWORD", ""),
@@ -67,9 +79,11 @@ func main() {
 	utillog.Setup()
 	log := utillog.NewLogger(klogr.New(), *flRoot, *flErrorFile)
 	log.Info("rendering Helm chart with arguments", "--repo", *flRepo,
-		"--chart", *flChart, "--version", *flVersion, "--root", *flRoot, "--dest", *flDest, "--wait", *flWait,
+		"--chart", *flChart, "--version", *flVersion, "--values-files", *flValuesFiles,
+		"--include-crds", *flIncludeCRDs, "--root", *flRoot, "--dest", *flDest, "--wait", *flWait,
 		"--error-file", *flErrorFile, "--timeout", *flSyncTimeout,
-		"--one-time", *flOneTime, "--max-sync-failures", *flMaxSyncFailures)
+		"--one-time", *flOneTime, "--max-sync-failures", *flMaxSyncFailures,
+		"--sync-trigger-addr", *flSyncTriggerAddr, "--notify-url", *flNotifyURL)
 
 	if *flRepo == "" {
 		utillog.HandleError(log, true, "ERROR: --repo must be specified")
@@ -93,16 +107,31 @@ func main() {
 		}
 	}
 
+	var valuesFiles []string
+	if *flValuesFiles != "" {
+		valuesFiles = strings.Split(*flValuesFiles, ",")
+	}
+
+	var syncTrigger *trigger.Handler
+	if *flSyncTriggerAddr != "" {
+		syncTrigger = trigger.NewHandler(trigger.Options{})
+		trigger.Start(context.Background(), "", *flSyncTriggerAddr, syncTrigger)
+	}
+
 	initialSync := true
 	failCount := 0
 	for {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*flSyncTimeout))
+		oldChartDir := util.SymlinkTarget(*flRoot, *flDest)
 		hydrator := &helm.Hydrator{
 			Chart:       *flChart,
 			Repo:        *flRepo,
 			Version:     *flVersion,
 			ReleaseName: *flReleaseName,
 			Namespace:   *flNamespace,
+			Values:      *flValuesYAML,
+			ValuesFiles: valuesFiles,
+			IncludeCRDs: *flIncludeCRDs,
 			Auth:        configsync.AuthType(*flAuth),
 			HydrateRoot: *flRoot,
 			Dest:        *flDest,
@@ -120,7 +149,7 @@ func main() {
 			log.Error(err, "unexpected error rendering chart, will retry")
 			log.Info("waiting before retrying", "waitTime", util.WaitTime(*flWait))
 			cancel()
-			time.Sleep(util.WaitTime(*flWait))
+			trigger.Sleep(util.WaitTime(*flWait), syncTrigger.C())
 			continue
 		}
 
@@ -134,8 +163,20 @@ func main() {
 
 		failCount = 0
 		log.DeleteErrorFile()
+		notifyReconciler(ctx, log, oldChartDir)
 		log.Info("next sync", "wait_time", util.WaitTime(*flWait))
 		cancel()
-		time.Sleep(util.WaitTime(*flWait))
+		trigger.Sleep(util.WaitTime(*flWait), syncTrigger.C())
+	}
+}
+
+// notifyReconciler calls --notify-url if the sync rendered a new chart version,
+// so that the reconciler doesn't wait for its next polling period.
+func notifyReconciler(ctx context.Context, log *utillog.Logger, oldChartDir string) {
+	if *flNotifyURL == "" || oldChartDir == "" || util.SymlinkTarget(*flRoot, *flDest) == oldChartDir {
+		return
+	}
+	if err := trigger.Notify(ctx, *flNotifyURL); err != nil {
+		log.Info("failed to notify the reconciler of the new chart version", "error", err.Error())
 	}
 }
@@ -66,6 +66,9 @@ var (
 	rehydratePeriod = flag.Duration("rehydrate-period", 30*time.Minute,
 		"Period of time between rehydrating on errors.")
 
+	renderTimeout = flag.Duration("render-timeout", hydrate.DefaultRenderTimeout,
+		"Maximum duration of the rendering of a commit. There is no limit if it is zero.")
+
 	reconcilerName = flag.String("reconciler-name", os.Getenv(reconcilermanager.ReconcilerNameKey),
 		"Name of the reconciler Deployment.")
 )
@@ -123,6 +126,7 @@ func main() {
 		PollingFrequency:   hydrationPollingPeriod,
 		RehydrateFrequency: *rehydratePeriod,
 		ReconcilerName:     *reconcilerName,
+		RenderTimeout:      *renderTimeout,
 	}
 
 	hydrator.Run(context.Background())
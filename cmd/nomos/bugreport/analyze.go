@@ -0,0 +1,51 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"kpt.dev/configsync/pkg/bugreport"
+)
+
+// analyzeCmd detects the common failure patterns in a bug report zip file.
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze ZIP_FILE",
+	Short: "Detects common failure patterns in a bug report zip file.",
+	Long: "Detects common failure patterns in a zip file generated by bugreport, such as KNV errors, " +
+		"reconcilers killed because they ran out of memory, authentication failures and admission webhook denials, " +
+		"and prints their likely causes.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Don't show usage on error, as argument validation passed.
+		cmd.SilenceUsage = true
+
+		findings, err := bugreport.Analyze(args[0])
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		if len(findings) == 0 {
+			fmt.Fprintln(out, "No common failure patterns found.")
+			return nil
+		}
+		for _, f := range findings {
+			fmt.Fprintf(out, "%s (%d occurrences, first in %s)\n", f.Problem, f.Count, f.Example)
+			fmt.Fprintf(out, "  Likely cause: %s\n", f.Cause)
+		}
+		return nil
+	},
+}
@@ -17,6 +17,7 @@ package bugreport
 import (
 	"flag"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -27,15 +28,26 @@ import (
 	"kpt.dev/configsync/pkg/client/restconfig"
 )
 
+var (
+	since             time.Duration
+	redactionPatterns []string
+)
+
 func init() {
 	Cmd.Flags().DurationVar(&flags.ClientTimeout, "timeout", flags.DefaultClusterClientTimeout, "Timeout for connecting to the cluster")
+	Cmd.Flags().DurationVar(&since, "since", 0, "Only include the logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs.")
+	Cmd.Flags().StringArrayVar(&redactionPatterns, "redact", nil,
+		"A regular expression matching sensitive data to redact from the bug report, in addition to the credentials and tokens redacted by default. "+
+			"If the expression has capture groups, only the text they match is redacted. Can be repeated.")
+	Cmd.AddCommand(analyzeCmd)
 }
 
 // Cmd retrieves readers for all relevant nomos container logs and cluster state commands and writes them to a zip file
 var Cmd = &cobra.Command{
 	Use:   "bugreport",
 	Short: fmt.Sprintf("Generates a zip file of relevant %v debug information.", configmanagement.CLIName),
-	Long:  "Generates a zip file in your current directory containing an aggregate of the logs and cluster state for debugging purposes.",
+	Long: "Generates a zip file in your current directory containing an aggregate of the logs and cluster state for debugging purposes. " +
+		"Credentials, tokens and private keys are redacted from every file of the bug report.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Don't show usage on error, as argument validation passed.
 		cmd.SilenceUsage = true
@@ -51,7 +63,10 @@ var Cmd = &cobra.Command{
 			return errors.Wrapf(err, "failed to create rest config")
 		}
 
-		report, err := bugreport.New(cmd.Context(), cfg)
+		report, err := bugreport.New(cmd.Context(), cfg, bugreport.Options{
+			Since:             since,
+			RedactionPatterns: redactionPatterns,
+		})
 		if err != nil {
 			return errors.Wrap(err, "failed to initialize bug reporter")
 		}
@@ -63,6 +78,7 @@ var Cmd = &cobra.Command{
 		report.WriteRawInZip(report.FetchLogSources(cmd.Context()))
 		report.WriteRawInZip(report.FetchResources(cmd.Context()))
 		report.WriteRawInZip(report.FetchCMSystemPods(cmd.Context()))
+		report.WriteProcessedInZip(report.FetchSnapshots(cmd.Context()))
 		report.AddNomosStatusToZip(cmd.Context())
 		report.AddNomosVersionToZip(cmd.Context())
 
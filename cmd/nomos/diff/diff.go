@@ -0,0 +1,481 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	nomosparse "kpt.dev/configsync/cmd/nomos/parse"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/client/restconfig"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/hydrate"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/filesystem"
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/importer/reader"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/parse"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
+	"kpt.dev/configsync/pkg/validate"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+var (
+	clusterName   string
+	syncName      string
+	syncNamespace string
+	serverDryRun  bool
+)
+
+func init() {
+	flags.AddPath(Cmd)
+	flags.AddSourceFormat(Cmd)
+	flags.AddClusterLabels(Cmd)
+	flags.AddClusterValues(Cmd)
+	Cmd.Flags().StringVar(&clusterName, "cluster-name", "",
+		"Name of the cluster to evaluate the cluster selectors with, as set in the reconciler of the RootSync or RepoSync.")
+	Cmd.Flags().StringVar(&syncNamespace, "namespace", configsync.ControllerNamespace,
+		"Namespace of the RepoSync to diff against. Defaults to the namespace of the RootSyncs.")
+	Cmd.Flags().StringVar(&syncName, "name", "",
+		fmt.Sprintf("Name of the RootSync or RepoSync to diff against. Defaults to %q or %q.", configsync.RootSyncName, configsync.RepoSyncName))
+	Cmd.Flags().BoolVar(&serverDryRun, "server-dry-run", true,
+		"If true, computes the field changes with a server-side apply dry-run. "+
+			"Otherwise, compares the declared fields with the live objects, and finds the fields which are no longer declared with the declared-fields annotation.")
+}
+
+// Cmd compares the local repository with the objects on the cluster in the
+// current context.
+var Cmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compares the local repository with the objects synced to the cluster in the current context.",
+	Long: `Compares the local repository with the objects synced to the cluster in the current context.
+
+The repository is parsed and validated the same way as the reconciler of the RootSync or RepoSync does.
+It then prints the objects which would be created, updated or pruned, with the field-level changes
+of the updated objects. The values of the data and stringData of Secrets are redacted. Objects which
+are managed by another RootSync or RepoSync are reported as conflicts, and make the command fail.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Don't show usage on error, as argument validation passed.
+		cmd.SilenceUsage = true
+
+		scope := declared.RootReconciler
+		name := syncName
+		sourceFormat := filesystem.SourceFormat(flags.SourceFormat)
+		if syncNamespace != configsync.ControllerNamespace {
+			scope = declared.Scope(syncNamespace)
+			if name == "" {
+				name = configsync.RepoSyncName
+			}
+			// Namespace repos are always unstructured.
+			sourceFormat = filesystem.SourceFormatUnstructured
+		} else if name == "" {
+			name = configsync.RootSyncName
+		}
+		if sourceFormat == "" {
+			sourceFormat = filesystem.SourceFormatHierarchy
+		}
+
+		objs, converter, err := parseRepo(cmd.Context(), sourceFormat, scope)
+		if err != nil {
+			return err
+		}
+		if err := parse.AddAnnotationsAndLabels(objs, scope, name, parse.SourceContext{}, ""); err != nil {
+			return err
+		}
+
+		cfg, err := restconfig.NewRestConfig(restconfig.DefaultTimeout)
+		if err != nil {
+			return errors.Wrap(err, "failed to create rest config")
+		}
+		mapper, err := apiutil.NewDynamicRESTMapper(cfg)
+		if err != nil {
+			return errors.Wrap(err, "failed to create mapper")
+		}
+		c, err := client.New(cfg, client.Options{Mapper: mapper})
+		if err != nil {
+			return errors.Wrap(err, "failed to create client")
+		}
+
+		inventory, err := applier.InventoryObjects(cmd.Context(), c, name, syncNamespace)
+		if err != nil {
+			return err
+		}
+		d := &liveDiffer{client: c, mapper: mapper, converter: converter, scope: scope, syncName: name, serverDryRun: serverDryRun}
+		diffs, err := d.diff(cmd.Context(), objs, inventory)
+		if err != nil {
+			return err
+		}
+		if conflicts := printDiffs(os.Stdout, diffs); conflicts > 0 {
+			return errors.Errorf("%d object(s) are managed by another RootSync or RepoSync", conflicts)
+		}
+		return nil
+	},
+}
+
+// parseRepo parses and validates the repository in --path the same way as the
+// reconciler of the given scope. It also returns the converter of the objects
+// into typed values with the schemas of the cluster.
+func parseRepo(ctx context.Context, sourceFormat filesystem.SourceFormat, scope declared.Scope) ([]ast.FileObject, *declared.ValueConverter, error) {
+	rootDir, needsHydrate, err := hydrate.SourceDir(sourceFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+	if needsHydrate {
+		// update rootDir to point to the hydrated output for further processing.
+		if rootDir, err = hydrate.ValidateAndRender(rootDir.OSPath()); err != nil {
+			return nil, nil, err
+		}
+		// delete the hydrated output directory in the end.
+		defer func() {
+			_ = os.RemoveAll(rootDir.OSPath())
+		}()
+	}
+
+	files, err := nomosparse.FindFiles(rootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sourceFormat == filesystem.SourceFormatHierarchy {
+		files = filesystem.FilterHierarchyFiles(rootDir, files)
+	}
+	filePaths := reader.FilePaths{
+		RootDir:   rootDir,
+		PolicyDir: cmpath.RelativeOS(rootDir.OSPath()),
+		Files:     files,
+	}
+
+	options, err := hydrate.ValidateOptions(ctx, rootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	options.ClusterName = clusterName
+	options = parse.OptionsForScope(options, scope)
+
+	objs, errs := filesystem.NewParser(&reader.File{}).Parse(filePaths)
+	if !status.HasBlockingErrors(errs) {
+		var vErrs status.MultiError
+		if sourceFormat == filesystem.SourceFormatHierarchy {
+			objs, vErrs = validate.Hierarchical(objs, options)
+		} else {
+			objs, vErrs = validate.Unstructured(objs, options)
+		}
+		errs = status.Append(errs, vErrs)
+	}
+	if status.HasBlockingErrors(errs) {
+		return nil, nil, errs
+	}
+	if errs != nil {
+		util.PrintErrOrDie(errs)
+	}
+	return objs, options.Converter, nil
+}
+
+// objectDiff is the change of a single object on the cluster.
+type objectDiff struct {
+	id core.ID
+	// operation is diff.Create, diff.Update, diff.Delete, diff.Unmanage or
+	// diff.ManagementConflict.
+	operation diff.Operation
+	// changes are the field changes of an updated object.
+	changes []diff.FieldChange
+	// manager is the manager of a conflicting object.
+	manager string
+}
+
+// liveDiffer compares the declared objects with the live objects on a cluster.
+type liveDiffer struct {
+	client       client.Client
+	mapper       meta.RESTMapper
+	converter    *declared.ValueConverter
+	scope        declared.Scope
+	syncName     string
+	serverDryRun bool
+}
+
+// diff returns the changes the RootSync or RepoSync would make to sync objs,
+// given the inventory of the objects it synced previously. The changes are
+// sorted by object.
+func (d *liveDiffer) diff(ctx context.Context, objs []ast.FileObject, inventory []core.ID) ([]objectDiff, error) {
+	var diffs []objectDiff
+	declaredIDs := make(map[core.ID]bool)
+	manager := declared.ResourceManager(d.scope, d.syncName)
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		declaredIDs[id] = true
+		live, err := d.get(ctx, obj.GroupVersionKind().Version, id)
+		if err != nil {
+			return nil, err
+		}
+		od := objectDiff{id: id}
+		var actual client.Object
+		if live != nil {
+			actual = live
+			od.manager = core.GetAnnotation(live, metadata.ResourceManagerKey)
+		}
+		od.operation = diff.Diff{Declared: obj.Unstructured, Actual: actual}.Operation(ctx, d.scope, d.syncName)
+		switch od.operation {
+		case diff.Create, diff.Unmanage, diff.ManagementConflict:
+			diffs = append(diffs, od)
+		case diff.Update:
+			if differ.ManagementEnabled(live) && od.manager != "" && od.manager != manager {
+				// The reconciler may adopt the object, but the other RootSync
+				// or RepoSync fights over it.
+				od.operation = diff.ManagementConflict
+				diffs = append(diffs, od)
+				continue
+			}
+			od.changes, err = d.fieldChanges(ctx, obj.Unstructured, live)
+			if err != nil {
+				return nil, err
+			}
+			if len(od.changes) > 0 {
+				diffs = append(diffs, od)
+			}
+		}
+	}
+
+	for _, id := range inventory {
+		if declaredIDs[id] {
+			continue
+		}
+		mapping, err := d.mapper.RESTMapping(id.GroupKind)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				// The type was removed, along with its objects.
+				continue
+			}
+			return nil, err
+		}
+		live, err := d.get(ctx, mapping.GroupVersionKind.Version, id)
+		if err != nil {
+			return nil, err
+		}
+		if live == nil {
+			continue
+		}
+		op := diff.Diff{Actual: live}.Operation(ctx, d.scope, d.syncName)
+		if op == diff.Delete || op == diff.Unmanage {
+			diffs = append(diffs, objectDiff{id: id, operation: op})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].id.String() < diffs[j].id.String()
+	})
+	return diffs, nil
+}
+
+// get returns the live object with the given ID, or nil if it does not exist.
+func (d *liveDiffer) get(ctx context.Context, version string, id core.ID) (*unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(id.WithVersion(version))
+	if err := d.client.Get(ctx, id.ObjectKey, u); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// The type may be declared in the repo as well.
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get %s", id)
+	}
+	return u, nil
+}
+
+// fieldChanges returns the fields which syncing the declared object changes in
+// the live object.
+func (d *liveDiffer) fieldChanges(ctx context.Context, declaredObj, live *unstructured.Unstructured) ([]diff.FieldChange, error) {
+	if d.serverDryRun {
+		applied := declaredObj.DeepCopy()
+		err := d.client.Patch(ctx, applied, client.Apply, client.FieldOwner(configsync.FieldManager), client.ForceOwnership, client.DryRunAll)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to dry-run applying %s", core.IDOf(declaredObj))
+		}
+		return diff.FieldChanges(comparable(live.Object), comparable(applied.Object)), nil
+	}
+
+	// Only the fields which are declared now, or were declared in the last
+	// sync, are changed by the sync.
+	fields, err := d.declaredFields(declaredObj, live)
+	if err != nil {
+		return nil, err
+	}
+	return diff.FieldChanges(comparable(diff.FilterFields(fields, live.Object)), comparable(declaredObj.Object)), nil
+}
+
+// declaredFields returns the fields of the declared object, and the fields in
+// the declared-fields annotation of the live object, which are the fields
+// declared in its last sync. The elements of lists are identified by their
+// merge keys, as in the declared-fields annotation.
+func (d *liveDiffer) declaredFields(declaredObj, live *unstructured.Unstructured) (*fieldpath.Set, error) {
+	val, err := d.converter.TypedValue(declaredObj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert %s", core.IDOf(declaredObj))
+	}
+	set, err := val.ToFieldSet()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the fields of %s", core.IDOf(declaredObj))
+	}
+	if decls := core.GetAnnotation(live, metadata.DeclaredFieldsKey); decls != "" {
+		lastSet := &fieldpath.Set{}
+		if err := lastSet.FromJSON(strings.NewReader(decls)); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the %s annotation of %s", metadata.DeclaredFieldsKey, core.IDOf(live))
+		}
+		set = set.Union(lastSet)
+	}
+	return set, nil
+}
+
+// comparable returns a copy of the object without the fields which are set by
+// the API server, or which change with every sync.
+func comparable(obj map[string]interface{}) map[string]interface{} {
+	u := &unstructured.Unstructured{Object: obj}
+	u = u.DeepCopy()
+	for _, field := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"} {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+	for _, key := range []string{metadata.DeclaredFieldsKey, metadata.SyncTokenAnnotationKey, metadata.GitContextKey} {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations", key)
+	}
+	if annotations, found, _ := unstructured.NestedMap(u.Object, "metadata", "annotations"); found && len(annotations) == 0 {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+	}
+	return u.Object
+}
+
+// printDiffs prints the object diffs and a summary, and returns the number of
+// conflicts.
+// nolint:errcheck
+func printDiffs(out io.Writer, diffs []objectDiff) int {
+	counts := make(map[diff.Operation]int)
+	for _, d := range diffs {
+		counts[d.operation]++
+		switch d.operation {
+		case diff.ManagementConflict:
+			fmt.Fprintf(out, "%-9s %s: managed by %s\n", "conflict", objectString(d.id), managerString(d.manager))
+		case diff.Delete:
+			fmt.Fprintf(out, "%-9s %s\n", "prune", objectString(d.id))
+		default:
+			fmt.Fprintf(out, "%-9s %s\n", d.operation, objectString(d.id))
+		}
+		for _, c := range d.changes {
+			fmt.Fprintf(out, "    %s: %s -> %s\n", c.Path, fieldValueString(d.id, c.Path, c.Old), fieldValueString(d.id, c.Path, c.New))
+		}
+	}
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "No changes.")
+		return 0
+	}
+	fmt.Fprintf(out, "\n%d to create, %d to update, %d to prune, %d to unmanage, %d conflict(s).\n",
+		counts[diff.Create], counts[diff.Update], counts[diff.Delete], counts[diff.Unmanage], counts[diff.ManagementConflict])
+	return counts[diff.ManagementConflict]
+}
+
+// objectString returns the type, name and namespace of an object, e.g.
+// `deployment.apps/web in namespace bookstore`.
+func objectString(id core.ID) string {
+	kind := strings.ToLower(id.Kind)
+	if id.Group != "" {
+		kind += "." + id.Group
+	}
+	if id.Namespace == "" {
+		return fmt.Sprintf("%s/%s", kind, id.Name)
+	}
+	return fmt.Sprintf("%s/%s in namespace %s", kind, id.Name, id.Namespace)
+}
+
+// managerString returns the RootSync or RepoSync of a manager annotation.
+func managerString(manager string) string {
+	scope, name := declared.ManagerScopeAndName(manager)
+	if scope == declared.RootReconciler {
+		return fmt.Sprintf("RootSync %s/%s", configsync.ControllerNamespace, name)
+	}
+	return fmt.Sprintf("RepoSync %s/%s", scope, name)
+}
+
+// fieldValueString formats the value of the field at path of the object id
+// like valueString. The values of the data and stringData of a Secret are
+// redacted, since the output is meant to be shared, e.g. in pull requests.
+func fieldValueString(id core.ID, path string, value interface{}) string {
+	if value == nil || id.GroupKind != kinds.Secret().GroupKind() {
+		return valueString(value)
+	}
+	field := strings.SplitN(path, ".", 2)[0]
+	if field != "data" && field != "stringData" {
+		return valueString(value)
+	}
+	encoded := field == "data"
+	if values, ok := value.(map[string]interface{}); ok && !strings.Contains(path, ".") {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		redacted := make([]string, len(keys))
+		for i, key := range keys {
+			redacted[i] = fmt.Sprintf("%q:%s", key, redactedString(values[key], encoded))
+		}
+		return "{" + strings.Join(redacted, ",") + "}"
+	}
+	return redactedString(value, encoded)
+}
+
+// redactedString returns the size of a Secret value in place of the value,
+// e.g. `<redacted, 8 bytes>`. The values of data are base64-encoded.
+func redactedString(value interface{}, encoded bool) string {
+	s, ok := value.(string)
+	if !ok {
+		return "<redacted>"
+	}
+	size := len(s)
+	if encoded {
+		if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+			size = len(decoded)
+		}
+	}
+	return fmt.Sprintf("<redacted, %d bytes>", size)
+}
+
+// valueString formats a field value as JSON, or `<none>` for a missing field.
+func valueString(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
@@ -0,0 +1,267 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/parse"
+	syncertest "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func configMap(t *testing.T, name string, data map[string]interface{}, opts ...core.MetaMutator) *unstructured.Unstructured {
+	t.Helper()
+	u := fake.UnstructuredObject(kinds.ConfigMap(), append(opts, core.Name(name), core.Namespace("bookstore"))...)
+	if err := unstructured.SetNestedMap(u.Object, data, "data"); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func configMapID(name string) core.ID {
+	return core.ID{GroupKind: kinds.ConfigMap().GroupKind(), ObjectKey: client.ObjectKey{Namespace: "bookstore", Name: name}}
+}
+
+// liveObject returns the object as synced by the RootSync with the given
+// name, with the given declared fields.
+func liveObject(t *testing.T, u *unstructured.Unstructured, syncName, declaredFields string) *unstructured.Unstructured {
+	t.Helper()
+	objs := []ast.FileObject{{Unstructured: u.DeepCopy()}}
+	if err := parse.AddAnnotationsAndLabels(objs, declared.RootReconciler, syncName, parse.SourceContext{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	live := objs[0].Unstructured
+	if declaredFields != "" {
+		core.SetAnnotation(live, metadata.DeclaredFieldsKey, declaredFields)
+	}
+	return live
+}
+
+// declare marks the objects as managed by the RootSync root-sync.
+func declare(t *testing.T, objs []ast.FileObject) {
+	t.Helper()
+	if err := parse.AddAnnotationsAndLabels(objs, declared.RootReconciler, "root-sync", parse.SourceContext{}, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDiffer(t *testing.T, serverDryRun bool, live ...client.Object) *liveDiffer {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{kinds.ConfigMap().GroupVersion()})
+	mapper.Add(kinds.ConfigMap(), meta.RESTScopeNamespace)
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &liveDiffer{
+		client:       syncertest.NewClient(t, runtime.NewScheme(), live...),
+		mapper:       mapper,
+		converter:    converter,
+		scope:        declared.RootReconciler,
+		syncName:     "root-sync",
+		serverDryRun: serverDryRun,
+	}
+}
+
+func TestDiff(t *testing.T) {
+	created := configMap(t, "created", map[string]interface{}{"a": "1"})
+	updated := configMap(t, "updated", map[string]interface{}{"a": "2"})
+	unchanged := configMap(t, "unchanged", map[string]interface{}{"a": "1"})
+	conflict := configMap(t, "conflict", map[string]interface{}{"a": "1"})
+	pruned := configMap(t, "pruned", map[string]interface{}{"a": "1"})
+
+	liveUpdated := liveObject(t, configMap(t, "updated", map[string]interface{}{"a": "1", "removed": "x", "undeclared": "y"}),
+		"root-sync", `{"f:data":{"f:a":{},"f:removed":{}}}`)
+	d := testDiffer(t, false,
+		liveUpdated,
+		liveObject(t, unchanged, "root-sync", ""),
+		liveObject(t, conflict, "other", ""),
+		liveObject(t, pruned, "root-sync", ""),
+	)
+
+	objs := []ast.FileObject{{Unstructured: created}, {Unstructured: updated}, {Unstructured: unchanged}, {Unstructured: conflict}}
+	declare(t, objs)
+	inventory := []core.ID{configMapID("updated"), configMapID("unchanged"), configMapID("pruned"), configMapID("deleted")}
+
+	got, err := d.diff(context.Background(), objs, inventory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []objectDiff{
+		{id: configMapID("conflict"), operation: diff.ManagementConflict, manager: ":root_other"},
+		{id: configMapID("created"), operation: diff.Create},
+		{id: configMapID("pruned"), operation: diff.Delete},
+		{
+			id:        configMapID("updated"),
+			operation: diff.Update,
+			manager:   ":root",
+			changes: []diff.FieldChange{
+				{Path: "data.a", Old: "1", New: "2"},
+				{Path: "data.removed", Old: "x"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(objectDiff{})); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDiff_ListElements(t *testing.T) {
+	containers := func(containers ...interface{}) *unstructured.Unstructured {
+		u := fake.UnstructuredObject(kinds.Deployment(), core.Name("bookstore"), core.Namespace("bookstore"))
+		if err := unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	// The live containers have defaulted fields, and the sidecar container was
+	// declared in the last sync.
+	live := liveObject(t, containers(
+		map[string]interface{}{"name": "app", "image": "app:v1", "imagePullPolicy": "IfNotPresent", "terminationMessagePath": "/dev/termination-log"},
+		map[string]interface{}{"name": "sidecar", "image": "sidecar:v1", "imagePullPolicy": "IfNotPresent"},
+	), "root-sync", `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}},"k:{\"name\":\"sidecar\"}":{".":{},"f:image":{},"f:name":{}}}}}}}`)
+	d := testDiffer(t, false, live)
+
+	testCases := []struct {
+		name     string
+		declared *unstructured.Unstructured
+		want     []diff.FieldChange
+	}{
+		{
+			name: "defaulted fields are ignored",
+			declared: containers(
+				map[string]interface{}{"name": "app", "image": "app:v1"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"},
+			),
+		},
+		{
+			name: "removed element and changed field",
+			declared: containers(
+				map[string]interface{}{"name": "app", "image": "app:v2"},
+			),
+			want: []diff.FieldChange{{
+				Path: "spec.template.spec.containers",
+				Old: []interface{}{
+					map[string]interface{}{"name": "app", "image": "app:v1"},
+					map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"},
+				},
+				New: []interface{}{
+					map[string]interface{}{"name": "app", "image": "app:v2"},
+				},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := []ast.FileObject{{Unstructured: tc.declared}}
+			declare(t, objs)
+			got, err := d.fieldChanges(context.Background(), objs[0].Unstructured, live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDiff_ServerDryRun(t *testing.T) {
+	updated := configMap(t, "updated", map[string]interface{}{"a": "2"})
+	d := testDiffer(t, true, liveObject(t, configMap(t, "updated", map[string]interface{}{"a": "1"}), "root-sync", ""))
+
+	objs := []ast.FileObject{{Unstructured: updated}}
+	declare(t, objs)
+
+	got, err := d.diff(context.Background(), objs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []objectDiff{{
+		id:        configMapID("updated"),
+		operation: diff.Update,
+		manager:   ":root",
+		changes:   []diff.FieldChange{{Path: "data.a", Old: "1", New: "2"}},
+	}}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(objectDiff{})); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPrintDiffs(t *testing.T) {
+	diffs := []objectDiff{
+		{id: configMapID("conflict"), operation: diff.ManagementConflict, manager: "bookstore_repo-sync"},
+		{id: core.ID{GroupKind: kinds.Namespace().GroupKind(), ObjectKey: client.ObjectKey{Name: "bookstore"}}, operation: diff.Create},
+		{id: configMapID("pruned"), operation: diff.Delete},
+		{
+			id:        configMapID("updated"),
+			operation: diff.Update,
+			changes: []diff.FieldChange{
+				{Path: "data.a", Old: "1", New: "2"},
+				{Path: "data.removed", Old: "x"},
+			},
+		},
+		{
+			id:        core.ID{GroupKind: kinds.Secret().GroupKind(), ObjectKey: client.ObjectKey{Namespace: "bookstore", Name: "creds"}},
+			operation: diff.Update,
+			changes: []diff.FieldChange{
+				{Path: "data", New: map[string]interface{}{"user": "YWRtaW4=", "password": "aHVudGVyMg=="}},
+				{Path: "metadata.labels.team", Old: "a", New: "b"},
+				{Path: "stringData.token", Old: "abc", New: "abcdef"},
+			},
+		},
+	}
+	want := `conflict  configmap/conflict in namespace bookstore: managed by RepoSync bookstore/repo-sync
+create    namespace/bookstore
+prune     configmap/pruned in namespace bookstore
+update    configmap/updated in namespace bookstore
+    data.a: "1" -> "2"
+    data.removed: "x" -> <none>
+update    secret/creds in namespace bookstore
+    data: <none> -> {"password":<redacted, 7 bytes>,"user":<redacted, 5 bytes>}
+    metadata.labels.team: "a" -> "b"
+    stringData.token: <redacted, 3 bytes> -> <redacted, 6 bytes>
+
+1 to create, 2 to update, 1 to prune, 0 to unmanage, 1 conflict(s).
+`
+	var buf bytes.Buffer
+	if conflicts := printDiffs(&buf, diffs); conflicts != 1 {
+		t.Errorf("printDiffs() = %d conflicts, want 1", conflicts)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Error(diff)
+	}
+
+	buf.Reset()
+	printDiffs(&buf, nil)
+	if got := buf.String(); got != "No changes.\n" {
+		t.Errorf("printDiffs() = %q, want %q", got, "No changes.\n")
+	}
+}
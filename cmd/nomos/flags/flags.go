@@ -36,6 +36,12 @@ const (
 	// clusterFlag is the flag name for the Clusters below.
 	clustersFlag = "clusters"
 
+	// clusterLabelsFlag is the flag name for the ClusterLabels below.
+	clusterLabelsFlag = "cluster-labels"
+
+	// clusterValuesFlag is the flag name for the ClusterValuesFile below.
+	clusterValuesFlag = "cluster-values"
+
 	// SkipAPIServerFlag is the flag name for SkipAPIServer below.
 	SkipAPIServerFlag = "no-api-server-check"
 
@@ -60,6 +66,15 @@ var (
 	// Clusters contains the list of Cluster names (specified in clusters/) to perform an action on.
 	Clusters []string
 
+	// ClusterLabels contains the labels of the cluster to perform cluster
+	// selection with, as read by the reconcilers from the live cluster.
+	ClusterLabels string
+
+	// ClusterValuesFile is the path of the local file holding the values of the
+	// ${cluster.values.KEY} variables, as read by the reconcilers from the
+	// cluster-values ConfigMap.
+	ClusterValuesFile string
+
 	// Path says where the Nomos directory is
 	Path string
 
@@ -88,6 +103,18 @@ func AddClusters(cmd *cobra.Command) {
 		`Accepts a comma-separated list of Cluster names to use in multi-cluster commands. Defaults to all clusters. Use "" for no clusters.`)
 }
 
+// AddClusterLabels adds the --cluster-labels flag.
+func AddClusterLabels(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&ClusterLabels, clusterLabelsFlag, "",
+		`Accepts a comma-separated list of key=value labels of the cluster to use for cluster selection, as read by the reconcilers from the live cluster. They override the labels of the Cluster objects in the repository.`)
+}
+
+// AddClusterValues adds the --cluster-values flag.
+func AddClusterValues(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&ClusterValuesFile, clusterValuesFlag, "",
+		`Path to a YAML or JSON file of key: value pairs to substitute the ${cluster.values.KEY} variables with, as the data of the cluster-values ConfigMap on the cluster.`)
+}
+
 // AddPath adds the --path flag.
 func AddPath(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&Path, pathFlag, PathDefault,
@@ -37,6 +37,8 @@ var (
 
 func init() {
 	flags.AddClusters(Cmd)
+	flags.AddClusterLabels(Cmd)
+	flags.AddClusterValues(Cmd)
 	flags.AddPath(Cmd)
 	flags.AddSkipAPIServerCheck(Cmd)
 	flags.AddSourceFormat(Cmd)
@@ -81,7 +83,7 @@ which you could kubectl apply -fR to the cluster, or have Config Sync sync to th
 
 		if needsHydrate {
 			// update rootDir to point to the hydrated output for further processing.
-			if rootDir, err = hydrate.ValidateAndRunKustomize(rootDir.OSPath()); err != nil {
+			if rootDir, err = hydrate.ValidateAndRender(rootDir.OSPath()); err != nil {
 				return err
 			}
 			// delete the hydrated output directory in the end.
@@ -0,0 +1,416 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/status"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/client/restconfig"
+	"kpt.dev/configsync/pkg/helm"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/parse"
+	"kpt.dev/configsync/pkg/reposync"
+	"kpt.dev/configsync/pkg/rootsync"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultWaitTimeout = 10 * time.Minute
+	digestPrefix       = "sha256:"
+)
+
+var (
+	syncName      string
+	syncNamespace string
+	toRevision    string
+	listHistory   bool
+	waitTimeout   time.Duration
+
+	// pollInterval is how often the RootSync or RepoSync is read while waiting
+	// for the rollback to sync. It can be changed in tests.
+	pollInterval = 5 * time.Second
+)
+
+func init() {
+	Cmd.Flags().StringSliceVar(&flags.Contexts, "contexts", nil,
+		`Accepts a comma-separated list of contexts to use in multi-cluster environments. Defaults to the current context. Use "all" for all contexts.`)
+	Cmd.Flags().DurationVar(&flags.ClientTimeout, "connect-timeout", flags.DefaultClusterClientTimeout, "Timeout for connecting to each cluster.")
+	Cmd.Flags().StringVar(&syncNamespace, "namespace", configsync.ControllerNamespace,
+		"Namespace of the RepoSync to roll back. Defaults to the namespace of the RootSyncs.")
+	Cmd.Flags().StringVar(&syncName, "name", "",
+		fmt.Sprintf("Name of the RootSync or RepoSync to roll back. Defaults to %q or %q.", configsync.RootSyncName, configsync.RepoSyncName))
+	Cmd.Flags().StringVar(&toRevision, "to", "",
+		"Git commit, OCI image digest or Helm chart version to roll back to. Defaults to the last commit which synced without errors.")
+	Cmd.Flags().BoolVar(&listHistory, "list", false,
+		"If true, only prints the sync history.")
+	Cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitTimeout,
+		"Timeout for waiting for the rollback to sync. Use 0 to not wait.")
+}
+
+// Cmd pins a RootSync or RepoSync to a previously synced revision.
+var Cmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Pins a RootSync or RepoSync to a previously synced revision.",
+	Long: `Pins a RootSync or RepoSync to a revision from its sync history, and waits for it to sync. ` +
+		`By default, it rolls back to the last commit which synced without errors. ` +
+		`Git sources are pinned with spec.git.revision, OCI sources with the digest of spec.oci.image, and Helm sources with spec.helm.version. ` +
+		`Only the chart version of Helm sources is rolled back, not the values. ` +
+		`To resume syncing the latest changes, restore the previous revision printed by the command.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Don't show usage on error, as argument validation passed.
+		cmd.SilenceUsage = true
+
+		var contexts []string
+		if len(flags.Contexts) == 0 {
+			currentContext, err := restconfig.CurrentContextName()
+			if err != nil {
+				return fmt.Errorf("failed to get current context name with err: %v", errors.Cause(err))
+			}
+			contexts = append(contexts, currentContext)
+		} else if len(flags.Contexts) != 1 || flags.Contexts[0] != "all" {
+			contexts = flags.Contexts
+		}
+
+		clientMap, err := status.ClusterClients(cmd.Context(), contexts)
+		if err != nil {
+			return err
+		}
+		if len(clientMap) == 0 {
+			return errors.New("no clusters found")
+		}
+		var names []string
+		for name := range clientMap {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		key := syncKey(syncNamespace, syncName)
+		var failed int
+		for _, name := range names {
+			fmt.Println()
+			fmt.Println(util.Separator)
+			fmt.Printf("Cluster %q: %s %s\n", name, kindOf(key), key)
+			var err error
+			if listHistory {
+				err = printHistory(cmd.Context(), os.Stdout, clientMap[name].Client, key)
+			} else {
+				err = rollback(cmd.Context(), clientMap[name].Client, key)
+			}
+			if err != nil {
+				fmt.Printf("%s%sError: %s.%s\n", util.Bullet, util.ColorRed, err, util.ColorDefault)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("failed on %d of %d cluster(s)", failed, len(names))
+		}
+		return nil
+	},
+}
+
+// syncKey returns the key of the RootSync or RepoSync, defaulting its name.
+func syncKey(namespace, name string) client.ObjectKey {
+	if name == "" {
+		name = configsync.RepoSyncName
+		if namespace == configsync.ControllerNamespace {
+			name = configsync.RootSyncName
+		}
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}
+}
+
+// kindOf returns the kind of the sync object with the key: RootSyncs live in
+// the config-management-system namespace, and RepoSyncs in other namespaces.
+func kindOf(key client.ObjectKey) string {
+	if key.Namespace == configsync.ControllerNamespace {
+		return kinds.RootSyncV1Beta1().Kind
+	}
+	return kinds.RepoSyncV1Beta1().Kind
+}
+
+func getSync(ctx context.Context, c client.Client, key client.ObjectKey) (client.Object, error) {
+	var obj client.Object = &v1beta1.RepoSync{}
+	if kindOf(key) == kinds.RootSyncV1Beta1().Kind {
+		obj = &v1beta1.RootSync{}
+	}
+	if err := c.Get(ctx, key, obj); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %s", kindOf(key))
+	}
+	return obj, nil
+}
+
+// source returns the source type and the source configurations of a RootSync
+// or RepoSync, which point into the object.
+func source(obj client.Object) (string, *v1beta1.Git, *v1beta1.Oci, *v1beta1.Helm) {
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		return rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm
+	case *v1beta1.RepoSync:
+		return rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm
+	}
+	return "", nil, nil, nil
+}
+
+// syncStatus returns the status of a RootSync or RepoSync.
+func syncStatus(obj client.Object) *v1beta1.Status {
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		return &rs.Status.Status
+	case *v1beta1.RepoSync:
+		return &rs.Status.Status
+	}
+	return &v1beta1.Status{}
+}
+
+// syncing returns true if the Syncing condition of a RootSync or RepoSync is
+// True, and the commit of the condition.
+func syncing(obj client.Object) (bool, string) {
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		if cond := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncSyncing); cond != nil {
+			return cond.Status == metav1.ConditionTrue, cond.Commit
+		}
+	case *v1beta1.RepoSync:
+		if cond := reposync.GetCondition(rs.Status.Conditions, v1beta1.RepoSyncSyncing); cond != nil {
+			return cond.Status == metav1.ConditionTrue, cond.Commit
+		}
+	}
+	return false, ""
+}
+
+// printHistory prints the sync history of a RootSync or RepoSync.
+func printHistory(ctx context.Context, out io.Writer, c client.Client, key client.ObjectKey) error {
+	obj, err := getSync(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	history := syncStatus(obj).History
+	if len(history) == 0 {
+		fmt.Fprintf(out, "%sThe sync history is empty.\n", util.Bullet)
+		return nil
+	}
+	w := util.NewWriter(out)
+	fmt.Fprintf(w, "COMMIT\tOUTCOME\tERRORS\tSTARTED\tCOMPLETED\n")
+	for _, entry := range history {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", entry.Commit, entry.Outcome, entry.ErrorCount,
+			formatTime(entry.StartTime), formatTime(entry.CompletionTime))
+	}
+	return w.Flush()
+}
+
+func formatTime(t metav1.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// rollback pins a RootSync or RepoSync to a revision from its sync history,
+// and waits for it to sync.
+func rollback(ctx context.Context, c client.Client, key client.ObjectKey) error {
+	obj, err := getSync(ctx, c, key)
+	if err != nil {
+		return err
+	}
+	sourceType, _, _, _ := source(obj)
+	entry, err := resolve(sourceType, syncStatus(obj), toRevision)
+	if err != nil {
+		return err
+	}
+	p, err := pin(obj, entry)
+	if err != nil {
+		return err
+	}
+	if err := c.Update(ctx, obj); err != nil {
+		return errors.Wrapf(err, "failed to update the %s", kindOf(key))
+	}
+	fmt.Printf("%sPinned %s to %s (previously %s).\n", util.Bullet, p.field, p.revision, p.previous)
+	fmt.Printf("%s%sTo resume syncing the latest changes, set %s back to %s.%s\n", util.Bullet, util.ColorCyan, p.field, p.previous, util.ColorDefault)
+	if waitTimeout <= 0 {
+		return nil
+	}
+
+	fmt.Printf("%sWaiting for %s to sync ...\n", util.Bullet, p.revision)
+	var syncErr error
+	err = wait.PollImmediate(pollInterval, waitTimeout, func() (bool, error) {
+		obj, err := getSync(ctx, c, key)
+		if err != nil {
+			return false, err
+		}
+		var done bool
+		done, syncErr = rolledBack(obj, p.matches)
+		return done, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out after %s waiting for %s to sync, check the progress with `nomos status`", waitTimeout, p.revision)
+	}
+	if err != nil {
+		return err
+	}
+	if syncErr != nil {
+		return syncErr
+	}
+	fmt.Printf("%s%sRolled back to %s.%s\n", util.Bullet, util.ColorGreen, p.revision, util.ColorDefault)
+	return nil
+}
+
+// rolledBack returns true once the sync of the pinned revision completes, and
+// an error if the revision fails to be parsed or synced.
+func rolledBack(obj client.Object, matches func(commit string) bool) (bool, error) {
+	st := syncStatus(obj)
+	if matches(st.Source.Commit) && st.Source.ErrorSummary != nil && st.Source.ErrorSummary.TotalCount > 0 {
+		return true, errors.Errorf("commit %s failed to be parsed with %d error(s), see .status.source.errors", st.Source.Commit, st.Source.ErrorSummary.TotalCount)
+	}
+	if !matches(st.Sync.Commit) {
+		return false, nil
+	}
+	if inProgress, commit := syncing(obj); inProgress || !matches(commit) {
+		return false, nil
+	}
+	if st.Sync.ErrorSummary != nil && st.Sync.ErrorSummary.TotalCount > 0 {
+		return true, errors.Errorf("commit %s synced with %d error(s), see .status.sync.errors", st.Sync.Commit, st.Sync.ErrorSummary.TotalCount)
+	}
+	return true, nil
+}
+
+// resolve returns the entry of the sync history to roll back to: the entry of
+// the revision `to`, or the last entry which synced without errors, other than
+// the current commit, if `to` is empty. A revision which is not in the sync
+// history is rolled back to as is.
+func resolve(sourceType string, st *v1beta1.Status, to string) (v1beta1.SyncHistoryEntry, error) {
+	if to == "" {
+		entry := parse.LastGoodSync(st.History, st.Sync.Commit)
+		if entry == nil {
+			return v1beta1.SyncHistoryEntry{}, errors.New("no commit other than the current one synced without errors in the sync history, use --to to choose a revision")
+		}
+		return *entry, nil
+	}
+	for _, entry := range st.History {
+		if historyMatches(sourceType, entry, to) {
+			return entry, nil
+		}
+	}
+	return v1beta1.SyncHistoryEntry{Commit: to}, nil
+}
+
+// historyMatches returns true if the entry of the sync history is the revision
+// to: a prefix of a git commit, an OCI image digest, or a Helm chart version.
+func historyMatches(sourceType string, entry v1beta1.SyncHistoryEntry, to string) bool {
+	switch v1beta1.SourceType(sourceType) {
+	case v1beta1.OciSource:
+		return entry.Commit == strings.TrimPrefix(to, digestPrefix)
+	case v1beta1.HelmSource:
+		return entry.Commit == to || helm.VersionFromCommit(entry.Commit) == to || (entry.Helm != nil && entry.Helm.Version == to)
+	default:
+		return strings.HasPrefix(entry.Commit, to)
+	}
+}
+
+// pinned describes the revision which a RootSync or RepoSync is pinned to.
+type pinned struct {
+	// field is the pinned field of the spec.
+	field string
+	// revision is the pinned value.
+	revision string
+	// previous is the value of the field before the rollback.
+	previous string
+	// matches returns true if the commit in the status is the pinned revision.
+	matches func(commit string) bool
+}
+
+// pin sets the revision of the source of the RootSync or RepoSync to the entry
+// of the sync history.
+func pin(obj client.Object, entry v1beta1.SyncHistoryEntry) (pinned, error) {
+	sourceType, git, oci, helmSpec := source(obj)
+	switch v1beta1.SourceType(sourceType) {
+	case v1beta1.OciSource:
+		if oci == nil {
+			return pinned{}, errors.New("spec.oci is not set")
+		}
+		ref, err := name.ParseReference(oci.Image)
+		if err != nil {
+			return pinned{}, errors.Wrapf(err, "invalid spec.oci.image %q", oci.Image)
+		}
+		digest := digestPrefix + strings.TrimPrefix(entry.Commit, digestPrefix)
+		p := pinned{
+			field:    "spec.oci.image",
+			revision: ref.Context().Name() + "@" + digest,
+			previous: oci.Image,
+			matches: func(commit string) bool {
+				return commit != "" && commit == strings.TrimPrefix(digest, digestPrefix)
+			},
+		}
+		oci.Image = p.revision
+		return p, nil
+	case v1beta1.HelmSource:
+		if helmSpec == nil {
+			return pinned{}, errors.New("spec.helm is not set")
+		}
+		version := helm.VersionFromCommit(entry.Commit)
+		if entry.Helm != nil && entry.Helm.Version != "" {
+			version = entry.Helm.Version
+		} else if version == "" {
+			version = entry.Commit
+		}
+		p := pinned{
+			field:    "spec.helm.version",
+			revision: version,
+			previous: valueOrDefault(helmSpec.Version, "latest"),
+			matches: func(commit string) bool {
+				return helm.VersionFromCommit(commit) == version
+			},
+		}
+		helmSpec.Version = version
+		return p, nil
+	default:
+		if git == nil {
+			return pinned{}, errors.New("spec.git is not set")
+		}
+		p := pinned{
+			field:    "spec.git.revision",
+			revision: entry.Commit,
+			previous: valueOrDefault(git.Revision, "HEAD"),
+			matches: func(commit string) bool {
+				return commit != "" && strings.HasPrefix(commit, entry.Commit)
+			},
+		}
+		git.Revision = entry.Commit
+		return p, nil
+	}
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,279 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollback
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	syncertest "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var history = []v1beta1.SyncHistoryEntry{
+	{Commit: "ccc333", Outcome: v1beta1.SyncFailed, ErrorCount: 2},
+	{Commit: "bbb222", Outcome: v1beta1.SyncSucceeded},
+	{Commit: "aaa111", Outcome: v1beta1.SyncSucceeded},
+}
+
+func TestResolve(t *testing.T) {
+	helmHistory := []v1beta1.SyncHistoryEntry{
+		{Commit: "my-chart:1.1.0", Outcome: v1beta1.SyncFailed, Helm: &v1beta1.HelmStatus{Chart: "my-chart", Version: "1.1.0"}},
+		{Commit: "my-chart:1.0.0:0a1b2c3d", Outcome: v1beta1.SyncSucceeded, Helm: &v1beta1.HelmStatus{Chart: "my-chart", Version: "1.0.0"}},
+	}
+	testCases := []struct {
+		name       string
+		sourceType v1beta1.SourceType
+		history    []v1beta1.SyncHistoryEntry
+		current    string
+		to         string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "last good commit",
+			sourceType: v1beta1.GitSource,
+			history:    history,
+			current:    "ccc333",
+			want:       "bbb222",
+		},
+		{
+			name:       "skip the current commit",
+			sourceType: v1beta1.GitSource,
+			history:    history,
+			current:    "bbb222",
+			want:       "aaa111",
+		},
+		{
+			name:       "no good commit",
+			sourceType: v1beta1.GitSource,
+			history:    history[:1],
+			current:    "ccc333",
+			wantErr:    true,
+		},
+		{
+			name:       "git commit prefix",
+			sourceType: v1beta1.GitSource,
+			history:    history,
+			to:         "aaa",
+			want:       "aaa111",
+		},
+		{
+			name:       "git commit missing from the history",
+			sourceType: v1beta1.GitSource,
+			history:    history,
+			to:         "ddd444",
+			want:       "ddd444",
+		},
+		{
+			name:       "oci digest",
+			sourceType: v1beta1.OciSource,
+			history:    history,
+			to:         "sha256:aaa111",
+			want:       "aaa111",
+		},
+		{
+			name:       "helm version",
+			sourceType: v1beta1.HelmSource,
+			history:    helmHistory,
+			to:         "1.0.0",
+			want:       "my-chart:1.0.0:0a1b2c3d",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := &v1beta1.Status{Sync: v1beta1.SyncStatus{Commit: tc.current}, History: tc.history}
+			got, err := resolve(string(tc.sourceType), st, tc.to)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("resolve() = %s, want an error", got.Commit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() got unexpected error %v", err)
+			}
+			if got.Commit != tc.want {
+				t.Errorf("resolve() = %s, want %s", got.Commit, tc.want)
+			}
+		})
+	}
+}
+
+func TestPin(t *testing.T) {
+	testCases := []struct {
+		name         string
+		spec         v1beta1.RootSyncSpec
+		entry        v1beta1.SyncHistoryEntry
+		wantRevision string
+		wantPrevious string
+		wantSpec     v1beta1.RootSyncSpec
+		synced       string
+	}{
+		{
+			name:         "git",
+			spec:         v1beta1.RootSyncSpec{Git: &v1beta1.Git{Branch: "main"}},
+			entry:        v1beta1.SyncHistoryEntry{Commit: "aaa111"},
+			wantRevision: "aaa111",
+			wantPrevious: "HEAD",
+			wantSpec:     v1beta1.RootSyncSpec{Git: &v1beta1.Git{Branch: "main", Revision: "aaa111"}},
+			synced:       "aaa111",
+		},
+		{
+			name:         "oci",
+			spec:         v1beta1.RootSyncSpec{SourceType: string(v1beta1.OciSource), Oci: &v1beta1.Oci{Image: "us-docker.pkg.dev/my-project/configs/app:latest"}},
+			entry:        v1beta1.SyncHistoryEntry{Commit: "aaa111"},
+			wantRevision: "us-docker.pkg.dev/my-project/configs/app@sha256:aaa111",
+			wantPrevious: "us-docker.pkg.dev/my-project/configs/app:latest",
+			wantSpec:     v1beta1.RootSyncSpec{SourceType: string(v1beta1.OciSource), Oci: &v1beta1.Oci{Image: "us-docker.pkg.dev/my-project/configs/app@sha256:aaa111"}},
+			synced:       "aaa111",
+		},
+		{
+			name:         "helm",
+			spec:         v1beta1.RootSyncSpec{SourceType: string(v1beta1.HelmSource), Helm: &v1beta1.Helm{Chart: "my-chart", Version: "^1.0.0"}},
+			entry:        v1beta1.SyncHistoryEntry{Commit: "my-chart:1.0.0", Helm: &v1beta1.HelmStatus{Chart: "my-chart", Version: "1.0.0"}},
+			wantRevision: "1.0.0",
+			wantPrevious: "^1.0.0",
+			wantSpec:     v1beta1.RootSyncSpec{SourceType: string(v1beta1.HelmSource), Helm: &v1beta1.Helm{Chart: "my-chart", Version: "1.0.0"}},
+			synced:       "my-chart:1.0.0:0a1b2c3d",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := fake.RootSyncObjectV1Beta1(configsync.RootSyncName)
+			rs.Spec = tc.spec
+			p, err := pin(rs, tc.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.revision != tc.wantRevision {
+				t.Errorf("pin() got revision %q, want %q", p.revision, tc.wantRevision)
+			}
+			if p.previous != tc.wantPrevious {
+				t.Errorf("pin() got previous revision %q, want %q", p.previous, tc.wantPrevious)
+			}
+			if _, _, _, helm := source(rs); helm != nil && helm.Version != tc.wantSpec.Helm.Version {
+				t.Errorf("pin() got helm version %q, want %q", helm.Version, tc.wantSpec.Helm.Version)
+			}
+			if tc.wantSpec.Git != nil && rs.Spec.Git.Revision != tc.wantSpec.Git.Revision {
+				t.Errorf("pin() got git revision %q, want %q", rs.Spec.Git.Revision, tc.wantSpec.Git.Revision)
+			}
+			if tc.wantSpec.Oci != nil && rs.Spec.Oci.Image != tc.wantSpec.Oci.Image {
+				t.Errorf("pin() got oci image %q, want %q", rs.Spec.Oci.Image, tc.wantSpec.Oci.Image)
+			}
+			if !p.matches(tc.synced) {
+				t.Errorf("matches(%q) = false, want true", tc.synced)
+			}
+			if p.matches("bbb222") {
+				t.Error(`matches("bbb222") = true, want false`)
+			}
+		})
+	}
+}
+
+func TestRolledBack(t *testing.T) {
+	matches := func(commit string) bool { return commit == "aaa111" }
+	testCases := []struct {
+		name       string
+		source     v1beta1.SourceStatus
+		sync       v1beta1.SyncStatus
+		conditions []v1beta1.RootSyncCondition
+		wantDone   bool
+		wantErr    bool
+	}{
+		{
+			name: "not synced yet",
+			sync: v1beta1.SyncStatus{Commit: "ccc333"},
+		},
+		{
+			name: "syncing",
+			sync: v1beta1.SyncStatus{Commit: "aaa111"},
+			conditions: []v1beta1.RootSyncCondition{
+				{Type: v1beta1.RootSyncSyncing, Status: metav1.ConditionTrue, Commit: "aaa111"},
+			},
+		},
+		{
+			name: "synced",
+			sync: v1beta1.SyncStatus{Commit: "aaa111", ErrorSummary: &v1beta1.ErrorSummary{}},
+			conditions: []v1beta1.RootSyncCondition{
+				{Type: v1beta1.RootSyncSyncing, Status: metav1.ConditionFalse, Commit: "aaa111"},
+			},
+			wantDone: true,
+		},
+		{
+			name: "synced with errors",
+			sync: v1beta1.SyncStatus{Commit: "aaa111", ErrorSummary: &v1beta1.ErrorSummary{TotalCount: 1}},
+			conditions: []v1beta1.RootSyncCondition{
+				{Type: v1beta1.RootSyncSyncing, Status: metav1.ConditionFalse, Commit: "aaa111"},
+			},
+			wantDone: true,
+			wantErr:  true,
+		},
+		{
+			name:     "parse errors",
+			source:   v1beta1.SourceStatus{Commit: "aaa111", ErrorSummary: &v1beta1.ErrorSummary{TotalCount: 1}},
+			sync:     v1beta1.SyncStatus{Commit: "ccc333"},
+			wantDone: true,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := fake.RootSyncObjectV1Beta1(configsync.RootSyncName)
+			rs.Status.Source = tc.source
+			rs.Status.Sync = tc.sync
+			rs.Status.Conditions = tc.conditions
+			done, err := rolledBack(rs, matches)
+			if done != tc.wantDone {
+				t.Errorf("rolledBack() = %t, want %t", done, tc.wantDone)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("rolledBack() got error %v, want error: %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	waitTimeout = 0
+	toRevision = ""
+	rs := fake.RepoSyncObjectV1Beta1("bookstore", configsync.RepoSyncName)
+	rs.Spec.Git = &v1beta1.Git{Branch: "main"}
+	rs.Status.Sync.Commit = "ccc333"
+	rs.Status.History = history
+	s := runtime.NewScheme()
+	if err := v1beta1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	c := syncertest.NewClient(t, s, rs)
+
+	ctx := context.Background()
+	key := syncKey("bookstore", "")
+	if err := rollback(ctx, c, key); err != nil {
+		t.Fatal(err)
+	}
+	got := &v1beta1.RepoSync{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "bookstore", Name: configsync.RepoSyncName}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Git.Revision != "bbb222" {
+		t.Errorf("got spec.git.revision %q, want %q", got.Spec.Git.Revision, "bbb222")
+	}
+}
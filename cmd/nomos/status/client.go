@@ -135,6 +135,12 @@ func (c *ClusterClient) resourceGroups(ctx context.Context, ns string, nsAndName
 		localRG := rg
 		resourceGroups = append(resourceGroups, &localRG)
 	}
+	// The resources of a large repo are spread across the ResourceGroup and
+	// its shards.
+	resourceGroups, err := mergeInventoryShards(resourceGroups)
+	if err != nil {
+		return nil, err
+	}
 	return consistentOrder(nsAndNames, resourceGroups), nil
 }
 
@@ -259,7 +265,9 @@ func (c *ClusterClient) multiRepoClusterStatus(ctx context.Context, cs *ClusterS
 		var repos []*RepoState
 		for i, rs := range rootSyncs {
 			rg := rootRGs[i]
-			repos = append(repos, RootRepoStatus(rs, rg, syncingConditionSupported))
+			repo := RootRepoStatus(rs, rg, syncingConditionSupported)
+			repo.syncStatus = &rs.Status.Status
+			repos = append(repos, repo)
 		}
 		sort.Slice(repos, func(i, j int) bool {
 			return repos[i].scope < repos[j].scope || (repos[i].scope == repos[j].scope && repos[i].syncName < repos[j].syncName)
@@ -283,7 +291,9 @@ func (c *ClusterClient) multiRepoClusterStatus(ctx context.Context, cs *ClusterS
 		var repos []*RepoState
 		for i, rs := range repoSyncs {
 			rg := namespaceRGs[i]
-			repos = append(repos, namespaceRepoStatus(rs, rg, syncingConditionSupported))
+			repo := namespaceRepoStatus(rs, rg, syncingConditionSupported)
+			repo.syncStatus = &rs.Status.Status
+			repos = append(repos, repo)
 		}
 		sort.Slice(repos, func(i, j int) bool {
 			return repos[i].scope < repos[j].scope || (repos[i].scope == repos[j].scope && repos[i].syncName < repos[j].syncName)
@@ -321,7 +331,9 @@ func (c *ClusterClient) namespaceRepoClusterStatus(ctx context.Context, cs *Clus
 		var repos []*RepoState
 		for i, rs := range syncs {
 			rg := rgs[i]
-			repos = append(repos, namespaceRepoStatus(rs, rg, syncingConditionSupported))
+			repo := namespaceRepoStatus(rs, rg, syncingConditionSupported)
+			repo.syncStatus = &rs.Status.Status
+			repos = append(repos, repo)
 		}
 		sort.Slice(repos, func(i, j int) bool {
 			return repos[i].scope < repos[j].scope || (repos[i].scope == repos[j].scope && repos[i].syncName < repos[j].syncName)
@@ -432,14 +444,15 @@ func (c *ClusterClient) IsConfigured(ctx context.Context, cs *ClusterState) bool
 }
 
 // ClusterClients returns a map of of typed clients keyed by the name of the kubeconfig context they
-// are initialized from.
+// are initialized from. The clusters which cannot be reached are mapped to nil. Problems connecting
+// to the clusters are printed to stderr, so that they do not mix with the status on stdout.
 func ClusterClients(ctx context.Context, contexts []string) (map[string]*ClusterClient, error) {
 	configs, err := restconfig.AllKubectlConfigs(flags.ClientTimeout)
 	if configs == nil {
 		return nil, errors.Wrap(err, "failed to create client configs")
 	}
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 	}
 	configs = filterConfigs(contexts, configs)
 
@@ -460,33 +473,35 @@ func ClusterClients(ctx context.Context, contexts []string) (map[string]*Cluster
 	}
 
 	for name, cfg := range configs {
-		mapper, err := apiutil.NewDynamicRESTMapper(cfg)
+		// Discover the REST mappings lazily, so that an unreachable cluster
+		// is reported as such by the reachability check below.
+		mapper, err := apiutil.NewDynamicRESTMapper(cfg, apiutil.WithLazyDiscovery)
 		if err != nil {
-			fmt.Printf("Failed to create mapper for %q: %v\n", name, err)
+			fmt.Fprintf(os.Stderr, "Failed to create mapper for %q: %v\n", name, err)
 			continue
 		}
 
 		cl, err := client.New(cfg, client.Options{Scheme: s, Mapper: mapper})
 		if err != nil {
-			fmt.Printf("Failed to generate runtime client for %q: %v\n", name, err)
+			fmt.Fprintf(os.Stderr, "Failed to generate runtime client for %q: %v\n", name, err)
 			continue
 		}
 
 		policyHierarchyClientSet, err := apis.NewForConfig(cfg)
 		if err != nil {
-			fmt.Printf("Failed to generate Repo client for %q: %v\n", name, err)
+			fmt.Fprintf(os.Stderr, "Failed to generate Repo client for %q: %v\n", name, err)
 			continue
 		}
 
 		k8sClientset, err := kubernetes.NewForConfig(cfg)
 		if err != nil {
-			fmt.Printf("Failed to generate Kubernetes client for %q: %v\n", name, err)
+			fmt.Fprintf(os.Stderr, "Failed to generate Kubernetes client for %q: %v\n", name, err)
 			continue
 		}
 
 		cmClient, err := util.NewConfigManagementClient(cfg)
 		if err != nil {
-			fmt.Printf("Failed to generate ConfigManagement client for %q: %v\n", name, err)
+			fmt.Fprintf(os.Stderr, "Failed to generate ConfigManagement client for %q: %v\n", name, err)
 			continue
 		}
 
@@ -520,7 +535,7 @@ func ClusterClients(ctx context.Context, contexts []string) (map[string]*Cluster
 		// We can't stop the underlying libraries from spamming to klog when a cluster is unreachable,
 		// so just flush it out and print a blank line to at least make a clean separation.
 		klog.Flush()
-		fmt.Println()
+		fmt.Fprintln(os.Stderr)
 	}
 	return clientMap, nil
 }
@@ -556,9 +571,9 @@ func isReachable(ctx context.Context, clientset *apis.Clientset, cluster string)
 		return true
 	}
 	if nErr, ok := err.(net.Error); ok && nErr.Timeout() {
-		fmt.Printf("%q is an invalid cluster\n", cluster)
+		fmt.Fprintf(os.Stderr, "%q is an invalid cluster\n", cluster)
 	} else {
-		fmt.Printf("Failed to connect to cluster %q: %v\n", cluster, err)
+		fmt.Fprintf(os.Stderr, "Failed to connect to cluster %q: %v\n", cluster, err)
 	}
 	return false
 }
@@ -0,0 +1,106 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kpt.dev/configsync/cmd/nomos/flags"
+)
+
+const unreachableKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: unreachable
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: unreachable
+  context:
+    cluster: unreachable
+current-context: unreachable
+`
+
+// captureStdout returns what f writes to stdout.
+func captureStdout(t *testing.T, f func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- b
+	}()
+	f()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return <-out
+}
+
+func TestClusterClients_UnreachableJSON(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(kubeconfig, []byte(unreachableKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+	// The reachability check is skipped on a cluster.
+	if host, found := os.LookupEnv("KUBERNETES_SERVICE_HOST"); found {
+		os.Unsetenv("KUBERNETES_SERVICE_HOST")
+		t.Cleanup(func() { os.Setenv("KUBERNETES_SERVICE_HOST", host) })
+	}
+	oldFormat := format
+	format = flags.OutputJSON
+	t.Cleanup(func() { format = oldFormat })
+
+	ctx := context.Background()
+	var report *Report
+	out := captureStdout(t, func() {
+		clientMap, err := ClusterClients(ctx, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		report, err = printReport(ctx, os.Stdout, clientMap, clusterNames(clientMap))
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if report == nil {
+		t.Fatal("got no report")
+	}
+
+	got := &Report{}
+	if err := json.Unmarshal(out, got); err != nil {
+		t.Fatalf("failed to parse the JSON output %q: %v", out, err)
+	}
+	if len(got.Clusters) != 1 || got.Clusters[0].Name != "unreachable" || got.Clusters[0].Reachable {
+		t.Errorf("got clusters %+v, want the unreachable cluster", got.Clusters)
+	}
+	if got.ExitCode != ExitUnreachable {
+		t.Errorf("got exit code %d, want %d", got.ExitCode, ExitUnreachable)
+	}
+}
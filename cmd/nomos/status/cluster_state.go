@@ -26,6 +26,7 @@ import (
 	"kpt.dev/configsync/cmd/nomos/util"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/parse"
 	"kpt.dev/configsync/pkg/reposync"
 	"kpt.dev/configsync/pkg/rootsync"
 )
@@ -33,6 +34,7 @@ import (
 const (
 	commitHashLength = 8
 	emptyCommit      = "N/A"
+	unreachableMsg   = "Failed to connect to cluster"
 )
 
 // ClusterState represents the sync status of all repos on a cluster.
@@ -65,7 +67,7 @@ func unavailableCluster(ref string) *ClusterState {
 	return &ClusterState{
 		Ref:    ref,
 		status: "N/A",
-		Error:  "Failed to connect to cluster",
+		Error:  unreachableMsg,
 	}
 }
 
@@ -82,11 +84,26 @@ type RepoState struct {
 	// errorSummary summarizes the `errors` field.
 	errorSummary *v1beta1.ErrorSummary
 	resources    []resourceState
+	// drift lists the resources which have drifted from their declarations,
+	// when the remediator runs in audit-only mode.
+	drift *v1beta1.DriftStatus
+	// namespaceSelectors lists the Namespaces selected by each NamespaceSelector
+	// in dynamic mode.
+	namespaceSelectors []v1beta1.NamespaceSelectorStatus
+	// message details the status, such as when a commit waits for the sync
+	// windows to open.
+	message string
+	// syncStatus is the status of the RootSync or RepoSync, which reports the
+	// source, rendering and sync stages in the machine-readable output.
+	syncStatus *v1beta1.Status
 }
 
 func (r *RepoState) printRows(writer io.Writer) {
 	fmt.Fprintf(writer, "%s%s:%s\t%s\t\n", util.Indent, r.scope, r.syncName, sourceString(r.sourceType, r.git, r.oci))
 	fmt.Fprintf(writer, "%s%s\t%s\t\n", util.Indent, r.status, r.commit)
+	if r.message != "" {
+		fmt.Fprintf(writer, "%s%s\n", util.Indent, r.message)
+	}
 
 	if r.errorSummary != nil && r.errorSummary.TotalCount > 0 {
 		if r.errorSummary.Truncated {
@@ -101,6 +118,28 @@ func (r *RepoState) printRows(writer io.Writer) {
 		fmt.Fprintf(writer, "%sError:\t%s\t\n", util.Indent, err)
 	}
 
+	if r.drift != nil && r.drift.Count > 0 {
+		if r.drift.Truncated {
+			fmt.Fprintf(writer, "%sDriftedResourceCount: %d, DriftTruncated: %v\n", util.Indent, r.drift.Count, r.drift.Truncated)
+		} else {
+			fmt.Fprintf(writer, "%sDriftedResourceCount: %d\n", util.Indent, r.drift.Count)
+		}
+		fmt.Fprintf(writer, "%sDrifted resources:\n", util.Indent)
+		fmt.Fprintf(writer, "%s\tNAMESPACE\tNAME\tDRIFT\tFIELDS\tACTOR\n", util.Indent)
+		for _, d := range r.drift.Resources {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", util.Indent, d.Resource.Namespace, driftedResourceString(d.Resource),
+				d.Type, strings.Join(d.Fields, ","), d.Actor)
+		}
+	}
+
+	if len(r.namespaceSelectors) > 0 {
+		fmt.Fprintf(writer, "%sNamespaceSelectors:\n", util.Indent)
+		fmt.Fprintf(writer, "%s\tNAME\tNAMESPACES\n", util.Indent)
+		for _, nss := range r.namespaceSelectors {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", util.Indent, nss.Name, strings.Join(nss.Namespaces, ","))
+		}
+	}
+
 	if resourceStatus && len(r.resources) > 0 {
 		sort.Sort(byNamespaceAndType(r.resources))
 		fmt.Fprintf(writer, "%sManaged resources:\n", util.Indent)
@@ -125,6 +164,12 @@ func (r *RepoState) printRows(writer io.Writer) {
 	}
 }
 
+// driftedResourceString returns the type and name of a drifted resource, in the
+// same format as the managed resources.
+func driftedResourceString(ref v1beta1.ResourceRef) string {
+	return resourceState{Group: ref.GVK.Group, Kind: ref.GVK.Kind, Name: ref.Name}.String()
+}
+
 func sourceString(sourceType v1beta1.SourceType, git *v1beta1.Git, oci *v1beta1.Oci) string {
 	if sourceType == v1beta1.OciSource {
 		return ociString(oci)
@@ -284,6 +329,7 @@ func namespaceRepoStatus(rs *v1beta1.RepoSync, rg *unstructured.Unstructured, sy
 		git:        rs.Spec.Git,
 		oci:        rs.Spec.Oci,
 		commit:     emptyCommit,
+		drift:      rs.Status.Drift,
 	}
 
 	stalledCondition := reposync.GetCondition(rs.Status.Conditions, v1beta1.RepoSyncStalled)
@@ -342,6 +388,16 @@ func namespaceRepoStatus(rs *v1beta1.RepoSync, rg *unstructured.Unstructured, sy
 				}
 			}
 		}
+	case syncingCondition.Reason == parse.SyncWindowReason:
+		// The commit waits for the sync windows to open.
+		repostate.status = waitingMsg
+		repostate.commit = syncingCondition.Commit
+		repostate.message = syncingCondition.Message
+	case syncingCondition.Reason == parse.SuspendedReason:
+		// The commit is not applied until the reconciler resumes.
+		repostate.status = suspendedMsg
+		repostate.commit = syncingCondition.Commit
+		repostate.message = syncingCondition.Message
 	case reposync.ConditionHasNoErrors(*syncingCondition):
 		// The sync step finished without any errors.
 		repostate.status = syncedMsg
@@ -379,6 +435,9 @@ func RootRepoStatus(rs *v1beta1.RootSync, rg *unstructured.Unstructured, syncing
 		git:        rs.Spec.Git,
 		oci:        rs.Spec.Oci,
 		commit:     emptyCommit,
+		drift:      rs.Status.Drift,
+
+		namespaceSelectors: rs.Status.Sync.NamespaceSelectors,
 	}
 	stalledCondition := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncStalled)
 	reconcilingCondition := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncReconciling)
@@ -436,6 +495,16 @@ func RootRepoStatus(rs *v1beta1.RootSync, rg *unstructured.Unstructured, syncing
 				}
 			}
 		}
+	case syncingCondition.Reason == parse.SyncWindowReason:
+		// The commit waits for the sync windows to open.
+		repostate.status = waitingMsg
+		repostate.commit = syncingCondition.Commit
+		repostate.message = syncingCondition.Message
+	case syncingCondition.Reason == parse.SuspendedReason:
+		// The commit is not applied until the reconciler resumes.
+		repostate.status = suspendedMsg
+		repostate.commit = syncingCondition.Commit
+		repostate.message = syncingCondition.Message
 	case rootsync.ConditionHasNoErrors(*syncingCondition):
 		// The sync step finished without any errors.
 		repostate.status = syncedMsg
@@ -26,6 +26,7 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/parse"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -278,6 +279,59 @@ func TestRepoState_PrintRows(t *testing.T) {
 			},
 			"  bookstore:repo-sync\tus-docker.pkg.dev/test-project/test-ar-repo/sample/test\t\n  ERROR\tabc123\t\n  TotalErrorCount: 2\n  Error:\terror1\t\n  Error:\terror2\t\n",
 		},
+		{
+			"drifted resources in audit-only mode",
+			&RepoState{
+				scope:    "<root>",
+				syncName: "root-sync",
+				git: &v1beta1.Git{
+					Repo: "https://github.com/tester/sample/",
+				},
+				status: "SYNCED",
+				commit: "abc123",
+				drift: &v1beta1.DriftStatus{
+					Count: 2,
+					Resources: []v1beta1.DriftedResource{
+						{
+							Type: v1beta1.DriftModified,
+							Resource: v1beta1.ResourceRef{
+								Name:      "test",
+								Namespace: "bookstore",
+								GVK:       metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+							},
+							Fields: []string{"spec.replicas", "spec.template.spec.containers"},
+							Actor:  "kubectl-edit",
+						},
+						{
+							Type: v1beta1.DriftDeleted,
+							Resource: v1beta1.ResourceRef{
+								Name:      "test",
+								Namespace: "bookstore",
+								GVK:       metav1.GroupVersionKind{Version: "v1", Kind: "Service"},
+							},
+						},
+					},
+				},
+			},
+			"  <root>:root-sync\thttps://github.com/tester/sample@master\t\n  SYNCED\tabc123\t\n  DriftedResourceCount: 2\n  Drifted resources:\n  \tNAMESPACE\tNAME\tDRIFT\tFIELDS\tACTOR\n  \tbookstore\tdeployment.apps/test\tModified\tspec.replicas,spec.template.spec.containers\tkubectl-edit\n  \tbookstore\tservice/test\tDeleted\t\t\n",
+		},
+		{
+			"namespaces selected by dynamic NamespaceSelectors",
+			&RepoState{
+				scope:    "<root>",
+				syncName: "root-sync",
+				git: &v1beta1.Git{
+					Repo: "https://github.com/tester/sample/",
+				},
+				status: "SYNCED",
+				commit: "abc123",
+				namespaceSelectors: []v1beta1.NamespaceSelectorStatus{
+					{Name: "dev-only", Namespaces: []string{"dev1", "dev2"}},
+					{Name: "staging-only"},
+				},
+			},
+			"  <root>:root-sync\thttps://github.com/tester/sample@master\t\n  SYNCED\tabc123\t\n  NamespaceSelectors:\n  \tNAME\tNAMESPACES\n  \tdev-only\tdev1,dev2\n  \tstaging-only\t\n",
+		},
 		{
 			"Git field is missing when sourceType is git",
 			&RepoState{
@@ -2681,6 +2735,68 @@ func TestRepoState_RootRepoStatus(t *testing.T) {
 				commit:   "abc123",
 			},
 		},
+		{
+			name:                      "commit is waiting for the sync windows",
+			gitSpec:                   git,
+			syncingConditionSupported: true,
+			conditions: []v1beta1.RootSyncCondition{
+				reconciledCondition,
+				{
+					Type:    v1beta1.RootSyncSyncing,
+					Status:  metav1.ConditionFalse,
+					Reason:  parse.SyncWindowReason,
+					Commit:  "def456",
+					Message: "Waiting for sync window: commit def456 is pending until the next window starts at 2022-03-01T22:00:00Z",
+				},
+			},
+			sourceStatus: v1beta1.SourceStatus{
+				Git:    toGitStatus(git),
+				Commit: "def456",
+			},
+			syncStatus: v1beta1.SyncStatus{
+				Git:    toGitStatus(git),
+				Commit: "abc123",
+			},
+			want: &RepoState{
+				scope:    "<root>",
+				syncName: "root-sync",
+				git:      git,
+				status:   waitingMsg,
+				commit:   "def456",
+				message:  "Waiting for sync window: commit def456 is pending until the next window starts at 2022-03-01T22:00:00Z",
+			},
+		},
+		{
+			name:                      "root sync is suspended",
+			gitSpec:                   git,
+			syncingConditionSupported: true,
+			conditions: []v1beta1.RootSyncCondition{
+				reconciledCondition,
+				{
+					Type:    v1beta1.RootSyncSyncing,
+					Status:  metav1.ConditionFalse,
+					Reason:  parse.SuspendedReason,
+					Commit:  "def456",
+					Message: "Suspended: commit def456 is pending until spec.suspend is unset",
+				},
+			},
+			sourceStatus: v1beta1.SourceStatus{
+				Git:    toGitStatus(git),
+				Commit: "def456",
+			},
+			syncStatus: v1beta1.SyncStatus{
+				Git:    toGitStatus(git),
+				Commit: "abc123",
+			},
+			want: &RepoState{
+				scope:    "<root>",
+				syncName: "root-sync",
+				git:      git,
+				status:   suspendedMsg,
+				commit:   "def456",
+				message:  "Suspended: commit def456 is pending until spec.suspend is unset",
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
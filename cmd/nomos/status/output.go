@@ -0,0 +1,318 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/kinds"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ReportAPIVersion is the version of the machine-readable status schema.
+	// Fields may be added within a version, but never renamed or removed.
+	ReportAPIVersion = "nomos.configsync.gke.io/v1alpha1"
+	// ReportKind is the kind of the machine-readable status report.
+	ReportKind = "Status"
+)
+
+// Exit codes of `nomos status` when the output format is set. When several
+// clusters or syncs are reported, the highest code wins.
+const (
+	// ExitSynced indicates that every sync on every cluster is synced.
+	ExitSynced = 0
+	// ExitPending indicates that at least one sync is still in progress, such
+	// as reconciling, pending, waiting for a sync window or suspended.
+	ExitPending = 2
+	// ExitError indicates that at least one cluster or sync reports errors.
+	ExitError = 3
+	// ExitUnreachable indicates that at least one cluster could not be reached.
+	ExitUnreachable = 4
+)
+
+// ExitCodeError is returned by `nomos status` when the output format is set and
+// not every sync is synced. The report already describes the status, so the
+// caller only exits with the code.
+type ExitCodeError struct {
+	Code int
+}
+
+// Error implements error.
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("status exit code %d", e.Code)
+}
+
+// Report is the machine-readable status of all the clusters.
+type Report struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Time is when the status was collected.
+	Time metav1.Time `json:"time"`
+	// ExitCode summarizes the status of all the clusters.
+	ExitCode int             `json:"exitCode"`
+	Clusters []ClusterReport `json:"clusters"`
+}
+
+// ClusterReport is the machine-readable status of a single cluster.
+type ClusterReport struct {
+	// Name is the name of the cluster context.
+	Name string `json:"name"`
+	// CurrentContext is true for the current context of the kubeconfig.
+	CurrentContext bool `json:"currentContext,omitempty"`
+	// Reachable is false if the cluster could not be connected to.
+	Reachable bool `json:"reachable"`
+	// Status is set when the status of the cluster cannot be broken down into
+	// syncs, e.g. when Config Sync is not installed.
+	Status string `json:"status,omitempty"`
+	// Error details the Status.
+	Error string       `json:"error,omitempty"`
+	Syncs []SyncReport `json:"syncs,omitempty"`
+}
+
+// SyncReport is the machine-readable status of a single RootSync or RepoSync,
+// or of the repo of a cluster in mono-repo mode.
+type SyncReport struct {
+	// Kind is RootSync or RepoSync, and empty in mono-repo mode.
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// SourceType is git, oci or helm.
+	SourceType string `json:"sourceType,omitempty"`
+	// Source is the repo, directory and revision being synced.
+	Source string `json:"source"`
+	// Status is one of SYNCED, PENDING, RECONCILING, WAITING, SUSPENDED,
+	// STALLED or ERROR.
+	Status  string `json:"status"`
+	Commit  string `json:"commit"`
+	Message string `json:"message,omitempty"`
+	// Errors are the error messages of the sync.
+	Errors []string `json:"errors,omitempty"`
+	// ErrorCodes are the distinct KNV codes of the errors in the source,
+	// rendering and sync stages.
+	ErrorCodes   []string              `json:"errorCodes,omitempty"`
+	ErrorSummary *v1beta1.ErrorSummary `json:"errorSummary,omitempty"`
+	// SourceStatus, RenderingStatus and SyncStatus are the states of the
+	// three stages of a sync. They are not reported in mono-repo mode.
+	SourceStatus    *StageReport         `json:"sourceStatus,omitempty"`
+	RenderingStatus *StageReport         `json:"renderingStatus,omitempty"`
+	SyncStatus      *StageReport         `json:"syncStatus,omitempty"`
+	Drift           *v1beta1.DriftStatus `json:"drift,omitempty"`
+	// NamespaceSelectors are the Namespaces selected by each NamespaceSelector
+	// in dynamic mode.
+	NamespaceSelectors []v1beta1.NamespaceSelectorStatus `json:"namespaceSelectors,omitempty"`
+	// Resources are the statuses of the managed resources from the
+	// ResourceGroup, when `--resources` is set.
+	Resources []resourceState `json:"resources,omitempty"`
+}
+
+// StageReport is the state of one stage of a sync.
+type StageReport struct {
+	Commit       string                    `json:"commit,omitempty"`
+	Message      string                    `json:"message,omitempty"`
+	Errors       []v1beta1.ConfigSyncError `json:"errors,omitempty"`
+	ErrorSummary *v1beta1.ErrorSummary     `json:"errorSummary,omitempty"`
+	LastUpdate   metav1.Time               `json:"lastUpdate,omitempty"`
+}
+
+// newReport converts the states of the named clusters into a Report.
+func newReport(stateMap map[string]*ClusterState, names []string, currentContext string, now metav1.Time) *Report {
+	report := &Report{
+		APIVersion: ReportAPIVersion,
+		Kind:       ReportKind,
+		Time:       now,
+		Clusters:   []ClusterReport{},
+	}
+	for _, name := range names {
+		cr := stateMap[name].report(name)
+		cr.CurrentContext = name == currentContext
+		report.Clusters = append(report.Clusters, cr)
+	}
+	report.ExitCode = report.exitCode()
+	return report
+}
+
+func (c *ClusterState) report(name string) ClusterReport {
+	cr := ClusterReport{
+		Name:      name,
+		Reachable: !c.unreachable(),
+		Status:    c.status,
+		Error:     c.Error,
+	}
+	for _, repo := range c.repos {
+		cr.Syncs = append(cr.Syncs, repo.report())
+	}
+	return cr
+}
+
+// unreachable returns true if the ClusterState is for a cluster which could
+// not be connected to.
+func (c *ClusterState) unreachable() bool {
+	return c.Error == unreachableMsg
+}
+
+func (r *RepoState) report() SyncReport {
+	sr := SyncReport{
+		Kind:         r.kind(),
+		Name:         r.syncName,
+		SourceType:   string(r.sourceType),
+		Source:       sourceString(r.sourceType, r.git, r.oci),
+		Status:       r.status,
+		Commit:       r.commit,
+		Message:      r.message,
+		Errors:       r.errors,
+		ErrorSummary: r.errorSummary,
+		Drift:        r.drift,
+
+		NamespaceSelectors: r.namespaceSelectors,
+	}
+	if sr.Kind == kinds.RepoSyncV1Beta1().Kind {
+		sr.Namespace = r.scope
+	}
+	if resourceStatus && len(r.resources) > 0 {
+		sr.Resources = append(sr.Resources, r.resources...)
+		sort.Sort(byNamespaceAndType(sr.Resources))
+	}
+	if s := r.syncStatus; s != nil {
+		sr.SourceStatus = &StageReport{
+			Commit:       s.Source.Commit,
+			Errors:       s.Source.Errors,
+			ErrorSummary: s.Source.ErrorSummary,
+			LastUpdate:   s.Source.LastUpdate,
+		}
+		sr.RenderingStatus = &StageReport{
+			Commit:       s.Rendering.Commit,
+			Message:      s.Rendering.Message,
+			Errors:       s.Rendering.Errors,
+			ErrorSummary: s.Rendering.ErrorSummary,
+			LastUpdate:   s.Rendering.LastUpdate,
+		}
+		sr.SyncStatus = &StageReport{
+			Commit:       s.Sync.Commit,
+			Errors:       s.Sync.Errors,
+			ErrorSummary: s.Sync.ErrorSummary,
+			LastUpdate:   s.Sync.LastUpdate,
+		}
+		sr.ErrorCodes = errorCodes(s.Source.Errors, s.Rendering.Errors, s.Sync.Errors)
+	}
+	return sr
+}
+
+// kind returns the kind of the RootSync or RepoSync the RepoState is computed
+// from, or an empty string in mono-repo mode.
+func (r *RepoState) kind() string {
+	switch {
+	case r.syncName == "":
+		return ""
+	case r.scope == "<root>":
+		return kinds.RootSyncV1Beta1().Kind
+	default:
+		return kinds.RepoSyncV1Beta1().Kind
+	}
+}
+
+// errorCodes returns the sorted, distinct codes of the given errors.
+func errorCodes(errs ...[]v1beta1.ConfigSyncError) []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, stageErrs := range errs {
+		for _, err := range stageErrs {
+			if err.Code == "" || seen[err.Code] {
+				continue
+			}
+			seen[err.Code] = true
+			codes = append(codes, err.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// exitCode returns the highest exit code of all the clusters in the Report.
+func (r *Report) exitCode() int {
+	code := ExitSynced
+	for _, c := range r.Clusters {
+		if cc := c.exitCode(); cc > code {
+			code = cc
+		}
+	}
+	return code
+}
+
+func (c ClusterReport) exitCode() int {
+	if !c.Reachable {
+		return ExitUnreachable
+	}
+	code := statusExitCode(c.Status)
+	for _, s := range c.Syncs {
+		if sc := statusExitCode(s.Status); sc > code {
+			code = sc
+		}
+	}
+	return code
+}
+
+// statusExitCode maps the status of a cluster or sync to an exit code.
+func statusExitCode(status string) int {
+	switch status {
+	case "", syncedMsg:
+		return ExitSynced
+	case pendingMsg, reconcilingMsg, waitingMsg, suspendedMsg:
+		return ExitPending
+	default:
+		return ExitError
+	}
+}
+
+// writeReport writes the Report to out in the given format. Successive
+// reports form a stream: JSON reports are written one per line, and YAML
+// reports are separated by `---`.
+func writeReport(out io.Writer, report *Report, format string) error {
+	switch format {
+	case flags.OutputJSON:
+		b, err := json.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the status to JSON")
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+	case flags.OutputYAML:
+		b, err := yaml.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the status to YAML")
+		}
+		_, err = fmt.Fprintf(out, "---\n%s", b)
+		return err
+	default:
+		return validFormat(format)
+	}
+}
+
+// validFormat returns an error if the given output format is not supported.
+func validFormat(format string) error {
+	switch format {
+	case "", flags.OutputJSON, flags.OutputYAML:
+		return nil
+	default:
+		return errors.Errorf("unsupported output format %q, must be one of %q or %q", format, flags.OutputYAML, flags.OutputJSON)
+	}
+}
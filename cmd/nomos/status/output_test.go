@@ -0,0 +1,275 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/cmd/nomos/flags"
+	"kpt.dev/configsync/cmd/nomos/util"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+var reportTime = metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+
+func TestNewReport(t *testing.T) {
+	syncStatus := &v1beta1.Status{
+		Source: v1beta1.SourceStatus{Commit: "abc123"},
+		Rendering: v1beta1.RenderingStatus{
+			Commit:  "abc123",
+			Message: "Rendering succeeded",
+		},
+		Sync: v1beta1.SyncStatus{
+			Commit: "abc123",
+			Errors: []v1beta1.ConfigSyncError{
+				{Code: "2009", ErrorMessage: "KNV2009: apply error"},
+				{Code: "1021", ErrorMessage: "KNV1021: unknown kind"},
+				{Code: "2009", ErrorMessage: "KNV2009: another apply error"},
+			},
+			ErrorSummary: errorSummayWithTwoErrors,
+		},
+	}
+	stateMap := map[string]*ClusterState{
+		"bar": unavailableCluster("bar"),
+		"foo": {
+			Ref: "foo",
+			repos: []*RepoState{
+				{
+					scope:      "<root>",
+					syncName:   "root-sync",
+					sourceType: v1beta1.GitSource,
+					git:        git,
+					status:     util.ErrorMsg,
+					commit:     "abc123",
+					errors:     []string{"KNV2009: apply error", "KNV1021: unknown kind"},
+					syncStatus: syncStatus,
+				},
+				{
+					scope:      "bookstore",
+					syncName:   "repo-sync",
+					sourceType: v1beta1.OciSource,
+					oci:        oci,
+					status:     syncedMsg,
+					commit:     "def456",
+					resources: []resourceState{
+						{Namespace: "bookstore", Name: "b", Kind: "ConfigMap", Status: "Current"},
+						{Namespace: "bookstore", Name: "a", Kind: "ConfigMap", Status: "Current"},
+					},
+				},
+			},
+		},
+		"mono": {
+			Ref:   "mono",
+			repos: []*RepoState{{scope: "<root>", git: git, status: pendingMsg, commit: emptyCommit}},
+		},
+	}
+
+	got := newReport(stateMap, []string{"bar", "foo", "mono"}, "foo", reportTime)
+	want := &Report{
+		APIVersion: ReportAPIVersion,
+		Kind:       ReportKind,
+		Time:       reportTime,
+		ExitCode:   ExitUnreachable,
+		Clusters: []ClusterReport{
+			{
+				Name:   "bar",
+				Status: "N/A",
+				Error:  unreachableMsg,
+			},
+			{
+				Name:           "foo",
+				CurrentContext: true,
+				Reachable:      true,
+				Syncs: []SyncReport{
+					{
+						Kind:       "RootSync",
+						Name:       "root-sync",
+						SourceType: "git",
+						Source:     "git@github.com:tester/sample/admin@v1",
+						Status:     util.ErrorMsg,
+						Commit:     "abc123",
+						Errors:     []string{"KNV2009: apply error", "KNV1021: unknown kind"},
+						ErrorCodes: []string{"1021", "2009"},
+						SourceStatus: &StageReport{
+							Commit: "abc123",
+						},
+						RenderingStatus: &StageReport{
+							Commit:  "abc123",
+							Message: "Rendering succeeded",
+						},
+						SyncStatus: &StageReport{
+							Commit:       "abc123",
+							Errors:       syncStatus.Sync.Errors,
+							ErrorSummary: errorSummayWithTwoErrors,
+						},
+					},
+					{
+						Kind:       "RepoSync",
+						Namespace:  "bookstore",
+						Name:       "repo-sync",
+						SourceType: "oci",
+						Source:     "us-docker.pkg.dev/test-project/test-ar-repo/sample/test",
+						Status:     syncedMsg,
+						Commit:     "def456",
+						Resources: []resourceState{
+							{Namespace: "bookstore", Name: "a", Kind: "ConfigMap", Status: "Current"},
+							{Namespace: "bookstore", Name: "b", Kind: "ConfigMap", Status: "Current"},
+						},
+					},
+				},
+			},
+			{
+				Name:      "mono",
+				Reachable: true,
+				Syncs: []SyncReport{
+					{
+						Source: "git@github.com:tester/sample/admin@v1",
+						Status: pendingMsg,
+						Commit: emptyCommit,
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReport_ExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		clusters []ClusterReport
+		want     int
+	}{
+		{
+			name: "no clusters",
+			want: ExitSynced,
+		},
+		{
+			name: "synced",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: syncedMsg}, {Status: syncedMsg}}},
+			},
+			want: ExitSynced,
+		},
+		{
+			name: "pending",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: syncedMsg}, {Status: reconcilingMsg}}},
+			},
+			want: ExitPending,
+		},
+		{
+			name: "suspended is pending",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: suspendedMsg}}},
+			},
+			want: ExitPending,
+		},
+		{
+			name: "stalled sync",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: pendingMsg}, {Status: stalledMsg}}},
+			},
+			want: ExitError,
+		},
+		{
+			name: "not installed",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: syncedMsg}}},
+				{Reachable: true, Status: util.NotInstalledMsg},
+			},
+			want: ExitError,
+		},
+		{
+			name: "unreachable wins",
+			clusters: []ClusterReport{
+				{Reachable: true, Syncs: []SyncReport{{Status: util.ErrorMsg}}},
+				{Reachable: false, Status: "N/A"},
+			},
+			want: ExitUnreachable,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Report{Clusters: tc.clusters}
+			if got := r.exitCode(); got != tc.want {
+				t.Errorf("exitCode() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report := newReport(map[string]*ClusterState{"foo": unavailableCluster("foo")}, []string{"foo"}, "", reportTime)
+
+	t.Run("json stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		for i := 0; i < 2; i++ {
+			if err := writeReport(&buf, report, flags.OutputJSON); err != nil {
+				t.Fatal(err)
+			}
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+		}
+		for _, line := range lines {
+			got := &Report{}
+			if err := json.Unmarshal([]byte(line), got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(report, got); diff != "" {
+				t.Error(diff)
+			}
+		}
+	})
+
+	t.Run("yaml stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		for i := 0; i < 2; i++ {
+			if err := writeReport(&buf, report, flags.OutputYAML); err != nil {
+				t.Fatal(err)
+			}
+		}
+		docs := strings.Split(buf.String(), "---\n")
+		if len(docs) != 3 || docs[0] != "" {
+			t.Fatalf("got %d YAML documents, want 2:\n%s", len(docs)-1, buf.String())
+		}
+		for _, doc := range docs[1:] {
+			got := &Report{}
+			if err := yaml.Unmarshal([]byte(doc), got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(report, got); diff != "" {
+				t.Error(diff)
+			}
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		if err := writeReport(&bytes.Buffer{}, report, "table"); err == nil {
+			t.Error("writeReport() got no error, want an error")
+		}
+	})
+}
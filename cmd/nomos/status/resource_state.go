@@ -20,6 +20,8 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"kpt.dev/configsync/pkg/metadata"
 	"sigs.k8s.io/yaml"
 )
 
@@ -107,6 +109,49 @@ func resourceLevelStatus(rg *unstructured.Unstructured) ([]resourceState, error)
 	return checkConflict(states), nil
 }
 
+// mergeInventoryShards returns the ResourceGroups with the resources and
+// resource statuses of the inventory shards appended to the ResourceGroup they
+// are a shard of. The shards themselves are dropped.
+func mergeInventoryShards(rgs []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	var result []*unstructured.Unstructured
+	inventories := map[types.NamespacedName]*unstructured.Unstructured{}
+	for _, rg := range rgs {
+		if rg.GetLabels()[metadata.InventoryShardOfLabel] == "" {
+			rg = rg.DeepCopy()
+			inventories[types.NamespacedName{Namespace: rg.GetNamespace(), Name: rg.GetName()}] = rg
+			result = append(result, rg)
+		}
+	}
+	for _, shard := range rgs {
+		name := shard.GetLabels()[metadata.InventoryShardOfLabel]
+		if name == "" {
+			continue
+		}
+		rg, found := inventories[types.NamespacedName{Namespace: shard.GetNamespace(), Name: name}]
+		if !found {
+			// The inventory was deleted, and the shard is garbage collected.
+			continue
+		}
+		for _, field := range [][]string{{"spec", "resources"}, {"status", "resourceStatuses"}} {
+			shardItems, found, err := unstructured.NestedSlice(shard.Object, field...)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			items, _, err := unstructured.NestedSlice(rg.Object, field...)
+			if err != nil {
+				return nil, err
+			}
+			if err := unstructured.SetNestedSlice(rg.Object, append(items, shardItems...), field...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
 func checkConflict(states []resourceState) []resourceState {
 	for i, s := range states {
 		for _, c := range s.Conditions {
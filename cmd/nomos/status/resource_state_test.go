@@ -19,6 +19,10 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/testing/fake"
 )
 
 func TestResourceState(t *testing.T) {
@@ -84,3 +88,54 @@ func TestResourceState(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func resourceGroupWithStatus(t *testing.T, name string, resourceNames []string, opts ...core.MetaMutator) *unstructured.Unstructured {
+	t.Helper()
+	rg := fake.ResourceGroupObject(append(opts, core.Name(name), core.Namespace("config-management-system"))...)
+	var statuses []interface{}
+	for _, n := range resourceNames {
+		statuses = append(statuses, map[string]interface{}{
+			"kind":      "ConfigMap",
+			"namespace": "bookstore",
+			"name":      n,
+			"status":    "Current",
+		})
+	}
+	if err := unstructured.SetNestedSlice(rg.Object, statuses, "status", "resourceStatuses"); err != nil {
+		t.Fatal(err)
+	}
+	return rg
+}
+
+func TestMergeInventoryShards(t *testing.T) {
+	rgs := []*unstructured.Unstructured{
+		resourceGroupWithStatus(t, "root-sync", []string{"cm-0", "cm-1"}),
+		resourceGroupWithStatus(t, "root-sync-shard-1", []string{"cm-2", "cm-3"},
+			core.Label(metadata.InventoryShardOfLabel, "root-sync")),
+		resourceGroupWithStatus(t, "other-sync", []string{"cm-4"}),
+		resourceGroupWithStatus(t, "deleted-sync-shard-1", []string{"cm-5"},
+			core.Label(metadata.InventoryShardOfLabel, "deleted-sync")),
+	}
+	got, err := mergeInventoryShards(rgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].GetName() != "root-sync" || got[1].GetName() != "other-sync" {
+		t.Fatalf("mergeInventoryShards() got %v, want the root-sync and other-sync ResourceGroups", got)
+	}
+
+	states, err := resourceLevelStatus(got[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range states {
+		names = append(names, s.Name)
+	}
+	if diff := cmp.Diff([]string{"cm-0", "cm-1", "cm-2", "cm-3"}, names); diff != "" {
+		t.Error(diff)
+	}
+	if states, _ := resourceLevelStatus(rgs[0]); len(states) != 2 {
+		t.Errorf("mergeInventoryShards() modified the input ResourceGroup, got %d resource statuses", len(states))
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/cmd/nomos/flags"
 	"kpt.dev/configsync/cmd/nomos/util"
@@ -39,12 +40,15 @@ const (
 	syncedMsg      = "SYNCED"
 	stalledMsg     = "STALLED"
 	reconcilingMsg = "RECONCILING"
+	waitingMsg     = "WAITING"
+	suspendedMsg   = "SUSPENDED"
 )
 
 var (
 	pollingInterval time.Duration
 	namespace       string
 	resourceStatus  bool
+	format          string
 )
 
 func init() {
@@ -53,6 +57,9 @@ func init() {
 	Cmd.Flags().DurationVar(&pollingInterval, "poll", 0*time.Second, "Polling interval (leave unset to run once)")
 	Cmd.Flags().StringVar(&namespace, "namespace", "", "Namespace repo to get status for (multi-repo only, leave unset to get all repos)")
 	Cmd.Flags().BoolVar(&resourceStatus, "resources", true, "show resource level status for Namespace repo (multi-repo only)")
+	Cmd.Flags().StringVar(&format, "format", "",
+		fmt.Sprintf("Output format. Accepts '%s' and '%s' (leave unset for a table). When set, the exit code is %d if all clusters are synced, %d if any sync is pending, %d on errors and %d if any cluster is unreachable",
+			flags.OutputYAML, flags.OutputJSON, ExitSynced, ExitPending, ExitError, ExitUnreachable))
 }
 
 // SaveToTempFile writes the `nomos status` output into a temporary file, and
@@ -97,7 +104,12 @@ var Cmd = &cobra.Command{
 		// Don't show usage on error, as argument validation passed.
 		cmd.SilenceUsage = true
 
-		fmt.Println("Connecting to clusters...")
+		if err := validFormat(format); err != nil {
+			return err
+		}
+		if format == "" {
+			fmt.Println("Connecting to clusters...")
+		}
 
 		clientMap, err := ClusterClients(cmd.Context(), flags.Contexts)
 		if err != nil {
@@ -115,6 +127,23 @@ var Cmd = &cobra.Command{
 		// Use a sorted order of names to avoid shuffling in the output.
 		names := clusterNames(clientMap)
 
+		if format != "" {
+			for {
+				report, err := printReport(cmd.Context(), os.Stdout, clientMap, names)
+				if err != nil {
+					return err
+				}
+				if pollingInterval <= 0 {
+					if report.ExitCode != ExitSynced {
+						cmd.SilenceErrors = true
+						return &ExitCodeError{Code: report.ExitCode}
+					}
+					return nil
+				}
+				time.Sleep(pollingInterval)
+			}
+		}
+
 		writer := util.NewWriter(os.Stdout)
 		if pollingInterval > 0 {
 			for {
@@ -194,6 +223,21 @@ func printStatus(ctx context.Context, writer *tabwriter.Writer, clientMap map[st
 	writer.Flush()
 }
 
+// printReport fetches the status of each cluster in the given map and writes
+// it to out as a Report in the output format. When polling, successive reports
+// are appended to out as a stream.
+func printReport(ctx context.Context, out io.Writer, clientMap map[string]*ClusterClient, names []string) (*Report, error) {
+	stateMap, _ := clusterStates(ctx, clientMap)
+
+	currentContext, err := restconfig.CurrentContextName()
+	if err != nil {
+		klog.Warningf("Failed to get current context name with err: %v", errors.Cause(err))
+	}
+
+	report := newReport(stateMap, names, currentContext, metav1.Now())
+	return report, writeReport(out, report, format)
+}
+
 // clearTerminal executes an OS-specific command to clear all output on the terminal.
 func clearTerminal(out io.Writer) {
 	var cmd *exec.Cmd
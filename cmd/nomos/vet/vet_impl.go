@@ -65,7 +65,7 @@ func runVet(ctx context.Context, namespace string, sourceFormat filesystem.Sourc
 
 	if needsHydrate {
 		// update rootDir to point to the hydrated output for further processing.
-		if rootDir, err = hydrate.ValidateAndRunKustomize(rootDir.OSPath()); err != nil {
+		if rootDir, err = hydrate.ValidateAndRender(rootDir.OSPath()); err != nil {
 			return err
 		}
 		// delete the hydrated output directory in the end.
@@ -321,6 +321,18 @@ func Generate() AllExamples {
 	// 1068
 	result.add(status.HydrationError(status.ActionableHydrationErrorCode, errors.New("user actionable rendering error")))
 
+	// 1069
+	result.add(status.HydrationError(status.KustomizeMissingResourceErrorCode,
+		errors.New("failed to render the kustomization in /repo/overlay: accumulating resources: accumulation err='accumulating resources from '../base': evalsymlink failure on '/repo/base' : lstat /repo/base: no such file or directory'")))
+
+	// 1070
+	result.add(status.HydrationError(status.KustomizeInvalidKustomizationErrorCode,
+		errors.New(`failed to render the kustomization in /repo/overlay: json: unknown field "resourcez"`)))
+
+	// 1071
+	result.add(status.HydrationError(status.KustomizePluginErrorCode,
+		errors.New("failed to render the kustomization in /repo/overlay: couldn't execute function: fork/exec ./generator: no such file or directory")))
+
 	// 2001
 	result.add(status.PathWrapError(errors.New("error creating directory"), "namespaces/foo"))
 
@@ -371,6 +383,14 @@ func Generate() AllExamples {
 	// 2015
 	result.add(status.InternalHydrationError(errors.New("internal rendering error"), "internal rendering error"))
 
+	// 2016
+	result.add(status.SourceVerificationError.Sprint("no signature or attestation of the OCI image is signed by a trusted public key").Build())
+	result.add(status.SourceVerificationError.Sprint("commit 1234abcd is not signed by a trusted key: the commit is not signed").Build())
+
+	// 2017
+	result.add(declared.DeletionBudgetError("abc123", "RootSync config-management-system/root-sync",
+		[]string{"12 of 40 Deployment.apps objects (budget: maxPercent 10)"}))
+
 	// 9998
 	result.add(status.InternalError("we made a mistake"))
 
@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -23,11 +24,13 @@ import (
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/google"
 	"k8s.io/klog/v2/klogr"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/oci"
 	"kpt.dev/configsync/pkg/reconcilermanager"
+	"kpt.dev/configsync/pkg/trigger"
 	"kpt.dev/configsync/pkg/util"
 	utillog "kpt.dev/configsync/pkg/util/log"
 )
@@ -35,8 +38,14 @@ import (
 var flImage = flag.String("image", util.EnvString(reconcilermanager.OciSyncImage, ""),
 	"the OCI image repository for the package")
 var flAuth = flag.String("auth", util.EnvString(reconcilermanager.OciSyncAuth, string(configsync.AuthNone)),
-	fmt.Sprintf("the authentication type for access to the OCI package. Must be one of %s, %s, or %s. Defaults to %s",
-		configsync.AuthGCPServiceAccount, configsync.AuthGCENode, configsync.AuthNone, configsync.AuthNone))
+	fmt.Sprintf("the authentication type for access to the OCI package. Must be one of %s, %s, %s, %s, or %s. Defaults to %s",
+		configsync.AuthGCPServiceAccount, configsync.AuthGCENode, configsync.AuthToken, configsync.AuthDockerConfigJSON, configsync.AuthNone, configsync.AuthNone))
+var flUsername = flag.String("username", util.EnvString("OCI_SYNC_USERNAME", ""),
+	"the username to use for token authentication")
+var flPassword = flag.String("password", util.EnvString("OCI_SYNC_PASSWORD", ""),
+	"the password or personal access token to use for token authentication")
+var flDockerConfigFile = flag.String("docker-config-file", util.EnvString("OCI_SYNC_DOCKER_CONFIG_FILE", "/etc/oci-secret/.dockerconfigjson"),
+	"the Docker config file holding the per-registry credentials to use for dockerconfigjson authentication")
 var flRoot = flag.String("root", util.EnvString("OCI_SYNC_ROOT", util.EnvString("HOME", "")+"/oci"),
 	"the root directory for oci-sync operations, under which --dest will be created")
 var flDest = flag.String("dest", util.EnvString("OCI_SYNC_DEST", ""),
@@ -49,6 +58,12 @@ var flSyncTimeout = flag.Int("timeout", util.EnvInt("OCI_SYNC_TIMEOUT", 120),
 	"the max number of seconds allowed for a complete sync")
 var flOneTime = flag.Bool("one-time", util.EnvBool("OCI_SYNC_ONE_TIME", false),
 	"exit after the first sync")
+var flVerificationKeysDir = flag.String("verification-keys-dir", util.EnvString(reconcilermanager.OciSyncVerificationKeysDir, ""),
+	"the directory holding the PEM-encoded public keys used to verify the image signatures (defaults to \"\", disabling the verification)")
+var flSyncTriggerAddr = flag.String("sync-trigger-addr", util.EnvString("OCI_SYNC_TRIGGER_ADDR", trigger.SidecarAddr),
+	"the address on which to serve the sync trigger endpoint, which starts a sync without waiting for --wait (defaults to a Pod-local address, \"\" disables the endpoint)")
+var flNotifyURL = flag.String("notify-url", util.EnvString("OCI_SYNC_NOTIFY_URL", trigger.ReconcilerURL),
+	"the URL to call once a new image digest is pulled (defaults to the sync trigger endpoint of the reconciler, \"\" disables the notification)")
 var flMaxSyncFailures = flag.Int("max-sync-failures", util.EnvInt("OCI_SYNC_MAX_SYNC_FAILURES", 0),
 	"the number of consecutive failures allowed before aborting (the first sync must succeed, -1 will retry forever after the initial sync)")
 
@@ -59,7 +74,9 @@ func main() {
 	log.Info("pulling OCI image with arguments", "--image", *flImage,
 		"--auth", *flAuth, "--root", *flRoot, "--dest", *flDest, "--wait", *flWait,
 		"--error-file", *flErrorFile, "--timeout", *flSyncTimeout,
-		"--one-time", *flOneTime, "--max-sync-failures", *flMaxSyncFailures)
+		"--one-time", *flOneTime, "--max-sync-failures", *flMaxSyncFailures,
+		"--verification-keys-dir", *flVerificationKeysDir, "--docker-config-file", *flDockerConfigFile,
+		"--sync-trigger-addr", *flSyncTriggerAddr, "--notify-url", *flNotifyURL)
 
 	if *flImage == "" {
 		utillog.HandleError(log, true, "ERROR: --image must be specified")
@@ -83,9 +100,18 @@ func main() {
 	}
 
 	var auth authn.Authenticator
+	var keychain authn.Keychain
 	switch configsync.AuthType(*flAuth) {
 	case configsync.AuthNone:
 		auth = authn.Anonymous
+	case configsync.AuthToken:
+		if *flUsername == "" || *flPassword == "" {
+			utillog.HandleError(log, true, "ERROR: --username and --password must be specified when --auth=%s", configsync.AuthToken)
+		}
+		auth = &authn.Basic{Username: *flUsername, Password: *flPassword}
+	case configsync.AuthDockerConfigJSON:
+		// The credentials are resolved on each sync from the registry of the image.
+		keychain = oci.NewDockerConfigKeychain(*flDockerConfigFile)
 	case configsync.AuthGCPServiceAccount, configsync.AuthGCENode:
 		a, err := google.NewEnvAuthenticator()
 		if err != nil {
@@ -96,11 +122,19 @@ func main() {
 		utillog.HandleError(log, true, "ERROR: unsupported authentication type %q", *flAuth)
 	}
 
+	var syncTrigger *trigger.Handler
+	if *flSyncTriggerAddr != "" {
+		syncTrigger = trigger.NewHandler(trigger.Options{})
+		trigger.Start(context.Background(), "", *flSyncTriggerAddr, syncTrigger)
+	}
+
 	initialSync := true
 	failCount := 0
 	for {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(*flSyncTimeout))
-		if err := oci.FetchPackage(ctx, *flImage, *flRoot, *flDest, auth); err != nil {
+		oldPackageDir := util.SymlinkTarget(*flRoot, *flDest)
+		err := fetchPackage(ctx, auth, keychain)
+		if err != nil {
 			if *flMaxSyncFailures != -1 && failCount >= *flMaxSyncFailures {
 				// Exit after too many retries, maybe the error is not recoverable.
 				log.Error(err, "too many failures, aborting", "failCount", failCount)
@@ -108,10 +142,16 @@ func main() {
 			}
 
 			failCount++
-			log.Error(err, "unexpected error fetching package, will retry")
+			var verr *oci.VerificationError
+			if errors.As(err, &verr) {
+				// The message lets the reconciler surface a SourceVerificationError.
+				log.Error(err, oci.VerificationFailedMsg, "image", verr.Image, "digest", verr.Digest.String())
+			} else {
+				log.Error(err, "unexpected error fetching package, will retry")
+			}
 			log.Info("waiting before retrying", "waitTime", util.WaitTime(*flWait))
 			cancel()
-			time.Sleep(util.WaitTime(*flWait))
+			trigger.Sleep(util.WaitTime(*flWait), syncTrigger.C())
 			continue
 		}
 
@@ -125,9 +165,47 @@ func main() {
 
 		failCount = 0
 		log.DeleteErrorFile()
+		notifyReconciler(ctx, log, oldPackageDir)
 		log.Info("next sync", "wait_time", util.WaitTime(*flWait))
 		cancel()
-		time.Sleep(util.WaitTime(*flWait))
+		trigger.Sleep(util.WaitTime(*flWait), syncTrigger.C())
 	}
+}
 
+// fetchPackage pulls the package, verifying its signature if
+// --verification-keys-dir is set. The public keys and the keychain credentials
+// are reloaded on each sync so that updates to the mounted Secrets or
+// ConfigMap take effect.
+func fetchPackage(ctx context.Context, auth authn.Authenticator, keychain authn.Keychain) error {
+	if keychain != nil {
+		ref, err := name.ParseReference(*flImage)
+		if err != nil {
+			return fmt.Errorf("failed to parse reference %q: %w", *flImage, err)
+		}
+		a, err := keychain.Resolve(ref.Context())
+		if err != nil {
+			return err
+		}
+		auth = a
+	}
+	var verifier *oci.Verifier
+	if *flVerificationKeysDir != "" {
+		v, err := oci.NewVerifier(*flVerificationKeysDir)
+		if err != nil {
+			return err
+		}
+		verifier = v
+	}
+	return oci.FetchPackage(ctx, *flImage, *flRoot, *flDest, auth, verifier)
+}
+
+// notifyReconciler calls --notify-url if the sync pulled a new image digest, so
+// that the reconciler doesn't wait for its next polling period.
+func notifyReconciler(ctx context.Context, log *utillog.Logger, oldPackageDir string) {
+	if *flNotifyURL == "" || oldPackageDir == "" || util.SymlinkTarget(*flRoot, *flDest) == oldPackageDir {
+		return
+	}
+	if err := trigger.Notify(ctx, *flNotifyURL); err != nil {
+		log.Info("failed to notify the reconciler of the new image digest", "error", err.Error())
+	}
 }
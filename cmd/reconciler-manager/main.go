@@ -45,6 +45,9 @@ var (
 	clusterName = flag.String("cluster-name", os.Getenv(reconcilermanager.ClusterNameKey),
 		"Cluster name to use for Cluster selection")
 
+	clusterLabelsSource = flag.String("cluster-labels-source", os.Getenv(reconcilermanager.ClusterLabelsSourceKey),
+		"Where to read the labels of the cluster to use for Cluster selection, in addition to the Cluster objects in the repo. Must be empty, configmap or membership.")
+
 	reconcilerPollingPeriod = flag.Duration("reconciler-polling-period", controllers.PollingPeriod(reconcilermanager.ReconcilerPollingPeriod, configsync.DefaultReconcilerPollingPeriod),
 		"How often the reconciler should poll the filesystem for updates to the source or rendered configs.")
 
@@ -89,7 +92,13 @@ func main() {
 
 	watchFleetMembership := fleetMembershipCRDExists(mgr.GetConfig(), mgr.GetRESTMapper())
 
-	repoSync := controllers.NewRepoSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod, mgr.GetClient(),
+	labelsSource := controllers.ClusterLabelsSource(*clusterLabelsSource)
+	if err := controllers.ValidateClusterLabelsSource(labelsSource); err != nil {
+		setupLog.Error(err, "invalid --cluster-labels-source")
+		os.Exit(1)
+	}
+
+	repoSync := controllers.NewRepoSyncReconciler(*clusterName, labelsSource, *reconcilerPollingPeriod, *hydrationPollingPeriod, mgr.GetClient(),
 		ctrl.Log.WithName("controllers").WithName("RepoSync"),
 		mgr.GetScheme())
 	if err := repoSync.SetupWithManager(mgr, watchFleetMembership); err != nil {
@@ -97,7 +106,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	rootSync := controllers.NewRootSyncReconciler(*clusterName, *reconcilerPollingPeriod, *hydrationPollingPeriod, mgr.GetClient(),
+	rootSync := controllers.NewRootSyncReconciler(*clusterName, labelsSource, *reconcilerPollingPeriod, *hydrationPollingPeriod, mgr.GetClient(),
 		ctrl.Log.WithName("controllers").WithName("RootSync"),
 		mgr.GetScheme())
 	if err := rootSync.SetupWithManager(mgr, watchFleetMembership); err != nil {
@@ -15,11 +15,13 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"os"
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	"kpt.dev/configsync/pkg/api/configsync"
@@ -33,6 +35,8 @@ import (
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/reconcilermanager/controllers"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/trigger"
+	"kpt.dev/configsync/pkg/util"
 	"kpt.dev/configsync/pkg/util/log"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -40,6 +44,10 @@ import (
 var (
 	clusterName = flag.String(flags.clusterName, os.Getenv(reconcilermanager.ClusterNameKey),
 		"Cluster name to use for Cluster selection")
+	clusterLabels = flag.String("cluster-labels", os.Getenv(reconcilermanager.ClusterLabelsKey),
+		"The comma-separated key=value labels of the cluster to use for Cluster selection, which override the labels of the Cluster object in the repo.")
+	clusterValues = flag.String("cluster-values", os.Getenv(reconcilermanager.ClusterValuesKey),
+		"The JSON encoded values of the ${cluster.values.KEY} variables in the declared configs.")
 	scope = flag.String("scope", os.Getenv(reconcilermanager.ScopeKey),
 		"Scope of the reconciler, either a namespace or ':root'.")
 	syncName = flag.String("sync-name", os.Getenv(reconcilermanager.SyncNameKey),
@@ -59,6 +67,8 @@ var (
 		"The reference we're syncing to in the repo. Could be a specific commit or a chart version.")
 	syncDir = flag.String("sync-dir", os.Getenv(reconcilermanager.SyncDirKey),
 		"The relative path of the root configuration directory within the repo.")
+	gitVerificationKeysDir = flag.String("git-verification-keys-dir", os.Getenv(reconcilermanager.GitVerificationKeysDir),
+		"The directory holding the OpenPGP or SSH keys trusted to sign the git commits. If set, unsigned or untrusted commits are not synced.")
 
 	// Performance tuning flags.
 	sourceDir = flag.String(flags.sourceDir, "/repo/source/rev",
@@ -88,6 +98,25 @@ var (
 	statusMode = flag.String(flags.statusMode, os.Getenv(reconcilermanager.StatusMode),
 		"When the value is enabled or empty, the applier injects actuation status data into the ResourceGroup object")
 
+	dryRun = flag.Bool("dry-run", util.EnvBool(reconcilermanager.DryRun, false),
+		"If true, compute the changes to the cluster and publish them in the status of the RootSync or RepoSync instead of applying them.")
+	auditOnly = flag.Bool("audit-only", util.EnvBool(reconcilermanager.AuditOnly, false),
+		"If true, the remediator records the drift of the managed resources in the status of the RootSync or RepoSync instead of correcting it.")
+	deletionBudgets = flag.String("deletion-budgets", os.Getenv(reconcilermanager.DeletionBudgets),
+		"The JSON encoded deletion budgets which limit the number of managed objects per kind which a commit, or the remediator between two commits, may delete.")
+	syncWindows = flag.String("sync-windows", os.Getenv(reconcilermanager.SyncWindows),
+		"The JSON encoded sync windows which restrict when the changes of the source of truth are applied.")
+	suspend = flag.Bool("suspend", util.EnvBool(reconcilermanager.Suspend, false),
+		"If true, stop applying the changes of the source of truth and remediating drift, while still fetching the source and reporting the status.")
+
+	// Sync trigger flags.
+	syncTriggerAddr = flag.String("sync-trigger-addr", trigger.ReconcilerAddr,
+		"The address on which to serve the authenticated sync trigger endpoint for push webhooks and \"sync now\" calls. Empty disables the endpoint.")
+	syncTriggerLocalAddr = flag.String("sync-trigger-local-addr", trigger.ReconcilerLocalAddr,
+		"The loopback address on which to serve the unauthenticated sync trigger endpoint for the source containers. Empty disables the endpoint.")
+	syncTriggerTokenFile = flag.String("sync-trigger-token-file", trigger.DefaultTokenFile,
+		"The file holding the token which authenticates the requests to the sync trigger endpoint. It is read on every request.")
+
 	debug = flag.Bool("debug", false,
 		"Enable debug mode, panicking in many scenarios where normally an InternalError would be logged. "+
 			"Do not use in production.")
@@ -158,8 +187,36 @@ func main() {
 		klog.Fatal(err)
 	}
 
+	liveLabels, err := labels.ConvertSelectorToLabelsMap(*clusterLabels)
+	if err != nil {
+		klog.Fatalf("Invalid cluster labels %q: %v", *clusterLabels, err)
+	}
+
+	var values map[string]string
+	if *clusterValues != "" {
+		if err := json.Unmarshal([]byte(*clusterValues), &values); err != nil {
+			klog.Fatalf("Invalid cluster values %q: %v", *clusterValues, err)
+		}
+	}
+
+	var budgets []v1beta1.DeletionBudget
+	if *deletionBudgets != "" {
+		if err := json.Unmarshal([]byte(*deletionBudgets), &budgets); err != nil {
+			klog.Fatalf("Invalid deletion budgets %q: %v", *deletionBudgets, err)
+		}
+	}
+
+	var windows []v1beta1.SyncWindow
+	if *syncWindows != "" {
+		if err := json.Unmarshal([]byte(*syncWindows), &windows); err != nil {
+			klog.Fatalf("Invalid sync windows %q: %v", *syncWindows, err)
+		}
+	}
+
 	opts := reconciler.Options{
 		ClusterName:                *clusterName,
+		ClusterLabels:              liveLabels,
+		ClusterValues:              values,
 		FightDetectionThreshold:    *fightDetectionThreshold,
 		NumWorkers:                 *workers,
 		ReconcilerScope:            declared.Scope(*scope),
@@ -174,10 +231,19 @@ func main() {
 		SourceType:                 v1beta1.SourceType(*sourceType),
 		SourceRepo:                 *sourceRepo,
 		SyncDir:                    relSyncDir,
+		GitVerificationKeysDir:     *gitVerificationKeysDir,
 		SyncName:                   *syncName,
 		ReconcilerName:             *reconcilerName,
 		StatusMode:                 *statusMode,
 		ReconcileTimeout:           *reconcileTimeout,
+		DryRun:                     *dryRun,
+		AuditOnly:                  *auditOnly,
+		DeletionBudgets:            budgets,
+		SyncWindows:                windows,
+		Suspend:                    *suspend,
+		SyncTriggerAddr:            *syncTriggerAddr,
+		SyncTriggerLocalAddr:       *syncTriggerLocalAddr,
+		SyncTriggerTokenFile:       *syncTriggerTokenFile,
 	}
 
 	if declared.Scope(*scope) == declared.RootReconciler {
@@ -99,7 +99,7 @@ func TestHydrateKustomizeComponents(t *testing.T) {
 	nt.T.Log("Make kustomization.yaml invalid")
 	nt.RootRepos[configsync.RootSyncName].Copy("../testdata/hydration/invalid-kustomization.yaml", "./kustomize-components/kustomization.yml")
 	nt.RootRepos[configsync.RootSyncName].CommitAndPush("update kustomization.yaml to make it invalid")
-	nt.WaitForRootSyncRenderingError(configsync.RootSyncName, status.ActionableHydrationErrorCode, "")
+	nt.WaitForRootSyncRenderingError(configsync.RootSyncName, status.KustomizeInvalidKustomizationErrorCode, "")
 
 	rs = getUpdatedRootSync(nt, configsync.RootSyncName, configsync.ControllerNamespace)
 	rsCommit, rsStatus, rsErrorSummary = getRootSyncCommitStatusErrorSummary(rs, nil, false)
@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/GoogleContainerTools/kpt/pkg/live"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -241,6 +242,92 @@ func TestStressLargeRequest(t *testing.T) {
 		nomostest.WithTimeout(30*time.Minute))
 }
 
+// TestStressShardedInventory syncs 12000 ConfigMaps, which do not fit into a
+// single ResourceGroup, and verifies that the inventory is sharded and that
+// pruning shrinks it again. The sync durations are logged to measure the
+// scaling limits.
+func TestStressShardedInventory(t *testing.T) {
+	nt := nomostest.New(t, ntopts.Unstructured, ntopts.SkipMonoRepo, ntopts.StressTest,
+		ntopts.WithReconcileTimeout(configsync.DefaultReconcileTimeout))
+	nt.T.Log("Stop the CS webhook by removing the webhook configuration")
+	nomostest.StopWebhook(nt)
+
+	nt.T.Log("Override the memory limit of the reconciler container of root-reconciler to 2GiB")
+	rootSync := fake.RootSyncObjectV1Beta1(configsync.RootSyncName)
+	nt.MustMergePatch(rootSync, `{"spec": {"override": {"resources": [{"containerName": "reconciler", "memoryLimit": "2Gi"}]}}}`)
+	nt.WaitForRepoSyncs()
+
+	ns := "my-ns-1"
+	nt.RootRepos[configsync.RootSyncName].Add("acme/ns.yaml", fake.NamespaceObject(ns))
+
+	labelKey := "StressTestName"
+	labelValue := "TestStressShardedInventory"
+	for i := 0; i < 12000; i++ {
+		// Group the ConfigMaps into directories of 1000 to remove them in bulk.
+		nt.RootRepos[configsync.RootSyncName].Add(fmt.Sprintf("acme/cms-%d/cm-%d.yaml", i/1000, i), fake.ConfigMapObject(
+			core.Name(fmt.Sprintf("cm-%d", i)), core.Namespace(ns), core.Label(labelKey, labelValue)))
+	}
+	nt.RootRepos[configsync.RootSyncName].CommitAndPush("Add configs (12000 ConfigMaps and 1 Namespace)")
+	start := time.Now()
+	nt.WaitForRepoSyncs(nomostest.WithTimeout(30 * time.Minute))
+	nt.T.Logf("Synced 12001 objects in %v", time.Since(start))
+
+	nt.T.Log("Verify that the 12001 objects are spread across the inventory and 6 shards")
+	validateInventoryShards(nt, 12001, 6)
+
+	nt.T.Log("Remove 7000 ConfigMaps")
+	for i := 5; i < 12; i++ {
+		nt.RootRepos[configsync.RootSyncName].Remove(fmt.Sprintf("acme/cms-%d", i))
+	}
+	nt.RootRepos[configsync.RootSyncName].CommitAndPush("Remove 7000 ConfigMaps")
+	start = time.Now()
+	nt.WaitForRepoSyncs(nomostest.WithTimeout(30 * time.Minute))
+	nt.T.Logf("Pruned 7000 objects in %v", time.Since(start))
+
+	nt.T.Log("Verify that the ConfigMaps are pruned, and the inventory has 2 shards left")
+	cmList := &corev1.ConfigMapList{}
+	if err := nt.Client.List(nt.Context, cmList, &client.ListOptions{Namespace: ns}, client.MatchingLabels{labelKey: labelValue}); err != nil {
+		nt.T.Fatal(err)
+	}
+	if len(cmList.Items) != 5000 {
+		nt.T.Errorf("The %s namespace should include 5000 ConfigMaps having the `%s: %s` label exactly, found %v instead", ns, labelKey, labelValue, len(cmList.Items))
+	}
+	validateInventoryShards(nt, 5001, 2)
+}
+
+// validateInventoryShards validates that the objects of the root-sync
+// inventory are spread across the ResourceGroup and the given number of shards.
+func validateInventoryShards(nt *nomostest.NT, objects, shards int) {
+	nt.T.Helper()
+	rgList := &unstructured.UnstructuredList{}
+	rgList.SetGroupVersionKind(live.ResourceGroupGVK.GroupVersion().WithKind(live.ResourceGroupGVK.Kind + "List"))
+	if err := nt.Client.List(nt.Context, rgList, client.InNamespace(configmanagement.ControllerNamespace)); err != nil {
+		nt.T.Fatal(err)
+	}
+	foundObjects := 0
+	foundShards := 0
+	for _, rg := range rgList.Items {
+		shardOf := rg.GetLabels()[metadata.InventoryShardOfLabel]
+		if rg.GetName() != configsync.RootSyncName && shardOf != configsync.RootSyncName {
+			continue
+		}
+		if shardOf != "" {
+			foundShards++
+		}
+		resources, _, err := unstructured.NestedSlice(rg.Object, "spec", "resources")
+		if err != nil {
+			nt.T.Fatal(err)
+		}
+		foundObjects += len(resources)
+	}
+	if foundShards != shards {
+		nt.T.Errorf("The inventory should have %d shards, found %d instead", shards, foundShards)
+	}
+	if foundObjects != objects {
+		nt.T.Errorf("The inventory and its shards should store %d objects, found %d instead", objects, foundObjects)
+	}
+}
+
 func truncateSourceErrors() nomostest.Predicate {
 	return func(o client.Object) error {
 		rs, ok := o.(*v1beta1.RootSync)
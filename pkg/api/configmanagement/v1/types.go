@@ -268,8 +268,28 @@ type NamespaceSelectorSpec struct {
 	// This field is NOT optional and follows standard label selector semantics. An empty selector
 	// matches all namespaces.
 	Selector metav1.LabelSelector `json:"selector"`
+
+	// Mode sets which Namespaces the selector is evaluated against.
+	// In static mode, the default, it selects the Namespaces declared in the repo.
+	// In dynamic mode, it also selects the Namespaces on the cluster, and the
+	// selection follows them as they are created, relabelled or deleted. Only
+	// unstructured repos synced by a RootSync support dynamic mode.
+	// +optional
+	Mode NamespaceSelectorMode `json:"mode,omitempty"`
 }
 
+// NamespaceSelectorMode sets which Namespaces a NamespaceSelector is evaluated
+// against.
+type NamespaceSelectorMode string
+
+const (
+	// NSSelectorStaticMode selects the Namespaces declared in the repo.
+	NSSelectorStaticMode NamespaceSelectorMode = "static"
+	// NSSelectorDynamicMode selects the Namespaces declared in the repo and the
+	// Namespaces on the cluster.
+	NSSelectorDynamicMode NamespaceSelectorMode = "dynamic"
+)
+
 // +kubebuilder:object:root=true
 
 // NamespaceSelectorList holds a list of NamespaceSelector resources.
@@ -66,8 +66,11 @@ const (
 	AuthCookieFile AuthType = "cookiefile"
 	// AuthNone indicates no auth token is required for Git or OCI or Helm.
 	AuthNone AuthType = "none"
-	// AuthToken indicates using a username/password to authenticate to Git or Helm. It doesn't apply to OCI.
+	// AuthToken indicates using a username/password to authenticate to Git or Helm or OCI.
 	AuthToken AuthType = "token"
+	// AuthDockerConfigJSON indicates using a dockerconfigjson file with per-registry
+	// credentials to authenticate to OCI. It doesn't apply to Git or Helm.
+	AuthDockerConfigJSON AuthType = "dockerconfigjson"
 	// AuthGCPServiceAccount indicates using a GCP service account to authenticate to
 	// Git or OCI or Helm, when GKE Workload Identity or Fleet Workload Identity is enabled.
 	AuthGCPServiceAccount AuthType = "gcpserviceaccount"
@@ -85,6 +85,22 @@ type Git struct {
 	// +nullable
 	// +optional
 	PrivateCertSecret SecretReference `json:"privateCertSecret,omitempty"`
+
+	// verification specifies the keys trusted to sign the commits of the Git
+	// repository. If set, a commit is only synced if it is signed by one of
+	// the keys.
+	// +optional
+	Verification *GitVerification `json:"verification,omitempty"`
+}
+
+// GitVerification references the keys trusted to sign the commits of a Git
+// repository. Every key of the referenced Secret holds either ASCII-armored
+// OpenPGP public keys, or SSH public keys in the allowed_signers format of
+// `ssh-keygen`.
+type GitVerification struct {
+	// secretRef is the Secret holding the trusted keys. The Secret must be in
+	// the same namespace as the RootSync or RepoSync.
+	SecretRef SecretReference `json:"secretRef"`
 }
 
 // SecretReference contains the reference to the secret used to connect to
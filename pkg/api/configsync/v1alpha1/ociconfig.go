@@ -45,14 +45,52 @@ type Oci struct {
 	Period metav1.Duration `json:"period,omitempty"`
 
 	// auth is the type of secret configured for access to the OCI package.
-	// Must be one of gcenode, gcpserviceaccount, or none.
+	// Must be one of gcenode, gcpserviceaccount, token, dockerconfigjson, or none.
 	// The validation of this is case-sensitive. Required.
 	//
-	// +kubebuilder:validation:Enum=gcenode;gcpserviceaccount;none
+	// +kubebuilder:validation:Enum=gcenode;gcpserviceaccount;token;dockerconfigjson;none
 	Auth configsync.AuthType `json:"auth"`
 
 	// gcpServiceAccountEmail specifies the GCP service account used to annotate
 	// the RootSync/RepoSync controller Kubernetes Service Account.
 	// Note: The field is used when secretType: gcpServiceAccount.
 	GCPServiceAccountEmail string `json:"gcpServiceAccountEmail,omitempty"`
+
+	// secretRef holds the authentication secret for accessing the OCI
+	// repository. It is required when auth is token or dockerconfigjson.
+	// With token, the Secret must have the `username` and `password` keys.
+	// With dockerconfigjson, the Secret must be of type
+	// kubernetes.io/dockerconfigjson, whose credentials are looked up by the
+	// registry of the image.
+	// +optional
+	SecretRef SecretReference `json:"secretRef,omitempty"`
+
+	// verification specifies the public keys used to verify the OCI image
+	// before it is synced. If set, a new image is only synced if its
+	// repository holds a cosign signature or attestation of the image digest
+	// that is signed by one of the keys.
+	// +optional
+	Verification *OciVerification `json:"verification,omitempty"`
+}
+
+// OciVerification references the PEM-encoded public keys used to verify the
+// signatures of an OCI image. Exactly one of secretRef or configMapRef must
+// be set. Every key of the referenced object holds one or more public keys.
+type OciVerification struct {
+	// secretRef is the Secret holding the public keys. The Secret must be in
+	// the same namespace as the RootSync or RepoSync.
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// configMapRef is the ConfigMap holding the public keys. The ConfigMap
+	// must be in the same namespace as the RootSync or RepoSync.
+	// +optional
+	ConfigMapRef *ConfigMapReference `json:"configMapRef,omitempty"`
+}
+
+// ConfigMapReference contains the reference to a ConfigMap.
+type ConfigMapReference struct {
+	// name represents the ConfigMap name.
+	// +optional
+	Name string `json:"name,omitempty"`
 }
@@ -71,6 +71,9 @@ const (
 	RepoSyncStalled RepoSyncConditionType = "Stalled"
 	// RepoSyncSyncing means that the namespace reconciler is processing a hash (git commit hash or OCI image digest).
 	RepoSyncSyncing RepoSyncConditionType = "Syncing"
+	// RepoSyncSuspended means that the changes of the source of truth are not
+	// applied, and the remediator is stopped, because spec.suspend is set.
+	RepoSyncSuspended RepoSyncConditionType = "Suspended"
 )
 
 // RepoSyncCondition describes the state of a RepoSync at a certain point.
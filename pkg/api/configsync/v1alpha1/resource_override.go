@@ -15,6 +15,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kpt.dev/configsync/pkg/api/configsync"
@@ -59,6 +60,112 @@ type OverrideSpec struct {
 	// support pulling remote bases from public repositories.
 	// +optional
 	EnableShellInRendering *bool `json:"enableShellInRendering,omitempty"`
+
+	// dryRun specifies whether to only compute the changes to the cluster
+	// instead of applying them. Default: false.
+	// The reconciler reads, renders and validates the source of truth as usual,
+	// and publishes the objects it would create, update or prune, and the ones
+	// it could not manage because of a conflict, in status.plan.
+	// +optional
+	DryRun *bool `json:"dryRun,omitempty"`
+	// auditOnly specifies whether the remediator only records the drift of the
+	// managed resources from their declarations instead of correcting it.
+	// Default: false.
+	// The drifted resources are published in status.drift. Changes to the
+	// source of truth are still applied, except to the drifted resources, which
+	// are left as they are on the cluster.
+	// +optional
+	AuditOnly *bool `json:"auditOnly,omitempty"`
+	// deletionBudgets limit the number of managed objects per kind which a
+	// single commit may delete, and which the remediator may delete between two
+	// commits. Exceeding a budget blocks the sync until the commit is approved
+	// with the configsync.gke.io/approve-deletions annotation, whose value is
+	// the approved commit.
+	// +optional
+	DeletionBudgets []DeletionBudget `json:"deletionBudgets,omitempty"`
+	// podTemplate allows one to override the scheduling, metadata, security
+	// context and environment of a reconciler pod, which are otherwise set by
+	// the reconciler-manager from its Deployment template.
+	// +optional
+	PodTemplate *PodTemplateOverride `json:"podTemplate,omitempty"`
+}
+
+// PodTemplateOverride allows to override the settings of the pod template of a
+// reconciler Deployment. The labels and environment variables set by Config
+// Sync can not be overridden.
+type PodTemplateOverride struct {
+	// labels are added to the labels of the reconciler pod, except for the
+	// labels it already has, which the Deployment selects the pod with.
+	// Keys must not use the configsync.gke.io or configmanagement.gke.io prefixes.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// annotations are added to the annotations of the reconciler pod.
+	// Keys must not use the configsync.gke.io or configmanagement.gke.io prefixes.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// nodeSelector constrains the nodes the reconciler pod can be scheduled on.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// tolerations allow the reconciler pod to be scheduled on tainted nodes.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// affinity sets the scheduling constraints of the reconciler pod.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// priorityClassName is the name of the PriorityClass of the reconciler pod.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// securityContext overrides the fields of the pod-level security context of
+	// the reconciler pod which it sets.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+	// env adds environment variables to the containers of the reconciler pod.
+	// +optional
+	Env []ContainerEnvSpec `json:"env,omitempty"`
+}
+
+// ContainerEnvSpec allows to add environment variables to a container
+type ContainerEnvSpec struct {
+	// containerName specifies the name of a container to add the environment variables to.
+	// Must be "reconciler", "git-sync", "hydration-controller", "oci-sync", or "helm-sync".
+	//
+	// +kubebuilder:validation:Pattern=^(reconciler|git-sync|hydration-controller|oci-sync|helm-sync)$
+	ContainerName string `json:"containerName"`
+	// env is the list of environment variables to add to the container.
+	// They must not set the variables which Config Sync may set on the container,
+	// or the ones prefixed with GIT_SYNC_, HELM_SYNC_ or OCI_SYNC_. The variables
+	// of RepoSyncs must not reference Secrets or ConfigMaps, which would be read
+	// from the config-management-system namespace.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// DeletionBudget limits the number of managed objects of a kind which may be
+// deleted at once. If both maxCount and maxPercent are set, exceeding either
+// of them exceeds the budget.
+type DeletionBudget struct {
+	// group of the objects the budget applies to. Empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// kind of the objects the budget applies to. "*" applies the budget to
+	// every kind which does not have a budget of its own.
+	Kind string `json:"kind"`
+	// maxCount is the maximum number of objects which may be deleted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxCount *int64 `json:"maxCount,omitempty"`
+	// maxPercent is the maximum percentage of the managed objects of the kind
+	// which may be deleted.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxPercent *int64 `json:"maxPercent,omitempty"`
 }
 
 // ContainerResourcesSpec allows to override the resource requirements for a container
@@ -90,3 +197,13 @@ func GetReconcileTimeout(d *metav1.Duration) string {
 	}
 	return d.Duration.String()
 }
+
+// GetDryRun returns whether dry-run mode is enabled, defaulting to false if unset.
+func GetDryRun(dryRun *bool) bool {
+	return dryRun != nil && *dryRun
+}
+
+// GetAuditOnly returns whether audit-only mode is enabled, defaulting to false if unset.
+func GetAuditOnly(auditOnly *bool) bool {
+	return auditOnly != nil && *auditOnly
+}
@@ -42,6 +42,33 @@ type RootSync struct {
 // RootSyncSpec defines the desired state of RootSync
 type RootSyncSpec struct {
 	Spec `json:",inline"`
+
+	// roleRefs is a list of Roles or ClusterRoles to bind to the root
+	// reconciler instead of the cluster-admin ClusterRole. A ClusterRole is
+	// bound with a ClusterRoleBinding, unless a namespace is specified, in which
+	// case it is bound with a RoleBinding in that namespace. A Role is bound with
+	// a RoleBinding in its namespace. When roleRefs is set, the reconciler is
+	// also bound to the configsync.gke.io:root-reconciler ClusterRole, which
+	// grants the permissions it needs to report its own status.
+	// +optional
+	RoleRefs []RootSyncRoleRef `json:"roleRefs,omitempty"`
+}
+
+// RootSyncRoleRef refers to a Role or ClusterRole to bind to the root
+// reconciler.
+type RootSyncRoleRef struct {
+	// kind of the role, either Role or ClusterRole.
+	// +kubebuilder:validation:Enum=Role;ClusterRole
+	Kind string `json:"kind"`
+
+	// name of the role.
+	Name string `json:"name"`
+
+	// namespace of the Role, or the namespace in which to bind the ClusterRole.
+	// Required for a Role. Optional for a ClusterRole, which is bound
+	// cluster-wide if it is not set.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // RootSyncStatus defines the observed state of RootSync
@@ -71,6 +98,9 @@ const (
 	RootSyncStalled RootSyncConditionType = "Stalled"
 	// RootSyncSyncing means that the root reconciler is processing a hash (git commit hash or OCI image digest).
 	RootSyncSyncing RootSyncConditionType = "Syncing"
+	// RootSyncSuspended means that the changes of the source of truth are not
+	// applied, and the remediator is stopped, because spec.suspend is set.
+	RootSyncSuspended RootSyncConditionType = "Suspended"
 )
 
 // ErrorSource indicates the origination of errors.
@@ -83,6 +113,8 @@ const (
 	SourceError ErrorSource = "status.source.errors"
 	// SyncError indicates the errors are from the `status.sync.errors` field.
 	SyncError ErrorSource = "status.sync.errors"
+	// PlanError indicates the errors are from the `status.plan.errors` field.
+	PlanError ErrorSource = "status.plan.errors"
 )
 
 // RootSyncCondition describes the state of a RootSync at a certain point.
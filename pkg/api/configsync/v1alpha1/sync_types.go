@@ -55,6 +55,23 @@ type Spec struct {
 	// +nullable
 	// +optional
 	Override OverrideSpec `json:"override,omitempty"`
+
+	// syncWindows restrict when the changes of the source of truth are applied.
+	// Outside of the windows, new commits are still fetched and validated, but
+	// they are only applied when the next window opens. A change is applied if
+	// no Block window is active and, if there are Allow windows, one of them is
+	// active. A commit may be applied immediately by annotating the object with
+	// configsync.gke.io/bypass-sync-window, whose value is the commit.
+	// +optional
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// suspend stops applying the changes of the source of truth, including
+	// pruning, and stops the remediator. The source of truth is still fetched
+	// and validated, and the status is still reported. The Suspended condition
+	// reports the commit which has not been applied yet. Resuming triggers a
+	// full resync. Default: false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // Status provides a common type that is embedded in RepoSyncStatus and RootSyncStatus.
@@ -88,6 +105,21 @@ type Status struct {
 	// source of truth to the cluster.
 	// +optional
 	Sync SyncStatus `json:"sync,omitempty"`
+
+	// plan contains fields describing the changes which would be made to the
+	// cluster to sync the resources from the source of truth.
+	// It is only set when spec.override.dryRun is true.
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
+	// drift contains fields describing the managed resources which have
+	// drifted from their declarations.
+	// It is only set when spec.override.auditOnly is true.
+	// +optional
+	Drift *DriftStatus `json:"drift,omitempty"`
+	// history lists the most recently synced commits, newest first, with the
+	// outcome of their sync. It is bounded to the last 10 commits.
+	// +optional
+	History []SyncHistoryEntry `json:"history,omitempty"`
 }
 
 // SourceStatus describes the source status of a source-of-truth.
@@ -122,6 +154,23 @@ type SourceStatus struct {
 	// errorSummary summarizes the errors encountered during the process of reading from the source of truth.
 	// +optional
 	ErrorSummary *ErrorSummary `json:"errorSummary,omitempty"`
+
+	// verification describes the signature of the last verified commit, when
+	// spec.git.verification is set.
+	// +optional
+	Verification *SourceVerificationStatus `json:"verification,omitempty"`
+}
+
+// SourceVerificationStatus describes the signature of a verified commit.
+type SourceVerificationStatus struct {
+	// signer identifies the trusted key which signed the commit.
+	// +optional
+	Signer string `json:"signer,omitempty"`
+
+	// lastVerifiedCommit is the last commit whose signature was verified. It
+	// is still synced while a newer commit fails the verification.
+	// +optional
+	LastVerifiedCommit string `json:"lastVerifiedCommit,omitempty"`
 }
 
 // RenderingStatus describes the status of rendering the source DRY configs to the WET format.
@@ -194,6 +243,210 @@ type SyncStatus struct {
 	// errorSummary summarizes the errors encountered during the process of syncing the resources.
 	// +optional
 	ErrorSummary *ErrorSummary `json:"errorSummary,omitempty"`
+
+	// namespaceSelectors lists the Namespaces selected by each NamespaceSelector
+	// in dynamic mode, which the objects referencing it are copied into.
+	// +optional
+	NamespaceSelectors []NamespaceSelectorStatus `json:"namespaceSelectors,omitempty"`
+}
+
+// NamespaceSelectorStatus describes the Namespaces selected by a
+// NamespaceSelector in dynamic mode.
+type NamespaceSelectorStatus struct {
+	// name is the name of the NamespaceSelector.
+	Name string `json:"name"`
+
+	// namespaces are the names of the selected Namespaces.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// SyncOutcome is the outcome of the sync of a commit.
+type SyncOutcome string
+
+const (
+	// SyncInProgress indicates that the commit is being synced.
+	SyncInProgress SyncOutcome = "InProgress"
+	// SyncSucceeded indicates that the commit was synced without errors.
+	SyncSucceeded SyncOutcome = "Succeeded"
+	// SyncFailed indicates that the sync of the commit completed with errors.
+	SyncFailed SyncOutcome = "Failed"
+)
+
+// SyncHistoryEntry describes the sync of a commit from the source of truth.
+type SyncHistoryEntry struct {
+	// commit is the hash of the synced source of truth.
+	// It can be a git commit hash, an OCI image digest, or a Helm chart version.
+	Commit string `json:"commit"`
+
+	// gitStatus contains fields describing the Git source of truth of the commit.
+	// +optional
+	Git *GitStatus `json:"gitStatus,omitempty"`
+
+	// ociStatus contains fields describing the OCI source of truth of the commit.
+	// +optional
+	Oci *OciStatus `json:"ociStatus,omitempty"`
+
+	// helmStatus contains fields describing the Helm source of truth of the commit.
+	// +optional
+	Helm *HelmStatus `json:"helmStatus,omitempty"`
+
+	// outcome is the outcome of the sync of the commit.
+	// Must be one of InProgress, Succeeded, Failed.
+	Outcome SyncOutcome `json:"outcome"`
+
+	// errorCount is the number of errors of the last sync of the commit.
+	// +optional
+	ErrorCount int `json:"errorCount,omitempty"`
+
+	// startTime is the timestamp of when the sync of the commit started.
+	// +nullable
+	// +optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// completionTime is the timestamp of when the last sync of the commit
+	// completed. It is not set while the first sync is in progress.
+	// +nullable
+	// +optional
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PlanStatus describes the changes which would be made to the cluster to sync
+// the resources from a source-of-truth.
+type PlanStatus struct {
+	// hash of the source of truth that is planned.
+	// It can be a git commit hash, or an OCI image digest.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// lastUpdate is the timestamp of when this status was last updated by a
+	// reconciler.
+	// +nullable
+	// +optional
+	LastUpdate metav1.Time `json:"lastUpdate,omitempty"`
+
+	// summary counts the planned changes by action.
+	// +optional
+	Summary PlanSummary `json:"summary,omitempty"`
+
+	// changes is a list of the objects which would be changed.
+	// +optional
+	Changes []PlanChange `json:"changes,omitempty"`
+
+	// truncated indicates whether the `Changes` field includes all the changes.
+	// If `true`, the `Changes` field does not include all the changes, which
+	// are still counted in the `Summary` field.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+
+	// errors is a list of any errors that occurred while computing the changes.
+	// +optional
+	Errors []ConfigSyncError `json:"errors,omitempty"`
+
+	// errorSummary summarizes the errors encountered during the process of computing the changes.
+	// +optional
+	ErrorSummary *ErrorSummary `json:"errorSummary,omitempty"`
+}
+
+// PlanSummary counts the planned changes by action.
+type PlanSummary struct {
+	// create is the number of objects which would be created.
+	Create int `json:"create,omitempty"`
+	// update is the number of objects which would be updated.
+	Update int `json:"update,omitempty"`
+	// prune is the number of objects which would be deleted.
+	Prune int `json:"prune,omitempty"`
+	// conflict is the number of objects which would not be applied because
+	// they are managed by another reconciler.
+	Conflict int `json:"conflict,omitempty"`
+}
+
+// PlanAction is the change which would be made to an object.
+type PlanAction string
+
+const (
+	// PlanActionCreate indicates that the object would be created.
+	PlanActionCreate PlanAction = "Create"
+	// PlanActionUpdate indicates that the object would be updated.
+	PlanActionUpdate PlanAction = "Update"
+	// PlanActionPrune indicates that the object would be deleted.
+	PlanActionPrune PlanAction = "Prune"
+	// PlanActionConflict indicates that the object would not be applied
+	// because it is managed by another reconciler.
+	PlanActionConflict PlanAction = "Conflict"
+)
+
+// PlanChange describes the change which would be made to a single object.
+type PlanChange struct {
+	// action is the change which would be made to the object.
+	// Must be one of Create, Update, Prune, Conflict.
+	Action PlanAction `json:"action"`
+
+	// resource identifies the object.
+	Resource ResourceRef `json:"resource"`
+}
+
+// DriftStatus describes the managed resources which have drifted from their
+// declarations in the source-of-truth.
+type DriftStatus struct {
+	// lastUpdate is the timestamp of when this status was last updated by a
+	// reconciler.
+	// +nullable
+	// +optional
+	LastUpdate metav1.Time `json:"lastUpdate,omitempty"`
+
+	// count is the number of drifted resources.
+	// +optional
+	Count int `json:"count,omitempty"`
+
+	// resources is a list of the drifted resources.
+	// +optional
+	Resources []DriftedResource `json:"resources,omitempty"`
+
+	// truncated indicates whether the `Resources` field includes all the
+	// drifted resources. If `true`, the `Resources` field does not include all
+	// the drifted resources, which are still counted in the `Count` field.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DriftType is the kind of drift of a resource.
+type DriftType string
+
+const (
+	// DriftModified indicates that the resource differs from its declaration.
+	DriftModified DriftType = "Modified"
+	// DriftDeleted indicates that the declared resource was deleted from the
+	// cluster.
+	DriftDeleted DriftType = "Deleted"
+	// DriftUndeclared indicates that the resource is managed by the reconciler
+	// but is not declared in the source-of-truth.
+	DriftUndeclared DriftType = "Undeclared"
+)
+
+// DriftedResource describes the drift of a single resource.
+type DriftedResource struct {
+	// type is the kind of drift.
+	// Must be one of Modified, Deleted, Undeclared.
+	Type DriftType `json:"type"`
+
+	// resource identifies the resource.
+	Resource ResourceRef `json:"resource"`
+
+	// fields is a list of the paths of the declared fields which differ from
+	// the resource in the cluster, e.g. `spec.replicas`.
+	// It is only set for Modified resources.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	// actor is the field manager which last changed the resource, if known.
+	// +optional
+	Actor string `json:"actor,omitempty"`
+
+	// detectedAt is the timestamp of when the drift was first detected.
+	// +nullable
+	// +optional
+	DetectedAt metav1.Time `json:"detectedAt,omitempty"`
 }
 
 // GitStatus describes the status of a Git source of truth.
@@ -227,7 +480,8 @@ type HelmStatus struct {
 	// repo is the helm repository URL being synced from.
 	Repo string `json:"repo"`
 
-	// version is the helm chart version being fetched.
+	// version is the concrete helm chart version being fetched, which is
+	// resolved from spec.helm.version if that is a constraint or "latest".
 	Version string `json:"version"`
 
 	// chart is the name of helm chart being fetched
@@ -28,7 +28,10 @@ type Helm struct {
 	// chart is a Helm chart name. Required.
 	Chart string `json:"chart"`
 
-	// version is the chart version. If this is not specified, the latest version is used
+	// version is the chart version. It can be an exact version, or a semantic
+	// version constraint like "~1.4" or ">=2.0.0 <3", which is resolved to the
+	// highest matching version on every sync. If this is not specified or
+	// is "latest", the latest stable version is used.
 	// +optional
 	Version string `json:"version,omitempty"`
 
@@ -45,8 +48,8 @@ type Helm struct {
 	Values *unstructured.Unstructured `json:"values,omitempty"`
 
 	// valuesFiles is a list of path to Helm value files.
-	// Values files must be in the same repository with the Helm chart.
-	// And the paths here are absolute path from the root directory of the repository
+	// Values files must be shipped inside the Helm chart, and the paths here
+	// are relative to the root directory of the chart.
 	// +optional
 	ValuesFiles []string `json:"valuesFiles,omitempty"`
 
@@ -59,7 +62,8 @@ type Helm struct {
 	// period is the time duration between consecutive syncs. Default: 15s.
 	// Use string to specify this field value, like "30s", "5m".
 	// More details about valid inputs: https://pkg.go.dev/time#ParseDuration.
-	// Chart will not be re-synced if version is specified and it is not "latest"
+	// A version constraint is resolved again on every sync, while the chart is
+	// only rendered again if the resolved version or values change.
 	// +optional
 	Period metav1.Duration `json:"period,omitempty"`
 
@@ -77,6 +77,23 @@ type RepoSyncSpec struct {
 	// +nullable
 	// +optional
 	Override OverrideSpec `json:"override,omitempty"`
+
+	// syncWindows restrict when the changes of the source of truth are applied.
+	// Outside of the windows, new commits are still fetched and validated, but
+	// they are only applied when the next window opens. A change is applied if
+	// no Block window is active and, if there are Allow windows, one of them is
+	// active. A commit may be applied immediately by annotating the object with
+	// configsync.gke.io/bypass-sync-window, whose value is the commit.
+	// +optional
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// suspend stops applying the changes of the source of truth, including
+	// pruning, and stops the remediator. The source of truth is still fetched
+	// and validated, and the status is still reported. The Suspended condition
+	// reports the commit which has not been applied yet. Resuming triggers a
+	// full resync. Default: false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // RepoSyncStatus defines the observed state of a RepoSync.
@@ -106,6 +123,9 @@ const (
 	RepoSyncStalled RepoSyncConditionType = "Stalled"
 	// RepoSyncSyncing means that the namespace reconciler is processing a hash (git commit hash or OCI image digest).
 	RepoSyncSyncing RepoSyncConditionType = "Syncing"
+	// RepoSyncSuspended means that the changes of the source of truth are not
+	// applied, and the remediator is stopped, because spec.suspend is set.
+	RepoSyncSuspended RepoSyncConditionType = "Suspended"
 )
 
 // ErrorSource indicates the origination of errors.
@@ -118,6 +138,8 @@ const (
 	SourceError ErrorSource = "status.source.errors"
 	// SyncError indicates the errors are from the `status.sync.errors` field.
 	SyncError ErrorSource = "status.sync.errors"
+	// PlanError indicates the errors are from the `status.plan.errors` field.
+	PlanError ErrorSource = "status.plan.errors"
 )
 
 // RepoSyncCondition describes the state of a RepoSync at a certain point.
@@ -77,6 +77,50 @@ type RootSyncSpec struct {
 	// +nullable
 	// +optional
 	Override OverrideSpec `json:"override,omitempty"`
+
+	// syncWindows restrict when the changes of the source of truth are applied.
+	// Outside of the windows, new commits are still fetched and validated, but
+	// they are only applied when the next window opens. A change is applied if
+	// no Block window is active and, if there are Allow windows, one of them is
+	// active. A commit may be applied immediately by annotating the object with
+	// configsync.gke.io/bypass-sync-window, whose value is the commit.
+	// +optional
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// suspend stops applying the changes of the source of truth, including
+	// pruning, and stops the remediator. The source of truth is still fetched
+	// and validated, and the status is still reported. The Suspended condition
+	// reports the commit which has not been applied yet. Resuming triggers a
+	// full resync. Default: false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// roleRefs is a list of Roles or ClusterRoles to bind to the root
+	// reconciler instead of the cluster-admin ClusterRole. A ClusterRole is
+	// bound with a ClusterRoleBinding, unless a namespace is specified, in which
+	// case it is bound with a RoleBinding in that namespace. A Role is bound with
+	// a RoleBinding in its namespace. When roleRefs is set, the reconciler is
+	// also bound to the configsync.gke.io:root-reconciler ClusterRole, which
+	// grants the permissions it needs to report its own status.
+	// +optional
+	RoleRefs []RootSyncRoleRef `json:"roleRefs,omitempty"`
+}
+
+// RootSyncRoleRef refers to a Role or ClusterRole to bind to the root
+// reconciler.
+type RootSyncRoleRef struct {
+	// kind of the role, either Role or ClusterRole.
+	// +kubebuilder:validation:Enum=Role;ClusterRole
+	Kind string `json:"kind"`
+
+	// name of the role.
+	Name string `json:"name"`
+
+	// namespace of the Role, or the namespace in which to bind the ClusterRole.
+	// Required for a Role. Optional for a ClusterRole, which is bound
+	// cluster-wide if it is not set.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // RootSyncStatus defines the observed state of RootSync
@@ -106,6 +150,9 @@ const (
 	RootSyncStalled RootSyncConditionType = "Stalled"
 	// RootSyncSyncing means that the root reconciler is processing a hash (git commit hash or OCI image digest).
 	RootSyncSyncing RootSyncConditionType = "Syncing"
+	// RootSyncSuspended means that the changes of the source of truth are not
+	// applied, and the remediator is stopped, because spec.suspend is set.
+	RootSyncSuspended RootSyncConditionType = "Suspended"
 )
 
 // RootSyncCondition describes the state of a RootSync at a certain point.
@@ -0,0 +1,51 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SyncWindowType is the type of a SyncWindow.
+type SyncWindowType string
+
+const (
+	// AllowSyncWindow only allows the changes to be applied during the window.
+	AllowSyncWindow SyncWindowType = "Allow"
+	// BlockSyncWindow prevents the changes from being applied during the window.
+	BlockSyncWindow SyncWindowType = "Block"
+)
+
+// SyncWindow is a recurring period of time during which the changes of the
+// source of truth may, or may not, be applied.
+type SyncWindow struct {
+	// type of the window, either Allow or Block. Default: Allow.
+	// +kubebuilder:validation:Enum=Allow;Block
+	// +optional
+	Type SyncWindowType `json:"type,omitempty"`
+
+	// schedule is a cron expression with five fields (minute, hour, day of
+	// month, month, day of week) at which the window starts, such as
+	// "0 22 * * 1-5".
+	Schedule string `json:"schedule"`
+
+	// duration of the window, such as "8h".
+	Duration metav1.Duration `json:"duration"`
+
+	// timeZone is the IANA time zone of the schedule, such as
+	// "America/New_York". Default: UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
@@ -52,8 +52,16 @@ func SkipErrorForResource(err error, id core.ID, strategy actuation.ActuationStr
 		strings.ToLower(strategy.String()), id, err)).Build()
 }
 
+// PermissionErrorForResource indicates that the reconciler is not allowed to
+// apply or prune the given resource.
+func PermissionErrorForResource(err error, id core.ID, strategy actuation.ActuationStrategy) status.Error {
+	return status.InsufficientPermissionError(err, fmt.Sprintf("%s %v", strings.ToLower(strategy.String()), id), id.GroupKind)
+}
+
 func largeResourceGroupError(err error, id core.ID) status.Error {
-	e := fmt.Errorf("too many declared resources causing %v failed"+
-		"to be applied: %s. To fix, split the resources into multiple repositories.", id, err)
+	e := fmt.Errorf("too many declared resources causing %v or one of its shards failed "+
+		"to be applied: %s. Each shard stores at most %d objects, so the declared resources "+
+		"likely have unusually long names. To fix, shorten them or split the resources into multiple repositories.",
+		id, err, defaultInventoryShardSize)
 	return applierErrorBuilder.Wrap(e).Build()
 }
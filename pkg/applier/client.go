@@ -20,7 +20,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	"kpt.dev/configsync/pkg/api/configsync"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
@@ -47,6 +49,25 @@ func (uc *resourceClient) get(ctx context.Context, meta object.ObjMetadata) (*un
 	return r.Get(ctx, meta.Name, metav1.GetOptions{})
 }
 
+// dryRunApply server-side applies obj in dry-run mode, and returns the object
+// which the API server would persist.
+func (uc *resourceClient) dryRunApply(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	r, err := uc.resourceInterface(objMetaFrom(obj))
+	if err != nil {
+		return nil, err
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	force := true
+	return r.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		Force:        &force,
+		FieldManager: configsync.FieldManager,
+	})
+}
+
 func (uc *resourceClient) resourceInterface(meta object.ObjMetadata) (dynamic.ResourceInterface, error) {
 	mapping, err := uc.restMapper.RESTMapping(meta.GroupKind)
 	if err != nil {
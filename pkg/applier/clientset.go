@@ -61,10 +61,11 @@ func newClientSet(c client.Client, configFlags *genericclioptions.ConfigFlags, s
 		klog.Infof("Disabled status reporting")
 		statusPolicy = inventory.StatusPolicyNone
 	}
-	invClient, err := inventory.NewClient(f, live.WrapInventoryObj, live.InvToUnstructuredFunc, statusPolicy)
+	clusterClient, err := inventory.NewClient(f, live.WrapInventoryObj, live.InvToUnstructuredFunc, statusPolicy)
 	if err != nil {
 		return nil, err
 	}
+	invClient := newShardedInventoryClient(clusterClient, c, statusPolicy)
 
 	builder := apply.NewApplierBuilder()
 	applier, err := builder.WithInventoryClient(invClient).WithFactory(f).Build()
@@ -130,12 +131,8 @@ func (cs *clientSet) removeFromInventory(rg *live.InventoryResourceGroup, objs [
 		// If inventory does not exist, there is nothing to remove
 		return nil
 	}
-	wrappedInv, err := wrapInventoryObj(clusterInv)
-	if err != nil {
-		return err
-	}
-
-	oldObjs, err := wrappedInv.Load()
+	// The objects may be stored in the shards of the inventory.
+	oldObjs, err := cs.invClient.GetClusterObjs(rg)
 	if err != nil {
 		return err
 	}
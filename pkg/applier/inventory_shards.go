@@ -0,0 +1,403 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultInventoryShardSize is the maximum number of objects stored in a
+// single ResourceGroup of an inventory. With typical object names, a full
+// shard including the object statuses stays well below maxRequestBytes.
+const defaultInventoryShardSize = 2000
+
+// inventoryShardBytes is the maximum estimated size of the objects stored in
+// a single ResourceGroup of an inventory. It only limits the shards of objects
+// with unusually long names: 2000 objects with names and namespaces of the
+// maximum length would exceed maxRequestBytes.
+var inventoryShardBytes = maxRequestBytes * 2 / 5
+
+// inventoryObjectOverheadBytes is the size of an object stored in a
+// ResourceGroup, in its spec and its status, besides its group, kind,
+// namespace and name.
+const inventoryObjectOverheadBytes = 200
+
+// shardedInventoryClient is an inventory.Client which spreads the objects of
+// an inventory across multiple ResourceGroups, so that the inventory of a
+// large repo does not exceed the maximum object size.
+//
+// The first shardSize objects are stored in the inventory ResourceGroup
+// itself, which cli-utils and the status aggregation know about. The remaining
+// objects are stored in the shards <name>-shard-<index>, which are labeled with
+// metadata.InventoryShardOfLabel and owned by the inventory ResourceGroup.
+// The shards keep the inventory id of the inventory ResourceGroup, which the
+// synced objects reference in their owning-inventory annotation, so that the
+// ResourceGroup controller does not report them as owned by another inventory.
+// Objects are sorted before sharding, so that they stay in the same shard
+// unless objects are added or removed before them.
+type shardedInventoryClient struct {
+	inventory.Client
+	client       client.Client
+	statusPolicy inventory.StatusPolicy
+	shardSize    int
+	shardBytes   int64
+}
+
+var _ inventory.Client = &shardedInventoryClient{}
+
+func newShardedInventoryClient(inner inventory.Client, c client.Client, statusPolicy inventory.StatusPolicy) *shardedInventoryClient {
+	return &shardedInventoryClient{
+		Client:       inner,
+		client:       c,
+		statusPolicy: statusPolicy,
+		shardSize:    defaultInventoryShardSize,
+		shardBytes:   inventoryShardBytes,
+	}
+}
+
+// GetClusterObjs returns the objects stored in the inventory and its shards.
+func (sic *shardedInventoryClient) GetClusterObjs(inv inventory.Info) (object.ObjMetadataSet, error) {
+	return clusterInventoryObjMetas(context.TODO(), sic.client, inv)
+}
+
+// Merge stores the union of objs and the objects in the inventory, and returns
+// the objects in the inventory which are not in objs. It creates the
+// inventory if it does not exist.
+func (sic *shardedInventoryClient) Merge(inv inventory.Info, objs object.ObjMetadataSet, dryRun common.DryRunStrategy) (object.ObjMetadataSet, error) {
+	clusterObjs, err := sic.GetClusterObjs(inv)
+	if err != nil {
+		return nil, err
+	}
+	pruneIds := clusterObjs.Diff(objs)
+	if dryRun.ClientOrServerDryRun() {
+		return pruneIds, nil
+	}
+	unionObjs := clusterObjs.Union(objs)
+	var objStatus []actuation.ObjectStatus
+	if sic.statusPolicy == inventory.StatusPolicyAll {
+		objStatus = pendingObjectStatus(pruneIds, unionObjs)
+	}
+	klog.V(4).Infof("num objects to prune: %d", len(pruneIds))
+	klog.V(4).Infof("num merged objects to store in inventory: %d", len(unionObjs))
+	return pruneIds, sic.store(context.TODO(), inv, unionObjs, objStatus)
+}
+
+// Replace stores objs and their status in the inventory.
+func (sic *shardedInventoryClient) Replace(inv inventory.Info, objs object.ObjMetadataSet, objStatus []actuation.ObjectStatus, dryRun common.DryRunStrategy) error {
+	if dryRun.ClientOrServerDryRun() {
+		klog.V(4).Infoln("dry-run replace inventory object: not applied")
+		return nil
+	}
+	if sic.statusPolicy == inventory.StatusPolicyNone {
+		objStatus = nil
+	}
+	return sic.store(context.TODO(), inv, objs, objStatus)
+}
+
+// DeleteInventoryObj deletes the shards of the inventory, and the inventory.
+func (sic *shardedInventoryClient) DeleteInventoryObj(inv inventory.Info, dryRun common.DryRunStrategy) error {
+	if !dryRun.ClientOrServerDryRun() {
+		if err := sic.deleteShards(context.TODO(), inv, 0); err != nil {
+			return err
+		}
+	}
+	return sic.Client.DeleteInventoryObj(inv, dryRun)
+}
+
+// store writes the sharded objs and their status to the inventory and its
+// shards, and deletes the shards which are no longer needed.
+func (sic *shardedInventoryClient) store(ctx context.Context, inv inventory.Info, objs object.ObjMetadataSet, objStatus []actuation.ObjectStatus) error {
+	chunks := sic.chunk(objs)
+	primary, err := sic.write(ctx, live.InvToUnstructuredFunc(inv).DeepCopy(), chunks[0], objStatus)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(chunks); i++ {
+		if _, err := sic.write(ctx, shardTemplate(primary, i), chunks[i], objStatus); err != nil {
+			return err
+		}
+	}
+	if len(chunks) > 1 {
+		klog.V(3).Infof("Stored %d objects in inventory %s/%s and %d shards",
+			len(objs), inv.Namespace(), inv.Name(), len(chunks)-1)
+	}
+	return sic.deleteShards(ctx, inv, len(chunks))
+}
+
+// chunk sorts objs and splits them into chunks of at most shardSize objects,
+// whose estimated size is at most shardBytes. It always returns at least one,
+// possibly empty, chunk for the inventory.
+func (sic *shardedInventoryClient) chunk(objs object.ObjMetadataSet) []object.ObjMetadataSet {
+	sorted := make(object.ObjMetadataSet, len(objs))
+	copy(sorted, objs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+	chunks := []object.ObjMetadataSet{nil}
+	var chunkBytes int64
+	for _, obj := range sorted {
+		objBytes := inventoryObjectBytes(obj)
+		last := chunks[len(chunks)-1]
+		if len(last) >= sic.shardSize || (len(last) > 0 && chunkBytes+objBytes > sic.shardBytes) {
+			chunks = append(chunks, nil)
+			chunkBytes = 0
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], obj)
+		chunkBytes += objBytes
+	}
+	return chunks
+}
+
+// inventoryObjectBytes estimates the size of obj stored in a ResourceGroup.
+// Its identifiers are stored in both the spec and the status.
+func inventoryObjectBytes(obj object.ObjMetadata) int64 {
+	return int64(2*(len(obj.GroupKind.Group)+len(obj.GroupKind.Kind)+len(obj.Namespace)+len(obj.Name)) +
+		inventoryObjectOverheadBytes)
+}
+
+// write stores objs and their status in the ResourceGroup of the template,
+// creating it if it does not exist. It returns the ResourceGroup as written.
+func (sic *shardedInventoryClient) write(ctx context.Context, template *unstructured.Unstructured, objs object.ObjMetadataSet, objStatus []actuation.ObjectStatus) (*unstructured.Unstructured, error) {
+	existing := template.DeepCopy()
+	err := sic.client.Get(ctx, client.ObjectKeyFromObject(template), existing)
+	found := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if !found {
+		existing = template
+	} else {
+		// Keep the metadata of the template, e.g. to adopt a shard left behind
+		// by a deleted inventory.
+		for k, v := range template.GetLabels() {
+			core.SetLabel(existing, k, v)
+		}
+		for k, v := range template.GetAnnotations() {
+			core.SetAnnotation(existing, k, v)
+		}
+		if len(template.GetOwnerReferences()) > 0 {
+			existing.SetOwnerReferences(template.GetOwnerReferences())
+		}
+	}
+
+	wrapped, err := wrapInventoryObj(existing)
+	if err != nil {
+		return nil, err
+	}
+	if err := wrapped.Store(objs, objStatus); err != nil {
+		return nil, err
+	}
+	rg, err := wrapped.GetObject()
+	if err != nil {
+		return nil, err
+	}
+	// Create and Update ignore the status, so it is written separately.
+	rgStatus := rg.Object["status"]
+	if found {
+		klog.V(4).Infof("update inventory %s/%s with %d objects", rg.GetNamespace(), rg.GetName(), len(objs))
+		err = sic.client.Update(ctx, rg)
+	} else {
+		klog.V(4).Infof("create inventory %s/%s with %d objects", rg.GetNamespace(), rg.GetName(), len(objs))
+		err = sic.client.Create(ctx, rg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sic.statusPolicy == inventory.StatusPolicyAll && rgStatus != nil {
+		rg.Object["status"] = rgStatus
+		if err := sic.client.Status().Update(ctx, rg); err != nil {
+			return nil, err
+		}
+	}
+	return rg, nil
+}
+
+// clusterInventoryObjMetas returns the objects stored in the inventory and its
+// shards.
+func clusterInventoryObjMetas(ctx context.Context, c client.Reader, inv inventory.Info) (object.ObjMetadataSet, error) {
+	rgs, err := clusterInventoryObjs(ctx, c, inv)
+	if err != nil {
+		return nil, err
+	}
+	var objs object.ObjMetadataSet
+	for _, rg := range rgs {
+		wrapped, err := wrapInventoryObj(rg)
+		if err != nil {
+			return nil, err
+		}
+		rgObjs, err := wrapped.Load()
+		if err != nil {
+			return nil, err
+		}
+		objs = objs.Union(rgObjs)
+	}
+	return objs, nil
+}
+
+// InventoryObjects returns the IDs of the objects stored in the inventory of
+// the RootSync or RepoSync with the given name and namespace, including its
+// shards. It returns nothing if the inventory does not exist.
+func InventoryObjects(ctx context.Context, c client.Reader, syncName, syncNamespace string) ([]core.ID, error) {
+	inv, err := wrapInventoryObj(newInventoryUnstructured(syncName, syncNamespace, ""))
+	if err != nil {
+		return nil, err
+	}
+	objs, err := clusterInventoryObjMetas(ctx, c, inv)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]core.ID, len(objs))
+	for i, obj := range objs {
+		ids[i] = idFrom(obj)
+	}
+	return ids, nil
+}
+
+// clusterInventoryObjs returns the inventory and its shards, or nothing if the
+// inventory does not exist.
+func clusterInventoryObjs(ctx context.Context, c client.Reader, inv inventory.Info) ([]*unstructured.Unstructured, error) {
+	rg := live.InvToUnstructuredFunc(inv).DeepCopy()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(rg), rg); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read inventory from cluster: %w", err)
+	}
+	shards, err := listShards(ctx, c, inv)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*unstructured.Unstructured{rg}, shards...), nil
+}
+
+// listShards returns the shards of the inventory, sorted by their index.
+func listShards(ctx context.Context, c client.Reader, inv inventory.Info) ([]*unstructured.Unstructured, error) {
+	rgList := &unstructured.UnstructuredList{}
+	rgList.SetGroupVersionKind(live.ResourceGroupGVK.GroupVersion().WithKind(live.ResourceGroupGVK.Kind + "List"))
+	if err := c.List(ctx, rgList, client.InNamespace(inv.Namespace()),
+		client.MatchingLabels{metadata.InventoryShardOfLabel: inv.Name()}); err != nil {
+		return nil, fmt.Errorf("failed to list inventory shards from cluster: %w", err)
+	}
+	var shards []*unstructured.Unstructured
+	for i := range rgList.Items {
+		if shardIndex(inv.Name(), rgList.Items[i].GetName()) > 0 {
+			shards = append(shards, &rgList.Items[i])
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shardIndex(inv.Name(), shards[i].GetName()) < shardIndex(inv.Name(), shards[j].GetName())
+	})
+	return shards, nil
+}
+
+// deleteShards deletes the shards of the inventory with an index of at least
+// from.
+func (sic *shardedInventoryClient) deleteShards(ctx context.Context, inv inventory.Info, from int) error {
+	shards, err := listShards(ctx, sic.client, inv)
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		if shardIndex(inv.Name(), shard.GetName()) < from {
+			continue
+		}
+		klog.V(3).Infof("Deleting inventory shard %s/%s", shard.GetNamespace(), shard.GetName())
+		if err := sic.client.Delete(ctx, shard); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardTemplate returns the shard with the given index of the inventory
+// ResourceGroup primary.
+func shardTemplate(primary *unstructured.Unstructured, index int) *unstructured.Unstructured {
+	name := shardName(primary.GetName(), index)
+	u := newInventoryUnstructured(name, primary.GetNamespace(), primary.GetAnnotations()[StatusModeKey])
+	// The shard belongs to the RootSync or RepoSync of the inventory, and is
+	// part of the same inventory.
+	core.SetLabel(u, metadata.SyncNameLabel, primary.GetLabels()[metadata.SyncNameLabel])
+	core.SetLabel(u, common.InventoryLabel, primary.GetLabels()[common.InventoryLabel])
+	core.SetLabel(u, metadata.InventoryShardOfLabel, primary.GetName())
+	u.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: primary.GetAPIVersion(),
+		Kind:       primary.GetKind(),
+		Name:       primary.GetName(),
+		UID:        primary.GetUID(),
+	}})
+	return u
+}
+
+// shardName returns the name of the shard with the given index of the
+// inventory with the given name.
+func shardName(name string, index int) string {
+	return fmt.Sprintf("%s-shard-%d", name, index)
+}
+
+// shardIndex returns the index of the shard with the given name of the
+// inventory with the given name, or 0 if it is not a shard of the inventory.
+func shardIndex(name, shard string) int {
+	index, err := strconv.Atoi(strings.TrimPrefix(shard, name+"-shard-"))
+	if err != nil || index < 1 || shardName(name, index) != shard {
+		return 0
+	}
+	return index
+}
+
+// IsInventoryShard returns true if shard is the name of a shard of the
+// inventory with the given name.
+func IsInventoryShard(name, shard string) bool {
+	return shardIndex(name, shard) > 0
+}
+
+// pendingObjectStatus returns the status of the objects at the beginning of an
+// apply, like the cli-utils inventory client.
+func pendingObjectStatus(pruneIds, unionIds object.ObjMetadataSet) []actuation.ObjectStatus {
+	var objStatus []actuation.ObjectStatus
+	for _, obj := range unionIds {
+		objStatus = append(objStatus, actuation.ObjectStatus{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(obj),
+			Strategy:        actuation.ActuationStrategyApply,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		})
+	}
+	for _, obj := range pruneIds {
+		objStatus = append(objStatus, actuation.ObjectStatus{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(obj),
+			Strategy:        actuation.ActuationStrategyDelete,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		})
+	}
+	return objStatus
+}
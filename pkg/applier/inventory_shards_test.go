@@ -0,0 +1,252 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"sigs.k8s.io/cli-utils/pkg/apis/actuation"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func configMapIDs(n int) object.ObjMetadataSet {
+	var ids object.ObjMetadataSet
+	for i := 0; i < n; i++ {
+		ids = append(ids, object.ObjMetadata{
+			GroupKind: kinds.ConfigMap().GroupKind(),
+			Namespace: "bookstore",
+			Name:      fmt.Sprintf("cm-%d", i),
+		})
+	}
+	return ids
+}
+
+// storedObjs returns the objects stored in the ResourceGroup with the given
+// name, or nil if it does not exist.
+func storedObjs(t *testing.T, c client.Client, name string) object.ObjMetadataSet {
+	t.Helper()
+	rg := newInventoryUnstructured(name, "test-namespace", StatusEnabled)
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(rg), rg); err != nil {
+		return nil
+	}
+	inv, err := wrapInventoryObj(rg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := inv.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objs
+}
+
+func TestShardedInventoryClient(t *testing.T) {
+	fakeClient := testingfake.NewClient(t, runtime.NewScheme())
+	sic := newShardedInventoryClient(nil, fakeClient, inventory.StatusPolicyAll)
+	sic.shardSize = 2
+	inv, err := wrapInventoryObj(newInventoryUnstructured("rs", "test-namespace", StatusEnabled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := configMapIDs(5)
+
+	// Creating the inventory spreads the objects across the shards.
+	pruneIds, err := sic.Merge(inv, ids, common.DryRunNone)
+	if err != nil {
+		t.Fatalf("Merge() got unexpected error %v", err)
+	}
+	if len(pruneIds) != 0 {
+		t.Errorf("Merge() got prune ids %v, want none", pruneIds)
+	}
+	want := map[string]object.ObjMetadataSet{
+		"rs":         ids[:2],
+		"rs-shard-1": ids[2:4],
+		"rs-shard-2": ids[4:],
+	}
+	for name, wantObjs := range want {
+		if diff := cmp.Diff(wantObjs, storedObjs(t, fakeClient, name)); diff != "" {
+			t.Errorf("objects of ResourceGroup %s: %s", name, diff)
+		}
+	}
+	shard := newInventoryUnstructured("rs-shard-2", "test-namespace", StatusEnabled)
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(shard), shard); err != nil {
+		t.Fatal(err)
+	}
+	if got := shard.GetLabels()[metadata.InventoryShardOfLabel]; got != "rs" {
+		t.Errorf("got shard label %q, want %q", got, "rs")
+	}
+	if got := shard.GetLabels()[common.InventoryLabel]; got != InventoryID("rs", "test-namespace") {
+		t.Errorf("got inventory label %q, want the id of the inventory", got)
+	}
+	if refs := shard.GetOwnerReferences(); len(refs) != 1 || refs[0].Name != "rs" || refs[0].Kind != live.ResourceGroupGVK.Kind {
+		t.Errorf("got owner references %v, want the inventory", refs)
+	}
+	resourceStatuses, _, err := unstructured.NestedSlice(shard.Object, "status", "resourceStatuses")
+	if err != nil || len(resourceStatuses) != 1 {
+		t.Errorf("got resource statuses %v, want the status of %s", resourceStatuses, ids[4])
+	}
+
+	clusterObjs, err := sic.GetClusterObjs(inv)
+	if err != nil {
+		t.Fatalf("GetClusterObjs() got unexpected error %v", err)
+	}
+	if !clusterObjs.Equal(ids) {
+		t.Errorf("GetClusterObjs() got %v, want %v", clusterObjs, ids)
+	}
+
+	// Merging keeps the objects to prune in the inventory.
+	pruneIds, err = sic.Merge(inv, ids[:3], common.DryRunNone)
+	if err != nil {
+		t.Fatalf("Merge() got unexpected error %v", err)
+	}
+	if !pruneIds.Equal(ids[3:]) {
+		t.Errorf("Merge() got prune ids %v, want %v", pruneIds, ids[3:])
+	}
+	if got := storedObjs(t, fakeClient, "rs-shard-2"); !got.Equal(ids[4:]) {
+		t.Errorf("got objects %v in the last shard after Merge(), want %v", got, ids[4:])
+	}
+
+	// Replacing the objects after pruning deletes the shards which are no
+	// longer needed.
+	objStatus := []actuation.ObjectStatus{{
+		ObjectReference: inventory.ObjectReferenceFromObjMetadata(ids[2]),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+	}}
+	if err := sic.Replace(inv, ids[:3], objStatus, common.DryRunNone); err != nil {
+		t.Fatalf("Replace() got unexpected error %v", err)
+	}
+	if got := storedObjs(t, fakeClient, "rs-shard-1"); !got.Equal(ids[2:3]) {
+		t.Errorf("got objects %v in the first shard after Replace(), want %v", got, ids[2:3])
+	}
+	if got := storedObjs(t, fakeClient, "rs-shard-2"); got != nil {
+		t.Errorf("got objects %v in the deleted shard after Replace(), want none", got)
+	}
+
+	if err := sic.Replace(inv, nil, nil, common.DryRunNone); err != nil {
+		t.Fatalf("Replace() got unexpected error %v", err)
+	}
+	if got := storedObjs(t, fakeClient, "rs"); len(got) != 0 {
+		t.Errorf("got objects %v in the inventory, want none", got)
+	}
+	if got := storedObjs(t, fakeClient, "rs-shard-1"); got != nil {
+		t.Errorf("got objects %v in the deleted shard, want none", got)
+	}
+}
+
+func TestShardIndex(t *testing.T) {
+	testCases := []struct {
+		shard string
+		want  int
+	}{
+		{shard: "rs-shard-1", want: 1},
+		{shard: "rs-shard-12", want: 12},
+		{shard: "rs", want: 0},
+		{shard: "rs-shard-0", want: 0},
+		{shard: "rs-shard-01", want: 0},
+		{shard: "other-shard-1", want: 0},
+		{shard: "rs-shard-1-shard-1", want: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.shard, func(t *testing.T) {
+			if got := shardIndex("rs", tc.shard); got != tc.want {
+				t.Errorf("shardIndex(%q) = %d, want %d", tc.shard, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInventoryShardSize(t *testing.T) {
+	testCases := []struct {
+		name      string
+		namespace string
+		objName   string
+		wantObjs  int
+	}{
+		{
+			name:      "short names",
+			namespace: "bookstore",
+			objName:   "cm",
+			wantObjs:  defaultInventoryShardSize,
+		},
+		{
+			name:      "names of the maximum length",
+			namespace: strings.Repeat("n", 63),
+			objName:   strings.Repeat("c", 245),
+			wantObjs:  743,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids object.ObjMetadataSet
+			for i := 0; i < 3*defaultInventoryShardSize; i++ {
+				ids = append(ids, object.ObjMetadata{
+					GroupKind: kinds.ConfigMap().GroupKind(),
+					Namespace: tc.namespace,
+					Name:      fmt.Sprintf("%s-%05d", tc.objName, i),
+				})
+			}
+			sic := newShardedInventoryClient(nil, nil, inventory.StatusPolicyAll)
+			chunk := sic.chunk(ids)[0]
+			if len(chunk) != tc.wantObjs {
+				t.Errorf("got %d objects in the first shard, want %d", len(chunk), tc.wantObjs)
+			}
+
+			// The full shard with the status of every object stays well below the
+			// maximum object size.
+			var objStatus []actuation.ObjectStatus
+			for _, obj := range chunk {
+				objStatus = append(objStatus, actuation.ObjectStatus{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(obj),
+					Strategy:        actuation.ActuationStrategyApply,
+					Actuation:       actuation.ActuationSucceeded,
+					Reconcile:       actuation.ReconcileSucceeded,
+				})
+			}
+			inv, err := wrapInventoryObj(newInventoryUnstructured("root-sync", "config-management-system", StatusEnabled))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := inv.Store(chunk, objStatus); err != nil {
+				t.Fatal(err)
+			}
+			rg, err := inv.GetObject()
+			if err != nil {
+				t.Fatal(err)
+			}
+			size, err := getObjectSize(rg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(size) > maxRequestBytes/2 {
+				t.Errorf("got a shard of %d bytes, want at most %d", size, maxRequestBytes/2)
+			}
+		})
+	}
+}
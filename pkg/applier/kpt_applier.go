@@ -22,15 +22,20 @@ import (
 	"time"
 
 	"github.com/GoogleContainerTools/kpt/pkg/live"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/api/configmanagement"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	m "kpt.dev/configsync/pkg/metrics"
@@ -78,6 +83,8 @@ type Applier struct {
 	errs status.MultiError
 	// syncing indicates whether the applier is syncing.
 	syncing bool
+	// scope of the reconciler, which is used to detect management conflicts.
+	scope declared.Scope
 	// name and namespace of the RootSync|RepoSync object
 	// for the current applier.
 	syncName      string
@@ -89,6 +96,10 @@ type Applier struct {
 	statusMode string
 	// reconcileTimeout controls the reconcile and prune timeout
 	reconcileTimeout time.Duration
+	// recorder records the Events on the applied and pruned objects.
+	recorder record.EventRecorder
+	// safeguard checks the objects to prune against the deletion budgets.
+	safeguard *declared.DeletionSafeguard
 }
 
 // Interface is a fake-able subset of the interface Applier implements.
@@ -103,13 +114,16 @@ type Interface interface {
 	Errors() status.MultiError
 	// Syncing indicates whether the applier is syncing.
 	Syncing() bool
+	// Plan computes the changes which Apply would make to the cluster to apply
+	// the latest parsed git resource, without making any.
+	Plan(ctx context.Context, desiredResources []client.Object) ([]PlannedChange, status.MultiError)
 }
 
 var _ Interface = &Applier{}
 
 // NewNamespaceApplier initializes an applier that fetches a certain namespace's resources from
 // the API server.
-func NewNamespaceApplier(c client.Client, configFlags *genericclioptions.ConfigFlags, namespace declared.Scope, syncName string, statusMode string, reconcileTimeout time.Duration) (*Applier, error) {
+func NewNamespaceApplier(c client.Client, configFlags *genericclioptions.ConfigFlags, namespace declared.Scope, syncName string, statusMode string, reconcileTimeout time.Duration, recorder record.EventRecorder, safeguard *declared.DeletionSafeguard) (*Applier, error) {
 	u := newInventoryUnstructured(syncName, string(namespace), statusMode)
 	// If the ResourceGroup object exists, annotate the status mode on the
 	// existing object.
@@ -128,17 +142,20 @@ func NewNamespaceApplier(c client.Client, configFlags *genericclioptions.ConfigF
 		configFlags:      configFlags,
 		clientSetFunc:    newClientSet,
 		policy:           inventory.PolicyAdoptIfNoInventory,
+		scope:            namespace,
 		syncName:         syncName,
 		syncNamespace:    string(namespace),
 		statusMode:       statusMode,
 		reconcileTimeout: reconcileTimeout,
+		recorder:         recorder,
+		safeguard:        safeguard,
 	}
 	klog.V(4).Infof("Applier %s/%s is initialized", namespace, syncName)
 	return a, nil
 }
 
 // NewRootApplier initializes an applier that can fetch all resources from the API server.
-func NewRootApplier(c client.Client, configFlags *genericclioptions.ConfigFlags, syncName, statusMode string, reconcileTimeout time.Duration) (*Applier, error) {
+func NewRootApplier(c client.Client, configFlags *genericclioptions.ConfigFlags, syncName, statusMode string, reconcileTimeout time.Duration, recorder record.EventRecorder, safeguard *declared.DeletionSafeguard) (*Applier, error) {
 	u := newInventoryUnstructured(syncName, configmanagement.ControllerNamespace, statusMode)
 	// If the ResourceGroup object exists, annotate the status mode on the
 	// existing object.
@@ -157,8 +174,12 @@ func NewRootApplier(c client.Client, configFlags *genericclioptions.ConfigFlags,
 		configFlags:      configFlags,
 		clientSetFunc:    newClientSet,
 		policy:           inventory.PolicyAdoptAll,
+		scope:            declared.RootReconciler,
+		syncName:         syncName,
 		statusMode:       statusMode,
 		reconcileTimeout: reconcileTimeout,
+		recorder:         recorder,
+		safeguard:        safeguard,
 	}
 	klog.V(4).Infof("Root applier %s is initialized and synced with the API server", syncName)
 	return a, nil
@@ -201,6 +222,9 @@ func processApplyEvent(ctx context.Context, e event.ApplyEvent, stats *applyEven
 			unknownTypeResources[id] = struct{}{}
 			return ErrorForResource(e.Error, id)
 		default:
+			if apierrors.IsForbidden(e.Error) {
+				return PermissionErrorForResource(e.Error, id, actuation.ActuationStrategyApply)
+			}
 			return ErrorForResource(e.Error, id)
 		}
 
@@ -293,6 +317,9 @@ func processPruneEvent(ctx context.Context, e event.PruneEvent, stats *pruneEven
 
 	case event.PruneFailed:
 		objectStatus.Actuation = actuation.ActuationFailed
+		if apierrors.IsForbidden(e.Error) {
+			return PermissionErrorForResource(e.Error, id, actuation.ActuationStrategyDelete)
+		}
 		return PruneErrorForResource(e.Error, id)
 
 	case event.PruneSkipped:
@@ -354,6 +381,57 @@ func handlePruneSkippedEvent(obj *unstructured.Unstructured, id core.ID, err err
 	return SkipErrorForResource(err, id, actuation.ActuationStrategyDelete)
 }
 
+// recordApplyEvent records an Event on the object of the apply event once it
+// is changed by the sync, or once a management conflict prevents its apply.
+func (a *Applier) recordApplyEvent(e event.ApplyEvent, syncStart time.Time) {
+	if e.Resource == nil {
+		return
+	}
+	switch e.Status {
+	case event.ApplySuccessful:
+		// Every apply is reported as successful, including the ones which leave
+		// the object unchanged, so only the objects changed by this sync get an
+		// Event. Otherwise every object would get one on every resync.
+		if !appliedSince(e.Resource, syncStart) {
+			return
+		}
+		a.recorder.Eventf(e.Resource, corev1.EventTypeNormal, events.ReasonApplied,
+			"Applied commit %s of %s", commitOf(e.Resource), events.SyncString(a.scope, a.syncName))
+	case event.ApplySkipped:
+		var policyErr *inventory.PolicyPreventedActuationError
+		if errors.As(e.Error, &policyErr) {
+			a.recorder.Eventf(e.Resource, corev1.EventTypeWarning, events.ReasonManagementConflict,
+				"%s cannot apply the object because it is managed by another repository", events.SyncString(a.scope, a.syncName))
+		}
+	}
+}
+
+// recordPruneEvent records an Event on the object of the prune event once it
+// is deleted.
+func (a *Applier) recordPruneEvent(e event.PruneEvent) {
+	if e.Object == nil || e.Status != event.PruneSuccessful {
+		return
+	}
+	a.recorder.Eventf(e.Object, corev1.EventTypeNormal, events.ReasonPruned,
+		"Pruned by %s because it is no longer declared", events.SyncString(a.scope, a.syncName))
+}
+
+// appliedSince returns whether Config Sync changed obj with a server-side apply
+// at or after t. The times of the managed fields are truncated to seconds.
+func appliedSince(obj *unstructured.Unstructured, t time.Time) bool {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == configsync.FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply && entry.Time != nil {
+			return !entry.Time.Time.Before(t.Truncate(time.Second))
+		}
+	}
+	return false
+}
+
+// commitOf returns the commit of the source which obj was applied from.
+func commitOf(obj *unstructured.Unstructured) string {
+	return core.GetAnnotation(obj, metadata.SyncTokenAnnotationKey)
+}
+
 func isNamespace(obj *unstructured.Unstructured) bool {
 	return obj.GetObjectKind().GroupVersionKind().GroupKind() == kinds.Namespace().GroupKind()
 }
@@ -367,24 +445,49 @@ func handleMetrics(ctx context.Context, operation string, err error, gvk schema.
 	m.RecordApplyOperation(ctx, operation, m.StatusTagKey(err), gvk)
 }
 
-// checkInventoryObjectSize checks the inventory object size limit.
-// If it is close to the size limit 1M, log a warning.
+// checkInventoryObjectSize checks the size of the inventory and its shards.
+// If one of them is close to the size limit, log a warning.
 func (a *Applier) checkInventoryObjectSize(ctx context.Context, c client.Client) {
-	u := newInventoryUnstructured(a.syncName, a.syncNamespace, a.statusMode)
-	err := c.Get(ctx, client.ObjectKey{Namespace: a.syncNamespace, Name: a.syncName}, u)
-	if err == nil {
-		size, err := getObjectSize(u)
+	rgs, err := clusterInventoryObjs(ctx, c, a.inventory)
+	if err != nil {
+		klog.Warningf("Failed to get the ResourceGroup inventory %s/%s to check its size: %s", a.inventory.Namespace(), a.inventory.Name(), err)
+		return
+	}
+	for _, rg := range rgs {
+		size, err := getObjectSize(rg)
 		if err != nil {
-			klog.Warningf("Failed to marshal ResourceGroup %s/%s to get its size: %s", a.syncNamespace, a.syncName, err)
+			klog.Warningf("Failed to marshal ResourceGroup %s/%s to get its size: %s", rg.GetNamespace(), rg.GetName(), err)
 		}
 		if int64(size) > maxRequestBytes/2 {
-			klog.Warningf("ResourceGroup %s/%s is close to the maximum object size limit (size: %d, max: %s). "+
-				"There are too many resources being synced than Config Sync can handle! Please split your repo into smaller repos "+
-				"to avoid future failure.", a.syncNamespace, a.syncName, size, maxRequestBytesStr)
+			klog.Warningf("ResourceGroup %s/%s is close to the maximum object size limit (size: %d, max: %s), "+
+				"although its objects are estimated to take at most %d bytes.",
+				rg.GetNamespace(), rg.GetName(), size, maxRequestBytesStr, inventoryShardBytes)
 		}
 	}
 }
 
+// checkDeletionBudgets checks pruning the objects in the inventory which are
+// not in objs against the deletion budgets.
+func (a *Applier) checkDeletionBudgets(ctx context.Context, c client.Client, objs []client.Object) status.Error {
+	if a.safeguard == nil {
+		return nil
+	}
+	// There is nothing to prune without an inventory.
+	invObjs, err := clusterInventoryObjMetas(ctx, c, a.inventory)
+	if err != nil {
+		return Error(err)
+	}
+	inventory := make([]core.ID, len(invObjs))
+	for i, obj := range invObjs {
+		inventory[i] = idFrom(obj)
+	}
+	desired := make([]core.ID, len(objs))
+	for i, obj := range objs {
+		desired[i] = core.IDOf(obj)
+	}
+	return a.safeguard.CheckPrune(ctx, inventory, desired)
+}
+
 // sync triggers a kpt live apply library call to apply a set of resources.
 func (a *Applier) sync(ctx context.Context, objs []client.Object) (map[schema.GroupVersionKind]struct{}, status.MultiError) {
 	cs, err := a.clientSetFunc(a.client, a.configFlags, a.statusMode)
@@ -392,6 +495,10 @@ func (a *Applier) sync(ctx context.Context, objs []client.Object) (map[schema.Gr
 		return nil, Error(err)
 	}
 	a.checkInventoryObjectSize(ctx, cs.client)
+	if err := a.checkDeletionBudgets(ctx, cs.client, objs); err != nil {
+		a.errs = status.Append(a.errs, err)
+		return nil, a.errs
+	}
 
 	stats := newApplyStats()
 	objStatusMap := make(ObjectStatusMap)
@@ -441,8 +548,9 @@ func (a *Applier) sync(ctx context.Context, objs []client.Object) (map[schema.Gr
 	}
 	meta.MaybeResetRESTMapper(mapper)
 
-	events := cs.apply(ctx, a.inventory, resources, options)
-	for e := range events {
+	syncStart := time.Now()
+	applyEvents := cs.apply(ctx, a.inventory, resources, options)
+	for e := range applyEvents {
 		switch e.Type {
 		case event.InitType:
 			for _, ag := range e.InitEvent.ActionGroups {
@@ -477,6 +585,7 @@ func (a *Applier) sync(ctx context.Context, objs []client.Object) (map[schema.Gr
 			}
 			klog.V(4).Info(logEvent)
 			a.errs = status.Append(a.errs, processApplyEvent(ctx, e.ApplyEvent, &stats.ApplyEvent, objStatusMap, unknownTypeResources))
+			a.recordApplyEvent(e.ApplyEvent, syncStart)
 		case event.PruneType:
 			logEvent := event.PruneEvent{
 				GroupName:  e.PruneEvent.GroupName,
@@ -487,6 +596,7 @@ func (a *Applier) sync(ctx context.Context, objs []client.Object) (map[schema.Gr
 			}
 			klog.V(4).Info(logEvent)
 			a.errs = status.Append(a.errs, processPruneEvent(ctx, e.PruneEvent, &stats.PruneEvent, objStatusMap, cs))
+			a.recordPruneEvent(e.PruneEvent)
 		default:
 			klog.V(4).Infof("skipped %v event", e.Type)
 		}
@@ -27,12 +27,20 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/status"
 	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
 	"kpt.dev/configsync/pkg/testing/fake"
@@ -255,7 +263,7 @@ func TestSync(t *testing.T) {
 		}
 
 		var errs status.MultiError
-		applier, err := NewNamespaceApplier(fakeClient, configFlags, "test-namespace", "rs", "", 5*time.Minute)
+		applier, err := NewNamespaceApplier(fakeClient, configFlags, "test-namespace", "rs", "", 5*time.Minute, &record.FakeRecorder{}, nil)
 		if err != nil {
 			errs = Error(err)
 		} else {
@@ -450,6 +458,80 @@ func TestProcessApplyEvent(t *testing.T) {
 	// TODO: test handleApplySkippedEvent on skip
 }
 
+func TestProcessEventsForbidden(t *testing.T) {
+	deploymentID := object.UnstructuredToObjMetadata(newDeploymentObj())
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"},
+		deploymentID.Name, fmt.Errorf("no RBAC policy matched"))
+
+	ctx := context.Background()
+	stats := newApplyStats()
+	objStatusMap := make(ObjectStatusMap)
+	unknownTypeResources := make(map[core.ID]struct{})
+
+	err := processApplyEvent(ctx, formApplyEvent(event.ApplyFailed, &deploymentID, forbidden).ApplyEvent, &stats.ApplyEvent, objStatusMap, unknownTypeResources)
+	expectedError := PermissionErrorForResource(forbidden, idFrom(deploymentID), actuation.ActuationStrategyApply)
+	testutil.AssertEqual(t, expectedError, err, "expected processApplyEvent to return a permission error")
+	assert.Equal(t, status.InsufficientPermissionErrorCode, err.Code())
+
+	err = processPruneEvent(ctx, formPruneEvent(event.PruneFailed, &deploymentID, forbidden).PruneEvent, &stats.PruneEvent, objStatusMap, &clientSet{})
+	expectedError = PermissionErrorForResource(forbidden, idFrom(deploymentID), actuation.ActuationStrategyDelete)
+	testutil.AssertEqual(t, expectedError, err, "expected processPruneEvent to return a permission error")
+}
+
+func TestSyncDeletionBudget(t *testing.T) {
+	deploymentObj := newDeploymentObj()
+	deploymentID := object.UnstructuredToObjMetadata(deploymentObj)
+	testObj := newTestObj()
+	testID := object.UnstructuredToObjMetadata(testObj)
+
+	// The inventory holds the objects applied before the reconciler restarted,
+	// so the declared resources do not know about them.
+	inv, err := wrapInventoryObj(newInventoryUnstructured("rs", "test-namespace", StatusEnabled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Store(object.ObjMetadataSet{deploymentID, testID}, nil); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := inv.GetObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := fake.RepoSyncObjectV1Beta1("test-namespace", "rs")
+	fakeClient := testingfake.NewClient(t, runtime.NewScheme(), rg, rs)
+
+	budgets := []v1beta1.DeletionBudget{{Kind: declared.WildcardKind, MaxCount: pointer.Int64(0)}}
+	safeguard := declared.NewDeletionSafeguard(budgets, fakeClient, "test-namespace", "rs")
+	if err := safeguard.Update(context.Background(), "abc123", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	applier, err := NewNamespaceApplier(fakeClient, &genericclioptions.ConfigFlags{}, "test-namespace", "rs", StatusEnabled, 5*time.Minute, &record.FakeRecorder{}, safeguard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applier.clientSetFunc = func(c client.Client, _ *genericclioptions.ConfigFlags, _ string) (*clientSet, error) {
+		return &clientSet{
+			kptApplier: newFakeApplier(nil, nil),
+			client:     fakeClient,
+		}, nil
+	}
+
+	_, errs := applier.Apply(context.Background(), []client.Object{deploymentObj})
+	want := declared.DeletionBudgetError("abc123", "RepoSync test-namespace/rs",
+		[]string{"1 of 1 Test.configsync.test objects (budget: maxCount 0)"})
+	if errs == nil || errs.Error() != status.Append(nil, want).Error() {
+		t.Errorf("sync() got %v, want %v", errs, want)
+	}
+
+	core.SetAnnotation(rs, metadata.ApproveDeletionsAnnotationKey, "abc123")
+	if err := fakeClient.Update(context.Background(), rs); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := applier.Apply(context.Background(), []client.Object{deploymentObj}); errs != nil {
+		t.Errorf("sync() got unexpected errors %v after the deletions were approved", errs)
+	}
+}
+
 func TestProcessPruneEvent(t *testing.T) {
 	deploymentID := object.UnstructuredToObjMetadata(newDeploymentObj())
 	testID := object.UnstructuredToObjMetadata(newTestObj())
@@ -489,6 +571,78 @@ func TestProcessPruneEvent(t *testing.T) {
 	// TODO: test handlePruneSkippedEvent on skip
 }
 
+func TestRecordEvents(t *testing.T) {
+	syncStart := time.Unix(1000, 0)
+	appliedAt := func(seconds int64) *unstructured.Unstructured {
+		u := newDeploymentObj()
+		core.SetAnnotation(u, metadata.SyncTokenAnnotationKey, "abc123")
+		applyTime := metav1.NewTime(time.Unix(seconds, 0))
+		u.SetManagedFields([]metav1.ManagedFieldsEntry{
+			{Manager: configsync.FieldManager, Operation: metav1.ManagedFieldsOperationApply, Time: &applyTime},
+		})
+		return u
+	}
+	deploymentID := object.UnstructuredToObjMetadata(newDeploymentObj())
+
+	testCases := []struct {
+		name   string
+		apply  *event.ApplyEvent
+		prune  *event.PruneEvent
+		events []string
+	}{
+		{
+			name:   "object changed by the sync",
+			apply:  &event.ApplyEvent{Status: event.ApplySuccessful, Identifier: deploymentID, Resource: appliedAt(1000)},
+			events: []string{"Normal Applied Applied commit abc123 of RepoSync test-namespace/rs"},
+		},
+		{
+			name:  "object unchanged by the sync",
+			apply: &event.ApplyEvent{Status: event.ApplySuccessful, Identifier: deploymentID, Resource: appliedAt(900)},
+		},
+		{
+			name: "management conflict",
+			apply: &event.ApplyEvent{Status: event.ApplySkipped, Identifier: deploymentID, Resource: newDeploymentObj(),
+				Error: &inventory.PolicyPreventedActuationError{Strategy: actuation.ActuationStrategyApply, Policy: inventory.PolicyMustMatch, Status: inventory.NoMatch}},
+			events: []string{"Warning ManagementConflict RepoSync test-namespace/rs cannot apply the object because it is managed by another repository"},
+		},
+		{
+			name:   "object pruned",
+			prune:  &event.PruneEvent{Status: event.PruneSuccessful, Identifier: deploymentID, Object: newDeploymentObj()},
+			events: []string{"Normal Pruned Pruned by RepoSync test-namespace/rs because it is no longer declared"},
+		},
+		{
+			name:  "prune failed",
+			prune: &event.PruneEvent{Status: event.PruneFailed, Identifier: deploymentID, Object: newDeploymentObj(), Error: errors.New("failed")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			a := &Applier{
+				scope:         "test-namespace",
+				syncName:      "rs",
+				syncNamespace: "test-namespace",
+				recorder:      recorder,
+			}
+			if tc.apply != nil {
+				a.recordApplyEvent(*tc.apply, syncStart)
+			}
+			if tc.prune != nil {
+				a.recordPruneEvent(*tc.prune)
+			}
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			if diff := cmp.Diff(tc.events, events); diff != "" {
+				t.Errorf("Diff of the recorded events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestProcessWaitEvent(t *testing.T) {
 	deploymentID := object.UnstructuredToObjMetadata(newDeploymentObj())
 	testID := object.UnstructuredToObjMetadata(newTestObj())
@@ -0,0 +1,228 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncer/differ"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlannedChange is a change which the applier would make to an object.
+type PlannedChange struct {
+	// Action is the change which would be made to the object.
+	Action v1beta1.PlanAction
+	// Object is the declared object, or the object in the cluster if it would
+	// be pruned.
+	Object client.Object
+}
+
+// serverSetFields are the fields the API server sets on every write, so they
+// are ignored when comparing an object with the result of a dry-run apply.
+var serverSetFields = [][]string{
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+}
+
+// commitAnnotations are the annotations Config Sync sets on every declared
+// object which change with every commit, even if the object doesn't, so they
+// are ignored as well.
+var commitAnnotations = []string{
+	metadata.SyncTokenAnnotationKey,
+	metadata.GitContextKey,
+	metadata.DeclaredFieldsKey,
+}
+
+// Plan implements Interface.
+// Plan computes the changes which Apply would make to the cluster, without
+// making any. Objects which are already up to date are omitted.
+//
+// An object is created if it doesn't exist yet, and updated if a server-side
+// dry-run apply changes it, ignoring the annotations which change with every
+// commit. Both are dry-run, so that the objects rejected by the API server or
+// an admission webhook fail to plan. An object fails to apply because of a conflict if
+// the inventory policy or another reconciler prevents the applier from managing
+// it. The objects in the inventory which are no longer declared are pruned,
+// unless their deletion is prevented.
+func (a *Applier) Plan(ctx context.Context, objs []client.Object) ([]PlannedChange, status.MultiError) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	cs, err := a.clientSetFunc(a.client, a.configFlags, a.statusMode)
+	if err != nil {
+		return nil, Error(err)
+	}
+	// Reset shared mapper to invalidate the discovery cache, as Apply does.
+	mapper, err := a.configFlags.ToRESTMapper()
+	if err != nil {
+		return nil, status.Append(nil, err)
+	}
+	meta.MaybeResetRESTMapper(mapper)
+
+	// Objects for which the management is disabled are removed from the
+	// inventory instead of being applied or pruned.
+	enabledObjs, _ := partitionObjs(objs)
+	resources, errs := toUnstructured(enabledObjs)
+	if errs != nil {
+		return nil, errs
+	}
+
+	var changes []PlannedChange
+	for _, u := range resources {
+		action, err := a.planApply(ctx, cs.resouceClient, u)
+		if err != nil {
+			errs = status.Append(errs, ErrorForResource(err, core.IDOf(u)))
+			continue
+		}
+		if action != "" {
+			changes = append(changes, PlannedChange{Action: action, Object: u})
+		}
+	}
+
+	pruneChanges, pruneErrs := a.planPrune(ctx, cs, objs)
+	changes = append(changes, pruneChanges...)
+	errs = status.Append(errs, pruneErrs)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return core.IDOf(changes[i].Object).String() < core.IDOf(changes[j].Object).String()
+	})
+	klog.Infof("The applier planned %d changes to %d objects", len(changes), len(objs))
+	return changes, errs
+}
+
+// planApply returns the change which applying u would make, or an empty action
+// if u is up to date.
+func (a *Applier) planApply(ctx context.Context, rc *resourceClient, u *unstructured.Unstructured) (v1beta1.PlanAction, error) {
+	desired := u.DeepCopy()
+	inventory.AddInventoryIDAnnotation(desired, a.inventory)
+
+	current, err := rc.get(ctx, objMetaFrom(u))
+	switch {
+	case meta.IsNoMatchError(err):
+		// The type, which may be declared in the same commit, doesn't exist
+		// yet, so the creation can't be dry-run.
+		return v1beta1.PlanActionCreate, nil
+	case apierrors.IsNotFound(err):
+		if _, err := rc.dryRunApply(ctx, desired); err != nil {
+			return "", err
+		}
+		return v1beta1.PlanActionCreate, nil
+	case err != nil:
+		return "", err
+	}
+
+	if ok, _ := inventory.CanApply(a.inventory, current, a.policy); !ok {
+		return v1beta1.PlanActionConflict, nil
+	}
+	if !diff.CanManage(a.scope, a.syncName, current, admissionv1.Update) {
+		return v1beta1.PlanActionConflict, nil
+	}
+
+	applied, err := rc.dryRunApply(ctx, desired)
+	if err != nil {
+		return "", err
+	}
+	if unchanged(current, applied) {
+		return "", nil
+	}
+	return v1beta1.PlanActionUpdate, nil
+}
+
+// planPrune returns the objects in the inventory which are no longer declared
+// and would be deleted.
+func (a *Applier) planPrune(ctx context.Context, cs *clientSet, objs []client.Object) ([]PlannedChange, status.MultiError) {
+	inventoryObjs, err := cs.invClient.GetClusterObjs(a.inventory)
+	if err != nil {
+		return nil, Error(err)
+	}
+	declaredObjs := make(map[object.ObjMetadata]bool, len(objs))
+	for _, obj := range objs {
+		declaredObjs[objMetaFrom(obj)] = true
+	}
+
+	var changes []PlannedChange
+	var errs status.MultiError
+	for _, id := range inventoryObjs {
+		if declaredObjs[id] {
+			continue
+		}
+		current, err := cs.resouceClient.get(ctx, id)
+		switch {
+		case apierrors.IsNotFound(err), meta.IsNoMatchError(err):
+			continue
+		case err != nil:
+			errs = status.Append(errs, PruneErrorForResource(err, idFrom(id)))
+			continue
+		}
+		if a.prunable(current) {
+			changes = append(changes, PlannedChange{Action: v1beta1.PlanActionPrune, Object: current})
+		}
+	}
+	return changes, errs
+}
+
+// prunable returns true if the applier would delete obj once it is no longer
+// declared.
+func (a *Applier) prunable(obj *unstructured.Unstructured) bool {
+	if ok, _ := inventory.CanPrune(a.inventory, obj, a.policy); !ok {
+		return false
+	}
+	for key, value := range obj.GetAnnotations() {
+		if common.NoDeletion(key, value) {
+			return false
+		}
+	}
+	return !(isNamespace(obj) && differ.SpecialNamespaces[obj.GetName()])
+}
+
+// unchanged returns true if the result of a dry-run apply is the same as the
+// object in the cluster, except for the fields set by the API server and the
+// annotations which change with every commit.
+func unchanged(current, applied *unstructured.Unstructured) bool {
+	current = current.DeepCopy()
+	applied = applied.DeepCopy()
+	for _, fields := range serverSetFields {
+		unstructured.RemoveNestedField(current.Object, fields...)
+		unstructured.RemoveNestedField(applied.Object, fields...)
+	}
+	for _, key := range commitAnnotations {
+		unstructured.RemoveNestedField(current.Object, "metadata", "annotations", key)
+		unstructured.RemoveNestedField(applied.Object, "metadata", "annotations", key)
+	}
+	// Removing the last annotation leaves an empty map behind.
+	for _, u := range []*unstructured.Unstructured{current, applied} {
+		if len(u.GetAnnotations()) == 0 {
+			unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+		}
+	}
+	return equality.Semantic.DeepEqual(current.Object, applied.Object)
+}
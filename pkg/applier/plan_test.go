@@ -0,0 +1,217 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applier
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+func TestUnchanged(t *testing.T) {
+	current := fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("foo"))
+	current.SetResourceVersion("1")
+	current.SetGeneration(1)
+	if err := unstructured.SetNestedField(current.Object, "bar", "data", "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	sameApplied := current.DeepCopy()
+	sameApplied.SetResourceVersion("2")
+	sameApplied.SetGeneration(2)
+	if !unchanged(current, sameApplied) {
+		t.Error("unchanged() = false for an object which only differs by the server-set fields, want true")
+	}
+
+	changedApplied := current.DeepCopy()
+	if err := unstructured.SetNestedField(changedApplied.Object, "baz", "data", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if unchanged(current, changedApplied) {
+		t.Error("unchanged() = true for an object with different data, want false")
+	}
+}
+
+func TestUnchangedAcrossCommits(t *testing.T) {
+	// The object declared with the same content in two commits only differs
+	// by the annotations which Config Sync sets for every commit.
+	declaredAt := func(commit string) *unstructured.Unstructured {
+		u := fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("foo"),
+			core.Annotation(metadata.SyncTokenAnnotationKey, commit),
+			core.Annotation(metadata.GitContextKey, `{"repo":"https://github.com/org/repo","branch":"main","rev":"`+commit+`"}`),
+			core.Annotation(metadata.DeclaredFieldsKey, `{"f:data":{"f:foo":{}}}`))
+		if err := unstructured.SetNestedField(u.Object, "bar", "data", "foo"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	current := declaredAt("commit1")
+	current.SetResourceVersion("1")
+	applied := declaredAt("commit2")
+	applied.SetResourceVersion("2")
+	if !unchanged(current, applied) {
+		t.Error("unchanged() = false for an object declared with the same content in two commits, want true")
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	inv, err := wrapInventoryObj(newInventoryUnstructured("rs", "test-namespace", StatusDisabled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{kinds.ConfigMap().GroupVersion()})
+	mapper.Add(kinds.ConfigMap(), meta.RESTScopeNamespace)
+	denied := apierrors.NewForbidden(gvr.GroupResource(), "cm", errors.New(`admission webhook "deny.example.com" denied the request`))
+
+	declaredCM := func(commit string) *unstructured.Unstructured {
+		return fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("test-namespace"),
+			core.Annotation(metadata.SyncTokenAnnotationKey, commit))
+	}
+	current := declaredCM("commit1")
+	inventory.AddInventoryIDAnnotation(current, inv)
+	current.SetResourceVersion("1")
+
+	testCases := []struct {
+		name       string
+		objs       []runtime.Object
+		dryRunErr  error
+		wantAction v1beta1.PlanAction
+		wantErr    bool
+	}{
+		{
+			name:       "create",
+			wantAction: v1beta1.PlanActionCreate,
+		},
+		{
+			name:      "create denied by an admission webhook",
+			dryRunErr: denied,
+			wantErr:   true,
+		},
+		{
+			name: "object declared with the same content in a new commit",
+			objs: []runtime.Object{current},
+		},
+		{
+			name:      "update denied by an admission webhook",
+			objs:      []runtime.Object{current},
+			dryRunErr: denied,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tc.objs...)
+			dryRuns := 0
+			client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				dryRuns++
+				if tc.dryRunErr != nil {
+					return true, nil, tc.dryRunErr
+				}
+				applied := declaredCM("commit2")
+				inventory.AddInventoryIDAnnotation(applied, inv)
+				applied.SetResourceVersion("2")
+				return true, applied, nil
+			})
+			a := &Applier{inventory: inv, policy: inventory.PolicyAdoptIfNoInventory, scope: declared.RootReconciler, syncName: "rs"}
+
+			got, err := a.planApply(context.Background(), newResourceClient(client, mapper), declaredCM("commit2"))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("planApply() got error %v, want error %t", err, tc.wantErr)
+			}
+			if got != tc.wantAction {
+				t.Errorf("planApply() = %q, want %q", got, tc.wantAction)
+			}
+			if dryRuns != 1 {
+				t.Errorf("planApply() made %d dry-run applies, want 1", dryRuns)
+			}
+		})
+	}
+}
+
+func TestPrunable(t *testing.T) {
+	inv, err := wrapInventoryObj(newInventoryUnstructured("rs", "test-namespace", StatusDisabled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned := core.Annotation(inventory.OwningInventoryKey, inv.ID())
+
+	testCases := []struct {
+		name   string
+		policy inventory.Policy
+		obj    *unstructured.Unstructured
+		want   bool
+	}{
+		{
+			name:   "owned object",
+			policy: inventory.PolicyAdoptIfNoInventory,
+			obj:    fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("test-namespace"), owned),
+			want:   true,
+		},
+		{
+			name:   "object owned by another inventory",
+			policy: inventory.PolicyAdoptIfNoInventory,
+			obj: fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("test-namespace"),
+				core.Annotation(inventory.OwningInventoryKey, "other")),
+			want: false,
+		},
+		{
+			name:   "object owned by another inventory with the adopt-all policy",
+			policy: inventory.PolicyAdoptAll,
+			obj: fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("test-namespace"),
+				core.Annotation(inventory.OwningInventoryKey, "other")),
+			want: true,
+		},
+		{
+			name:   "object with deletion prevented",
+			policy: inventory.PolicyAdoptIfNoInventory,
+			obj: fake.UnstructuredObject(kinds.ConfigMap(), core.Name("cm"), core.Namespace("test-namespace"), owned,
+				core.Annotation(common.LifecycleDeleteAnnotation, common.PreventDeletion)),
+			want: false,
+		},
+		{
+			name:   "special namespace",
+			policy: inventory.PolicyAdoptAll,
+			obj:    fake.UnstructuredObject(kinds.Namespace(), core.Name("default"), owned),
+			want:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Applier{inventory: inv, policy: tc.policy}
+			if got := a.prunable(tc.obj); got != tc.want {
+				t.Errorf("prunable() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
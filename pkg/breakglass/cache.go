@@ -0,0 +1,86 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breakglass
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultRefreshPeriod is how long the Cache serves the policies before listing
+// them again.
+const DefaultRefreshPeriod = 10 * time.Second
+
+// Cache lists the break-glass policies at most once per refresh period, so that
+// looking them up on every admission request or remediation is cheap.
+//
+// A nil Cache has no policies.
+type Cache struct {
+	reader        client.Reader
+	refreshPeriod time.Duration
+	// now is overridden in tests.
+	now func() time.Time
+
+	mux       sync.Mutex
+	policies  []*Policy
+	refreshed time.Time
+}
+
+// NewCache returns a Cache which lists the policies with reader.
+func NewCache(reader client.Reader, refreshPeriod time.Duration) *Cache {
+	return &Cache{
+		reader:        reader,
+		refreshPeriod: refreshPeriod,
+		now:           time.Now,
+	}
+}
+
+// Policies returns the break-glass policies, listing them again if the last
+// list is older than the refresh period. If listing fails, the previously
+// listed policies are returned.
+func (c *Cache) Policies(ctx context.Context) []*Policy {
+	if c == nil {
+		return nil
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := c.now()
+	if !c.refreshed.IsZero() && now.Sub(c.refreshed) < c.refreshPeriod {
+		return c.policies
+	}
+	// Retry listing after the refresh period even on failure, so that a missing
+	// permission does not turn into a request to the API server per lookup.
+	c.refreshed = now
+	policies, err := List(ctx, c.reader)
+	if err != nil {
+		klog.Warningf("Using the previously listed break-glass policies: %v", err)
+		return c.policies
+	}
+	c.policies = policies
+	return c.policies
+}
+
+// Now returns the current time of the Cache's clock.
+func (c *Cache) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.now()
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breakglass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	syncertestfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestCache(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := corev1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	valid := `
+users: alice@acme.com
+kinds: "*"
+expiresAt: ` + expiresAt.Format(time.RFC3339)
+	policies := policyConfigMap(map[string]string{
+		"valid":   valid,
+		"invalid": "users: bob@acme.com",
+	})
+	// Other ConfigMaps are not policies.
+	other := fake.ConfigMapObject(core.Name("other"), core.Namespace(configsync.ControllerNamespace))
+	other.Data = map[string]string{"other": valid}
+	fakeClient := syncertestfake.NewClient(t, s, policies, other)
+
+	clock := now
+	c := NewCache(fakeClient, time.Minute)
+	c.now = func() time.Time { return clock }
+
+	ctx := context.Background()
+	got := c.Policies(ctx)
+	if len(got) != 1 || got[0].Name != "valid" {
+		t.Fatalf("Policies() = %v, want only the valid policy", got)
+	}
+
+	// New policies are not listed until the refresh period elapses.
+	policies.Data["added"] = valid
+	if err := fakeClient.Update(ctx, policies); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Policies(ctx); len(got) != 1 {
+		t.Errorf("Policies() = %v, want the cached policy", got)
+	}
+	clock = clock.Add(time.Minute)
+	if got := c.Policies(ctx); len(got) != 2 {
+		t.Errorf("Policies() = %v, want both valid policies after the refresh period", got)
+	}
+
+	// Without the ConfigMap, there are no policies.
+	if err := fakeClient.Delete(ctx, policies); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(time.Minute)
+	if got := c.Policies(ctx); len(got) != 0 {
+		t.Errorf("Policies() = %v, want none without the ConfigMap", got)
+	}
+
+	var nilCache *Cache
+	if got := nilCache.Policies(ctx); got != nil {
+		t.Errorf("Policies() of a nil Cache = %v, want nil", got)
+	}
+}
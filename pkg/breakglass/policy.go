@@ -0,0 +1,282 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package breakglass implements the break-glass policies which grant users a
+// time-limited exemption from the Config Sync admission webhook, for example
+// to hot-fix a managed object during an incident.
+//
+// The policies are the entries of the break-glass-policies ConfigMap in the
+// config-management-system namespace, keyed by the name of the policy, e.g.
+//
+//	data:
+//	  inc-1234: |
+//	    users: alice@example.com,bob@example.com
+//	    groups: oncall@example.com
+//	    kinds: Deployment.apps,ConfigMap
+//	    namespaces: bookstore,shipping
+//	    expiresAt: "2022-08-01T18:00:00Z"
+//	    pauseRemediation: "true"
+//	    reason: INC-1234
+//
+// The admission webhook and the reconcilers of both RootSyncs and RepoSyncs
+// read the ConfigMap, so pauseRemediation pauses the remediator of either.
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// PolicyConfigMapName is the name of the ConfigMap holding the break-glass
+// policies in the config-management-system namespace.
+const PolicyConfigMapName = "break-glass-policies"
+
+// The keys of a break-glass policy. The lists are
+// comma-separated.
+const (
+	// UsersKey lists the usernames exempted by the policy.
+	UsersKey = "users"
+	// GroupsKey lists the groups whose members are exempted by the policy.
+	GroupsKey = "groups"
+	// KindsKey lists the kinds, as Kind.group, of the objects the policy applies
+	// to. Use Wildcard for all kinds.
+	KindsKey = "kinds"
+	// NamespacesKey lists the namespaces of the objects the policy applies to.
+	// The policy applies to the objects in all namespaces and to cluster-scoped
+	// objects if it is not set.
+	NamespacesKey = "namespaces"
+	// ExpiresAtKey is the RFC 3339 time at which the policy expires.
+	ExpiresAtKey = "expiresAt"
+	// PauseRemediationKey, if "true", makes the remediator stop reverting the
+	// objects the policy applies to until it expires.
+	PauseRemediationKey = "pauseRemediation"
+	// ReasonKey is a free-form justification for the policy, e.g. an incident.
+	ReasonKey = "reason"
+)
+
+// Wildcard matches all kinds in KindsKey.
+const Wildcard = "*"
+
+// Policy is a break-glass policy.
+type Policy struct {
+	// Name of the policy, which is its key in the ConfigMap.
+	Name string
+	// Users and Groups exempted by the policy.
+	Users  []string
+	Groups []string
+	// Kinds the policy applies to. Nil means all kinds.
+	Kinds []schema.GroupKind
+	// Namespaces the policy applies to. Nil means all namespaces and
+	// cluster-scoped objects.
+	Namespaces []string
+	// ExpiresAt is the time at which the policy stops applying.
+	ExpiresAt time.Time
+	// PauseRemediation is true if the remediator stops reverting the objects the
+	// policy applies to until it expires.
+	PauseRemediation bool
+	// Reason is the justification for the policy.
+	Reason string
+}
+
+// Parse parses the break-glass policy with the given name from its YAML
+// definition.
+func Parse(name, definition string) (*Policy, error) {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal([]byte(definition), &fields); err != nil {
+		return nil, errors.Wrapf(err, "break-glass policy %s is not a YAML map", name)
+	}
+	data := make(map[string]string, len(fields))
+	for k, v := range fields {
+		data[k] = fmt.Sprint(v)
+	}
+	return fromData(name, data)
+}
+
+// fromData parses the break-glass policy with the given name from its fields.
+func fromData(name string, data map[string]string) (*Policy, error) {
+	p := &Policy{
+		Name:       name,
+		Users:      splitList(data[UsersKey]),
+		Groups:     splitList(data[GroupsKey]),
+		Namespaces: splitList(data[NamespacesKey]),
+		Reason:     data[ReasonKey],
+	}
+	if len(p.Users) == 0 && len(p.Groups) == 0 {
+		return nil, errors.Errorf("break-glass policy %s must specify %s or %s", name, UsersKey, GroupsKey)
+	}
+
+	kinds := splitList(data[KindsKey])
+	if len(kinds) == 0 {
+		return nil, errors.Errorf("break-glass policy %s must specify %s, use %q for all kinds", name, KindsKey, Wildcard)
+	}
+	for _, k := range kinds {
+		if k == Wildcard {
+			p.Kinds = nil
+			break
+		}
+		p.Kinds = append(p.Kinds, schema.ParseGroupKind(k))
+	}
+
+	expiresAt, found := data[ExpiresAtKey]
+	if !found {
+		return nil, errors.Errorf("break-glass policy %s must specify %s", name, ExpiresAtKey)
+	}
+	var err error
+	p.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "break-glass policy %s has an invalid %s", name, ExpiresAtKey)
+	}
+
+	if pause, found := data[PauseRemediationKey]; found {
+		p.PauseRemediation, err = strconv.ParseBool(pause)
+		if err != nil {
+			return nil, errors.Wrapf(err, "break-glass policy %s has an invalid %s", name, PauseRemediationKey)
+		}
+	}
+	return p, nil
+}
+
+// Active returns true if the policy has not expired at the given time.
+func (p *Policy) Active(now time.Time) bool {
+	return now.Before(p.ExpiresAt)
+}
+
+// AppliesTo returns true if the policy applies to the objects of the given
+// kind in the given namespace, which is empty for cluster-scoped objects.
+func (p *Policy) AppliesTo(gk schema.GroupKind, namespace string) bool {
+	if p.Kinds != nil && !containsGroupKind(p.Kinds, gk) {
+		return false
+	}
+	return p.Namespaces == nil || contains(p.Namespaces, namespace)
+}
+
+// Exempts returns true if the policy exempts the given user.
+func (p *Policy) Exempts(user authenticationv1.UserInfo) bool {
+	if contains(p.Users, user.Username) {
+		return true
+	}
+	for _, g := range user.Groups {
+		if contains(p.Groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a description of the policy for audit annotations and Events.
+func (p *Policy) String() string {
+	s := fmt.Sprintf("break-glass policy %s, which expires at %s", p.Name, p.ExpiresAt.Format(time.RFC3339))
+	if p.Reason != "" {
+		s += fmt.Sprintf(" (reason: %s)", p.Reason)
+	}
+	return s
+}
+
+// List returns the break-glass policies. Invalid policies are logged and
+// skipped, so that a typo in one policy does not lock out the users of the
+// others.
+func List(ctx context.Context, reader client.Reader) ([]*Policy, error) {
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: PolicyConfigMapName}
+	if err := reader.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get the break-glass policies")
+	}
+	names := make([]string, 0, len(cm.Data))
+	for name := range cm.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var policies []*Policy
+	for _, name := range names {
+		p, err := Parse(name, cm.Data[name])
+		if err != nil {
+			klog.Warningf("Ignoring invalid break-glass policy: %v", err)
+			continue
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// Exemption returns the active policy among policies which exempts the user
+// from the admission webhook for the given object, or nil if none does.
+func Exemption(policies []*Policy, user authenticationv1.UserInfo, gk schema.GroupKind, namespace string, now time.Time) *Policy {
+	for _, p := range policies {
+		if p.Active(now) && p.AppliesTo(gk, namespace) && p.Exempts(user) {
+			return p
+		}
+	}
+	return nil
+}
+
+// RemediationPause returns the active policy among policies which pauses the
+// remediation of the given object, or nil if none does. If several do, the one
+// which expires last is returned.
+func RemediationPause(policies []*Policy, gk schema.GroupKind, namespace string, now time.Time) *Policy {
+	var result *Policy
+	for _, p := range policies {
+		if !p.PauseRemediation || !p.Active(now) || !p.AppliesTo(gk, namespace) {
+			continue
+		}
+		if result == nil || p.ExpiresAt.After(result.ExpiresAt) {
+			result = p
+		}
+	}
+	return result
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsGroupKind(list []schema.GroupKind, gk schema.GroupKind) bool {
+	for _, item := range list {
+		if item == gk {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breakglass
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+var (
+	now        = time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt  = now.Add(time.Hour)
+	deployment = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+)
+
+// policyConfigMap returns the ConfigMap holding the policies with the given
+// YAML definitions.
+func policyConfigMap(definitions map[string]string) *corev1.ConfigMap {
+	cm := fake.ConfigMapObject(core.Name(PolicyConfigMapName), core.Namespace(configsync.ControllerNamespace))
+	cm.Data = definitions
+	return cm
+}
+
+func TestFromData(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    map[string]string
+		want    *Policy
+		wantErr bool
+	}{
+		{
+			name: "full policy",
+			data: map[string]string{
+				UsersKey:            "alice@acme.com, bob@acme.com",
+				GroupsKey:           "oncall@acme.com",
+				KindsKey:            "Deployment.apps,ConfigMap",
+				NamespacesKey:       "bookstore",
+				ExpiresAtKey:        expiresAt.Format(time.RFC3339),
+				PauseRemediationKey: "true",
+				ReasonKey:           "INC-1234",
+			},
+			want: &Policy{
+				Name:             "incident",
+				Users:            []string{"alice@acme.com", "bob@acme.com"},
+				Groups:           []string{"oncall@acme.com"},
+				Kinds:            []schema.GroupKind{deployment, kinds.ConfigMap().GroupKind()},
+				Namespaces:       []string{"bookstore"},
+				ExpiresAt:        expiresAt,
+				PauseRemediation: true,
+				Reason:           "INC-1234",
+			},
+		},
+		{
+			name: "wildcard kinds",
+			data: map[string]string{
+				GroupsKey:    "oncall@acme.com",
+				KindsKey:     "Deployment.apps,*",
+				ExpiresAtKey: expiresAt.Format(time.RFC3339),
+			},
+			want: &Policy{
+				Name:      "incident",
+				Groups:    []string{"oncall@acme.com"},
+				ExpiresAt: expiresAt,
+			},
+		},
+		{
+			name: "missing users and groups",
+			data: map[string]string{
+				KindsKey:     Wildcard,
+				ExpiresAtKey: expiresAt.Format(time.RFC3339),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing kinds",
+			data: map[string]string{
+				UsersKey:     "alice@acme.com",
+				ExpiresAtKey: expiresAt.Format(time.RFC3339),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing expiresAt",
+			data: map[string]string{
+				UsersKey: "alice@acme.com",
+				KindsKey: Wildcard,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid expiresAt",
+			data: map[string]string{
+				UsersKey:     "alice@acme.com",
+				KindsKey:     Wildcard,
+				ExpiresAtKey: "tomorrow",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid pauseRemediation",
+			data: map[string]string{
+				UsersKey:            "alice@acme.com",
+				KindsKey:            Wildcard,
+				ExpiresAtKey:        expiresAt.Format(time.RFC3339),
+				PauseRemediationKey: "sometimes",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := fromData("incident", tc.data)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("fromData() got error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("incident", `
+users: alice@acme.com
+kinds: "*"
+expiresAt: 2022-08-01T13:00:00Z
+pauseRemediation: true
+`)
+	if err != nil {
+		t.Fatalf("Parse() got error %v, want nil", err)
+	}
+	want := &Policy{
+		Name:             "incident",
+		Users:            []string{"alice@acme.com"},
+		ExpiresAt:        expiresAt,
+		PauseRemediation: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := Parse("incident", "alice@acme.com"); err == nil {
+		t.Error("Parse() of a policy which is not a map got nil error, want an error")
+	}
+}
+
+func TestExemption(t *testing.T) {
+	oncall := &Policy{
+		Name:       "oncall",
+		Groups:     []string{"oncall@acme.com"},
+		Kinds:      []schema.GroupKind{deployment},
+		Namespaces: []string{"bookstore"},
+		ExpiresAt:  expiresAt,
+	}
+	alice := &Policy{
+		Name:      "alice",
+		Users:     []string{"alice@acme.com"},
+		ExpiresAt: expiresAt,
+	}
+	policies := []*Policy{oncall, alice}
+
+	testCases := []struct {
+		name      string
+		user      authenticationv1.UserInfo
+		gk        schema.GroupKind
+		namespace string
+		now       time.Time
+		want      *Policy
+	}{
+		{
+			name:      "group member in listed namespace",
+			user:      authenticationv1.UserInfo{Username: "bob@acme.com", Groups: []string{"oncall@acme.com"}},
+			gk:        deployment,
+			namespace: "bookstore",
+			now:       now,
+			want:      oncall,
+		},
+		{
+			name:      "group member in other namespace",
+			user:      authenticationv1.UserInfo{Username: "bob@acme.com", Groups: []string{"oncall@acme.com"}},
+			gk:        deployment,
+			namespace: "shipping",
+			now:       now,
+		},
+		{
+			name:      "group member with other kind",
+			user:      authenticationv1.UserInfo{Username: "bob@acme.com", Groups: []string{"oncall@acme.com"}},
+			gk:        kinds.ConfigMap().GroupKind(),
+			namespace: "bookstore",
+			now:       now,
+		},
+		{
+			name: "user with all kinds and namespaces",
+			user: authenticationv1.UserInfo{Username: "alice@acme.com"},
+			gk:   kinds.ClusterRole().GroupKind(),
+			now:  now,
+			want: alice,
+		},
+		{
+			name:      "expired",
+			user:      authenticationv1.UserInfo{Username: "alice@acme.com"},
+			gk:        deployment,
+			namespace: "bookstore",
+			now:       expiresAt,
+		},
+		{
+			name:      "other user",
+			user:      authenticationv1.UserInfo{Username: "mallory@acme.com", Groups: []string{"devs@acme.com"}},
+			gk:        deployment,
+			namespace: "bookstore",
+			now:       now,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Exemption(policies, tc.user, tc.gk, tc.namespace, tc.now)
+			if got != tc.want {
+				t.Errorf("Exemption() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRemediationPause(t *testing.T) {
+	notPausing := &Policy{Name: "not-pausing", Users: []string{"alice@acme.com"}, ExpiresAt: expiresAt.Add(time.Hour)}
+	short := &Policy{Name: "short", Users: []string{"alice@acme.com"}, ExpiresAt: expiresAt, PauseRemediation: true}
+	long := &Policy{Name: "long", Users: []string{"bob@acme.com"}, Namespaces: []string{"bookstore"},
+		ExpiresAt: expiresAt.Add(30 * time.Minute), PauseRemediation: true}
+	policies := []*Policy{notPausing, short, long}
+
+	if got := RemediationPause(policies, deployment, "bookstore", now); got != long {
+		t.Errorf("RemediationPause() = %v, want the policy which expires last %v", got, long)
+	}
+	if got := RemediationPause(policies, deployment, "shipping", now); got != short {
+		t.Errorf("RemediationPause() = %v, want %v", got, short)
+	}
+	if got := RemediationPause(policies, deployment, "shipping", expiresAt); got != nil {
+		t.Errorf("RemediationPause() = %v, want nil after expiry", got)
+	}
+}
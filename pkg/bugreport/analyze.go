@@ -0,0 +1,226 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Finding is a failure pattern detected in a bug report.
+type Finding struct {
+	// Problem identifies the failure pattern, such as a KNV error code.
+	Problem string
+	// Cause describes the likely cause of the problem.
+	Cause string
+	// Count is the number of times the problem was detected.
+	Count int
+	// Example is the location of the first detection, as the path of the file
+	// in the bug report followed by the line number, if any.
+	Example string
+}
+
+// knvCauses describe the likely causes of the most common KNV errors.
+var knvCauses = map[string]string{
+	"1021": "A declared object has a kind which is not registered on the cluster. Its CRD may be missing or not yet established.",
+	"1060": "A declared object is already managed by another RootSync or RepoSync.",
+	"1061": "The RootSync or RepoSync spec is invalid.",
+	"1068": "Rendering the Kustomize or Helm configuration of the source failed.",
+	"1069": "A kustomization references a resource, base, component, patch or file which does not exist.",
+	"1070": "A kustomization file of the source is invalid.",
+	"1071": "A generator or transformer plugin of a kustomization, or a Helm chart, failed.",
+	"2004": "The reconciler failed to read the source. Check the repository, revision and credentials.",
+	"2006": "The source is empty, so syncing it would delete every managed object.",
+	"2008": "A declared object was modified on the cluster while it was being applied.",
+	"2009": "The applier failed to apply or prune some objects. Check the reconciler logs for them.",
+	"2010": "The API server rejected a declared object.",
+	"2013": "The reconciler is missing RBAC permissions to manage the declared objects.",
+	"2015": "Rendering the source failed because of an internal error of the hydration controller.",
+	"2016": "The signature of the source could not be verified.",
+	"2017": "Syncing the source would delete more objects than allowed by the deletion budget.",
+	"2018": "A declared object references a cluster variable which has no value on this cluster.",
+}
+
+// lineRule detects a failure pattern in the lines of the files of a bug report.
+type lineRule struct {
+	re *regexp.Regexp
+	// finding returns the problem and cause of a match of re.
+	finding func(match []string) (problem, cause string)
+}
+
+var lineRules = []lineRule{
+	{
+		re: regexp.MustCompile(`KNV(\d{4})`),
+		finding: func(match []string) (string, string) {
+			cause, found := knvCauses[match[1]]
+			if !found {
+				cause = "See https://g.co/cloud/acm-errors#knv" + match[1]
+			}
+			return "KNV" + match[1], cause
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)authentication failed|permission denied \(publickey\)|could not read username|\b401 unauthorized\b|\b403 forbidden\b|invalid username or password`),
+		finding: func([]string) (string, string) {
+			return "AuthenticationFailure", "The reconciler failed to authenticate to the source. Check the auth type of the spec and the credentials in its Secret."
+		},
+	},
+	{
+		re: regexp.MustCompile(`Host key verification failed`),
+		finding: func([]string) (string, string) {
+			return "HostKeyVerificationFailure", "The host key of the Git server is not in the known_hosts of the Secret of the RootSync or RepoSync."
+		},
+	},
+	{
+		re: regexp.MustCompile(`admission webhook "([^"]+)" denied the request`),
+		finding: func(match []string) (string, string) {
+			return "WebhookDenial", fmt.Sprintf("The admission webhook %q rejected objects applied by the reconciler. Check the policies of the webhook.", match[1])
+		},
+	},
+}
+
+// Analyze detects the common failure patterns in the bug report zip file at
+// zipPath and returns the findings, sorted by problem and cause.
+func Analyze(zipPath string) ([]Finding, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bug report %s: %v", zipPath, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	a := &analyzer{findings: map[[2]string]*Finding{}}
+	for _, f := range r.File {
+		// Every resource is written as both JSON and YAML, so skip the YAML
+		// copies to not count their problems twice.
+		if f.FileInfo().IsDir() || strings.HasSuffix(f.Name, "_yaml.txt") {
+			continue
+		}
+		if err := a.analyzeFile(f); err != nil {
+			return nil, err
+		}
+	}
+	return a.sortedFindings(), nil
+}
+
+type analyzer struct {
+	// findings are keyed by problem and cause.
+	findings map[[2]string]*Finding
+}
+
+func (a *analyzer) add(problem, cause, location string) {
+	key := [2]string{problem, cause}
+	f, found := a.findings[key]
+	if !found {
+		f = &Finding{Problem: problem, Cause: cause, Example: location}
+		a.findings[key] = f
+	}
+	f.Count++
+}
+
+func (a *analyzer) sortedFindings() []Finding {
+	var result []Finding
+	for _, f := range a.findings {
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Problem != result[j].Problem {
+			return result[i].Problem < result[j].Problem
+		}
+		return result[i].Cause < result[j].Cause
+	})
+	return result
+}
+
+func (a *analyzer) analyzeFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s in bug report: %v", f.Name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	if path.Base(f.Name) == "pods.txt" {
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return fmt.Errorf("failed to read %s in bug report: %v", f.Name, err)
+		}
+		a.analyzePods(f.Name, data)
+		return nil
+	}
+
+	reader := bufio.NewReader(rc)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		a.analyzeLine(fmt.Sprintf("%s:%d", f.Name, lineNumber), line)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s in bug report: %v", f.Name, err)
+		}
+	}
+}
+
+func (a *analyzer) analyzeLine(location, line string) {
+	for _, rule := range lineRules {
+		// Count each problem once per line.
+		seen := map[[2]string]bool{}
+		for _, match := range rule.re.FindAllStringSubmatch(line, -1) {
+			problem, cause := rule.finding(match)
+			if key := [2]string{problem, cause}; !seen[key] {
+				seen[key] = true
+				a.add(problem, cause, location)
+			}
+		}
+	}
+}
+
+// analyzePods detects the containers which were killed because they ran out of
+// memory or which are crash looping, in the pods listed by FetchCMSystemPods.
+// The invalid pod lists are ignored, since they are not worth failing the
+// analysis of the rest of the bug report.
+func (a *analyzer) analyzePods(name string, data []byte) {
+	pods := &corev1.PodList{}
+	if err := json.Unmarshal(data, pods); err != nil {
+		return
+	}
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			location := fmt.Sprintf("%s: %s/%s/%s", name, pod.Namespace, pod.Name, cs.Name)
+			if isOOMKilled(cs.State) || isOOMKilled(cs.LastTerminationState) {
+				a.add("OOMKilled", fmt.Sprintf("The %s container of the %s/%s pod ran out of memory. Raise its memory limit, for example with spec.override.resources of its RootSync or RepoSync.", cs.Name, pod.Namespace, pod.Name), location)
+			}
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == "CrashLoopBackOff" {
+				a.add("CrashLoopBackOff", fmt.Sprintf("The %s container of the %s/%s pod is crashing repeatedly. Check its logs for the error.", cs.Name, pod.Namespace, pod.Name), location)
+			}
+		}
+	}
+}
+
+func isOOMKilled(state corev1.ContainerState) bool {
+	return state.Terminated != nil && state.Terminated.Reason == "OOMKilled"
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnalyze(t *testing.T) {
+	pods := &corev1.PodList{Items: []corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "config-management-system", Name: "root-reconciler-abc"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{
+				Name:  "reconciler",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+				},
+			},
+			{
+				Name:  "git-sync",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			},
+		}},
+	}}}
+	podsJSON, err := json.Marshal(pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reconcilerLog := `E1017 reconciler.go:10] KNV2009: failed to apply: admission webhook "validation.gatekeeper.sh" denied the request: missing label
+E1017 reconciler.go:11] KNV2009: failed to apply
+E1017 reconciler.go:12] KNV1099: unknown and KNV1099 again
+`
+	gitSyncLog := `E1017 main.go:20] fatal: Authentication failed for 'https://<REDACTED>@github.com/org/repo.git'
+`
+	files := map[string]string{
+		"bug_report_1/raw/ctx/namespaces/config-management-system/pods.txt":                           string(podsJSON),
+		"bug_report_1/raw/ctx/namespaces/config-management-system/pods_yaml.txt":                      "KNV2009",
+		"bug_report_1/raw/ctx/namespaces/config-management-system/root-reconciler-abc/reconciler.txt": reconcilerLog,
+		"bug_report_1/raw/ctx/namespaces/config-management-system/root-reconciler-abc/git-sync.txt":   gitSyncLog,
+		"bug_report_1/raw/ctx/namespaces/config-management-system/root-reconciler-abc/otel-agent.txt": "all good",
+		"bug_report_1/processed/ctx/snapshots/config-management-system/RootSync-root-sync_yaml.txt":   "KNV2009",
+	}
+	zipPath := filepath.Join(t.TempDir(), "bug_report_1.zip")
+	writeTestZip(t, zipPath, files)
+
+	findings, err := Analyze(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logDir := "bug_report_1/raw/ctx/namespaces/config-management-system/root-reconciler-abc/"
+	podLocation := "bug_report_1/raw/ctx/namespaces/config-management-system/pods.txt: config-management-system/root-reconciler-abc/reconciler"
+	expected := []Finding{
+		{
+			Problem: "AuthenticationFailure",
+			Cause:   "The reconciler failed to authenticate to the source. Check the auth type of the spec and the credentials in its Secret.",
+			Count:   1,
+			Example: logDir + "git-sync.txt:1",
+		},
+		{
+			Problem: "CrashLoopBackOff",
+			Cause:   "The reconciler container of the config-management-system/root-reconciler-abc pod is crashing repeatedly. Check its logs for the error.",
+			Count:   1,
+			Example: podLocation,
+		},
+		{
+			Problem: "KNV1099",
+			Cause:   "See https://g.co/cloud/acm-errors#knv1099",
+			Count:   1,
+			Example: logDir + "reconciler.txt:3",
+		},
+		{
+			Problem: "KNV2009",
+			Cause:   knvCauses["2009"],
+			Count:   2,
+			Example: logDir + "reconciler.txt:1",
+		},
+		{
+			Problem: "OOMKilled",
+			Cause:   "The reconciler container of the config-management-system/root-reconciler-abc pod ran out of memory. Raise its memory limit, for example with spec.override.resources of its RootSync or RepoSync.",
+			Count:   1,
+			Example: podLocation,
+		},
+		{
+			Problem: "WebhookDenial",
+			Cause:   `The admission webhook "validation.gatekeeper.sh" rejected objects applied by the reconciler. Check the policies of the webhook.`,
+			Count:   1,
+			Example: logDir + "reconciler.txt:1",
+		},
+	}
+	if diff := cmp.Diff(expected, findings); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestAnalyzeMissingFile(t *testing.T) {
+	if _, err := Analyze(filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("expected an error for a missing bug report")
+	}
+}
+
+func writeTestZip(t *testing.T, zipPath string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
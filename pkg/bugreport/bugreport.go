@@ -92,10 +92,35 @@ type BugReporter struct {
 	name          string
 	ErrorList     []error
 	WritingErrors []error
+	// since limits the logs to the given time window, if positive.
+	since time.Duration
+	// redactors redact the sensitive data from every file written to the report.
+	redactors []Redactor
+}
+
+// Options configures the data gathered by a BugReporter.
+type Options struct {
+	// Since limits the container logs to the ones newer than this duration.
+	// All the logs are gathered if it is zero.
+	Since time.Duration
+	// RedactionPatterns are the regular expressions matching the sensitive data
+	// to redact, in addition to DefaultRedactionPatterns.
+	RedactionPatterns []string
 }
 
 // New creates a new BugReport
-func New(ctx context.Context, cfg *rest.Config) (*BugReporter, error) {
+func New(ctx context.Context, cfg *rest.Config, opts Options) (*BugReporter, error) {
+	if opts.Since < 0 {
+		return nil, fmt.Errorf("invalid time window %v: must not be negative", opts.Since)
+	}
+	var patterns []string
+	patterns = append(patterns, DefaultRedactionPatterns...)
+	patterns = append(patterns, opts.RedactionPatterns...)
+	redactor, err := NewPatternRedactor(patterns)
+	if err != nil {
+		return nil, err
+	}
+
 	cs, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -143,6 +168,8 @@ func New(ctx context.Context, cfg *rest.Config) (*BugReporter, error) {
 		k8sContext:    currentk8sContext,
 		ErrorList:     errorList,
 		WritingErrors: []error{},
+		since:         opts.Since,
+		redactors:     []Redactor{redactor},
 	}, nil
 }
 
@@ -266,7 +293,7 @@ func (b *BugReporter) logSourcesForNamespace(ctx context.Context, name string, l
 		return nil, wrap(err, "failed to list pods for namespace %v", name)
 	}
 
-	return assembleLogSources(*ns, *pods), nil
+	return assembleLogSources(*ns, *pods, b.since), nil
 }
 
 func (b *BugReporter) fetchNamespace(ctx context.Context, name string, nsLabels map[string]string) (*corev1.Namespace, error) {
@@ -298,14 +325,15 @@ func (b *BugReporter) listPods(ctx context.Context, ns corev1.Namespace, lOps cl
 	return pods, nil
 }
 
-func assembleLogSources(ns corev1.Namespace, pods corev1.PodList) logSources {
+func assembleLogSources(ns corev1.Namespace, pods corev1.PodList, since time.Duration) logSources {
 	var ls logSources
 	for _, p := range pods.Items {
 		for _, c := range p.Spec.Containers {
 			ls = append(ls, &logSource{
-				ns:   ns,
-				pod:  p,
-				cont: c,
+				ns:    ns,
+				pod:   p,
+				cont:  c,
+				since: since,
 			})
 		}
 	}
@@ -363,7 +391,7 @@ func (b *BugReporter) fetchConfigMaps(ctx context.Context) (rd []Readable) {
 			if strings.HasSuffix(cm.Name, "git-sync") {
 				for _, k := range redactKeys {
 					if _, ok := cm.Data[k]; ok {
-						cm.Data[k] = "<REDACTED>"
+						cm.Data[k] = Redacted
 					}
 				}
 			}
@@ -487,7 +515,8 @@ func (b *BugReporter) copyToZip(inputFile *os.File, zipFileName string) error {
 		e := fmt.Errorf("failed to create file %v inside zip: %v", fileName, err)
 		return e
 	}
-	if _, err = io.Copy(zipFile, inputFile); err != nil {
+	redacted := redactReadable(Readable{Name: zipFileName, ReadCloser: inputFile}, b.redactors)
+	if _, err = io.Copy(zipFile, redacted); err != nil {
 		return fmt.Errorf("failed to copy file %s to zip %s: %v", inputFile.Name(), fileName, err)
 	}
 	if err = inputFile.Close(); err != nil {
@@ -522,6 +551,7 @@ func getReportName() string {
 }
 
 func (b *BugReporter) writeReadableToZip(readable Readable) error {
+	readable = redactReadable(readable, b.redactors)
 	baseName := filepath.Base(b.name)
 	dirName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
 	fileName := filepath.FromSlash(filepath.Join(dirName, readable.Name) + ".txt")
@@ -562,6 +592,17 @@ func (b *BugReporter) WriteRawInZip(toBeRead []Readable) {
 
 }
 
+// WriteProcessedInZip writes the data processed from the kubernetes resources
+// to bugreport zip file
+func (b *BugReporter) WriteProcessedInZip(toBeRead []Readable) {
+	for _, readable := range toBeRead {
+		readable.Name = path.Join(Processed, b.k8sContext, readable.Name)
+		if err := b.writeReadableToZip(readable); err != nil {
+			b.WritingErrors = append(b.WritingErrors, err)
+		}
+	}
+}
+
 // Close closes all file streams
 func (b *BugReporter) Close() {
 
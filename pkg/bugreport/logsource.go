@@ -18,6 +18,7 @@ import (
 	"context"
 	"io"
 	"path"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 )
@@ -26,6 +27,8 @@ type logSource struct {
 	ns   v1.Namespace
 	pod  v1.Pod
 	cont v1.Container
+	// since limits the logs to the given time window, if positive.
+	since time.Duration
 }
 
 func (l *logSource) pathName() string {
@@ -33,6 +36,17 @@ func (l *logSource) pathName() string {
 }
 
 func (l *logSource) fetchRcForLogSource(ctx context.Context, cs coreClient) (io.ReadCloser, error) {
-	options := v1.PodLogOptions{Timestamps: true, Container: l.cont.Name}
+	options := l.podLogOptions()
 	return cs.CoreV1().Pods(l.ns.Name).GetLogs(l.pod.Name, &options).Stream(ctx)
 }
+
+func (l *logSource) podLogOptions() v1.PodLogOptions {
+	options := v1.PodLogOptions{Timestamps: true, Container: l.cont.Name}
+	if l.since > 0 {
+		// The API server only accepts whole seconds, so round up to not miss
+		// the logs at the start of the time window.
+		seconds := int64((l.since + time.Second - 1) / time.Second)
+		options.SinceSeconds = &seconds
+	}
+	return options
+}
@@ -16,8 +16,11 @@ package bugreport
 
 import (
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 	"kpt.dev/configsync/pkg/testing/fake"
 )
 
@@ -49,3 +52,37 @@ func TestLogSourceGetPathName(t *testing.T) {
 		})
 	}
 }
+
+func TestLogSourcePodLogOptions(t *testing.T) {
+	tests := []struct {
+		name                 string
+		since                time.Duration
+		expectedSinceSeconds *int64
+	}{
+		{
+			name: "all logs without a time window",
+		},
+		{
+			name:                 "time window in whole seconds",
+			since:                2 * time.Hour,
+			expectedSinceSeconds: pointer.Int64(7200),
+		},
+		{
+			name:                 "time window rounded up to whole seconds",
+			since:                1500 * time.Millisecond,
+			expectedSinceSeconds: pointer.Int64(2),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			source := logSource{cont: *fake.ContainerObject("myContainer"), since: test.since}
+			expected := v1.PodLogOptions{Timestamps: true, Container: "myContainer", SinceSeconds: test.expectedSinceSeconds}
+			if diff := cmp.Diff(expected, source.podLogOptions()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
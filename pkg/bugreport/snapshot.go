@@ -0,0 +1,268 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bugreport
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/GoogleContainerTools/kpt/pkg/live"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/kinds"
+)
+
+// Snapshots is the directory of the snapshots in the processed part of the
+// bug report.
+const Snapshots = "snapshots"
+
+// syncSnapshot summarizes the spec and status of a RootSync or RepoSync.
+type syncSnapshot struct {
+	Kind               string `json:"kind"`
+	Namespace          string `json:"namespace"`
+	Name               string `json:"name"`
+	Generation         int64  `json:"generation"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+	SourceType         string `json:"sourceType,omitempty"`
+	Source             string `json:"source,omitempty"`
+	// Path is the directory of a Git repository or OCI image, or the chart of
+	// a Helm repository.
+	Path             string                               `json:"path,omitempty"`
+	Revision         string                               `json:"revision,omitempty"`
+	SourceCommit     string                               `json:"sourceCommit,omitempty"`
+	RenderingCommit  string                               `json:"renderingCommit,omitempty"`
+	SyncCommit       string                               `json:"syncCommit,omitempty"`
+	LastSyncedCommit string                               `json:"lastSyncedCommit,omitempty"`
+	Conditions       []conditionSnapshot                  `json:"conditions,omitempty"`
+	Errors           map[string][]v1beta1.ConfigSyncError `json:"errors,omitempty"`
+}
+
+// conditionSnapshot is a condition of a RootSync, RepoSync or ResourceGroup.
+type conditionSnapshot struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// resourceGroupSnapshot summarizes the status of the resources of a
+// ResourceGroup.
+type resourceGroupSnapshot struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Resources int    `json:"resources"`
+	// StatusCounts counts the resources by status.
+	StatusCounts map[string]int `json:"statusCounts,omitempty"`
+	// NotCurrent lists the resources which are not Current.
+	NotCurrent []resourceSnapshot  `json:"notCurrent,omitempty"`
+	Conditions []conditionSnapshot `json:"conditions,omitempty"`
+}
+
+// resourceSnapshot is a resource of a ResourceGroup.
+type resourceSnapshot struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+}
+
+// FetchSnapshots provides a set of Readables summarizing the state of the
+// RootSync, RepoSync and ResourceGroup objects on the cluster.
+func (b *BugReporter) FetchSnapshots(ctx context.Context) []Readable {
+	var rd []Readable
+
+	for _, gvk := range []schema.GroupVersionKind{kinds.RootSyncV1Beta1(), kinds.RepoSyncV1Beta1()} {
+		ul, ok := b.listForSnapshots(ctx, gvk)
+		if !ok {
+			continue
+		}
+		for _, u := range ul.Items {
+			snapshot, err := newSyncSnapshot(u)
+			if err != nil {
+				b.ErrorList = append(b.ErrorList, err)
+				continue
+			}
+			rd = b.appendPrettyJSON(rd, pathToSnapshot(u), snapshot)
+		}
+	}
+
+	if ul, ok := b.listForSnapshots(ctx, live.ResourceGroupGVK); ok {
+		for _, u := range ul.Items {
+			rd = b.appendPrettyJSON(rd, pathToSnapshot(u), newResourceGroupSnapshot(u))
+		}
+	}
+	return rd
+}
+
+// listForSnapshots lists the objects of the given kind. It returns false if
+// the kind is not registered with the cluster or the objects can not be
+// listed.
+func (b *BugReporter) listForSnapshots(ctx context.Context, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, bool) {
+	ul := &unstructured.UnstructuredList{}
+	ul.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := b.client.List(ctx, ul); err != nil {
+		if meta.IsNoMatchError(err) {
+			fmt.Println("No match for " + gvk.String())
+		} else {
+			b.ErrorList = append(b.ErrorList, fmt.Errorf("failed to list %s resources: %v", gvk.Kind, err))
+		}
+		return nil, false
+	}
+	return ul, true
+}
+
+func pathToSnapshot(u unstructured.Unstructured) string {
+	return path.Join(Snapshots, u.GetNamespace(), u.GetKind()+"-"+u.GetName())
+}
+
+// newSyncSnapshot summarizes the RootSync or RepoSync u.
+func newSyncSnapshot(u unstructured.Unstructured) (*syncSnapshot, error) {
+	snapshot := &syncSnapshot{
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Generation: u.GetGeneration(),
+	}
+	var status v1beta1.Status
+	switch u.GetKind() {
+	case kinds.RootSyncV1Beta1().Kind:
+		rs := &v1beta1.RootSync{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rs); err != nil {
+			return nil, fmt.Errorf("failed to convert RootSync %s/%s: %v", u.GetNamespace(), u.GetName(), err)
+		}
+		snapshot.setSource(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm)
+		for _, c := range rs.Status.Conditions {
+			snapshot.Conditions = append(snapshot.Conditions, conditionSnapshot{
+				Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+			})
+		}
+		status = rs.Status.Status
+	case kinds.RepoSyncV1Beta1().Kind:
+		rs := &v1beta1.RepoSync{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rs); err != nil {
+			return nil, fmt.Errorf("failed to convert RepoSync %s/%s: %v", u.GetNamespace(), u.GetName(), err)
+		}
+		snapshot.setSource(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm)
+		for _, c := range rs.Status.Conditions {
+			snapshot.Conditions = append(snapshot.Conditions, conditionSnapshot{
+				Type: string(c.Type), Status: string(c.Status), Reason: c.Reason, Message: c.Message,
+			})
+		}
+		status = rs.Status.Status
+	default:
+		return nil, fmt.Errorf("unexpected kind %q for %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+	}
+
+	snapshot.ObservedGeneration = status.ObservedGeneration
+	snapshot.SourceCommit = status.Source.Commit
+	snapshot.RenderingCommit = status.Rendering.Commit
+	snapshot.SyncCommit = status.Sync.Commit
+	snapshot.LastSyncedCommit = status.LastSyncedCommit
+	errs := map[string][]v1beta1.ConfigSyncError{
+		"source":    status.Source.Errors,
+		"rendering": status.Rendering.Errors,
+		"sync":      status.Sync.Errors,
+	}
+	for stage, e := range errs {
+		if len(e) == 0 {
+			delete(errs, stage)
+		}
+	}
+	if len(errs) > 0 {
+		snapshot.Errors = errs
+	}
+	return snapshot, nil
+}
+
+func (s *syncSnapshot) setSource(sourceType string, git *v1beta1.Git, oci *v1beta1.Oci, helm *v1beta1.Helm) {
+	s.SourceType = sourceType
+	switch {
+	case git != nil && (sourceType == "" || sourceType == string(v1beta1.GitSource)):
+		s.Source = git.Repo
+		s.Path = git.Dir
+		s.Revision = git.Revision
+		if s.Revision == "" {
+			s.Revision = git.Branch
+		}
+	case oci != nil && sourceType == string(v1beta1.OciSource):
+		s.Source = oci.Image
+		s.Path = oci.Dir
+	case helm != nil && sourceType == string(v1beta1.HelmSource):
+		s.Source = helm.Repo
+		s.Path = helm.Chart
+		s.Revision = helm.Version
+	}
+}
+
+// newResourceGroupSnapshot summarizes the ResourceGroup u.
+func newResourceGroupSnapshot(u unstructured.Unstructured) *resourceGroupSnapshot {
+	snapshot := &resourceGroupSnapshot{
+		Namespace:    u.GetNamespace(),
+		Name:         u.GetName(),
+		StatusCounts: map[string]int{},
+	}
+	statuses, _, _ := unstructured.NestedSlice(u.Object, "status", "resourceStatuses")
+	for _, s := range statuses {
+		m, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		r := resourceSnapshot{}
+		r.Group, _, _ = unstructured.NestedString(m, "group")
+		r.Kind, _, _ = unstructured.NestedString(m, "kind")
+		r.Namespace, _, _ = unstructured.NestedString(m, "namespace")
+		r.Name, _, _ = unstructured.NestedString(m, "name")
+		r.Status, _, _ = unstructured.NestedString(m, "status")
+		snapshot.Resources++
+		snapshot.StatusCounts[r.Status]++
+		if r.Status != "Current" {
+			snapshot.NotCurrent = append(snapshot.NotCurrent, r)
+		}
+	}
+	sort.Slice(snapshot.NotCurrent, func(i, j int) bool {
+		a, b := snapshot.NotCurrent[i], snapshot.NotCurrent[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cs := conditionSnapshot{}
+		cs.Type, _, _ = unstructured.NestedString(m, "type")
+		cs.Status, _, _ = unstructured.NestedString(m, "status")
+		cs.Reason, _, _ = unstructured.NestedString(m, "reason")
+		cs.Message, _, _ = unstructured.NestedString(m, "message")
+		snapshot.Conditions = append(snapshot.Conditions, cs)
+	}
+	return snapshot
+}
@@ -0,0 +1,270 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declared
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeletionBudgetErrorCode is the error code for a DeletionBudgetError.
+const DeletionBudgetErrorCode = "2017"
+
+var deletionBudgetErrorBuilder = status.NewErrorBuilder(DeletionBudgetErrorCode)
+
+// WildcardKind is the kind of a DeletionBudget which applies to every kind
+// without a budget of its own.
+const WildcardKind = "*"
+
+// DeletionSafeguard blocks the deletion of more managed objects of a kind than
+// the deletion budgets of the RootSync or RepoSync allow, unless the commit
+// which deletes them is approved with the ApproveDeletionsAnnotationKey
+// annotation on the RootSync or RepoSync.
+//
+// It checks the deletions of a commit against the previously declared objects
+// when the declared resources are updated, against the inventory before the
+// applier prunes, and the deletions of the remediator between two updates.
+//
+// A nil DeletionSafeguard allows all deletions.
+type DeletionSafeguard struct {
+	budgets  []v1beta1.DeletionBudget
+	reader   client.Reader
+	scope    Scope
+	syncName string
+
+	mux sync.Mutex
+	// commit is the commit of the last update of the declared resources.
+	commit string
+	// declared counts the declared objects of commit per GroupKind.
+	declared map[schema.GroupKind]int
+	// remediated counts the objects per GroupKind which the remediator deleted
+	// since the last update of the declared resources.
+	remediated map[schema.GroupKind]int
+}
+
+// NewDeletionSafeguard returns a DeletionSafeguard enforcing budgets, which
+// reads the approvals from the RootSync or RepoSync with reader. It returns nil
+// if there are no budgets.
+func NewDeletionSafeguard(budgets []v1beta1.DeletionBudget, reader client.Reader, scope Scope, syncName string) *DeletionSafeguard {
+	if len(budgets) == 0 {
+		return nil
+	}
+	return &DeletionSafeguard{
+		budgets:    budgets,
+		reader:     reader,
+		scope:      scope,
+		syncName:   syncName,
+		declared:   make(map[schema.GroupKind]int),
+		remediated: make(map[schema.GroupKind]int),
+	}
+}
+
+// Update checks the deletion of the objects in previous which are not in
+// current against the budgets. On success, the remediator deletions are
+// checked against current until the next Update.
+func (s *DeletionSafeguard) Update(ctx context.Context, commit string, previous, current []core.ID) status.Error {
+	if s == nil {
+		return nil
+	}
+	if err := s.check(ctx, commit, previous, current); err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.commit = commit
+	s.declared = countByGroupKind(current)
+	s.remediated = make(map[schema.GroupKind]int)
+	return nil
+}
+
+// CheckPrune checks the deletion of the objects in inventory which are not in
+// desired against the budgets, on behalf of the commit of the last Update.
+func (s *DeletionSafeguard) CheckPrune(ctx context.Context, inventory, desired []core.ID) status.Error {
+	if s == nil {
+		return nil
+	}
+	s.mux.Lock()
+	commit := s.commit
+	s.mux.Unlock()
+	return s.check(ctx, commit, inventory, desired)
+}
+
+// CheckRemediation checks the deletion of the undeclared object by the
+// remediator against the budgets, relative to the objects declared by the last
+// Update. Every allowed deletion counts against the budget until the next
+// Update.
+func (s *DeletionSafeguard) CheckRemediation(ctx context.Context, id core.ID) status.Error {
+	if s == nil {
+		return nil
+	}
+	// The lock is held while reading the approval so that concurrent workers
+	// cannot delete more objects than the budget allows between them.
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	gk := id.GroupKind
+	budget := s.budgetFor(gk)
+	if budget == nil {
+		return nil
+	}
+	deleted := s.remediated[gk] + 1
+	if deleted > limit(*budget, s.declared[gk]) {
+		exceeded := []string{describe(gk, deleted, s.declared[gk], *budget)}
+		if !s.approved(ctx, s.commit, exceeded) {
+			return DeletionBudgetError(s.commit, s.syncString(), exceeded)
+		}
+	}
+	s.remediated[gk] = deleted
+	return nil
+}
+
+// check returns a DeletionBudgetError if deleting the objects in previous
+// which are not in current exceeds a budget, and commit is not approved.
+func (s *DeletionSafeguard) check(ctx context.Context, commit string, previous, current []core.ID) status.Error {
+	currentSet := make(map[core.ID]struct{}, len(current))
+	for _, id := range current {
+		currentSet[id] = struct{}{}
+	}
+	total := countByGroupKind(previous)
+	deleted := make(map[schema.GroupKind]int)
+	for _, id := range previous {
+		if _, found := currentSet[id]; !found {
+			deleted[id.GroupKind]++
+		}
+	}
+
+	var exceeded []string
+	for gk, n := range deleted {
+		budget := s.budgetFor(gk)
+		if budget != nil && n > limit(*budget, total[gk]) {
+			exceeded = append(exceeded, describe(gk, n, total[gk], *budget))
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	sort.Strings(exceeded)
+	if s.approved(ctx, commit, exceeded) {
+		return nil
+	}
+	return DeletionBudgetError(commit, s.syncString(), exceeded)
+}
+
+// budgetFor returns the budget of the GroupKind, falling back to the wildcard
+// budget, or nil if neither exists.
+func (s *DeletionSafeguard) budgetFor(gk schema.GroupKind) *v1beta1.DeletionBudget {
+	var wildcard *v1beta1.DeletionBudget
+	for i, b := range s.budgets {
+		if b.Kind == WildcardKind {
+			wildcard = &s.budgets[i]
+		} else if b.Group == gk.Group && b.Kind == gk.Kind {
+			return &s.budgets[i]
+		}
+	}
+	return wildcard
+}
+
+// approved returns true if the RootSync or RepoSync approves the deletions of
+// commit with the ApproveDeletionsAnnotationKey annotation.
+func (s *DeletionSafeguard) approved(ctx context.Context, commit string, exceeded []string) bool {
+	if commit == "" {
+		return false
+	}
+	var obj client.Object
+	key := client.ObjectKey{Name: s.syncName}
+	if s.scope == RootReconciler {
+		obj = &v1beta1.RootSync{}
+		key.Namespace = configsync.ControllerNamespace
+	} else {
+		obj = &v1beta1.RepoSync{}
+		key.Namespace = string(s.scope)
+	}
+	if err := s.reader.Get(ctx, key, obj); err != nil {
+		klog.Warningf("Failed to read the deletion approval of %s: %v", s.syncString(), err)
+		return false
+	}
+	if core.GetAnnotation(obj, metadata.ApproveDeletionsAnnotationKey) != commit {
+		return false
+	}
+	klog.Infof("The deletions of commit %s exceeding the deletion budgets are approved by %s: %s",
+		commit, s.syncString(), strings.Join(exceeded, "; "))
+	return true
+}
+
+func (s *DeletionSafeguard) syncString() string {
+	if s.scope == RootReconciler {
+		return fmt.Sprintf("RootSync %s/%s", configsync.ControllerNamespace, s.syncName)
+	}
+	return fmt.Sprintf("RepoSync %s/%s", s.scope, s.syncName)
+}
+
+// limit returns the number of objects out of total which the budget allows to
+// delete.
+func limit(budget v1beta1.DeletionBudget, total int) int {
+	result := math.MaxInt32
+	if budget.MaxCount != nil {
+		result = int(*budget.MaxCount)
+	}
+	if budget.MaxPercent != nil {
+		if n := int(*budget.MaxPercent) * total / 100; n < result {
+			result = n
+		}
+	}
+	return result
+}
+
+func describe(gk schema.GroupKind, deleted, total int, budget v1beta1.DeletionBudget) string {
+	var limits []string
+	if budget.MaxCount != nil {
+		limits = append(limits, fmt.Sprintf("maxCount %d", *budget.MaxCount))
+	}
+	if budget.MaxPercent != nil {
+		limits = append(limits, fmt.Sprintf("maxPercent %d", *budget.MaxPercent))
+	}
+	return fmt.Sprintf("%d of %d %s objects (budget: %s)", deleted, total, gk, strings.Join(limits, ", "))
+}
+
+func countByGroupKind(ids []core.ID) map[schema.GroupKind]int {
+	result := make(map[schema.GroupKind]int)
+	for _, id := range ids {
+		result[id.GroupKind]++
+	}
+	return result
+}
+
+// DeletionBudgetError reports that a commit would delete more objects than the
+// deletion budgets of a RootSync or RepoSync allow. The sync is blocked until
+// the source is fixed, or the deletions are approved.
+func DeletionBudgetError(commit, sync string, exceeded []string) status.Error {
+	return deletionBudgetErrorBuilder.Sprintf(
+		"Commit %q would delete more objects than the deletion budgets of %s allow: %s. "+
+			"If this is not intended, fix the source of truth. "+
+			"If it is, approve the deletions by annotating the %s with %s: %q.",
+		commit, sync, strings.Join(exceeded, "; "), sync, metadata.ApproveDeletionsAnnotationKey, commit).Build()
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package declared
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	syncertestfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	rootSyncString = "RootSync config-management-system/root-sync"
+	commit         = "abc123"
+)
+
+func ids(gk func() core.ID, n int) []core.ID {
+	var result []core.ID
+	for i := 0; i < n; i++ {
+		id := gk()
+		id.Name = fmt.Sprintf("%s-%d", id.Name, i)
+		result = append(result, id)
+	}
+	return result
+}
+
+func deploymentID() core.ID {
+	return core.ID{GroupKind: kinds.Deployment().GroupKind(), ObjectKey: client.ObjectKey{Namespace: "bookstore", Name: "deployment"}}
+}
+
+func undeclaredDeploymentID() core.ID {
+	return core.ID{GroupKind: kinds.Deployment().GroupKind(), ObjectKey: client.ObjectKey{Namespace: "bookstore", Name: "undeclared"}}
+}
+
+func configMapID() core.ID {
+	return core.ID{GroupKind: kinds.ConfigMap().GroupKind(), ObjectKey: client.ObjectKey{Namespace: "bookstore", Name: "configmap"}}
+}
+
+func newTestSafeguard(t *testing.T, budgets []v1beta1.DeletionBudget, approvedCommit string) *DeletionSafeguard {
+	t.Helper()
+	rs := fake.RootSyncObjectV1Beta1("root-sync")
+	if approvedCommit != "" {
+		core.SetAnnotation(rs, metadata.ApproveDeletionsAnnotationKey, approvedCommit)
+	}
+	return NewDeletionSafeguard(budgets, syncertestfake.NewClient(t, runtime.NewScheme(), rs), RootReconciler, "root-sync")
+}
+
+func TestDeletionSafeguard_Update(t *testing.T) {
+	deployments := ids(deploymentID, 10)
+	configMaps := ids(configMapID, 10)
+
+	testCases := []struct {
+		name     string
+		budgets  []v1beta1.DeletionBudget
+		approved string
+		previous []core.ID
+		current  []core.ID
+		want     status.Error
+	}{
+		{
+			name:     "no budgets",
+			previous: deployments,
+		},
+		{
+			name:     "within count budget",
+			budgets:  []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(3)}},
+			previous: deployments,
+			current:  deployments[3:],
+		},
+		{
+			name:     "exceeds count budget",
+			budgets:  []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(3)}},
+			previous: deployments,
+			current:  deployments[4:],
+			want: DeletionBudgetError(commit, rootSyncString,
+				[]string{"4 of 10 Deployment.apps objects (budget: maxCount 3)"}),
+		},
+		{
+			name:     "exceeds percent budget",
+			budgets:  []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxPercent: pointer.Int64(25)}},
+			previous: deployments,
+			current:  deployments[3:],
+			want: DeletionBudgetError(commit, rootSyncString,
+				[]string{"3 of 10 Deployment.apps objects (budget: maxPercent 25)"}),
+		},
+		{
+			name: "exceeds the lower of count and percent",
+			budgets: []v1beta1.DeletionBudget{
+				{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(5), MaxPercent: pointer.Int64(20)},
+			},
+			previous: deployments,
+			current:  deployments[3:],
+			want: DeletionBudgetError(commit, rootSyncString,
+				[]string{"3 of 10 Deployment.apps objects (budget: maxCount 5, maxPercent 20)"}),
+		},
+		{
+			name:     "other kinds are not limited",
+			budgets:  []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(0)}},
+			previous: append(append([]core.ID{}, deployments...), configMaps...),
+			current:  deployments,
+		},
+		{
+			name: "wildcard applies to kinds without a budget",
+			budgets: []v1beta1.DeletionBudget{
+				{Kind: WildcardKind, MaxCount: pointer.Int64(1)},
+				{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(10)},
+			},
+			previous: append(append([]core.ID{}, deployments...), configMaps...),
+			current:  configMaps[8:],
+			want: DeletionBudgetError(commit, rootSyncString,
+				[]string{"8 of 10 ConfigMap objects (budget: maxCount 1)"}),
+		},
+		{
+			name:     "approved commit",
+			budgets:  []v1beta1.DeletionBudget{{Kind: WildcardKind, MaxCount: pointer.Int64(0)}},
+			approved: commit,
+			previous: deployments,
+		},
+		{
+			name:     "approved other commit",
+			budgets:  []v1beta1.DeletionBudget{{Kind: WildcardKind, MaxCount: pointer.Int64(0)}},
+			approved: "def456",
+			previous: deployments[:1],
+			want: DeletionBudgetError(commit, rootSyncString,
+				[]string{"1 of 1 Deployment.apps objects (budget: maxCount 0)"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestSafeguard(t, tc.budgets, tc.approved)
+			got := s.Update(context.Background(), commit, tc.previous, tc.current)
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("Update() got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDeletionSafeguard_CheckPrune(t *testing.T) {
+	deployments := ids(deploymentID, 4)
+	s := newTestSafeguard(t, []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(1)}}, "")
+
+	// After a restart, the previously declared objects are only known from the
+	// inventory.
+	if err := s.Update(context.Background(), commit, nil, nil); err != nil {
+		t.Fatalf("Update() got unexpected error %v", err)
+	}
+	if err := s.CheckPrune(context.Background(), deployments, deployments[1:]); err != nil {
+		t.Errorf("CheckPrune() got unexpected error %v", err)
+	}
+	want := DeletionBudgetError(commit, rootSyncString, []string{"4 of 4 Deployment.apps objects (budget: maxCount 1)"})
+	if err := s.CheckPrune(context.Background(), deployments, nil); fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("CheckPrune() got %v, want %v", err, want)
+	}
+}
+
+func TestDeletionSafeguard_CheckRemediation(t *testing.T) {
+	deployments := ids(deploymentID, 4)
+	s := newTestSafeguard(t, []v1beta1.DeletionBudget{{Group: "apps", Kind: "Deployment", MaxCount: pointer.Int64(2)}}, "")
+	if err := s.Update(context.Background(), commit, nil, deployments); err != nil {
+		t.Fatalf("Update() got unexpected error %v", err)
+	}
+
+	undeclared := ids(undeclaredDeploymentID, 3)
+	for i := 0; i < 2; i++ {
+		if err := s.CheckRemediation(context.Background(), undeclared[i]); err != nil {
+			t.Fatalf("CheckRemediation() got unexpected error %v", err)
+		}
+	}
+	want := DeletionBudgetError(commit, rootSyncString, []string{"3 of 4 Deployment.apps objects (budget: maxCount 2)"})
+	if err := s.CheckRemediation(context.Background(), undeclared[2]); fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("CheckRemediation() got %v, want %v", err, want)
+	}
+	if err := s.CheckRemediation(context.Background(), configMapID()); err != nil {
+		t.Errorf("CheckRemediation() got unexpected error %v for a kind without a budget", err)
+	}
+
+	// The next update resets the budget of the remediator.
+	if err := s.Update(context.Background(), "def456", deployments, deployments); err != nil {
+		t.Fatalf("Update() got unexpected error %v", err)
+	}
+	if err := s.CheckRemediation(context.Background(), undeclared[2]); err != nil {
+		t.Errorf("CheckRemediation() got unexpected error %v after an update", err)
+	}
+}
+
+func TestResources_UpdateDeletionBudget(t *testing.T) {
+	budgets := []v1beta1.DeletionBudget{{Kind: WildcardKind, MaxCount: pointer.Int64(1)}}
+	dr := Resources{DeletionSafeguard: newTestSafeguard(t, budgets, "")}
+	objs := []client.Object{
+		fake.RoleObject(core.Name("foo"), core.Namespace("bar")),
+		fake.RoleObject(core.Name("baz"), core.Namespace("bar")),
+	}
+	if _, err := dr.Update(context.Background(), objs, "first"); err != nil {
+		t.Fatalf("Update() got unexpected error %v", err)
+	}
+	_, err := dr.Update(context.Background(), nil, "second")
+	want := DeletionBudgetError("second", rootSyncString, []string{"2 of 2 Role.rbac.authorization.k8s.io objects (budget: maxCount 1)"})
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Fatalf("Update() got %v, want %v", err, want)
+	}
+	if got := len(dr.Declarations()); got != 2 {
+		t.Errorf("got %d declarations after a blocked update, want the previous 2", got)
+	}
+}
@@ -39,10 +39,18 @@ type Resources struct {
 	// directly. The map should never be written to once it has been assigned to
 	// this reference; it should be treated as read-only from then on.
 	objectSet map[core.ID]*unstructured.Unstructured
+	// appliedSet is the objectSet which was last applied to the cluster. Until
+	// the applier applies a new commit, the objects on the cluster still match
+	// the declarations of the previous one. It is read-only like objectSet.
+	appliedSet map[core.ID]*unstructured.Unstructured
+	// DeletionSafeguard checks the deletions of each update, and of the
+	// remediator, against the deletion budgets. Nil allows all deletions.
+	DeletionSafeguard *DeletionSafeguard
 }
 
-// Update performs an atomic update on the resource declaration set.
-func (r *Resources) Update(ctx context.Context, objects []client.Object) ([]client.Object, status.Error) {
+// Update performs an atomic update on the resource declaration set with the
+// objects declared in commit.
+func (r *Resources) Update(ctx context.Context, objects []client.Object, commit string) ([]client.Object, status.Error) {
 	// First build up the new map using a local pointer/reference.
 	newSet := make(map[core.ID]*unstructured.Unstructured)
 	newObjects := []client.Object{}
@@ -67,6 +75,9 @@ func (r *Resources) Update(ctx context.Context, objects []client.Object) ([]clie
 	if err := deletesAllNamespaces(previousSet, newSet); err != nil {
 		return nil, err
 	}
+	if err := r.DeletionSafeguard.Update(ctx, commit, idsOf(previousSet), idsOf(newSet)); err != nil {
+		return nil, err
+	}
 
 	// Now assign the pointer for the new map to the struct reference in a
 	// threadsafe context. From now on, this map is read-only.
@@ -88,6 +99,25 @@ func (r *Resources) Get(id core.ID) (*unstructured.Unstructured, bool) {
 	return u.DeepCopy(), found
 }
 
+// GetApplied returns a copy of the resource declaration as it was last
+// applied to the cluster.
+func (r *Resources) GetApplied(id core.ID) (*unstructured.Unstructured, bool) {
+	r.mutex.RLock()
+	objSet := r.appliedSet
+	r.mutex.RUnlock()
+
+	u, found := objSet[id]
+	return u.DeepCopy(), found
+}
+
+// MarkApplied records that the current resource declarations have been applied
+// to the cluster.
+func (r *Resources) MarkApplied() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.appliedSet = r.objectSet
+}
+
 // Declarations returns all resource declarations from Git.
 func (r *Resources) Declarations() []*unstructured.Unstructured {
 	var objects []*unstructured.Unstructured
@@ -117,6 +147,14 @@ func (r *Resources) GVKSet() map[schema.GroupVersionKind]bool {
 	return gvkSet
 }
 
+func idsOf(objectSet map[core.ID]*unstructured.Unstructured) []core.ID {
+	ids := make([]core.ID, 0, len(objectSet))
+	for id := range objectSet {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func (r *Resources) getObjectSet() map[core.ID]*unstructured.Unstructured {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
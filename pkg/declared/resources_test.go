@@ -45,7 +45,7 @@ func TestUpdate(t *testing.T) {
 	objects := testSet
 	expectedIDs := getIDs(objects)
 
-	newObjects, err := dr.Update(context.Background(), objects)
+	newObjects, err := dr.Update(context.Background(), objects, "")
 	if err != nil {
 		t.Fatalf("unexpected error %v", err)
 	}
@@ -70,7 +70,7 @@ func TestMutateImpossible(t *testing.T) {
 	o1.SetResourceVersion(wantResourceVersion)
 	o2 := asUnstructured(t, fake.RoleObject(core.Name("baz"), core.Namespace("bar")))
 	o2.SetResourceVersion(wantResourceVersion)
-	_, err := dr.Update(context.Background(), []client.Object{o1, o2})
+	_, err := dr.Update(context.Background(), []client.Object{o1, o2}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -125,7 +125,7 @@ func asUnstructured(t *testing.T, o client.Object) *unstructured.Unstructured {
 
 func TestDeclarations(t *testing.T) {
 	dr := Resources{}
-	objects, err := dr.Update(context.Background(), testSet)
+	objects, err := dr.Update(context.Background(), testSet, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +156,7 @@ func TestDeclarations(t *testing.T) {
 
 func TestGet(t *testing.T) {
 	dr := Resources{}
-	_, err := dr.Update(context.Background(), testSet)
+	_, err := dr.Update(context.Background(), testSet, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -170,9 +170,31 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetApplied(t *testing.T) {
+	dr := Resources{}
+	if _, err := dr.Update(context.Background(), testSet, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := dr.GetApplied(core.IDOf(obj1)); found {
+		t.Fatal("got found before the declarations are applied, want not found")
+	}
+
+	dr.MarkApplied()
+	if _, err := dr.Update(context.Background(), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	actual, found := dr.GetApplied(core.IDOf(obj1))
+	if !found {
+		t.Fatal("got not found, want found")
+	}
+	if diff := cmp.Diff(asUnstructured(t, obj1), actual); diff != "" {
+		t.Error(diff)
+	}
+}
+
 func TestGVKSet(t *testing.T) {
 	dr := Resources{}
-	_, err := dr.Update(context.Background(), testSet)
+	_, err := dr.Update(context.Background(), testSet, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,7 +212,7 @@ func TestGVKSet(t *testing.T) {
 func TestResources_InternalErrorMetricValidation(t *testing.T) {
 	m := testmetrics.RegisterMetrics(metrics.InternalErrorsView)
 	dr := Resources{}
-	if _, err := dr.Update(context.Background(), nilSet); err != nil {
+	if _, err := dr.Update(context.Background(), nilSet, ""); err != nil {
 		t.Fatal(err)
 	}
 	wantMetrics := []*view.Row{
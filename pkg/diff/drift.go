@@ -0,0 +1,317 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// DriftedFields returns the paths of the declared fields whose values differ
+// in the actual object, e.g. `spec.replicas`. The fields which are not declared,
+// such as the ones defaulted by the API server or set by other controllers, are
+// ignored, including within the elements of lists.
+//
+// The declared fields are read from the declared-fields annotation, which
+// identifies the list elements by their merge keys, e.g.
+// `spec.containers[name="app"].image`. Without the annotation, the list
+// elements are compared by index.
+//
+// It returns nil if either the declared or the actual object is missing.
+func (d Diff) DriftedFields() ([]string, status.Error) {
+	declared, err := d.UnstructuredDeclared()
+	if err != nil || declared == nil {
+		return nil, err
+	}
+	actual, err := d.UnstructuredActual()
+	if err != nil || actual == nil {
+		return nil, err
+	}
+	// The sync token changes with every commit, so it differs until the
+	// applier has applied the new commit.
+	unstructured.RemoveNestedField(declared.Object, "metadata", "annotations", metadata.SyncTokenAnnotationKey)
+
+	var fields []string
+	if declaredFields, found := declared.GetAnnotations()[metadata.DeclaredFieldsKey]; found {
+		set := &fieldpath.Set{}
+		if err := set.FromJSON(strings.NewReader(declaredFields)); err != nil {
+			return nil, status.ResourceWrap(err, "unable to read the declared fields", declared)
+		}
+		fields = driftedDeclaredFields(set, declared.Object, actual.Object)
+	} else {
+		driftedFields(declared.Object, actual.Object, "", &fields)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// driftedDeclaredFields returns the paths of the leaves of the declared field
+// set whose values differ between declared and actual. The leaves which are
+// missing from declared, such as the removed sync token, are ignored.
+func driftedDeclaredFields(set *fieldpath.Set, declared, actual map[string]interface{}) []string {
+	var fields []string
+	set.Leaves().Iterate(func(path fieldpath.Path) {
+		declaredValue, found := valueAt(declared, path)
+		if !found {
+			return
+		}
+		actualValue, found := valueAt(actual, path)
+		if !found || !equality.Semantic.DeepEqual(declaredValue, actualValue) {
+			fields = append(fields, pathString(path))
+		}
+	})
+	return fields
+}
+
+// valueAt returns the value at path in obj, and whether it was found.
+func valueAt(obj interface{}, path fieldpath.Path) (interface{}, bool) {
+	for _, pe := range path {
+		switch {
+		case pe.FieldName != nil:
+			m, ok := obj.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if obj, ok = m[*pe.FieldName]; !ok {
+				return nil, false
+			}
+		case pe.Index != nil:
+			l, ok := obj.([]interface{})
+			if !ok || *pe.Index < 0 || *pe.Index >= len(l) {
+				return nil, false
+			}
+			obj = l[*pe.Index]
+		default:
+			l, ok := obj.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			i := findElement(l, pe)
+			if i < 0 {
+				return nil, false
+			}
+			obj = l[i]
+		}
+	}
+	return obj, true
+}
+
+// FilterFields returns the fields of obj which are in set. The elements of
+// lists are matched by the index, key or value of the path elements in set, so
+// that the fields which are not in set, such as the defaulted fields of list
+// elements, are left out.
+func FilterFields(set *fieldpath.Set, obj map[string]interface{}) map[string]interface{} {
+	return filterValue(set, obj).(map[string]interface{})
+}
+
+// filterValue returns the fields of v which are in set. A path element which
+// has children in set is filtered recursively, and one which is only a member
+// of set is kept as a whole.
+func filterValue(set *fieldpath.Set, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, value := range v {
+			name := key
+			pe := fieldpath.PathElement{FieldName: &name}
+			if child, found := set.Children.Get(pe); found {
+				result[key] = filterValue(child, value)
+			} else if set.Members.Has(pe) {
+				result[key] = value
+			}
+		}
+		return result
+	case []interface{}:
+		elements := make(map[int]fieldpath.PathElement)
+		addElement := func(pe fieldpath.PathElement) {
+			if pe.FieldName != nil {
+				return
+			}
+			var i int
+			if pe.Index != nil {
+				i = *pe.Index
+			} else {
+				i = findElement(v, pe)
+			}
+			if i >= 0 && i < len(v) {
+				elements[i] = pe
+			}
+		}
+		set.Members.Iterate(addElement)
+		set.Children.Iterate(addElement)
+		result := make([]interface{}, 0, len(elements))
+		for i, item := range v {
+			pe, found := elements[i]
+			if !found {
+				continue
+			}
+			if child, found := set.Children.Get(pe); found {
+				result = append(result, filterValue(child, item))
+			} else {
+				result = append(result, item)
+			}
+		}
+		return result
+	}
+	return v
+}
+
+// findElement returns the index of the element of l identified by the key or
+// value of pe, or -1 if there is none.
+func findElement(l []interface{}, pe fieldpath.PathElement) int {
+	for i, item := range l {
+		if pe.Value != nil {
+			if value.Equals(value.NewValueInterface(item), *pe.Value) {
+				return i
+			}
+			continue
+		}
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		matches := true
+		for _, key := range *pe.Key {
+			field, found := m[key.Name]
+			if !found || !value.Equals(value.NewValueInterface(field), key.Value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return i
+		}
+	}
+	return -1
+}
+
+// pathString formats path like the paths of driftedFields, with the list
+// elements identified by their merge keys, e.g. `spec.ports[port=80,protocol="TCP"]`.
+func pathString(path fieldpath.Path) string {
+	var result string
+	for _, pe := range path {
+		if pe.FieldName != nil {
+			result = fieldPath(result, *pe.FieldName)
+		} else {
+			result += pe.String()
+		}
+	}
+	return result
+}
+
+// driftedFields appends to fields the paths under prefix of the values in
+// declared which differ in actual.
+func driftedFields(declared, actual map[string]interface{}, prefix string, fields *[]string) {
+	for key, declaredValue := range declared {
+		path := fieldPath(prefix, key)
+		actualValue, found := actual[key]
+		if !found {
+			*fields = append(*fields, path)
+			continue
+		}
+		driftedValue(declaredValue, actualValue, path, fields)
+	}
+}
+
+// driftedValue appends path to fields if the declared value differs in actual.
+// Maps and the elements of lists of the same length are compared recursively.
+func driftedValue(declaredValue, actualValue interface{}, path string, fields *[]string) {
+	switch declaredValue := declaredValue.(type) {
+	case map[string]interface{}:
+		if actualMap, ok := actualValue.(map[string]interface{}); ok {
+			driftedFields(declaredValue, actualMap, path, fields)
+			return
+		}
+	case []interface{}:
+		if actualList, ok := actualValue.([]interface{}); ok && len(actualList) == len(declaredValue) {
+			for i := range declaredValue {
+				driftedValue(declaredValue[i], actualList[i], fmt.Sprintf("%s[%d]", path, i), fields)
+			}
+			return
+		}
+	}
+	if !equality.Semantic.DeepEqual(declaredValue, actualValue) {
+		*fields = append(*fields, path)
+	}
+}
+
+// fieldPath appends key to the path prefix. Keys which contain dots or slashes,
+// such as the label and annotation keys, are enclosed in brackets.
+func fieldPath(prefix, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%s]", prefix, key)
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// FieldChange is a field whose value differs between two versions of an object.
+type FieldChange struct {
+	// Path is the path of the field, e.g. `spec.replicas`.
+	Path string
+	// Old is the value of the field in the old object, or nil if it is missing.
+	Old interface{}
+	// New is the value of the field in the new object, or nil if it is missing.
+	New interface{}
+}
+
+// FieldChanges returns the fields which are added, changed or removed from
+// oldObj to newObj, sorted by their paths. Maps are compared field by field,
+// and lists are compared as a whole.
+func FieldChanges(oldObj, newObj map[string]interface{}) []FieldChange {
+	var changes []FieldChange
+	fieldChanges(oldObj, newObj, "", &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// fieldChanges appends to changes the fields under prefix which differ between
+// oldObj and newObj.
+func fieldChanges(oldObj, newObj map[string]interface{}, prefix string, changes *[]FieldChange) {
+	for key, oldValue := range oldObj {
+		path := fieldPath(prefix, key)
+		newValue, found := newObj[key]
+		if !found {
+			*changes = append(*changes, FieldChange{Path: path, Old: oldValue})
+			continue
+		}
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			fieldChanges(oldMap, newMap, path, changes)
+			continue
+		}
+		if !equality.Semantic.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, FieldChange{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newObj {
+		if _, found := oldObj[key]; !found {
+			*changes = append(*changes, FieldChange{Path: fieldPath(prefix, key), New: newValue})
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+func TestDriftedFields(t *testing.T) {
+	configMap := func(data map[string]interface{}, opts ...core.MetaMutator) *unstructured.Unstructured {
+		u := fake.UnstructuredObject(kinds.ConfigMap(), append(opts, core.Name("cm"), core.Namespace("foo"))...)
+		if data != nil {
+			if err := unstructured.SetNestedMap(u.Object, data, "data"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return u
+	}
+
+	testCases := []struct {
+		name     string
+		declared client.Object
+		actual   client.Object
+		want     []string
+	}{
+		{
+			name:     "no drift",
+			declared: configMap(map[string]interface{}{"a": "1"}, core.Label("app", "foo")),
+			actual:   configMap(map[string]interface{}{"a": "1", "b": "2"}, core.Label("app", "foo"), core.Label("extra", "bar")),
+		},
+		{
+			name:     "changed and removed fields",
+			declared: configMap(map[string]interface{}{"a": "1", "b": "2"}, core.Label("app.kubernetes.io/name", "foo")),
+			actual:   configMap(map[string]interface{}{"a": "changed"}, core.Label("app.kubernetes.io/name", "bar")),
+			want:     []string{"data.a", "data.b", "metadata.labels[app.kubernetes.io/name]"},
+		},
+		{
+			name:     "sync token is ignored",
+			declared: configMap(nil, core.Annotation(metadata.SyncTokenAnnotationKey, "new")),
+			actual:   configMap(nil, core.Annotation(metadata.SyncTokenAnnotationKey, "old")),
+		},
+		{
+			name:     "missing actual",
+			declared: configMap(map[string]interface{}{"a": "1"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Diff{Declared: tc.declared, Actual: tc.actual}
+			got, err := d.DriftedFields()
+			if err != nil {
+				t.Fatalf("DriftedFields() got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("DriftedFields() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDriftedFieldsWithDefaults(t *testing.T) {
+	// deployment returns a Deployment with a container of the image, and the
+	// fields defaulted by the API server if defaulted is true.
+	deployment := func(image string, defaulted bool, opts ...core.MetaMutator) *unstructured.Unstructured {
+		u := fake.UnstructuredObject(kinds.Deployment(), append(opts, core.Name("app"), core.Namespace("foo"))...)
+		container := map[string]interface{}{"name": "app", "image": image}
+		if defaulted {
+			container["imagePullPolicy"] = "IfNotPresent"
+			container["terminationMessagePath"] = "/dev/termination-log"
+			container["terminationMessagePolicy"] = "File"
+			container["resources"] = map[string]interface{}{}
+		}
+		if err := unstructured.SetNestedSlice(u.Object, []interface{}{container}, "spec", "template", "spec", "containers"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	// service returns a Service with a port, with its protocol if set, as the
+	// declared fields hydration and the API server do, and with the fields
+	// defaulted by the API server if defaulted is true.
+	service := func(protocol string, defaulted bool, opts ...core.MetaMutator) *unstructured.Unstructured {
+		u := fake.UnstructuredObject(kinds.Service(), append(opts, core.Name("app"), core.Namespace("foo"))...)
+		port := map[string]interface{}{"port": int64(80)}
+		if protocol != "" {
+			port["protocol"] = protocol
+		}
+		if defaulted {
+			port["targetPort"] = int64(80)
+		}
+		if err := unstructured.SetNestedSlice(u.Object, []interface{}{port}, "spec", "ports"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	deploymentFields := core.Annotation(metadata.DeclaredFieldsKey,
+		`{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}}}}}}}`)
+	serviceFields := core.Annotation(metadata.DeclaredFieldsKey,
+		`{"f:spec":{"f:ports":{"k:{\"port\":80,\"protocol\":\"TCP\"}":{".":{},"f:port":{}}}}}`)
+
+	testCases := []struct {
+		name     string
+		declared client.Object
+		actual   client.Object
+		want     []string
+	}{
+		{
+			name:     "defaulted container",
+			declared: deployment("nginx:1.23", false, deploymentFields),
+			actual:   deployment("nginx:1.23", true),
+		},
+		{
+			name:     "drifted container",
+			declared: deployment("nginx:1.23", false, deploymentFields),
+			actual:   deployment("nginx:latest", true),
+			want:     []string{`spec.template.spec.containers[name="app"].image`},
+		},
+		{
+			name:     "defaulted container without the declared fields",
+			declared: deployment("nginx:1.23", false),
+			actual:   deployment("nginx:1.23", true),
+		},
+		{
+			name:     "drifted container without the declared fields",
+			declared: deployment("nginx:1.23", false),
+			actual:   deployment("nginx:latest", true),
+			want:     []string{"spec.template.spec.containers[0].image"},
+		},
+		{
+			name:     "defaulted Service port",
+			declared: service("TCP", false, serviceFields),
+			actual:   service("TCP", true),
+		},
+		{
+			name:     "drifted Service port",
+			declared: service("TCP", false, serviceFields),
+			actual:   service("UDP", true),
+			want:     []string{`spec.ports[port=80,protocol="TCP"].port`},
+		},
+		{
+			name:     "defaulted Service port without the declared fields",
+			declared: service("", false),
+			actual:   service("TCP", true),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := Diff{Declared: tc.declared, Actual: tc.actual}
+			got, err := d.DriftedFields()
+			if err != nil {
+				t.Fatalf("DriftedFields() got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("DriftedFields() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterFields(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:v1", "imagePullPolicy": "IfNotPresent"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"},
+				map[string]interface{}{"name": "injected", "image": "injected:v1"},
+			},
+			"args": []interface{}{"a", "b"},
+		},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}
+	set := &fieldpath.Set{}
+	fields := `{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}},"k:{\"name\":\"sidecar\"}":{}},"f:args":{}}}`
+	if err := set.FromJSON(strings.NewReader(fields)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "image": "app:v1"},
+				map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"},
+			},
+			"args": []interface{}{"a", "b"},
+		},
+	}
+	if diff := cmp.Diff(want, FilterFields(set, obj)); diff != "" {
+		t.Errorf("FilterFields() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestFieldChanges(t *testing.T) {
+	testCases := []struct {
+		name   string
+		oldObj map[string]interface{}
+		newObj map[string]interface{}
+		want   []FieldChange
+	}{
+		{
+			name:   "no changes",
+			oldObj: map[string]interface{}{"data": map[string]interface{}{"a": "1"}},
+			newObj: map[string]interface{}{"data": map[string]interface{}{"a": "1"}},
+		},
+		{
+			name: "added, changed and removed fields",
+			oldObj: map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "foo"}},
+				"data":     map[string]interface{}{"a": "1", "b": "2"},
+			},
+			newObj: map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "bar"}},
+				"data":     map[string]interface{}{"a": "changed", "c": "3"},
+			},
+			want: []FieldChange{
+				{Path: "data.a", Old: "1", New: "changed"},
+				{Path: "data.b", Old: "2"},
+				{Path: "data.c", New: "3"},
+				{Path: "metadata.labels[app.kubernetes.io/name]", Old: "foo", New: "bar"},
+			},
+		},
+		{
+			name:   "lists are compared as a whole",
+			oldObj: map[string]interface{}{"rules": []interface{}{"a", "b"}},
+			newObj: map[string]interface{}{"rules": []interface{}{"a"}},
+			want:   []FieldChange{{Path: "rules", Old: []interface{}{"a", "b"}, New: []interface{}{"a"}}},
+		},
+		{
+			name:   "map replaced by a value",
+			oldObj: map[string]interface{}{"spec": map[string]interface{}{"a": "1"}},
+			newObj: map[string]interface{}{"spec": "none"},
+			want:   []FieldChange{{Path: "spec", Old: map[string]interface{}{"a": "1"}, New: "none"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FieldChanges(tc.oldObj, tc.newObj)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("FieldChanges() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events records the Kubernetes Events of the reconciler on the
+// objects it manages and on its RootSync or RepoSync.
+package events
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configmanagement"
+	"kpt.dev/configsync/pkg/api/configsync/v1alpha1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/kinds"
+)
+
+const (
+	// ReasonApplied reports that the reconciler created or updated an object
+	// to match its declaration.
+	ReasonApplied = "Applied"
+	// ReasonPruned reports that the reconciler deleted an object which is no
+	// longer declared.
+	ReasonPruned = "Pruned"
+	// ReasonRemediated reports that the reconciler reverted a change made to an
+	// object outside of its source of truth.
+	ReasonRemediated = "Remediated"
+	// ReasonManagementConflict reports that an object is managed by another
+	// reconciler, which prevents the reconciler from applying it.
+	ReasonManagementConflict = "ManagementConflict"
+	// ReasonSynced reports that the reconciler synced a commit.
+	ReasonSynced = "Synced"
+	// ReasonSyncFailed reports that the reconciler failed to sync a commit.
+	ReasonSyncFailed = "SyncFailed"
+	// ReasonBreakGlassExemption reports that the admission webhook admitted a
+	// change to a managed object because of a break-glass policy.
+	ReasonBreakGlassExemption = "BreakGlassExemption"
+)
+
+const (
+	// defaultQPS is the rate at which the Events of all the objects are
+	// recorded once the burst is exhausted.
+	defaultQPS = 1
+	// defaultBurst is the number of Events which are recorded at once, e.g.
+	// for the objects applied by the first sync of a commit.
+	defaultBurst = 100
+)
+
+// NewRecorder returns an EventRecorder which records Events from component.
+//
+// Repeated Events on an object are deduplicated and aggregated by the event
+// correlator of client-go, which also limits the rate of the Events of each
+// object. On top of that, the rate of the Events of all the managed objects is
+// limited so that large syncs don't flood the API server. The Events of the
+// RootSyncs and RepoSyncs are not limited, so that the Synced and SyncFailed
+// Events are not dropped after the Events of a large sync.
+func NewRecorder(cfg *rest.Config, scheme *runtime.Scheme, component string) (record.EventRecorder, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{})
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: component})
+	return NewRateLimitedRecorder(recorder, defaultQPS, defaultBurst), nil
+}
+
+// NewRateLimitedRecorder returns an EventRecorder which drops the Events
+// recorded above qps, after an initial burst, except for the Events of the
+// RootSyncs and RepoSyncs.
+func NewRateLimitedRecorder(recorder record.EventRecorder, qps float32, burst int) record.EventRecorder {
+	return &rateLimitedRecorder{
+		underlying: recorder,
+		limiter:    flowcontrol.NewTokenBucketPassiveRateLimiter(qps, burst),
+	}
+}
+
+// rateLimitedRecorder drops the Events which exceed the rate limit.
+type rateLimitedRecorder struct {
+	underlying record.EventRecorder
+	limiter    flowcontrol.PassiveRateLimiter
+}
+
+var _ record.EventRecorder = &rateLimitedRecorder{}
+
+// Event implements record.EventRecorder.
+func (r *rateLimitedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if !r.accept(object, reason) {
+		return
+	}
+	r.underlying.Event(object, eventtype, reason, message)
+}
+
+// Eventf implements record.EventRecorder.
+func (r *rateLimitedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if !r.accept(object, reason) {
+		return
+	}
+	r.underlying.Eventf(object, eventtype, reason, messageFmt, args...)
+}
+
+// AnnotatedEventf implements record.EventRecorder.
+func (r *rateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if !r.accept(object, reason) {
+		return
+	}
+	r.underlying.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+}
+
+func (r *rateLimitedRecorder) accept(object runtime.Object, reason string) bool {
+	if isSyncObject(object) || r.limiter.TryAccept() {
+		return true
+	}
+	klog.V(4).Infof("Dropped %s event: the event rate limit is exceeded", reason)
+	return false
+}
+
+// isSyncObject returns true if the object is a RootSync or RepoSync.
+func isSyncObject(object runtime.Object) bool {
+	switch object.(type) {
+	case *v1beta1.RootSync, *v1beta1.RepoSync, *v1alpha1.RootSync, *v1alpha1.RepoSync:
+		return true
+	}
+	gk := object.GetObjectKind().GroupVersionKind().GroupKind()
+	return gk == kinds.RootSyncV1Beta1().GroupKind() || gk == kinds.RepoSyncV1Beta1().GroupKind()
+}
+
+// SyncString returns the kind, namespace and name of the RootSync or RepoSync
+// of the reconciler with the given scope, for the messages of the Events, e.g.
+// `RootSync config-management-system/root-sync`.
+func SyncString(scope declared.Scope, syncName string) string {
+	if scope == declared.RootReconciler {
+		return fmt.Sprintf("%s %s/%s", kinds.RootSyncV1Beta1().Kind, configmanagement.ControllerNamespace, syncName)
+	}
+	return fmt.Sprintf("%s %s/%s", kinds.RepoSyncV1Beta1().Kind, scope, syncName)
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestRateLimitedRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	// The bucket is not refilled within the test.
+	recorder := NewRateLimitedRecorder(fakeRecorder, 0.001, 2)
+	cm := fake.ConfigMapObject(core.Name("cm"), core.Namespace("foo"))
+
+	recorder.Event(cm, corev1.EventTypeNormal, ReasonApplied, "applied")
+	recorder.Eventf(cm, corev1.EventTypeNormal, ReasonPruned, "pruned %s", "cm")
+	recorder.Eventf(cm, corev1.EventTypeNormal, ReasonRemediated, "remediated %s", "cm")
+	// The Events of the RootSyncs and RepoSyncs are not limited.
+	recorder.Eventf(fake.RootSyncObjectV1Beta1("root-sync"), corev1.EventTypeNormal, ReasonSynced, "synced %s", "abc")
+	recorder.Eventf(&v1beta1.RepoSync{}, corev1.EventTypeWarning, ReasonSyncFailed, "failed %s", "abc")
+	close(fakeRecorder.Events)
+
+	var got []string
+	for e := range fakeRecorder.Events {
+		got = append(got, e)
+	}
+	want := []string{"Normal Applied applied", "Normal Pruned pruned cm", "Normal Synced synced abc", "Warning SyncFailed failed abc"}
+	if len(got) != len(want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got event %q, want %q", got[i], want[i])
+		}
+	}
+}
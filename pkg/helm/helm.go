@@ -17,6 +17,7 @@ package helm
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -27,13 +28,31 @@ import (
 	"kpt.dev/configsync/pkg/util"
 )
 
+// valuesFileName is the name of the file into which the inline values are
+// written before being passed to helm template.
+const valuesFileName = "inline-values.yaml"
+
+// renderDirSeparator separates the chart name, version and values hash in the
+// name of the directory holding the rendered chart. Neither chart names nor
+// semantic versions may contain it.
+const renderDirSeparator = ":"
+
 // Hydrator runs the helm hydration process.
+//
+// Values are merged by helm in the following order, where later sources
+// take precedence over earlier ones:
+//  1. the default values.yaml of the chart,
+//  2. the ValuesFiles, in the order they are listed,
+//  3. the inline Values.
 type Hydrator struct {
 	Chart       string
 	Repo        string
 	Version     string
 	ReleaseName string
 	Namespace   string
+	Values      string
+	ValuesFiles []string
+	IncludeCRDs bool
 	HydrateRoot string
 	Dest        string
 	Auth        configsync.AuthType
@@ -41,28 +60,56 @@ type Hydrator struct {
 	Password    string
 }
 
-func (h *Hydrator) templateArgs(destDir string) []string {
+func (h *Hydrator) chartRef() string {
+	if h.isOCI() {
+		return h.Repo + "/" + h.Chart
+	}
+	return h.Chart
+}
+
+// templateArgs returns the arguments of helm template for the resolved chart
+// version. chartPath is the path to a locally pulled copy of the chart, or
+// empty if the chart should be fetched from the repository. valuesFiles are
+// absolute paths, in increasing order of precedence.
+func (h *Hydrator) templateArgs(version, chartPath, destDir string, valuesFiles []string) []string {
 	args := []string{"template"}
 	if h.ReleaseName != "" {
 		args = append(args, h.ReleaseName)
 	}
-	if h.isOCI() {
-		args = append(args, h.Repo+"/"+h.Chart)
+	if chartPath != "" {
+		args = append(args, chartPath)
 	} else {
-		args = append(args, h.Chart)
-		args = append(args, "--repo", h.Repo)
+		args = append(args, h.chartRef())
+		if !h.isOCI() {
+			args = append(args, "--repo", h.Repo)
+		}
+		args = append(args, "--version", version)
 	}
 	if h.Namespace != "" {
 		args = append(args, "--namespace", h.Namespace)
 	}
-	if h.Version != "" {
-		args = append(args, "--version", h.Version)
+	for _, f := range valuesFiles {
+		args = append(args, "--values", f)
+	}
+	if h.IncludeCRDs {
+		args = append(args, "--include-crds")
 	}
-	//TODO: add logic for values/crd update.
 	args = append(args, "--output-dir", destDir)
 	return args
 }
 
+// pullArgs returns the arguments of helm pull, which downloads and unpacks
+// the resolved chart version into untarDir.
+func (h *Hydrator) pullArgs(version, untarDir string) []string {
+	args := []string{"pull", h.chartRef()}
+	if !h.isOCI() {
+		args = append(args, "--repo", h.Repo)
+	}
+	args = append(args, "--version", version)
+	args = append(args, "--untar", "--untardir", untarDir)
+	return args
+}
+
 func (h *Hydrator) registryLoginArgs() []string {
 	args := []string{"registry", "login"}
 	if h.Auth == configsync.AuthToken {
@@ -74,27 +121,106 @@ func (h *Hydrator) registryLoginArgs() []string {
 	return args
 }
 
+// renderDir returns the name of the directory holding the rendered chart,
+// in the format of <chart>:<version>[:<hash>]. The name is reported as the
+// source commit by the reconciler. Besides the chart and the resolved version,
+// it encodes every other rendering input, so that changing the values or CRD
+// handling renders the chart again. valuesFiles holds the contents of the
+// ValuesFiles, in the order they are listed.
+func (h *Hydrator) renderDir(version string, valuesFiles [][]byte) string {
+	dir := h.Chart + renderDirSeparator + version
+	if h.Values == "" && len(valuesFiles) == 0 && !h.IncludeCRDs {
+		return dir
+	}
+	hasher := fnv.New32a()
+	// hash.Hash never returns an error on Write.
+	_, _ = fmt.Fprintf(hasher, "%s\x00%t", h.Values, h.IncludeCRDs)
+	for _, contents := range valuesFiles {
+		_, _ = fmt.Fprintf(hasher, "\x00%d\x00", len(contents))
+		_, _ = hasher.Write(contents)
+	}
+	return fmt.Sprintf("%s%s%08x", dir, renderDirSeparator, hasher.Sum32())
+}
+
+// VersionFromCommit returns the chart version encoded in the source commit
+// reported for a Helm chart, or an empty string if the commit was not
+// produced by helm-sync.
+func VersionFromCommit(commit string) string {
+	parts := strings.Split(commit, renderDirSeparator)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
 // HelmTemplate runs helm template with args
 func (h *Hydrator) HelmTemplate(ctx context.Context) error {
-	//TODO: add logic to handle "latest" version
-	destDir := filepath.Join(h.HydrateRoot, h.Chart+h.Version)
+	version, err := h.resolveVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the helm chart version %q: %w", h.Version, err)
+	}
+	workDir, err := os.MkdirTemp(h.HydrateRoot, ".work-")
+	if err != nil {
+		return fmt.Errorf("failed to create a working directory for the helm chart: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(workDir); err != nil {
+			klog.Warningf("unable to remove the working directory %s: %v", workDir, err)
+		}
+	}()
+
+	var chartPath string
+	var valuesFiles []string
+	var valuesFileContents [][]byte
+	if len(h.ValuesFiles) > 0 {
+		// The values files are shipped inside the chart, so pull it locally
+		// first and render from the local copy. Their contents are part of the
+		// rendering inputs, so the chart is pulled before deciding whether it
+		// needs to be rendered again.
+		if err := h.registryLogin(ctx); err != nil {
+			return err
+		}
+		out, err := exec.CommandContext(ctx, "helm", h.pullArgs(version, workDir)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to pull the helm chart: %w, stdout: %s", err, string(out))
+		}
+		chartPath = filepath.Join(workDir, h.Chart)
+		for _, f := range h.ValuesFiles {
+			valuesFile := filepath.Join(chartPath, filepath.Clean("/"+f))
+			contents, err := os.ReadFile(valuesFile)
+			if err != nil {
+				return fmt.Errorf("failed to read the values file %q in the helm chart %q: %w", f, h.Chart, err)
+			}
+			valuesFiles = append(valuesFiles, valuesFile)
+			valuesFileContents = append(valuesFileContents, contents)
+		}
+	}
+
+	destDir := filepath.Join(h.HydrateRoot, h.renderDir(version, valuesFileContents))
 	linkPath := filepath.Join(h.HydrateRoot, h.Dest)
 	oldDir, err := filepath.EvalSymlinks(linkPath)
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to evaluate the symbolic path %q to the Helm chart: %w", linkPath, err)
 	}
 	if oldDir == destDir {
-		klog.Infof("no update required with the same helm chart version %q", h.Version)
+		klog.Infof("no update required with the same helm chart version %q and values", version)
 		return nil
 	}
-	if h.Auth != configsync.AuthNone && h.isOCI() {
-		args := h.registryLoginArgs()
-		out, err := exec.CommandContext(ctx, "helm", args...).CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to authenticate to helm registry: %w, stdout: %s", err, string(out))
+	if chartPath == "" {
+		if err := h.registryLogin(ctx); err != nil {
+			return err
+		}
+	}
+
+	if h.Values != "" {
+		valuesFile := filepath.Join(workDir, valuesFileName)
+		if err := os.WriteFile(valuesFile, []byte(h.Values), 0600); err != nil {
+			return fmt.Errorf("failed to write the inline values: %w", err)
 		}
+		valuesFiles = append(valuesFiles, valuesFile)
 	}
-	args := h.templateArgs(destDir)
+
+	args := h.templateArgs(version, chartPath, destDir, valuesFiles)
 	out, err := exec.CommandContext(ctx, "helm", args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to render the helm chart: %w, stdout: %s", err, string(out))
@@ -103,6 +229,18 @@ func (h *Hydrator) HelmTemplate(ctx context.Context) error {
 	return util.UpdateSymlink(h.HydrateRoot, linkPath, destDir, oldDir)
 }
 
+// registryLogin authenticates to the OCI registry hosting the chart, if any.
+func (h *Hydrator) registryLogin(ctx context.Context) error {
+	if h.Auth == configsync.AuthNone || !h.isOCI() {
+		return nil
+	}
+	out, err := exec.CommandContext(ctx, "helm", h.registryLoginArgs()...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to helm registry: %w, stdout: %s", err, string(out))
+	}
+	return nil
+}
+
 func (h *Hydrator) isOCI() bool {
 	return strings.HasPrefix(h.Repo, "oci://")
 }
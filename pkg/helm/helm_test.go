@@ -0,0 +1,126 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateArgs(t *testing.T) {
+	testCases := []struct {
+		name        string
+		hydrator    *Hydrator
+		chartPath   string
+		valuesFiles []string
+		want        []string
+	}{
+		{
+			name: "remote chart without values",
+			hydrator: &Hydrator{
+				Chart:       "my-chart",
+				Repo:        "https://charts.example.com",
+				Version:     "1.0.0",
+				ReleaseName: "my-release",
+				Namespace:   "my-ns",
+			},
+			want: []string{"template", "my-release", "my-chart", "--repo", "https://charts.example.com",
+				"--version", "1.0.0", "--namespace", "my-ns", "--output-dir", "/dest"},
+		},
+		{
+			name: "OCI chart with CRDs",
+			hydrator: &Hydrator{
+				Chart:       "my-chart",
+				Repo:        "oci://registry.example.com/charts",
+				Version:     "1.0.0",
+				IncludeCRDs: true,
+			},
+			want: []string{"template", "oci://registry.example.com/charts/my-chart",
+				"--version", "1.0.0", "--include-crds", "--output-dir", "/dest"},
+		},
+		{
+			name: "local chart with values files in order",
+			hydrator: &Hydrator{
+				Chart:   "my-chart",
+				Repo:    "https://charts.example.com",
+				Version: "1.0.0",
+			},
+			chartPath:   "/work/my-chart",
+			valuesFiles: []string{"/work/my-chart/values-prod.yaml", "/work/inline-values.yaml"},
+			want: []string{"template", "/work/my-chart",
+				"--values", "/work/my-chart/values-prod.yaml", "--values", "/work/inline-values.yaml",
+				"--output-dir", "/dest"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.hydrator.templateArgs(tc.hydrator.Version, tc.chartPath, "/dest", tc.valuesFiles)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("templateArgs() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderDir(t *testing.T) {
+	base := Hydrator{Chart: "my-chart", Version: "~1.0"}
+	if got, want := base.renderDir("1.0.3", nil), "my-chart:1.0.3"; got != want {
+		t.Errorf("renderDir() = %q, want %q", got, want)
+	}
+
+	withValues := base
+	withValues.Values = "replicas: 3\n"
+	withOtherValues := base
+	withOtherValues.Values = "replicas: 5\n"
+	withCRDs := base
+	withCRDs.IncludeCRDs = true
+
+	testCases := []struct {
+		name        string
+		hydrator    Hydrator
+		valuesFiles [][]byte
+	}{
+		{name: "inline values", hydrator: withValues},
+		{name: "other inline values", hydrator: withOtherValues},
+		{name: "values files", hydrator: base, valuesFiles: [][]byte{[]byte("replicas: 3\n")}},
+		{name: "values files with other contents", hydrator: base, valuesFiles: [][]byte{[]byte("replicas: 5\n")}},
+		{name: "values files split differently", hydrator: base, valuesFiles: [][]byte{[]byte("replicas: "), []byte("3\n")}},
+		{name: "CRDs", hydrator: withCRDs},
+	}
+	seen := map[string]string{base.renderDir("1.0.3", nil): "no values"}
+	for _, tc := range testCases {
+		dir := tc.hydrator.renderDir("1.0.3", tc.valuesFiles)
+		if other, found := seen[dir]; found {
+			t.Errorf("renderDir() = %q is the same for %q and %q", dir, tc.name, other)
+		}
+		seen[dir] = tc.name
+		if dir != tc.hydrator.renderDir("1.0.3", tc.valuesFiles) {
+			t.Errorf("renderDir() is not stable for %q", tc.name)
+		}
+		if got := VersionFromCommit(dir); got != "1.0.3" {
+			t.Errorf("VersionFromCommit(%q) = %q, want %q", dir, got, "1.0.3")
+		}
+	}
+
+	// Renaming a values file without changing its contents renders the same
+	// chart.
+	renamed := base
+	renamed.ValuesFiles = []string{"values-renamed.yaml"}
+	if got, want := renamed.renderDir("1.0.3", [][]byte{[]byte("replicas: 3\n")}), base.renderDir("1.0.3", [][]byte{[]byte("replicas: 3\n")}); got != want {
+		t.Errorf("renderDir() = %q after renaming the values file, want %q", got, want)
+	}
+}
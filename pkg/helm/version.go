@@ -0,0 +1,205 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/google"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"sigs.k8s.io/yaml"
+)
+
+// LatestVersion is the chart version which resolves to the highest stable
+// version available in the repository. An empty version is equivalent.
+const LatestVersion = "latest"
+
+// exactVersionRegex matches a full semantic version, which is used as-is
+// without listing the versions available in the repository.
+var exactVersionRegex = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// andSeparatorRegex matches the whitespace between two space-separated
+// constraints, e.g. ">=2.0.0 <3", which must be joined with a comma to be
+// understood by the semver library.
+var andSeparatorRegex = regexp.MustCompile(`([0-9A-Za-z*])\s+([<>=!~^])`)
+
+// constraintVersionRegex matches the versions of a constraint, e.g. "1.2" and
+// "1.4.0-rc.1" in "1.2 - 1.4.0-rc.1". The hyphen of a hyphen range is
+// surrounded by spaces, so it is not matched as a pre-release.
+var constraintVersionRegex = regexp.MustCompile(`v?[0-9]+(\.[0-9xX*]+)*(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?`)
+
+// repoIndex is the subset of a Helm repository index.yaml needed to list the
+// versions of a chart.
+type repoIndex struct {
+	Entries map[string][]struct {
+		Version string `json:"version"`
+	} `json:"entries"`
+}
+
+// resolveVersion returns the concrete chart version to render. An exact
+// version is returned unchanged, while an empty version, "latest" or a
+// semantic version constraint is resolved against the versions available in
+// the repository.
+func (h *Hydrator) resolveVersion(ctx context.Context) (string, error) {
+	if exactVersionRegex.MatchString(h.Version) {
+		return h.Version, nil
+	}
+	var versions []string
+	var err error
+	if h.isOCI() {
+		versions, err = h.listOCIVersions(ctx)
+	} else {
+		versions, err = h.listRepoVersions(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	return latestMatching(versions, h.Version)
+}
+
+// listRepoVersions lists the versions of the chart from the index.yaml of a
+// HTTP(S) Helm repository.
+func (h *Hydrator) listRepoVersions(ctx context.Context) ([]string, error) {
+	indexURL := strings.TrimSuffix(h.Repo, "/") + "/index.yaml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request for %q: %w", indexURL, err)
+	}
+	if h.Auth == configsync.AuthToken {
+		req.SetBasicAuth(h.UserName, h.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the helm repository index %q: %w", indexURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the helm repository index %q: %s", indexURL, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the helm repository index %q: %w", indexURL, err)
+	}
+	index := &repoIndex{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse the helm repository index %q: %w", indexURL, err)
+	}
+	var versions []string
+	for _, entry := range index.Entries[h.Chart] {
+		versions = append(versions, entry.Version)
+	}
+	return versions, nil
+}
+
+// listOCIVersions lists the versions of the chart from the tags of its OCI
+// repository.
+func (h *Hydrator) listOCIVersions(ctx context.Context) ([]string, error) {
+	repoName := strings.TrimPrefix(h.Repo, "oci://") + "/" + h.Chart
+	repo, err := name.NewRepository(repoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the OCI repository %q: %w", repoName, err)
+	}
+	auth, err := h.authenticator()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := remote.List(repo, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of the OCI repository %q: %w", repoName, err)
+	}
+	var versions []string
+	for _, tag := range tags {
+		// OCI tags don't allow "+", so helm replaces it with "_" when pushing.
+		versions = append(versions, strings.ReplaceAll(tag, "_", "+"))
+	}
+	return versions, nil
+}
+
+// authenticator returns the credentials used to list the tags of an OCI
+// repository.
+func (h *Hydrator) authenticator() (authn.Authenticator, error) {
+	switch h.Auth {
+	case configsync.AuthToken:
+		return &authn.Basic{Username: h.UserName, Password: h.Password}, nil
+	case configsync.AuthGCPServiceAccount, configsync.AuthGCENode:
+		auth, err := google.NewEnvAuthenticator()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the authentication with type %q: %w", h.Auth, err)
+		}
+		return auth, nil
+	default:
+		return authn.Anonymous, nil
+	}
+}
+
+// latestMatching returns the highest of the versions which satisfies the
+// constraint. An empty or "latest" constraint matches any stable version.
+// Pre-release versions are only considered if the constraint mentions one.
+func latestMatching(versions []string, constraint string) (string, error) {
+	if constraint == "" || constraint == LatestVersion {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(andSeparatorRegex.ReplaceAllString(constraint, "$1, $2"))
+	if err != nil {
+		return "", fmt.Errorf("invalid helm chart version constraint %q: %w", constraint, err)
+	}
+	allowPrerelease := hasPrerelease(constraint)
+
+	var latest string
+	var latestVersion *semver.Version
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil {
+			// Skip the versions which are not semantic versions, e.g. a
+			// non-version OCI tag.
+			continue
+		}
+		if sv.Prerelease() != "" && !allowPrerelease {
+			continue
+		}
+		if !c.Check(sv) {
+			continue
+		}
+		if latestVersion == nil || sv.GreaterThan(latestVersion) {
+			latest, latestVersion = v, sv
+		}
+	}
+	if latestVersion == nil {
+		return "", fmt.Errorf("no helm chart version satisfies the constraint %q", constraint)
+	}
+	return latest, nil
+}
+
+// hasPrerelease returns true if any version of the constraint is a
+// pre-release version.
+func hasPrerelease(constraint string) bool {
+	for _, v := range constraintVersionRegex.FindAllString(constraint, -1) {
+		if sv, err := semver.NewVersion(v); err == nil && sv.Prerelease() != "" {
+			return true
+		}
+	}
+	return false
+}
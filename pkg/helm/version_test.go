@@ -0,0 +1,139 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kpt.dev/configsync/pkg/api/configsync"
+)
+
+const testIndex = `apiVersion: v1
+entries:
+  my-chart:
+  - version: 1.4.0
+  - version: 1.4.2
+  - version: 1.5.0
+  - version: 2.0.0
+  - version: 2.1.0
+  - version: 3.0.0-rc.1
+  other-chart:
+  - version: 9.0.0
+`
+
+func TestLatestMatching(t *testing.T) {
+	versions := []string{"1.4.0", "1.4.2", "1.5.0", "v2.0.0", "2.1.0", "3.0.0-rc.1", "sha256-abc"}
+	testCases := []struct {
+		name       string
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{name: "empty means latest", constraint: "", want: "2.1.0"},
+		{name: "latest", constraint: LatestVersion, want: "2.1.0"},
+		{name: "tilde range", constraint: "~1.4", want: "1.4.2"},
+		{name: "caret range", constraint: "^1.4", want: "1.5.0"},
+		{name: "space separated range", constraint: ">=2.0.0 <3", want: "2.1.0"},
+		{name: "comma separated range", constraint: ">= 1.0.0, < 2.0.0", want: "1.5.0"},
+		{name: "keeps the original version string", constraint: "2.0.x", want: "v2.0.0"},
+		{name: "pre-release only when requested", constraint: ">=3.0.0-0", want: "3.0.0-rc.1"},
+		{name: "hyphen range", constraint: "1.4 - 3.0", want: "2.1.0"},
+		{name: "hyphen range with a pre-release", constraint: "3.0.0-0 - 3.0.0-rc.2", want: "3.0.0-rc.1"},
+		{name: "no match", constraint: ">=4", wantErr: true},
+		{name: "invalid constraint", constraint: "not a version", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := latestMatching(versions, tc.constraint)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("latestMatching() got error %v, want error %t", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("latestMatching() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHasPrerelease(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		want       bool
+	}{
+		{constraint: "*", want: false},
+		{constraint: ">=3.0.0-0", want: true},
+		{constraint: "1.2 - 1.4", want: false},
+		{constraint: "1.2.0 - 1.4.0-rc.1", want: true},
+		{constraint: ">= 1.0.0, < 2.0.0-beta", want: true},
+		{constraint: "~1.4 || ^2.0", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			if got := hasPrerelease(tc.constraint); got != tc.want {
+				t.Errorf("hasPrerelease(%q) = %t, want %t", tc.constraint, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "exact version is not resolved", version: "1.0.0", want: "1.0.0"},
+		{name: "latest", version: "", want: "2.1.0"},
+		{name: "constraint", version: "~1.4", want: "1.4.2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Hydrator{
+				Chart:    "my-chart",
+				Repo:     server.URL + "/",
+				Version:  tc.version,
+				Auth:     configsync.AuthToken,
+				UserName: "user",
+				Password: "pass",
+			}
+			got, err := h.resolveVersion(context.Background())
+			if err != nil {
+				t.Fatalf("resolveVersion() got unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("resolveVersion() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/importer/git"
 	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/oci"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/status"
 )
@@ -41,6 +42,9 @@ const (
 	DoneFile = "done"
 	// ErrorFile is the file name of the hydration errors.
 	ErrorFile = "error.json"
+	// DefaultRenderTimeout is the default maximum duration of the rendering of
+	// a commit.
+	DefaultRenderTimeout = 10 * time.Minute
 )
 
 // Hydrator runs the hydration process.
@@ -65,6 +69,14 @@ type Hydrator struct {
 	RehydrateFrequency time.Duration
 	// ReconcilerName is the name of the reconciler.
 	ReconcilerName string
+	// RenderTimeout is the maximum duration of the rendering of a commit, after
+	// which the functions of a Kptfile pipeline which are still running are
+	// stopped. There is no limit if it is zero.
+	RenderTimeout time.Duration
+
+	// cache keeps the recently rendered configs, so that commits which do not
+	// change the kustomization are not rendered again.
+	cache *renderCache
 }
 
 // Run runs the hydration process periodically.
@@ -81,7 +93,7 @@ func (h *Hydrator) Run(ctx context.Context) {
 			if err != nil {
 				klog.Errorf("failed to get the commit hash and sync directory from the source directory %s: %v", absSourceDir.OSPath(), err)
 			} else {
-				h.rehydrateOnError(commit, syncDir.OSPath())
+				h.rehydrateOnError(ctx, commit, syncDir.OSPath())
 			}
 		case <-tickerPoll.C:
 			commit, syncDir, err := SourceCommitAndDir(h.SourceType, absSourceDir, h.SyncDir, h.ReconcilerName)
@@ -91,7 +103,7 @@ func (h *Hydrator) Run(ctx context.Context) {
 				// If the commit has been processed before, regardless of success or failure,
 				// skip the hydration to avoid repeated execution.
 				// The rehydrate ticker will retry on the failed commit.
-				hydrateErr := h.hydrate(commit, syncDir.OSPath())
+				hydrateErr := h.hydrate(ctx, commit, syncDir.OSPath())
 				if err := h.complete(commit, hydrateErr); err != nil {
 					klog.Errorf("failed to complete the rendering execution for commit %q: %v", commit, err)
 				}
@@ -100,12 +112,20 @@ func (h *Hydrator) Run(ctx context.Context) {
 	}
 }
 
-// runHydrate runs `kustomize build` on the source configs.
-func (h *Hydrator) runHydrate(sourceCommit, syncDir string) HydrationError {
+// runHydrate renders the source configs with Kustomize or the Kptfile pipeline.
+func (h *Hydrator) runHydrate(ctx context.Context, sourceCommit, syncDir string) HydrationError {
 	newHydratedDir := h.HydratedRoot.Join(cmpath.RelativeOS(sourceCommit))
 	dest := newHydratedDir.Join(h.SyncDir).OSPath()
 
-	if err := kustomizeBuild(syncDir, dest, true); err != nil {
+	if h.cache == nil {
+		h.cache = newRenderCache()
+	}
+	if h.RenderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.RenderTimeout)
+		defer cancel()
+	}
+	if err := render(ctx, syncDir, dest, true, h.cache); err != nil {
 		return err
 	}
 	if err := updateSymlink(h.HydratedRoot.OSPath(), h.HydratedLink, newHydratedDir.OSPath()); err != nil {
@@ -116,8 +136,8 @@ func (h *Hydrator) runHydrate(sourceCommit, syncDir string) HydrationError {
 }
 
 // hydrate renders the source git repo to hydrated configs.
-func (h *Hydrator) hydrate(sourceCommit, syncDir string) HydrationError {
-	hydrate, err := needsKustomize(syncDir)
+func (h *Hydrator) hydrate(ctx context.Context, sourceCommit, syncDir string) HydrationError {
+	hydrate, err := needsRendering(syncDir)
 	if err != nil {
 		return NewInternalError(errors.Wrapf(err, "unable to check if rendering is needed for the source directory: %s", syncDir))
 	}
@@ -131,7 +151,7 @@ func (h *Hydrator) hydrate(sourceCommit, syncDir string) HydrationError {
 				"To fix, either add kustomization.yaml in the sync directory to trigger the rendering process, "+
 				"or remove kustomizaiton.yaml from all sub directories to skip rendering.", syncDir))
 		}
-		klog.V(5).Infof("no rendering is needed because of no Kustomization config file in the source configs with commit %s", sourceCommit)
+		klog.V(5).Infof("no rendering is needed because of no Kustomization config file or Kptfile pipeline in the source configs with commit %s", sourceCommit)
 		if err := os.RemoveAll(h.HydratedRoot.OSPath()); err != nil {
 			return NewInternalError(err)
 		}
@@ -142,11 +162,11 @@ func (h *Hydrator) hydrate(sourceCommit, syncDir string) HydrationError {
 	if err := os.RemoveAll(h.DonePath.OSPath()); err != nil {
 		return NewInternalError(errors.Wrapf(err, "unable to remove the done file: %s", h.DonePath.OSPath()))
 	}
-	return h.runHydrate(sourceCommit, syncDir)
+	return h.runHydrate(ctx, sourceCommit, syncDir)
 }
 
 // rehydrateOnError retries the hydration on errors.
-func (h *Hydrator) rehydrateOnError(sourceCommit, syncDir string) {
+func (h *Hydrator) rehydrateOnError(ctx context.Context, sourceCommit, syncDir string) {
 	errorFile := h.HydratedRoot.Join(cmpath.RelativeSlash(ErrorFile))
 	if _, err := os.Stat(errorFile.OSPath()); err != nil {
 		if !os.IsNotExist(err) {
@@ -155,7 +175,7 @@ func (h *Hydrator) rehydrateOnError(sourceCommit, syncDir string) {
 		return
 	}
 	klog.Infof("retry rendering commit %s", sourceCommit)
-	hydrationErr := h.runHydrate(sourceCommit, syncDir)
+	hydrationErr := h.runHydrate(ctx, sourceCommit, syncDir)
 	if err := h.complete(sourceCommit, hydrationErr); err != nil {
 		klog.Errorf("failed to complete the re-rendering execution for commit %q: %v", sourceCommit, err)
 	}
@@ -318,6 +338,10 @@ func SourceCommitAndDir(sourceType v1beta1.SourceType, sourceRoot cmpath.Absolut
 	}
 
 	if _, err := os.Stat(errFilePath); err == nil || !os.IsNotExist(err) {
+		if err == nil && isVerificationError(errFilePath) {
+			return "", "", status.SourceVerificationError.Sprintf("unable to verify the source\n%s",
+				git.SyncError(containerName, errFilePath, fmt.Sprintf("%s=%s", metadata.ReconcilerLabel, reconcilerName))).Build()
+		}
 		return "", "", toSourceError(err)
 	}
 	gitDir, err := sourceRoot.EvalSymlinks()
@@ -342,3 +366,19 @@ func SourceCommitAndDir(sourceType v1beta1.SourceType, sourceRoot cmpath.Absolut
 	}
 	return commit, sourceDir, nil
 }
+
+// isVerificationError returns true if the error file written by the source
+// container reports that the source is not signed by a trusted key.
+func isVerificationError(errFilePath string) bool {
+	content, err := ioutil.ReadFile(errFilePath)
+	if err != nil {
+		return false
+	}
+	payload := struct {
+		Msg string
+	}{}
+	if err := json.Unmarshal(content, &payload); err != nil {
+		return false
+	}
+	return payload.Msg == oci.VerificationFailedMsg
+}
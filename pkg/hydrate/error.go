@@ -26,16 +26,23 @@ type HydrationError interface {
 // ActionableError represents the user actionable hydration error.
 type ActionableError struct {
 	error
+	code string
 }
 
 // NewActionableError returns the wrapper of the user actionable error.
 func NewActionableError(e error) ActionableError {
-	return ActionableError{e}
+	return ActionableError{error: e, code: status.ActionableHydrationErrorCode}
+}
+
+// NewActionableErrorWithCode returns the wrapper of the user actionable error
+// with a more specific code, e.g. the code of a kustomize failure.
+func NewActionableErrorWithCode(code string, e error) ActionableError {
+	return ActionableError{error: e, code: code}
 }
 
 // Code returns the user actionable error code.
 func (e ActionableError) Code() string {
-	return status.ActionableHydrationErrorCode
+	return e.code
 }
 
 // InternalError represents the internal hydration error.
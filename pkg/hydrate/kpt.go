@@ -0,0 +1,428 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/runtimeutil"
+	"sigs.k8s.io/kustomize/kyaml/fn/runtime/starlark"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// starlarkImage is the image of the kpt Starlark function, which is run in
+// process rather than in a container.
+const starlarkImage = "gcr.io/kpt-fn/starlark"
+
+// needsKptPipeline checks if the directory is a kpt package with a function
+// pipeline in its Kptfile or in the Kptfile of any subpackage.
+// Kptfiles which cannot be parsed are reported when the pipeline runs.
+func needsKptPipeline(dir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(dir, kptfilev1.KptFileName)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "unable to read the Kptfile in %s", dir)
+	}
+	found := false
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || d.Name() != kptfilev1.KptFileName {
+			return nil
+		}
+		kf, err := readKptfile(filepath.Dir(path))
+		if err != nil || hasPipeline(kf) {
+			found = true
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found, err
+}
+
+func hasPipeline(kf *kptfilev1.KptFile) bool {
+	return kf.Pipeline != nil && (len(kf.Pipeline.Mutators) > 0 || len(kf.Pipeline.Validators) > 0)
+}
+
+func readKptfile(dir string) (*kptfilev1.KptFile, error) {
+	b, err := os.ReadFile(filepath.Join(dir, kptfilev1.KptFileName))
+	if err != nil {
+		return nil, err
+	}
+	kf := &kptfilev1.KptFile{}
+	if err := yaml.Unmarshal(b, kf); err != nil {
+		return nil, errors.Wrapf(err, "invalid Kptfile in %s", dir)
+	}
+	return kf, nil
+}
+
+// kptRender runs the function pipelines of the kpt package in input, like
+// `kpt fn render`, and writes the rendered package to the output directory.
+// Only exec and Starlark functions are supported, so no container runtime is
+// needed.
+func kptRender(ctx context.Context, input, output string) HydrationError {
+	if err := resetOutput(output); err != nil {
+		return err
+	}
+	nodes, err := renderPackage(ctx, input, ".")
+	if err != nil {
+		hydrationErr := NewActionableError(errors.Wrapf(err, "failed to render the kpt package in %s", input))
+		mustDeleteOutput(hydrationErr, output)
+		return hydrationErr
+	}
+	if err := (kio.LocalPackageWriter{PackagePath: output}).Write(nodes); err != nil {
+		hydrationErr := NewInternalError(errors.Wrapf(err, "unable to write the rendered configs to %s", output))
+		mustDeleteOutput(hydrationErr, output)
+		return hydrationErr
+	}
+	return nil
+}
+
+// renderPackage renders the subpackages of the package in root/rel depth
+// first, and then runs the pipeline of the package on its own resources and
+// the rendered resources of its subpackages.
+func renderPackage(ctx context.Context, root, rel string) ([]*yaml.RNode, error) {
+	dir := filepath.Join(root, rel)
+	kf, err := readKptfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := kio.LocalPackageReader{
+		PackagePath:     dir,
+		PackageFileName: kptfilev1.KptFileName,
+		MatchFilesGlob:  append(kio.MatchAll, kptfilev1.KptFileName),
+	}.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the resources in %s", dir)
+	}
+	if err := prefixPaths(nodes, rel); err != nil {
+		return nil, err
+	}
+
+	subpackages, err := subpackageDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subpackages {
+		subNodes, err := renderPackage(ctx, root, filepath.Join(rel, sub))
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, subNodes...)
+	}
+
+	if kf.Pipeline == nil {
+		return nodes, nil
+	}
+	for _, fn := range kf.Pipeline.Mutators {
+		if nodes, err = runFunction(ctx, dir, fn, nodes); err != nil {
+			return nil, errors.Wrapf(err, "mutator %s failed in %s", functionName(fn), rel)
+		}
+	}
+	for _, fn := range kf.Pipeline.Validators {
+		// Validators must not change the resources, so their output is dropped.
+		if _, err := runFunction(ctx, dir, fn, nodes); err != nil {
+			return nil, errors.Wrapf(err, "validator %s failed in %s", functionName(fn), rel)
+		}
+	}
+	return nodes, nil
+}
+
+// subpackageDirs returns the paths of the direct subpackages of the package in
+// dir, relative to dir.
+func subpackageDirs(dir string) ([]string, error) {
+	var subpackages []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == dir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, kptfilev1.KptFileName)); err == nil {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			subpackages = append(subpackages, rel)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return subpackages, err
+}
+
+// prefixPaths makes the path annotations of the resources of a subpackage
+// relative to the root package.
+func prefixPaths(nodes []*yaml.RNode, rel string) error {
+	if rel == "." {
+		return nil
+	}
+	for _, n := range nodes {
+		for _, key := range []string{kioutil.PathAnnotation, kioutil.LegacyPathAnnotation} {
+			p, found := n.GetAnnotations()[key]
+			if !found {
+				continue
+			}
+			if err := n.PipeE(yaml.SetAnnotation(key, filepath.ToSlash(filepath.Join(rel, p)))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func functionName(fn kptfilev1.Function) string {
+	switch {
+	case fn.Name != "":
+		return fmt.Sprintf("%q", fn.Name)
+	case fn.Exec != "":
+		return fmt.Sprintf("%q", fn.Exec)
+	default:
+		return fmt.Sprintf("%q", fn.Image)
+	}
+}
+
+// runFunction runs a function of a pipeline on the resources it selects, and
+// returns the resources it did not select along with its output.
+func runFunction(ctx context.Context, pkgDir string, fn kptfilev1.Function, nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	config, err := functionConfig(pkgDir, fn)
+	if err != nil {
+		return nil, err
+	}
+	ff := &runtimeutil.FunctionFilter{
+		FunctionConfig: config,
+		GlobalScope:    true,
+		DeferFailure:   true,
+	}
+	var filter kio.Filter
+	switch {
+	case fn.Exec != "":
+		ff.Run = execFunction(ctx, pkgDir, fn.Exec)
+		filter = ff
+	case strings.HasPrefix(fn.Image, starlarkImage):
+		sf := &starlark.Filter{Name: fn.Name, FunctionFilter: *ff}
+		sf.Program, err = starlarkSource(config)
+		if err != nil {
+			return nil, err
+		}
+		ff = &sf.FunctionFilter
+		filter = sf
+	default:
+		return nil, errors.Errorf("the image %q cannot run without a container runtime, only exec and %s functions are supported", fn.Image, starlarkImage)
+	}
+
+	selected, unselected := selectResources(fn, nodes)
+	out, err := filter.Filter(selected)
+	if err != nil {
+		return nil, err
+	}
+	if err := functionError(ff); err != nil {
+		return nil, err
+	}
+	return append(out, unselected...), nil
+}
+
+// execFunction runs the executable of an exec function. Relative paths are
+// relative to the package directory. The executable is killed once ctx is
+// done.
+func execFunction(ctx context.Context, pkgDir, command string) func(io.Reader, io.Writer) error {
+	return func(reader io.Reader, writer io.Writer) error {
+		args := strings.Fields(command)
+		path := args[0]
+		if !filepath.IsAbs(path) && strings.ContainsRune(path, filepath.Separator) {
+			path = filepath.Join(pkgDir, path)
+		}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, args[1:]...)
+		cmd.Dir = pkgDir
+		cmd.Stdin = reader
+		cmd.Stdout = writer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return errors.Wrapf(ctx.Err(), "%s did not complete in time", args[0])
+			}
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return errors.Wrap(err, msg)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// functionConfig returns the functionConfig of a function, read from either
+// its configPath or its configMap.
+func functionConfig(pkgDir string, fn kptfilev1.Function) (*yaml.RNode, error) {
+	switch {
+	case fn.ConfigPath != "" && fn.ConfigMap != nil:
+		return nil, errors.New("configPath and configMap are mutually exclusive")
+	case fn.ConfigPath != "":
+		config, err := yaml.ReadFile(filepath.Join(pkgDir, fn.ConfigPath))
+		return config, errors.Wrapf(err, "unable to read the function config %s", fn.ConfigPath)
+	case fn.ConfigMap != nil:
+		config, err := yaml.Parse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: function-input\n")
+		if err != nil {
+			return nil, err
+		}
+		config.SetDataMap(fn.ConfigMap)
+		return config, nil
+	default:
+		return nil, nil
+	}
+}
+
+// starlarkSource returns the program of a StarlarkRun function config.
+func starlarkSource(config *yaml.RNode) (string, error) {
+	if config != nil {
+		for _, path := range [][]string{{"source"}, {"spec", "source"}} {
+			source, err := config.Pipe(yaml.Lookup(path...))
+			if err == nil && source != nil {
+				return yaml.GetValue(source), nil
+			}
+		}
+	}
+	return "", errors.New("the Starlark function needs a functionConfig with a source")
+}
+
+// selectResources partitions the resources into those selected by the
+// selectors and exclusions of the function, and the others.
+func selectResources(fn kptfilev1.Function, nodes []*yaml.RNode) ([]*yaml.RNode, []*yaml.RNode) {
+	var selected, unselected []*yaml.RNode
+	for _, n := range nodes {
+		if isSelected(fn, n) {
+			selected = append(selected, n)
+		} else {
+			unselected = append(unselected, n)
+		}
+	}
+	return selected, unselected
+}
+
+func isSelected(fn kptfilev1.Function, n *yaml.RNode) bool {
+	for _, s := range fn.Exclusions {
+		if matches(s, n) {
+			return false
+		}
+	}
+	if len(fn.Selectors) == 0 {
+		return true
+	}
+	for _, s := range fn.Selectors {
+		if matches(s, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(s kptfilev1.Selector, n *yaml.RNode) bool {
+	if (s.APIVersion != "" && s.APIVersion != n.GetApiVersion()) ||
+		(s.Kind != "" && s.Kind != n.GetKind()) ||
+		(s.Name != "" && s.Name != n.GetName()) ||
+		(s.Namespace != "" && s.Namespace != n.GetNamespace()) {
+		return false
+	}
+	return isSubset(s.Labels, n.GetLabels()) && isSubset(s.Annotations, n.GetAnnotations())
+}
+
+func isSubset(want, got map[string]string) bool {
+	for k, v := range want {
+		if got[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// functionResult is an item of the results of a function.
+type functionResult struct {
+	Message     string                   `yaml:"message,omitempty"`
+	Severity    string                   `yaml:"severity,omitempty"`
+	ResourceRef *yaml.ResourceIdentifier `yaml:"resourceRef,omitempty"`
+	File        *struct {
+		Path string `yaml:"path,omitempty"`
+	} `yaml:"file,omitempty"`
+}
+
+func (r functionResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", r.Severity, r.Message)
+	if r.ResourceRef != nil {
+		fmt.Fprintf(&b, " in %s %s", r.ResourceRef.Kind, r.ResourceRef.Name)
+		if r.ResourceRef.Namespace != "" {
+			fmt.Fprintf(&b, " in namespace %s", r.ResourceRef.Namespace)
+		}
+	}
+	if r.File != nil && r.File.Path != "" {
+		fmt.Fprintf(&b, " (%s)", r.File.Path)
+	}
+	return b.String()
+}
+
+// functionError returns an error if the function exited with an error or
+// reported results with the error severity.
+func functionError(ff *runtimeutil.FunctionFilter) error {
+	var results []functionResult
+	if ff.Results != nil {
+		s, err := ff.Results.String()
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal([]byte(s), &results); err != nil {
+			return errors.Wrap(err, "unable to parse the function results")
+		}
+	}
+	var msgs []string
+	failed := ff.GetExit() != nil
+	for _, r := range results {
+		if r.Severity == "" || r.Severity == "error" {
+			failed = true
+			msgs = append(msgs, r.String())
+		}
+	}
+	if !failed {
+		return nil
+	}
+	err := ff.GetExit()
+	if err == nil {
+		err = errors.New("the function reported errors")
+	}
+	if len(msgs) > 0 {
+		err = errors.Errorf("%v:\n%s", err, strings.Join(msgs, "\n"))
+	}
+	return err
+}
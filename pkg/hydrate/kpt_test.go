@@ -0,0 +1,325 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"kpt.dev/configsync/pkg/status"
+)
+
+const starlarkLabel = `apiVersion: fn.kpt.dev/v1alpha1
+kind: StarlarkRun
+metadata:
+  name: set-label
+  annotations:
+    config.kubernetes.io/local-config: "true"
+source: |
+  def run(resources):
+    for r in resources:
+      r["metadata"]["labels"] = {"env": "prod"}
+  run(ctx.resource_list["items"])
+`
+
+const starlarkAnnotation = `apiVersion: fn.kpt.dev/v1alpha1
+kind: StarlarkRun
+metadata:
+  name: set-annotation
+  annotations:
+    config.kubernetes.io/local-config: "true"
+source: |
+  def run(resources):
+    for r in resources:
+      r["metadata"]["annotations"]["team"] = "backend"
+  run(ctx.resource_list["items"])
+`
+
+func kptfile(pipeline string) string {
+	return `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: pkg
+  annotations:
+    config.kubernetes.io/local-config: "true"
+` + pipeline
+}
+
+func configMapYAML(name string) string {
+	return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ` + name + `
+  namespace: bookstore
+data:
+  key: value
+`
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNeedsKptPipeline(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{
+			name:  "no Kptfile",
+			files: map[string]string{"cm.yaml": configMapYAML("cm")},
+		},
+		{
+			name:  "Kptfile without a pipeline",
+			files: map[string]string{"Kptfile": kptfile("")},
+		},
+		{
+			name: "Kptfile with a pipeline",
+			files: map[string]string{
+				"Kptfile": kptfile("pipeline:\n  validators:\n  - exec: validate\n"),
+			},
+			want: true,
+		},
+		{
+			name: "subpackage with a pipeline",
+			files: map[string]string{
+				"Kptfile":     kptfile(""),
+				"sub/Kptfile": kptfile("pipeline:\n  mutators:\n  - exec: mutate\n"),
+			},
+			want: true,
+		},
+		{
+			name: "pipeline in a subpackage of a directory without a Kptfile",
+			files: map[string]string{
+				"sub/Kptfile": kptfile("pipeline:\n  mutators:\n  - exec: mutate\n"),
+			},
+		},
+		{
+			name:  "invalid Kptfile",
+			files: map[string]string{"Kptfile": "pipeline: [\n"},
+			want:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			got, err := needsKptPipeline(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("needsKptPipeline() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestKptRender(t *testing.T) {
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "hydrated")
+	writeFiles(t, input, map[string]string{
+		"Kptfile": kptfile(`pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/starlark:v0.4
+    configPath: set-label.yaml
+    selectors:
+    - kind: ConfigMap
+`),
+		"set-label.yaml": starlarkLabel,
+		"root.yaml":      configMapYAML("root"),
+		"sub/Kptfile": kptfile(`pipeline:
+  mutators:
+  - name: set-annotation
+    image: gcr.io/kpt-fn/starlark:v0.4
+    configPath: set-annotation.yaml
+    exclude:
+    - kind: StarlarkRun
+    - kind: Kptfile
+`),
+		"sub/set-annotation.yaml": starlarkAnnotation,
+		"sub/cm.yaml":             configMapYAML("sub"),
+	})
+
+	if err := kptRender(context.Background(), input, output); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := os.ReadFile(filepath.Join(output, "root.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: root
+  namespace: bookstore
+  labels:
+    env: prod
+data:
+  key: value
+`
+	if diff := cmp.Diff(wantRoot, string(root)); diff != "" {
+		t.Error(diff)
+	}
+
+	// The pipeline of the subpackage runs first, and then the pipeline of the
+	// root package runs on its output.
+	sub, err := os.ReadFile(filepath.Join(output, "sub", "cm.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSub := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sub
+  namespace: bookstore
+  annotations:
+    team: backend
+  labels:
+    env: prod
+data:
+  key: value
+`
+	if diff := cmp.Diff(wantSub, string(sub)); diff != "" {
+		t.Error(diff)
+	}
+	if _, err := os.Stat(filepath.Join(output, "sub", "Kptfile")); err != nil {
+		t.Errorf("the Kptfile of the subpackage is missing from the output: %v", err)
+	}
+}
+
+func TestKptRender_ValidatorFailure(t *testing.T) {
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "hydrated")
+	writeFiles(t, input, map[string]string{
+		"Kptfile": kptfile(`pipeline:
+  validators:
+  - name: deny-all
+    exec: ./validate.sh
+`),
+		"validate.sh": `#!/bin/sh
+cat > /dev/null
+cat <<EOF
+apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items: []
+results:
+- message: configmaps are not allowed
+  severity: error
+  resourceRef:
+    apiVersion: v1
+    kind: ConfigMap
+    name: cm
+    namespace: bookstore
+EOF
+exit 1
+`,
+		"cm.yaml": configMapYAML("cm"),
+	})
+
+	err := kptRender(context.Background(), input, output)
+	if err == nil {
+		t.Fatal("kptRender() got no error, want an error")
+	}
+	if err.Code() != status.ActionableHydrationErrorCode {
+		t.Errorf("kptRender() got error code %s, want %s", err.Code(), status.ActionableHydrationErrorCode)
+	}
+	for _, want := range []string{`validator "deny-all" failed`, "[error] configmaps are not allowed in ConfigMap cm in namespace bookstore"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("kptRender() got error %q, want it to contain %q", err, want)
+		}
+	}
+	if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+		t.Errorf("kptRender() did not delete the output directory: %v", statErr)
+	}
+}
+
+func TestKptRender_Timeout(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"Kptfile": kptfile(`pipeline:
+  mutators:
+  - name: hang
+    exec: ./hang.sh
+`),
+		"hang.sh": "#!/bin/sh\nexec sleep 60\n",
+		"cm.yaml": configMapYAML("cm"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := kptRender(ctx, input, filepath.Join(t.TempDir(), "hydrated"))
+	if err == nil {
+		t.Fatal("kptRender() got no error, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("kptRender() returned after %v, want the function to be stopped at the timeout", elapsed)
+	}
+	if !strings.Contains(err.Error(), "did not complete in time") {
+		t.Errorf("kptRender() got error %q, want a timeout error", err)
+	}
+}
+
+func TestKptRender_ContainerFunction(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"Kptfile": kptfile(`pipeline:
+  mutators:
+  - image: gcr.io/kpt-fn/set-namespace:v0.2
+    configMap:
+      namespace: bookstore
+`),
+		"cm.yaml": configMapYAML("cm"),
+	})
+
+	err := kptRender(context.Background(), input, filepath.Join(t.TempDir(), "hydrated"))
+	if err == nil {
+		t.Fatal("kptRender() got no error, want an error")
+	}
+	if !strings.Contains(err.Error(), "cannot run without a container runtime") {
+		t.Errorf("kptRender() got error %q, want an unsupported image error", err)
+	}
+}
+
+func TestRender_KustomizeAndKpt(t *testing.T) {
+	input := t.TempDir()
+	writeFiles(t, input, map[string]string{
+		"Kptfile":            kptfile("pipeline:\n  mutators:\n  - exec: mutate\n"),
+		"kustomization.yaml": "resources:\n- cm.yaml\n",
+		"cm.yaml":            configMapYAML("cm"),
+	})
+
+	err := render(context.Background(), input, filepath.Join(t.TempDir(), "hydrated"), false, nil)
+	if err == nil || err.Code() != status.ActionableHydrationErrorCode {
+		t.Errorf("render() got error %v, want an actionable error", err)
+	}
+}
@@ -0,0 +1,536 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kloader "sigs.k8s.io/kustomize/api/loader"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/resource"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// renderCacheSize is the number of renders kept by a renderCache.
+const renderCacheSize = 4
+
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// kustomize does not return typed errors for most of its failures, so the ones
+// without a typed error are classified by their messages. The plugin failures
+// are matched first, since the failure of a missing exec plugin also reads
+// "no such file or directory".
+var (
+	kustomizePluginErrorRegex               = regexp.MustCompile(`couldn't execute function|unable to find plugin root|plugin \S+ (fails to load|fails configuration|not a generator|not a transformer)|unexecutable plugin|unable to load builtin|unable to run: '`)
+	kustomizeMissingResourceErrorRegex      = regexp.MustCompile(`no such file or directory|evalsymlink failure|unable to find one of .* in directory`)
+	kustomizeInvalidKustomizationErrorRegex = regexp.MustCompile(`json: unknown field|json: cannot unmarshal|error converting YAML to JSON|Failed to read kustomization file|Found multiple kustomization files|expected kind`)
+)
+
+// kustomizeOptions returns the options of the in-process kustomize build.
+// They match `kustomize build --enable-alpha-plugins --enable-exec --enable-helm`,
+// so that both the Helm chart inflation generator and the Helm inflation
+// function are supported.
+func kustomizeOptions() *krusty.Options {
+	opts := krusty.MakeDefaultOptions()
+	// `kustomize build` sorts resources in the legacy order by default.
+	opts.DoLegacyResourceSort = true
+	opts.PluginConfig = types.MakePluginConfig(types.PluginRestrictionsNone, types.BploUseStaticallyLinked)
+	opts.PluginConfig.FnpLoadingOptions.EnableExec = true
+	opts.PluginConfig.HelmConfig = types.HelmConfig{Enabled: true, Command: Helm}
+	return opts
+}
+
+// checkHelm returns an error if the kustomization in dir declares Helm charts,
+// but the Helm binary is not installed. kustomize only reports the failure of
+// the Helm command as text, so a missing binary, which is an issue with the
+// installation rather than the kustomization, is detected before the build.
+func checkHelm(fSys filesys.FileSystem, dir string) HydrationError {
+	if !declaresHelmCharts(fSys, dir, make(map[string]bool)) {
+		return nil
+	}
+	if _, err := exec.LookPath(Helm); err != nil {
+		return NewInternalError(errors.Wrapf(err, "unable to render the Helm charts of the kustomization in %s", dir))
+	}
+	return nil
+}
+
+// kustomizeError returns the user actionable error of a failed kustomize build
+// of the kustomization in dir, with the code of the kind of failure.
+func kustomizeError(dir string, err error) ActionableError {
+	code := kustomizeErrorCode(err)
+	err = errors.Wrapf(err, "failed to render the kustomization in %s", dir)
+	if code == "" {
+		return NewActionableError(err)
+	}
+	return NewActionableErrorWithCode(code, err)
+}
+
+// kustomizeErrorCode returns the code of the kind of failure of a kustomize
+// build, or an empty string if it is unknown. The typed errors of kustomize
+// are checked first, and the messages of the other errors are matched against
+// the kustomize*ErrorRegex. A missing file is only checked for after the
+// plugin failures, since a missing exec plugin also fails with fs.ErrNotExist.
+func kustomizeErrorCode(err error) string {
+	switch {
+	case types.IsErrOnlyBuiltinPluginsAllowed(err), types.IsErrUnableToFind(err):
+		return status.KustomizePluginErrorCode
+	case errors.Is(err, kloader.ErrorHTTP):
+		return status.KustomizeMissingResourceErrorCode
+	}
+
+	msg := err.Error()
+	switch {
+	case kustomizePluginErrorRegex.MatchString(msg):
+		return status.KustomizePluginErrorCode
+	case errors.Is(err, fs.ErrNotExist), kustomizeMissingResourceErrorRegex.MatchString(msg):
+		return status.KustomizeMissingResourceErrorCode
+	case kustomizeInvalidKustomizationErrorRegex.MatchString(msg):
+		return status.KustomizeInvalidKustomizationErrorCode
+	default:
+		return ""
+	}
+}
+
+// declaresHelmCharts returns true if the kustomization in dir, or one of its
+// local bases or components, declares Helm charts.
+func declaresHelmCharts(fSys filesys.FileSystem, dir string, visited map[string]bool) bool {
+	if visited[dir] {
+		return false
+	}
+	visited[dir] = true
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		b, err := fSys.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		kt := &types.Kustomization{}
+		if err := yaml.Unmarshal(b, kt); err != nil {
+			// kustomize reports the invalid kustomization.
+			return false
+		}
+		if len(kt.HelmCharts) > 0 || len(kt.HelmChartInflationGenerator) > 0 {
+			return true
+		}
+		for _, r := range append(kt.Resources, append(kt.Bases, kt.Components...)...) {
+			if path := filepath.Join(dir, r); fSys.IsDir(path) && declaresHelmCharts(fSys, path, visited) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// resourceFiles returns the rendered resources keyed by their file names,
+// laid out the same way as `kustomize build --output <dir>`.
+func resourceFiles(m resmap.ResMap) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	add := func(name string, r *resource.Resource) error {
+		b, err := r.AsYAML()
+		if err != nil {
+			return err
+		}
+		files[name] = b
+		return nil
+	}
+	byNamespace := m.GroupedByCurrentNamespace()
+	for namespace, resources := range byNamespace {
+		for _, r := range resources {
+			name := resourceFileName(r)
+			if len(byNamespace) > 1 {
+				name = strings.ToLower(namespace) + "_" + name
+			}
+			if err := add(name, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, r := range m.ClusterScoped() {
+		if err := add(resourceFileName(r), r); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func resourceFileName(r *resource.Resource) string {
+	return strings.ToLower(r.GetGvk().StringWoEmptyField()) + "_" + strings.ToLower(r.GetName()) + ".yaml"
+}
+
+// writeResourceFiles writes the rendered files to the output directory.
+func writeResourceFiles(output string, files map[string][]byte) error {
+	for name, b := range files {
+		if err := os.WriteFile(filepath.Join(output, name), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderInput is an input of a kustomize build: the outcome of a filesystem
+// operation on a path relative to the kustomization root.
+type renderInput struct {
+	op   string
+	path string
+}
+
+const (
+	opRead    = "read"
+	opExists  = "exists"
+	opIsDir   = "isdir"
+	opReadDir = "readdir"
+	opGlob    = "glob"
+	opTree    = "tree"
+)
+
+// digest returns the content hash of the input in the kustomization root.
+func (in renderInput) digest(fSys filesys.FileSystem, root string) string {
+	path := filepath.Join(root, in.path)
+	switch in.op {
+	case opRead:
+		b, err := fSys.ReadFile(path)
+		return hashOf(b, err)
+	case opExists:
+		return fmt.Sprint(fSys.Exists(path))
+	case opIsDir:
+		return fmt.Sprint(fSys.IsDir(path))
+	case opReadDir:
+		names, err := fSys.ReadDir(path)
+		return hashOf([]byte(strings.Join(names, "\n")), err)
+	case opGlob:
+		matches, err := fSys.Glob(path)
+		return globDigest(root, matches, err)
+	case opTree:
+		return treeDigest(fSys, path)
+	default:
+		return ""
+	}
+}
+
+func hashOf(b []byte, err error) string {
+	if err != nil {
+		return "error"
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func globDigest(root string, matches []string, err error) string {
+	var rel []string
+	for _, m := range matches {
+		r, relErr := filepath.Rel(root, m)
+		if relErr != nil {
+			return "error"
+		}
+		rel = append(rel, r)
+	}
+	return hashOf([]byte(strings.Join(rel, "\n")), err)
+}
+
+// treeDigest returns the content hash of all the files in a directory tree.
+func treeDigest(fSys filesys.FileSystem, dir string) string {
+	h := sha256.New()
+	err := fSys.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\n", rel)
+		if info.IsDir() {
+			return nil
+		}
+		b, err := fSys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\n", hashOf(b, nil))
+		return nil
+	})
+	if err != nil {
+		return "error"
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingFS is a filesys.FileSystem which records the content hash of every
+// input read by a kustomize build, so that the output can be reused as long as
+// the inputs are unchanged.
+type recordingFS struct {
+	filesys.FileSystem
+	// root is the cleaned absolute path of the kustomization root.
+	root   string
+	inputs map[renderInput]string
+	// ignored are the directories whose contents are pinned by a reference in
+	// a kustomization, such as Helm charts pulled at a fixed version.
+	ignored []string
+	// uncacheable explains why the output cannot be reused, if it cannot.
+	uncacheable string
+}
+
+func newRecordingFS(fSys filesys.FileSystem, input string) (*recordingFS, error) {
+	root, _, err := fSys.CleanedAbs(input)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingFS{
+		FileSystem: fSys,
+		root:       root.String(),
+		inputs:     make(map[renderInput]string),
+	}, nil
+}
+
+func (f *recordingFS) record(op, path, digest string) {
+	if f.uncacheable != "" {
+		return
+	}
+	// Remote bases are cloned into temporary directories. They are only
+	// cached if the kustomization pins them to a commit.
+	if strings.HasPrefix(path, filepath.Join(os.TempDir(), "kustomize-")) {
+		return
+	}
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil || !filepath.IsAbs(path) {
+		f.uncacheable = fmt.Sprintf("unable to resolve the path %q", path)
+		return
+	}
+	f.inputs[renderInput{op: op, path: rel}] = digest
+}
+
+// ReadFile implements filesys.FileSystem.
+func (f *recordingFS) ReadFile(path string) ([]byte, error) {
+	b, err := f.FileSystem.ReadFile(path)
+	f.record(opRead, path, hashOf(b, err))
+	if err == nil && hasKustomization(filepath.Base(path)) {
+		f.readKustomization(filepath.Dir(path), b)
+	}
+	return b, err
+}
+
+// Open implements filesys.FileSystem.
+func (f *recordingFS) Open(path string) (filesys.File, error) {
+	file, err := f.FileSystem.Open(path)
+	b, readErr := f.FileSystem.ReadFile(path)
+	f.record(opRead, path, hashOf(b, readErr))
+	return file, err
+}
+
+// Exists implements filesys.FileSystem.
+func (f *recordingFS) Exists(path string) bool {
+	exists := f.FileSystem.Exists(path)
+	f.record(opExists, path, fmt.Sprint(exists))
+	return exists
+}
+
+// CleanedAbs implements filesys.FileSystem. Kustomize resolves a path before
+// reading it, so a file which does not exist is only seen here.
+func (f *recordingFS) CleanedAbs(path string) (filesys.ConfirmedDir, string, error) {
+	dir, file, err := f.FileSystem.CleanedAbs(path)
+	if filepath.IsAbs(path) {
+		f.record(opExists, filepath.Clean(path), fmt.Sprint(err == nil))
+	}
+	return dir, file, err
+}
+
+// IsDir implements filesys.FileSystem.
+func (f *recordingFS) IsDir(path string) bool {
+	isDir := f.FileSystem.IsDir(path)
+	f.record(opIsDir, path, fmt.Sprint(isDir))
+	return isDir
+}
+
+// ReadDir implements filesys.FileSystem.
+func (f *recordingFS) ReadDir(path string) ([]string, error) {
+	names, err := f.FileSystem.ReadDir(path)
+	f.record(opReadDir, path, hashOf([]byte(strings.Join(names, "\n")), err))
+	return names, err
+}
+
+// Glob implements filesys.FileSystem.
+func (f *recordingFS) Glob(pattern string) ([]string, error) {
+	matches, err := f.FileSystem.Glob(pattern)
+	f.record(opGlob, pattern, globDigest(f.root, matches, err))
+	return matches, err
+}
+
+// readKustomization checks the references of a kustomization which are not
+// read through the filesystem, i.e. remote bases, Helm charts and plugins.
+func (f *recordingFS) readKustomization(dir string, b []byte) {
+	kt := &types.Kustomization{}
+	if err := yaml.Unmarshal(b, kt); err != nil {
+		f.uncacheable = fmt.Sprintf("unable to parse the kustomization in %s", dir)
+		return
+	}
+	for _, r := range append(kt.Resources, append(kt.Bases, kt.Components...)...) {
+		if f.FileSystem.Exists(filepath.Join(dir, r)) {
+			continue
+		}
+		if !pinnedToCommit(r) {
+			f.uncacheable = fmt.Sprintf("remote resource %q is not pinned to a commit", r)
+			return
+		}
+	}
+	if len(kt.HelmChartInflationGenerator) > 0 {
+		f.uncacheable = "helmChartInflationGenerator is not cached"
+		return
+	}
+	if len(kt.Generators) > 0 || len(kt.Transformers) > 0 || len(kt.Validators) > 0 {
+		f.uncacheable = "plugins are not cached"
+		return
+	}
+	chartHome := "charts"
+	if kt.HelmGlobals != nil && kt.HelmGlobals.ChartHome != "" {
+		chartHome = kt.HelmGlobals.ChartHome
+	}
+	for _, chart := range kt.HelmCharts {
+		chartDir := filepath.Join(dir, chartHome, chart.Name)
+		switch {
+		case chart.Repo == "":
+			// Helm reads local charts directly from the disk.
+			f.record(opTree, chartDir, treeDigest(f.FileSystem, chartDir))
+		case chart.Version == "":
+			f.uncacheable = fmt.Sprintf("helm chart %q has no version", chart.Name)
+			return
+		default:
+			f.ignored = append(f.ignored, chartDir)
+		}
+	}
+}
+
+// pinnedToCommit returns true if the remote resource references a commit.
+func pinnedToCommit(r string) bool {
+	i := strings.Index(r, "?")
+	if i < 0 {
+		return false
+	}
+	query, err := url.ParseQuery(r[i+1:])
+	if err != nil {
+		return false
+	}
+	return commitSHARegex.MatchString(query.Get("ref")) || commitSHARegex.MatchString(query.Get("version"))
+}
+
+// digests returns the recorded inputs, or nil if the output cannot be cached.
+func (f *recordingFS) digests() map[renderInput]string {
+	if f.uncacheable != "" {
+		return nil
+	}
+	digests := make(map[renderInput]string)
+	for in, d := range f.inputs {
+		if !f.isIgnored(in.path) {
+			digests[in] = d
+		}
+	}
+	return digests
+}
+
+func (f *recordingFS) isIgnored(rel string) bool {
+	path := filepath.Join(f.root, rel)
+	for _, dir := range f.ignored {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderCache caches the output of kustomize builds by the content hash of
+// their inputs, so that a commit which does not change the configs under the
+// kustomization root, or the remote bases and Helm charts it pins, is not
+// rendered again. It is not safe for concurrent use.
+type renderCache struct {
+	// entries are the cached renders, most recent first.
+	entries []renderEntry
+}
+
+type renderEntry struct {
+	inputs map[renderInput]string
+	files  map[string][]byte
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{}
+}
+
+// get returns the cached files of a render whose inputs are unchanged in the
+// kustomization root.
+func (c *renderCache) get(fSys filesys.FileSystem, input string) (map[string][]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	root, _, err := fSys.CleanedAbs(input)
+	if err != nil {
+		return nil, false
+	}
+	for _, e := range c.entries {
+		if e.matches(fSys, root.String()) {
+			return e.files, true
+		}
+	}
+	return nil, false
+}
+
+func (e renderEntry) matches(fSys filesys.FileSystem, root string) bool {
+	// Check the inputs in a stable order so that misses are cheap to debug.
+	var inputs []renderInput
+	for in := range e.inputs {
+		inputs = append(inputs, in)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].path != inputs[j].path {
+			return inputs[i].path < inputs[j].path
+		}
+		return inputs[i].op < inputs[j].op
+	})
+	for _, in := range inputs {
+		if in.digest(fSys, root) != e.inputs[in] {
+			return false
+		}
+	}
+	return true
+}
+
+// add caches the files rendered from the recorded inputs.
+func (c *renderCache) add(f *recordingFS, files map[string][]byte) {
+	if c == nil {
+		return
+	}
+	inputs := f.digests()
+	if len(inputs) == 0 {
+		return
+	}
+	c.entries = append([]renderEntry{{inputs: inputs, files: files}}, c.entries...)
+	if len(c.entries) > renderCacheSize {
+		c.entries = c.entries[:renderCacheSize]
+	}
+}
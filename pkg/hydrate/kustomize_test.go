@@ -0,0 +1,373 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"kpt.dev/configsync/pkg/status"
+	kloader "sigs.k8s.io/kustomize/api/loader"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const (
+	testBase = `resources:
+- configmap.yaml
+`
+	testOverlay = `namespace: bookstore
+resources:
+- ../base
+`
+	testConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  key: value
+`
+)
+
+// writeRepo writes an overlay and its base into a new commit directory, and
+// returns the path of the overlay.
+func writeRepo(t *testing.T, commitDir string, files map[string]string) string {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(commitDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(commitDir, "overlay")
+}
+
+func testRepo() map[string]string {
+	return map[string]string{
+		"base/kustomization.yaml":    testBase,
+		"base/configmap.yaml":        testConfigMap,
+		"overlay/kustomization.yaml": testOverlay,
+	}
+}
+
+func TestKustomizeBuild(t *testing.T) {
+	tmp := t.TempDir()
+	input := writeRepo(t, filepath.Join(tmp, "source", "commit1"), testRepo())
+	output := filepath.Join(tmp, "hydrated")
+
+	if err := kustomizeBuild(context.Background(), input, output, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(output, "v1_configmap_cm.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+data:
+  key: value
+kind: ConfigMap
+metadata:
+  annotations:
+    config.kubernetes.io/origin: |
+      path: ../base/configmap.yaml
+  name: cm
+  namespace: bookstore
+`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Error(diff)
+	}
+	kustomization, err := os.ReadFile(filepath.Join(input, "kustomization.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(kustomization) != testOverlay {
+		t.Errorf("kustomizeBuild() rewrote the kustomization file:\n%s", kustomization)
+	}
+}
+
+func TestKustomizeBuild_Error(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		wantCode string
+	}{
+		{
+			name:     "missing base",
+			files:    map[string]string{"overlay/kustomization.yaml": "resources:\n- ../missing\n"},
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+		{
+			name:     "missing patch",
+			files:    map[string]string{"overlay/kustomization.yaml": "patchesStrategicMerge:\n- missing.yaml\n"},
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+		{
+			name:     "missing kustomization in a base",
+			files:    map[string]string{"base/README.md": "no kustomization", "overlay/kustomization.yaml": testOverlay},
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+		{
+			name:     "unknown field in the kustomization",
+			files:    map[string]string{"overlay/kustomization.yaml": "resourcez:\n- configmap.yaml\n"},
+			wantCode: status.KustomizeInvalidKustomizationErrorCode,
+		},
+		{
+			name:     "resources of the wrong type in the kustomization",
+			files:    map[string]string{"overlay/kustomization.yaml": "resources: configmap.yaml\n"},
+			wantCode: status.KustomizeInvalidKustomizationErrorCode,
+		},
+		{
+			name:     "malformed kustomization",
+			files:    map[string]string{"overlay/kustomization.yaml": "resources: [\n"},
+			wantCode: status.KustomizeInvalidKustomizationErrorCode,
+		},
+		{
+			name: "missing exec function",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "generators:\n- generator.yaml\n",
+				"overlay/generator.yaml": "apiVersion: example.com/v1\nkind: Generator\nmetadata:\n  name: generator\n" +
+					"  annotations:\n    config.kubernetes.io/function: |\n      exec:\n        path: ./missing-function\n",
+			},
+			wantCode: status.KustomizePluginErrorCode,
+		},
+		{
+			name: "missing transformer plugin",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "transformers:\n- transformer.yaml\n",
+				"overlay/transformer.yaml":   "apiVersion: example.com/v1\nkind: Transformer\nmetadata:\n  name: transformer\n",
+			},
+			wantCode: status.KustomizePluginErrorCode,
+		},
+		{
+			name: "other failure",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "resources:\n- a.yaml\n- b.yaml\n",
+				"overlay/a.yaml":             testConfigMap,
+				"overlay/b.yaml":             testConfigMap,
+			},
+			wantCode: status.ActionableHydrationErrorCode,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			input := writeRepo(t, tmp, tc.files)
+			output := filepath.Join(tmp, "hydrated")
+
+			err := kustomizeBuild(context.Background(), input, output, false, nil)
+			if err == nil {
+				t.Fatal("kustomizeBuild() got no error, want an error")
+			}
+			if err.Code() != tc.wantCode {
+				t.Errorf("kustomizeBuild() got error code %s, want %s: %v", err.Code(), tc.wantCode, err)
+			}
+			if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+				t.Errorf("kustomizeBuild() did not delete the output directory: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestKustomizeError_Helm(t *testing.T) {
+	err := kustomizeError("overlay", errors.New("unable to run: 'helm template chart' with env=[] (is 'helm' installed?): exit status 1"))
+	if err.Code() != status.KustomizePluginErrorCode {
+		t.Errorf("kustomizeError() got error code %s, want %s", err.Code(), status.KustomizePluginErrorCode)
+	}
+}
+
+func TestKustomizeError_Typed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{
+			name:     "external plugins disabled",
+			err:      errors.Wrap(types.NewErrOnlyBuiltinPluginsAllowed("Transformer"), "failed to load"),
+			wantCode: status.KustomizePluginErrorCode,
+		},
+		{
+			name:     "missing plugin root",
+			err:      types.NewErrUnableToFind("plugin root", nil),
+			wantCode: status.KustomizePluginErrorCode,
+		},
+		{
+			name:     "remote resource not found",
+			err:      fmt.Errorf("%w: status code 404 (Not Found)", kloader.ErrorHTTP),
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+		{
+			name:     "missing file",
+			err:      errors.Wrap(&fs.PathError{Op: "open", Path: "cm.yaml", Err: fs.ErrNotExist}, "accumulating resources"),
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := kustomizeError("overlay", tc.err)
+			if err.Code() != tc.wantCode {
+				t.Errorf("kustomizeError() got error code %s, want %s: %v", err.Code(), tc.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestKustomizeBuild_MissingHelm(t *testing.T) {
+	// No Helm binary can be found.
+	t.Setenv("PATH", t.TempDir())
+
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		wantCode string
+	}{
+		{
+			name: "helm charts in the kustomization",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "helmCharts:\n- name: chart\n  repo: https://charts.example.com\n  version: 1.0.0\n",
+			},
+			wantCode: status.InternalHydrationErrorCode,
+		},
+		{
+			name: "helm charts in a base",
+			files: map[string]string{
+				"base/kustomization.yaml":    "helmCharts:\n- name: chart\n",
+				"overlay/kustomization.yaml": testOverlay,
+			},
+			wantCode: status.InternalHydrationErrorCode,
+		},
+		{
+			name: "invalid kustomization without helm charts",
+			files: map[string]string{
+				"overlay/kustomization.yaml": "resources:\n- ../missing\n",
+			},
+			wantCode: status.KustomizeMissingResourceErrorCode,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			input := writeRepo(t, tmp, tc.files)
+			output := filepath.Join(tmp, "hydrated")
+
+			err := kustomizeBuild(context.Background(), input, output, false, nil)
+			if err == nil {
+				t.Fatal("kustomizeBuild() got no error, want an error")
+			}
+			if err.Code() != tc.wantCode {
+				t.Errorf("kustomizeBuild() got error code %s, want %s: %v", err.Code(), tc.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	tmp := t.TempDir()
+	fSys := filesys.MakeFsOnDisk()
+	cache := newRenderCache()
+
+	input1 := writeRepo(t, filepath.Join(tmp, "commit1"), testRepo())
+	if err := kustomizeBuild(context.Background(), input1, filepath.Join(tmp, "hydrated1"), false, cache); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("got %d cache entries, want 1", len(cache.entries))
+	}
+
+	// A new commit which does not change the kustomization reuses the output.
+	files := testRepo()
+	files["README.md"] = "unrelated change"
+	input2 := writeRepo(t, filepath.Join(tmp, "commit2"), files)
+	if _, found := cache.get(fSys, input2); !found {
+		t.Error("cache.get() missed a kustomization with unchanged inputs")
+	}
+
+	// Changes to the base invalidate the cache.
+	files = testRepo()
+	files["base/configmap.yaml"] = testConfigMap + "  other: value\n"
+	input3 := writeRepo(t, filepath.Join(tmp, "commit3"), files)
+	if _, found := cache.get(fSys, input3); found {
+		t.Error("cache.get() hit a kustomization with a changed base")
+	}
+
+	// So do new files which change how the kustomization is loaded.
+	files = testRepo()
+	files["overlay/Kustomization"] = testOverlay
+	input4 := writeRepo(t, filepath.Join(tmp, "commit4"), files)
+	if _, found := cache.get(fSys, input4); found {
+		t.Error("cache.get() hit a kustomization root with multiple kustomization files")
+	}
+}
+
+func TestRenderCache_Uncacheable(t *testing.T) {
+	testCases := []struct {
+		name          string
+		kustomization string
+	}{
+		{
+			name:          "unpinned remote base",
+			kustomization: "resources:\n- github.com/example/repo//base?ref=main\n",
+		},
+		{
+			name:          "unversioned helm chart",
+			kustomization: "helmCharts:\n- name: chart\n  repo: https://example.com/charts\n",
+		},
+		{
+			name:          "plugins",
+			kustomization: "transformers:\n- transformer.yaml\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte(tc.kustomization), 0644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := newRecordingFS(filesys.MakeFsOnDisk(), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.ReadFile(filepath.Join(f.root, "kustomization.yaml")); err != nil {
+				t.Fatal(err)
+			}
+			if f.uncacheable == "" || f.digests() != nil {
+				t.Errorf("got a cacheable kustomization, want uncacheable")
+			}
+		})
+	}
+}
+
+func TestPinnedToCommit(t *testing.T) {
+	testCases := map[string]bool{
+		"github.com/example/repo//base":                                                    false,
+		"github.com/example/repo//base?ref=v1.0.0":                                         false,
+		"github.com/example/repo//base?ref=0123456789abcdef0123456789abcdef01234567":       true,
+		"https://github.com/example/repo?version=0123456789abcdef0123456789abcdef01234567": true,
+	}
+	for r, want := range testCases {
+		if got := pinnedToCommit(r); got != want {
+			t.Errorf("pinnedToCommit(%q) = %t, want %t", r, got, want)
+		}
+	}
+}
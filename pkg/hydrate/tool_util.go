@@ -26,6 +26,7 @@ import (
 
 	"github.com/Masterminds/semver"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/cmd/nomos/flags"
 	nomosparse "kpt.dev/configsync/cmd/nomos/parse"
@@ -38,6 +39,8 @@ import (
 	"kpt.dev/configsync/pkg/util/discovery"
 	"kpt.dev/configsync/pkg/validate"
 	"kpt.dev/configsync/pkg/vet"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -123,17 +126,40 @@ func mustDeleteOutput(err error, output string) {
 	klog.Fatalf("Attempted to delete the output directory %s for %d times, but all failed. Exiting now...", output, retries)
 }
 
-// kustomizeBuild runs the 'kustomize build' command to render the configs.
-func kustomizeBuild(input, output string, sendMetrics bool) HydrationError {
-	// The `--enable-alpha-plugins` and `--enable-exec` flags are to support rendering
-	// Helm charts using the Helm inflation function.
-	// The `--enable-helm` flag is to enable use of the Helm chart inflator generator.
-	// We decided to enable all the flags so that both the Helm plugin and Helm
-	// inflation function are supported. This provides us with a fallback plan
-	// if the new Helm inflation function is having issues.
-	// It has no side-effect if no Helm chart in the DRY configs.
-	args := []string{"--enable-alpha-plugins", "--enable-exec", "--enable-helm", "--output", output}
+// needsRendering checks if the configs in the directory need to be rendered,
+// either with Kustomize or with a Kptfile pipeline.
+func needsRendering(dir string) (bool, error) {
+	kustomize, err := needsKustomize(dir)
+	if err != nil || kustomize {
+		return kustomize, err
+	}
+	return needsKptPipeline(dir)
+}
+
+// render renders the configs in the input directory to the output directory,
+// running the Kptfile pipeline of a kpt package or building a kustomization.
+func render(ctx context.Context, input, output string, sendMetrics bool, cache *renderCache) HydrationError {
+	kustomize, err := needsKustomize(input)
+	if err != nil {
+		return NewInternalError(err)
+	}
+	kpt, err := needsKptPipeline(input)
+	if err != nil {
+		return NewInternalError(err)
+	}
+	switch {
+	case kustomize && kpt:
+		return NewActionableError(errors.Errorf("both a Kustomization and a Kptfile pipeline are found in %s. "+
+			"To fix, render the configs with only one of them.", input))
+	case kpt:
+		return kptRender(ctx, input, output)
+	default:
+		return kustomizeBuild(ctx, input, output, sendMetrics, cache)
+	}
+}
 
+// resetOutput deletes the output directory if it exists, and recreates it.
+func resetOutput(output string) HydrationError {
 	if _, err := os.Stat(output); err == nil {
 		mustDeleteOutput(err, output)
 	}
@@ -142,15 +168,54 @@ func kustomizeBuild(input, output string, sendMetrics bool) HydrationError {
 	if err := os.MkdirAll(output, fileMode); err != nil {
 		return NewInternalError(errors.Wrapf(err, "unable to make directory: %s", output))
 	}
+	return nil
+}
+
+// kustomizeBuild renders the configs with the kustomize API, and writes them to
+// the output directory the same way as `kustomize build --output`.
+// If the cache is not nil, the output is reused when none of the inputs of the
+// kustomization changed since a previous build.
+func kustomizeBuild(ctx context.Context, input, output string, sendMetrics bool, cache *renderCache) HydrationError {
+	if err := resetOutput(output); err != nil {
+		return err
+	}
 
-	// run kustomize build with the wrapper library
-	out, err := kmetrics.RunKustomizeBuild(context.Background(), sendMetrics, input, args...)
-	if err != nil {
-		kustomizeErr := errors.Wrapf(err, "failed to run kustomize build in %s, stdout: %s", input, out)
-		mustDeleteOutput(kustomizeErr, output)
-		return NewActionableError(kustomizeErr)
+	fSys := filesys.MakeFsOnDisk()
+	files, found := cache.get(fSys, input)
+	if found {
+		klog.V(4).Infof("Reusing the rendered configs of an unchanged kustomization in %s", input)
+	} else {
+		recorder, err := newRecordingFS(fSys, input)
+		if err != nil {
+			return NewActionableError(errors.Wrapf(err, "unable to find the kustomization in %s", input))
+		}
+		if hydrationErr := checkHelm(fSys, input); hydrationErr != nil {
+			mustDeleteOutput(hydrationErr, output)
+			return hydrationErr
+		}
+		m, err := kmetrics.RunKustomizeBuild(ctx, sendMetrics, recorder, input, kustomizeOptions())
+		if err != nil {
+			hydrationErr := kustomizeError(input, err)
+			mustDeleteOutput(hydrationErr, output)
+			return hydrationErr
+		}
+		files, err = resourceFiles(m)
+		if err != nil {
+			hydrationErr := NewInternalError(errors.Wrapf(err, "unable to serialize the rendered configs in %s", input))
+			mustDeleteOutput(hydrationErr, output)
+			return hydrationErr
+		}
+		if recorder.uncacheable != "" {
+			klog.V(4).Infof("Not caching the rendered configs in %s: %s", input, recorder.uncacheable)
+		}
+		cache.add(recorder, files)
 	}
 
+	if err := writeResourceFiles(output, files); err != nil {
+		hydrationErr := NewInternalError(errors.Wrapf(err, "unable to write the rendered configs to %s", output))
+		mustDeleteOutput(hydrationErr, output)
+		return hydrationErr
+	}
 	return nil
 }
 
@@ -193,22 +258,11 @@ func getVersion(tool string) (string, error) {
 	return version, nil
 }
 
-func validateKustomize() error {
-	version, err := getVersion(Kustomize)
-	if err != nil {
-		return errors.Errorf("Kustomization file is detected, but Kustomize is not installed: %v. Please install Kustomize and re-run the command.", err)
-	}
-	if err := validateTool(Kustomize, version, KustomizeVersion); err != nil {
-		fmt.Printf("WARNING: %v\n", err)
-	}
-	return nil
-}
-
 func validateHelm() error {
 	version, err := getVersion(Helm)
 	if err != nil {
 		// return nil because Helm binary is optional
-		// 'kustomize build' will fail if Helm is needed but not installed
+		// rendering will fail if Helm is needed but not installed
 		return nil
 	}
 	if err := validateTool(Helm, version, HelmVersion); err != nil {
@@ -217,38 +271,52 @@ func validateHelm() error {
 	return nil
 }
 
-// ValidateAndRunKustomize validates if the Kustomize and Helm binaries are supported.
-// If supported, it copies the source configs to a temp directory, run 'kustomize build',
-// save the output to another temp directory, and return the output path for further
-// parsing and validation.
-func ValidateAndRunKustomize(sourcePath string) (cmpath.Absolute, error) {
+// ValidateAndRender validates if the Helm binary is supported.
+// If supported, it renders the source configs with Kustomize or the Kptfile
+// pipeline, saves the output to a temp directory, and returns the output path
+// for further parsing and validation.
+func ValidateAndRender(sourcePath string) (cmpath.Absolute, error) {
 	var output cmpath.Absolute
-	if err := validateKustomize(); err != nil {
-		return output, err
-	}
 	if err := validateHelm(); err != nil {
 		return output, err
 	}
 
-	// Save the 'kustomize build' output to a temp directory for further
+	// Save the rendered output to a temp directory for further
 	// parsing or validation.
 	tmpHydratedDir, err := ioutil.TempDir(os.TempDir(), "hydrated-")
 	if err != nil {
 		return output, err
 	}
 
-	if err := kustomizeBuild(sourcePath, tmpHydratedDir, false); err != nil {
+	if err := render(context.Background(), sourcePath, tmpHydratedDir, false, nil); err != nil {
 		return output, errors.Wrapf(err, "unable to render the source configs in %s", sourcePath)
 	}
 
-	fmt.Println("NOTICE: The command will save the remote Helm charts to a local directory defined in the `helmGlobals.chartHome` field if the Kustomization file references remote Helm charts. " +
-		"The default value is `charts`, which is relative to the Kustomization root. Please delete or ignore the directory in your Git repository.")
+	kustomize, err := needsKustomize(sourcePath)
+	if err != nil {
+		return output, err
+	}
+	if kustomize {
+		fmt.Println("NOTICE: The command will save the remote Helm charts to a local directory defined in the `helmGlobals.chartHome` field if the Kustomization file references remote Helm charts. " +
+			"The default value is `charts`, which is relative to the Kustomization root. Please delete or ignore the directory in your Git repository.")
+	}
 	return cmpath.AbsoluteOS(tmpHydratedDir)
 }
 
 // ValidateHydrateFlags validates the hydrate and vet flags.
 // It returns the absolute path of the source directory, if hydration is needed, and errors.
 func ValidateHydrateFlags(sourceFormat filesystem.SourceFormat) (cmpath.Absolute, bool, error) {
+	switch flags.OutputFormat {
+	case flags.OutputYAML, flags.OutputJSON: // do nothing
+	default:
+		return "", false, fmt.Errorf("format argument must be %q or %q", flags.OutputYAML, flags.OutputJSON)
+	}
+	return SourceDir(sourceFormat)
+}
+
+// SourceDir returns the absolute path of the source directory in the --path
+// flag, and if it needs to be rendered with Kustomize.
+func SourceDir(sourceFormat filesystem.SourceFormat) (cmpath.Absolute, bool, error) {
 	abs, err := filepath.Abs(flags.Path)
 	if err != nil {
 		return "", false, err
@@ -262,27 +330,30 @@ func ValidateHydrateFlags(sourceFormat filesystem.SourceFormat) (cmpath.Absolute
 		return "", false, err
 	}
 
-	switch flags.OutputFormat {
-	case flags.OutputYAML, flags.OutputJSON: // do nothing
-	default:
-		return "", false, fmt.Errorf("format argument must be %q or %q", flags.OutputYAML, flags.OutputJSON)
-	}
-
-	needsKustomize, err := needsKustomize(abs)
+	needsRendering, err := needsRendering(abs)
 	if err != nil {
-		return "", false, errors.Wrapf(err, "unable to check if Kustomize is needed for the source directory: %s", abs)
+		return "", false, errors.Wrapf(err, "unable to check if rendering is needed for the source directory: %s", abs)
 	}
 
-	if needsKustomize && sourceFormat == filesystem.SourceFormatHierarchy {
-		return "", false, fmt.Errorf("%s must be %s when Kustomization is needed", reconcilermanager.SourceFormat, filesystem.SourceFormatUnstructured)
+	if needsRendering && sourceFormat == filesystem.SourceFormatHierarchy {
+		return "", false, fmt.Errorf("%s must be %s when Kustomization or a Kptfile pipeline is needed", reconcilermanager.SourceFormat, filesystem.SourceFormatUnstructured)
 	}
 
-	return rootDir, needsKustomize, nil
+	return rootDir, needsRendering, nil
 }
 
 // ValidateOptions returns the validate options for nomos hydrate and vet commands.
 func ValidateOptions(ctx context.Context, rootDir cmpath.Absolute) (validate.Options, error) {
 	var options = validate.Options{}
+	clusterLabels, err := labels.ConvertSelectorToLabelsMap(flags.ClusterLabels)
+	if err != nil {
+		return options, fmt.Errorf("invalid --cluster-labels %q: %w", flags.ClusterLabels, err)
+	}
+	clusterValues, err := readClusterValues(flags.ClusterValuesFile)
+	if err != nil {
+		return options, err
+	}
+
 	syncedCRDs, err := nomosparse.GetSyncedCRDs(ctx, flags.SkipAPIServer)
 	if err != nil {
 		return options, err
@@ -316,6 +387,8 @@ func ValidateOptions(ctx context.Context, rootDir cmpath.Absolute) (validate.Opt
 
 	addFunc := vet.AddCachedAPIResources(rootDir.Join(vet.APIResourcesPath))
 
+	options.ClusterLabels = clusterLabels
+	options.ClusterValues = clusterValues
 	options.PolicyDir = cmpath.RelativeOS(rootDir.OSPath())
 	options.PreviousCRDs = syncedCRDs
 	options.BuildScoper = discovery.ScoperBuilder(serverResourcer, addFunc)
@@ -323,3 +396,20 @@ func ValidateOptions(ctx context.Context, rootDir cmpath.Absolute) (validate.Opt
 	options.AllowUnknownKinds = flags.SkipAPIServer
 	return options, nil
 }
+
+// readClusterValues returns the values of the ${cluster.values.KEY} variables
+// in the given YAML or JSON file, or nil if no file is given.
+func readClusterValues(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster values: %w", err)
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid cluster values in %s: %w", path, err)
+	}
+	return values, nil
+}
@@ -16,7 +16,11 @@ package hydrate
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestValidateTool(t *testing.T) {
@@ -93,3 +97,44 @@ func TestNeedsKustomize(t *testing.T) {
 		})
 	}
 }
+
+func TestReadClusterValues(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "YAML values",
+			content: "replicas: \"3\"\nzone: us-east1-b\n",
+			want:    map[string]string{"replicas": "3", "zone": "us-east1-b"},
+		},
+		{
+			name:    "JSON values",
+			content: `{"replicas": "3"}`,
+			want:    map[string]string{"replicas": "3"},
+		},
+		{
+			name:    "nested values",
+			content: "replicas:\n  min: 3\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "values.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readClusterValues(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got readClusterValues() error %v, want error %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package selectors
 
 import (
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/status"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +44,20 @@ func EmptySelectorError(selector client.Object) status.Error {
 	return invalidSelectorError.Sprintf("%ss MUST define `spec.selector`", selector.GetObjectKind().GroupVersionKind().Kind).BuildWithResources(selector)
 }
 
+// UnknownNamespaceSelectorModeError reports that a NamespaceSelector has an
+// unknown `spec.mode`.
+func UnknownNamespaceSelectorModeError(selector client.Object, mode string) status.Error {
+	return invalidSelectorError.Sprintf("NamespaceSelector `spec.mode` MUST be %q or %q, got %q",
+		v1.NSSelectorStaticMode, v1.NSSelectorDynamicMode, mode).BuildWithResources(selector)
+}
+
+// UnsupportedDynamicNamespaceSelectorError reports that a NamespaceSelector is
+// in dynamic mode in a repo which does not support it.
+func UnsupportedDynamicNamespaceSelectorError(selector client.Object) status.Error {
+	return invalidSelectorError.Sprintf("NamespaceSelectors in %q mode are only supported in unstructured repos synced by a RootSync. "+
+		"To fix, remove `spec.mode` from:", v1.NSSelectorDynamicMode).BuildWithResources(selector)
+}
+
 // ClusterSelectorAnnotationConflictErrorCode is the error code for ClusterSelectorAnnotationConflictError
 const ClusterSelectorAnnotationConflictErrorCode = "1066"
 
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"io/ioutil"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/pkg/errors"
+)
+
+// ReadCommit returns the raw content of the commit object with the given hash
+// from the repository of worktree, which is either the checkout of a regular
+// repository or a worktree created by git-sync. The objects are read with
+// go-git, as the reconciler image does not have a git binary.
+func ReadCommit(worktree, commit string) ([]byte, error) {
+	if !plumbing.IsHash(commit) {
+		return nil, errors.Errorf("invalid commit hash %q", commit)
+	}
+	// The objects of a worktree are stored in the common git directory.
+	repo, err := gogit.PlainOpenWithOptions(worktree, &gogit.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the git repository of %s", worktree)
+	}
+	hash := plumbing.NewHash(commit)
+	obj, err := repo.Storer.EncodedObject(plumbing.CommitObject, hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read commit %s", commit)
+	}
+	r, err := obj.Reader()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read commit %s", commit)
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read commit %s", commit)
+	}
+	// The content must match the hash, otherwise a signed commit could be
+	// swapped for another one in the objects directory.
+	if plumbing.ComputeHash(plumbing.CommitObject, content) != hash {
+		return nil, errors.Errorf("the content of commit %s does not match its hash", commit)
+	}
+	return content, nil
+}
@@ -0,0 +1,421 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	pgpKeyBlockHeader  = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	pgpSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureFooter = "-----END SSH SIGNATURE-----"
+
+	// sshSignatureNamespace is the namespace of the SSH signatures of commits.
+	sshSignatureNamespace = "git"
+)
+
+// VerificationError is returned when a commit is not signed by a trusted key.
+type VerificationError struct {
+	// Commit is the hash of the rejected commit.
+	Commit string
+	// Reason explains why the commit was rejected.
+	Reason string
+}
+
+// Error implements error.
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("commit %s is not signed by a trusted key: %s", e.Commit, e.Reason)
+}
+
+// allowedSigner is an entry of an allowed_signers file.
+type allowedSigner struct {
+	principals string
+	namespaces []string
+	// validAfter and validBefore bound the commit times the key may sign, and
+	// are zero when unset.
+	validAfter  time.Time
+	validBefore time.Time
+	key         ssh.PublicKey
+}
+
+// Verifier checks that a git commit is signed by one of a set of trusted
+// OpenPGP or SSH keys before it is synced.
+type Verifier struct {
+	pgpKeys    openpgp.EntityList
+	sshSigners []allowedSigner
+}
+
+// NewVerifier returns a Verifier trusting the keys stored in the files of dir,
+// which is usually a mounted Secret. Every file holds either ASCII-armored
+// OpenPGP public keys, or SSH public keys in the allowed_signers format of
+// `ssh-keygen`. Files whose name starts with a dot are skipped.
+func NewVerifier(dir string) (*Verifier, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the trusted keys directory %q", dir)
+	}
+	v := &Verifier{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the trusted keys file %q", path)
+		}
+		if bytes.Contains(data, []byte(pgpKeyBlockHeader)) {
+			keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the OpenPGP keys file %q", path)
+			}
+			v.pgpKeys = append(v.pgpKeys, keys...)
+			continue
+		}
+		signers, err := parseAllowedSigners(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the allowed signers file %q", path)
+		}
+		v.sshSigners = append(v.sshSigners, signers...)
+	}
+	if len(v.pgpKeys) == 0 && len(v.sshSigners) == 0 {
+		return nil, errors.Errorf("no trusted key found in %q", dir)
+	}
+	return v, nil
+}
+
+// parseAllowedSigners parses the lines of an allowed_signers file, which are
+// `principals [options] keytype key [comment]`.
+func parseAllowedSigners(data []byte) ([]allowedSigner, error) {
+	var result []allowedSigner
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var principals, rest string
+		if strings.HasPrefix(text, `"`) {
+			end := strings.Index(text[1:], `"`)
+			if end < 0 {
+				return nil, errors.Errorf("line %d: unterminated quoted principals", line)
+			}
+			principals, rest = text[1:end+1], text[end+2:]
+		} else {
+			fields := strings.SplitN(text, " ", 2)
+			if len(fields) != 2 {
+				return nil, errors.Errorf("line %d: missing public key", line)
+			}
+			principals, rest = fields[0], fields[1]
+		}
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		signer := allowedSigner{principals: principals, key: key}
+		for _, option := range options {
+			name, value := option, ""
+			if i := strings.Index(option, "="); i >= 0 {
+				name, value = option[:i], strings.Trim(option[i+1:], `"`)
+			}
+			switch strings.ToLower(name) {
+			case "namespaces":
+				signer.namespaces = strings.Split(value, ",")
+			case "valid-after":
+				signer.validAfter, err = parseSignerTime(value)
+			case "valid-before":
+				signer.validBefore, err = parseSignerTime(value)
+			case "cert-authority":
+				// Commits signed with certificates are not supported, so a
+				// certificate authority can't be trusted either.
+				return nil, errors.Errorf("line %d: the cert-authority option is not supported", line)
+			default:
+				return nil, errors.Errorf("line %d: unsupported option %q", line, name)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+		}
+		result = append(result, signer)
+	}
+	return result, scanner.Err()
+}
+
+// signerTimeLayouts are the layouts of the valid-after and valid-before
+// options of allowed_signers, by length.
+var signerTimeLayouts = map[int]string{
+	8:  "20060102",
+	12: "200601021504",
+	14: "20060102150405",
+}
+
+// parseSignerTime parses a YYYYMMDD[HHMM[SS]] time of an allowed_signers file,
+// which is in UTC if it ends with Z and in the local time zone otherwise, like
+// ssh-keygen does.
+func parseSignerTime(value string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(value, "Z") || strings.HasSuffix(value, "z") {
+		loc, value = time.UTC, value[:len(value)-1]
+	}
+	layout, ok := signerTimeLayouts[len(value)]
+	if !ok {
+		return time.Time{}, errors.Errorf("invalid time %q", value)
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
+
+// Verify checks that the commit checked out in worktree is signed by one of
+// the trusted keys, and returns a description of the signer. It returns a
+// VerificationError if the commit is unsigned or not signed by a trusted key,
+// and other errors if the commit can't be read.
+func (v *Verifier) Verify(worktree, commit string) (string, error) {
+	content, err := ReadCommit(worktree, commit)
+	if err != nil {
+		return "", err
+	}
+	payload, signature := splitSignature(content)
+	verr := &VerificationError{Commit: commit}
+	switch {
+	case signature == "":
+		verr.Reason = "the commit is not signed"
+	case strings.HasPrefix(signature, pgpSignatureHeader):
+		signer, err := v.verifyPGP(payload, signature)
+		if err == nil {
+			return signer, nil
+		}
+		verr.Reason = err.Error()
+	case strings.HasPrefix(signature, sshSignatureHeader):
+		signer, err := v.verifySSH(payload, signature, commitTime(payload))
+		if err == nil {
+			return signer, nil
+		}
+		verr.Reason = err.Error()
+	default:
+		verr.Reason = "the commit is signed with an unsupported signature format"
+	}
+	return "", verr
+}
+
+// splitSignature returns the commit without its gpgsig header, which is the
+// signed payload, and the value of the gpgsig header.
+func splitSignature(content []byte) ([]byte, string) {
+	lines := strings.SplitAfter(string(content), "\n")
+	var payload strings.Builder
+	var signature []string
+	inHeaders, inSignature := true, false
+	for _, line := range lines {
+		switch {
+		case !inHeaders:
+			payload.WriteString(line)
+		case inSignature && strings.HasPrefix(line, " "):
+			// Continuation lines of a header start with a space.
+			signature = append(signature, strings.TrimPrefix(line, " "))
+		case strings.HasPrefix(line, "gpgsig "):
+			inSignature = true
+			signature = append(signature, strings.TrimPrefix(line, "gpgsig "))
+		default:
+			inSignature = false
+			inHeaders = line != "\n"
+			payload.WriteString(line)
+		}
+	}
+	return []byte(payload.String()), strings.Join(signature, "")
+}
+
+// commitTime returns the committer time of a commit, or the zero time if the
+// commit has no valid committer header.
+func commitTime(payload []byte) time.Time {
+	for _, line := range strings.Split(string(payload), "\n") {
+		if line == "" {
+			break
+		}
+		if !strings.HasPrefix(line, "committer ") {
+			continue
+		}
+		// The committer header ends with "<email> <seconds> <zone>".
+		fields := strings.Fields(line[strings.LastIndex(line, ">")+1:])
+		if len(fields) != 2 {
+			return time.Time{}
+		}
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(seconds, 0)
+	}
+	return time.Time{}
+}
+
+// verifyPGP checks an ASCII-armored detached OpenPGP signature.
+func (v *Verifier) verifyPGP(payload []byte, signature string) (string, error) {
+	if len(v.pgpKeys) == 0 {
+		return "", errors.New("the commit has an OpenPGP signature, but no OpenPGP key is trusted")
+	}
+	entity, err := openpgp.CheckArmoredDetachedSignature(v.pgpKeys, bytes.NewReader(payload), strings.NewReader(signature), nil)
+	if err != nil {
+		if err == pgperrors.ErrUnknownIssuer {
+			return "", errors.New("the OpenPGP signature is not made by a trusted key")
+		}
+		return "", errors.Wrap(err, "invalid OpenPGP signature")
+	}
+	var identity string
+	if id := entity.PrimaryIdentity(); id != nil {
+		identity = id.Name
+	}
+	return fmt.Sprintf("%s (OpenPGP key %s)", identity, entity.PrimaryKey.KeyIdString()), nil
+}
+
+// sshSignature is an SSHSIG signature blob following its magic preamble, see
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig.
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data signed by an SSHSIG signature, following the
+// magic preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+const sshSignatureMagic = "SSHSIG"
+
+// verifySSH checks an armored SSHSIG signature of a commit made at
+// committed.
+func (v *Verifier) verifySSH(payload []byte, signature string, committed time.Time) (string, error) {
+	if len(v.sshSigners) == 0 {
+		return "", errors.New("the commit has an SSH signature, but no SSH key is trusted")
+	}
+	body := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(signature, sshSignatureHeader)), sshSignatureFooter))
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid SSH signature encoding")
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return "", errors.New("invalid SSH signature preamble")
+	}
+	sig := &sshSignature{}
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], sig); err != nil {
+		return "", errors.Wrap(err, "invalid SSH signature")
+	}
+	if sig.Version != 1 {
+		return "", errors.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return "", errors.Errorf("the SSH signature is for namespace %q, not %q", sig.Namespace, sshSignatureNamespace)
+	}
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", errors.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(&sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+
+	key, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid SSH signature public key")
+	}
+	sshSig := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, sshSig); err != nil {
+		return "", errors.Wrap(err, "invalid SSH signature")
+	}
+	if err := key.Verify(signed, sshSig); err != nil {
+		return "", errors.Wrap(err, "invalid SSH signature")
+	}
+
+	expired := false
+	for _, signer := range v.sshSigners {
+		if !bytes.Equal(signer.key.Marshal(), key.Marshal()) || !signer.allows(sig.Namespace) {
+			continue
+		}
+		if !signer.validAt(committed) {
+			expired = true
+			continue
+		}
+		return fmt.Sprintf("%s (SSH key %s)", signer.principals, ssh.FingerprintSHA256(key)), nil
+	}
+	if expired {
+		return "", errors.Errorf("the SSH signature is made by key %s, which is not valid at the commit time", ssh.FingerprintSHA256(key))
+	}
+	return "", errors.Errorf("the SSH signature is made by key %s, which is not an allowed signer", ssh.FingerprintSHA256(key))
+}
+
+// allows returns true if the signer is allowed to sign in namespace.
+func (s allowedSigner) allows(namespace string) bool {
+	if len(s.namespaces) == 0 {
+		return true
+	}
+	for _, ns := range s.namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// validAt returns true if the signer is allowed to sign a commit made at
+// committed. Signers with a validity interval can't sign commits without a
+// committer time.
+func (s allowedSigner) validAt(committed time.Time) bool {
+	if s.validAfter.IsZero() && s.validBefore.IsZero() {
+		return true
+	}
+	if committed.IsZero() {
+		return false
+	}
+	if !s.validAfter.IsZero() && committed.Before(s.validAfter) {
+		return false
+	}
+	return s.validBefore.IsZero() || !committed.After(s.validBefore)
+}
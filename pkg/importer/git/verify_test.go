@@ -0,0 +1,438 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+)
+
+const unsignedCommit = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+	"author Jane Doe <jane@example.com> 1650000000 +0000\n" +
+	"committer Jane Doe <jane@example.com> 1650000000 +0000\n"
+
+// writeObject writes a loose object to the repository of worktree, which is
+// initialized with a HEAD if needed, and returns its hash.
+func writeObject(t *testing.T, worktree, objType string, content []byte) string {
+	t.Helper()
+	data := append([]byte(fmt.Sprintf("%s %d\x00", objType, len(content))), content...)
+	sum := sha1.Sum(data)
+	hash := hex.EncodeToString(sum[:])
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(worktree, ".git", "objects", hash[:2])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(worktree, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, hash[2:]), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// signedCommit returns a commit with message whose gpgsig header is computed
+// by sign from the payload.
+func signedCommit(message string, sign func(payload []byte) string) []byte {
+	payload := unsignedCommit + "\n" + message
+	if sign == nil {
+		return []byte(payload)
+	}
+	signature := strings.TrimSuffix(sign([]byte(payload)), "\n")
+	header := "gpgsig " + strings.ReplaceAll(signature, "\n", "\n ") + "\n"
+	return []byte(unsignedCommit + header + "\n" + message)
+}
+
+func newPGPEntity(t *testing.T, name string) (*openpgp.Entity, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", strings.ToLower(name)+"@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return entity, buf.String()
+}
+
+func pgpSigner(t *testing.T, entity *openpgp.Entity) func([]byte) string {
+	return func(payload []byte) string {
+		var buf bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&buf, entity, bytes.NewReader(payload), nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+}
+
+func newSSHKey(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func sshSigner(t *testing.T, key ssh.Signer, namespace string) func([]byte) string {
+	return func(payload []byte) string {
+		h := sha512.Sum512(payload)
+		signed := append([]byte(sshSignatureMagic), ssh.Marshal(&sshSignedData{
+			Namespace:     namespace,
+			HashAlgorithm: "sha512",
+			Hash:          h[:],
+		})...)
+		sig, err := key.Sign(rand.Reader, signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob := append([]byte(sshSignatureMagic), ssh.Marshal(&sshSignature{
+			Version:       1,
+			PublicKey:     key.PublicKey().Marshal(),
+			Namespace:     namespace,
+			HashAlgorithm: "sha512",
+			Signature:     ssh.Marshal(sig),
+		})...)
+		encoded := base64.StdEncoding.EncodeToString(blob)
+		var lines []string
+		for len(encoded) > 70 {
+			lines = append(lines, encoded[:70])
+			encoded = encoded[70:]
+		}
+		lines = append(lines, encoded)
+		return sshSignatureHeader + "\n" + strings.Join(lines, "\n") + "\n" + sshSignatureFooter + "\n"
+	}
+}
+
+func allowedSignersLine(principal, options string, key ssh.PublicKey) string {
+	line := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(key)), "\n")
+	if options != "" {
+		line = options + " " + line
+	}
+	return principal + " " + line + "\n"
+}
+
+func writeKeys(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	trustedPGP, trustedPGPKey := newPGPEntity(t, "Jane")
+	untrustedPGP, _ := newPGPEntity(t, "Mallory")
+	trustedSSH := newSSHKey(t)
+	untrustedSSH := newSSHKey(t)
+	otherNamespaceSSH := newSSHKey(t)
+	validSSH := newSSHKey(t)
+	expiredSSH := newSSHKey(t)
+	notYetValidSSH := newSSHKey(t)
+
+	keysDir := writeKeys(t, map[string]string{
+		"maintainers.asc": trustedPGPKey,
+		"allowed_signers": "# Maintainers\n" +
+			allowedSignersLine("jane@example.com", "", trustedSSH.PublicKey()) +
+			allowedSignersLine("bot@example.com", `namespaces="file"`, otherNamespaceSSH.PublicKey()) +
+			allowedSignersLine("ci@example.com", `valid-after="20220101Z",valid-before="20230101Z"`, validSSH.PublicKey()) +
+			allowedSignersLine("old@example.com", `valid-before="202001011200Z"`, expiredSSH.PublicKey()) +
+			allowedSignersLine("new@example.com", `valid-after="20990101000000Z"`, notYetValidSSH.PublicKey()),
+	})
+	v, err := NewVerifier(keysDir)
+	if err != nil {
+		t.Fatalf("NewVerifier() got unexpected error %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		content    []byte
+		wantSigner string
+		wantReason string
+	}{
+		{
+			name:       "trusted OpenPGP signature",
+			content:    signedCommit("Add bookstore\n", pgpSigner(t, trustedPGP)),
+			wantSigner: fmt.Sprintf("Jane <jane@example.com> (OpenPGP key %s)", trustedPGP.PrimaryKey.KeyIdString()),
+		},
+		{
+			name:       "trusted SSH signature",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, trustedSSH, "git")),
+			wantSigner: fmt.Sprintf("jane@example.com (SSH key %s)", ssh.FingerprintSHA256(trustedSSH.PublicKey())),
+		},
+		{
+			name:       "unsigned",
+			content:    signedCommit("Add bookstore\n", nil),
+			wantReason: "the commit is not signed",
+		},
+		{
+			name:       "untrusted OpenPGP signature",
+			content:    signedCommit("Add bookstore\n", pgpSigner(t, untrustedPGP)),
+			wantReason: "the OpenPGP signature is not made by a trusted key",
+		},
+		{
+			name:       "untrusted SSH signature",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, untrustedSSH, "git")),
+			wantReason: "which is not an allowed signer",
+		},
+		{
+			name:       "SSH signer not allowed in the git namespace",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, otherNamespaceSSH, "git")),
+			wantReason: "which is not an allowed signer",
+		},
+		{
+			name:       "SSH signer valid at the commit time",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, validSSH, "git")),
+			wantSigner: fmt.Sprintf("ci@example.com (SSH key %s)", ssh.FingerprintSHA256(validSSH.PublicKey())),
+		},
+		{
+			name:       "SSH signer expired before the commit time",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, expiredSSH, "git")),
+			wantReason: "which is not valid at the commit time",
+		},
+		{
+			name:       "SSH signer not yet valid at the commit time",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, notYetValidSSH, "git")),
+			wantReason: "which is not valid at the commit time",
+		},
+		{
+			name:       "SSH signature of another namespace",
+			content:    signedCommit("Add bookstore\n", sshSigner(t, trustedSSH, "file")),
+			wantReason: `the SSH signature is for namespace "file"`,
+		},
+		{
+			name: "tampered OpenPGP commit",
+			content: bytes.Replace(signedCommit("Add bookstore\n", pgpSigner(t, trustedPGP)),
+				[]byte("Add bookstore"), []byte("Delete bookstore"), 1),
+			wantReason: "invalid OpenPGP signature",
+		},
+		{
+			name: "tampered SSH commit",
+			content: bytes.Replace(signedCommit("Add bookstore\n", sshSigner(t, trustedSSH, "git")),
+				[]byte("Add bookstore"), []byte("Delete bookstore"), 1),
+			wantReason: "invalid SSH signature",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			worktree := t.TempDir()
+			commit := writeObject(t, worktree, "commit", tc.content)
+			signer, err := v.Verify(worktree, commit)
+			if tc.wantReason == "" {
+				if err != nil {
+					t.Fatalf("Verify() got unexpected error %v", err)
+				}
+				if signer != tc.wantSigner {
+					t.Errorf("Verify() got signer %q, want %q", signer, tc.wantSigner)
+				}
+				return
+			}
+			verr, ok := err.(*VerificationError)
+			if !ok {
+				t.Fatalf("Verify() got error %v, want a VerificationError", err)
+			}
+			if verr.Commit != commit || !strings.Contains(verr.Reason, tc.wantReason) {
+				t.Errorf("Verify() got error %v, want commit %s rejected with %q", err, commit, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifyMissingCommit(t *testing.T) {
+	key := newSSHKey(t)
+	v, err := NewVerifier(writeKeys(t, map[string]string{
+		"allowed_signers": allowedSignersLine("jane@example.com", "", key.PublicKey()),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree := t.TempDir()
+	writeObject(t, worktree, "blob", []byte("bookstore"))
+	_, err = v.Verify(worktree, strings.Repeat("ab", 20))
+	if err == nil {
+		t.Fatal("Verify() got no error for a missing commit")
+	}
+	if _, ok := err.(*VerificationError); ok {
+		t.Errorf("Verify() got VerificationError %v for a missing commit, want another error", err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	key := newSSHKey(t)
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "quoted principals and options",
+			files: map[string]string{"allowed_signers": allowedSignersLine(`"jane@example.com,bot@example.com"`, `namespaces="git,file"`, key.PublicKey())},
+		},
+		{
+			name: "hidden files are skipped",
+			files: map[string]string{
+				"allowed_signers": allowedSignersLine("jane@example.com", "", key.PublicKey()),
+				"..data":          "not a key",
+			},
+		},
+		{
+			name:    "no keys",
+			files:   map[string]string{"allowed_signers": "# nobody\n"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid allowed signers",
+			files:   map[string]string{"allowed_signers": "jane@example.com ssh-ed25519 invalid\n"},
+			wantErr: true,
+		},
+		{
+			name:  "validity interval",
+			files: map[string]string{"allowed_signers": allowedSignersLine("jane@example.com", `valid-after="20220101",valid-before="202301011230Z"`, key.PublicKey())},
+		},
+		{
+			name:    "invalid validity time",
+			files:   map[string]string{"allowed_signers": allowedSignersLine("jane@example.com", `valid-after="2022"`, key.PublicKey())},
+			wantErr: true,
+		},
+		{
+			name:    "cert-authority is rejected",
+			files:   map[string]string{"allowed_signers": allowedSignersLine("*@example.com", "cert-authority", key.PublicKey())},
+			wantErr: true,
+		},
+		{
+			name:    "unknown option is rejected",
+			files:   map[string]string{"allowed_signers": allowedSignersLine("jane@example.com", "no-touch-required", key.PublicKey())},
+			wantErr: true,
+		},
+		{
+			name:    "invalid OpenPGP keys",
+			files:   map[string]string{"maintainers.asc": pgpKeyBlockHeader + "\ninvalid\n-----END PGP PUBLIC KEY BLOCK-----\n"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewVerifier(writeKeys(t, tc.files))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("NewVerifier() got error %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifier_VerifyGitWorktree verifies a commit signed by git, packed, and
+// checked out in a linked worktree the way git-sync does.
+func TestVerifier_VerifyGitWorktree(t *testing.T) {
+	for _, bin := range []string{"git", "ssh-keygen"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found", bin)
+		}
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	keyFile := filepath.Join(dir, "key")
+	run("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "", "-f", keyFile)
+	publicKey, err := ioutil.ReadFile(keyFile + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(dir, "repo")
+	run("git", "init", "-q", repo)
+	git := func(args ...string) string {
+		return run(append([]string{"git", "-C", repo, "-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com",
+			"-c", "gpg.format=ssh", "-c", "user.signingkey=" + keyFile}, args...)...)
+	}
+	for i := 0; i < 3; i++ {
+		if err := ioutil.WriteFile(filepath.Join(repo, "cm.yaml"), []byte(fmt.Sprintf("data: %d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "cm.yaml")
+		git("commit", "-q", "-S", "-m", fmt.Sprintf("Commit %d", i))
+	}
+	git("gc", "-q", "--aggressive")
+	commit := git("rev-parse", "HEAD")
+	worktree := filepath.Join(dir, "worktree")
+	git("worktree", "add", "-q", "--detach", worktree, commit)
+	unsigned := filepath.Join(dir, "unsigned")
+	git("commit", "-q", "--allow-empty", "-m", "Unsigned")
+	git("worktree", "add", "-q", "--detach", unsigned, "HEAD")
+
+	v, err := NewVerifier(writeKeys(t, map[string]string{"allowed_signers": "jane@example.com " + string(publicKey)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := v.Verify(worktree, commit)
+	if err != nil {
+		t.Fatalf("Verify() got unexpected error %v", err)
+	}
+	if !strings.HasPrefix(signer, "jane@example.com (SSH key SHA256:") {
+		t.Errorf("Verify() got signer %q", signer)
+	}
+	if _, err := v.Verify(unsigned, git("rev-parse", "HEAD")); err == nil {
+		t.Error("Verify() got no error for an unsigned commit")
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 Google LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kmetrics
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// RunKustomizeBuild renders the kustomization in inputDir in process with the
+// kustomize API, reading the configs from fSys.
+//
+// The argument sendMetrics determines whether to send metrics about kustomize
+// to Google Cloud.
+//
+// By default, we would like to enable the `buildMetadata.originAnnotations`.
+// If the kustomization file does not include it already, it is added to the
+// copy of the file that kustomize reads, so the user's file is never rewritten.
+func RunKustomizeBuild(ctx context.Context, sendMetrics bool, fSys filesys.FileSystem, inputDir string, opts *krusty.Options) (resmap.ResMap, error) {
+	now := time.Now()
+	m, err := krusty.MakeKustomizer(opts).Run(withOriginAnnotations(fSys, inputDir), inputDir)
+	executionTime := time.Since(now).Nanoseconds()
+	if err != nil {
+		return nil, err
+	}
+
+	if sendMetrics {
+		// Send execution time and resource count metrics to OC collector
+		RecordKustomizeResourceCount(ctx, m.Size())
+		RecordKustomizeExecutionTime(ctx, float64(executionTime))
+
+		kt, err := readKustomizeFile(inputDir)
+		if kt != nil && err == nil {
+			fieldMetrics, fieldErr := kustomizeFieldUsage(kt, inputDir)
+			if fieldErr == nil && fieldMetrics != nil {
+				// Send field count metrics to OC collector
+				RecordKustomizeFieldCountData(ctx, fieldMetrics)
+			}
+		}
+	}
+	return m, nil
+}
+
+// originFS is a filesys.FileSystem which adds the originAnnotations build
+// option to the root kustomization file when it is read.
+type originFS struct {
+	filesys.FileSystem
+	// root is the cleaned absolute path of the kustomization root.
+	root string
+}
+
+// withOriginAnnotations wraps fSys so that the kustomization file in inputDir
+// enables the originAnnotations build option.
+func withOriginAnnotations(fSys filesys.FileSystem, inputDir string) filesys.FileSystem {
+	root, _, err := fSys.CleanedAbs(inputDir)
+	if err != nil {
+		// Let kustomize report the error.
+		return fSys
+	}
+	return &originFS{FileSystem: fSys, root: root.String()}
+}
+
+// ReadFile implements filesys.FileSystem.
+func (f *originFS) ReadFile(path string) ([]byte, error) {
+	b, err := f.FileSystem.ReadFile(path)
+	if err != nil || filepath.Dir(path) != f.root || !isKustomizationFile(filepath.Base(path)) {
+		return b, err
+	}
+	return addOriginAnnotations(b), nil
+}
+
+func isKustomizationFile(name string) bool {
+	for _, f := range konfig.RecognizedKustomizationFileNames() {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// addOriginAnnotations returns the kustomization file contents with the
+// originAnnotations build option. The contents are returned unchanged if they
+// are malformed, since kustomize reports a better error than we can here.
+func addOriginAnnotations(b []byte) []byte {
+	kt, err := yaml.Parse(string(b))
+	if err != nil || kt.YNode().Kind != yaml.MappingNode {
+		return b
+	}
+	opts, err := kt.Pipe(yaml.LookupCreate(yaml.SequenceNode, "buildMetadata"))
+	if err != nil || opts == nil || opts.YNode().Kind != yaml.SequenceNode {
+		return b
+	}
+	for _, opt := range opts.Content() {
+		if opt.Value == types.OriginAnnotations {
+			return b
+		}
+	}
+	if err := opts.PipeE(yaml.Append(yaml.NewScalarRNode(types.OriginAnnotations).YNode())); err != nil {
+		return b
+	}
+	s, err := kt.String()
+	if err != nil {
+		return b
+	}
+	return []byte(s)
+}
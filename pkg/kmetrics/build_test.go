@@ -21,12 +21,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
 func TestRunKustomizeBuild(t *testing.T) {
 	testCases := map[string]struct {
 		inputDir    string
-		flags       []string
 		expected    string
 		expectedErr string
 	}{
@@ -49,7 +50,7 @@ spec:
 		},
 		"missing kustomization": {
 			inputDir:    "./testdata/missingkustomization",
-			expectedErr: "Error: unable to find one of 'kustomization.yaml', 'kustomization.yml' or 'Kustomization' in directory",
+			expectedErr: "unable to find one of 'kustomization.yaml', 'kustomization.yml' or 'Kustomization' in directory",
 		},
 		"complex": {
 			inputDir:    "./testdata/complex",
@@ -61,11 +62,11 @@ spec:
 		},
 		"invalid kustomization": {
 			inputDir:    "./testdata/invalidkustomization",
-			expectedErr: "Error: json: cannot unmarshal string into Go struct field Kustomization.resources of type []string",
+			expectedErr: "json: cannot unmarshal string into Go struct field Kustomization.resources of type []string",
 		},
 		"multiple kustomization files": {
 			inputDir:    "./testdata/multiplekustomizationfiles",
-			expectedErr: "Error: Found multiple kustomization files",
+			expectedErr: "Found multiple kustomization files",
 		},
 		"with generator": {
 			inputDir: "./testdata/withgenerator",
@@ -127,12 +128,18 @@ spec:
 
 	for tn, tc := range testCases {
 		t.Run(tn, func(t *testing.T) {
-			out, err := RunKustomizeBuild(context.Background(), false, tc.inputDir, tc.flags...)
+			opts := krusty.MakeDefaultOptions()
+			opts.DoLegacyResourceSort = true
+			m, err := RunKustomizeBuild(context.Background(), false, filesys.MakeFsOnDisk(), tc.inputDir, opts)
 			if tc.expectedErr == "" {
 				if !assert.NoError(t, err) {
 					t.FailNow()
 				}
-				if !assert.Equal(t, tc.expected, out) {
+				out, err := m.AsYaml()
+				if !assert.NoError(t, err) {
+					t.FailNow()
+				}
+				if !assert.Equal(t, tc.expected, string(out)) {
 					t.FailNow()
 				}
 			} else {
@@ -146,3 +153,55 @@ spec:
 		})
 	}
 }
+
+func TestAddOriginAnnotations(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"no buildMetadata": {
+			input: "resources:\n- deployment.yaml\n",
+			expected: `resources:
+- deployment.yaml
+buildMetadata:
+- originAnnotations
+`,
+		},
+		"other buildMetadata": {
+			input: "buildMetadata: [managedByLabel]\n",
+			expected: `buildMetadata: [managedByLabel, originAnnotations]
+`,
+		},
+		"originAnnotations already set": {
+			input:    "buildMetadata:\n- originAnnotations\n",
+			expected: "buildMetadata:\n- originAnnotations\n",
+		},
+		"malformed": {
+			input:    "resources: deployment.yaml\nbuildMetadata: originAnnotations\n",
+			expected: "resources: deployment.yaml\nbuildMetadata: originAnnotations\n",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(addOriginAnnotations([]byte(tc.input))))
+		})
+	}
+}
+
+func TestRunKustomizeBuild_KeepsKustomizationFile(t *testing.T) {
+	fSys := filesys.MakeFsInMemory()
+	kustomization := "resources:\n- configmap.yaml\n"
+	assert.NoError(t, fSys.WriteFile("/app/kustomization.yaml", []byte(kustomization)))
+	assert.NoError(t, fSys.WriteFile("/app/configmap.yaml", []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n")))
+
+	m, err := RunKustomizeBuild(context.Background(), false, fSys, "/app", krusty.MakeDefaultOptions())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "path: configmap.yaml\n", m.Resources()[0].GetAnnotations()["config.kubernetes.io/origin"])
+
+	b, err := fSys.ReadFile("/app/kustomization.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, kustomization, string(b))
+}
@@ -88,6 +88,11 @@ const (
 	// This annotation is set by Config Sync on a root-reconciler, namespace-reconciler, or otel-collector pod.
 	ConfigMapAnnotationKey = configsync.ConfigSyncPrefix + "configmap"
 
+	// PodTemplateOverrideAnnotationKey is the annotation key representing the
+	// hash of the spec.override.podTemplate of a RootSync or RepoSync.
+	// This annotation is set by Config Sync on a root-reconciler or namespace-reconciler pod.
+	PodTemplateOverrideAnnotationKey = configsync.ConfigSyncPrefix + "pod-template-override"
+
 	// DeclaredFieldsKey is the annotation key that stores the declared configuration of
 	// a resource in Git. This uses the same format as the managed fields of server-side apply.
 	// This annotation is set by Config Sync on a managed resource.
@@ -132,6 +137,33 @@ const (
 	// UnknownScopeAnnotationValue is the value for UnknownScopeAnnotationKey
 	// to indicate that the scope of a resource is unknown.
 	UnknownScopeAnnotationValue = "true"
+
+	// ApproveDeletionsAnnotationKey is the annotation which approves the
+	// deletions of a commit exceeding the deletion budgets of a RootSync or
+	// RepoSync. Its value is the approved commit.
+	// This annotation is set by Config Sync users on a RootSync or RepoSync.
+	ApproveDeletionsAnnotationKey = configsync.ConfigSyncPrefix + "approve-deletions"
+
+	// BypassSyncWindowAnnotationKey is the annotation which allows a commit to
+	// be applied outside of the sync windows of a RootSync or RepoSync. Its
+	// value is the commit.
+	// This annotation is set by Config Sync users on a RootSync or RepoSync.
+	BypassSyncWindowAnnotationKey = configsync.ConfigSyncPrefix + "bypass-sync-window"
+
+	// BreakGlassExemptionAnnotationKey is the annotation which records the last
+	// change to a managed object admitted by a break-glass policy.
+	// This annotation is set by the Config Sync admission webhook on a managed resource.
+	BreakGlassExemptionAnnotationKey = configsync.ConfigSyncPrefix + "break-glass-exemption"
+
+	// ClusterVariablesAnnotationKey is the annotation which enables the
+	// substitution of the per-cluster variables in the string values of a
+	// resource.
+	// This annotation is set by Config Sync users on a managed resource.
+	ClusterVariablesAnnotationKey = configsync.ConfigSyncPrefix + "cluster-variables"
+
+	// ClusterVariablesEnabled is the value for ClusterVariablesAnnotationKey
+	// to enable the substitution of the per-cluster variables.
+	ClusterVariablesEnabled = "enabled"
 )
 
 // Lifecycle annotations
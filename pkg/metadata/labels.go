@@ -50,6 +50,10 @@ const (
 	// This is used to enable selecting pods by label, primarily for printing logs.
 	// Example: kubectl logs deployment/<deploy-name> <container-name> -n config-management-system
 	DeploymentNameLabel = configsync.ConfigSyncPrefix + "deployment-name"
+
+	// InventoryShardOfLabel indicates the name of the inventory ResourceGroup
+	// whose objects are partially stored in a ResourceGroup shard.
+	InventoryShardOfLabel = configsync.ConfigSyncPrefix + "inventory-shard-of"
 )
 
 // DepthSuffix is a label suffix for hierarchical namespace depth.
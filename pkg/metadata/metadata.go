@@ -56,6 +56,7 @@ var sourceAnnotations = map[string]bool{
 	ClusterNameSelectorAnnotationKey:   true,
 	ResourceManagementKey:              true,
 	LifecycleMutationAnnotation:        true,
+	ClusterVariablesAnnotationKey:      true,
 }
 
 // IsSourceAnnotation returns true if the annotation is a ConfigSync source
@@ -59,6 +59,12 @@ var (
 		"The number of declared resources parsed from Git",
 		stats.UnitDimensionless)
 
+	// DriftedResources metric measures the number of managed resources which have drifted from their declarations.
+	DriftedResources = stats.Int64(
+		"drifted_resources",
+		"The number of managed resources which have drifted from their declarations",
+		stats.UnitDimensionless)
+
 	// ApplyOperations metric measures the number of applier apply events.
 	ApplyOperations = stats.Int64(
 		"apply_operations",
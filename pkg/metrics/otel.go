@@ -67,6 +67,7 @@ processors:
           - reconciler_errors
           - pipeline_error_observed
           - declared_resources
+          - drifted_resources
           - apply_operations_total
           - resource_fights_total
           - internal_errors_total
@@ -88,6 +89,7 @@ processors:
           - reconcile_duration_seconds
           - parser_duration_seconds
           - declared_resources
+          - drifted_resources
           - apply_operations_total
           - apply_duration_seconds
           - resource_fights_total
@@ -109,6 +111,9 @@ processors:
       - include: declared_resources
         action: update
         new_name: current_declared_resources
+      - include: drifted_resources
+        action: update
+        new_name: current_drifted_resources
       - include: reconciler_errors
         action: update
         new_name: last_reconciler_errors
@@ -76,9 +76,10 @@ func RecordParserDuration(ctx context.Context, trigger, source, status string, s
 }
 
 // RecordLastSync produces a measurement for the LastSync view.
-func RecordLastSync(ctx context.Context, commit string, timestamp time.Time) {
+func RecordLastSync(ctx context.Context, trigger, commit string, timestamp time.Time) {
+	tagCtx, _ := tag.New(ctx, tag.Upsert(KeyTrigger, trigger))
 	measurement := LastSync.M(timestamp.Unix())
-	stats.Record(ctx, measurement)
+	stats.Record(tagCtx, measurement)
 }
 
 // RecordDeclaredResources produces a measurement for the DeclaredResources view.
@@ -87,6 +88,12 @@ func RecordDeclaredResources(ctx context.Context, numResources int) {
 	stats.Record(ctx, measurement)
 }
 
+// RecordDriftedResources produces a measurement for the DriftedResources view.
+func RecordDriftedResources(ctx context.Context, numResources int) {
+	measurement := DriftedResources.M(int64(numResources))
+	stats.Record(ctx, measurement)
+}
+
 // RecordApplyOperation produces a measurement for the ApplyOperations view.
 func RecordApplyOperation(ctx context.Context, operation, status string, gvk schema.GroupVersionKind) {
 	tagCtx, _ := tag.New(ctx,
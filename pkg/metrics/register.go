@@ -60,6 +60,7 @@ func RegisterReconcilerMetricsViews() error {
 		LastApplyTimestampView,
 		LastSyncTimestampView,
 		DeclaredResourcesView,
+		DriftedResourcesView,
 		ApplyOperationsView,
 		ApplyDurationView,
 		ResourceFightsView,
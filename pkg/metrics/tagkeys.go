@@ -47,7 +47,7 @@ var (
 	// KeyParserSource groups the metrics for the parser by their source. Possible values: read, parse, update.
 	KeyParserSource, _ = tag.NewKey("source")
 
-	// KeyTrigger groups metrics by their trigger. Possible values: retry, watchUpdate, managementConflict, resync, reimport.
+	// KeyTrigger groups metrics by their trigger. Possible values: retry, watchUpdate, managementConflict, resync, reimport, push, namespaceUpdate, syncWindow.
 	KeyTrigger, _ = tag.NewKey("trigger")
 
 	// KeyCommit groups metrics by their git commit. Even though this tag has a high cardinality,
@@ -72,6 +72,7 @@ var (
 		Name:        LastSync.Name(),
 		Measure:     LastSync,
 		Description: "The timestamp of the most recent sync from Git",
+		TagKeys:     []tag.Key{KeyTrigger},
 		Aggregation: view.LastValue(),
 	}
 
@@ -83,6 +84,14 @@ var (
 		Aggregation: view.LastValue(),
 	}
 
+	// DriftedResourcesView aggregates the DriftedResources metric measurements.
+	DriftedResourcesView = &view.View{
+		Name:        DriftedResources.Name(),
+		Measure:     DriftedResources,
+		Description: "The current number of managed resources which have drifted from their declarations",
+		Aggregation: view.LastValue(),
+	}
+
 	// ApplyOperationsView aggregates the ApplyOps metric measurements.
 	ApplyOperationsView = &view.View{
 		Name:        ApplyOperations.Name() + "_total",
@@ -0,0 +1,239 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package namespaceselector tracks the Namespaces on the cluster for the
+// NamespaceSelectors in dynamic mode.
+package namespaceselector
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+// listTimeout bounds how long the first parse which needs the Namespaces waits
+// for them to be listed.
+const listTimeout = 30 * time.Second
+
+// Watcher keeps the labels of the Namespaces on the cluster up to date from a
+// watch. It flags a resync whenever a Namespace is created, relabelled or
+// deleted in a way which changes which Namespaces the NamespaceSelectors in
+// dynamic mode selected in the last parse.
+//
+// The watch only starts the first time the labels of the Namespaces are
+// needed, so that reconcilers without NamespaceSelectors in dynamic mode do not
+// watch the Namespaces.
+//
+// Namespaces which are terminating are left out, since no object can be
+// created in them.
+type Watcher struct {
+	mux        sync.Mutex
+	namespaces map[string]labels.Set
+	selection  []objects.NamespaceSelection
+	changed    bool
+
+	ctx         context.Context
+	lw          cache.ListerWatcher
+	listTimeout time.Duration
+
+	startMux sync.Mutex
+	informer cache.Controller
+	listErr  error
+}
+
+var _ objects.LiveNamespaces = &Watcher{}
+
+// NewListWatch returns a ListerWatcher of the Namespaces on the cluster, which
+// uses the metadata client.
+func NewListWatch(ctx context.Context, client metadata.Interface) cache.ListerWatcher {
+	resource := client.Resource(kinds.Namespace().GroupVersion().WithResource("namespaces"))
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return resource.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return resource.Watch(ctx, options)
+		},
+	}
+}
+
+// NewWatcher returns a Watcher which watches the Namespaces with lw until the
+// context is done, once it is first asked for their labels.
+func NewWatcher(ctx context.Context, lw cache.ListerWatcher) *Watcher {
+	return &Watcher{
+		namespaces:  make(map[string]labels.Set),
+		ctx:         ctx,
+		lw:          lw,
+		listTimeout: listTimeout,
+	}
+}
+
+// start starts watching the Namespaces unless it already has, and waits until
+// they have been listed.
+func (w *Watcher) start() error {
+	w.startMux.Lock()
+	defer w.startMux.Unlock()
+	if w.informer == nil {
+		klog.Infof("Watching the Namespaces for the NamespaceSelectors in dynamic mode")
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list, err := w.lw.List(options)
+				w.setListErr(err)
+				return list, err
+			},
+			WatchFunc: w.lw.Watch,
+		}
+		_, w.informer = cache.NewInformer(lw, &metav1.PartialObjectMetadata{}, 0, w)
+		go w.informer.Run(w.ctx.Done())
+	}
+	ctx, cancel := context.WithTimeout(w.ctx, w.listTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		if err := w.getListErr(); err != nil {
+			return err
+		}
+		return errors.New("timed out listing the Namespaces on the cluster")
+	}
+	return nil
+}
+
+func (w *Watcher) setListErr(err error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.listErr = err
+}
+
+func (w *Watcher) getListErr() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.listErr
+}
+
+// Labels implements objects.LiveNamespaces.
+func (w *Watcher) Labels() (map[string]labels.Set, error) {
+	if err := w.start(); err != nil {
+		return nil, err
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	result := make(map[string]labels.Set, len(w.namespaces))
+	for name, l := range w.namespaces {
+		result[name] = l
+	}
+	return result, nil
+}
+
+// SetSelection implements objects.LiveNamespaces.
+func (w *Watcher) SetSelection(selection []objects.NamespaceSelection) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.selection = selection
+}
+
+// Selection returns the Namespaces selected by each NamespaceSelector in
+// dynamic mode in the last parse.
+func (w *Watcher) Selection() []objects.NamespaceSelection {
+	if w == nil {
+		return nil
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.selection
+}
+
+// Changed returns whether a Namespace event changed the selection since the
+// last call, and resets it.
+func (w *Watcher) Changed() bool {
+	if w == nil {
+		return false
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	changed := w.changed
+	w.changed = false
+	return changed
+}
+
+// OnAdd implements cache.ResourceEventHandler.
+func (w *Watcher) OnAdd(obj interface{}) {
+	w.OnUpdate(nil, obj)
+}
+
+// OnUpdate implements cache.ResourceEventHandler.
+func (w *Watcher) OnUpdate(_, newObj interface{}) {
+	ns, ok := newObj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		klog.Warningf("Ignoring unexpected Namespace event object %T", newObj)
+		return
+	}
+	if ns.GetDeletionTimestamp() != nil {
+		w.remove(ns.GetName())
+		return
+	}
+	w.set(ns.GetName(), labels.Set(ns.GetLabels()))
+}
+
+// OnDelete implements cache.ResourceEventHandler.
+func (w *Watcher) OnDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		klog.Warningf("Ignoring unexpected Namespace event object %T", obj)
+		return
+	}
+	w.remove(ns.GetName())
+}
+
+func (w *Watcher) set(name string, l labels.Set) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.namespaces[name] = l
+	w.checkSelection(name, l, true)
+}
+
+func (w *Watcher) remove(name string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	delete(w.namespaces, name)
+	w.checkSelection(name, nil, false)
+}
+
+// checkSelection flags a resync if the Namespace with the given labels, or the
+// Namespace which no longer exists, is not selected the way it was in the last
+// parse.
+func (w *Watcher) checkSelection(name string, l labels.Set, exists bool) {
+	for _, s := range w.selection {
+		matches := exists && s.Selector.Matches(l)
+		i := sort.SearchStrings(s.Namespaces, name)
+		selected := i < len(s.Namespaces) && s.Namespaces[i] == name
+		if matches != selected {
+			klog.Infof("Namespace %s changed the selection of NamespaceSelector %s", name, s.Name)
+			w.changed = true
+		}
+	}
+}
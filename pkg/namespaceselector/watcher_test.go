@@ -0,0 +1,205 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespaceselector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"kpt.dev/configsync/pkg/validate/objects"
+)
+
+func namespace(name string, l map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: l},
+	}
+}
+
+func terminating(ns *metav1.PartialObjectMetadata) *metav1.PartialObjectMetadata {
+	now := metav1.Now()
+	ns.DeletionTimestamp = &now
+	return ns
+}
+
+// fakeListWatch lists the given Namespaces, or fails with the given error, and
+// counts the calls to List.
+type fakeListWatch struct {
+	namespaces []metav1.PartialObjectMetadata
+	err        error
+	lists      chan struct{}
+}
+
+func newFakeListWatch(err error, namespaces ...*metav1.PartialObjectMetadata) *fakeListWatch {
+	lw := &fakeListWatch{err: err, lists: make(chan struct{}, 100)}
+	for _, ns := range namespaces {
+		lw.namespaces = append(lw.namespaces, *ns)
+	}
+	return lw
+}
+
+func (lw *fakeListWatch) List(metav1.ListOptions) (runtime.Object, error) {
+	lw.lists <- struct{}{}
+	if lw.err != nil {
+		return nil, lw.err
+	}
+	return &metav1.PartialObjectMetadataList{
+		ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+		Items:    lw.namespaces,
+	}, nil
+}
+
+func (lw *fakeListWatch) Watch(metav1.ListOptions) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+func TestWatcher_Labels(t *testing.T) {
+	dev := map[string]string{"environment": "dev"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lw := newFakeListWatch(nil, namespace("dev1", dev), terminating(namespace("dev2", dev)))
+	w := NewWatcher(ctx, lw)
+	if len(lw.lists) != 0 {
+		t.Fatal("the Namespaces were listed before their labels were needed")
+	}
+	got, err := w.Labels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]labels.Set{"dev1": dev}, got); diff != "" {
+		t.Error(diff)
+	}
+	// The watch is only started once.
+	if _, err := w.Labels(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(lw.lists); n != 1 {
+		t.Errorf("got %d List calls, want 1", n)
+	}
+}
+
+func TestWatcher_LabelsError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", nil)
+	w := NewWatcher(ctx, newFakeListWatch(forbidden))
+	w.listTimeout = 500 * time.Millisecond
+	if _, err := w.Labels(); !apierrors.IsForbidden(err) {
+		t.Errorf("got Labels() error %v, want the Forbidden error of the List call", err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dev := map[string]string{"environment": "dev"}
+	prod := map[string]string{"environment": "prod"}
+
+	testCases := []struct {
+		name        string
+		event       func(w *Watcher)
+		wantChanged bool
+		wantLabels  map[string]labels.Set
+	}{
+		{
+			name:        "add a matching Namespace",
+			event:       func(w *Watcher) { w.OnAdd(namespace("dev2", dev)) },
+			wantChanged: true,
+			wantLabels:  map[string]labels.Set{"dev1": dev, "prod1": prod, "dev2": dev},
+		},
+		{
+			name:       "add a Namespace which does not match",
+			event:      func(w *Watcher) { w.OnAdd(namespace("prod2", prod)) },
+			wantLabels: map[string]labels.Set{"dev1": dev, "prod1": prod, "prod2": prod},
+		},
+		{
+			name:        "relabel a Namespace to match",
+			event:       func(w *Watcher) { w.OnUpdate(namespace("prod1", prod), namespace("prod1", dev)) },
+			wantChanged: true,
+			wantLabels:  map[string]labels.Set{"dev1": dev, "prod1": dev},
+		},
+		{
+			name:        "relabel a Namespace to stop matching",
+			event:       func(w *Watcher) { w.OnUpdate(namespace("dev1", dev), namespace("dev1", nil)) },
+			wantChanged: true,
+			wantLabels:  map[string]labels.Set{"dev1": nil, "prod1": prod},
+		},
+		{
+			name: "update a Namespace without changing the selection",
+			event: func(w *Watcher) {
+				w.OnUpdate(namespace("dev1", dev), namespace("dev1", map[string]string{"environment": "dev", "team": "a"}))
+			},
+			wantLabels: map[string]labels.Set{"dev1": {"environment": "dev", "team": "a"}, "prod1": prod},
+		},
+		{
+			name:        "terminate a selected Namespace",
+			event:       func(w *Watcher) { w.OnUpdate(namespace("dev1", dev), terminating(namespace("dev1", dev))) },
+			wantChanged: true,
+			wantLabels:  map[string]labels.Set{"prod1": prod},
+		},
+		{
+			name:        "delete a selected Namespace",
+			event:       func(w *Watcher) { w.OnDelete(cache.DeletedFinalStateUnknown{Obj: namespace("dev1", dev)}) },
+			wantChanged: true,
+			wantLabels:  map[string]labels.Set{"prod1": prod},
+		},
+		{
+			name:       "delete a Namespace which was not selected",
+			event:      func(w *Watcher) { w.OnDelete(namespace("prod1", prod)) },
+			wantLabels: map[string]labels.Set{"dev1": dev},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			w := NewWatcher(ctx, newFakeListWatch(nil))
+			w.OnAdd(namespace("dev1", dev))
+			w.OnAdd(namespace("prod1", prod))
+			w.SetSelection([]objects.NamespaceSelection{{
+				Name:       "dev-only",
+				Selector:   labels.SelectorFromSet(dev),
+				Namespaces: []string{"dev1"},
+			}})
+			if w.Changed() {
+				t.Fatal("Changed() = true before any event after the selection")
+			}
+
+			tc.event(w)
+			if got := w.Changed(); got != tc.wantChanged {
+				t.Errorf("Changed() = %t, want %t", got, tc.wantChanged)
+			}
+			if w.Changed() {
+				t.Error("Changed() = true after it was reset")
+			}
+			got, err := w.Labels()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.wantLabels, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// dockerConfigKeychain is an authn.Keychain that looks up the credentials of
+// a registry in a Docker config file, such as the `.dockerconfigjson` key of a
+// Secret of type kubernetes.io/dockerconfigjson.
+type dockerConfigKeychain struct {
+	path string
+}
+
+// NewDockerConfigKeychain returns a keychain that resolves the credentials of
+// each registry from the Docker config file at path. The file is read on each
+// call to Resolve so that updates to the mounted Secret take effect without
+// restarting the container.
+func NewDockerConfigKeychain(path string) authn.Keychain {
+	return &dockerConfigKeychain{path: path}
+}
+
+// Resolve implements authn.Keychain. It returns authn.Anonymous if the file
+// holds no credentials for the registry of target.
+func (k *dockerConfigKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	f, err := os.Open(k.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the Docker config file %q: %w", k.path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	cf, err := config.LoadFromReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the Docker config file %q: %w", k.path, err)
+	}
+
+	// Docker Hub credentials are stored under the legacy index server address.
+	key := target.RegistryStr()
+	if key == name.DefaultRegistry {
+		key = authn.DefaultAuthKey
+	}
+	cfg, err := cf.GetAuthConfig(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the credentials of registry %q: %w", key, err)
+	}
+	if cfg == (types.AuthConfig{}) {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(authn.AuthConfig{
+		Username:      cfg.Username,
+		Password:      cfg.Password,
+		Auth:          cfg.Auth,
+		IdentityToken: cfg.IdentityToken,
+		RegistryToken: cfg.RegistryToken,
+	}), nil
+}
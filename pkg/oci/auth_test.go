@@ -0,0 +1,102 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+func TestDockerConfigKeychain(t *testing.T) {
+	encode := func(user, password string) string {
+		return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", user, password)))
+	}
+	path := filepath.Join(t.TempDir(), ".dockerconfigjson")
+	config := fmt.Sprintf(`{"auths":{
+  "us-docker.pkg.dev":{"auth":%q},
+  "registry.example.com:5000":{"username":"bob","password":"bob-token"},
+  "https://index.docker.io/v1/":{"auth":%q}
+}}`, encode("alice", "alice-token"), encode("carol", "carol-token"))
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keychain := NewDockerConfigKeychain(path)
+
+	testCases := []struct {
+		name  string
+		image string
+		want  *authn.AuthConfig
+	}{
+		{
+			name:  "registry with encoded credentials",
+			image: "us-docker.pkg.dev/project/repo/package:v1",
+			want:  &authn.AuthConfig{Username: "alice", Password: "alice-token"},
+		},
+		{
+			name:  "registry with a port",
+			image: "registry.example.com:5000/package@sha256:" + fmt.Sprintf("%064d", 0),
+			want:  &authn.AuthConfig{Username: "bob", Password: "bob-token"},
+		},
+		{
+			name:  "Docker Hub",
+			image: "config-sync/package",
+			want:  &authn.AuthConfig{Username: "carol", Password: "carol-token"},
+		},
+		{
+			name:  "registry without credentials",
+			image: "gcr.io/project/package",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := name.ParseReference(tc.image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			auth, err := keychain.Resolve(ref.Context())
+			if err != nil {
+				t.Fatalf("Resolve() got unexpected error: %v", err)
+			}
+			if tc.want == nil {
+				if auth != authn.Anonymous {
+					t.Errorf("Resolve() = %v, want authn.Anonymous", auth)
+				}
+				return
+			}
+			got, err := auth.Authorization()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Authorization() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keychain.Resolve(name.MustParseReference("gcr.io/project/package").Context()); err == nil {
+		t.Error("Resolve() without the Docker config file got nil error, want error")
+	}
+}
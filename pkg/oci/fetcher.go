@@ -32,10 +32,17 @@ import (
 )
 
 // FetchPackage fetches the package from the OCI repository and write it to the destination.
-func FetchPackage(ctx context.Context, imageName, ociRoot, rev string, auth authn.Authenticator) error {
-	image, err := pullImage(imageName, remote.WithContext(ctx), remote.WithAuth(auth))
+// If verifier is not nil, the symbolic link is only updated to a new image
+// if the verifier finds a trusted signature or attestation of its digest.
+func FetchPackage(ctx context.Context, imageName, ociRoot, rev string, auth authn.Authenticator, verifier *Verifier) error {
+	options := []remote.Option{remote.WithContext(ctx), remote.WithAuth(auth)}
+	ref, err := name.ParseReference(imageName)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to parse reference %q: %v", imageName, err)
+	}
+	image, err := remote.Image(ref, options...)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %v", imageName, err)
 	}
 
 	// Determine the digest of the image that was extracted
@@ -56,6 +63,13 @@ func FetchPackage(ctx context.Context, imageName, ociRoot, rev string, auth auth
 		return nil
 	}
 
+	if verifier != nil {
+		if err := verifier.Verify(ref, imageDigestHash, options...); err != nil {
+			return err
+		}
+		klog.Infof("verified the signature of image digest %q", imageDigestHash)
+	}
+
 	if _, err = os.Stat(destDir); os.IsNotExist(err) {
 		fileMode := os.FileMode(0755)
 		if err = os.MkdirAll(destDir, fileMode); err != nil {
@@ -74,20 +88,6 @@ func FetchPackage(ctx context.Context, imageName, ociRoot, rev string, auth auth
 	return util.UpdateSymlink(ociRoot, linkPath, destDir, oldDir)
 }
 
-// pullImage pulls image from source using provided options for auth credentials
-func pullImage(imageName string, options ...remote.Option) (v1.Image, error) {
-	ref, err := name.ParseReference(imageName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse reference %q: %v", imageName, err)
-	}
-
-	image, err := remote.Image(ref, options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to pull image %s: %v", imageName, err)
-	}
-	return image, nil
-}
-
 // extract extracts (untar) image files to target directory.
 func extract(image v1.Image, dir string) error {
 	// Stream image files as if single tar (merged layers)
@@ -0,0 +1,330 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	// SignatureTagSuffix is the suffix of the tag holding the signatures of an
+	// image, following the cosign convention `<alg>-<hex>.sig`.
+	SignatureTagSuffix = ".sig"
+	// AttestationTagSuffix is the suffix of the tag holding the attestations of
+	// an image, following the cosign convention `<alg>-<hex>.att`.
+	AttestationTagSuffix = ".att"
+	// SignatureAnnotation is the layer annotation holding the base64-encoded
+	// signature of a cosign simple signing payload.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	// VerificationFailedMsg is the message logged by oci-sync when an image
+	// can't be verified, which lets the reconciler report a
+	// SourceVerificationError instead of a generic source error.
+	VerificationFailedMsg = "failed to verify the OCI image signature"
+)
+
+// VerificationError is returned when the registry holds no signature or
+// attestation of an image digest signed by a trusted public key.
+type VerificationError struct {
+	// Image is the reference of the image that was pulled.
+	Image string
+	// Digest is the digest of the image that was pulled.
+	Digest v1.Hash
+	// Reasons explains why each candidate signature or attestation was rejected.
+	Reasons []string
+}
+
+// Error implements error.
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("no signature or attestation of image %s@%s is signed by a trusted public key: %s",
+		e.Image, e.Digest, strings.Join(e.Reasons, "; "))
+}
+
+// Verifier checks that an OCI image is signed by one of a set of trusted
+// public keys before it is synced.
+type Verifier struct {
+	keys []crypto.PublicKey
+}
+
+// NewVerifier returns a Verifier trusting the PEM-encoded public keys stored
+// in the files of dir, which is usually a mounted Secret or ConfigMap.
+// Files whose name starts with a dot are skipped.
+func NewVerifier(dir string) (*Verifier, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public keys directory %q: %w", dir, err)
+	}
+	v := &Verifier{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the public key file %q: %w", path, err)
+		}
+		keys, err := parsePublicKeys(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the public key file %q: %w", path, err)
+		}
+		v.keys = append(v.keys, keys...)
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("no public key found in %q", dir)
+	}
+	return v, nil
+}
+
+// parsePublicKeys parses all the PEM-encoded public keys in data.
+func parsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		case "RSA PUBLIC KEY":
+			key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM-encoded public key found")
+	}
+	return keys, nil
+}
+
+// Verify returns nil if the registry holds a signature or an attestation of
+// the image digest which is signed by one of the trusted keys. Signatures and
+// attestations are looked up in the repository of the image under the tags
+// `<alg>-<hex>.sig` and `<alg>-<hex>.att` respectively.
+func (v *Verifier) Verify(ref name.Reference, digest v1.Hash, options ...remote.Option) error {
+	verr := &VerificationError{Image: ref.Context().String(), Digest: digest}
+	for _, suffix := range []string{SignatureTagSuffix, AttestationTagSuffix} {
+		tag := ref.Context().Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, suffix))
+		reasons, err := v.verifyTag(tag, digest, options...)
+		if err != nil {
+			return err
+		}
+		if len(reasons) == 0 {
+			return nil
+		}
+		verr.Reasons = append(verr.Reasons, reasons...)
+	}
+	return verr
+}
+
+// verifyTag checks the signatures or attestations stored in the image at tag.
+// It returns nil if one of them is valid, otherwise the reasons why each of
+// them was rejected. An error is only returned if the tag can't be fetched.
+func (v *Verifier) verifyTag(tag name.Tag, digest v1.Hash, options ...remote.Option) ([]string, error) {
+	image, err := remote.Image(tag, options...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			return []string{fmt.Sprintf("%s not found", tag)}, nil
+		}
+		return nil, fmt.Errorf("failed to pull %s: %w", tag, err)
+	}
+	manifest, err := image.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the manifest of %s: %w", tag, err)
+	}
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the layers of %s: %w", tag, err)
+	}
+
+	var reasons []string
+	for i, layer := range layers {
+		payload, err := readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %d of %s: %w", i, tag, err)
+		}
+		if strings.HasSuffix(tag.TagStr(), AttestationTagSuffix) {
+			err = v.verifyAttestation(payload, digest)
+		} else {
+			err = v.verifySignature(payload, manifest.Layers[i].Annotations[SignatureAnnotation], digest)
+		}
+		if err == nil {
+			return nil, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("layer %d of %s: %v", i, tag, err))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, fmt.Sprintf("%s has no layers", tag))
+	}
+	return reasons, nil
+}
+
+// readLayer returns the content of a layer as stored in the registry.
+// Signature and attestation layers are not compressed.
+func readLayer(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	return io.ReadAll(rc)
+}
+
+// simpleSigning is the subset of a cosign simple signing payload which
+// identifies the signed image.
+type simpleSigning struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifySignature checks a cosign signature layer, whose payload is a simple
+// signing document and whose signature is stored in a layer annotation.
+func (v *Verifier) verifySignature(payload []byte, signature string, digest v1.Hash) error {
+	if signature == "" {
+		return fmt.Errorf("missing the %s annotation", SignatureAnnotation)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode the signature: %w", err)
+	}
+	if !v.verifyAny(payload, sig) {
+		return errors.New("the signature doesn't match any trusted public key")
+	}
+	doc := &simpleSigning{}
+	if err := json.Unmarshal(payload, doc); err != nil {
+		return fmt.Errorf("failed to parse the signature payload: %w", err)
+	}
+	if doc.Critical.Image.DockerManifestDigest != digest.String() {
+		return fmt.Errorf("the signature is for digest %q", doc.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// dsseEnvelope is a DSSE envelope wrapping an in-toto attestation.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		Sig string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// inTotoStatement is the subset of an in-toto statement which identifies the
+// attested artifacts.
+type inTotoStatement struct {
+	Subject []struct {
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+}
+
+// verifyAttestation checks a cosign attestation layer, whose payload is a DSSE
+// envelope around an in-toto statement.
+func (v *Verifier) verifyAttestation(payload []byte, digest v1.Hash) error {
+	envelope := &dsseEnvelope{}
+	if err := json.Unmarshal(payload, envelope); err != nil {
+		return fmt.Errorf("failed to parse the attestation envelope: %w", err)
+	}
+	body, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode the attestation payload: %w", err)
+	}
+	pae := preAuthEncoding(envelope.PayloadType, body)
+	signed := false
+	for _, s := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err == nil && v.verifyAny(pae, sig) {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return errors.New("the attestation isn't signed by any trusted public key")
+	}
+	statement := &inTotoStatement{}
+	if err := json.Unmarshal(body, statement); err != nil {
+		return fmt.Errorf("failed to parse the attestation statement: %w", err)
+	}
+	for _, subject := range statement.Subject {
+		if subject.Digest[digest.Algorithm] == digest.Hex {
+			return nil
+		}
+	}
+	return errors.New("the attestation has no subject with the image digest")
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of a payload,
+// which is the message that is actually signed.
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// verifyAny returns true if sig is a valid signature of message by one of the
+// trusted keys. ECDSA and RSA signatures are over the SHA-256 digest of the
+// message, while Ed25519 signatures are over the message itself.
+func (v *Verifier) verifyAny(message, sig []byte) bool {
+	hash := sha256.Sum256(message)
+	for _, key := range v.keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, hash[:], sig) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], sig) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, message, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
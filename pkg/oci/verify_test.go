@@ -0,0 +1,281 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// testRegistry starts an in-memory registry and pushes a package image
+// holding a single file. It returns the reference and digest of the image.
+func testRegistry(t *testing.T) (name.Reference, v1.Hash) {
+	t.Helper()
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: foo\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "ns.yaml", Mode: 0644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	image, err := mutate.AppendLayers(empty.Image, static.NewLayer(buf.Bytes(), types.DockerLayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://") + "/config-sync/package:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, image); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := image.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref, digest
+}
+
+// newKey generates an ECDSA key and writes its public key to dir.
+func newKey(t *testing.T, dir, fileName string) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, fileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, message []byte) string {
+	t.Helper()
+	hash := sha256.Sum256(message)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// pushSignature pushes a cosign signature of digest signed by key.
+func pushSignature(t *testing.T, ref name.Reference, digest v1.Hash, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`,
+		ref.Context().String(), digest.String()))
+	image, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json"),
+		Annotations: map[string]string{SignatureAnnotation: sign(t, key, payload)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := ref.Context().Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, SignatureTagSuffix))
+	if err := remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pushAttestation pushes a cosign attestation of digest signed by key.
+func pushAttestation(t *testing.T, ref name.Reference, digest v1.Hash, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payloadType := "application/vnd.in-toto+json"
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","subject":[{"name":%q,"digest":{%q:%q}}],"predicate":{}}`,
+		ref.Context().String(), digest.Algorithm, digest.Hex))
+	envelope, err := json.Marshal(map[string]interface{}{
+		"payloadType": payloadType,
+		"payload":     base64.StdEncoding.EncodeToString(statement),
+		"signatures":  []map[string]string{{"sig": sign(t, key, preAuthEncoding(payloadType, statement))}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(envelope, "application/vnd.dsse.envelope.v1+json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := ref.Context().Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, AttestationTagSuffix))
+	if err := remote.Write(tag, image); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchPackageWithVerification(t *testing.T) {
+	otherDigest := v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("0", 64)}
+	testCases := []struct {
+		name string
+		// push pushes the signatures and attestations with the trusted and the
+		// untrusted keys.
+		push    func(t *testing.T, ref name.Reference, digest v1.Hash, trusted, untrusted *ecdsa.PrivateKey)
+		wantErr bool
+	}{
+		{
+			name:    "unsigned image",
+			push:    func(*testing.T, name.Reference, v1.Hash, *ecdsa.PrivateKey, *ecdsa.PrivateKey) {},
+			wantErr: true,
+		},
+		{
+			name: "signed by a trusted key",
+			push: func(t *testing.T, ref name.Reference, digest v1.Hash, trusted, _ *ecdsa.PrivateKey) {
+				pushSignature(t, ref, digest, trusted)
+			},
+		},
+		{
+			name: "signed by an untrusted key",
+			push: func(t *testing.T, ref name.Reference, digest v1.Hash, _, untrusted *ecdsa.PrivateKey) {
+				pushSignature(t, ref, digest, untrusted)
+			},
+			wantErr: true,
+		},
+		{
+			name: "signature of another digest",
+			push: func(t *testing.T, ref name.Reference, digest v1.Hash, trusted, _ *ecdsa.PrivateKey) {
+				pushSignature(t, ref, otherDigest, trusted)
+				// Copy the signature of the other digest under the tag of the image.
+				sig, err := remote.Image(ref.Context().Tag(fmt.Sprintf("sha256-%s.sig", otherDigest.Hex)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := remote.Write(ref.Context().Tag(fmt.Sprintf("sha256-%s.sig", digest.Hex)), sig); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "attested by a trusted key",
+			push: func(t *testing.T, ref name.Reference, digest v1.Hash, trusted, _ *ecdsa.PrivateKey) {
+				pushAttestation(t, ref, digest, trusted)
+			},
+		},
+		{
+			name: "attested by an untrusted key",
+			push: func(t *testing.T, ref name.Reference, digest v1.Hash, _, untrusted *ecdsa.PrivateKey) {
+				pushAttestation(t, ref, digest, untrusted)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, digest := testRegistry(t)
+			keysDir := t.TempDir()
+			trusted := newKey(t, keysDir, "cosign.pub")
+			untrusted := newKey(t, t.TempDir(), "cosign.pub")
+			tc.push(t, ref, digest, trusted, untrusted)
+
+			verifier, err := NewVerifier(keysDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ociRoot := t.TempDir()
+			err = FetchPackage(context.Background(), ref.String(), ociRoot, "rev", authn.Anonymous, verifier)
+			_, statErr := os.Stat(filepath.Join(ociRoot, "rev", "ns.yaml"))
+			if tc.wantErr {
+				var verr *VerificationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("FetchPackage() got error %v, want a VerificationError", err)
+				}
+				if verr.Digest != digest {
+					t.Errorf("VerificationError.Digest = %s, want %s", verr.Digest, digest)
+				}
+				if !os.IsNotExist(statErr) {
+					t.Errorf("the package must not be synced without a trusted signature, got: %v", statErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchPackage() got unexpected error: %v", err)
+			}
+			if statErr != nil {
+				t.Errorf("the package is not synced: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	dir := t.TempDir()
+	// Mounted Secrets and ConfigMaps hold hidden files and directories.
+	if err := os.Mkdir(filepath.Join(dir, "..data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifier(dir); err == nil {
+		t.Error("NewVerifier() without public keys got nil error, want error")
+	}
+
+	newKey(t, dir, "first.pub")
+	newKey(t, dir, "second.pub")
+	verifier, err := NewVerifier(dir)
+	if err != nil {
+		t.Fatalf("NewVerifier() got unexpected error: %v", err)
+	}
+	if len(verifier.keys) != 2 {
+		t.Errorf("NewVerifier() loaded %d keys, want 2", len(verifier.keys))
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "invalid.pub"), []byte("not a key"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVerifier(dir); err == nil {
+		t.Error("NewVerifier() with an invalid public key got nil error, want error")
+	}
+}
@@ -26,17 +26,19 @@ import (
 	"kpt.dev/configsync/pkg/metadata"
 )
 
-// sourceContext contains the fields which identify where a resource is being synced from.
-type sourceContext struct {
+// SourceContext contains the fields which identify where a resource is being synced from.
+type SourceContext struct {
 	Repo   string `json:"repo"`
 	Branch string `json:"branch,omitempty"`
 	Rev    string `json:"rev,omitempty"`
 }
 
-func addAnnotationsAndLabels(objs []ast.FileObject, scope declared.Scope, syncName string, sc sourceContext, commitHash string) error {
+// AddAnnotationsAndLabels adds the annotations and labels which mark the
+// objects as managed by the RootSync or RepoSync with the given scope and name.
+func AddAnnotationsAndLabels(objs []ast.FileObject, scope declared.Scope, syncName string, sc SourceContext, commitHash string) error {
 	gcVal, err := json.Marshal(sc)
 	if err != nil {
-		return fmt.Errorf("marshaling sourceContext: %w", err)
+		return fmt.Errorf("marshaling SourceContext: %w", err)
 	}
 	var inventoryID string
 	if scope == declared.RootReconciler {
@@ -30,7 +30,7 @@ func TestAddAnnotationsAndLabels(t *testing.T) {
 		name       string
 		actual     []ast.FileObject
 		expected   []ast.FileObject
-		gc         sourceContext
+		gc         SourceContext
 		commitHash string
 	}{
 		{
@@ -40,7 +40,7 @@ func TestAddAnnotationsAndLabels(t *testing.T) {
 		},
 		{
 			name: "nil annotation without env",
-			gc: sourceContext{
+			gc: SourceContext{
 				Repo:   "git@github.com/foo",
 				Branch: "main",
 				Rev:    "HEAD",
@@ -62,7 +62,7 @@ func TestAddAnnotationsAndLabels(t *testing.T) {
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := addAnnotationsAndLabels(tc.actual, "some-namespace", "rs", tc.gc, tc.commitHash); err != nil {
+			if err := AddAnnotationsAndLabels(tc.actual, "some-namespace", "rs", tc.gc, tc.commitHash); err != nil {
 				t.Fatalf("Failed to add annotations and labels: %v", err)
 			}
 			if diff := cmp.Diff(tc.expected, tc.actual, ast.CompareFileObject); diff != "" {
@@ -0,0 +1,36 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+// setDriftStatus sets the drift status from the resources recorded by the
+// remediator in audit-only mode, and clears it otherwise. The drifted resources
+// are truncated by the denominator, like the sync errors.
+func setDriftStatus(syncStatus *v1beta1.Status, drifted []v1beta1.DriftedResource, auditOnly bool, denominator int) {
+	if !auditOnly {
+		syncStatus.Drift = nil
+		return
+	}
+	syncStatus.Drift = &v1beta1.DriftStatus{
+		LastUpdate: metav1.Now(),
+		Count:      len(drifted),
+		Resources:  drifted[0 : len(drifted)/denominator],
+		Truncated:  denominator != 1,
+	}
+}
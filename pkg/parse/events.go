@@ -0,0 +1,82 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"kpt.dev/configsync/pkg/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncEvents records the Events on the RootSync or RepoSync about the commits
+// which were synced or failed to sync. The status is set every few seconds, so
+// an Event is only recorded when the commit or its number of errors changes.
+//
+// A nil *syncEvents records nothing.
+type syncEvents struct {
+	recorder record.EventRecorder
+	// lastSource is the result of the last source Event.
+	lastSource syncResult
+	// lastSync is the result of the last sync Event.
+	lastSync syncResult
+}
+
+// syncResult is the commit and the number of errors of a source or sync.
+type syncResult struct {
+	commit     string
+	errorCount int
+}
+
+func newSyncEvents(recorder record.EventRecorder) *syncEvents {
+	return &syncEvents{recorder: recorder}
+}
+
+// recordSource records a SyncFailed Event on obj once the commit fails to be
+// read, rendered or parsed.
+func (e *syncEvents) recordSource(obj client.Object, commit string, errorCount int) {
+	if e == nil {
+		return
+	}
+	result := syncResult{commit: commit, errorCount: errorCount}
+	if result == e.lastSource {
+		return
+	}
+	e.lastSource = result
+	if errorCount == 0 {
+		return
+	}
+	e.recorder.Eventf(obj, corev1.EventTypeWarning, events.ReasonSyncFailed,
+		"Failed to parse commit %s: %d error(s), see .status.source.errors", commit, errorCount)
+}
+
+// recordSync records a Synced or SyncFailed Event on obj once the sync of the
+// commit completes.
+func (e *syncEvents) recordSync(obj client.Object, commit string, errorCount int) {
+	if e == nil {
+		return
+	}
+	result := syncResult{commit: commit, errorCount: errorCount}
+	if result == e.lastSync {
+		return
+	}
+	e.lastSync = result
+	if errorCount == 0 {
+		e.recorder.Eventf(obj, corev1.EventTypeNormal, events.ReasonSynced, "Synced commit %s", commit)
+		return
+	}
+	e.recorder.Eventf(obj, corev1.EventTypeWarning, events.ReasonSyncFailed,
+		"Failed to sync commit %s: %d error(s), see .status.sync.errors", commit, errorCount)
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/client-go/tools/record"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestSyncEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	e := newSyncEvents(recorder)
+	rs := fake.RootSyncObjectV1Beta1(configsync.RootSyncName)
+
+	e.recordSource(rs, "abc", 2)
+	// The status is set again with the same result.
+	e.recordSource(rs, "abc", 2)
+	e.recordSource(rs, "abc", 0)
+	e.recordSync(rs, "abc", 1)
+	e.recordSync(rs, "abc", 1)
+	e.recordSync(rs, "abc", 0)
+	e.recordSync(rs, "def", 0)
+	close(recorder.Events)
+
+	var got []string
+	for event := range recorder.Events {
+		got = append(got, event)
+	}
+	want := []string{
+		"Warning SyncFailed Failed to parse commit abc: 2 error(s), see .status.source.errors",
+		"Warning SyncFailed Failed to sync commit abc: 1 error(s), see .status.sync.errors",
+		"Normal Synced Synced commit abc",
+		"Normal Synced Synced commit def",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Diff of the recorded events (-want +got):\n%s", diff)
+	}
+
+	// A nil syncEvents records nothing.
+	var nilEvents *syncEvents
+	nilEvents.recordSync(rs, "abc", 0)
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+// syncHistoryLimit bounds the number of commits in the sync history.
+const syncHistoryLimit = 10
+
+// setSyncHistory records the sync of the commit in `.status.sync` at the head
+// of the sync history. Successive syncs of the same commit update its entry,
+// while a new commit adds an entry and drops the oldest one past the limit.
+func setSyncHistory(syncStatus *v1beta1.Status, syncing bool) {
+	sync := syncStatus.Sync
+	if sync.Commit == "" {
+		return
+	}
+	var errorCount int
+	if sync.ErrorSummary != nil {
+		errorCount = sync.ErrorSummary.TotalCount
+	}
+
+	var entry v1beta1.SyncHistoryEntry
+	if len(syncStatus.History) > 0 && syncStatus.History[0].Commit == sync.Commit {
+		entry = syncStatus.History[0]
+	} else {
+		entry = v1beta1.SyncHistoryEntry{
+			Commit:    sync.Commit,
+			StartTime: sync.LastUpdate,
+		}
+		syncStatus.History = append([]v1beta1.SyncHistoryEntry{entry}, syncStatus.History...)
+		if len(syncStatus.History) > syncHistoryLimit {
+			syncStatus.History = syncStatus.History[:syncHistoryLimit]
+		}
+	}
+	entry.Git = sync.Git
+	entry.Oci = sync.Oci
+	entry.Helm = sync.Helm
+	entry.ErrorCount = errorCount
+	switch {
+	case syncing:
+		// A resync of a completed commit keeps its last outcome until it
+		// completes again.
+		if entry.CompletionTime.IsZero() {
+			entry.Outcome = v1beta1.SyncInProgress
+		}
+	case errorCount == 0:
+		entry.Outcome = v1beta1.SyncSucceeded
+		entry.CompletionTime = sync.LastUpdate
+	default:
+		entry.Outcome = v1beta1.SyncFailed
+		entry.CompletionTime = sync.LastUpdate
+	}
+	syncStatus.History[0] = entry
+}
+
+// LastGoodSync returns the most recent entry of the sync history which synced
+// without errors, skipping the commits in skip, or nil if there is none.
+func LastGoodSync(history []v1beta1.SyncHistoryEntry, skip ...string) *v1beta1.SyncHistoryEntry {
+	for i, entry := range history {
+		if entry.Outcome != v1beta1.SyncSucceeded {
+			continue
+		}
+		skipped := false
+		for _, commit := range skip {
+			skipped = skipped || entry.Commit == commit
+		}
+		if !skipped {
+			return &history[i]
+		}
+	}
+	return nil
+}
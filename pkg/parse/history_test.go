@@ -0,0 +1,154 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+)
+
+var (
+	startTime = metav1.NewTime(time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC))
+	endTime   = metav1.NewTime(time.Date(2022, 3, 1, 10, 1, 0, 0, time.UTC))
+)
+
+func syncedStatus(commit string, errorCount int, lastUpdate metav1.Time, history ...v1beta1.SyncHistoryEntry) *v1beta1.Status {
+	return &v1beta1.Status{
+		Sync: v1beta1.SyncStatus{
+			Git:          &v1beta1.GitStatus{Repo: "https://github.com/test/repo", Revision: "HEAD", Branch: "main"},
+			Commit:       commit,
+			LastUpdate:   lastUpdate,
+			ErrorSummary: &v1beta1.ErrorSummary{TotalCount: errorCount},
+		},
+		History: history,
+	}
+}
+
+func historyEntry(commit string, outcome v1beta1.SyncOutcome, errorCount int, completionTime metav1.Time) v1beta1.SyncHistoryEntry {
+	return v1beta1.SyncHistoryEntry{
+		Commit:         commit,
+		Git:            &v1beta1.GitStatus{Repo: "https://github.com/test/repo", Revision: "HEAD", Branch: "main"},
+		Outcome:        outcome,
+		ErrorCount:     errorCount,
+		StartTime:      startTime,
+		CompletionTime: completionTime,
+	}
+}
+
+func TestSetSyncHistory(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  *v1beta1.Status
+		syncing bool
+		want    []v1beta1.SyncHistoryEntry
+	}{
+		{
+			name:    "no commit",
+			status:  syncedStatus("", 0, startTime),
+			syncing: true,
+		},
+		{
+			name:    "first commit starts syncing",
+			status:  syncedStatus("abc123", 0, startTime),
+			syncing: true,
+			want:    []v1beta1.SyncHistoryEntry{historyEntry("abc123", v1beta1.SyncInProgress, 0, metav1.Time{})},
+		},
+		{
+			name:   "commit synced",
+			status: syncedStatus("abc123", 0, endTime, historyEntry("abc123", v1beta1.SyncInProgress, 0, metav1.Time{})),
+			want:   []v1beta1.SyncHistoryEntry{historyEntry("abc123", v1beta1.SyncSucceeded, 0, endTime)},
+		},
+		{
+			name:   "commit failed",
+			status: syncedStatus("abc123", 2, endTime, historyEntry("abc123", v1beta1.SyncInProgress, 0, metav1.Time{})),
+			want:   []v1beta1.SyncHistoryEntry{historyEntry("abc123", v1beta1.SyncFailed, 2, endTime)},
+		},
+		{
+			name:    "resync keeps the last outcome",
+			status:  syncedStatus("abc123", 0, endTime, historyEntry("abc123", v1beta1.SyncSucceeded, 0, startTime)),
+			syncing: true,
+			want:    []v1beta1.SyncHistoryEntry{historyEntry("abc123", v1beta1.SyncSucceeded, 0, startTime)},
+		},
+		{
+			name:    "new commit",
+			status:  syncedStatus("def456", 0, startTime, historyEntry("abc123", v1beta1.SyncSucceeded, 0, endTime)),
+			syncing: true,
+			want: []v1beta1.SyncHistoryEntry{
+				historyEntry("def456", v1beta1.SyncInProgress, 0, metav1.Time{}),
+				historyEntry("abc123", v1beta1.SyncSucceeded, 0, endTime),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setSyncHistory(tc.status, tc.syncing)
+			if diff := cmp.Diff(tc.want, tc.status.History); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSetSyncHistory_Limit(t *testing.T) {
+	status := &v1beta1.Status{}
+	for i := 0; i < syncHistoryLimit+5; i++ {
+		status.Sync = syncedStatus(fmt.Sprintf("commit-%d", i), 0, endTime).Sync
+		setSyncHistory(status, false)
+	}
+	if len(status.History) != syncHistoryLimit {
+		t.Fatalf("got %d history entries, want %d", len(status.History), syncHistoryLimit)
+	}
+	if got, want := status.History[0].Commit, fmt.Sprintf("commit-%d", syncHistoryLimit+4); got != want {
+		t.Errorf("got newest commit %s, want %s", got, want)
+	}
+	if got, want := status.History[syncHistoryLimit-1].Commit, "commit-5"; got != want {
+		t.Errorf("got oldest commit %s, want %s", got, want)
+	}
+}
+
+func TestLastGoodSync(t *testing.T) {
+	history := []v1beta1.SyncHistoryEntry{
+		historyEntry("ccc333", v1beta1.SyncFailed, 1, endTime),
+		historyEntry("bbb222", v1beta1.SyncSucceeded, 0, endTime),
+		historyEntry("aaa111", v1beta1.SyncSucceeded, 0, endTime),
+	}
+	testCases := []struct {
+		name string
+		skip []string
+		want string
+	}{
+		{name: "last successful commit", want: "bbb222"},
+		{name: "skip the current commit", skip: []string{"bbb222"}, want: "aaa111"},
+		{name: "none", skip: []string{"bbb222", "aaa111"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := LastGoodSync(history, tc.skip...)
+			switch {
+			case tc.want == "" && got != nil:
+				t.Errorf("LastGoodSync() = %s, want none", got.Commit)
+			case tc.want != "" && got == nil:
+				t.Errorf("LastGoodSync() = nil, want %s", tc.want)
+			case tc.want != "" && got.Commit != tc.want:
+				t.Errorf("LastGoodSync() = %s, want %s", got.Commit, tc.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
@@ -35,13 +36,14 @@ import (
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/reposync"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncwindow"
 	utildiscovery "kpt.dev/configsync/pkg/util/discovery"
 	"kpt.dev/configsync/pkg/validate"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // NewNamespaceRunner creates a new runnable parser for parsing a Namespace repo.
-func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope declared.Scope, fileReader reader.Reader, c client.Client, pollingFrequency time.Duration, resyncPeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Interface, rem remediator.Interface) (Parser, error) {
+func NewNamespaceRunner(clusterName string, clusterLabels, clusterValues map[string]string, syncName, reconcilerName string, scope declared.Scope, fileReader reader.Reader, c client.Client, pollingFrequency time.Duration, resyncPeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Interface, rem remediator.Interface, dryRun, auditOnly, suspend bool, syncWindows *syncwindow.Gate, recorder record.EventRecorder) (Parser, error) {
 	converter, err := declared.NewValueConverter(dc)
 	if err != nil {
 		return nil, err
@@ -50,6 +52,8 @@ func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope decl
 	return &namespace{
 		opts: opts{
 			clusterName:      clusterName,
+			clusterLabels:    clusterLabels,
+			clusterValues:    clusterValues,
 			client:           c,
 			syncName:         syncName,
 			reconcilerName:   reconcilerName,
@@ -62,9 +66,16 @@ func NewNamespaceRunner(clusterName, syncName, reconcilerName string, scope decl
 				resources:  resources,
 				applier:    app,
 				remediator: rem,
+				dryRun:     dryRun,
+				auditOnly:  auditOnly,
+				reader:     c,
+				planMux:    &sync.Mutex{},
 			},
 			discoveryInterface: dc,
 			converter:          converter,
+			syncEvents:         newSyncEvents(recorder),
+			syncWindows:        syncWindows,
+			suspend:            suspend,
 			mux:                &sync.Mutex{},
 		},
 		scope: scope,
@@ -109,11 +120,13 @@ func (p *namespace) parseSource(ctx context.Context, state sourceState) ([]ast.F
 	}
 
 	options := validate.Options{
-		ClusterName:  p.clusterName,
-		PolicyDir:    p.SyncDir,
-		PreviousCRDs: crds,
-		BuildScoper:  builder,
-		Converter:    p.converter,
+		ClusterName:   p.clusterName,
+		ClusterLabels: p.clusterLabels,
+		ClusterValues: p.clusterValues,
+		PolicyDir:     p.SyncDir,
+		PreviousCRDs:  crds,
+		BuildScoper:   builder,
+		Converter:     p.converter,
 	}
 	options = OptionsForScope(options, p.scope)
 
@@ -126,7 +139,7 @@ func (p *namespace) parseSource(ctx context.Context, state sourceState) ([]ast.F
 	}
 
 	// Duplicated with root.go.
-	e := addAnnotationsAndLabels(objs, p.scope, p.syncName, p.sourceContext(), state.commit)
+	e := AddAnnotationsAndLabels(objs, p.scope, p.syncName, p.sourceContext(), state.commit)
 	if e != nil {
 		err = status.Append(err, status.InternalErrorf("unable to add annotations and labels: %v", e))
 		return nil, err
@@ -181,6 +194,7 @@ func (p *namespace) setSourceStatusWithRetries(ctx context.Context, newStatus so
 		}
 		return status.APIServerError(err, "failed to update RepoSync source status from parser")
 	}
+	p.syncEvents.recordSource(&rs, newStatus.commit, rs.Status.Source.ErrorSummary.TotalCount)
 	return nil
 }
 
@@ -259,25 +273,46 @@ func (p *namespace) setSyncStatusWithRetries(ctx context.Context, errs status.Mu
 
 	currentRS := rs.DeepCopy()
 
-	// syncing indicates whether the applier is syncing.
-	syncing := p.applier.Syncing()
+	setDriftStatus(&rs.Status.Status, p.remediator.DriftedResources(), p.auditOnly, denominator)
 
-	setSyncStatus(&rs.Status.Status, status.ToCSE(errs), denominator)
+	// syncCompleted indicates whether the applier completed the sync of the
+	// commit.
+	var syncCompleted bool
+	if p.dryRun {
+		plan := p.lastPlan()
+		if plan == nil {
+			// No dry run has completed yet.
+			return nil
+		}
+		setPlanStatus(&rs.Status.Status, plan, denominator)
+		errorSources, errorSummary := summarizePlanErrors(rs.Status.Source, rs.Status.Plan)
+		reposync.SetSyncing(rs, false, "DryRun", "Dry Run Completed", rs.Status.Plan.Commit, errorSources, errorSummary, rs.Status.Plan.LastUpdate)
+	} else {
+		// The plan of a previous dry run is stale once the reconciler applies.
+		rs.Status.Plan = nil
 
-	metrics.RecordReconcilerErrors(ctx, "sync", status.ToCSE(errs))
-	metrics.RecordPipelineError(ctx, configsync.RepoSyncName, "sync", rs.Status.Sync.ErrorSummary.TotalCount)
-	if !syncing {
-		metrics.RecordLastSync(ctx, rs.Status.Sync.Commit, rs.Status.Sync.LastUpdate.Time)
-	}
+		// syncing indicates whether the applier is syncing.
+		syncing := p.applier.Syncing()
+		syncCompleted = !syncing
 
-	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
-	if syncing {
-		reposync.SetSyncing(rs, true, "Sync", "Syncing", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
-	} else {
-		if errorSummary.TotalCount == 0 {
-			rs.Status.LastSyncedCommit = rs.Status.Sync.Commit
+		setSyncStatus(&rs.Status.Status, status.ToCSE(errs), denominator)
+		setSyncHistory(&rs.Status.Status, syncing)
+
+		metrics.RecordReconcilerErrors(ctx, "sync", status.ToCSE(errs))
+		metrics.RecordPipelineError(ctx, configsync.RepoSyncName, "sync", rs.Status.Sync.ErrorSummary.TotalCount)
+		if !syncing {
+			metrics.RecordLastSync(ctx, p.lastTrigger, rs.Status.Sync.Commit, rs.Status.Sync.LastUpdate.Time)
+		}
+
+		errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+		if syncing {
+			reposync.SetSyncing(rs, true, "Sync", "Syncing", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
+		} else {
+			if errorSummary.TotalCount == 0 {
+				rs.Status.LastSyncedCommit = rs.Status.Sync.Commit
+			}
+			reposync.SetSyncing(rs, false, "Sync", "Sync Completed", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
 		}
-		reposync.SetSyncing(rs, false, "Sync", "Sync Completed", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
 	}
 
 	// Avoid unnecessary status updates.
@@ -299,6 +334,49 @@ func (p *namespace) setSyncStatusWithRetries(ctx context.Context, errs status.Mu
 		}
 		return status.APIServerError(err, fmt.Sprintf("failed to update the RepoSync sync status for the %v namespace", p.scope))
 	}
+	if syncCompleted {
+		p.syncEvents.recordSync(rs, rs.Status.Sync.Commit, rs.Status.Sync.ErrorSummary.TotalCount)
+	}
+	return nil
+}
+
+// setSyncWindowStatus implements the Parser interface
+func (p *namespace) setSyncWindowStatus(ctx context.Context, newStatus syncWindowStatus) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var rs v1beta1.RepoSync
+	if err := p.client.Get(ctx, reposync.ObjectKey(p.scope, p.syncName), &rs); err != nil {
+		return status.APIServerError(err, fmt.Sprintf("failed to get the RepoSync object for the %v namespace", p.scope))
+	}
+
+	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+	reposync.SetSyncing(&rs, false, SyncWindowReason, syncWindowMessage(newStatus), newStatus.commit, errorSources, errorSummary, newStatus.lastUpdate)
+
+	if err := p.client.Status().Update(ctx, &rs); err != nil {
+		return status.APIServerError(err, fmt.Sprintf("failed to update the RepoSync sync window status for the %v namespace", p.scope))
+	}
+	return nil
+}
+
+// setSuspendedStatus implements the Parser interface
+func (p *namespace) setSuspendedStatus(ctx context.Context, newStatus suspendedStatus) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var rs v1beta1.RepoSync
+	if err := p.client.Get(ctx, reposync.ObjectKey(p.scope, p.syncName), &rs); err != nil {
+		return status.APIServerError(err, fmt.Sprintf("failed to get the RepoSync object for the %v namespace", p.scope))
+	}
+
+	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+	message := suspendedMessage(newStatus, rs.Status.Sync.Commit)
+	reposync.SetSyncing(&rs, false, SuspendedReason, message, newStatus.commit, errorSources, errorSummary, newStatus.lastUpdate)
+	reposync.SetSuspended(&rs, SuspendedReason, message, newStatus.commit, newStatus.lastUpdate)
+
+	if err := p.client.Status().Update(ctx, &rs); err != nil {
+		return status.APIServerError(err, fmt.Sprintf("failed to update the RepoSync suspended status for the %v namespace", p.scope))
+	}
 	return nil
 }
 
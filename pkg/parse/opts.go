@@ -22,7 +22,9 @@ import (
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem"
+	"kpt.dev/configsync/pkg/namespaceselector"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncwindow"
 	"kpt.dev/configsync/pkg/util/discovery"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -34,6 +36,14 @@ type opts struct {
 	// clusterName is the name of the cluster we're syncing configuration to.
 	clusterName string
 
+	// clusterLabels are the labels of the cluster read from the live cluster,
+	// which override the labels of its Cluster object in the repo.
+	clusterLabels map[string]string
+
+	// clusterValues are the values of the ${cluster.values.KEY} variables in
+	// the declared configs.
+	clusterValues map[string]string
+
 	// client knows how to read objects from a Kubernetes cluster and update status.
 	client client.Client
 
@@ -63,6 +73,28 @@ type opts struct {
 	// reconciling indicates whether the reconciler is reconciling a change.
 	reconciling bool
 
+	// lastTrigger is the trigger of the last parse-apply-watch loop which
+	// started to apply the configuration. It tags the LastSync metric.
+	// It is guarded by mux.
+	lastTrigger string
+
+	// syncWindows restricts when the changes of the source of truth are
+	// applied. It is nil if there are no sync windows.
+	syncWindows *syncwindow.Gate
+
+	// suspend indicates that the changes of the source of truth are not
+	// applied, while the source is still fetched and the status reported.
+	suspend bool
+
+	// namespaces watches the Namespaces on the cluster for the
+	// NamespaceSelectors in dynamic mode. It is nil for namespace reconcilers
+	// and hierarchical repos.
+	namespaces *namespaceselector.Watcher
+
+	// syncEvents records the Events of the RootSync or RepoSync.
+	// It is guarded by mux.
+	syncEvents *syncEvents
+
 	// mux prevents status update conflicts.
 	mux *sync.Mutex
 
@@ -76,6 +108,8 @@ type Parser interface {
 	setSourceStatus(ctx context.Context, newStatus sourceStatus) error
 	setRenderingStatus(ctx context.Context, oldStatus, newStatus renderingStatus) error
 	SetSyncStatus(ctx context.Context, errs status.MultiError) error
+	setSyncWindowStatus(ctx context.Context, newStatus syncWindowStatus) error
+	setSuspendedStatus(ctx context.Context, newStatus suspendedStatus) error
 	options() *opts
 	// SetReconciling sets the field indicating whether the reconciler is reconciling a change.
 	SetReconciling(value bool)
@@ -104,3 +138,15 @@ func (o *opts) SetReconciling(value bool) {
 func (o *opts) Reconciling() bool {
 	return o.reconciling
 }
+
+// namespacesChanged returns whether a Namespace event changed the Namespaces
+// selected by the NamespaceSelectors in dynamic mode since the last call.
+func (o *opts) namespacesChanged() bool {
+	return o.namespaces.Changed()
+}
+
+func (o *opts) setLastTrigger(trigger string) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.lastTrigger = trigger
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parse
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/status"
+)
+
+// planResult is the result of a dry run.
+type planResult struct {
+	// commit is the commit which was planned.
+	commit string
+	// changes are the changes which the applier would make.
+	changes []applier.PlannedChange
+	// errs are the errors encountered while computing the changes.
+	errs status.MultiError
+}
+
+// setPlanStatus sets the plan status from the result of a dry run. The
+// changes and the errors are truncated by the denominator, like the sync
+// errors.
+func setPlanStatus(syncStatus *v1beta1.Status, plan *planResult, denominator int) {
+	planErrs := status.ToCSE(plan.errs)
+	planStatus := &v1beta1.PlanStatus{
+		Commit:     plan.commit,
+		LastUpdate: metav1.Now(),
+		Truncated:  denominator != 1,
+		ErrorSummary: &v1beta1.ErrorSummary{
+			TotalCount: len(planErrs),
+			Truncated:  denominator != 1,
+		},
+		Errors: planErrs[0 : len(planErrs)/denominator],
+	}
+
+	changes := make([]v1beta1.PlanChange, len(plan.changes))
+	for i, change := range plan.changes {
+		switch change.Action {
+		case v1beta1.PlanActionCreate:
+			planStatus.Summary.Create++
+		case v1beta1.PlanActionUpdate:
+			planStatus.Summary.Update++
+		case v1beta1.PlanActionPrune:
+			planStatus.Summary.Prune++
+		case v1beta1.PlanActionConflict:
+			planStatus.Summary.Conflict++
+		}
+		gvk := change.Object.GetObjectKind().GroupVersionKind()
+		changes[i] = v1beta1.PlanChange{
+			Action: change.Action,
+			Resource: v1beta1.ResourceRef{
+				SourcePath: status.GetSourceAnnotation(change.Object),
+				Name:       change.Object.GetName(),
+				Namespace:  change.Object.GetNamespace(),
+				GVK: metav1.GroupVersionKind{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+				},
+			},
+		}
+	}
+	planStatus.Changes = changes[0 : len(changes)/denominator]
+	syncStatus.Plan = planStatus
+}
+
+// summarizePlanErrors summarizes the errors from `sourceStatus` and
+// `planStatus`, and returns an ErrorSource slice and an ErrorSummary.
+func summarizePlanErrors(sourceStatus v1beta1.SourceStatus, planStatus *v1beta1.PlanStatus) ([]v1beta1.ErrorSource, *v1beta1.ErrorSummary) {
+	errorSources, errorSummary := summarizeErrors(sourceStatus, v1beta1.SyncStatus{})
+	if len(planStatus.Errors) > 0 {
+		errorSources = append(errorSources, v1beta1.PlanError)
+	}
+	if summary := planStatus.ErrorSummary; summary != nil {
+		errorSummary.TotalCount += summary.TotalCount
+		errorSummary.ErrorCountAfterTruncation += summary.ErrorCountAfterTruncation
+		if summary.Truncated {
+			errorSummary.Truncated = true
+		}
+	}
+	return errorSources, errorSummary
+}
@@ -28,29 +28,34 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/helm"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/importer/reader"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/namespaceselector"
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/rootsync"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncwindow"
 	utildiscovery "kpt.dev/configsync/pkg/util/discovery"
 	"kpt.dev/configsync/pkg/validate"
+	"kpt.dev/configsync/pkg/validate/objects"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // NewRootRunner creates a new runnable parser for parsing a Root repository.
-func NewRootRunner(clusterName, syncName, reconcilerName string, format filesystem.SourceFormat, fileReader reader.Reader, c client.Client, pollingFrequency time.Duration, resyncPeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Interface, rem remediator.Interface) (Parser, error) {
+func NewRootRunner(clusterName string, clusterLabels, clusterValues map[string]string, syncName, reconcilerName string, format filesystem.SourceFormat, fileReader reader.Reader, c client.Client, pollingFrequency time.Duration, resyncPeriod time.Duration, fs FileSource, dc discovery.DiscoveryInterface, resources *declared.Resources, app applier.Interface, rem remediator.Interface, dryRun, auditOnly, suspend bool, syncWindows *syncwindow.Gate, namespaces *namespaceselector.Watcher, recorder record.EventRecorder) (Parser, error) {
 	converter, err := declared.NewValueConverter(dc)
 	if err != nil {
 		return nil, err
@@ -58,6 +63,8 @@ func NewRootRunner(clusterName, syncName, reconcilerName string, format filesyst
 
 	opts := opts{
 		clusterName:      clusterName,
+		clusterLabels:    clusterLabels,
+		clusterValues:    clusterValues,
 		syncName:         syncName,
 		reconcilerName:   reconcilerName,
 		client:           c,
@@ -70,9 +77,17 @@ func NewRootRunner(clusterName, syncName, reconcilerName string, format filesyst
 			resources:  resources,
 			applier:    app,
 			remediator: rem,
+			dryRun:     dryRun,
+			auditOnly:  auditOnly,
+			reader:     c,
+			planMux:    &sync.Mutex{},
 		},
 		discoveryInterface: dc,
 		converter:          converter,
+		syncEvents:         newSyncEvents(recorder),
+		syncWindows:        syncWindows,
+		suspend:            suspend,
+		namespaces:         namespaces,
 		mux:                &sync.Mutex{},
 	}
 	return &root{opts: opts, sourceFormat: format}, nil
@@ -121,16 +136,21 @@ func (p *root) parseSource(ctx context.Context, state sourceState) ([]ast.FileOb
 	}
 
 	options := validate.Options{
-		ClusterName:  p.clusterName,
-		PolicyDir:    p.SyncDir,
-		PreviousCRDs: crds,
-		BuildScoper:  builder,
-		Converter:    p.converter,
+		ClusterName:   p.clusterName,
+		ClusterLabels: p.clusterLabels,
+		ClusterValues: p.clusterValues,
+		PolicyDir:     p.SyncDir,
+		PreviousCRDs:  crds,
+		BuildScoper:   builder,
+		Converter:     p.converter,
 	}
 	options = OptionsForScope(options, p.scope)
 
 	if p.sourceFormat == filesystem.SourceFormatUnstructured {
 		options.Visitors = append(options.Visitors, p.addImplicitNamespaces)
+		if p.namespaces != nil {
+			options.LiveNamespaces = p.namespaces
+		}
 		objs, err = validate.Unstructured(objs, options)
 	} else {
 		objs, err = validate.Hierarchical(objs, options)
@@ -143,7 +163,7 @@ func (p *root) parseSource(ctx context.Context, state sourceState) ([]ast.FileOb
 	}
 
 	// Duplicated with namespace.go.
-	e := addAnnotationsAndLabels(objs, declared.RootReconciler, p.syncName, p.sourceContext(), state.commit)
+	e := AddAnnotationsAndLabels(objs, declared.RootReconciler, p.syncName, p.sourceContext(), state.commit)
 	if e != nil {
 		err = status.Append(err, status.InternalErrorf("unable to add annotations and labels: %v", e))
 		return nil, err
@@ -191,6 +211,7 @@ func (p *root) setSourceStatusWithRetries(ctx context.Context, newStatus sourceS
 		}
 		return status.APIServerError(err, "failed to update RootSync source status from parser")
 	}
+	p.syncEvents.recordSource(&rs, newStatus.commit, rs.Status.Source.ErrorSummary.TotalCount)
 	return nil
 }
 
@@ -215,10 +236,16 @@ func setSourceStatus(source *v1beta1.SourceStatus, p Parser, newStatus sourceSta
 		source.Git = nil
 		source.Helm = nil
 	case v1beta1.HelmSource:
+		// Report the concrete chart version that helm-sync resolved, which
+		// differs from the spec when it is a version constraint or "latest".
+		version := helm.VersionFromCommit(newStatus.commit)
+		if version == "" {
+			version = p.options().SourceRev
+		}
 		source.Helm = &v1beta1.HelmStatus{
 			Repo:    p.options().SourceRepo,
 			Chart:   p.options().SyncDir.SlashPath(),
-			Version: p.options().SourceRev,
+			Version: version,
 		}
 		source.Git = nil
 		source.Oci = nil
@@ -230,6 +257,14 @@ func setSourceStatus(source *v1beta1.SourceStatus, p Parser, newStatus sourceSta
 	}
 	source.Errors = cse[0 : len(cse)/denominator]
 	source.ErrorSummary = errorSummary
+	if newStatus.verification.commit != "" {
+		source.Verification = &v1beta1.SourceVerificationStatus{
+			Signer:             newStatus.verification.signer,
+			LastVerifiedCommit: newStatus.verification.commit,
+		}
+	} else {
+		source.Verification = nil
+	}
 	source.LastUpdate = newStatus.lastUpdate
 }
 
@@ -344,25 +379,47 @@ func (p *root) setSyncStatusWithRetries(ctx context.Context, errs status.MultiEr
 
 	currentRS := rs.DeepCopy()
 
-	// syncing indicates whether the applier is syncing.
-	syncing := p.applier.Syncing()
+	setDriftStatus(&rs.Status.Status, p.remediator.DriftedResources(), p.auditOnly, denominator)
 
-	setSyncStatus(&rs.Status.Status, status.ToCSE(errs), denominator)
+	// syncCompleted indicates whether the applier completed the sync of the
+	// commit.
+	var syncCompleted bool
+	if p.dryRun {
+		plan := p.lastPlan()
+		if plan == nil {
+			// No dry run has completed yet.
+			return nil
+		}
+		setPlanStatus(&rs.Status.Status, plan, denominator)
+		errorSources, errorSummary := summarizePlanErrors(rs.Status.Source, rs.Status.Plan)
+		rootsync.SetSyncing(rs, false, "DryRun", "Dry Run Completed", rs.Status.Plan.Commit, errorSources, errorSummary, rs.Status.Plan.LastUpdate)
+	} else {
+		// The plan of a previous dry run is stale once the reconciler applies.
+		rs.Status.Plan = nil
 
-	metrics.RecordReconcilerErrors(ctx, "sync", status.ToCSE(errs))
-	metrics.RecordPipelineError(ctx, configsync.RootSyncName, "sync", rs.Status.Sync.ErrorSummary.TotalCount)
-	if !syncing {
-		metrics.RecordLastSync(ctx, rs.Status.Sync.Commit, rs.Status.Sync.LastUpdate.Time)
-	}
+		// syncing indicates whether the applier is syncing.
+		syncing := p.applier.Syncing()
+		syncCompleted = !syncing
 
-	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
-	if syncing {
-		rootsync.SetSyncing(rs, true, "Sync", "Syncing", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
-	} else {
-		if errorSummary.TotalCount == 0 {
-			rs.Status.LastSyncedCommit = rs.Status.Sync.Commit
+		setSyncStatus(&rs.Status.Status, status.ToCSE(errs), denominator)
+		setNamespaceSelectorStatus(&rs.Status.Sync, p.namespaces.Selection())
+		setSyncHistory(&rs.Status.Status, syncing)
+
+		metrics.RecordReconcilerErrors(ctx, "sync", status.ToCSE(errs))
+		metrics.RecordPipelineError(ctx, configsync.RootSyncName, "sync", rs.Status.Sync.ErrorSummary.TotalCount)
+		if !syncing {
+			metrics.RecordLastSync(ctx, p.lastTrigger, rs.Status.Sync.Commit, rs.Status.Sync.LastUpdate.Time)
+		}
+
+		errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+		if syncing {
+			rootsync.SetSyncing(rs, true, "Sync", "Syncing", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
+		} else {
+			if errorSummary.TotalCount == 0 {
+				rs.Status.LastSyncedCommit = rs.Status.Sync.Commit
+			}
+			rootsync.SetSyncing(rs, false, "Sync", "Sync Completed", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
 		}
-		rootsync.SetSyncing(rs, false, "Sync", "Sync Completed", rs.Status.Sync.Commit, errorSources, errorSummary, rs.Status.Sync.LastUpdate)
 	}
 
 	// Avoid unnecessary status updates.
@@ -384,13 +441,70 @@ func (p *root) setSyncStatusWithRetries(ctx context.Context, errs status.MultiEr
 		}
 		return status.APIServerError(err, "failed to update RootSync sync status")
 	}
+	if syncCompleted {
+		p.syncEvents.recordSync(rs, rs.Status.Sync.Commit, rs.Status.Sync.ErrorSummary.TotalCount)
+	}
 	return nil
 }
 
+// setSyncWindowStatus implements the Parser interface
+func (p *root) setSyncWindowStatus(ctx context.Context, newStatus syncWindowStatus) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var rs v1beta1.RootSync
+	if err := p.client.Get(ctx, rootsync.ObjectKey(p.syncName), &rs); err != nil {
+		return status.APIServerError(err, "failed to get RootSync for parser")
+	}
+
+	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+	rootsync.SetSyncing(&rs, false, SyncWindowReason, syncWindowMessage(newStatus), newStatus.commit, errorSources, errorSummary, newStatus.lastUpdate)
+
+	if err := p.client.Status().Update(ctx, &rs); err != nil {
+		return status.APIServerError(err, "failed to update RootSync sync window status from parser")
+	}
+	return nil
+}
+
+// setSuspendedStatus implements the Parser interface
+func (p *root) setSuspendedStatus(ctx context.Context, newStatus suspendedStatus) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var rs v1beta1.RootSync
+	if err := p.client.Get(ctx, rootsync.ObjectKey(p.syncName), &rs); err != nil {
+		return status.APIServerError(err, "failed to get RootSync for parser")
+	}
+
+	errorSources, errorSummary := summarizeErrors(rs.Status.Source, rs.Status.Sync)
+	message := suspendedMessage(newStatus, rs.Status.Sync.Commit)
+	rootsync.SetSyncing(&rs, false, SuspendedReason, message, newStatus.commit, errorSources, errorSummary, newStatus.lastUpdate)
+	rootsync.SetSuspended(&rs, SuspendedReason, message, newStatus.commit, newStatus.lastUpdate)
+
+	if err := p.client.Status().Update(ctx, &rs); err != nil {
+		return status.APIServerError(err, "failed to update RootSync suspended status from parser")
+	}
+	return nil
+}
+
+// setNamespaceSelectorStatus reports the Namespaces selected by each
+// NamespaceSelector in dynamic mode, which the objects referencing it were
+// copied into.
+func setNamespaceSelectorStatus(syncStatus *v1beta1.SyncStatus, selection []objects.NamespaceSelection) {
+	syncStatus.NamespaceSelectors = nil
+	for _, s := range selection {
+		syncStatus.NamespaceSelectors = append(syncStatus.NamespaceSelectors, v1beta1.NamespaceSelectorStatus{
+			Name:       s.Name,
+			Namespaces: s.Namespaces,
+		})
+	}
+}
+
 func setSyncStatus(syncStatus *v1beta1.Status, syncErrs []v1beta1.ConfigSyncError, denominator int) {
 	syncStatus.Sync.Commit = syncStatus.Source.Commit
 	syncStatus.Sync.Git = syncStatus.Source.Git
 	syncStatus.Sync.Oci = syncStatus.Source.Oci
+	syncStatus.Sync.Helm = syncStatus.Source.Helm
 	syncStatus.Sync.ErrorSummary = &v1beta1.ErrorSummary{
 		TotalCount: len(syncErrs),
 		Truncated:  denominator != 1,
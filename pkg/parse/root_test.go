@@ -17,17 +17,23 @@ package parse
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
 	"kpt.dev/configsync/pkg/api/configmanagement"
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
@@ -40,8 +46,11 @@ import (
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/namespaceselector"
+	"kpt.dev/configsync/pkg/rootsync"
 	"kpt.dev/configsync/pkg/status"
 	syncertest "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/syncwindow"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"kpt.dev/configsync/pkg/testing/testmetrics"
 	"sigs.k8s.io/cli-utils/pkg/common"
@@ -56,12 +65,17 @@ const (
 
 type noOpRemediator struct {
 	needsUpdate bool
+	drifted     []v1beta1.DriftedResource
 }
 
 func (r *noOpRemediator) ConflictErrors() []status.ManagementConflictError {
 	return nil
 }
 
+func (r *noOpRemediator) DriftedResources() []v1beta1.DriftedResource {
+	return r.drifted
+}
+
 func (r *noOpRemediator) NeedsUpdate() bool {
 	return r.needsUpdate
 }
@@ -451,6 +465,506 @@ func TestRoot_Parse(t *testing.T) {
 	}
 }
 
+func TestRoot_DryRun(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := &declared.Resources{}
+	fakeApplier := &fakeApplier{}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName)),
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  resources,
+				remediator: &noOpRemediator{},
+				applier:    fakeApplier,
+				dryRun:     true,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+	if err := parseAndUpdate(context.Background(), parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Plan() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+	if decls := resources.Declarations(); len(decls) != 0 {
+		t.Errorf("got %d declared resources in dry-run mode, want none so that the Remediator stays idle", len(decls))
+	}
+
+	rs := &v1beta1.RootSync{}
+	if err := parser.client.Get(context.Background(), client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Status.Plan == nil {
+		t.Fatal("got nil status.plan, want the planned changes")
+	}
+	if diff := cmp.Diff(v1beta1.PlanSummary{Create: 2}, rs.Status.Plan.Summary); diff != "" {
+		t.Errorf("status.plan.summary diff (-want +got):\n%s", diff)
+	}
+	wantChanges := []v1beta1.PlanChange{
+		{
+			Action: v1beta1.PlanActionCreate,
+			Resource: v1beta1.ResourceRef{
+				SourcePath: "namespaces/foo/role.yaml",
+				Name:       "default-name",
+				Namespace:  "foo",
+				GVK:        metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+			},
+		},
+		{
+			Action: v1beta1.PlanActionCreate,
+			Resource: v1beta1.ResourceRef{
+				Name: "foo",
+				GVK:  metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantChanges, rs.Status.Plan.Changes, cmpopts.SortSlices(func(a, b v1beta1.PlanChange) bool {
+		return a.Resource.Name < b.Resource.Name
+	})); diff != "" {
+		t.Errorf("status.plan.changes diff (-want +got):\n%s", diff)
+	}
+	if rs.Status.Sync.Commit != "" || rs.Status.LastSyncedCommit != "" {
+		t.Errorf("got sync commit %q and last synced commit %q in dry-run mode, want none", rs.Status.Sync.Commit, rs.Status.LastSyncedCommit)
+	}
+
+	// Turning off dry-run restarts the reconciler, which applies the commit
+	// and clears the plan of the last dry run.
+	parser.dryRun = false
+	parser.parser = &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}}
+	fakeApplier.got = nil
+	state = reconcilerState{}
+	if err := parseAndUpdate(context.Background(), parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+	rs = &v1beta1.RootSync{}
+	if err := parser.client.Get(context.Background(), client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Status.Plan != nil {
+		t.Errorf("got status.plan %v after turning off dry-run, want none", rs.Status.Plan)
+	}
+}
+
+func TestRoot_AuditOnly(t *testing.T) {
+	drifted := []v1beta1.DriftedResource{
+		{
+			Type: v1beta1.DriftModified,
+			Resource: v1beta1.ResourceRef{
+				SourcePath: "namespaces/foo/role.yaml",
+				Name:       "default-name",
+				Namespace:  "foo",
+				GVK:        metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+			},
+			Fields:     []string{"rules"},
+			Actor:      "kubectl-edit",
+			DetectedAt: metav1.Now(),
+		},
+	}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			syncName:       rootSyncName,
+			reconcilerName: rootReconcilerName,
+			client:         syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName)),
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{drifted: drifted},
+				applier:    &fakeApplier{},
+				auditOnly:  true,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	if err := parser.SetSyncStatus(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rs := &v1beta1.RootSync{}
+	if err := parser.client.Get(context.Background(), client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Status.Drift == nil {
+		t.Fatal("got nil status.drift, want the drifted resources")
+	}
+	if rs.Status.Drift.Count != 1 {
+		t.Errorf("got status.drift.count %d, want 1", rs.Status.Drift.Count)
+	}
+	if diff := cmp.Diff(drifted, rs.Status.Drift.Resources, cmpopts.IgnoreFields(v1beta1.DriftedResource{}, "DetectedAt")); diff != "" {
+		t.Errorf("status.drift.resources diff (-want +got):\n%s", diff)
+	}
+	if len(rs.Status.Sync.Errors) != 0 {
+		t.Errorf("got sync errors %v, want the drift to be reported separately from the errors", rs.Status.Sync.Errors)
+	}
+}
+
+func TestRoot_SyncWindows(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName))
+	// A Block window which is always active.
+	gate, err := syncwindow.NewGate([]v1beta1.SyncWindow{{
+		Type:     v1beta1.BlockSyncWindow,
+		Schedule: "* * * * *",
+		Duration: metav1.Duration{Duration: time.Hour},
+	}}, fakeClient, declared.RootReconciler, rootSyncName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeApplier := &fakeApplier{}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			syncWindows:        gate,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    fakeApplier,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+	state.cache.source.commit = "abc123"
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}
+
+	// Outside of the sync windows, the commit is parsed but not applied.
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if fakeApplier.got != nil {
+		t.Errorf("Apply() got %d objects outside of the sync windows, want none", len(fakeApplier.got))
+	}
+	if state.syncWindowStatus == nil {
+		t.Error("got no sync window status, want the commit to wait for the sync windows")
+	}
+	rs := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, key, rs); err != nil {
+		t.Fatal(err)
+	}
+	syncing := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncSyncing)
+	if syncing == nil || syncing.Reason != SyncWindowReason || syncing.Commit != "abc123" || !strings.HasPrefix(syncing.Message, WaitingForSyncWindow) {
+		t.Errorf("got Syncing condition %v, want the commit to wait for the sync windows", syncing)
+	}
+
+	// The commit is applied once it bypasses the sync windows.
+	core.SetAnnotation(rs, metadata.BypassSyncWindowAnnotationKey, "abc123")
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatal(err)
+	}
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+	if state.syncWindowStatus != nil {
+		t.Errorf("got sync window status %v, want none", state.syncWindowStatus)
+	}
+}
+
+func TestRoot_SyncWindowsSyncedCommit(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := fake.RootSyncObjectV1Beta1(rootSyncName)
+	rs.Status.Sync.Commit = "abc123"
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), rs)
+	// A Block window which is always active.
+	gate, err := syncwindow.NewGate([]v1beta1.SyncWindow{{
+		Type:     v1beta1.BlockSyncWindow,
+		Schedule: "* * * * *",
+		Duration: metav1.Duration{Duration: time.Hour},
+	}}, fakeClient, declared.RootReconciler, rootSyncName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeApplier := &fakeApplier{}
+	resources := &declared.Resources{}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			syncWindows:        gate,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  resources,
+				remediator: &noOpRemediator{},
+				applier:    fakeApplier,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+	state.cache.source.commit = "abc123"
+
+	// After a restart, the commit which is already synced is applied again
+	// outside of the sync windows, which loads its declared resources.
+	if err := parseAndUpdate(context.Background(), parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+	if state.syncWindowStatus != nil {
+		t.Errorf("got sync window status %v, want none", state.syncWindowStatus)
+	}
+	if got := len(resources.Declarations()); got != 2 {
+		t.Errorf("got %d declared resources, want 2", got)
+	}
+}
+
+func TestRoot_Suspend(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName))
+	fakeApplier := &fakeApplier{}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			suspend:            true,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    fakeApplier,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+	state.cache.source.commit = "abc123"
+	ctx := context.Background()
+
+	// While suspended, the commit is parsed but not applied.
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if fakeApplier.got != nil {
+		t.Errorf("Apply() got %d objects while suspended, want none", len(fakeApplier.got))
+	}
+	if state.suspendedStatus == nil || state.suspendedStatus.commit != "abc123" {
+		t.Errorf("got suspended status %v, want commit abc123 to be pending", state.suspendedStatus)
+	}
+	rs := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	suspended := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncSuspended)
+	if suspended == nil || suspended.Status != metav1.ConditionTrue || suspended.Commit != "abc123" {
+		t.Errorf("got Suspended condition %v, want a True condition for commit abc123", suspended)
+	}
+	if want := suspendedMessage(*state.suspendedStatus, ""); suspended != nil && suspended.Message != want {
+		t.Errorf("got Suspended message %q, want %q", suspended.Message, want)
+	}
+	syncing := rootsync.GetCondition(rs.Status.Conditions, v1beta1.RootSyncSyncing)
+	if syncing == nil || syncing.Status != metav1.ConditionFalse || syncing.Reason != SuspendedReason {
+		t.Errorf("got Syncing condition %v, want a False condition with reason %s", syncing, SuspendedReason)
+	}
+
+	// Resuming applies the commit.
+	parser.suspend = false
+	state.suspendedStatus = nil
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+}
+
+func TestRoot_SuspendSyncedCommit(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reconciler restarts while suspended, after commit abc123 is synced.
+	rsObj := fake.RootSyncObjectV1Beta1(rootSyncName)
+	rsObj.Status.Sync.Commit = "abc123"
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), rsObj)
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			suspend:            true,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    &fakeApplier{},
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+	state.cache.source.commit = "abc123"
+	ctx := context.Background()
+
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	rs := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	want := "Suspended: commit abc123 is synced, and new commits are pending until spec.suspend is unset"
+	for _, condType := range []v1beta1.RootSyncConditionType{v1beta1.RootSyncSuspended, v1beta1.RootSyncSyncing} {
+		condition := rootsync.GetCondition(rs.Status.Conditions, condType)
+		if condition == nil || condition.Message != want {
+			t.Errorf("got %s condition %v, want the message %q", condType, condition, want)
+		}
+	}
+}
+
+func TestRoot_DynamicNamespaceSelectors(t *testing.T) {
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName))
+	fakeApplier := &fakeApplier{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// No Namespace is listed from the cluster, they are all added below.
+	namespaces := namespaceselector.NewWatcher(ctx, &cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			return &metav1.PartialObjectMetadataList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	})
+	namespaces.OnAdd(&metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "dev1", Labels: map[string]string{"environment": "dev"}}})
+	namespaces.OnAdd(&metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "prod1", Labels: map[string]string{"environment": "prod"}}})
+	// The parser annotates the objects in place, so each parse gets new ones.
+	parsed := func() *fakeParser {
+		nss := fake.NamespaceSelectorObject(core.Name("dev-only"), func(o client.Object) {
+			nss := o.(*v1.NamespaceSelector)
+			nss.Spec.Selector.MatchLabels = map[string]string{"environment": "dev"}
+			nss.Spec.Mode = v1.NSSelectorDynamicMode
+		})
+		return &fakeParser{parse: []ast.FileObject{
+			fake.FileObject(nss, "dev-only.yaml"),
+			fake.Role(core.Annotation(metadata.NamespaceSelectorAnnotationKey, "dev-only")),
+		}}
+	}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             parsed(),
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			namespaces:         namespaces,
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    fakeApplier,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := reconcilerState{}
+
+	// The Role is copied into the live Namespace which matches the selector.
+	if err := parseAndUpdate(ctx, parser, triggerReimport, &state); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, obj := range fakeApplier.got {
+		if obj.GetObjectKind().GroupVersionKind() == kinds.Role() {
+			got = append(got, obj.GetNamespace())
+		}
+	}
+	if diff := cmp.Diff([]string{"dev1"}, got); diff != "" {
+		t.Errorf("got the Role in unexpected Namespaces: %s", diff)
+	}
+	rs := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+		t.Fatal(err)
+	}
+	want := []v1beta1.NamespaceSelectorStatus{{Name: "dev-only", Namespaces: []string{"dev1"}}}
+	if diff := cmp.Diff(want, rs.Status.Sync.NamespaceSelectors); diff != "" {
+		t.Errorf("got unexpected NamespaceSelector status: %s", diff)
+	}
+
+	// Relabelling a Namespace to match the selector triggers a new sync, which
+	// copies the Role into it too.
+	namespaces.OnUpdate(nil, &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "prod1", Labels: map[string]string{"environment": "dev"}}})
+	if !parser.namespacesChanged() {
+		t.Fatal("namespacesChanged() = false after a Namespace started to match the selector")
+	}
+	state.resetAllButSourceState()
+	parser.parser = parsed()
+	if err := parseAndUpdate(ctx, parser, triggerNamespaceUpdate, &state); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, obj := range fakeApplier.got {
+		if obj.GetObjectKind().GroupVersionKind() == kinds.Role() {
+			got = append(got, obj.GetNamespace())
+		}
+	}
+	if diff := cmp.Diff([]string{"dev1", "prod1"}, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("got the Role in unexpected Namespaces: %s", diff)
+	}
+}
+
 func TestRoot_ParseErrorsMetricValidation(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -688,6 +1202,18 @@ func (a *fakeApplier) Syncing() bool {
 	return false
 }
 
+func (a *fakeApplier) Plan(_ context.Context, objs []client.Object) ([]applier.PlannedChange, status.MultiError) {
+	if a.errors != nil {
+		return nil, a.Errors()
+	}
+	a.got = objs
+	var changes []applier.PlannedChange
+	for _, obj := range objs {
+		changes = append(changes, applier.PlannedChange{Action: v1beta1.PlanActionCreate, Object: obj})
+	}
+	return changes, nil
+}
+
 func TestSummarizeErrors(t *testing.T) {
 	testCases := []struct {
 		name                 string
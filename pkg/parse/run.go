@@ -16,11 +16,13 @@ package parse
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
@@ -36,6 +38,20 @@ const (
 	triggerRetry              = "retry"
 	triggerManagementConflict = "managementConflict"
 	triggerWatchUpdate        = "watchUpdate"
+	triggerPush               = "push"
+	triggerNamespaceUpdate    = "namespaceUpdate"
+	triggerSyncWindow         = "syncWindow"
+)
+
+const (
+	// pushPollInterval is the interval at which the configuration is
+	// re-imported after a sync trigger, until the source container has fetched
+	// the new commit.
+	pushPollInterval = time.Second
+
+	// pushPollTimeout is how long the configuration is re-imported after a
+	// sync trigger, if the source container fetches no new commit.
+	pushPollTimeout = time.Minute
 )
 
 const (
@@ -52,14 +68,51 @@ const (
 	RenderingSkipped string = "Rendering skipped"
 )
 
+const (
+	// SyncWindowReason is the reason of the Syncing condition of a commit
+	// waiting for the sync windows to open.
+	SyncWindowReason = "SyncWindow"
+
+	// WaitingForSyncWindow means that the commit is only applied when the sync
+	// windows open.
+	WaitingForSyncWindow = "Waiting for sync window"
+
+	// SuspendedReason is the reason of the Suspended and Syncing conditions of
+	// a suspended RootSync or RepoSync.
+	SuspendedReason = "Suspended"
+)
+
 // Run keeps checking whether a parse-apply-watch loop is necessary and starts a loop if needed.
-func Run(ctx context.Context, p Parser) {
+// syncTrigger delivers the requests accepted by the sync trigger endpoint, such
+// as push webhooks. It may be nil if the endpoint is disabled.
+func Run(ctx context.Context, p Parser, syncTrigger <-chan struct{}) {
 	opts := p.options()
 	tickerPoll := time.NewTicker(opts.pollingFrequency)
 	tickerResync := time.NewTicker(opts.resyncPeriod)
 	tickerRetryOrWatchUpdate := time.NewTicker(time.Second)
 	state := &reconcilerState{}
+	// pushPoll fires while the source container fetches the commit of the last
+	// sync trigger. It is nil, which blocks forever, otherwise.
+	var pushPoll <-chan time.Time
+	var pushSyncDir cmpath.Absolute
+	var pushDeadline time.Time
+	// syncWindowTimer fires when the sync windows open for the commit waiting
+	// for them, if any.
+	var syncWindowTimer *time.Timer
+	var syncWindowOpen <-chan time.Time
+	var syncWindowNext time.Time
 	for {
+		if next := nextSyncWindow(state); !next.Equal(syncWindowNext) {
+			if syncWindowTimer != nil {
+				syncWindowTimer.Stop()
+			}
+			syncWindowTimer, syncWindowOpen, syncWindowNext = nil, nil, next
+			if !next.IsZero() {
+				syncWindowTimer = time.NewTimer(time.Until(next))
+				syncWindowOpen = syncWindowTimer.C
+			}
+		}
+
 		select {
 		case <-ctx.Done():
 			return
@@ -77,6 +130,24 @@ func Run(ctx context.Context, p Parser) {
 		case <-tickerPoll.C:
 			run(ctx, p, triggerReimport, state)
 
+		// a push webhook or a "sync now" call requested to re-import the configuration
+		// without waiting for the next polling period
+		case <-syncTrigger:
+			klog.Infof("A sync was triggered")
+			pushSyncDir = state.cache.source.syncDir
+			pushDeadline = time.Now().Add(pushPollTimeout)
+			pushPoll = runPush(ctx, p, state, pushSyncDir, pushDeadline)
+
+		// the source container may not have fetched the commit of the last sync trigger yet
+		case <-pushPoll:
+			pushPoll = runPush(ctx, p, state, pushSyncDir, pushDeadline)
+
+		// the sync windows opened for the commit waiting for them
+		case <-syncWindowOpen:
+			klog.Infof("The sync windows opened")
+			syncWindowTimer, syncWindowOpen = nil, nil
+			run(ctx, p, triggerSyncWindow, state)
+
 		// it is time to check whether the last parse-apply-watch loop failed or any watches need to be updated
 		case <-tickerRetryOrWatchUpdate.C:
 			var trigger string
@@ -87,6 +158,11 @@ func Run(ctx context.Context, p Parser) {
 				trigger = triggerManagementConflict
 				// When conflict is detected, wait longer (same as the polling frequency) for the next retry.
 				time.Sleep(opts.pollingFrequency)
+			} else if opts.namespacesChanged() {
+				klog.Infof("The Namespaces selected by the NamespaceSelectors changed")
+				// Reset the cache so that the objects are selected again.
+				state.resetAllButSourceState()
+				trigger = triggerNamespaceUpdate
 			} else if state.cache.needToRetry && state.cache.readyToRetry() {
 				klog.Infof("The last reconciliation failed")
 				trigger = triggerRetry
@@ -101,6 +177,24 @@ func Run(ctx context.Context, p Parser) {
 	}
 }
 
+// runPush re-imports the configuration after a sync trigger. The source
+// container fetches the new commit asynchronously, either once it is woken, or
+// on its own period for git-sync, so the new commit may not be fetched yet.
+// runPush returns the channel on which to re-import the configuration again, or
+// nil once a source other than syncDir has been read, or the deadline has
+// passed.
+func runPush(ctx context.Context, p Parser, state *reconcilerState, syncDir cmpath.Absolute, deadline time.Time) <-chan time.Time {
+	run(ctx, p, triggerPush, state)
+	if newSyncDir := state.cache.source.syncDir; newSyncDir != "" && newSyncDir != syncDir {
+		return nil
+	}
+	if time.Now().After(deadline) {
+		klog.Infof("No new source changes were fetched within %v of the sync trigger", pushPollTimeout)
+		return nil
+	}
+	return time.After(pushPollInterval)
+}
+
 func run(ctx context.Context, p Parser, trigger string, state *reconcilerState) {
 	p.SetReconciling(true)
 	defer func() {
@@ -111,6 +205,17 @@ func run(ctx context.Context, p Parser, trigger string, state *reconcilerState)
 	gs := sourceStatus{}
 	gs.commit, syncDir, gs.errs = hydrate.SourceCommitAndDir(p.options().SourceType, p.options().SourceDir, p.options().SyncDir, p.options().reconcilerName)
 
+	// Only sync the commit if it is signed by a trusted key. Otherwise, the
+	// source error keeps the last verified commit synced.
+	if gs.errs == nil && p.options().SourceType == v1beta1.GitSource && p.options().GitVerificationKeysDir != "" {
+		var signer string
+		signer, gs.errs = p.options().verifyCommit(gs.commit)
+		if gs.errs == nil {
+			state.verification = sourceVerification{signer: signer, commit: gs.commit}
+		}
+	}
+	gs.verification = state.verification
+
 	// If failed to fetch the source commit and directory, set `.status.source` to fail early.
 	// Otherwise, set `.status.rendering` before `.status.source` because the parser needs to
 	// read and parse the configs after rendering is done and there might have errors.
@@ -174,13 +279,14 @@ func run(ctx context.Context, p Parser, trigger string, state *reconcilerState)
 	}
 
 	newSyncDir := state.cache.source.syncDir
-	// The parse-apply-watch sequence will be skipped if the trigger type is `triggerReimport` and
-	// there is no new source changes. The reasons are:
+	// The parse-apply-watch sequence will be skipped if the trigger type is `triggerReimport` or
+	// `triggerPush` and there is no new source changes. The reasons are:
 	//   * If a former parse-apply-watch sequence for syncDir succeeded, there is no need to run the sequence again;
 	//   * If all the former parse-apply-watch sequences for syncDir failed, the next retry will call the sequence;
 	//   * The retry logic tracks the number of reconciliation attempts failed with the same errors, and when
 	//     the next retry should happen. Calling the parse-apply-watch sequence here makes the retry logic meaningless.
-	if trigger == triggerReimport && oldSyncDir == newSyncDir {
+	// A commit waiting for the sync windows to open is applied when they open, or as soon as it bypasses them.
+	if (trigger == triggerReimport || trigger == triggerPush) && oldSyncDir == newSyncDir && !syncWindowAllows(ctx, p, state) {
 		return
 	}
 
@@ -190,6 +296,12 @@ func run(ctx context.Context, p Parser, trigger string, state *reconcilerState)
 		return
 	}
 
+	// The commit waiting for the sync windows to open, or of a suspended
+	// reconciler, is not applied yet.
+	if state.syncWindowStatus != nil || state.suspendedStatus != nil {
+		return
+	}
+
 	// Only checkpoint the state after *everything* succeeded, including status update.
 	state.checkpoint()
 }
@@ -241,7 +353,8 @@ func readFromSource(ctx context.Context, p Parser, trigger string, state *reconc
 		commit: sourceState.commit,
 	}
 	sourceStatus := sourceStatus{
-		commit: sourceState.commit,
+		commit:       sourceState.commit,
+		verification: state.verification,
 	}
 
 	// Check if the hydratedRoot directory exists.
@@ -319,9 +432,10 @@ func parseSource(ctx context.Context, p Parser, trigger string, state *reconcile
 func parseAndUpdate(ctx context.Context, p Parser, trigger string, state *reconcilerState) status.MultiError {
 	sourceErrs := parseSource(ctx, p, trigger, state)
 	newSourceStatus := sourceStatus{
-		commit:     state.cache.source.commit,
-		errs:       sourceErrs,
-		lastUpdate: metav1.Now(),
+		commit:       state.cache.source.commit,
+		errs:         sourceErrs,
+		verification: state.verification,
+		lastUpdate:   metav1.Now(),
 	}
 	if state.needToSetSourceStatus(newSourceStatus) {
 		if err := p.setSourceStatus(ctx, newSourceStatus); err != nil {
@@ -338,11 +452,51 @@ func parseAndUpdate(ctx context.Context, p Parser, trigger string, state *reconc
 		return sourceErrs
 	}
 
+	// While suspended, the commit is parsed and validated, but it is only
+	// applied once the reconciler resumes.
+	if p.options().suspend {
+		newSuspendedStatus := suspendedStatus{
+			commit:     state.cache.source.commit,
+			lastUpdate: metav1.Now(),
+		}
+		if state.needToSetSuspendedStatus(newSuspendedStatus) {
+			klog.Infof("Commit %s is not applied while the reconciler is suspended", newSuspendedStatus.commit)
+			if err := p.setSuspendedStatus(ctx, newSuspendedStatus); err != nil {
+				return status.Append(sourceErrs, err)
+			}
+			state.suspendedStatus = &newSuspendedStatus
+		}
+		return sourceErrs
+	}
+
+	// Outside of the sync windows, a new commit is parsed and validated, but it
+	// is only applied when the windows open. The commit which is already synced
+	// is always applied, so that its declared resources are loaded and its drift
+	// is remediated after a restart.
+	if allowed, next := p.options().syncWindows.Allows(ctx, state.cache.source.commit, time.Now()); !allowed {
+		newSyncWindowStatus := syncWindowStatus{
+			commit:     state.cache.source.commit,
+			next:       next,
+			lastUpdate: metav1.Now(),
+		}
+		if state.needToSetSyncWindowStatus(newSyncWindowStatus) {
+			klog.Infof("Commit %s is waiting for the sync windows to open", newSyncWindowStatus.commit)
+			if err := p.setSyncWindowStatus(ctx, newSyncWindowStatus); err != nil {
+				return status.Append(sourceErrs, err)
+			}
+			state.syncWindowStatus = &newSyncWindowStatus
+		}
+		return sourceErrs
+	}
+	state.syncWindowStatus = nil
+
 	// Create a new context with its cancellation function.
 	ctxForUpdateSyncStatus, cancel := context.WithCancel(context.Background())
 
 	go updateSyncStatus(ctxForUpdateSyncStatus, p)
 
+	p.options().setLastTrigger(trigger)
+
 	start := time.Now()
 	syncErrs := p.options().update(ctx, &state.cache)
 	metrics.RecordParserDuration(ctx, trigger, "update", metrics.StatusTagKey(syncErrs), start)
@@ -367,6 +521,46 @@ func parseAndUpdate(ctx context.Context, p Parser, trigger string, state *reconc
 	return status.Append(sourceErrs, syncErrs)
 }
 
+// nextSyncWindow returns when the sync windows open for the commit waiting for
+// them, or the zero time if no commit is waiting or they do not open within a
+// year.
+func nextSyncWindow(state *reconcilerState) time.Time {
+	if state.syncWindowStatus == nil {
+		return time.Time{}
+	}
+	return state.syncWindowStatus.next
+}
+
+// syncWindowAllows returns true if a commit is waiting for the sync windows to
+// open, and may now be applied.
+func syncWindowAllows(ctx context.Context, p Parser, state *reconcilerState) bool {
+	if state.syncWindowStatus == nil {
+		return false
+	}
+	allowed, _ := p.options().syncWindows.Allows(ctx, state.syncWindowStatus.commit, time.Now())
+	return allowed
+}
+
+// syncWindowMessage returns the message of the Syncing condition of a commit
+// waiting for the sync windows to open.
+func syncWindowMessage(ws syncWindowStatus) string {
+	if ws.next.IsZero() {
+		return fmt.Sprintf("%s: commit %s is pending, and no sync window opens within a year", WaitingForSyncWindow, ws.commit)
+	}
+	return fmt.Sprintf("%s: commit %s is pending until the next window starts at %s", WaitingForSyncWindow, ws.commit, ws.next.UTC().Format(time.RFC3339))
+}
+
+// suspendedMessage returns the message of the Suspended and Syncing conditions
+// of a suspended RootSync or RepoSync. The commit is only reported as pending
+// if it differs from syncedCommit, which is already synced, e.g. when the
+// reconciler restarts while suspended.
+func suspendedMessage(ss suspendedStatus, syncedCommit string) string {
+	if ss.commit == syncedCommit {
+		return fmt.Sprintf("%s: commit %s is synced, and new commits are pending until spec.suspend is unset", SuspendedReason, ss.commit)
+	}
+	return fmt.Sprintf("%s: commit %s is pending until spec.suspend is unset", SuspendedReason, ss.commit)
+}
+
 // updateSyncStatus update the sync status periodically until the cancellation function of the context is called.
 func updateSyncStatus(ctx context.Context, p Parser) {
 	ticker := time.NewTicker(5 * time.Second)
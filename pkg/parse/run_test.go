@@ -15,13 +15,29 @@
 package parse
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/filesystem"
+	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
+	syncertest "kpt.dev/configsync/pkg/syncer/syncertest/fake"
 	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestSplitObjects(t *testing.T) {
@@ -75,3 +91,261 @@ func TestSplitObjects(t *testing.T) {
 		})
 	}
 }
+
+// checkout points the source link of repoRoot at the commit and marks it as
+// rendered, as git-sync and the hydration controller do.
+func checkout(t *testing.T, repoRoot, commit string) {
+	t.Helper()
+	link := filepath.Join(repoRoot, "source", "rev")
+	if err := os.Symlink(filepath.Join(repoRoot, "source", commit), link+".tmp"); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := os.Rename(link+".tmp", link); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, hydrate.DoneFile), []byte(commit), 0644); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunPush(t *testing.T) {
+	repoRoot := t.TempDir()
+	for _, commit := range []string{"commit1", "commit2"} {
+		if err := os.MkdirAll(filepath.Join(repoRoot, "source", commit), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoRoot, "source", commit, "role.yaml"), []byte("kind: Role\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkout(t, repoRoot, "commit1")
+
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName))
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			pollingFrequency:   time.Hour,
+			resyncPeriod:       time.Hour,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			files: files{FileSource: FileSource{
+				SourceDir:    cmpath.Absolute(filepath.Join(repoRoot, "source", "rev")),
+				RepoRoot:     cmpath.Absolute(repoRoot),
+				HydratedRoot: filepath.Join(repoRoot, "hydrated"),
+				SyncDir:      cmpath.RelativeOS("."),
+				SourceType:   v1beta1.GitSource,
+			}},
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: &noOpRemediator{},
+				applier:    &fakeApplier{},
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := &reconcilerState{}
+	ctx := context.Background()
+	// push runs the sync trigger as Run does, until runPush stops polling.
+	push := func() time.Duration {
+		start := time.Now()
+		syncDir := state.cache.source.syncDir
+		deadline := start.Add(pushPollTimeout)
+		for c := runPush(ctx, parser, state, syncDir, deadline); c != nil; c = runPush(ctx, parser, state, syncDir, deadline) {
+			<-c
+		}
+		return time.Since(start)
+	}
+	syncedCommit := func() string {
+		rs := &v1beta1.RootSync{}
+		if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+			t.Fatal(err)
+		}
+		return rs.Status.Sync.Commit
+	}
+
+	push()
+	if got := syncedCommit(); got != "commit1" {
+		t.Fatalf("got synced commit %q, want commit1", got)
+	}
+
+	// Parsing annotates the returned objects, so return new ones for the next
+	// commit.
+	parser.parser = &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}}
+	// The source container fetches the pushed commit some time after it is
+	// woken. The reconciler must keep polling until then, rather than wait for
+	// its polling period.
+	go func() {
+		time.Sleep(2 * pushPollInterval)
+		checkout(t, repoRoot, "commit2")
+	}()
+	if elapsed := push(); elapsed >= pushPollTimeout {
+		t.Errorf("the push was synced after %v, want it synced before the timeout", elapsed)
+	}
+	if got := syncedCommit(); got != "commit2" {
+		t.Errorf("got synced commit %q, want commit2", got)
+	}
+}
+
+func TestRunResync_AuditOnly(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoRoot, "source", "commit1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	checkout(t, repoRoot, "commit1")
+
+	converter, err := declared.ValueConverterForTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClient := syncertest.NewClient(t, runtime.NewScheme(), fake.RootSyncObjectV1Beta1(rootSyncName))
+	fakeApplier := &fakeApplier{}
+	remediator := &noOpRemediator{}
+	parser := &root{
+		sourceFormat: filesystem.SourceFormatUnstructured,
+		opts: opts{
+			parser:             &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}},
+			syncName:           rootSyncName,
+			reconcilerName:     rootReconcilerName,
+			client:             fakeClient,
+			discoveryInterface: syncertest.NewDiscoveryClient(kinds.Namespace(), kinds.Role()),
+			converter:          converter,
+			files: files{FileSource: FileSource{
+				SourceDir:    cmpath.Absolute(filepath.Join(repoRoot, "source", "rev")),
+				RepoRoot:     cmpath.Absolute(repoRoot),
+				HydratedRoot: filepath.Join(repoRoot, "hydrated"),
+				SyncDir:      cmpath.RelativeOS("."),
+				SourceType:   v1beta1.GitSource,
+			}},
+			updater: updater{
+				scope:      declared.RootReconciler,
+				resources:  &declared.Resources{},
+				remediator: remediator,
+				applier:    fakeApplier,
+				auditOnly:  true,
+				reader:     fakeClient,
+				planMux:    &sync.Mutex{},
+			},
+			mux: &sync.Mutex{},
+		},
+	}
+	state := &reconcilerState{}
+	ctx := context.Background()
+	getRootSync := func() *v1beta1.RootSync {
+		rs := &v1beta1.RootSync{}
+		if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: configsync.ControllerNamespace, Name: rootSyncName}, rs); err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+
+	run(ctx, parser, triggerReimport, state)
+	if len(fakeApplier.got) != 2 {
+		t.Fatalf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+
+	// The Role is modified on the cluster and its Namespace is deleted, and the
+	// remediator records the drift instead of correcting it.
+	if err := fakeClient.Create(ctx, fake.RoleObject(core.Namespace("foo"), core.Label("edited", "true"))); err != nil {
+		t.Fatal(err)
+	}
+	remediator.drifted = []v1beta1.DriftedResource{
+		{
+			Type: v1beta1.DriftModified,
+			Resource: v1beta1.ResourceRef{
+				Name:      "default-name",
+				Namespace: "foo",
+				GVK:       metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+			},
+			Fields: []string{"metadata.labels"},
+		},
+		{
+			Type: v1beta1.DriftDeleted,
+			Resource: v1beta1.ResourceRef{
+				Name: "foo",
+				GVK:  metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			},
+		},
+	}
+	fakeApplier.got = nil
+	// Parsing annotates the returned objects, so return new ones for every
+	// resync.
+	parser.parser = &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}}
+
+	// The periodic resync applies the commit, but must not revert the drift:
+	// the Role is applied as it is on the cluster, and the Namespace is not
+	// created again.
+	state.resetAllButSourceState()
+	run(ctx, parser, triggerResync, state)
+	if len(fakeApplier.got) != 1 {
+		t.Fatalf("Apply() got %d objects while the drift is recorded, want only the drifted Role", len(fakeApplier.got))
+	}
+	if got := fakeApplier.got[0]; got.GetObjectKind().GroupVersionKind() != kinds.Role() || got.GetLabels()["edited"] != "true" {
+		t.Errorf("Apply() got %v, want the Role as it is on the cluster", got)
+	}
+	if got := fakeApplier.got[0]; got.GetResourceVersion() != "" {
+		t.Errorf("Apply() got resourceVersion %q, want it cleared", got.GetResourceVersion())
+	}
+	rs := getRootSync()
+	if rs.Status.Drift == nil || rs.Status.Drift.Count != 2 {
+		t.Errorf("got status.drift %v, want the drift to survive the resync", rs.Status.Drift)
+	}
+	if len(rs.Status.Sync.Errors) != 0 {
+		t.Errorf("got sync errors %v, want the drift to be reported separately from the errors", rs.Status.Sync.Errors)
+	}
+
+	// Once the drift is resolved, the commit is applied again.
+	remediator.drifted = nil
+	parser.parser = &fakeParser{parse: []ast.FileObject{fake.Role(core.Namespace("foo"))}}
+	state.resetAllButSourceState()
+	run(ctx, parser, triggerResync, state)
+	if len(fakeApplier.got) != 2 {
+		t.Errorf("Apply() got %d objects, want the Role and its implicit Namespace", len(fakeApplier.got))
+	}
+}
+
+func TestVerifyCommitCache(t *testing.T) {
+	keysDir := t.TempDir()
+	keysFile := filepath.Join(keysDir, "allowed_signers")
+	if err := os.WriteFile(keysFile, []byte("# nobody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commit := "1234567890123456789012345678901234567890"
+	o := &files{FileSource: FileSource{
+		SourceDir:              cmpath.Absolute(filepath.Join(t.TempDir(), "rev")),
+		GitVerificationKeysDir: keysDir,
+	}}
+	if _, err := o.verifyCommit(commit); err == nil {
+		t.Fatal("verifyCommit() got no error without trusted keys")
+	}
+
+	// The result of the same commit with the same keys is cached.
+	o.lastVerification.signer, o.lastVerification.err = "cached", nil
+	if signer, err := o.verifyCommit(commit); signer != "cached" || err != nil {
+		t.Errorf("verifyCommit() got (%q, %v), want the cached result", signer, err)
+	}
+	// Another commit is verified.
+	if _, err := o.verifyCommit("abcdefabcdefabcdefabcdefabcdefabcdefabcd"); err == nil {
+		t.Error("verifyCommit() got no error for another commit without trusted keys")
+	}
+
+	// The same commit is verified again once the keys changed.
+	o.lastVerification = &commitVerification{commit: commit, keysVersion: keysVersion(keysDir), signer: "cached"}
+	if err := os.WriteFile(keysFile, []byte("# still nobody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.verifyCommit(commit); err == nil {
+		t.Error("verifyCommit() got no error after the keys changed")
+	}
+}
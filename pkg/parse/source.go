@@ -27,6 +27,7 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/hydrate"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
+	"kpt.dev/configsync/pkg/importer/git"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"kpt.dev/configsync/pkg/status"
@@ -52,6 +53,9 @@ type FileSource struct {
 	SourceBranch string
 	// SourceRev is the revision of the source repo to sync.
 	SourceRev string
+	// GitVerificationKeysDir is the directory holding the keys trusted to sign
+	// the git commits. If empty, the commits are not verified.
+	GitVerificationKeysDir string
 }
 
 // files lists files in a repository and ensures the source repository hasn't been
@@ -62,6 +66,73 @@ type files struct {
 	// currentSyncDir is the directory (including git commit hash or OCI image digest)
 	// last seen by the Parser.
 	currentSyncDir string
+
+	// lastVerification is the result of the last commit verification.
+	lastVerification *commitVerification
+}
+
+// commitVerification is the result of the verification of a commit with a
+// version of the trusted keys.
+type commitVerification struct {
+	commit      string
+	keysVersion string
+	signer      string
+	err         status.Error
+}
+
+// verifyCommit checks that the git commit is signed by one of the keys in
+// GitVerificationKeysDir, and returns the signer. The result is cached until
+// either the commit or the keys change, so that rotating the keys does not
+// require a restart, while the polling and retries do not verify the same
+// commit again.
+func (o *files) verifyCommit(commit string) (string, status.Error) {
+	version := keysVersion(o.GitVerificationKeysDir)
+	if last := o.lastVerification; last != nil && last.commit == commit && version != "" && last.keysVersion == version {
+		return last.signer, last.err
+	}
+	signer, err := o.verifyCommitWithKeys(commit)
+	o.lastVerification = &commitVerification{commit: commit, keysVersion: version, signer: signer, err: err}
+	return signer, err
+}
+
+// keysVersion returns an identifier of the content of the keys directory, or
+// an empty string if it can not be read. Kubernetes updates the files of a
+// mounted Secret by swapping the target of its ..data symbolic link.
+// Otherwise, the names, sizes and modification times of the files are used.
+func keysVersion(dir string) string {
+	if target, err := os.Readlink(filepath.Join(dir, "..data")); err == nil {
+		return target
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	version := ""
+	for _, entry := range entries {
+		version += fmt.Sprintf("%s:%d:%d;", entry.Name(), entry.Size(), entry.ModTime().UnixNano())
+	}
+	return version
+}
+
+// verifyCommitWithKeys loads the keys in GitVerificationKeysDir and checks
+// that the git commit is signed by one of them.
+func (o *files) verifyCommitWithKeys(commit string) (string, status.Error) {
+	verifier, err := git.NewVerifier(o.GitVerificationKeysDir)
+	if err != nil {
+		return "", status.SourceError.Wrap(err).Sprint("unable to load the keys trusted to sign the commits").Build()
+	}
+	// git-sync checks out every commit in a worktree named after the commit,
+	// next to the symbolic link of the source repository.
+	worktree := filepath.Join(filepath.Dir(o.SourceDir.OSPath()), commit)
+	signer, err := verifier.Verify(worktree, commit)
+	if err != nil {
+		var verr *git.VerificationError
+		if errors.As(err, &verr) {
+			return "", status.SourceVerificationError.Wrap(err).Sprint("unable to verify the source").Build()
+		}
+		return "", status.SourceError.Wrap(err).Sprint("unable to verify the source").Build()
+	}
+	return signer, nil
 }
 
 // sourceState contains all state read from the mounted source repo.
@@ -100,8 +171,8 @@ func (o *files) readConfigFiles(state *sourceState) status.Error {
 	return nil
 }
 
-func (o *files) sourceContext() sourceContext {
-	return sourceContext{
+func (o *files) sourceContext() SourceContext {
+	return SourceContext{
 		Repo:   o.SourceRepo,
 		Branch: o.SourceBranch,
 		Rev:    o.SourceRev,
@@ -178,8 +249,8 @@ func hydratedError(errorFile, label string) hydrate.HydrationError {
 	if err := json.Unmarshal(content, payload); err != nil {
 		return hydrate.NewInternalError(err)
 	}
-	if payload.Code == status.ActionableHydrationErrorCode {
-		return hydrate.NewActionableError(errors.New(payload.Error))
+	if status.IsActionableHydrationErrorCode(payload.Code) {
+		return hydrate.NewActionableErrorWithCode(payload.Code, errors.New(payload.Error))
 	}
 	return hydrate.NewInternalError(errors.New(payload.Error))
 }
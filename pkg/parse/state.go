@@ -29,13 +29,40 @@ const (
 )
 
 type sourceStatus struct {
-	commit     string
-	errs       status.MultiError
-	lastUpdate metav1.Time
+	commit       string
+	errs         status.MultiError
+	verification sourceVerification
+	lastUpdate   metav1.Time
 }
 
 func (gs sourceStatus) equal(other sourceStatus) bool {
-	return gs.commit == other.commit && status.DeepEqual(gs.errs, other.errs)
+	return gs.commit == other.commit && gs.verification == other.verification && status.DeepEqual(gs.errs, other.errs)
+}
+
+// sourceVerification describes the signature of the last verified commit.
+type sourceVerification struct {
+	signer string
+	commit string
+}
+
+// syncWindowStatus describes a commit waiting for the sync windows to open.
+type syncWindowStatus struct {
+	commit string
+	// next is when the sync windows open next, or the zero time if they do not
+	// open within a year.
+	next       time.Time
+	lastUpdate metav1.Time
+}
+
+func (ws syncWindowStatus) equal(other syncWindowStatus) bool {
+	return ws.commit == other.commit && ws.next.Equal(other.next)
+}
+
+// suspendedStatus describes the commit which a suspended reconciler does not
+// apply.
+type suspendedStatus struct {
+	commit     string
+	lastUpdate metav1.Time
 }
 
 type renderingStatus struct {
@@ -56,12 +83,24 @@ type reconcilerState struct {
 	// sourceStatus tracks info from the `Status.Source` field of a RepoSync/RootSync.
 	sourceStatus
 
+	// verification tracks the signature of the last verified commit, which
+	// is reported in the `Status.Source` field of a RepoSync/RootSync.
+	verification sourceVerification
+
 	// renderingStatus tracks info from the `Status.Rendering` field of a RepoSync/RootSync.
 	renderingStatus
 
 	// syncStatus tracks info from the `Status.Sync` field of a RepoSync/RootSync.
 	syncStatus sourceStatus
 
+	// syncWindowStatus tracks the commit waiting for the sync windows to open,
+	// if any.
+	syncWindowStatus *syncWindowStatus
+
+	// suspendedStatus tracks the commit which is not applied while the
+	// reconciler is suspended, if any.
+	suspendedStatus *suspendedStatus
+
 	// syncingConditionLastUpdate tracks when the `Syncing` condition was updated most recently.
 	syncingConditionLastUpdate metav1.Time
 
@@ -151,6 +190,16 @@ func (s *reconcilerState) needToSetSourceStatus(newStatus sourceStatus) bool {
 	return !newStatus.equal(s.sourceStatus) || s.sourceStatus.lastUpdate.IsZero() || s.sourceStatus.lastUpdate.Before(&s.syncingConditionLastUpdate)
 }
 
+// needToSetSyncWindowStatus returns true if `p.setSyncWindowStatus` should be called.
+func (s *reconcilerState) needToSetSyncWindowStatus(newStatus syncWindowStatus) bool {
+	return s.syncWindowStatus == nil || !newStatus.equal(*s.syncWindowStatus) || s.syncWindowStatus.lastUpdate.Before(&s.syncingConditionLastUpdate)
+}
+
+// needToSetSuspendedStatus returns true if `p.setSuspendedStatus` should be called.
+func (s *reconcilerState) needToSetSuspendedStatus(newStatus suspendedStatus) bool {
+	return s.suspendedStatus == nil || newStatus.commit != s.suspendedStatus.commit || s.suspendedStatus.lastUpdate.Before(&s.syncingConditionLastUpdate)
+}
+
 // needToSetSyncStatus returns true if `p.SetSyncStatus` should be called.
 func (s *reconcilerState) needToSetSyncStatus(newStatus sourceStatus) bool {
 	return !newStatus.equal(s.syncStatus) || s.syncStatus.lastUpdate.IsZero() || s.syncStatus.lastUpdate.Before(&s.syncingConditionLastUpdate)
@@ -16,12 +16,18 @@ package parse
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
+	configsyncv1beta1 "kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/kinds"
@@ -29,6 +35,7 @@ import (
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/util/clusterconfig"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // updater mutates the most-recently-seen versions of objects stored in memory.
@@ -37,6 +44,32 @@ type updater struct {
 	resources  *declared.Resources
 	remediator remediator.Interface
 	applier    applier.Interface
+
+	// dryRun indicates that the updater only computes the changes which the
+	// applier would make, instead of applying the resources.
+	dryRun bool
+	// auditOnly indicates that the remediator only records the drift of the
+	// managed resources instead of correcting it, and that the applier leaves
+	// the drifted resources as they are on the cluster.
+	auditOnly bool
+	// reader reads the drifted resources from the cluster in audit-only mode.
+	reader client.Reader
+	// plan is the result of the last dry run, or nil if no dry run has
+	// completed yet. It is guarded by planMux.
+	plan    *planResult
+	planMux *sync.Mutex
+}
+
+func (u *updater) setPlan(plan *planResult) {
+	u.planMux.Lock()
+	defer u.planMux.Unlock()
+	u.plan = plan
+}
+
+func (u *updater) lastPlan() *planResult {
+	u.planMux.Lock()
+	defer u.planMux.Unlock()
+	return u.plan
 }
 
 func (u *updater) needToUpdateWatch() bool {
@@ -70,10 +103,14 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 	var errs status.MultiError
 	objs := filesystem.AsCoreObjects(cache.objsToApply)
 
+	if u.dryRun {
+		return u.planUpdate(ctx, cache.source.commit, objs)
+	}
+
 	// Update the declared resources so that the Remediator immediately
 	// starts enforcing the updated state.
 	if !cache.resourceDeclSetUpdated {
-		objs, err := u.resources.Update(ctx, objs)
+		objs, err := u.resources.Update(ctx, objs, cache.source.commit)
 		metrics.RecordDeclaredResources(ctx, len(objs))
 		if err != nil {
 			klog.Infof("Terminate the reconciliation (failed to update the declared resources): %v", err)
@@ -89,6 +126,19 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 	if cache.hasApplierResult {
 		gvks = cache.applierResult
 	} else {
+		// In audit-only mode, applying the declarations of the drifted objects
+		// would revert the drift which the remediator only records. The commit
+		// is applied with the drifted objects left as they are on the cluster.
+		if u.auditOnly {
+			if drifted := u.remediator.DriftedResources(); len(drifted) > 0 {
+				var err status.Error
+				objs, err = u.skipDrifted(ctx, objs, drifted)
+				if err != nil {
+					klog.Infof("Terminate the reconciliation (failed to read the drifted resources): %v", err)
+					return status.Append(errs, err)
+				}
+			}
+		}
 		var applyErrs status.MultiError
 		applyStart := time.Now()
 		// TODO: This will show users a transient error if they apply a
@@ -97,8 +147,11 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 		//  path.
 		gvks, applyErrs = u.applier.Apply(ctx, objs)
 		metrics.RecordApplyDuration(ctx, metrics.StatusTagKey(applyErrs), cache.source.commit, applyStart)
-		if applyErrs == nil && cache.parserErrs == nil {
-			cache.setApplierResult(gvks)
+		if applyErrs == nil {
+			u.resources.MarkApplied()
+			if cache.parserErrs == nil {
+				cache.setApplierResult(gvks)
+			}
 		}
 		errs = status.Append(errs, applyErrs)
 	}
@@ -109,3 +162,66 @@ func (u *updater) update(ctx context.Context, cache *cacheForCommit) status.Mult
 
 	return errs
 }
+
+// skipDrifted returns objs with the declarations of the drifted objects
+// replaced by their state on the cluster, so that applying them neither reverts
+// their drift nor prunes them. The drifted objects which are missing from the
+// cluster are not applied, so that they are not created again.
+func (u *updater) skipDrifted(ctx context.Context, objs []client.Object, drifted []configsyncv1beta1.DriftedResource) ([]client.Object, status.Error) {
+	driftedIDs := make(map[core.ID]configsyncv1beta1.DriftType, len(drifted))
+	for _, d := range drifted {
+		id := core.ID{
+			GroupKind: schema.GroupKind{Group: d.Resource.GVK.Group, Kind: d.Resource.GVK.Kind},
+			ObjectKey: client.ObjectKey{Namespace: d.Resource.Namespace, Name: d.Resource.Name},
+		}
+		driftedIDs[id] = d.Type
+	}
+
+	result := make([]client.Object, 0, len(objs))
+	for _, obj := range objs {
+		id := core.IDOf(obj)
+		driftType, found := driftedIDs[id]
+		if !found {
+			result = append(result, obj)
+			continue
+		}
+		if driftType == configsyncv1beta1.DriftDeleted {
+			klog.Infof("Skipping %s which has been deleted in audit-only mode", id)
+			continue
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+		if err := u.reader.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.Infof("Skipping %s which has been deleted in audit-only mode", id)
+				continue
+			}
+			return nil, status.APIServerError(err, "failed to get the drifted object", obj)
+		}
+		klog.Infof("Skipping the changes to %s which has drifted in audit-only mode", id)
+		result = append(result, asApplied(live))
+	}
+	return result, nil
+}
+
+// asApplied clears the fields of obj which are set by the API server, so that
+// obj can be applied as is.
+func asApplied(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	unstructured.RemoveNestedField(obj.Object, "status")
+	return obj
+}
+
+// planUpdate computes the changes which the applier would make to apply objs.
+// The declared resources and the watches are left untouched, so that the
+// Remediator does not enforce the planned state either.
+func (u *updater) planUpdate(ctx context.Context, commit string, objs []client.Object) status.MultiError {
+	changes, errs := u.applier.Plan(ctx, objs)
+	u.setPlan(&planResult{commit: commit, changes: changes, errs: errs})
+	return errs
+}
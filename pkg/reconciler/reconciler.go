@@ -19,21 +19,27 @@ import (
 	"time"
 
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
 	"k8s.io/klog/v2"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/client/restconfig"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/importer/filesystem/cmpath"
 	"kpt.dev/configsync/pkg/importer/reader"
+	"kpt.dev/configsync/pkg/namespaceselector"
 	"kpt.dev/configsync/pkg/parse"
 	"kpt.dev/configsync/pkg/remediator"
 	"kpt.dev/configsync/pkg/remediator/watch"
 	syncerclient "kpt.dev/configsync/pkg/syncer/client"
 	"kpt.dev/configsync/pkg/syncer/metrics"
 	"kpt.dev/configsync/pkg/syncer/reconcile"
+	"kpt.dev/configsync/pkg/syncwindow"
+	"kpt.dev/configsync/pkg/trigger"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -43,6 +49,12 @@ import (
 type Options struct {
 	// ClusterName is the name of the cluster we are parsing configuration for.
 	ClusterName string
+	// ClusterLabels are the labels of the cluster read from the live cluster,
+	// which ClusterSelectors select on.
+	ClusterLabels map[string]string
+	// ClusterValues are the values of the ${cluster.values.KEY} variables in
+	// the declared configs.
+	ClusterValues map[string]string
 	// FightDetectionThreshold is the rate of updates per minute to an API
 	// Resource at which the reconciler will log warnings about too many updates
 	// to the resource.
@@ -89,10 +101,41 @@ type Options struct {
 	SourceType v1beta1.SourceType
 	// SyncDir is the relative path to the configurations in the source.
 	SyncDir cmpath.Relative
+	// GitVerificationKeysDir is the directory holding the keys trusted to sign
+	// the git commits. If empty, the commits are not verified.
+	GitVerificationKeysDir string
 	// StatusMode controls the kpt applier to inject the actuation status data or not
 	StatusMode string
 	// ReconcileTimeout controls the reconcile/prune Timeout in kpt applier
 	ReconcileTimeout string
+	// DryRun indicates that the reconciler only computes the changes to the
+	// cluster, and publishes them in the RootSync or RepoSync status, instead
+	// of applying them.
+	DryRun bool
+	// AuditOnly indicates that the remediator only records the drift of the
+	// managed resources, and publishes it in the RootSync or RepoSync status,
+	// instead of correcting it.
+	AuditOnly bool
+	// DeletionBudgets limit the number of managed objects per kind which a
+	// commit, or the remediator between two commits, may delete.
+	DeletionBudgets []v1beta1.DeletionBudget
+	// SyncWindows restrict when the changes of the source of truth are
+	// applied.
+	SyncWindows []v1beta1.SyncWindow
+	// Suspend indicates that the reconciler stops applying the changes of the
+	// source of truth and remediating drift, while it keeps fetching the source
+	// and reporting the status.
+	Suspend bool
+	// SyncTriggerAddr is the address on which to serve the authenticated sync
+	// trigger endpoint. The endpoint is disabled if it is empty.
+	SyncTriggerAddr string
+	// SyncTriggerLocalAddr is the loopback address on which to serve the
+	// unauthenticated sync trigger endpoint, called by the source containers.
+	// The endpoint is disabled if it is empty.
+	SyncTriggerLocalAddr string
+	// SyncTriggerTokenFile is the file holding the token which authenticates
+	// the requests to the sync trigger endpoint.
+	SyncTriggerTokenFile string
 	// RootOptions is the set of options to fill in if this is configuring the
 	// Root reconciler.
 	// Unset for Namespace repositories.
@@ -148,6 +191,13 @@ func Run(opts Options) {
 		klog.Fatalf("failed to create client: %v", err)
 	}
 
+	// Configure the Event recorder shared by the Applier, the Remediator and
+	// the Parser.
+	recorder, err := events.NewRecorder(cfg, s, opts.ReconcilerName)
+	if err != nil {
+		klog.Fatalf("Error creating event recorder: %v", err)
+	}
+
 	// Configure the Applier.
 	genericClient := syncerclient.New(cl, metrics.APICallDuration)
 	baseApplier, err := reconcile.NewApplierForMultiRepo(cfg, genericClient)
@@ -162,18 +212,21 @@ func Run(opts Options) {
 	if reconcileTimeout < 0 {
 		klog.Fatalf("Invalid reconcileTimeout: %v, timeout should not be negative", reconcileTimeout)
 	}
+	// The applier, the declared resources and the remediator check their
+	// deletions against the same deletion budgets.
+	safeguard := declared.NewDeletionSafeguard(opts.DeletionBudgets, cl, opts.ReconcilerScope, opts.SyncName)
 	var a *applier.Applier
 	if opts.ReconcilerScope == declared.RootReconciler {
-		a, err = applier.NewRootApplier(cl, configFlags, opts.SyncName, opts.StatusMode, reconcileTimeout)
+		a, err = applier.NewRootApplier(cl, configFlags, opts.SyncName, opts.StatusMode, reconcileTimeout, recorder, safeguard)
 	} else {
-		a, err = applier.NewNamespaceApplier(cl, configFlags, opts.ReconcilerScope, opts.SyncName, opts.StatusMode, reconcileTimeout)
+		a, err = applier.NewNamespaceApplier(cl, configFlags, opts.ReconcilerScope, opts.SyncName, opts.StatusMode, reconcileTimeout, recorder, safeguard)
 	}
 	if err != nil {
 		klog.Fatalf("Error creating applier: %v", err)
 	}
 
 	// Configure the Remediator.
-	decls := &declared.Resources{}
+	decls := &declared.Resources{DeletionSafeguard: safeguard}
 
 	// Get a separate config for the remediator to talk to the apiserver since
 	// we want a longer REST config timeout for the remediator to avoid restarting
@@ -183,7 +236,13 @@ func Run(opts Options) {
 		klog.Fatalf("Error creating rest config for the remediator: %v", err)
 	}
 
-	rem, err := remediator.New(opts.ReconcilerScope, opts.SyncName, cfgForRemediator, baseApplier, decls, opts.NumWorkers)
+	// Break-glass policies do not pause the remediator in audit-only mode, which
+	// keeps recording the drift of the exempted objects.
+	var exemptions *breakglass.Cache
+	if !opts.AuditOnly {
+		exemptions = breakglass.NewCache(cl, breakglass.DefaultRefreshPeriod)
+	}
+	rem, err := remediator.New(opts.ReconcilerScope, opts.SyncName, cfgForRemediator, baseApplier, decls, opts.NumWorkers, opts.AuditOnly, recorder, exemptions)
 	if err != nil {
 		klog.Fatalf("Instantiating Remediator: %v", err)
 	}
@@ -200,25 +259,52 @@ func Run(opts Options) {
 		SourceRepo:   opts.SourceRepo,
 		SourceBranch: opts.SourceBranch,
 		SourceRev:    opts.SourceRev,
+
+		GitVerificationKeysDir: opts.GitVerificationKeysDir,
+	}
+	syncWindows, err := syncwindow.NewGate(opts.SyncWindows, cl, opts.ReconcilerScope, opts.SyncName)
+	if err != nil {
+		klog.Fatalf("Invalid sync windows: %v", err)
+	}
+	ctx := signals.SetupSignalHandler()
+
+	// Only unstructured repos synced by a RootSync support NamespaceSelectors in
+	// dynamic mode, which select from the Namespaces on the cluster. The
+	// Namespaces are only watched once such a NamespaceSelector is parsed.
+	var namespaces *namespaceselector.Watcher
+	if opts.ReconcilerScope == declared.RootReconciler && opts.SourceFormat == filesystem.SourceFormatUnstructured {
+		metadataClient, err := metadata.NewForConfig(cfg)
+		if err != nil {
+			klog.Fatalf("Error creating metadata client: %v", err)
+		}
+		namespaces = namespaceselector.NewWatcher(ctx, namespaceselector.NewListWatch(ctx, metadataClient))
 	}
 	if opts.ReconcilerScope == declared.RootReconciler {
-		parser, err = parse.NewRootRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.SourceFormat, &reader.File{}, cl,
-			opts.FilesystemPollingFrequency, opts.ResyncPeriod, fs, discoveryClient, decls, a, rem)
+		parser, err = parse.NewRootRunner(opts.ClusterName, opts.ClusterLabels, opts.ClusterValues, opts.SyncName, opts.ReconcilerName, opts.SourceFormat, &reader.File{}, cl,
+			opts.FilesystemPollingFrequency, opts.ResyncPeriod, fs, discoveryClient, decls, a, rem, opts.DryRun, opts.AuditOnly, opts.Suspend, syncWindows, namespaces, recorder)
 		if err != nil {
 			klog.Fatalf("Instantiating Root Repository Parser: %v", err)
 		}
 	} else {
-		parser, err = parse.NewNamespaceRunner(opts.ClusterName, opts.SyncName, opts.ReconcilerName, opts.ReconcilerScope, &reader.File{}, cl,
-			opts.FilesystemPollingFrequency, opts.ResyncPeriod, fs, discoveryClient, decls, a, rem)
+		parser, err = parse.NewNamespaceRunner(opts.ClusterName, opts.ClusterLabels, opts.ClusterValues, opts.SyncName, opts.ReconcilerName, opts.ReconcilerScope, &reader.File{}, cl,
+			opts.FilesystemPollingFrequency, opts.ResyncPeriod, fs, discoveryClient, decls, a, rem, opts.DryRun, opts.AuditOnly, opts.Suspend, syncWindows, recorder)
 		if err != nil {
 			klog.Fatalf("Instantiating Namespace Repository Parser: %v", err)
 		}
 	}
 
-	ctx := signals.SetupSignalHandler()
+	// Start the Remediator (non-blocking). A suspended reconciler does not
+	// correct drift. Resuming restarts the reconciler, which starts it then.
+	if !opts.Suspend {
+		rem.Start(ctx)
+	}
 
-	// Start the Remediator (non-blocking).
-	rem.Start(ctx)
+	// Start the sync trigger endpoint (non-blocking).
+	var syncTrigger *trigger.Handler
+	if opts.SyncTriggerAddr != "" || opts.SyncTriggerLocalAddr != "" {
+		syncTrigger = newSyncTrigger(opts)
+		trigger.Start(ctx, opts.SyncTriggerAddr, opts.SyncTriggerLocalAddr, syncTrigger)
+	}
 
 	// Create a new context with its cancellation function.
 	ctxForUpdateStatus, cancel := context.WithCancel(context.Background())
@@ -229,12 +315,33 @@ func Run(opts Options) {
 	// This will not return until:
 	// - the Context is cancelled, or
 	// - its Done channel is closed.
-	parse.Run(ctx, parser)
+	parse.Run(ctx, parser, syncTrigger.C())
 
 	// This is to terminate `updateSyncStatus`.
 	cancel()
 }
 
+// newSyncTrigger returns the handler of the sync trigger endpoint. Git push
+// webhooks of other branches are ignored. External requests wake the source
+// container, so that it fetches the new commit right away: git-sync is
+// restarted through its liveness probe, and oci-sync or helm-sync are called on
+// the sync trigger endpoint they serve on the loopback interface.
+func newSyncTrigger(opts Options) *trigger.Handler {
+	handlerOpts := trigger.Options{
+		TokenFile:   opts.SyncTriggerTokenFile,
+		MinInterval: trigger.DefaultMinInterval,
+	}
+	if opts.SourceType == v1beta1.GitSource {
+		handlerOpts.Branch = opts.SourceBranch
+		handlerOpts.Wake = trigger.NewGitSyncWaker(trigger.GitSyncWakeFile, trigger.GitSyncWakeMinInterval).Wake
+	} else {
+		handlerOpts.Wake = func(ctx context.Context) error {
+			return trigger.Notify(ctx, trigger.SidecarURL)
+		}
+	}
+	return trigger.NewHandler(handlerOpts)
+}
+
 // updateStatus update the status periodically until the cancellation function of the context is called.
 func updateStatus(ctx context.Context, p parse.Parser) {
 	ticker := time.NewTicker(5 * time.Second)
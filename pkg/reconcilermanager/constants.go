@@ -29,6 +29,18 @@ const (
 	// of the cluster.
 	ClusterNameKey = "CLUSTER_NAME"
 
+	// ClusterLabelsKey is the OS env variable key for the labels of the
+	// cluster, read from the live cluster, which ClusterSelectors select on.
+	ClusterLabelsKey = "CLUSTER_LABELS"
+
+	// ClusterLabelsSourceKey is the OS env variable key for where the
+	// reconciler-manager reads the labels of the cluster.
+	ClusterLabelsSourceKey = "CLUSTER_LABELS_SOURCE"
+
+	// ClusterValuesKey is the OS env variable key for the JSON encoded values
+	// of the ${cluster.values.KEY} variables in the declared configs.
+	ClusterValuesKey = "CLUSTER_VALUES"
+
 	// ScopeKey is the OS env variable key for the scope of the
 	// reconciler and hydration controller.
 	ScopeKey = "SCOPE"
@@ -71,6 +83,26 @@ const (
 	// ReconcileTimeout is to control the kpt applier reconcile/prune task timeout
 	ReconcileTimeout = "RECONCILE_TIMEOUT"
 
+	// DryRun is the OS env variable key for whether the reconciler computes
+	// the plan of the changes instead of applying them.
+	DryRun = "DRY_RUN"
+
+	// AuditOnly is the OS env variable key for whether the remediator only
+	// records the drift of the managed resources instead of correcting it.
+	AuditOnly = "AUDIT_ONLY"
+
+	// DeletionBudgets is the OS env variable key for the JSON encoded deletion
+	// budgets of the RootSync or RepoSync.
+	DeletionBudgets = "DELETION_BUDGETS"
+
+	// SyncWindows is the OS env variable key for the JSON encoded sync windows
+	// of the RootSync or RepoSync.
+	SyncWindows = "SYNC_WINDOWS"
+
+	// Suspend is the OS env variable key for whether the reconciler stops
+	// applying the changes of the source of truth and remediating drift.
+	Suspend = "SUSPEND"
+
 	// StatusMode is to control if the kpt applier needs to inject the actuation data
 	// into the ResourceGroup object.
 	StatusMode = "STATUS_MODE"
@@ -88,6 +120,10 @@ const (
 
 	// SourceRevKey is the OS env variable key for the git or helm revision.
 	SourceRevKey = "SOURCE_REV"
+
+	// GitVerificationKeysDir is the OS env variable key for the directory
+	// holding the keys trusted to sign the git commits.
+	GitVerificationKeysDir = "GIT_VERIFICATION_KEYS_DIR"
 )
 
 const (
@@ -109,6 +145,10 @@ const (
 
 	// OciSyncWait is the OS env variable key for the OCI sync wait period in seconds.
 	OciSyncWait = "OCI_SYNC_WAIT"
+
+	// OciSyncVerificationKeysDir is the OS env variable key for the directory
+	// holding the public keys used to verify the OCI image signatures.
+	OciSyncVerificationKeysDir = "OCI_SYNC_VERIFICATION_KEYS_DIR"
 )
 
 const (
@@ -132,4 +172,16 @@ const (
 
 	// HelmSyncWait is the OS env variable key for the Helm sync wait period in seconds.
 	HelmSyncWait = "HELM_SYNC_WAIT"
+
+	// HelmValuesYAML is the OS env variable key for the inline Helm values
+	// serialized as YAML.
+	HelmValuesYAML = "HELM_VALUES_YAML"
+
+	// HelmValuesFiles is the OS env variable key for the comma-separated list of
+	// values files inside the Helm chart.
+	HelmValuesFiles = "HELM_VALUES_FILES"
+
+	// HelmIncludeCRDs is the OS env variable key for whether Helm template
+	// should also render the CRDs of the chart.
+	HelmIncludeCRDs = "HELM_INCLUDE_CRDS"
 )
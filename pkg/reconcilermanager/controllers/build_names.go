@@ -16,8 +16,10 @@ package controllers
 
 import (
 	"fmt"
+	"strings"
 
 	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
 )
 
@@ -37,3 +39,17 @@ func RepoSyncPermissionsName() string {
 func RootSyncPermissionsName() string {
 	return fmt.Sprintf("%s:%s", configsync.GroupName, core.RootReconcilerPrefix)
 }
+
+// RootSyncBaseBindingName returns the name of the binding to the root
+// reconciler base ClusterRole for a RootSync which specifies spec.roleRefs.
+// e.g. configsync.gke.io:root-reconciler-my-root-sync:base
+func RootSyncBaseBindingName(reconcilerName string) string {
+	return fmt.Sprintf("%s:%s:base", configsync.GroupName, reconcilerName)
+}
+
+// RootSyncRoleRefBindingName returns the name of the binding to a role from
+// spec.roleRefs of a RootSync.
+// e.g. configsync.gke.io:root-reconciler-my-root-sync:clusterrole:view
+func RootSyncRoleRefBindingName(reconcilerName string, ref v1beta1.RootSyncRoleRef) string {
+	return fmt.Sprintf("%s:%s:%s:%s", configsync.GroupName, reconcilerName, strings.ToLower(ref.Kind), ref.Name)
+}
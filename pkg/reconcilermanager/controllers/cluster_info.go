@@ -0,0 +1,133 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterLabelsSource is where the reconciler-manager reads the labels of the
+// cluster, which the reconcilers use to evaluate the ClusterSelectors in
+// addition to the labels of the Cluster objects declared in the repo.
+type ClusterLabelsSource string
+
+const (
+	// NoClusterLabels reads no labels from the live cluster. The reconcilers
+	// only use the Cluster objects declared in the repo.
+	NoClusterLabels = ClusterLabelsSource("")
+	// ClusterLabelsFromConfigMap reads the labels from the data of the
+	// cluster-labels ConfigMap in the config-management-system namespace.
+	ClusterLabelsFromConfigMap = ClusterLabelsSource("configmap")
+	// ClusterLabelsFromMembership reads the labels from the metadata of the
+	// fleet Membership object.
+	ClusterLabelsFromMembership = ClusterLabelsSource("membership")
+
+	// ClusterLabelsConfigMapName is the name of the ConfigMap holding the
+	// labels of the cluster for ClusterLabelsFromConfigMap.
+	ClusterLabelsConfigMapName = "cluster-labels"
+
+	// ClusterValuesConfigMapName is the name of the ConfigMap holding the
+	// values of the ${cluster.values.KEY} variables in the declared configs.
+	ClusterValuesConfigMapName = "cluster-values"
+)
+
+// ValidateClusterLabelsSource returns an error if the source is not one of the
+// known ClusterLabelsSources.
+func ValidateClusterLabelsSource(source ClusterLabelsSource) error {
+	switch source {
+	case NoClusterLabels, ClusterLabelsFromConfigMap, ClusterLabelsFromMembership:
+		return nil
+	default:
+		return fmt.Errorf("unknown cluster labels source %q, must be one of %q or %q",
+			source, ClusterLabelsFromConfigMap, ClusterLabelsFromMembership)
+	}
+}
+
+// updateClusterInfo reads the labels and the values of the cluster, and
+// caches them for the environment of the reconcilers.
+func (r *reconcilerBase) updateClusterInfo(ctx context.Context) error {
+	if err := r.updateClusterLabels(ctx); err != nil {
+		return err
+	}
+	values, err := r.getClusterConfigMapData(ctx, ClusterValuesConfigMapName)
+	if err != nil {
+		return err
+	}
+	r.clusterValues = values
+	return nil
+}
+
+// updateClusterLabels reads the labels of the cluster from the
+// ClusterLabelsSource of the reconciler-manager. The labels are empty if there
+// is no source, or if the source object does not exist.
+func (r *reconcilerBase) updateClusterLabels(ctx context.Context) error {
+	var result map[string]string
+	switch r.clusterLabelsSource {
+	case ClusterLabelsFromConfigMap:
+		data, err := r.getClusterConfigMapData(ctx, ClusterLabelsConfigMapName)
+		if err != nil {
+			return err
+		}
+		result = data
+	case ClusterLabelsFromMembership:
+		if r.membership != nil {
+			result = r.membership.Labels
+		}
+	}
+	if _, err := labels.ValidatedSelectorFromSet(result); err != nil {
+		return errors.Wrapf(err, "invalid cluster labels read from the %s", r.clusterLabelsSource)
+	}
+	r.clusterLabels = result
+	return nil
+}
+
+// getClusterConfigMapData returns the data of the ConfigMap with the given name
+// in the config-management-system namespace, or nil if it does not exist.
+func (r *reconcilerBase) getClusterConfigMapData(ctx context.Context, name string) (map[string]string, error) {
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: configsync.ControllerNamespace, Name: name}
+	if err := r.client.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get the ConfigMap %s", key)
+	}
+	return cm.Data, nil
+}
+
+// isClusterConfigMap returns whether the object is one of the ConfigMaps
+// holding the labels or the values of the cluster.
+func (r *reconcilerBase) isClusterConfigMap(o client.Object) bool {
+	if o.GetNamespace() != configsync.ControllerNamespace {
+		return false
+	}
+	switch o.GetName() {
+	case ClusterValuesConfigMapName:
+		return true
+	case ClusterLabelsConfigMapName:
+		return r.clusterLabelsSource == ClusterLabelsFromConfigMap
+	default:
+		return false
+	}
+}
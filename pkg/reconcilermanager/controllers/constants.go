@@ -41,3 +41,29 @@ const (
 	// HelmSecretKeyUsername is the key at which a token's username is stored
 	HelmSecretKeyUsername = "username"
 )
+
+// OCI secret data key names
+const (
+	// OciSecretKeyPassword is the key at which a token's value is stored
+	OciSecretKeyPassword = "password"
+	// OciSecretKeyUsername is the key at which a token's username is stored
+	OciSecretKeyUsername = "username"
+)
+
+// Sync trigger secret
+const (
+	// SyncTriggerSecretSuffix is the suffix of the name of the optional Secret,
+	// in the config-management-system namespace, which holds the token of the
+	// sync trigger endpoint of a reconciler. The Secret of a namespace
+	// reconciler is copied from the namespace of its RepoSync.
+	// e.g. root-reconciler-sync-trigger
+	SyncTriggerSecretSuffix = "sync-trigger"
+	// SyncTriggerSecretKey is the key at which the sync trigger token is stored
+	SyncTriggerSecretKey = "token"
+	// SyncTriggerVolume is the volume name of the sync trigger token.
+	SyncTriggerVolume = "sync-trigger"
+	// SyncTriggerPath is the path where the sync trigger token is mounted in
+	// the reconciler container, which reads it from the SyncTriggerSecretKey
+	// file on every request.
+	SyncTriggerPath = "/etc/sync-trigger"
+)
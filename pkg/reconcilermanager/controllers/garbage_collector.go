@@ -31,6 +31,7 @@ import (
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/reconcilermanager"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -113,6 +114,7 @@ func (r *RepoSyncReconciler) deleteConfigmap(ctx context.Context, reconcilerName
 		ReconcilerResourceName(reconcilerName, reconcilermanager.Reconciler),
 		ReconcilerResourceName(reconcilerName, reconcilermanager.HydrationController),
 		ReconcilerResourceName(reconcilerName, reconcilermanager.GitSync),
+		ReconcilerResourceName(reconcilerName, OciVerificationVolume),
 	}
 	for _, c := range cms {
 		if err := r.cleanup(ctx, c, v1.NSConfigManagementSystem, kinds.ConfigMap()); err != nil {
@@ -165,7 +167,7 @@ func (r *RootSyncReconciler) deleteClusterRoleBinding(ctx context.Context) error
 		return errors.Wrapf(err, "failed to list the RootSync objects")
 	}
 	crbName := RootSyncPermissionsName()
-	if len(rootsyncList.Items) == 0 {
+	if !needsClusterAdmin(rootsyncList) {
 		return r.cleanup(ctx, crbName, kinds.ClusterRoleBinding())
 	}
 	crb := &rbacv1.ClusterRoleBinding{}
@@ -175,6 +177,55 @@ func (r *RootSyncReconciler) deleteClusterRoleBinding(ctx context.Context) error
 	return r.updateClusterRoleBindingSubjects(crb, rootsyncList)
 }
 
+// deleteRoleRefBindings deletes the ClusterRoleBindings and RoleBindings
+// created for the spec.roleRefs of the RootSync, except for those in
+// keepCRBs and keepRBs.
+func (r *RootSyncReconciler) deleteRoleRefBindings(ctx context.Context, rsKey client.ObjectKey, keepCRBs map[string]bool, keepRBs map[client.ObjectKey]bool) error {
+	labels := client.MatchingLabels{
+		metadata.SyncNamespaceLabel: rsKey.Namespace,
+		metadata.SyncNameLabel:      rsKey.Name,
+	}
+
+	crbList := &rbacv1.ClusterRoleBindingList{}
+	if err := r.client.List(ctx, crbList, labels); err != nil {
+		return errors.Wrapf(err, "failed to list the ClusterRoleBinding objects of RootSync %s", rsKey)
+	}
+	for _, crb := range crbList.Items {
+		if keepCRBs[crb.Name] {
+			continue
+		}
+		if err := r.cleanup(ctx, crb.Name, kinds.ClusterRoleBinding()); err != nil {
+			return errors.Wrapf(err, "failed to delete the ClusterRoleBinding object %s", crb.Name)
+		}
+	}
+
+	rbList := &rbacv1.RoleBindingList{}
+	if err := r.client.List(ctx, rbList, labels); err != nil {
+		return errors.Wrapf(err, "failed to list the RoleBinding objects of RootSync %s", rsKey)
+	}
+	for i := range rbList.Items {
+		rb := &rbList.Items[i]
+		if keepRBs[client.ObjectKeyFromObject(rb)] {
+			continue
+		}
+		if err := r.client.Delete(ctx, rb); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the RoleBinding object %s/%s", rb.Namespace, rb.Name)
+		}
+	}
+	return nil
+}
+
+// needsClusterAdmin returns true if any of the RootSyncs binds its reconciler
+// to cluster-admin, i.e. does not specify spec.roleRefs.
+func needsClusterAdmin(rsList *v1beta1.RootSyncList) bool {
+	for _, rs := range rsList.Items {
+		if len(rs.Spec.RoleRefs) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanup cleans up cluster-scoped resources that are created for RootSync.
 // Other namespace-scoped resources are garbage collected via OwnerReferences.
 // Cluster-scoped resources cannot be handled via OwnerReferences because
@@ -197,6 +248,10 @@ func (r *RootSyncReconciler) cleanup(ctx context.Context, name string, gvk schem
 func (r *RootSyncReconciler) updateClusterRoleBindingSubjects(crb *rbacv1.ClusterRoleBinding, rsList *v1beta1.RootSyncList) error {
 	var subjects []rbacv1.Subject
 	for _, rs := range rsList.Items {
+		if len(rs.Spec.RoleRefs) > 0 {
+			// The reconciler is bound to the roles in spec.roleRefs instead.
+			continue
+		}
 		subjects = append(subjects, subject(core.RootReconcilerName(rs.Name),
 			configsync.ControllerNamespace,
 			"ServiceAccount"))
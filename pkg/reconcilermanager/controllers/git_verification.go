@@ -0,0 +1,96 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// GitVerificationVolume is the volume name of the keys trusted to sign the
+// git commits.
+const GitVerificationVolume = "git-verification"
+
+// GitVerificationPath is the path where the keys trusted to sign the git
+// commits are mounted in the reconciler container.
+const GitVerificationPath = "/etc/git-verification"
+
+// gitVerificationEnabled returns true if the git commit signatures must be
+// verified before syncing.
+func gitVerificationEnabled(sourceType string, gitConfig *v1beta1.Git) bool {
+	return v1beta1.SourceType(sourceType) == v1beta1.GitSource && gitConfig != nil && gitConfig.Verification != nil
+}
+
+// gitVerificationVolume returns the volume of the Secret named secretName in
+// the config-management-system namespace, which holds the keys trusted to
+// sign the git commits.
+func gitVerificationVolume(secretName string) corev1.Volume {
+	return corev1.Volume{
+		Name: GitVerificationVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: &defaultMode,
+			},
+		},
+	}
+}
+
+// gitVerificationVolumeMount returns the VolumeMount of the keys trusted to
+// sign the git commits.
+func gitVerificationVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      GitVerificationVolume,
+		MountPath: GitVerificationPath,
+		ReadOnly:  true,
+	}
+}
+
+// upsertGitVerification copies the Secret holding the keys trusted to sign the
+// git commits from the reposync.namespace into the config-management-system
+// namespace, where it can be mounted by the namespace reconciler.
+func upsertGitVerification(ctx context.Context, rs *v1beta1.RepoSync, c client.Client, reconcilerName string) error {
+	if !gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) {
+		return nil
+	}
+	name := rs.Spec.Git.Verification.SecretRef.Name
+	namespaceSecret := &corev1.Secret{}
+	if err := get(ctx, name, rs.Namespace, namespaceSecret, c); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf(
+				"%s not found. Create %s secret in %s namespace", name, name, rs.Namespace)
+		}
+		return errors.Wrapf(err, "error while retrieving the git verification secret")
+	}
+	copied := &corev1.Secret{}
+	copied.Name = ReconcilerResourceName(reconcilerName, GitVerificationVolume)
+	copied.Namespace = v1.NSConfigManagementSystem
+	_, err := controllerutil.CreateOrUpdate(ctx, c, copied, func() error {
+		core.SetLabel(copied, metadata.SyncNamespaceLabel, rs.Namespace)
+		core.SetLabel(copied, metadata.SyncNameLabel, rs.Name)
+		copied.Data = namespaceSecret.Data
+		return nil
+	})
+	return errors.Wrapf(err, "failed to upsert the git verification secret %s", copied.Name)
+}
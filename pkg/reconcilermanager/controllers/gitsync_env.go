@@ -23,6 +23,7 @@ import (
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/trigger"
 )
 
 const (
@@ -187,6 +188,13 @@ func gitSyncEnvs(ctx context.Context, opts options) []corev1.EnvVar {
 		Name:  "GIT_SYNC_WAIT",
 		Value: fmt.Sprintf("%f", opts.period),
 	})
+	// git-sync calls the sync trigger endpoint of the reconciler once it has
+	// fetched a new commit, so that the reconciler doesn't wait for its next
+	// polling period.
+	result = append(result, corev1.EnvVar{
+		Name:  "GIT_SYNC_WEBHOOK_URL",
+		Value: trigger.ReconcilerURL,
+	})
 	// When branch and ref not set in RootSync/RepoSync then dont set GIT_SYNC_BRANCH
 	// and GIT_SYNC_REV, git-sync will use the default values for them.
 	if opts.branch != "" {
@@ -0,0 +1,167 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// OciVerificationVolume is the volume name of the public keys used to verify
+// the OCI image signatures.
+const OciVerificationVolume = "oci-verification"
+
+// OciVerificationPath is the path where the public keys used to verify the
+// OCI image signatures are mounted.
+const OciVerificationPath = "/etc/oci-verification"
+
+// ociVerificationEnabled returns true if the OCI image signatures must be
+// verified before syncing.
+func ociVerificationEnabled(sourceType string, ociConfig *v1beta1.Oci) bool {
+	return v1beta1.SourceType(sourceType) == v1beta1.OciSource && ociConfig != nil && ociConfig.Verification != nil
+}
+
+// ociVerificationVolume returns the volume of the Secret or ConfigMap named
+// objectName in the config-management-system namespace, which holds the
+// public keys used to verify the OCI image signatures.
+func ociVerificationVolume(verification *v1beta1.OciVerification, objectName string) corev1.Volume {
+	volume := corev1.Volume{Name: OciVerificationVolume}
+	if verification.SecretRef != nil && verification.SecretRef.Name != "" {
+		volume.Secret = &corev1.SecretVolumeSource{
+			SecretName:  objectName,
+			DefaultMode: &defaultMode,
+		}
+	} else {
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: objectName},
+			DefaultMode:          &defaultMode,
+		}
+	}
+	return volume
+}
+
+// ociVerificationVolumeMount returns the VolumeMount of the public keys used
+// to verify the OCI image signatures.
+func ociVerificationVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      OciVerificationVolume,
+		MountPath: OciVerificationPath,
+		ReadOnly:  true,
+	}
+}
+
+// ociVerificationRefName returns the name of the Secret or ConfigMap
+// referenced by the OCI verification spec.
+func ociVerificationRefName(verification *v1beta1.OciVerification) string {
+	if verification.SecretRef != nil && verification.SecretRef.Name != "" {
+		return verification.SecretRef.Name
+	}
+	if verification.ConfigMapRef != nil {
+		return verification.ConfigMapRef.Name
+	}
+	return ""
+}
+
+// upsertOciVerification copies the Secret or ConfigMap holding the public keys
+// used to verify the OCI image signatures from the reposync.namespace into
+// the config-management-system namespace, where it can be mounted by the
+// namespace reconciler. The copy of the other kind is deleted, so that a stale
+// copy is not left behind when switching between secretRef and configMapRef.
+func upsertOciVerification(ctx context.Context, rs *v1beta1.RepoSync, c client.Client, reconcilerName string) error {
+	if !ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) {
+		return nil
+	}
+	verification := rs.Spec.Oci.Verification
+	name := ociVerificationRefName(verification)
+	labels := map[string]string{
+		metadata.SyncNamespaceLabel: rs.Namespace,
+		metadata.SyncNameLabel:      rs.Name,
+	}
+	copiedName := ReconcilerResourceName(reconcilerName, OciVerificationVolume)
+
+	if verification.SecretRef != nil && verification.SecretRef.Name != "" {
+		if err := deleteOciVerificationCopy(ctx, c, kinds.ConfigMap(), copiedName); err != nil {
+			return err
+		}
+		namespaceSecret := &corev1.Secret{}
+		if err := get(ctx, name, rs.Namespace, namespaceSecret, c); err != nil {
+			if apierrors.IsNotFound(err) {
+				return errors.Errorf(
+					"%s not found. Create %s secret in %s namespace", name, name, rs.Namespace)
+			}
+			return errors.Wrapf(err, "error while retrieving the OCI verification secret")
+		}
+		copied := &corev1.Secret{}
+		copied.Name = copiedName
+		copied.Namespace = v1.NSConfigManagementSystem
+		_, err := controllerutil.CreateOrUpdate(ctx, c, copied, func() error {
+			for k, v := range labels {
+				core.SetLabel(copied, k, v)
+			}
+			copied.Data = namespaceSecret.Data
+			return nil
+		})
+		return errors.Wrapf(err, "failed to upsert the OCI verification secret %s", copied.Name)
+	}
+
+	if err := deleteOciVerificationCopy(ctx, c, kinds.Secret(), copiedName); err != nil {
+		return err
+	}
+	namespaceConfigMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: rs.Namespace}, namespaceConfigMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errors.Errorf(
+				"%s not found. Create %s configmap in %s namespace", name, name, rs.Namespace)
+		}
+		return errors.Wrapf(err, "error while retrieving the OCI verification configmap")
+	}
+	copied := &corev1.ConfigMap{}
+	copied.Name = copiedName
+	copied.Namespace = v1.NSConfigManagementSystem
+	_, err := controllerutil.CreateOrUpdate(ctx, c, copied, func() error {
+		for k, v := range labels {
+			core.SetLabel(copied, k, v)
+		}
+		copied.Data = namespaceConfigMap.Data
+		copied.BinaryData = namespaceConfigMap.BinaryData
+		return nil
+	})
+	return errors.Wrapf(err, "failed to upsert the OCI verification configmap %s", copied.Name)
+}
+
+// deleteOciVerificationCopy deletes the copy named copiedName of the given kind
+// from the config-management-system namespace, if it exists.
+func deleteOciVerificationCopy(ctx context.Context, c client.Client, gvk schema.GroupVersionKind, copiedName string) error {
+	copied := &unstructured.Unstructured{}
+	copied.SetName(copiedName)
+	copied.SetNamespace(v1.NSConfigManagementSystem)
+	copied.SetGroupVersionKind(gvk)
+	if err := c.Delete(ctx, copied); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the stale OCI verification copy %s", copiedName)
+	}
+	return nil
+}
@@ -35,7 +35,7 @@ import (
 )
 
 const (
-	depAnnotationGooglecloud = "64124da69731e38a713109f63d837749"
+	depAnnotationGooglecloud = "3cc46f6592301049a1bcfb25c12e871d"
 	depAnnotationCustom      = "9182661d55e260a55da649363c03c187"
 )
 
@@ -0,0 +1,149 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metadata"
+)
+
+// mutatePodTemplateOverride merges the pod template override of a
+// RootSync/RepoSync into the pod template of its reconciler Deployment. It must
+// be called after the containers are mutated, since it adds the environment
+// variables of the override to them.
+//
+// The hash of the override is recorded in an annotation of the pod template,
+// so the Deployment is rolled out whenever the override changes.
+func mutatePodTemplateOverride(template *corev1.PodTemplateSpec, override *v1beta1.PodTemplateOverride) error {
+	if override == nil {
+		return nil
+	}
+
+	// Keep the labels set by Config Sync or by the Deployment template, since
+	// the pod selector of the Deployment depends on them.
+	for k, v := range override.Labels {
+		if _, found := template.Labels[k]; !found {
+			core.SetLabel(template, k, v)
+		}
+	}
+	for k, v := range override.Annotations {
+		core.SetAnnotation(template, k, v)
+	}
+
+	podSpec := &template.Spec
+	if len(override.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = make(map[string]string, len(override.NodeSelector))
+		}
+		for k, v := range override.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	for _, t := range override.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, *t.DeepCopy())
+	}
+	if override.Affinity != nil {
+		podSpec.Affinity = override.Affinity.DeepCopy()
+	}
+	if override.PriorityClassName != "" {
+		podSpec.PriorityClassName = override.PriorityClassName
+	}
+	if override.SecurityContext != nil {
+		if podSpec.SecurityContext == nil {
+			podSpec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		mergePodSecurityContext(podSpec.SecurityContext, override.SecurityContext.DeepCopy())
+	}
+
+	for _, spec := range override.Env {
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == spec.ContainerName {
+				if err := addContainerEnv(&podSpec.Containers[i], spec.Env); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	h, err := hash(override)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash the pod template override")
+	}
+	core.SetAnnotation(template, metadata.PodTemplateOverrideAnnotationKey, fmt.Sprintf("%x", h))
+	return nil
+}
+
+// mergePodSecurityContext sets the fields of the current security context
+// which are set in the override.
+func mergePodSecurityContext(current, override *corev1.PodSecurityContext) {
+	if override.SELinuxOptions != nil {
+		current.SELinuxOptions = override.SELinuxOptions
+	}
+	if override.WindowsOptions != nil {
+		current.WindowsOptions = override.WindowsOptions
+	}
+	if override.RunAsUser != nil {
+		current.RunAsUser = override.RunAsUser
+	}
+	if override.RunAsGroup != nil {
+		current.RunAsGroup = override.RunAsGroup
+	}
+	if override.RunAsNonRoot != nil {
+		current.RunAsNonRoot = override.RunAsNonRoot
+	}
+	if override.SupplementalGroups != nil {
+		current.SupplementalGroups = override.SupplementalGroups
+	}
+	if override.FSGroup != nil {
+		current.FSGroup = override.FSGroup
+	}
+	if override.Sysctls != nil {
+		current.Sysctls = override.Sysctls
+	}
+	if override.FSGroupChangePolicy != nil {
+		current.FSGroupChangePolicy = override.FSGroupChangePolicy
+	}
+	if override.SeccompProfile != nil {
+		current.SeccompProfile = override.SeccompProfile
+	}
+}
+
+// addContainerEnv adds the environment variables of the override to the
+// container. The variables set by Config Sync can not be overridden.
+func addContainerEnv(c *corev1.Container, envs []corev1.EnvVar) error {
+	existing := make(map[string]bool, len(c.Env))
+	for _, env := range c.Env {
+		existing[env.Name] = true
+	}
+	for _, env := range envs {
+		if existing[env.Name] {
+			return errors.Errorf("the environment variable %q of the %s container is set by Config Sync and can not be overridden", env.Name, c.Name)
+		}
+		env = *env.DeepCopy()
+		// The API server defaults the apiVersion of field references, which
+		// would otherwise make the Deployment differ from its declaration on
+		// every reconcile.
+		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.APIVersion == "" {
+			env.ValueFrom.FieldRef.APIVersion = "v1"
+		}
+		c.Env = append(c.Env, env)
+	}
+	return nil
+}
@@ -58,6 +58,26 @@ const (
 	// It will be used in both the indexing and watching.
 	helmSecretRefField = ".spec.helm.secretRef.name"
 
+	// ociSecretRefField is the path of the field in the RootSync|RepoSync CRDs
+	// that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	ociSecretRefField = ".spec.oci.secretRef.name"
+
+	// ociVerificationSecretRefField is the path of the field in the RepoSync CRD
+	// that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	ociVerificationSecretRefField = ".spec.oci.verification.secretRef.name"
+
+	// ociVerificationConfigMapRefField is the path of the field in the RepoSync
+	// CRD that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	ociVerificationConfigMapRefField = ".spec.oci.verification.configMapRef.name"
+
+	// gitVerificationSecretRefField is the path of the field in the RepoSync CRD
+	// that we wish to use as the "object reference".
+	// It will be used in both the indexing and watching.
+	gitVerificationSecretRefField = ".spec.git.verification.secretRef.name"
+
 	// fleetMembershipName is the name of the fleet membership
 	fleetMembershipName = "membership"
 )
@@ -72,6 +92,13 @@ type reconcilerBase struct {
 	reconcilerPollingPeriod time.Duration
 	hydrationPollingPeriod  time.Duration
 	membership              *hubv1.Membership
+	clusterLabelsSource     ClusterLabelsSource
+	// clusterLabels is a cache of the labels of the cluster read from the
+	// clusterLabelsSource.
+	clusterLabels map[string]string
+	// clusterValues is a cache of the values of the cluster read from the
+	// cluster-values ConfigMap.
+	clusterValues map[string]string
 
 	// lastReconciledResourceVersions is a cache of the last reconciled
 	// ResourceVersion for each R*Sync objects.
@@ -28,6 +28,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -67,10 +68,11 @@ type RepoSyncReconciler struct {
 }
 
 // NewRepoSyncReconciler returns a new RepoSyncReconciler.
-func NewRepoSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, log logr.Logger, scheme *runtime.Scheme) *RepoSyncReconciler {
+func NewRepoSyncReconciler(clusterName string, clusterLabelsSource ClusterLabelsSource, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, log logr.Logger, scheme *runtime.Scheme) *RepoSyncReconciler {
 	return &RepoSyncReconciler{
 		reconcilerBase: reconcilerBase{
 			clusterName:             clusterName,
+			clusterLabelsSource:     clusterLabelsSource,
 			client:                  client,
 			log:                     log,
 			scheme:                  scheme,
@@ -158,12 +160,29 @@ func (r *RepoSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		return controllerruntime.Result{}, updateErr
 	}
 
+	// Read the labels and values of the cluster for the environment of the reconciler.
+	if err := r.updateClusterInfo(ctx); err != nil {
+		log.Error(err, "Failed to read the cluster labels and values")
+		reposync.SetStalled(rs, "ClusterInfo", err)
+		// Read errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RepoSync status")
+		}
+		// Use the read error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "ClusterInfo reconcile failed")
+	}
+
 	// Create secret in config-management-system namespace using the
 	// existing secret in the reposync.namespace.
 	if err := upsertSecret(ctx, rs, r.client, reconcilerName); err != nil {
 		var authType configsync.AuthType
 		if rs.Spec.SourceType == string(v1beta1.GitSource) {
 			authType = rs.Spec.Auth
+		} else if rs.Spec.SourceType == string(v1beta1.OciSource) {
+			authType = rs.Spec.Oci.Auth
 		} else if rs.Spec.SourceType == string(v1beta1.HelmSource) {
 			authType = rs.Spec.Helm.Auth
 		}
@@ -180,6 +199,54 @@ func (r *RepoSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		return controllerruntime.Result{}, errors.Wrap(err, "Secret reconcile failed")
 	}
 
+	// Copy the public keys used to verify the OCI image signatures into the
+	// config-management-system namespace.
+	if err := upsertOciVerification(ctx, rs, r.client, reconcilerName); err != nil {
+		log.Error(err, "RepoSync failed OCI verification keys creation")
+		reposync.SetStalled(rs, "OciVerification", err)
+		// Upsert errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RepoSync status")
+		}
+		// Use the upsert error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "OCI verification keys reconcile failed")
+	}
+
+	// Copy the keys trusted to sign the git commits into the
+	// config-management-system namespace.
+	if err := upsertGitVerification(ctx, rs, r.client, reconcilerName); err != nil {
+		log.Error(err, "RepoSync failed git verification keys creation")
+		reposync.SetStalled(rs, "GitVerification", err)
+		// Upsert errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RepoSync status")
+		}
+		// Use the upsert error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "git verification keys reconcile failed")
+	}
+
+	// Copy the optional sync trigger token into the config-management-system
+	// namespace.
+	if err := upsertSyncTrigger(ctx, rs, r.client, reconcilerName); err != nil {
+		log.Error(err, "RepoSync failed sync trigger token creation")
+		reposync.SetStalled(rs, "SyncTrigger", err)
+		// Upsert errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RepoSync status")
+		}
+		// Use the upsert error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "sync trigger token reconcile failed")
+	}
+
 	reposyncLabelMap := map[string]string{
 		metadata.SyncNamespaceLabel: rs.Namespace,
 		metadata.SyncNameLabel:      rs.Name,
@@ -294,6 +361,16 @@ func (r *RepoSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		reposync.ClearCondition(rs, v1beta1.RepoSyncStalled)
 	}
 
+	// The reconciler adds the commit which is not applied yet to the Suspended
+	// condition, so only set the condition when it is not set yet.
+	if rs.Spec.Suspend {
+		if !reposync.IsSuspended(rs) {
+			reposync.SetSuspended(rs, "Suspended", "Applying changes and remediating drift are suspended", "", metav1.Now())
+		}
+	} else {
+		reposync.ClearCondition(rs, v1beta1.RepoSyncSuspended)
+	}
+
 	updated, err := r.updateStatus(ctx, currentRS, rs)
 	// Use the status update error for metric tagging, if no other errors.
 	metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
@@ -330,6 +407,50 @@ func (r *RepoSyncReconciler) SetupWithManager(mgr controllerruntime.Manager, wat
 		return err
 	}
 
+	// Index the `ociSecretRefName` field, so that we will be able to lookup RepoSync be a referenced `SecretRef` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, ociSecretRefField, func(rawObj client.Object) []string {
+		rs := rawObj.(*v1beta1.RepoSync)
+		if rs.Spec.Oci == nil || rs.Spec.Oci.SecretRef.Name == "" {
+			return nil
+		}
+		return []string{rs.Spec.Oci.SecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	// Index the `ociVerificationSecretRefName` field, so that we will be able to lookup RepoSync be a referenced `SecretRef` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, ociVerificationSecretRefField, func(rawObj client.Object) []string {
+		rs := rawObj.(*v1beta1.RepoSync)
+		if rs.Spec.Oci == nil || rs.Spec.Oci.Verification == nil || rs.Spec.Oci.Verification.SecretRef == nil || rs.Spec.Oci.Verification.SecretRef.Name == "" {
+			return nil
+		}
+		return []string{rs.Spec.Oci.Verification.SecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	// Index the `ociVerificationConfigMapRefName` field, so that we will be able to lookup RepoSync be a referenced `ConfigMapRef` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, ociVerificationConfigMapRefField, func(rawObj client.Object) []string {
+		rs := rawObj.(*v1beta1.RepoSync)
+		if rs.Spec.Oci == nil || rs.Spec.Oci.Verification == nil || rs.Spec.Oci.Verification.ConfigMapRef == nil || rs.Spec.Oci.Verification.ConfigMapRef.Name == "" {
+			return nil
+		}
+		return []string{rs.Spec.Oci.Verification.ConfigMapRef.Name}
+	}); err != nil {
+		return err
+	}
+
+	// Index the `gitVerificationSecretRefField` field, so that we will be able to lookup RepoSync be a referenced `SecretRef` name.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1beta1.RepoSync{}, gitVerificationSecretRefField, func(rawObj client.Object) []string {
+		rs := rawObj.(*v1beta1.RepoSync)
+		if rs.Spec.Git == nil || rs.Spec.Git.Verification == nil || rs.Spec.Git.Verification.SecretRef.Name == "" {
+			return nil
+		}
+		return []string{rs.Spec.Git.Verification.SecretRef.Name}
+	}); err != nil {
+		return err
+	}
+
 	controllerBuilder := controllerruntime.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 1,
@@ -353,8 +474,14 @@ func (r *RepoSyncReconciler) SetupWithManager(mgr controllerruntime.Manager, wat
 		// Custom Watch for membership to trigger reconciliation.
 		controllerBuilder.Watches(&source.Kind{Type: &hubv1.Membership{}},
 			handler.EnqueueRequestsFromMapFunc(r.mapMembershipToRepoSyncs()),
-			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})))
 	}
+	// Custom Watch for the ConfigMaps holding the labels and the values of the
+	// cluster, and the public keys used to verify the OCI image signatures, to
+	// trigger reconciliation.
+	controllerBuilder.Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+		handler.EnqueueRequestsFromMapFunc(r.mapConfigMapToRepoSyncs),
+		builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}))
 	return controllerBuilder.Complete(r)
 }
 
@@ -402,7 +529,69 @@ func (r *RepoSyncReconciler) requeueAllRepoSyncs() []reconcile.Request {
 		}
 	}
 	if len(requests) > 0 {
-		klog.Infof("Changes to membership trigger reconciliations for %d RepoSync objects.", len(allRepoSyncs.Items))
+		klog.Infof("Changes to membership or cluster labels trigger reconciliations for %d RepoSync objects.", len(allRepoSyncs.Items))
+	}
+	return requests
+}
+
+// mapConfigMapToRepoSyncs triggers a reconciliation of all the RepoSync
+// objects when a ConfigMap holding the labels or the values of the cluster
+// changes, and of the attached RepoSync objects when a ConfigMap holding the
+// public keys used to verify the OCI image signatures, or its copy in the
+// config-management-system namespace, changes.
+func (r *RepoSyncReconciler) mapConfigMapToRepoSyncs(cm client.Object) []reconcile.Request {
+	if r.isClusterConfigMap(cm) {
+		return r.requeueAllRepoSyncs()
+	}
+	// map the copied ns-reconciler ConfigMap in the config-management-system to RepoSync request.
+	if cm.GetNamespace() == configsync.ControllerNamespace {
+		if !strings.HasPrefix(cm.GetName(), core.NsReconcilerPrefix) {
+			return nil
+		}
+		if err := r.addTypeInformationToObject(cm); err != nil {
+			klog.Errorf("failed to add type information to object (name: %s, namespace: %s): %v", cm.GetName(), cm.GetNamespace(), err)
+			return nil
+		}
+		allRepoSyncs := &v1beta1.RepoSyncList{}
+		if err := r.client.List(context.Background(), allRepoSyncs); err != nil {
+			klog.Errorf("failed to list all RepoSyncs for object (name: %s, namespace: %s): %v", cm.GetName(), cm.GetNamespace(), err)
+			return nil
+		}
+		for _, rs := range allRepoSyncs.Items {
+			reconcilerName := core.NsReconcilerName(rs.GetNamespace(), rs.GetName())
+			if ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) &&
+				cm.GetName() == ReconcilerResourceName(reconcilerName, OciVerificationVolume) {
+				return requeueRepoSyncRequest(cm, &rs)
+			}
+		}
+		return nil
+	}
+
+	// map the user-managed ConfigMap in the RepoSync's namespace to RepoSync request.
+	// The ConfigMap might be shared among multiple RepoSync objects in the same namespace,
+	// so requeue all the attached RepoSync objects.
+	attachedRepoSyncs := &v1beta1.RepoSyncList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(ociVerificationConfigMapRefField, cm.GetName()),
+		Namespace:     cm.GetNamespace(),
+	}
+	if err := r.client.List(context.Background(), attachedRepoSyncs, listOps); err != nil {
+		klog.Errorf("failed to list attached RepoSyncs for configmap (name: %s, namespace: %s): %v", cm.GetName(), cm.GetNamespace(), err)
+		return nil
+	}
+	requests := make([]reconcile.Request, len(attachedRepoSyncs.Items))
+	attachedRSNames := make([]string, len(attachedRepoSyncs.Items))
+	for i, rs := range attachedRepoSyncs.Items {
+		attachedRSNames[i] = rs.GetName()
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      rs.GetName(),
+				Namespace: rs.GetNamespace(),
+			},
+		}
+	}
+	if len(requests) > 0 {
+		klog.Infof("Changes to ConfigMap (name: %s, namespace: %s) triggers a reconciliation for the RepoSync object %q in the same namespace.", cm.GetName(), cm.GetNamespace(), strings.Join(attachedRSNames, ", "))
 	}
 	return requests
 }
@@ -434,7 +623,14 @@ func (r *RepoSyncReconciler) mapSecretToRepoSyncs(secret client.Object) []reconc
 				secret.GetName() == ReconcilerResourceName(reconcilerName, rs.Spec.SecretRef.Name)
 			isHelmSecret := rs.Spec.SourceType == string(v1beta1.HelmSource) && rs.Spec.Helm != nil &&
 				secret.GetName() == ReconcilerResourceName(reconcilerName, rs.Spec.Helm.SecretRef.Name)
-			if isGitSecret || isHelmSecret {
+			isOciSecret := rs.Spec.SourceType == string(v1beta1.OciSource) && rs.Spec.Oci != nil &&
+				secret.GetName() == ReconcilerResourceName(reconcilerName, rs.Spec.Oci.SecretRef.Name)
+			isOciVerificationSecret := ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) &&
+				secret.GetName() == ReconcilerResourceName(reconcilerName, OciVerificationVolume)
+			isGitVerificationSecret := gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) &&
+				secret.GetName() == ReconcilerResourceName(reconcilerName, GitVerificationVolume)
+			isSyncTriggerSecret := secret.GetName() == ReconcilerResourceName(reconcilerName, SyncTriggerSecretSuffix)
+			if isGitSecret || isHelmSecret || isOciSecret || isOciVerificationSecret || isGitVerificationSecret || isSyncTriggerSecret {
 				return requeueRepoSyncRequest(secret, &rs)
 			}
 			isSAToken := strings.HasPrefix(secret.GetName(), reconcilerName+"-token-")
@@ -480,7 +676,46 @@ func (r *RepoSyncReconciler) mapSecretToRepoSyncs(secret client.Object) []reconc
 		klog.Errorf("failed to list attached RepoSyncs for secret (name: %s, namespace: %s): %v", secret.GetName(), secret.GetNamespace(), err)
 		return nil
 	}
+	attachedOciRepoSyncs := &v1beta1.RepoSyncList{}
+	listOps = &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(ociSecretRefField, secret.GetName()),
+		Namespace:     secret.GetNamespace(),
+	}
+	if err := r.client.List(context.Background(), attachedOciRepoSyncs, listOps); err != nil {
+		klog.Errorf("failed to list attached RepoSyncs for secret (name: %s, namespace: %s): %v", secret.GetName(), secret.GetNamespace(), err)
+		return nil
+	}
+	attachedOciVerificationRepoSyncs := &v1beta1.RepoSyncList{}
+	listOps = &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(ociVerificationSecretRefField, secret.GetName()),
+		Namespace:     secret.GetNamespace(),
+	}
+	if err := r.client.List(context.Background(), attachedOciVerificationRepoSyncs, listOps); err != nil {
+		klog.Errorf("failed to list attached RepoSyncs for secret (name: %s, namespace: %s): %v", secret.GetName(), secret.GetNamespace(), err)
+		return nil
+	}
+	attachedGitVerificationRepoSyncs := &v1beta1.RepoSyncList{}
+	listOps = &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(gitVerificationSecretRefField, secret.GetName()),
+		Namespace:     secret.GetNamespace(),
+	}
+	if err := r.client.List(context.Background(), attachedGitVerificationRepoSyncs, listOps); err != nil {
+		klog.Errorf("failed to list attached RepoSyncs for secret (name: %s, namespace: %s): %v", secret.GetName(), secret.GetNamespace(), err)
+		return nil
+	}
 	attachedRepoSyncs.Items = append(attachedRepoSyncs.Items, attachedHelmRepoSyncs.Items...)
+	attachedRepoSyncs.Items = append(attachedRepoSyncs.Items, attachedOciRepoSyncs.Items...)
+	attachedRepoSyncs.Items = append(attachedRepoSyncs.Items, attachedOciVerificationRepoSyncs.Items...)
+	attachedRepoSyncs.Items = append(attachedRepoSyncs.Items, attachedGitVerificationRepoSyncs.Items...)
+	// The sync trigger Secret is attached to the RepoSync by its name.
+	if rsName := strings.TrimSuffix(secret.GetName(), "-"+SyncTriggerSecretSuffix); rsName != secret.GetName() {
+		rs := &v1beta1.RepoSync{}
+		if err := r.client.Get(context.Background(), client.ObjectKey{Name: rsName, Namespace: secret.GetNamespace()}, rs); err == nil {
+			attachedRepoSyncs.Items = append(attachedRepoSyncs.Items, *rs)
+		} else if !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get the RepoSync attached to secret (name: %s, namespace: %s): %v", secret.GetName(), secret.GetNamespace(), err)
+		}
+	}
 	requests := make([]reconcile.Request, len(attachedRepoSyncs.Items))
 	attachedRSNames := make([]string, len(attachedRepoSyncs.Items))
 	for i, rs := range attachedRepoSyncs.Items {
@@ -592,9 +827,29 @@ func requeueRepoSyncRequest(obj client.Object, rs *v1beta1.RepoSync) []reconcile
 }
 
 func (r *RepoSyncReconciler) populateRepoContainerEnvs(ctx context.Context, rs *v1beta1.RepoSync, reconcilerName string) map[string][]corev1.EnvVar {
+	reconcilerOpts := reconcilerOptions{
+		clusterName:      r.clusterName,
+		clusterLabels:    r.clusterLabels,
+		clusterValues:    r.clusterValues,
+		syncName:         rs.Name,
+		reconcilerName:   reconcilerName,
+		reconcilerScope:  declared.Scope(rs.Namespace),
+		sourceType:       rs.Spec.SourceType,
+		gitConfig:        rs.Spec.Git,
+		ociConfig:        rs.Spec.Oci,
+		helmConfig:       rs.Spec.Helm,
+		pollPeriod:       r.reconcilerPollingPeriod.String(),
+		statusMode:       rs.Spec.Override.StatusMode,
+		reconcileTimeout: v1beta1.GetReconcileTimeout(rs.Spec.Override.ReconcileTimeout),
+		dryRun:           v1beta1.GetDryRun(rs.Spec.Override.DryRun),
+		auditOnly:        v1beta1.GetAuditOnly(rs.Spec.Override.AuditOnly),
+		deletionBudgets:  rs.Spec.Override.DeletionBudgets,
+		syncWindows:      rs.Spec.SyncWindows,
+		suspend:          rs.Spec.Suspend,
+	}
 	result := map[string][]corev1.EnvVar{
 		reconcilermanager.HydrationController: hydrationEnvs(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, declared.Scope(rs.Namespace), reconcilerName, r.hydrationPollingPeriod.String()),
-		reconcilermanager.Reconciler:          reconcilerEnvs(r.clusterName, rs.Name, reconcilerName, declared.Scope(rs.Namespace), rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm, r.reconcilerPollingPeriod.String(), rs.Spec.Override.StatusMode, v1beta1.GetReconcileTimeout(rs.Spec.Override.ReconcileTimeout)),
+		reconcilermanager.Reconciler:          reconcilerEnvs(reconcilerOpts),
 	}
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
@@ -610,15 +865,21 @@ func (r *RepoSyncReconciler) populateRepoContainerEnvs(ctx context.Context, rs *
 			privateCertSecret: rs.Spec.Git.PrivateCertSecret.Name,
 		})
 	case v1beta1.OciSource:
-		result[reconcilermanager.OciSync] = ociSyncEnvs(rs.Spec.Oci.Image, rs.Spec.Oci.Auth, v1beta1.GetPeriodSecs(rs.Spec.Oci.Period))
+		result[reconcilermanager.OciSync] = ociSyncEnvs(rs.Spec.Oci)
 	case v1beta1.HelmSource:
-		result[reconcilermanager.HelmSync] = helmSyncEnvs(rs.Spec.Helm.Repo, rs.Spec.Helm.Chart, rs.Spec.Helm.Version, rs.Spec.Helm.ReleaseName, rs.Spec.Helm.Namespace, rs.Spec.Helm.Auth, v1beta1.GetPeriodSecs(rs.Spec.Helm.Period))
+		result[reconcilermanager.HelmSync] = helmSyncEnvs(rs.Spec.Helm)
 
 	}
 	return result
 }
 
 func (r *RepoSyncReconciler) validateSpec(ctx context.Context, rs *v1beta1.RepoSync, reconcilerName string) error {
+	if err := validate.SyncWindows(rs.Spec.SyncWindows, rs); err != nil {
+		return err
+	}
+	if err := validate.PodTemplateOverride(rs.Spec.Override.PodTemplate, rs); err != nil {
+		return err
+	}
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
 		if rs.Spec.Oci != nil {
@@ -631,8 +892,7 @@ func (r *RepoSyncReconciler) validateSpec(ctx context.Context, rs *v1beta1.RepoS
 		}
 		return validate.OciSpec(rs.Spec.Oci, rs)
 	case v1beta1.HelmSource:
-		//TODO: add validation logic here
-		return nil
+		return validate.HelmSpec(rs.Spec.Helm, rs)
 	default:
 		return validate.InvalidSourceType(rs)
 	}
@@ -759,6 +1019,7 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 		case v1beta1.OciSource:
 			auth = rs.Spec.Oci.Auth
 			gcpSAEmail = rs.Spec.Oci.GCPServiceAccountEmail
+			secretRefName = rs.Spec.Oci.SecretRef.Name
 		case v1beta1.HelmSource:
 			auth = rs.Spec.Helm.Auth
 			gcpSAEmail = rs.Spec.Helm.GCPServiceAccountEmail
@@ -781,11 +1042,25 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 		// Update DeprecatedServiceAccount to avoid discrepancy in equality check.
 		templateSpec.DeprecatedServiceAccount = reconcilerName
 		// Mutate secret.secretname to secret reference specified in RepoSync CR.
-		// Secret reference is the name of the secret used by git-sync or helm-sync container to
-		// authenticate with the git or helm repository using the authorization method specified
+		// Secret reference is the name of the secret used by git-sync, oci-sync or helm-sync container to
+		// authenticate with the git, OCI or helm repository using the authorization method specified
 		// in the RepoSync CR.
 		secretName := ReconcilerResourceName(reconcilerName, secretRefName)
 		templateSpec.Volumes = filterVolumes(templateSpec.Volumes, auth, secretName, privateCertSecret, rs.Spec.SourceType, r.membership)
+		// Mount the copied Secret or ConfigMap holding the public keys used by
+		// the oci-sync container to verify the image signatures.
+		if ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) {
+			templateSpec.Volumes = append(templateSpec.Volumes,
+				ociVerificationVolume(rs.Spec.Oci.Verification, ReconcilerResourceName(reconcilerName, OciVerificationVolume)))
+		}
+		// Mount the Secret holding the keys used by the reconciler container to
+		// verify the commit signatures.
+		if gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) {
+			templateSpec.Volumes = append(templateSpec.Volumes, gitVerificationVolume(ReconcilerResourceName(reconcilerName, GitVerificationVolume)))
+		}
+		// Mount the optional Secret holding the token of the sync trigger
+		// endpoint, which the reconciler container reads on every request.
+		templateSpec.Volumes = append(templateSpec.Volumes, syncTriggerVolume(ReconcilerResourceName(reconcilerName, SyncTriggerSecretSuffix)))
 		var updatedContainers []corev1.Container
 		// Mutate spec.Containers to update name, configmap references and volumemounts.
 		for _, container := range templateSpec.Containers {
@@ -793,6 +1068,10 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 			switch container.Name {
 			case reconcilermanager.Reconciler:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) {
+					container.VolumeMounts = append(container.VolumeMounts, gitVerificationVolumeMount())
+				}
+				container.VolumeMounts = append(container.VolumeMounts, syncTriggerVolumeMount())
 				mutateContainerResource(ctx, &container, rs.Spec.Override, string(NamespaceReconcilerType))
 			case reconcilermanager.HydrationController:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
@@ -808,6 +1087,13 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 					addContainer = false
 				} else {
 					container.Env = append(container.Env, containerEnvs[container.Name]...)
+					container.VolumeMounts = volumeMounts(rs.Spec.Oci.Auth, "", rs.Spec.SourceType, container.VolumeMounts)
+					if authTypeToken(rs.Spec.Oci.Auth) {
+						container.Env = append(container.Env, ociSyncTokenAuthEnv(secretName)...)
+					}
+					if ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) {
+						container.VolumeMounts = append(container.VolumeMounts, ociVerificationVolumeMount())
+					}
 					injectFWICredsToContainer(&container, injectFWICreds)
 					mutateContainerResource(ctx, &container, rs.Spec.Override, string(NamespaceReconcilerType))
 				}
@@ -860,6 +1146,9 @@ func (r *RepoSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RepoS
 		}
 
 		templateSpec.Containers = updatedContainers
-		return nil
+
+		// Merge the pod template override last, so it applies to the
+		// containers and settings of the final pod template.
+		return mutatePodTemplateOverride(&d.Spec.Template, rs.Spec.Override.PodTemplate)
 	}
 }
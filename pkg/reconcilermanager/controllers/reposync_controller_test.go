@@ -30,6 +30,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -114,6 +115,25 @@ var helmParsedDeployment = func(de *appsv1.Deployment) error {
 	return nil
 }
 
+var ociParsedDeployment = func(de *appsv1.Deployment) error {
+	de.TypeMeta = fake.ToTypeMeta(kinds.Deployment())
+	de.Spec = appsv1.DeploymentSpec{
+		Selector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				metadata.ReconcilerLabel: reconcilermanager.Reconciler,
+			},
+		},
+		Replicas: &reconcilerDeploymentReplicaCount,
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: ociSecretMountContainers(),
+				Volumes:    ociDeploymentSecretVolumes("oci-creds"),
+			},
+		},
+	}
+	return nil
+}
+
 func init() {
 	var err error
 	filesystemPollingPeriod, err = time.ParseDuration(pollingPeriod)
@@ -146,6 +166,11 @@ func reposyncOCIAuthType(auth configsync.AuthType) func(*v1beta1.RepoSync) {
 		rs.Spec.Oci.Auth = auth
 	}
 }
+func reposyncOCISecretRef(ref string) func(*v1beta1.RepoSync) {
+	return func(rs *v1beta1.RepoSync) {
+		rs.Spec.Oci.SecretRef = v1beta1.SecretReference{Name: ref}
+	}
+}
 func reposyncHelmAuthType(auth configsync.AuthType) func(*v1beta1.RepoSync) {
 	return func(rs *v1beta1.RepoSync) {
 		rs.Spec.Helm.Auth = auth
@@ -277,6 +302,7 @@ func setupNSReconciler(t *testing.T, objs ...client.Object) (*syncerFake.Client,
 	fakeClient := syncerFake.NewClient(t, s, objs...)
 	testReconciler := NewRepoSyncReconciler(
 		testCluster,
+		NoClusterLabels,
 		filesystemPollingPeriod,
 		hydrationPollingPeriod,
 		fakeClient,
@@ -1818,6 +1844,70 @@ func TestMapSecretToRepoSyncs(t *testing.T) {
 	}
 }
 
+func TestMapConfigMapToRepoSyncs(t *testing.T) {
+	keysName := "cosign-keys"
+	rs1 := repoSyncWithOCI("ns1", "rs1", reposyncOCIAuthType(configsync.AuthNone))
+	rs1.Spec.Oci.Verification = &v1beta1.OciVerification{
+		ConfigMapRef: &v1beta1.ConfigMapReference{Name: keysName},
+	}
+	rs2 := repoSyncWithOCI("ns1", "rs2", reposyncOCIAuthType(configsync.AuthNone))
+	ns1rs1ReconcilerName := core.NsReconcilerName(rs1.Namespace, rs1.Name)
+	ns1rs2ReconcilerName := core.NsReconcilerName(rs2.Namespace, rs2.Name)
+
+	testCases := []struct {
+		name string
+		cm   client.Object
+		want []reconcile.Request
+	}{
+		{
+			name: "A configmap from a namespace that has no RepoSync",
+			cm:   fake.ConfigMapObject(core.Name(keysName), core.Namespace("default")),
+			want: nil,
+		},
+		{
+			name: fmt.Sprintf("A configmap %s from the ns1 namespace with a mapping RepoSync", keysName),
+			cm:   fake.ConfigMapObject(core.Name(keysName), core.Namespace("ns1")),
+			want: []reconcile.Request{
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      "rs1",
+						Namespace: "ns1",
+					},
+				},
+			},
+		},
+		{
+			name: fmt.Sprintf("A copied configmap from the %s namespace with a mapping RepoSync", configsync.ControllerNamespace),
+			cm: fake.ConfigMapObject(core.Name(ReconcilerResourceName(ns1rs1ReconcilerName, OciVerificationVolume)),
+				core.Namespace(configsync.ControllerNamespace)),
+			want: []reconcile.Request{
+				{
+					NamespacedName: types.NamespacedName{
+						Name:      "rs1",
+						Namespace: "ns1",
+					},
+				},
+			},
+		},
+		{
+			name: fmt.Sprintf("A copied configmap from the %s namespace for a RepoSync without verification", configsync.ControllerNamespace),
+			cm: fake.ConfigMapObject(core.Name(ReconcilerResourceName(ns1rs2ReconcilerName, OciVerificationVolume)),
+				core.Namespace(configsync.ControllerNamespace)),
+			want: nil,
+		},
+	}
+
+	_, testReconciler := setupNSReconciler(t, rs1, rs2)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testReconciler.mapConfigMapToRepoSyncs(tc.cm)
+			if diff := cmp.Diff(tc.want, result, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s: unexpected requests diff %s", tc.name, diff)
+			}
+		})
+	}
+}
+
 func TestMapObjectToRepoSync(t *testing.T) {
 	rs1 := repoSync("ns1", "rs1", reposyncRef(gitRevision), reposyncBranch(branch), reposyncSecretType(configsync.AuthSSH), reposyncSecretRef(reposyncSSHKey))
 	ns1rs1ReconcilerName := core.NsReconcilerName(rs1.Namespace, rs1.Name)
@@ -2291,6 +2381,302 @@ func TestRepoSyncWithOCI(t *testing.T) {
 	t.Log("Deployment successfully updated")
 }
 
+func TestRepoSyncWithOCIToken(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = ociParsedDeployment
+	secretName := "oci-secret"
+	// Test creating RepoSync resources with Token auth type
+	rs := repoSyncWithOCI(reposyncNs, reposyncName,
+		reposyncOCIAuthType(configsync.AuthToken), reposyncOCISecretRef(secretName))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	ociSecret := secretObj(t, secretName, configsync.AuthToken, v1beta1.OciSource, core.Namespace(rs.Namespace))
+	fakeClient, testReconciler := setupNSReconciler(t, rs, ociSecret)
+
+	// Test creating Deployment resources.
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	copiedSecretName := ReconcilerResourceName(nsReconcilerName, secretName)
+	copied := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: copiedSecretName, Namespace: v1.NSConfigManagementSystem}, copied); err != nil {
+		t.Fatalf("failed to get the copied Secret: %v", err)
+	}
+	repoContainerEnvs := testReconciler.populateRepoContainerEnvs(ctx, rs, nsReconcilerName)
+	repoDeployment := repoSyncDeployment(nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		ociSecretMutator(copiedSecretName),
+		envVarMutator(ociSyncName, copiedSecretName, OciSecretKeyUsername),
+		envVarMutator(ociSyncPassword, copiedSecretName, OciSecretKeyPassword),
+		containerEnvMutator(repoContainerEnvs),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(repoDeployment): repoDeployment}
+	if err := validateDeployments(wantDeployments, fakeClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	t.Log("Deployment successfully created")
+
+	// Test updating RepoSync resources with dockerconfigjson auth type, which
+	// mounts the Secret without the token environment variables.
+	rs.Spec.Oci.Auth = configsync.AuthDockerConfigJSON
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the repo sync request, got error: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	repoContainerEnvs = testReconciler.populateRepoContainerEnvs(ctx, rs, nsReconcilerName)
+	repoDeployment = repoSyncDeployment(nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		ociSecretMutator(copiedSecretName),
+		containerEnvMutator(repoContainerEnvs),
+	)
+	wantDeployments[core.IDOf(repoDeployment)] = repoDeployment
+	if err := validateDeployments(wantDeployments, fakeClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+
+	// Test updating RepoSync resources with None auth type
+	rs.Spec.Oci.Auth = configsync.AuthNone
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the repo sync request, got error: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	repoContainerEnvs = testReconciler.populateRepoContainerEnvs(ctx, rs, nsReconcilerName)
+	repoDeployment = repoSyncDeployment(nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		containersWithRepoVolumeMutator(noneOciContainers()),
+		containerEnvMutator(repoContainerEnvs),
+	)
+	wantDeployments[core.IDOf(repoDeployment)] = repoDeployment
+	if err := validateDeployments(wantDeployments, fakeClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	t.Log("Deployment successfully updated")
+}
+
+func TestRepoSyncWithOCIVerification(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	keysName := "cosign-keys"
+	rs := repoSyncWithOCI(reposyncNs, reposyncName, reposyncOCIAuthType(configsync.AuthNone))
+	rs.Spec.Oci.Verification = &v1beta1.OciVerification{
+		ConfigMapRef: &v1beta1.ConfigMapReference{Name: keysName},
+	}
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	keys := fake.ConfigMapObject(core.Name(keysName), core.Namespace(rs.Namespace))
+	keys.Data = map[string]string{"cosign.pub": "public key"}
+	fakeClient, testReconciler := setupNSReconciler(t, rs, keys)
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	// The ConfigMap is copied into the config-management-system namespace.
+	copiedName := ReconcilerResourceName(nsReconcilerName, OciVerificationVolume)
+	copied := &corev1.ConfigMap{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: copiedName, Namespace: v1.NSConfigManagementSystem}, copied); err != nil {
+		t.Fatalf("failed to get the copied ConfigMap: %v", err)
+	}
+	if diff := cmp.Diff(keys.Data, copied.Data); diff != "" {
+		t.Errorf("copied ConfigMap data diff %s", diff)
+	}
+
+	containers := noneOciContainers()
+	for i := range containers {
+		if containers[i].Name == reconcilermanager.OciSync {
+			containers[i].VolumeMounts = append(containers[i].VolumeMounts, ociVerificationVolumeMount())
+		}
+	}
+	repoContainerEnv := testReconciler.populateRepoContainerEnvs(ctx, rs, nsReconcilerName)
+	repoDeployment := repoSyncDeployment(
+		nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		containersWithRepoVolumeMutator(containers),
+		func(dep *appsv1.Deployment) {
+			dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes,
+				ociVerificationVolume(rs.Spec.Oci.Verification, copiedName))
+		},
+		containerEnvMutator(repoContainerEnv),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(repoDeployment): repoDeployment}
+	if err := validateDeployments(wantDeployments, fakeClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	if !hasEnv(repoContainerEnv[reconcilermanager.OciSync], reconcilermanager.OciSyncVerificationKeysDir, OciVerificationPath) {
+		t.Errorf("missing the %s environment variable in the oci-sync container", reconcilermanager.OciSyncVerificationKeysDir)
+	}
+
+	// Switching to a secretRef copies the Secret and deletes the stale copied
+	// ConfigMap.
+	secretKeys := fake.SecretObject(keysName, core.Namespace(rs.Namespace))
+	secretKeys.Data = map[string][]byte{"cosign.pub": []byte("public key")}
+	if err := fakeClient.Create(ctx, secretKeys); err != nil {
+		t.Fatalf("failed to create the Secret: %v", err)
+	}
+	rs.Spec.Oci.Verification = &v1beta1.OciVerification{
+		SecretRef: &v1beta1.SecretReference{Name: keysName},
+	}
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the RepoSync: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	if err := validateResourceDeleted(core.IDOf(copied), fakeClient); err != nil {
+		t.Error(err)
+	}
+	copiedSecret := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: copiedName, Namespace: v1.NSConfigManagementSystem}, copiedSecret); err != nil {
+		t.Fatalf("failed to get the copied Secret: %v", err)
+	}
+	if diff := cmp.Diff(secretKeys.Data, copiedSecret.Data); diff != "" {
+		t.Errorf("copied Secret data diff %s", diff)
+	}
+
+	// The copied Secret is garbage collected with the RepoSync.
+	if err := fakeClient.Delete(ctx, rs); err != nil {
+		t.Fatalf("failed to delete the RepoSync: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon RepoSync deletion, got error: %q, want error: nil", err)
+	}
+	if err := validateResourceDeleted(core.IDOf(copiedSecret), fakeClient); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepoSyncWithGitVerification(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	keysName := "maintainers"
+	rs := repoSync(reposyncNs, reposyncName, reposyncRef(gitRevision), reposyncBranch(branch), reposyncSecretType(configsync.AuthNone))
+	rs.Spec.Git.Verification = &v1beta1.GitVerification{SecretRef: v1beta1.SecretReference{Name: keysName}}
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	keys := fake.SecretObject(keysName, core.Namespace(rs.Namespace))
+	keys.Data = map[string][]byte{"allowed_signers": []byte("jane@example.com ssh-ed25519 AAAA")}
+	fakeClient, testReconciler := setupNSReconciler(t, rs, keys)
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	// The Secret is copied into the config-management-system namespace.
+	copiedName := ReconcilerResourceName(nsReconcilerName, GitVerificationVolume)
+	copied := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: copiedName, Namespace: v1.NSConfigManagementSystem}, copied); err != nil {
+		t.Fatalf("failed to get the copied Secret: %v", err)
+	}
+	if diff := cmp.Diff(keys.Data, copied.Data); diff != "" {
+		t.Errorf("copied Secret data diff %s", diff)
+	}
+
+	containers := noneGitContainers()
+	for i := range containers {
+		if containers[i].Name == reconcilermanager.Reconciler {
+			containers[i].VolumeMounts = append(containers[i].VolumeMounts, gitVerificationVolumeMount())
+		}
+	}
+	repoContainerEnv := testReconciler.populateRepoContainerEnvs(ctx, rs, nsReconcilerName)
+	repoDeployment := repoSyncDeployment(
+		nsReconcilerName,
+		setServiceAccountName(nsReconcilerName),
+		containersWithRepoVolumeMutator(containers),
+		func(dep *appsv1.Deployment) {
+			dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes, gitVerificationVolume(copiedName))
+		},
+		containerEnvMutator(repoContainerEnv),
+	)
+	wantDeployments := map[core.ID]*appsv1.Deployment{core.IDOf(repoDeployment): repoDeployment}
+	if err := validateDeployments(wantDeployments, fakeClient); err != nil {
+		t.Errorf("Deployment validation failed. err: %v", err)
+	}
+	if !hasEnv(repoContainerEnv[reconcilermanager.Reconciler], reconcilermanager.GitVerificationKeysDir, GitVerificationPath) {
+		t.Errorf("missing the %s environment variable in the reconciler container", reconcilermanager.GitVerificationKeysDir)
+	}
+
+	// A missing Secret stalls the RepoSync.
+	if err := fakeClient.Delete(ctx, keys); err != nil {
+		t.Fatalf("failed to delete the Secret: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err == nil {
+		t.Error("got no reconciliation error for a missing git verification Secret")
+	}
+
+	// The copied Secret is garbage collected with the RepoSync.
+	if err := fakeClient.Delete(ctx, rs); err != nil {
+		t.Fatalf("failed to delete the RepoSync: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon RepoSync deletion, got error: %q, want error: nil", err)
+	}
+	if err := validateResourceDeleted(core.IDOf(copied), fakeClient); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRepoSyncWithSyncTrigger(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	rs := repoSync(reposyncNs, reposyncName, reposyncRef(gitRevision), reposyncBranch(branch), reposyncSecretType(configsync.AuthNone))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	token := fake.SecretObject(RepoSyncTriggerSecretName(rs.Name), core.Namespace(rs.Namespace))
+	token.Data = map[string][]byte{SyncTriggerSecretKey: []byte("s3cr3t")}
+	fakeClient, testReconciler := setupNSReconciler(t, rs, token)
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	// The Secret is copied into the config-management-system namespace, where
+	// the reconciler mounts it.
+	copiedName := ReconcilerResourceName(nsReconcilerName, SyncTriggerSecretSuffix)
+	copied := &corev1.Secret{}
+	if err := fakeClient.Get(ctx, client.ObjectKey{Name: copiedName, Namespace: v1.NSConfigManagementSystem}, copied); err != nil {
+		t.Fatalf("failed to get the copied Secret: %v", err)
+	}
+	if diff := cmp.Diff(token.Data, copied.Data); diff != "" {
+		t.Errorf("copied Secret data diff %s", diff)
+	}
+
+	// Both the Secret and its copy requeue the RepoSync.
+	wantRequests := []reconcile.Request{reqNamespacedName}
+	if diff := cmp.Diff(wantRequests, testReconciler.mapSecretToRepoSyncs(token)); diff != "" {
+		t.Errorf("mapSecretToRepoSyncs() for the Secret diff %s", diff)
+	}
+	if diff := cmp.Diff(wantRequests, testReconciler.mapSecretToRepoSyncs(copied)); diff != "" {
+		t.Errorf("mapSecretToRepoSyncs() for the copied Secret diff %s", diff)
+	}
+
+	// Deleting the Secret deletes the copy, which disables the endpoint.
+	if err := fakeClient.Delete(ctx, token); err != nil {
+		t.Fatalf("failed to delete the Secret: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	if err := validateResourceDeleted(core.IDOf(copied), fakeClient); err != nil {
+		t.Error(err)
+	}
+}
+
+func hasEnv(envs []corev1.EnvVar, name, value string) bool {
+	for _, env := range envs {
+		if env.Name == name && env.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
 func TestRepoSyncSpecValidation(t *testing.T) {
 	rs := fake.RepoSyncObjectV1Beta1(reposyncNs, reposyncName)
 	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
@@ -2703,5 +3089,41 @@ func repoSyncDeployment(reconcilerName string, muts ...depMutator) *appsv1.Deplo
 	for _, mut := range muts {
 		mut(dep)
 	}
+	syncTriggerMutator(reconcilerName)(dep)
 	return dep
 }
+
+func TestRepoSyncPodTemplateOverrideSecretRef(t *testing.T) {
+	rs := repoSync(reposyncNs, reposyncName, reposyncRef(gitRevision), reposyncBranch(branch), reposyncSecretType(configsync.AuthNone))
+	// The Secret would resolve in config-management-system, where the copies
+	// of the Secrets of the other RepoSyncs are.
+	rs.Spec.Override.PodTemplate = &v1beta1.PodTemplateOverride{
+		Env: []v1beta1.ContainerEnvSpec{{
+			ContainerName: reconcilermanager.GitSync,
+			Env: []corev1.EnvVar{{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: core.NsReconcilerName("other", configsync.RepoSyncName) + "-ssh-key"},
+					Key:                  "password",
+				},
+			}}},
+		}},
+	}
+	fakeClient, testReconciler := setupNSReconciler(t, rs)
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, namespacedName(rs.Name, rs.Namespace)); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	gotRS := &v1beta1.RepoSync{}
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(rs), gotRS); err != nil {
+		t.Fatal(err)
+	}
+	if !reposync.IsStalled(gotRS) {
+		t.Errorf("got conditions %v, want a True Stalled condition", gotRS.Status.Conditions)
+	}
+	deployment := &appsv1.Deployment{}
+	key := client.ObjectKey{Namespace: v1.NSConfigManagementSystem, Name: core.NsReconcilerName(rs.Namespace, rs.Name)}
+	if err := fakeClient.Get(ctx, key, deployment); !apierrors.IsNotFound(err) {
+		t.Errorf("got error %v getting the reconciler Deployment, want NotFound", err)
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/reconcilermanager"
@@ -76,10 +77,11 @@ type RootSyncReconciler struct {
 }
 
 // NewRootSyncReconciler returns a new RootSyncReconciler.
-func NewRootSyncReconciler(clusterName string, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, log logr.Logger, scheme *runtime.Scheme) *RootSyncReconciler {
+func NewRootSyncReconciler(clusterName string, clusterLabelsSource ClusterLabelsSource, reconcilerPollingPeriod, hydrationPollingPeriod time.Duration, client client.Client, log logr.Logger, scheme *runtime.Scheme) *RootSyncReconciler {
 	return &RootSyncReconciler{
 		reconcilerBase: reconcilerBase{
 			clusterName:             clusterName,
+			clusterLabelsSource:     clusterLabelsSource,
 			client:                  client,
 			log:                     log,
 			scheme:                  scheme,
@@ -106,6 +108,9 @@ func (r *RootSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
 		if apierrors.IsNotFound(err) {
 			r.clearLastReconciled(req.NamespacedName)
+			if err := r.deleteRoleRefBindings(ctx, req.NamespacedName, nil, nil); err != nil {
+				return controllerruntime.Result{}, err
+			}
 			return controllerruntime.Result{}, r.deleteClusterRoleBinding(ctx)
 		}
 		return controllerruntime.Result{}, status.APIServerError(err, "failed to get RootSync")
@@ -160,6 +165,21 @@ func (r *RootSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		return controllerruntime.Result{}, updateErr
 	}
 
+	// Read the labels and values of the cluster for the environment of the reconciler.
+	if err := r.updateClusterInfo(ctx); err != nil {
+		log.Error(err, "Failed to read the cluster labels and values")
+		rootsync.SetStalled(rs, "ClusterInfo", err)
+		// Read errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RootSync status")
+		}
+		// Use the read error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "ClusterInfo reconcile failed")
+	}
+
 	rootsyncLabelMap := map[string]string{
 		metadata.SyncNamespaceLabel: rs.Namespace,
 		metadata.SyncNameLabel:      rs.Name,
@@ -208,6 +228,21 @@ func (r *RootSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		return controllerruntime.Result{}, errors.Wrap(err, "ClusterRoleBinding reconcile failed")
 	}
 
+	// Overwrite reconciler bindings to the roles in spec.roleRefs.
+	if err := r.upsertRoleRefBindings(ctx, rs, reconcilerName, rootsyncLabelMap); err != nil {
+		log.Error(err, "Failed to create/update RoleRef bindings")
+		rootsync.SetStalled(rs, "RoleBinding", err)
+		// Upsert errors should always trigger retry (return error),
+		// even if status update is successful.
+		_, updateErr := r.updateStatus(ctx, currentRS, rs)
+		if updateErr != nil {
+			log.Error(updateErr, "failed to update RootSync status")
+		}
+		// Use the upsert error for metric tagging.
+		metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
+		return controllerruntime.Result{}, errors.Wrap(err, "RoleBinding reconcile failed")
+	}
+
 	containerEnvs := r.populateContainerEnvs(ctx, rs, reconcilerName)
 	mut := r.mutationsFor(ctx, rs, containerEnvs)
 
@@ -274,6 +309,16 @@ func (r *RootSyncReconciler) Reconcile(ctx context.Context, req controllerruntim
 		rootsync.ClearCondition(rs, v1beta1.RootSyncStalled)
 	}
 
+	// The reconciler adds the commit which is not applied yet to the Suspended
+	// condition, so only set the condition when it is not set yet.
+	if rs.Spec.Suspend {
+		if !rootsync.IsSuspended(rs) {
+			rootsync.SetSuspended(rs, "Suspended", "Applying changes and remediating drift are suspended", "", metav1.Now())
+		}
+	} else {
+		rootsync.ClearCondition(rs, v1beta1.RootSyncSuspended)
+	}
+
 	updated, err := r.updateStatus(ctx, currentRS, rs)
 	// Use the status update error for metric tagging, if no other errors.
 	metrics.RecordReconcileDuration(ctx, metrics.StatusTagKey(err), start)
@@ -314,8 +359,13 @@ func (r *RootSyncReconciler) SetupWithManager(mgr controllerruntime.Manager, wat
 		// Custom Watch for membership to trigger reconciliation.
 		controllerBuilder.Watches(&source.Kind{Type: &hubv1.Membership{}},
 			handler.EnqueueRequestsFromMapFunc(r.mapMembershipToRootSyncs()),
-			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})))
 	}
+	// Custom Watch for the ConfigMaps holding the labels and the values of the
+	// cluster to trigger reconciliation.
+	controllerBuilder.Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+		handler.EnqueueRequestsFromMapFunc(r.mapClusterConfigMapToRootSyncs),
+		builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}))
 	return controllerBuilder.Complete(r)
 }
 
@@ -363,11 +413,21 @@ func (r *RootSyncReconciler) requeueAllRootSyncs() []reconcile.Request {
 		}
 	}
 	if len(requests) > 0 {
-		klog.Infof("Changes to membership trigger reconciliations for %d RootSync objects.", len(allRootSyncs.Items))
+		klog.Infof("Changes to membership or cluster labels trigger reconciliations for %d RootSync objects.", len(allRootSyncs.Items))
 	}
 	return requests
 }
 
+// mapClusterConfigMapToRootSyncs triggers a reconciliation of all the
+// RootSync objects when a ConfigMap holding the labels or the values of the
+// cluster changes.
+func (r *RootSyncReconciler) mapClusterConfigMapToRootSyncs(cm client.Object) []reconcile.Request {
+	if !r.isClusterConfigMap(cm) {
+		return nil
+	}
+	return r.requeueAllRootSyncs()
+}
+
 // mapSecretToRootSyncs define a mapping from the Secret object to its attached
 // RootSync objects via the `spec.git.secretRef.name` field .
 // The update to the Secret object will trigger a reconciliation of the RootSync objects.
@@ -411,9 +471,29 @@ func (r *RootSyncReconciler) mapSecretToRootSyncs(secret client.Object) []reconc
 }
 
 func (r *RootSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1beta1.RootSync, reconcilerName string) map[string][]corev1.EnvVar {
+	reconcilerOpts := reconcilerOptions{
+		clusterName:      r.clusterName,
+		clusterLabels:    r.clusterLabels,
+		clusterValues:    r.clusterValues,
+		syncName:         rs.Name,
+		reconcilerName:   reconcilerName,
+		reconcilerScope:  declared.RootReconciler,
+		sourceType:       rs.Spec.SourceType,
+		gitConfig:        rs.Spec.Git,
+		ociConfig:        rs.Spec.Oci,
+		helmConfig:       rs.Spec.Helm,
+		pollPeriod:       r.reconcilerPollingPeriod.String(),
+		statusMode:       rs.Spec.Override.StatusMode,
+		reconcileTimeout: v1beta1.GetReconcileTimeout(rs.Spec.Override.ReconcileTimeout),
+		dryRun:           v1beta1.GetDryRun(rs.Spec.Override.DryRun),
+		auditOnly:        v1beta1.GetAuditOnly(rs.Spec.Override.AuditOnly),
+		deletionBudgets:  rs.Spec.Override.DeletionBudgets,
+		syncWindows:      rs.Spec.SyncWindows,
+		suspend:          rs.Spec.Suspend,
+	}
 	result := map[string][]corev1.EnvVar{
 		reconcilermanager.HydrationController: hydrationEnvs(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, declared.RootReconciler, reconcilerName, r.hydrationPollingPeriod.String()),
-		reconcilermanager.Reconciler:          append(reconcilerEnvs(r.clusterName, rs.Name, reconcilerName, declared.RootReconciler, rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm, r.reconcilerPollingPeriod.String(), rs.Spec.Override.StatusMode, v1beta1.GetReconcileTimeout(rs.Spec.Override.ReconcileTimeout)), sourceFormatEnv(rs.Spec.SourceFormat)),
+		reconcilermanager.Reconciler:          append(reconcilerEnvs(reconcilerOpts), sourceFormatEnv(rs.Spec.SourceFormat)),
 	}
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
@@ -429,14 +509,23 @@ func (r *RootSyncReconciler) populateContainerEnvs(ctx context.Context, rs *v1be
 			privateCertSecret: rs.Spec.Git.PrivateCertSecret.Name,
 		})
 	case v1beta1.OciSource:
-		result[reconcilermanager.OciSync] = ociSyncEnvs(rs.Spec.Oci.Image, rs.Spec.Oci.Auth, v1beta1.GetPeriodSecs(rs.Spec.Oci.Period))
+		result[reconcilermanager.OciSync] = ociSyncEnvs(rs.Spec.Oci)
 	case v1beta1.HelmSource:
-		result[reconcilermanager.HelmSync] = helmSyncEnvs(rs.Spec.Helm.Repo, rs.Spec.Helm.Chart, rs.Spec.Helm.Version, rs.Spec.Helm.ReleaseName, rs.Spec.Helm.Namespace, rs.Spec.Helm.Auth, v1beta1.GetPeriodSecs(rs.Spec.Helm.Period))
+		result[reconcilermanager.HelmSync] = helmSyncEnvs(rs.Spec.Helm)
 	}
 	return result
 }
 
 func (r *RootSyncReconciler) validateSpec(ctx context.Context, rs *v1beta1.RootSync, log logr.Logger) error {
+	if err := validate.RoleRefs(rs.Spec.RoleRefs, rs); err != nil {
+		return err
+	}
+	if err := validate.SyncWindows(rs.Spec.SyncWindows, rs); err != nil {
+		return err
+	}
+	if err := validate.PodTemplateOverride(rs.Spec.Override.PodTemplate, rs); err != nil {
+		return err
+	}
 	switch v1beta1.SourceType(rs.Spec.SourceType) {
 	case v1beta1.GitSource:
 		if rs.Spec.Oci != nil {
@@ -449,8 +538,7 @@ func (r *RootSyncReconciler) validateSpec(ctx context.Context, rs *v1beta1.RootS
 		}
 		return validate.OciSpec(rs.Spec.Oci, rs)
 	case v1beta1.HelmSource:
-		//TODO: add Helm Source Validation
-		return nil
+		return validate.HelmSpec(rs.Spec.Helm, rs)
 	default:
 		return validate.InvalidSourceType(rs)
 	}
@@ -493,11 +581,20 @@ func (r *RootSyncReconciler) validateRootSecret(ctx context.Context, rootSync *v
 }
 
 func (r *RootSyncReconciler) upsertClusterRoleBinding(ctx context.Context) error {
+	rootsyncList := &v1beta1.RootSyncList{}
+	if err := r.client.List(ctx, rootsyncList, client.InNamespace(configsync.ControllerNamespace)); err != nil {
+		return errors.Wrapf(err, "failed to list the RootSync objects")
+	}
+	if !needsClusterAdmin(rootsyncList) {
+		// Every RootSync binds its reconciler to its own roles.
+		return r.cleanup(ctx, RootSyncPermissionsName(), kinds.ClusterRoleBinding())
+	}
+
 	var childCRB rbacv1.ClusterRoleBinding
 	childCRB.Name = RootSyncPermissionsName()
 
 	op, err := controllerruntime.CreateOrUpdate(ctx, r.client, &childCRB, func() error {
-		return r.mutateRootSyncClusterRoleBinding(ctx, &childCRB)
+		return r.mutateRootSyncClusterRoleBinding(&childCRB, rootsyncList)
 	})
 	if err != nil {
 		return err
@@ -508,19 +605,89 @@ func (r *RootSyncReconciler) upsertClusterRoleBinding(ctx context.Context) error
 	return nil
 }
 
-func (r *RootSyncReconciler) mutateRootSyncClusterRoleBinding(ctx context.Context, crb *rbacv1.ClusterRoleBinding) error {
+func (r *RootSyncReconciler) mutateRootSyncClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, rootsyncList *v1beta1.RootSyncList) error {
 	crb.OwnerReferences = nil
 
 	// Update rolereference.
 	crb.RoleRef = rolereference("cluster-admin", "ClusterRole")
 
-	rootsyncList := &v1beta1.RootSyncList{}
-	if err := r.client.List(ctx, rootsyncList, client.InNamespace(configsync.ControllerNamespace)); err != nil {
-		return errors.Wrapf(err, "failed to list the RootSync objects")
-	}
 	return r.updateClusterRoleBindingSubjects(crb, rootsyncList)
 }
 
+// upsertRoleRefBindings binds the root reconciler to the base root reconciler
+// ClusterRole and to each of the roles in spec.roleRefs. Bindings left over
+// from roles which were removed from spec.roleRefs are deleted.
+func (r *RootSyncReconciler) upsertRoleRefBindings(ctx context.Context, rs *v1beta1.RootSync, reconcilerName string, labelMap map[string]string) error {
+	wantCRBs := map[string]bool{}
+	wantRBs := map[client.ObjectKey]bool{}
+	if len(rs.Spec.RoleRefs) > 0 {
+		baseName := RootSyncBaseBindingName(reconcilerName)
+		if err := r.upsertRoleRefClusterRoleBinding(ctx, baseName, RootSyncPermissionsName(), reconcilerName, labelMap); err != nil {
+			return err
+		}
+		wantCRBs[baseName] = true
+	}
+	for _, ref := range rs.Spec.RoleRefs {
+		name := RootSyncRoleRefBindingName(reconcilerName, ref)
+		if ref.Kind == kinds.ClusterRole().Kind && ref.Namespace == "" {
+			if err := r.upsertRoleRefClusterRoleBinding(ctx, name, ref.Name, reconcilerName, labelMap); err != nil {
+				return err
+			}
+			wantCRBs[name] = true
+			continue
+		}
+		key := client.ObjectKey{Namespace: ref.Namespace, Name: name}
+		if err := r.upsertRoleRefRoleBinding(ctx, key, ref, reconcilerName, labelMap); err != nil {
+			return err
+		}
+		wantRBs[key] = true
+	}
+	return r.deleteRoleRefBindings(ctx, client.ObjectKeyFromObject(rs), wantCRBs, wantRBs)
+}
+
+func (r *RootSyncReconciler) upsertRoleRefClusterRoleBinding(ctx context.Context, name, roleName, reconcilerName string, labelMap map[string]string) error {
+	var childCRB rbacv1.ClusterRoleBinding
+	childCRB.Name = name
+
+	op, err := controllerruntime.CreateOrUpdate(ctx, r.client, &childCRB, func() error {
+		r.addLabels(&childCRB, labelMap)
+		childCRB.RoleRef = rolereference(roleName, kinds.ClusterRole().Kind)
+		childCRB.Subjects = []rbacv1.Subject{
+			subject(reconcilerName, configsync.ControllerNamespace, "ServiceAccount"),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.log.Info("ClusterRoleBinding successfully reconciled", operationSubjectName, childCRB.Name, executedOperation, op)
+	}
+	return nil
+}
+
+func (r *RootSyncReconciler) upsertRoleRefRoleBinding(ctx context.Context, key client.ObjectKey, ref v1beta1.RootSyncRoleRef, reconcilerName string, labelMap map[string]string) error {
+	var childRB rbacv1.RoleBinding
+	childRB.Name = key.Name
+	childRB.Namespace = key.Namespace
+
+	op, err := controllerruntime.CreateOrUpdate(ctx, r.client, &childRB, func() error {
+		r.addLabels(&childRB, labelMap)
+		childRB.RoleRef = rolereference(ref.Name, ref.Kind)
+		childRB.Subjects = []rbacv1.Subject{
+			subject(reconcilerName, configsync.ControllerNamespace, "ServiceAccount"),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.log.Info("RoleBinding successfully reconciled", operationSubjectName, key.String(), executedOperation, op)
+	}
+	return nil
+}
+
 func (r *RootSyncReconciler) updateStatus(ctx context.Context, currentRS, rs *v1beta1.RootSync) (bool, error) {
 	rs.Status.ObservedGeneration = rs.Generation
 
@@ -578,6 +745,7 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 		case v1beta1.OciSource:
 			auth = rs.Spec.Oci.Auth
 			gcpSAEmail = rs.Spec.Oci.GCPServiceAccountEmail
+			secretRefName = rs.Spec.Oci.SecretRef.Name
 		case v1beta1.HelmSource:
 			auth = rs.Spec.Helm.Auth
 			gcpSAEmail = rs.Spec.Helm.GCPServiceAccountEmail
@@ -602,11 +770,25 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 		templateSpec.DeprecatedServiceAccount = reconcilerName
 
 		// Mutate secret.secretname to secret reference specified in RootSync CR.
-		// Secret reference is the name of the secret used by git-sync or helm-sync container to
-		// authenticate with the git or helm repository using the authorization method specified
+		// Secret reference is the name of the secret used by git-sync, oci-sync or helm-sync container to
+		// authenticate with the git, OCI or helm repository using the authorization method specified
 		// in the RootSync CR.
 		templateSpec.Volumes = filterVolumes(templateSpec.Volumes, auth, secretRefName, privateCertSecret, rs.Spec.SourceType, r.membership)
+		// Mount the Secret or ConfigMap holding the public keys used by the
+		// oci-sync container to verify the image signatures.
+		if ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) {
+			templateSpec.Volumes = append(templateSpec.Volumes,
+				ociVerificationVolume(rs.Spec.Oci.Verification, ociVerificationRefName(rs.Spec.Oci.Verification)))
+		}
+		// Mount the Secret holding the keys used by the reconciler container to
+		// verify the commit signatures.
+		if gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) {
+			templateSpec.Volumes = append(templateSpec.Volumes, gitVerificationVolume(rs.Spec.Git.Verification.SecretRef.Name))
+		}
 
+		// Mount the optional Secret holding the token of the sync trigger
+		// endpoint, which the reconciler container reads on every request.
+		templateSpec.Volumes = append(templateSpec.Volumes, syncTriggerVolume(ReconcilerResourceName(reconcilerName, SyncTriggerSecretSuffix)))
 		var updatedContainers []corev1.Container
 
 		for _, container := range templateSpec.Containers {
@@ -614,6 +796,10 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 			switch container.Name {
 			case reconcilermanager.Reconciler:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
+				if gitVerificationEnabled(rs.Spec.SourceType, rs.Spec.Git) {
+					container.VolumeMounts = append(container.VolumeMounts, gitVerificationVolumeMount())
+				}
+				container.VolumeMounts = append(container.VolumeMounts, syncTriggerVolumeMount())
 				mutateContainerResource(ctx, &container, rs.Spec.Override, string(RootReconcilerType))
 			case reconcilermanager.HydrationController:
 				container.Env = append(container.Env, containerEnvs[container.Name]...)
@@ -629,6 +815,13 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 					addContainer = false
 				} else {
 					container.Env = append(container.Env, containerEnvs[container.Name]...)
+					container.VolumeMounts = volumeMounts(rs.Spec.Oci.Auth, "", rs.Spec.SourceType, container.VolumeMounts)
+					if authTypeToken(rs.Spec.Oci.Auth) {
+						container.Env = append(container.Env, ociSyncTokenAuthEnv(secretRefName)...)
+					}
+					if ociVerificationEnabled(rs.Spec.SourceType, rs.Spec.Oci) {
+						container.VolumeMounts = append(container.VolumeMounts, ociVerificationVolumeMount())
+					}
 					injectFWICredsToContainer(&container, injectFWICreds)
 					mutateContainerResource(ctx, &container, rs.Spec.Override, string(RootReconcilerType))
 				}
@@ -682,6 +875,9 @@ func (r *RootSyncReconciler) mutationsFor(ctx context.Context, rs *v1beta1.RootS
 		}
 
 		templateSpec.Containers = updatedContainers
-		return nil
+
+		// Merge the pod template override last, so it applies to the
+		// containers and settings of the final pod template.
+		return mutatePodTemplateOverride(&d.Spec.Template, rs.Spec.Override.PodTemplate)
 	}
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -32,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
@@ -131,6 +133,7 @@ func setupRootReconciler(t *testing.T, objs ...client.Object) (*syncerFake.Clien
 	fakeClient := syncerFake.NewClient(t, s, objs...)
 	testReconciler := NewRootSyncReconciler(
 		testCluster,
+		NoClusterLabels,
 		filesystemPollingPeriod,
 		hydrationPollingPeriod,
 		fakeClient,
@@ -1173,6 +1176,248 @@ func TestRootSyncReconcilerRestart(t *testing.T) {
 	t.Log("Deployment successfully updated")
 }
 
+func TestRootSyncSuspend(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey))
+	rs.Spec.Suspend = true
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	fakeClient, testReconciler := setupRootReconciler(t, rs, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	rootContainerEnvs := testReconciler.populateContainerEnvs(ctx, rs, rootReconcilerName)
+	var suspendEnv string
+	for _, env := range rootContainerEnvs[reconcilermanager.Reconciler] {
+		if env.Name == reconcilermanager.Suspend {
+			suspendEnv = env.Value
+		}
+	}
+	if suspendEnv != "true" {
+		t.Errorf("got %s=%q in the reconciler container, want %q", reconcilermanager.Suspend, suspendEnv, "true")
+	}
+	gotRS := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, reqNamespacedName.NamespacedName, gotRS); err != nil {
+		t.Fatal(err)
+	}
+	if !rootsync.IsSuspended(gotRS) {
+		t.Errorf("got conditions %v, want a True Suspended condition", gotRS.Status.Conditions)
+	}
+
+	// Resuming clears the Suspended condition.
+	gotRS.Spec.Suspend = false
+	if err := fakeClient.Update(ctx, gotRS); err != nil {
+		t.Fatalf("failed to update the RootSync request, got error: %v, want error: nil", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	if err := fakeClient.Get(ctx, reqNamespacedName.NamespacedName, gotRS); err != nil {
+		t.Fatal(err)
+	}
+	if rootsync.IsSuspended(gotRS) {
+		t.Errorf("got conditions %v, want no True Suspended condition", gotRS.Status.Conditions)
+	}
+}
+
+func TestRootSyncClusterInfoFromConfigMaps(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey))
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	cm := fake.ConfigMapObject(core.Name(ClusterLabelsConfigMapName), core.Namespace(configsync.ControllerNamespace))
+	cm.Data = map[string]string{"environment": "prod", "region": "us-east1"}
+	values := fake.ConfigMapObject(core.Name(ClusterValuesConfigMapName), core.Namespace(configsync.ControllerNamespace))
+	values.Data = map[string]string{"replicas": "3"}
+	fakeClient, testReconciler := setupRootReconciler(t, rs, cm, values, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+	testReconciler.clusterLabelsSource = ClusterLabelsFromConfigMap
+
+	if got := testReconciler.mapClusterConfigMapToRootSyncs(cm); len(got) != 1 {
+		t.Errorf("got %d requests for the cluster labels ConfigMap, want 1", len(got))
+	}
+	if got := testReconciler.mapClusterConfigMapToRootSyncs(values); len(got) != 1 {
+		t.Errorf("got %d requests for the cluster values ConfigMap, want 1", len(got))
+	}
+	other := fake.ConfigMapObject(core.Name("other"), core.Namespace(configsync.ControllerNamespace))
+	if got := testReconciler.mapClusterConfigMapToRootSyncs(other); len(got) != 0 {
+		t.Errorf("got %d requests for an unrelated ConfigMap, want 0", len(got))
+	}
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	var labelsEnv, valuesEnv string
+	for _, env := range testReconciler.populateContainerEnvs(ctx, rs, rootReconcilerName)[reconcilermanager.Reconciler] {
+		switch env.Name {
+		case reconcilermanager.ClusterLabelsKey:
+			labelsEnv = env.Value
+		case reconcilermanager.ClusterValuesKey:
+			valuesEnv = env.Value
+		}
+	}
+	if want := "environment=prod,region=us-east1"; labelsEnv != want {
+		t.Errorf("got %s=%q in the reconciler container, want %q", reconcilermanager.ClusterLabelsKey, labelsEnv, want)
+	}
+	if want := `{"replicas":"3"}`; valuesEnv != want {
+		t.Errorf("got %s=%q in the reconciler container, want %q", reconcilermanager.ClusterValuesKey, valuesEnv, want)
+	}
+
+	// Invalid labels stall the RootSync until they are fixed.
+	cm.Data = map[string]string{"environment": "not a label value"}
+	if err := fakeClient.Update(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err == nil {
+		t.Error("got no reconciliation error for invalid cluster labels, want an error")
+	}
+}
+
+func TestRootSyncPodTemplateOverride(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(GitSecretConfigKeySSH), rootsyncSecretRef(rootsyncSSHKey))
+	rs.Spec.Override.PodTemplate = &v1beta1.PodTemplateOverride{
+		Labels:            map[string]string{"team": "platform"},
+		Annotations:       map[string]string{"cluster-autoscaler.kubernetes.io/safe-to-evict": "false"},
+		NodeSelector:      map[string]string{"pool": "system"},
+		Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "system", Effect: corev1.TaintEffectNoSchedule}},
+		PriorityClassName: "system-cluster-critical",
+		SecurityContext:   &corev1.PodSecurityContext{RunAsUser: pointer.Int64(2000)},
+		Env: []v1beta1.ContainerEnvSpec{{
+			ContainerName: reconcilermanager.Reconciler,
+			Env: []corev1.EnvVar{
+				{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+				{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+			},
+		}},
+	}
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	fakeClient, testReconciler := setupRootReconciler(t, rs, secretObj(t, rootsyncSSHKey, configsync.AuthSSH, v1beta1.GitSource, core.Namespace(rs.Namespace)))
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	deployKey := client.ObjectKey{Namespace: v1.NSConfigManagementSystem, Name: rootReconcilerName}
+	got := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, deployKey, got); err != nil {
+		t.Fatal(err)
+	}
+	template := got.Spec.Template
+	if template.Labels["team"] != "platform" {
+		t.Errorf("got labels %v, want the team label of the override", template.Labels)
+	}
+	if diff := cmp.Diff(rs.Spec.Override.PodTemplate.NodeSelector, template.Spec.NodeSelector); diff != "" {
+		t.Errorf("unexpected nodeSelector: %s", diff)
+	}
+	if diff := cmp.Diff(rs.Spec.Override.PodTemplate.Tolerations, template.Spec.Tolerations); diff != "" {
+		t.Errorf("unexpected tolerations: %s", diff)
+	}
+	if template.Spec.PriorityClassName != "system-cluster-critical" {
+		t.Errorf("got priorityClassName %q, want %q", template.Spec.PriorityClassName, "system-cluster-critical")
+	}
+	if sc := template.Spec.SecurityContext; sc == nil || sc.RunAsUser == nil || *sc.RunAsUser != 2000 {
+		t.Errorf("got securityContext %v, want runAsUser 2000", sc)
+	}
+	envs := map[string]corev1.EnvVar{}
+	for _, c := range template.Spec.Containers {
+		if c.Name == reconcilermanager.Reconciler {
+			for _, env := range c.Env {
+				envs[env.Name] = env
+			}
+		}
+	}
+	if envs["HTTPS_PROXY"].Value != "http://proxy:3128" {
+		t.Errorf("got HTTPS_PROXY=%q in the reconciler container, want %q", envs["HTTPS_PROXY"].Value, "http://proxy:3128")
+	}
+	if from := envs["NODE_NAME"].ValueFrom; from == nil || from.FieldRef == nil || from.FieldRef.APIVersion != "v1" {
+		t.Errorf("got NODE_NAME from %v in the reconciler container, want the defaulted field reference", from)
+	}
+	firstHash := template.Annotations[metadata.PodTemplateOverrideAnnotationKey]
+	if firstHash == "" {
+		t.Errorf("got no %s annotation on the pod template", metadata.PodTemplateOverrideAnnotationKey)
+	}
+
+	// Drift of the overridden fields is corrected.
+	got.Spec.Template.Spec.NodeSelector = nil
+	got.Spec.Template.Spec.Tolerations = nil
+	if err := fakeClient.Update(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	corrected := &appsv1.Deployment{}
+	if err := fakeClient.Get(ctx, deployKey, corrected); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(template, corrected.Spec.Template); diff != "" {
+		t.Errorf("unexpected pod template after correcting the drift: %s", diff)
+	}
+
+	// Changing the override changes the hash of the pod template.
+	gotRS := &v1beta1.RootSync{}
+	if err := fakeClient.Get(ctx, reqNamespacedName.NamespacedName, gotRS); err != nil {
+		t.Fatal(err)
+	}
+	gotRS.Spec.Override.PodTemplate.NodeSelector = map[string]string{"pool": "other"}
+	if err := fakeClient.Update(ctx, gotRS); err != nil {
+		t.Fatalf("failed to update the RootSync request, got error: %v, want error: nil", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	if err := fakeClient.Get(ctx, deployKey, got); err != nil {
+		t.Fatal(err)
+	}
+	if h := got.Spec.Template.Annotations[metadata.PodTemplateOverrideAnnotationKey]; h == "" || h == firstHash {
+		t.Errorf("got %s=%q after changing the override, want a new hash", metadata.PodTemplateOverrideAnnotationKey, h)
+	}
+
+	// Overriding the environment variables set by Config Sync is rejected.
+	if err := fakeClient.Get(ctx, reqNamespacedName.NamespacedName, gotRS); err != nil {
+		t.Fatal(err)
+	}
+	gotRS.Spec.Override.PodTemplate.Env[0].Env = []corev1.EnvVar{{Name: reconcilermanager.SyncDirKey, Value: "other"}}
+	if err := fakeClient.Update(ctx, gotRS); err != nil {
+		t.Fatalf("failed to update the RootSync request, got error: %v, want error: nil", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	if err := fakeClient.Get(ctx, reqNamespacedName.NamespacedName, gotRS); err != nil {
+		t.Fatal(err)
+	}
+	if !rootsync.IsStalled(gotRS) {
+		t.Errorf("got conditions %v for an environment variable set by Config Sync, want a True Stalled condition", gotRS.Status.Conditions)
+	}
+}
+
+func TestRootSyncPodTemplateOverrideValidation(t *testing.T) {
+	rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(configsync.AuthNone))
+	rs.Spec.Override.PodTemplate = &v1beta1.PodTemplateOverride{
+		Labels: map[string]string{metadata.ReconcilerLabel: "other"},
+	}
+	fakeClient, testReconciler := setupRootReconciler(t, rs)
+	if _, err := testReconciler.Reconcile(context.Background(), namespacedName(rs.Name, rs.Namespace)); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+	gotRS := &v1beta1.RootSync{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(rs), gotRS); err != nil {
+		t.Fatal(err)
+	}
+	if !rootsync.IsStalled(gotRS) {
+		t.Errorf("got conditions %v, want a True Stalled condition", gotRS.Status.Conditions)
+	}
+}
+
 // This test reconcilers multiple RootSyncs with different auth types.
 // - rs1: "my-root-sync", auth type is ssh.
 // - rs2: uses the default "root-sync" name and auth type is gcenode
@@ -1572,6 +1817,192 @@ func TestMultipleRootSyncs(t *testing.T) {
 	validateGeneratedResourcesDeleted(t, fakeClient, rootReconcilerName5, rs5.Spec.Git.SecretRef.Name)
 }
 
+func TestRootSyncRoleRefs(t *testing.T) {
+	// Mock out parseDeployment for testing.
+	parseDeployment = parsedDeployment
+
+	rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(configsync.AuthNone))
+	rs.Spec.RoleRefs = []v1beta1.RootSyncRoleRef{
+		{Kind: "ClusterRole", Name: "view"},
+		{Kind: "Role", Name: "deployer", Namespace: "bookstore"},
+		{Kind: "ClusterRole", Name: "edit", Namespace: "shipping"},
+	}
+	reqNamespacedName := namespacedName(rs.Name, rs.Namespace)
+	fakeClient, testReconciler := setupRootReconciler(t, rs)
+
+	ctx := context.Background()
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+	}
+
+	labels := core.Labels(map[string]string{
+		metadata.SyncNamespaceLabel: rs.Namespace,
+		metadata.SyncNameLabel:      rs.Name,
+	})
+	sub := subject(rootReconcilerName, configsync.ControllerNamespace, "ServiceAccount")
+
+	baseCRB := fake.ClusterRoleBindingObject(core.Name(RootSyncBaseBindingName(rootReconcilerName)), labels)
+	baseCRB.RoleRef = rolereference(RootSyncPermissionsName(), "ClusterRole")
+	baseCRB.Subjects = []rbacv1.Subject{sub}
+
+	viewCRB := fake.ClusterRoleBindingObject(core.Name(RootSyncRoleRefBindingName(rootReconcilerName, rs.Spec.RoleRefs[0])), labels)
+	viewCRB.RoleRef = rolereference("view", "ClusterRole")
+	viewCRB.Subjects = []rbacv1.Subject{sub}
+
+	deployerRB := fake.RoleBindingObject(core.Name(RootSyncRoleRefBindingName(rootReconcilerName, rs.Spec.RoleRefs[1])), core.Namespace("bookstore"), labels)
+	deployerRB.RoleRef = rolereference("deployer", "Role")
+	deployerRB.Subjects = []rbacv1.Subject{sub}
+
+	editRB := fake.RoleBindingObject(core.Name(RootSyncRoleRefBindingName(rootReconcilerName, rs.Spec.RoleRefs[2])), core.Namespace("shipping"), labels)
+	editRB.RoleRef = rolereference("edit", "ClusterRole")
+	editRB.Subjects = []rbacv1.Subject{sub}
+
+	for _, want := range []client.Object{baseCRB, viewCRB, deployerRB, editRB} {
+		if err := validateRoleRefBinding(want, fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+	// The reconciler is not bound to cluster-admin.
+	if err := validateResourceDeleted(core.IDOf(clusterrolebinding(RootSyncPermissionsName(), rootReconcilerName)), fakeClient); err != nil {
+		t.Error(err)
+	}
+
+	// Bindings to the roles removed from spec.roleRefs are deleted.
+	rs.Spec.RoleRefs = rs.Spec.RoleRefs[:1]
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the root sync request, got error: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	for _, want := range []client.Object{baseCRB, viewCRB} {
+		if err := validateRoleRefBinding(want, fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, deleted := range []client.Object{deployerRB, editRB} {
+		if err := validateResourceDeleted(core.IDOf(deleted), fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Without spec.roleRefs, the reconciler is bound to cluster-admin again.
+	rs.Spec.RoleRefs = nil
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the root sync request, got error: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	if err := validateClusterRoleBinding(clusterrolebinding(RootSyncPermissionsName(), rootReconcilerName), fakeClient); err != nil {
+		t.Error(err)
+	}
+	for _, deleted := range []client.Object{baseCRB, viewCRB} {
+		if err := validateResourceDeleted(core.IDOf(deleted), fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The bindings are garbage collected when the RootSync is deleted.
+	rs.Spec.RoleRefs = []v1beta1.RootSyncRoleRef{{Kind: "ClusterRole", Name: "view"}}
+	if err := fakeClient.Update(ctx, rs); err != nil {
+		t.Fatalf("failed to update the root sync request, got error: %v", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	if err := validateRoleRefBinding(viewCRB, fakeClient); err != nil {
+		t.Error(err)
+	}
+	if err := fakeClient.Delete(ctx, rs); err != nil {
+		t.Fatalf("failed to delete the root sync request, got error: %v, want error: nil", err)
+	}
+	if _, err := testReconciler.Reconcile(ctx, reqNamespacedName); err != nil {
+		t.Fatalf("unexpected reconciliation error upon request update, got error: %q, want error: nil", err)
+	}
+	for _, deleted := range []client.Object{baseCRB, viewCRB} {
+		if err := validateResourceDeleted(core.IDOf(deleted), fakeClient); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRootSyncRoleRefsValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ref     v1beta1.RootSyncRoleRef
+		wantErr func(client.Object) error
+	}{
+		{
+			name: "missing name",
+			ref:  v1beta1.RootSyncRoleRef{Kind: "ClusterRole"},
+			wantErr: func(o client.Object) error {
+				return validate.MissingRoleRefName(o)
+			},
+		},
+		{
+			name: "invalid kind",
+			ref:  v1beta1.RootSyncRoleRef{Kind: "Group", Name: "admins"},
+			wantErr: func(o client.Object) error {
+				return validate.InvalidRoleRefKind(o, "Group")
+			},
+		},
+		{
+			name: "Role without namespace",
+			ref:  v1beta1.RootSyncRoleRef{Kind: "Role", Name: "deployer"},
+			wantErr: func(o client.Object) error {
+				return validate.MissingRoleRefNamespace(o, "deployer")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := rootSync(rootsyncName, rootsyncRef(gitRevision), rootsyncBranch(branch), rootsyncSecretType(configsync.AuthNone))
+			rs.Spec.RoleRefs = []v1beta1.RootSyncRoleRef{tc.ref}
+			fakeClient, testReconciler := setupRootReconciler(t, rs)
+			if _, err := testReconciler.Reconcile(context.Background(), namespacedName(rs.Name, rs.Namespace)); err != nil {
+				t.Fatalf("unexpected reconciliation error, got error: %q, want error: nil", err)
+			}
+			wantRs := rs.DeepCopy()
+			rootsync.SetStalled(wantRs, "Validation", tc.wantErr(rs))
+			if err := validateRootSyncStatus(wantRs, fakeClient); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// validateRoleRefBinding validates that the RoleRef, Subjects and labels of the
+// binding match those of the binding found in the fakeClient.
+func validateRoleRefBinding(want client.Object, fakeClient *syncerFake.Client) error {
+	got, found := fakeClient.Objects[core.IDOf(want)]
+	if !found {
+		return errors.Errorf("binding %s not found", core.IDOf(want))
+	}
+	if diff := cmp.Diff(want.GetLabels(), got.GetLabels()); diff != "" {
+		return errors.Errorf("binding %s has unexpected labels: %s", core.IDOf(want), diff)
+	}
+	switch w := want.(type) {
+	case *rbacv1.ClusterRoleBinding:
+		g := got.(*rbacv1.ClusterRoleBinding)
+		if diff := cmp.Diff(w.RoleRef, g.RoleRef); diff != "" {
+			return errors.Errorf("binding %s has unexpected roleRef: %s", core.IDOf(want), diff)
+		}
+		if diff := cmp.Diff(w.Subjects, g.Subjects); diff != "" {
+			return errors.Errorf("binding %s has unexpected subjects: %s", core.IDOf(want), diff)
+		}
+	case *rbacv1.RoleBinding:
+		g := got.(*rbacv1.RoleBinding)
+		if diff := cmp.Diff(w.RoleRef, g.RoleRef); diff != "" {
+			return errors.Errorf("binding %s has unexpected roleRef: %s", core.IDOf(want), diff)
+		}
+		if diff := cmp.Diff(w.Subjects, g.Subjects); diff != "" {
+			return errors.Errorf("binding %s has unexpected subjects: %s", core.IDOf(want), diff)
+		}
+	}
+	return nil
+}
+
 func TestMapSecretToRootSyncs(t *testing.T) {
 	testSecretName := "ssh-test"
 	rootSyncs := map[string][]*v1beta1.RootSync{
@@ -2228,9 +2659,27 @@ func rootSyncDeployment(reconcilerName string, muts ...depMutator) *appsv1.Deplo
 	for _, mut := range muts {
 		mut(dep)
 	}
+	syncTriggerMutator(reconcilerName)(dep)
 	return dep
 }
 
+// syncTriggerMutator mounts the sync trigger token in the reconciler container,
+// as done for every reconciler.
+func syncTriggerMutator(reconcilerName string) depMutator {
+	return func(dep *appsv1.Deployment) {
+		if len(dep.Spec.Template.Spec.Containers) == 0 {
+			return
+		}
+		dep.Spec.Template.Spec.Volumes = append(dep.Spec.Template.Spec.Volumes,
+			syncTriggerVolume(ReconcilerResourceName(reconcilerName, SyncTriggerSecretSuffix)))
+		for i, con := range dep.Spec.Template.Spec.Containers {
+			if con.Name == reconcilermanager.Reconciler {
+				dep.Spec.Template.Spec.Containers[i].VolumeMounts = append(con.VolumeMounts, syncTriggerVolumeMount())
+			}
+		}
+	}
+}
+
 func setServiceAccountName(name string) depMutator {
 	return func(dep *appsv1.Deployment) {
 		dep.Spec.Template.Spec.ServiceAccountName = name
@@ -2251,6 +2700,13 @@ func helmSecretMutator(secretName string) depMutator {
 	}
 }
 
+func ociSecretMutator(secretName string) depMutator {
+	return func(dep *appsv1.Deployment) {
+		dep.Spec.Template.Spec.Volumes = ociDeploymentSecretVolumes(secretName)
+		dep.Spec.Template.Spec.Containers = ociSecretMountContainers()
+	}
+}
+
 func privateCertSecretMutator(secretName, privateCertSecretName string) depMutator {
 	return func(dep *appsv1.Deployment) {
 		dep.Spec.Template.Spec.Volumes = deploymentSecretVolumes(secretName, privateCertSecretName)
@@ -2261,7 +2717,7 @@ func privateCertSecretMutator(secretName, privateCertSecretName string) depMutat
 func envVarMutator(envName, secretName, key string) depMutator {
 	return func(dep *appsv1.Deployment) {
 		for i, con := range dep.Spec.Template.Spec.Containers {
-			if con.Name == reconcilermanager.GitSync || con.Name == reconcilermanager.HelmSync {
+			if con.Name == reconcilermanager.GitSync || con.Name == reconcilermanager.HelmSync || con.Name == reconcilermanager.OciSync {
 				dep.Spec.Template.Spec.Containers[i].Env = append(dep.Spec.Template.Spec.Containers[i].Env, corev1.EnvVar{
 					Name: envName,
 					ValueFrom: &corev1.EnvVarSource{
@@ -2544,6 +3000,28 @@ func helmSecretMountContainers() []corev1.Container {
 	}
 }
 
+func ociSecretMountContainers() []corev1.Container {
+	ociSyncVolumeMounts := []corev1.VolumeMount{
+		{Name: "oci-creds", MountPath: "/etc/oci-secret", ReadOnly: true},
+		{Name: "repo", MountPath: "/repo"},
+	}
+	return []corev1.Container{
+		{
+			Name:      reconcilermanager.Reconciler,
+			Resources: defaultResourceRequirements(),
+		},
+		{
+			Name:      reconcilermanager.HydrationController,
+			Resources: defaultResourceRequirements(),
+		},
+		{
+			Name:         reconcilermanager.OciSync,
+			Resources:    defaultResourceRequirements(),
+			VolumeMounts: ociSyncVolumeMounts,
+		},
+	}
+}
+
 func noneGitContainers() []corev1.Container {
 	return []corev1.Container{
 		{
@@ -2648,3 +3126,15 @@ func helmDeploymentSecretVolumes(secretName string) []corev1.Volume {
 	}
 	return volumes
 }
+
+func ociDeploymentSecretVolumes(secretName string) []corev1.Volume {
+	volumes := []corev1.Volume{
+		{Name: "repo"},
+		{Name: "oci-creds", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+			},
+		}},
+	}
+	return volumes
+}
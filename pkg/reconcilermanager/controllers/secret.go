@@ -35,20 +35,26 @@ func shouldUpsertGitSecret(rs *v1beta1.RepoSync) bool {
 func shouldUpsertHelmSecret(rs *v1beta1.RepoSync) bool {
 	return rs.Spec.SourceType == string(v1beta1.HelmSource) && !SkipForAuth(rs.Spec.Helm.Auth)
 }
+func shouldUpsertOciSecret(rs *v1beta1.RepoSync) bool {
+	return rs.Spec.SourceType == string(v1beta1.OciSource) && !SkipForAuth(rs.Spec.Oci.Auth)
+}
 
 // upsertSecret creates or updates the secret in config-management-system
 // namespace using the existing secret in the reposync.namespace.
 func upsertSecret(ctx context.Context, rs *v1beta1.RepoSync, c client.Client, reconcilerName string) error {
-	// Secret is only created if sourceType is git or helm and auth is not 'none', 'gcenode', or 'gcpserviceaccount'.
-	if !shouldUpsertGitSecret(rs) && !shouldUpsertHelmSecret(rs) {
+	// Secret is only created if sourceType is git, oci or helm and auth is not 'none', 'gcenode', or 'gcpserviceaccount'.
+	if !shouldUpsertGitSecret(rs) && !shouldUpsertHelmSecret(rs) && !shouldUpsertOciSecret(rs) {
 		return nil
 	}
 	// namespaceSecret represent secret in reposync.namespace.
 	namespaceSecret := &corev1.Secret{}
 	var namespaceSecretName string
-	if v1beta1.SourceType(rs.Spec.SourceType) == v1beta1.GitSource {
+	switch v1beta1.SourceType(rs.Spec.SourceType) {
+	case v1beta1.GitSource:
 		namespaceSecretName = rs.Spec.SecretRef.Name
-	} else {
+	case v1beta1.OciSource:
+		namespaceSecretName = rs.Spec.Oci.SecretRef.Name
+	default:
 		namespaceSecretName = rs.Spec.Helm.SecretRef.Name
 	}
 	if err := get(ctx, namespaceSecretName, rs.Namespace, namespaceSecret, c); err != nil {
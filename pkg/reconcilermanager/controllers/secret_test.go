@@ -79,7 +79,7 @@ func secretData(t *testing.T, data string, auth configsync.AuthType, sourceType
 	if err != nil {
 		t.Fatalf("failed to marshal test key: %v", err)
 	}
-	if auth == configsync.AuthToken && sourceType == v1beta1.HelmSource {
+	if auth == configsync.AuthToken && (sourceType == v1beta1.HelmSource || sourceType == v1beta1.OciSource) {
 		return map[string][]byte{
 			"username": key,
 			"password": key,
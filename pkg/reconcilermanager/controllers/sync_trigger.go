@@ -0,0 +1,100 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/pointer"
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// RepoSyncTriggerSecretName returns the name of the optional Secret, in the
+// namespace of the RepoSync named rsName, which holds the token of the sync
+// trigger endpoint of its reconciler.
+// e.g. repo-sync-sync-trigger
+func RepoSyncTriggerSecretName(rsName string) string {
+	return ReconcilerResourceName(rsName, SyncTriggerSecretSuffix)
+}
+
+// syncTriggerVolume returns the volume of the optional Secret named secretName
+// in the config-management-system namespace, which holds the token of the sync
+// trigger endpoint. The token is mounted as a file, rather than set as an
+// environment variable, so that a rotated token applies without restarting the
+// reconciler.
+func syncTriggerVolume(secretName string) corev1.Volume {
+	return corev1.Volume{
+		Name: SyncTriggerVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items: []corev1.KeyToPath{{
+					Key:  SyncTriggerSecretKey,
+					Path: SyncTriggerSecretKey,
+				}},
+				DefaultMode: &defaultMode,
+				Optional:    pointer.BoolPtr(true),
+			},
+		},
+	}
+}
+
+// syncTriggerVolumeMount returns the VolumeMount of the sync trigger token.
+func syncTriggerVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      SyncTriggerVolume,
+		MountPath: SyncTriggerPath,
+		ReadOnly:  true,
+	}
+}
+
+// upsertSyncTrigger copies the optional Secret holding the sync trigger token
+// from the reposync.namespace into the config-management-system namespace,
+// where it can be mounted by the namespace reconciler. The copy is deleted
+// when the Secret is, which disables the external sync trigger endpoint.
+func upsertSyncTrigger(ctx context.Context, rs *v1beta1.RepoSync, c client.Client, reconcilerName string) error {
+	copied := &corev1.Secret{}
+	copied.Name = ReconcilerResourceName(reconcilerName, SyncTriggerSecretSuffix)
+	copied.Namespace = v1.NSConfigManagementSystem
+
+	name := RepoSyncTriggerSecretName(rs.Name)
+	namespaceSecret := &corev1.Secret{}
+	if err := get(ctx, name, rs.Namespace, namespaceSecret, c); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error while retrieving the sync trigger secret")
+		}
+		copied.SetGroupVersionKind(kinds.Secret())
+		if err := c.Delete(ctx, copied); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the stale sync trigger secret %s", copied.Name)
+		}
+		return nil
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, c, copied, func() error {
+		core.SetLabel(copied, metadata.SyncNamespaceLabel, rs.Namespace)
+		core.SetLabel(copied, metadata.SyncNameLabel, rs.Name)
+		copied.Data = namespaceSecret.Data
+		return nil
+	})
+	return errors.Wrapf(err, "failed to upsert the sync trigger secret %s", copied.Name)
+}
@@ -15,12 +15,18 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
@@ -29,6 +35,7 @@ import (
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/importer/filesystem"
 	"kpt.dev/configsync/pkg/reconcilermanager"
+	"sigs.k8s.io/yaml"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -76,9 +83,51 @@ func hydrationEnvs(sourceType string, gitConfig *v1beta1.Git, ociConfig *v1beta1
 	return result
 }
 
+// reconcilerOptions are the options of the reconciler container.
+type reconcilerOptions struct {
+	// clusterName is the name of the cluster.
+	clusterName string
+	// clusterLabels are the labels of the cluster.
+	clusterLabels map[string]string
+	// clusterValues are the values of the ${cluster.values.KEY} variables.
+	clusterValues map[string]string
+	// syncName is the name of the RootSync or RepoSync.
+	syncName string
+	// reconcilerName is the name of the reconciler.
+	reconcilerName string
+	// reconcilerScope is the scope of the reconciler.
+	reconcilerScope declared.Scope
+	// sourceType is the type of the source.
+	sourceType string
+	// gitConfig is the git source, if sourceType is git.
+	gitConfig *v1beta1.Git
+	// ociConfig is the OCI source, if sourceType is oci.
+	ociConfig *v1beta1.Oci
+	// helmConfig is the helm source, if sourceType is helm.
+	helmConfig *v1beta1.Helm
+	// pollPeriod is the period of the polling of the filesystem.
+	pollPeriod string
+	// statusMode sets whether the applier injects the status of the resources.
+	statusMode string
+	// reconcileTimeout is the timeout of the applier waiting for the resources
+	// to become current.
+	reconcileTimeout string
+	// dryRun sets whether the reconciler only reports the changes it would make.
+	dryRun bool
+	// auditOnly sets whether the reconciler only reports the drift.
+	auditOnly bool
+	// deletionBudgets are the deletion budgets of the RootSync or RepoSync.
+	deletionBudgets []v1beta1.DeletionBudget
+	// syncWindows are the sync windows of the RootSync or RepoSync.
+	syncWindows []v1beta1.SyncWindow
+	// suspend sets whether the syncing is suspended.
+	suspend bool
+}
+
 // reconcilerEnvs returns environment variables for namespace reconciler.
-func reconcilerEnvs(clusterName, syncName, reconcilerName string, reconcilerScope declared.Scope, sourceType string, gitConfig *v1beta1.Git, ociConfig *v1beta1.Oci, helmConfig *v1beta1.Helm, pollPeriod, statusMode string, reconcileTimeout string) []corev1.EnvVar {
+func reconcilerEnvs(opts reconcilerOptions) []corev1.EnvVar {
 	var result []corev1.EnvVar
+	statusMode := opts.statusMode
 	if statusMode == "" {
 		statusMode = applier.StatusEnabled
 	}
@@ -86,28 +135,28 @@ func reconcilerEnvs(clusterName, syncName, reconcilerName string, reconcilerScop
 	var syncBranch string
 	var syncRevision string
 	var syncDir string
-	switch v1beta1.SourceType(sourceType) {
+	switch v1beta1.SourceType(opts.sourceType) {
 	case v1beta1.OciSource:
-		syncRepo = ociConfig.Image
-		syncDir = ociConfig.Dir
+		syncRepo = opts.ociConfig.Image
+		syncDir = opts.ociConfig.Dir
 	case v1beta1.HelmSource:
-		syncRepo = helmConfig.Repo
-		syncDir = helmConfig.Chart
-		if helmConfig.Version != "" {
-			syncRevision = helmConfig.Version
+		syncRepo = opts.helmConfig.Repo
+		syncDir = opts.helmConfig.Chart
+		if opts.helmConfig.Version != "" {
+			syncRevision = opts.helmConfig.Version
 		} else {
 			syncRevision = "latest"
 		}
 	case v1beta1.GitSource:
-		syncRepo = gitConfig.Repo
-		syncDir = gitConfig.Dir
-		if gitConfig.Branch != "" {
-			syncBranch = gitConfig.Branch
+		syncRepo = opts.gitConfig.Repo
+		syncDir = opts.gitConfig.Dir
+		if opts.gitConfig.Branch != "" {
+			syncBranch = opts.gitConfig.Branch
 		} else {
 			syncBranch = "master"
 		}
-		if gitConfig.Revision != "" {
-			syncRevision = gitConfig.Revision
+		if opts.gitConfig.Revision != "" {
+			syncRevision = opts.gitConfig.Revision
 		} else {
 			syncRevision = "HEAD"
 		}
@@ -116,23 +165,23 @@ func reconcilerEnvs(clusterName, syncName, reconcilerName string, reconcilerScop
 	result = append(result,
 		corev1.EnvVar{
 			Name:  reconcilermanager.ClusterNameKey,
-			Value: clusterName,
+			Value: opts.clusterName,
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.ScopeKey,
-			Value: string(reconcilerScope),
+			Value: string(opts.reconcilerScope),
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.SyncNameKey,
-			Value: syncName,
+			Value: opts.syncName,
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.ReconcilerNameKey,
-			Value: reconcilerName,
+			Value: opts.reconcilerName,
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.NamespaceNameKey,
-			Value: string(reconcilerScope),
+			Value: string(opts.reconcilerScope),
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.SyncDirKey,
@@ -144,7 +193,7 @@ func reconcilerEnvs(clusterName, syncName, reconcilerName string, reconcilerScop
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.SourceTypeKey,
-			Value: sourceType,
+			Value: opts.sourceType,
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.StatusMode,
@@ -152,14 +201,71 @@ func reconcilerEnvs(clusterName, syncName, reconcilerName string, reconcilerScop
 		},
 		corev1.EnvVar{
 			Name:  reconcilermanager.ReconcileTimeout,
-			Value: reconcileTimeout,
+			Value: opts.reconcileTimeout,
+		},
+		corev1.EnvVar{
+			Name:  reconcilermanager.DryRun,
+			Value: strconv.FormatBool(opts.dryRun),
+		},
+		corev1.EnvVar{
+			Name:  reconcilermanager.AuditOnly,
+			Value: strconv.FormatBool(opts.auditOnly),
 		},
 		// Add Filesystem Polling Period.
 		corev1.EnvVar{
 			Name:  reconcilermanager.ReconcilerPollingPeriod,
-			Value: pollPeriod,
+			Value: opts.pollPeriod,
 		})
 
+	if gitVerificationEnabled(opts.sourceType, opts.gitConfig) {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.GitVerificationKeysDir,
+			Value: GitVerificationPath,
+		})
+	}
+
+	if len(opts.deletionBudgets) > 0 {
+		if budgets, err := json.Marshal(opts.deletionBudgets); err != nil {
+			klog.Errorf("Failed to encode the deletion budgets: %v", err)
+		} else {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.DeletionBudgets,
+				Value: string(budgets),
+			})
+		}
+	}
+	if len(opts.syncWindows) > 0 {
+		if windows, err := json.Marshal(opts.syncWindows); err != nil {
+			klog.Errorf("Failed to encode the sync windows: %v", err)
+		} else {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.SyncWindows,
+				Value: string(windows),
+			})
+		}
+	}
+	if opts.suspend {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.Suspend,
+			Value: strconv.FormatBool(opts.suspend),
+		})
+	}
+	if len(opts.clusterLabels) > 0 {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.ClusterLabelsKey,
+			Value: labels.Set(opts.clusterLabels).String(),
+		})
+	}
+	if len(opts.clusterValues) > 0 {
+		if values, err := json.Marshal(opts.clusterValues); err != nil {
+			klog.Errorf("Failed to encode the cluster values: %v", err)
+		} else {
+			result = append(result, corev1.EnvVar{
+				Name:  reconcilermanager.ClusterValuesKey,
+				Value: string(values),
+			})
+		}
+	}
 	if syncBranch != "" {
 		result = append(result, corev1.EnvVar{
 			Name:  reconcilermanager.SourceBranchKey,
@@ -184,55 +290,92 @@ func sourceFormatEnv(format string) corev1.EnvVar {
 }
 
 // ociSyncEnvs returns the environment variables for the oci-sync container.
-func ociSyncEnvs(image string, auth configsync.AuthType, period float64) []corev1.EnvVar {
+func ociSyncEnvs(ociConfig *v1beta1.Oci) []corev1.EnvVar {
 	var result []corev1.EnvVar
 	result = append(result, corev1.EnvVar{
 		Name:  reconcilermanager.OciSyncImage,
-		Value: image,
+		Value: ociConfig.Image,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.OciSyncAuth,
-		Value: string(auth),
+		Value: string(ociConfig.Auth),
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.OciSyncWait,
-		Value: fmt.Sprintf("%f", period),
+		Value: fmt.Sprintf("%f", v1beta1.GetPeriodSecs(ociConfig.Period)),
 	})
+	if ociConfig.Verification != nil {
+		result = append(result, corev1.EnvVar{
+			Name:  reconcilermanager.OciSyncVerificationKeysDir,
+			Value: OciVerificationPath,
+		})
+	}
 	return result
 }
 
+const (
+	// oci-sync container specific environment variables.
+	ociSyncName     = "OCI_SYNC_USERNAME"
+	ociSyncPassword = "OCI_SYNC_PASSWORD"
+)
+
 const (
 	// helm-sync container specific environment variables.
 	helmSyncName     = "HELM_SYNC_USERNAME"
 	helmSyncPassword = "HELM_SYNC_PASSWORD"
 )
 
-// helmSyncEnvs returns the environment variables for the oci-sync container.
-func helmSyncEnvs(repo, chart, version, releaseName, namespace string, auth configsync.AuthType, period float64) []corev1.EnvVar {
+// helmSyncEnvs returns the environment variables for the helm-sync container.
+func helmSyncEnvs(helmConfig *v1beta1.Helm) []corev1.EnvVar {
 	var result []corev1.EnvVar
 	result = append(result, corev1.EnvVar{
 		Name:  reconcilermanager.HelmRepo,
-		Value: repo,
+		Value: helmConfig.Repo,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmChart,
-		Value: chart,
+		Value: helmConfig.Chart,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmChartVersion,
-		Value: version,
+		Value: helmConfig.Version,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmReleaseName,
-		Value: releaseName,
+		Value: helmConfig.ReleaseName,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmReleaseNamespace,
-		Value: namespace,
+		Value: helmConfig.Namespace,
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmAuthType,
-		Value: string(auth),
+		Value: string(helmConfig.Auth),
 	}, corev1.EnvVar{
 		Name:  reconcilermanager.HelmSyncWait,
-		Value: fmt.Sprintf("%f", period),
+		Value: fmt.Sprintf("%f", v1beta1.GetPeriodSecs(helmConfig.Period)),
+	}, corev1.EnvVar{
+		Name:  reconcilermanager.HelmValuesYAML,
+		Value: helmValuesYAML(helmConfig.Values),
+	}, corev1.EnvVar{
+		Name:  reconcilermanager.HelmValuesFiles,
+		Value: strings.Join(helmConfig.ValuesFiles, ","),
+	}, corev1.EnvVar{
+		Name:  reconcilermanager.HelmIncludeCRDs,
+		Value: strconv.FormatBool(helmConfig.IncludeCRDs),
 	})
 	return result
 }
 
+// helmValuesYAML serializes the inline Helm values so that they can be passed
+// to the helm-sync container. It returns an empty string if no values are set.
+func helmValuesYAML(values *unstructured.Unstructured) string {
+	if values == nil || len(values.Object) == 0 {
+		return ""
+	}
+	data, err := yaml.Marshal(values.Object)
+	if err != nil {
+		// The values were decoded from JSON by the API server, so re-encoding
+		// them is not expected to fail.
+		klog.Errorf("failed to marshal the Helm values: %v", err)
+		return ""
+	}
+	return string(data)
+}
+
 // helmSyncTokenAuthEnv returns environment variables for helm-sync container for 'token' Auth.
 func helmSyncTokenAuthEnv(secretRef string) []corev1.EnvVar {
 	helmSyncUsername := &corev1.EnvVarSource{
@@ -265,6 +408,34 @@ func helmSyncTokenAuthEnv(secretRef string) []corev1.EnvVar {
 	}
 }
 
+// ociSyncTokenAuthEnv returns environment variables for oci-sync container for 'token' Auth.
+func ociSyncTokenAuthEnv(secretRef string) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name: ociSyncName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: secretRef,
+					},
+					Key: OciSecretKeyUsername,
+				},
+			},
+		},
+		{
+			Name: ociSyncPassword,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: secretRef,
+					},
+					Key: OciSecretKeyPassword,
+				},
+			},
+		},
+	}
+}
+
 func ownerReference(kind, name string, uid types.UID) metav1.OwnerReference {
 	return metav1.OwnerReference{
 		APIVersion:         v1beta1.SchemeGroupVersion.String(),
@@ -32,6 +32,9 @@ const GitCredentialVolume = "git-creds"
 // HelmCredentialVolume is the volume name of the git credentials.
 const HelmCredentialVolume = "helm-creds"
 
+// OciCredentialVolume is the volume name of the OCI credentials.
+const OciCredentialVolume = "oci-creds"
+
 // PrivateCertVolume is the volume name of the private certificate.
 const PrivateCertVolume = "private-cert"
 
@@ -50,7 +53,7 @@ var defaultMode int32 = 0644
 var expirationSeconds = int64((48 * time.Hour).Seconds())
 
 // filterVolumes returns the volumes depending on different auth types.
-// If authType is `none`, `gcenode`, or `gcpserviceaccount`, it won't mount the `git-creds` volume.
+// If authType is `none`, `gcenode`, or `gcpserviceaccount`, it won't mount the `git-creds`, `helm-creds` or `oci-creds` volume.
 // If authType is `gcpserviceaccount` with fleet membership available, it also mounts a `gcp-ksa` volume.
 func filterVolumes(existing []corev1.Volume, authType configsync.AuthType, secretName, privateCertSecret, sourceType string, membership *hubv1.Membership) []corev1.Volume {
 	var updatedVolumes []corev1.Volume
@@ -67,6 +70,11 @@ func filterVolumes(existing []corev1.Volume, authType configsync.AuthType, secre
 				continue
 			}
 			volume.Secret.SecretName = secretName
+		} else if volume.Name == OciCredentialVolume {
+			if SkipForAuth(authType) || sourceType != string(v1beta1.OciSource) {
+				continue
+			}
+			volume.Secret.SecretName = secretName
 		}
 		updatedVolumes = append(updatedVolumes, volume)
 	}
@@ -141,6 +149,9 @@ func volumeMounts(auth configsync.AuthType, privateCertSecret, sourceType string
 		if volume.Name == HelmCredentialVolume && (SkipForAuth(auth) || sourceType != string(v1beta1.HelmSource)) {
 			continue
 		}
+		if volume.Name == OciCredentialVolume && (SkipForAuth(auth) || sourceType != string(v1beta1.OciSource)) {
+			continue
+		}
 		volumeMount = append(volumeMount, volume)
 	}
 	sort.Slice(volumeMount[:], func(i, j int) bool {
@@ -0,0 +1,132 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package drift keeps track of the managed resources which have drifted from
+// their declarations. The remediator records the drift instead of correcting it
+// when it runs in audit-only mode.
+package drift
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Report is a threadsafe record of the drifted resources.
+type Report struct {
+	mux       sync.Mutex
+	resources map[core.ID]v1beta1.DriftedResource
+}
+
+// NewReport returns an empty Report.
+func NewReport() *Report {
+	return &Report{
+		resources: make(map[core.ID]v1beta1.DriftedResource),
+	}
+}
+
+// Set records the drift of the resource with the given ID. If the resource had
+// already drifted, the time at which the drift was first detected is kept.
+func (r *Report) Set(ctx context.Context, id core.ID, drifted v1beta1.DriftedResource) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if existing, found := r.resources[id]; found {
+		drifted.DetectedAt = existing.DetectedAt
+	} else {
+		klog.Infof("Drift detected for %s: %s %v", id, drifted.Type, drifted.Fields)
+	}
+	r.resources[id] = drifted
+	metrics.RecordDriftedResources(ctx, len(r.resources))
+}
+
+// Remove clears the drift of the resource with the given ID, once it matches
+// its declaration again.
+func (r *Report) Remove(ctx context.Context, id core.ID) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, found := r.resources[id]; !found {
+		return
+	}
+	klog.Infof("Drift resolved for %s", id)
+	delete(r.resources, id)
+	metrics.RecordDriftedResources(ctx, len(r.resources))
+}
+
+// Resources returns the drifted resources, sorted by ID.
+func (r *Report) Resources() []v1beta1.DriftedResource {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	ids := make([]core.ID, 0, len(r.resources))
+	for id := range r.resources {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	resources := make([]v1beta1.DriftedResource, len(ids))
+	for i, id := range ids {
+		resources[i] = r.resources[id]
+	}
+	return resources
+}
+
+// NewDriftedResource returns the drift of obj, detected now.
+func NewDriftedResource(driftType v1beta1.DriftType, obj client.Object, fields []string, actor string) v1beta1.DriftedResource {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return v1beta1.DriftedResource{
+		Type: driftType,
+		Resource: v1beta1.ResourceRef{
+			SourcePath: status.GetSourceAnnotation(obj),
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+			GVK: metav1.GroupVersionKind{
+				Group:   gvk.Group,
+				Version: gvk.Version,
+				Kind:    gvk.Kind,
+			},
+		},
+		Fields:     fields,
+		Actor:      actor,
+		DetectedAt: metav1.Now(),
+	}
+}
+
+// Actor returns the field manager which last changed obj, other than Config
+// Sync, or an empty string if it is unknown.
+func Actor(obj client.Object) string {
+	var actor string
+	var last metav1.Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == configsync.FieldManager || entry.Time == nil {
+			continue
+		}
+		if actor == "" || last.Before(entry.Time) {
+			actor = entry.Manager
+			last = *entry.Time
+		}
+	}
+	return actor
+}
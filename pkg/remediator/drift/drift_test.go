@@ -0,0 +1,80 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	report := NewReport()
+	cm := fake.ConfigMapObject(core.Name("cm"), core.Namespace("foo"))
+	role := fake.RoleObject(core.Name("role"), core.Namespace("foo"))
+
+	first := NewDriftedResource(v1beta1.DriftModified, cm, []string{"data.a"}, "kubectl-edit")
+	first.DetectedAt = metav1.NewTime(time.Unix(1000, 0))
+	report.Set(ctx, core.IDOf(cm), first)
+	report.Set(ctx, core.IDOf(role), NewDriftedResource(v1beta1.DriftDeleted, role, nil, ""))
+	report.Set(ctx, core.IDOf(cm), NewDriftedResource(v1beta1.DriftModified, cm, []string{"data.a", "data.b"}, "kubectl-edit"))
+
+	got := report.Resources()
+	if len(got) != 2 {
+		t.Fatalf("got %d drifted resources, want 2", len(got))
+	}
+	if got[0].Resource.GVK.Kind != "ConfigMap" || got[1].Resource.GVK.Kind != "Role" {
+		t.Errorf("got drifted resources %v, want them sorted by ID", got)
+	}
+	if !got[0].DetectedAt.Equal(&first.DetectedAt) {
+		t.Errorf("got detectedAt %v, want the time the drift was first detected %v", got[0].DetectedAt, first.DetectedAt)
+	}
+	if len(got[0].Fields) != 2 {
+		t.Errorf("got drifted fields %v, want the latest ones", got[0].Fields)
+	}
+
+	report.Remove(ctx, core.IDOf(cm))
+	report.Remove(ctx, core.IDOf(cm))
+	if got := report.Resources(); len(got) != 1 || got[0].Resource.GVK.Kind != "Role" {
+		t.Errorf("got drifted resources %v after removing the ConfigMap, want the Role only", got)
+	}
+}
+
+func TestActor(t *testing.T) {
+	at := func(seconds int64) *metav1.Time {
+		t := metav1.NewTime(time.Unix(seconds, 0))
+		return &t
+	}
+	cm := fake.ConfigMapObject()
+	if got := Actor(cm); got != "" {
+		t.Errorf("Actor() = %q for an object without managed fields, want empty", got)
+	}
+
+	cm.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-client-side-apply", Time: at(100)},
+		{Manager: "kubectl-edit", Time: at(200)},
+		{Manager: configsync.FieldManager, Time: at(300)},
+	})
+	if got := Actor(cm); got != "kubectl-edit" {
+		t.Errorf("Actor() = %q, want %q", got, "kubectl-edit")
+	}
+}
@@ -16,6 +16,7 @@ package queue
 
 import (
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/util/workqueue"
@@ -52,6 +53,7 @@ type Interface interface {
 	Done(obj client.Object)
 	Forget(obj client.Object)
 	Retry(obj client.Object)
+	AddAfter(obj client.Object, duration time.Duration)
 	ShutDown()
 }
 
@@ -140,6 +142,11 @@ func (q *ObjectQueue) Retry(obj client.Object) {
 	q.delayer.AddAfter(obj, q.rateLimiter.When(gvknn))
 }
 
+// AddAfter schedules the object to be added after the given duration.
+func (q *ObjectQueue) AddAfter(obj client.Object, duration time.Duration) {
+	q.delayer.AddAfter(obj, duration)
+}
+
 // Get blocks until it can return an item to be processed.
 //
 // Returns the next item to process, and whether the queue has been shut down
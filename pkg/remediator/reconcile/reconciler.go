@@ -17,13 +17,18 @@ package reconcile
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/importer/analyzer/validation/nonhierarchical"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/remediator/drift"
 	"kpt.dev/configsync/pkg/status"
 	syncerreconcile "kpt.dev/configsync/pkg/syncer/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +48,13 @@ type reconciler struct {
 	applier syncerreconcile.Applier
 	// declared is the threadsafe in-memory representation of declared configuration.
 	declared *declared.Resources
+	// auditOnly is true if the drift is recorded in driftReport instead of
+	// being corrected.
+	auditOnly bool
+	// driftReport is the threadsafe record of the drifted resources.
+	driftReport *drift.Report
+	// recorder records the Events on the remediated objects.
+	recorder record.EventRecorder
 }
 
 // newReconciler instantiates a new reconciler.
@@ -51,12 +63,18 @@ func newReconciler(
 	syncName string,
 	applier syncerreconcile.Applier,
 	declared *declared.Resources,
+	auditOnly bool,
+	driftReport *drift.Report,
+	recorder record.EventRecorder,
 ) *reconciler {
 	return &reconciler{
-		scope:    scope,
-		syncName: syncName,
-		applier:  applier,
-		declared: declared,
+		scope:       scope,
+		syncName:    syncName,
+		applier:     applier,
+		declared:    declared,
+		auditOnly:   auditOnly,
+		driftReport: driftReport,
+		recorder:    recorder,
 	}
 }
 
@@ -76,29 +94,53 @@ func (r *reconciler) Remediate(ctx context.Context, id core.ID, obj client.Objec
 		Declared: decl,
 		Actual:   obj,
 	}
-	switch t := d.Operation(ctx, r.scope, r.syncName); t {
+	t := d.Operation(ctx, r.scope, r.syncName)
+	if r.auditOnly {
+		return r.audit(ctx, id, d, t)
+	}
+	switch t {
 	case diff.NoOp:
 		return nil
 	case diff.Create:
 		klog.V(3).Infof("The remediator is about to create object %v", core.GKNN(declU))
-		_, err := r.applier.Create(ctx, declU)
-		return err
+		if _, err := r.applier.Create(ctx, declU); err != nil {
+			return err
+		}
+		r.recorder.Eventf(declU, corev1.EventTypeNormal, events.ReasonRemediated,
+			"Recreated the object deleted outside of %s", events.SyncString(r.scope, r.syncName))
+		return nil
 	case diff.Update:
 		actual, err := d.UnstructuredActual()
 		if err != nil {
 			return err
 		}
 		klog.V(3).Infof("The remediator is about to update object %v", core.GKNN(actual))
-		_, err = r.applier.Update(ctx, declU, actual)
-		return err
+		updated, err := r.applier.Update(ctx, declU, actual)
+		if err != nil {
+			return err
+		}
+		if updated {
+			r.recordUpdate(actual)
+		}
+		return nil
 	case diff.Delete:
 		actual, err := d.UnstructuredActual()
 		if err != nil {
 			return err
 		}
+		if err := r.declared.DeletionSafeguard.CheckRemediation(ctx, id); err != nil {
+			return err
+		}
 		klog.V(3).Infof("The remediator is about to delete object %v", core.GKNN(actual))
-		_, err = r.applier.Delete(ctx, actual)
-		return err
+		deleted, err := r.applier.Delete(ctx, actual)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			r.recorder.Eventf(actual, corev1.EventTypeNormal, events.ReasonRemediated,
+				"Deleted the object which is not declared in %s", events.SyncString(r.scope, r.syncName))
+		}
+		return nil
 	case diff.Error:
 		// This is the case where the annotation in the *repository* is invalid.
 		// Should never happen as the Parser would have thrown an error.
@@ -121,6 +163,83 @@ func (r *reconciler) Remediate(ctx context.Context, id core.ID, obj client.Objec
 	}
 }
 
+// recordUpdate records the Event of reverting the changes made to actual.
+func (r *reconciler) recordUpdate(actual client.Object) {
+	if actor := drift.Actor(actual); actor != "" {
+		r.recorder.Eventf(actual, corev1.EventTypeNormal, events.ReasonRemediated,
+			"Reverted the changes made by %s outside of %s", actor, events.SyncString(r.scope, r.syncName))
+		return
+	}
+	r.recorder.Eventf(actual, corev1.EventTypeNormal, events.ReasonRemediated,
+		"Reverted the changes made outside of %s", events.SyncString(r.scope, r.syncName))
+}
+
+// audit records the drift of the object instead of correcting it, or clears it
+// once the object matches its declaration again.
+//
+// The object has only drifted if it matches neither its declaration nor the
+// declaration which was last applied: until the applier applies a new commit,
+// the objects on the cluster still match the previous one.
+func (r *reconciler) audit(ctx context.Context, id core.ID, d diff.Diff, t diff.Operation) status.Error {
+	drifted, err := driftOf(d, t)
+	if err != nil {
+		return err
+	}
+	if drifted != nil {
+		var applied client.Object
+		if u, found := r.declared.GetApplied(id); found {
+			applied = u
+		}
+		prev := diff.Diff{Declared: applied, Actual: d.Actual}
+		prevDrifted, err := driftOf(prev, prev.Operation(ctx, r.scope, r.syncName))
+		if err != nil {
+			return err
+		}
+		if prevDrifted == nil {
+			klog.V(3).Infof("Object %v still matches the declaration last applied", id)
+			drifted = nil
+		}
+	}
+	if drifted == nil {
+		r.driftReport.Remove(ctx, id)
+		return nil
+	}
+	r.driftReport.Set(ctx, id, *drifted)
+	return nil
+}
+
+// driftOf returns the drift of the object in d, or nil if the object matches
+// its declaration.
+func driftOf(d diff.Diff, t diff.Operation) (*v1beta1.DriftedResource, status.Error) {
+	var drifted v1beta1.DriftedResource
+	switch t {
+	case diff.Create:
+		drifted = drift.NewDriftedResource(v1beta1.DriftDeleted, d.Declared, nil, "")
+	case diff.Update:
+		fields, err := d.DriftedFields()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		drifted = drift.NewDriftedResource(v1beta1.DriftModified, d.Declared, fields, drift.Actor(d.Actual))
+	case diff.Delete:
+		drifted = drift.NewDriftedResource(v1beta1.DriftUndeclared, d.Actual, nil, drift.Actor(d.Actual))
+	case diff.Error:
+		return nil, nonhierarchical.IllegalManagementAnnotationError(
+			d.Declared,
+			d.Declared.GetAnnotations()[metadata.ResourceManagementKey],
+		)
+	default:
+		// diff.Unmanage follows a change to the declarations, not a change to the
+		// cluster, so it is left to the applier. diff.NoOp and
+		// diff.ManagementConflict: there is nothing this reconciler would correct.
+		return nil, nil
+	}
+	return &drifted, nil
+}
+
 // GetClient returns the reconciler's underlying client.Client.
 func (r *reconciler) GetClient() client.Client {
 	return r.applier.GetClient()
@@ -18,18 +18,23 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	rbacv1beta1 "k8s.io/api/rbac/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/importer/analyzer/validation/nonhierarchical"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/policycontroller"
+	"kpt.dev/configsync/pkg/remediator/drift"
 	"kpt.dev/configsync/pkg/syncer/syncertest"
 	testingfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
 	"kpt.dev/configsync/pkg/testing/fake"
@@ -51,6 +56,8 @@ func TestRemediator_Reconcile(t *testing.T) {
 		// wantError is the desired error resulting from calling Reconcile, if there
 		// is one.
 		wantError error
+		// wantEvent is the Event recorded on the object, if there is one.
+		wantEvent *testingfake.Event
 	}{
 		// Happy Paths.
 		{
@@ -60,6 +67,7 @@ func TestRemediator_Reconcile(t *testing.T) {
 			actual:    nil,
 			want:      fake.ClusterRoleBindingObject(syncertest.ManagementEnabled),
 			wantError: nil,
+			wantEvent: testingfake.NewEvent(fake.ClusterRoleBindingObject(), corev1.EventTypeNormal, events.ReasonRemediated),
 		},
 		{
 			name:    "update declared object",
@@ -70,6 +78,7 @@ func TestRemediator_Reconcile(t *testing.T) {
 			want: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
 				core.Label("new-label", "one")),
 			wantError: nil,
+			wantEvent: testingfake.NewEvent(fake.ClusterRoleBindingObject(), corev1.EventTypeNormal, events.ReasonRemediated),
 		},
 		{
 			name:     "delete removed object",
@@ -79,6 +88,7 @@ func TestRemediator_Reconcile(t *testing.T) {
 				core.Annotation(metadata.ResourceIDKey, "rbac.authorization.k8s.io_clusterrolebinding_default-name")),
 			want:      nil,
 			wantError: nil,
+			wantEvent: testingfake.NewEvent(fake.ClusterRoleBindingObject(), corev1.EventTypeNormal, events.ReasonRemediated),
 		},
 		// Unmanaged paths.
 		{
@@ -145,6 +155,7 @@ func TestRemediator_Reconcile(t *testing.T) {
 			want: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
 				core.Label("declared-label", "foo")),
 			wantError: nil,
+			wantEvent: testingfake.NewEvent(fake.ClusterRoleBindingObject(), corev1.EventTypeNormal, events.ReasonRemediated),
 		},
 		{
 			name:      "don't update non-Config-Sync-managed-objects with invalid management annotation",
@@ -196,6 +207,7 @@ func TestRemediator_Reconcile(t *testing.T) {
 			want: fake.ClusterRoleBindingV1Beta1Object(syncertest.ManagementEnabled,
 				core.Label("new-label", "one")),
 			wantError: nil,
+			wantEvent: testingfake.NewEvent(fake.ClusterRoleBindingObject(), corev1.EventTypeNormal, events.ReasonRemediated),
 		},
 	}
 
@@ -206,7 +218,8 @@ func TestRemediator_Reconcile(t *testing.T) {
 			// Simulate the Parser having already parsed the resource and recorded it.
 			d := makeDeclared(t, tc.declared)
 
-			r := newReconciler(declared.RootReconciler, configsync.RootSyncName, c.Applier(), d)
+			recorder := testingfake.NewEventRecorder(t)
+			r := newReconciler(declared.RootReconciler, configsync.RootSyncName, c.Applier(), d, false, drift.NewReport(), recorder)
 
 			// Get the triggering object for the reconcile event.
 			var obj client.Object
@@ -230,10 +243,207 @@ func TestRemediator_Reconcile(t *testing.T) {
 			} else {
 				c.Check(t, tc.want)
 			}
+
+			if tc.wantEvent == nil {
+				recorder.Check(t)
+			} else {
+				recorder.Check(t, *tc.wantEvent)
+			}
 		})
 	}
 }
 
+func TestRemediator_AuditOnly(t *testing.T) {
+	testCases := []struct {
+		name     string
+		declared client.Object
+		actual   client.Object
+		// wantType is the type of the recorded drift, if any.
+		wantType   v1beta1.DriftType
+		wantFields []string
+	}{
+		{
+			name:     "deleted object",
+			declared: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled),
+			wantType: v1beta1.DriftDeleted,
+		},
+		{
+			name: "modified object",
+			declared: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "one")),
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "two")),
+			wantType:   v1beta1.DriftModified,
+			wantFields: []string{"metadata.labels.new-label"},
+		},
+		{
+			name: "undeclared object",
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Annotation(metadata.ResourceIDKey, "rbac.authorization.k8s.io_clusterrolebinding_default-name")),
+			wantType: v1beta1.DriftUndeclared,
+		},
+		{
+			name: "object matching its declaration",
+			declared: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "one")),
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "one"), core.Label("other-label", "two")),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fakeClient(t, tc.actual)
+			d := makeDeclared(t, tc.declared)
+			d.MarkApplied()
+			report := drift.NewReport()
+			recorder := testingfake.NewEventRecorder(t)
+			r := newReconciler(declared.RootReconciler, configsync.RootSyncName, c.Applier(), d, true, report, recorder)
+
+			obj := tc.declared
+			if obj == nil {
+				obj = tc.actual
+			}
+			if err := r.Remediate(context.Background(), core.IDOf(obj), tc.actual); err != nil {
+				t.Fatalf("got Remediate() = %v, want nil", err)
+			}
+
+			// The cluster must be left untouched.
+			if tc.actual == nil {
+				c.Check(t)
+			} else {
+				c.Check(t, tc.actual)
+			}
+			recorder.Check(t)
+
+			got := report.Resources()
+			if tc.wantType == "" {
+				if len(got) != 0 {
+					t.Errorf("got drifted resources %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d drifted resources, want 1", len(got))
+			}
+			if got[0].Type != tc.wantType {
+				t.Errorf("got drift type %q, want %q", got[0].Type, tc.wantType)
+			}
+			if diff := cmp.Diff(tc.wantFields, got[0].Fields); diff != "" {
+				t.Errorf("drifted fields diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRemediator_AuditOnlyPendingCommit(t *testing.T) {
+	applied := fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+		core.Label("new-label", "one"))
+	added := fake.ClusterRoleBindingObject(syncertest.ManagementEnabled, core.Name("added"))
+
+	testCases := []struct {
+		name   string
+		id     core.ID
+		actual client.Object
+		// wantType is the type of the recorded drift, if any.
+		wantType   v1beta1.DriftType
+		wantFields []string
+	}{
+		{
+			name: "object changed by the commit still matches the applied declaration",
+			id:   core.IDOf(applied),
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "one"), core.Annotation("status", "updated")),
+		},
+		{
+			name: "object changed by the commit matches the new declaration",
+			id:   core.IDOf(applied),
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "two")),
+		},
+		{
+			name: "object changed by the commit matches neither declaration",
+			id:   core.IDOf(applied),
+			actual: fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "three")),
+			wantType:   v1beta1.DriftModified,
+			wantFields: []string{"metadata.labels.new-label"},
+		},
+		{
+			name: "object added by the commit is not created yet",
+			id:   core.IDOf(added),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fakeClient(t, tc.actual)
+			d := makeDeclared(t, applied)
+			d.MarkApplied()
+			// The new commit is declared, but not applied yet.
+			declaredObj := fake.ClusterRoleBindingObject(syncertest.ManagementEnabled,
+				core.Label("new-label", "two"))
+			if _, err := d.Update(context.Background(), []client.Object{declaredObj, added}, "def456"); err != nil {
+				t.Fatal(err)
+			}
+			report := drift.NewReport()
+			recorder := testingfake.NewEventRecorder(t)
+			r := newReconciler(declared.RootReconciler, configsync.RootSyncName, c.Applier(), d, true, report, recorder)
+
+			if err := r.Remediate(context.Background(), tc.id, tc.actual); err != nil {
+				t.Fatalf("got Remediate() = %v, want nil", err)
+			}
+
+			got := report.Resources()
+			if tc.wantType == "" {
+				if len(got) != 0 {
+					t.Errorf("got drifted resources %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d drifted resources, want 1", len(got))
+			}
+			if got[0].Type != tc.wantType {
+				t.Errorf("got drift type %q, want %q", got[0].Type, tc.wantType)
+			}
+			if diff := cmp.Diff(tc.wantFields, got[0].Fields); diff != "" {
+				t.Errorf("drifted fields diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRemediator_DeletionBudget(t *testing.T) {
+	undeclared := func(name string) client.Object {
+		return fake.ClusterRoleBindingObject(syncertest.ManagementEnabled, core.Name(name),
+			core.Annotation(metadata.ResourceIDKey, "rbac.authorization.k8s.io_clusterrolebinding_"+name))
+	}
+	first := undeclared("first")
+	second := undeclared("second")
+	c := fakeClient(t, first, second)
+
+	budgets := []v1beta1.DeletionBudget{{Kind: declared.WildcardKind, MaxCount: pointer.Int64(1)}}
+	d := &declared.Resources{DeletionSafeguard: declared.NewDeletionSafeguard(budgets, c, declared.RootReconciler, configsync.RootSyncName)}
+	if _, err := d.Update(context.Background(), nil, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	recorder := testingfake.NewEventRecorder(t)
+	r := newReconciler(declared.RootReconciler, configsync.RootSyncName, c.Applier(), d, false, drift.NewReport(), recorder)
+
+	if err := r.Remediate(context.Background(), core.IDOf(first), first); err != nil {
+		t.Fatalf("got Remediate() = %v, want nil", err)
+	}
+	err := r.Remediate(context.Background(), core.IDOf(second), second)
+	if !errors.Is(err, declared.DeletionBudgetError("abc123", "", nil)) {
+		t.Errorf("got Remediate() = %v, want a deletion budget error", err)
+	}
+
+	// Only the deletion within the budget is made.
+	c.Check(t, second)
+	recorder.Check(t, *testingfake.NewEvent(first, corev1.EventTypeNormal, events.ReasonRemediated))
+}
+
 func fakeClient(t *testing.T, actual ...client.Object) *testingfake.Client {
 	t.Helper()
 	s := runtime.NewScheme()
@@ -267,7 +477,7 @@ func fakeClient(t *testing.T, actual ...client.Object) *testingfake.Client {
 func makeDeclared(t *testing.T, objs ...client.Object) *declared.Resources {
 	t.Helper()
 	d := &declared.Resources{}
-	if _, err := d.Update(context.Background(), objs); err != nil {
+	if _, err := d.Update(context.Background(), objs, ""); err != nil {
 		// Test precondition; fail early.
 		t.Fatal(err)
 	}
@@ -21,10 +21,13 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/remediator/drift"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/status"
 	syncerclient "kpt.dev/configsync/pkg/syncer/client"
@@ -37,13 +40,21 @@ import (
 type Worker struct {
 	objectQueue queue.Interface
 	reconciler  reconcilerInterface
+	// exemptions holds the break-glass policies which pause the remediation of
+	// the objects they apply to.
+	exemptions *breakglass.Cache
 }
 
 // NewWorker returns a new Worker for the given queue and declared resources.
-func NewWorker(scope declared.Scope, syncName string, a syncerreconcile.Applier, q *queue.ObjectQueue, d *declared.Resources) *Worker {
+// If auditOnly is true, the Worker records the drift in driftReport instead of
+// correcting it. The Events of the remediated objects are recorded with recorder.
+// The objects covered by a break-glass policy in exemptions which pauses
+// remediation are left alone until the policy expires.
+func NewWorker(scope declared.Scope, syncName string, a syncerreconcile.Applier, q *queue.ObjectQueue, d *declared.Resources, auditOnly bool, driftReport *drift.Report, recorder record.EventRecorder, exemptions *breakglass.Cache) *Worker {
 	return &Worker{
 		objectQueue: q,
-		reconciler:  newReconciler(scope, syncName, a, d),
+		reconciler:  newReconciler(scope, syncName, a, d, auditOnly, driftReport, recorder),
+		exemptions:  exemptions,
 	}
 }
 
@@ -71,6 +82,14 @@ func (w *Worker) processNextObject(ctx context.Context) bool {
 }
 
 func (w *Worker) process(ctx context.Context, obj client.Object) bool {
+	if p := w.remediationPause(ctx, obj); p != nil {
+		klog.Infof("Worker paused remediating %q because of the %s", core.IDOf(obj), p)
+		// Check the object again once the policy expires.
+		w.objectQueue.Forget(obj)
+		w.objectQueue.AddAfter(obj, p.ExpiresAt.Sub(w.exemptions.Now()))
+		return true
+	}
+
 	var toRemediate client.Object
 	if queue.WasDeleted(ctx, obj) {
 		// Passing a nil Object to the reconciler signals that the accompanying ID
@@ -108,6 +127,17 @@ func (w *Worker) process(ctx context.Context, obj client.Object) bool {
 	return true
 }
 
+// remediationPause returns the break-glass policy which pauses the remediation
+// of the object, if any.
+func (w *Worker) remediationPause(ctx context.Context, obj client.Object) *breakglass.Policy {
+	policies := w.exemptions.Policies(ctx)
+	if len(policies) == 0 {
+		return nil
+	}
+	gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+	return breakglass.RemediationPause(policies, gk, obj.GetNamespace(), w.exemptions.Now())
+}
+
 // refresh updates the cached version of the object.
 func (w *Worker) refresh(ctx context.Context, o client.Object) status.Error {
 	c := w.reconciler.GetClient()
@@ -17,20 +17,25 @@ package reconcile
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
+	"kpt.dev/configsync/pkg/remediator/drift"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/status"
 	syncerclient "kpt.dev/configsync/pkg/syncer/client"
@@ -113,7 +118,7 @@ func TestWorker_ProcessNextObject(t *testing.T) {
 			}
 
 			d := makeDeclared(t, tc.declared...)
-			w := NewWorker(declared.RootReconciler, configsync.RootSyncName, c.Applier(), q, d)
+			w := NewWorker(declared.RootReconciler, configsync.RootSyncName, c.Applier(), q, d, false, drift.NewReport(), syncertestfake.NewEventRecorder(t), nil)
 
 			for _, obj := range tc.toProcess {
 				if ok := w.processNextObject(context.Background()); !ok {
@@ -258,6 +263,84 @@ func TestWorker_ResourceConflictMetricValidation(t *testing.T) {
 	}
 }
 
+func TestWorker_RemediationPaused(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := corev1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	policy := fake.ConfigMapObject(core.Name(breakglass.PolicyConfigMapName), core.Namespace(configsync.ControllerNamespace))
+	policy.Data = map[string]string{
+		"incident": fmt.Sprintf(`
+users: alice@acme.com
+kinds: Role.rbac.authorization.k8s.io
+namespaces: shipping
+expiresAt: %q
+pauseRemediation: "true"
+`, expiresAt.Format(time.RFC3339)),
+	}
+	exemptions := breakglass.NewCache(syncertestfake.NewClient(t, s, policy), breakglass.DefaultRefreshPeriod)
+
+	testCases := []struct {
+		name          string
+		obj           client.Object
+		wantPaused    bool
+		wantRemediate bool
+	}{
+		{
+			name:       "object covered by the policy is paused",
+			obj:        fake.UnstructuredObject(kinds.Role(), core.Name("admin"), core.Namespace("shipping")),
+			wantPaused: true,
+		},
+		{
+			name:          "object in another namespace is remediated",
+			obj:           fake.UnstructuredObject(kinds.Role(), core.Name("admin"), core.Namespace("bookstore")),
+			wantRemediate: true,
+		},
+		{
+			name:          "object of another kind is remediated",
+			obj:           fake.UnstructuredObject(kinds.RoleBinding(), core.Name("admin"), core.Namespace("shipping")),
+			wantRemediate: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &fakeQueue{}
+			r := &countingReconciler{}
+			w := &Worker{
+				objectQueue: q,
+				reconciler:  r,
+				exemptions:  exemptions,
+			}
+			if ok := w.process(context.Background(), tc.obj); !ok {
+				t.Errorf("unexpected false result from process()")
+			}
+			if got := r.remediated == 1; got != tc.wantRemediate {
+				t.Errorf("got remediated %t, want %t", got, tc.wantRemediate)
+			}
+			if tc.wantPaused {
+				if q.element != tc.obj {
+					t.Errorf("got requeued %v, want %v", q.element, tc.obj)
+				}
+				// The object is checked again once the policy expires.
+				if q.delay <= 0 || q.delay > time.Until(expiresAt) {
+					t.Errorf("got requeued after %v, want until the policy expires", q.delay)
+				}
+			}
+		})
+	}
+}
+
+type countingReconciler struct {
+	fakeReconciler
+	remediated int
+}
+
+func (r *countingReconciler) Remediate(_ context.Context, _ core.ID, _ client.Object) status.Error {
+	r.remediated++
+	return nil
+}
+
 type fakeReconciler struct {
 	client       client.Client
 	remediateErr status.Error
@@ -276,6 +359,12 @@ func (f fakeReconciler) GetClient() client.Client {
 type fakeQueue struct {
 	queue.Interface
 	element client.Object
+	delay   time.Duration
+}
+
+func (q *fakeQueue) AddAfter(o client.Object, d time.Duration) {
+	q.element = o
+	q.delay = d
 }
 
 func (q *fakeQueue) Add(o client.Object) {
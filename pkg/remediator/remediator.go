@@ -21,8 +21,12 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/remediator/drift"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/remediator/reconcile"
 	"kpt.dev/configsync/pkg/remediator/watch"
@@ -40,6 +44,8 @@ type Remediator struct {
 	watchMgr *watch.Manager
 	workers  []*reconcile.Worker
 	started  bool
+	// driftReport records the drifted resources in audit-only mode.
+	driftReport *drift.Report
 	// The following fields are guarded by the mutex.
 	mux sync.Mutex
 	// conflictErrs tracks all the management conflicts the remediator encounters,
@@ -62,6 +68,10 @@ type Interface interface {
 	ManagementConflict() bool
 	// ConflictErrors returns the errors the remediator encounters.
 	ConflictErrors() []status.ManagementConflictError
+	// DriftedResources returns the resources which have drifted from their
+	// declarations. It is always empty unless the remediator runs in audit-only
+	// mode.
+	DriftedResources() []v1beta1.DriftedResource
 }
 
 var _ Interface = &Remediator{}
@@ -71,19 +81,29 @@ var _ Interface = &Remediator{}
 //
 // It is safe for decls to be modified after they have been passed into the
 // Remediator.
-func New(scope declared.Scope, syncName string, cfg *rest.Config, applier syncerreconcile.Applier, decls *declared.Resources, numWorkers int) (*Remediator, error) {
+//
+// If auditOnly is true, the Remediator records the drift of the managed
+// resources instead of correcting it.
+//
+// The Events of the remediated objects are recorded with recorder.
+//
+// The remediation of the objects covered by a break-glass policy in exemptions
+// which pauses remediation is deferred until the policy expires.
+func New(scope declared.Scope, syncName string, cfg *rest.Config, applier syncerreconcile.Applier, decls *declared.Resources, numWorkers int, auditOnly bool, recorder record.EventRecorder, exemptions *breakglass.Cache) (*Remediator, error) {
 	q := queue.New(string(scope))
+	driftReport := drift.NewReport()
 	workers := make([]*reconcile.Worker, numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		workers[i] = reconcile.NewWorker(scope, syncName, applier, q, decls)
+		workers[i] = reconcile.NewWorker(scope, syncName, applier, q, decls, auditOnly, driftReport, recorder, exemptions)
 	}
 
 	remediator := &Remediator{
-		workers: workers,
+		workers:     workers,
+		driftReport: driftReport,
 	}
 
 	watchMgr, err := watch.NewManager(scope, syncName, cfg, q, decls, nil,
-		remediator.addConflictError, remediator.removeConflictError)
+		remediator.addConflictError, remediator.removeConflictError, recorder)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating watch manager")
 	}
@@ -123,6 +143,11 @@ func (r *Remediator) ConflictErrors() []status.ManagementConflictError {
 	return r.conflictErrs
 }
 
+// DriftedResources implements Interface.
+func (r *Remediator) DriftedResources() []v1beta1.DriftedResource {
+	return r.driftReport.Resources()
+}
+
 func (r *Remediator) addConflictError(e status.ManagementConflictError) {
 	r.mux.Lock()
 	defer r.mux.Unlock()
@@ -23,15 +23,18 @@ import (
 	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/metrics"
 	"kpt.dev/configsync/pkg/remediator/queue"
@@ -84,6 +87,7 @@ const errorLoggingInterval = time.Second
 // - or managed by the same reconciler.
 type filteredWatcher struct {
 	gvk        string
+	groupKind  schema.GroupKind
 	startWatch startWatchFunc
 	resources  *declared.Resources
 	queue      *queue.ObjectQueue
@@ -100,6 +104,8 @@ type filteredWatcher struct {
 	conflictErrMap          map[queue.GVKNN]status.ManagementConflictError
 	addConflictErrorFunc    func(status.ManagementConflictError)
 	removeConflictErrorFunc func(status.ManagementConflictError)
+	// recorder records the Events on the objects in management conflicts.
+	recorder record.EventRecorder
 }
 
 // filteredWatcher implements the Runnable interface.
@@ -109,6 +115,7 @@ var _ Runnable = &filteredWatcher{}
 func NewFiltered(_ context.Context, cfg watcherConfig) Runnable {
 	return &filteredWatcher{
 		gvk:                     cfg.gvk.String(),
+		groupKind:               cfg.gvk.GroupKind(),
 		startWatch:              cfg.startWatch,
 		resources:               cfg.resources,
 		queue:                   cfg.queue,
@@ -119,6 +126,7 @@ func NewFiltered(_ context.Context, cfg watcherConfig) Runnable {
 		conflictErrMap:          make(map[queue.GVKNN]status.ManagementConflictError),
 		addConflictErrorFunc:    cfg.addConflictErrorFunc,
 		removeConflictErrorFunc: cfg.removeConflictErrorFunc,
+		recorder:                cfg.recorder,
 	}
 }
 
@@ -193,6 +201,12 @@ func (w *filteredWatcher) SetManagementConflict(object client.Object) {
 	klog.Warningf("The remediator detects a management conflict for object %q between root reconcilers: %q and %q",
 		core.GKNN(object), newManager, manager)
 	gvknn := queue.GVKNNOf(object)
+	if _, found := w.conflictErrMap[gvknn]; !found {
+		// Only the new conflicts get an Event, rather than every watch event of
+		// the object.
+		w.recorder.Eventf(object, corev1.EventTypeWarning, events.ReasonManagementConflict,
+			"%s cannot remediate the object because it is managed by another repository", events.SyncString(w.scope, w.syncName))
+	}
 	w.conflictErrMap[gvknn] = status.ManagementConflictErrorWrap(object, newManager)
 	w.addConflictErrorFunc(w.conflictErrMap[gvknn])
 	metrics.RecordResourceConflict(context.Background(), object.GetObjectKind().GroupVersionKind())
@@ -287,6 +301,10 @@ func (w *filteredWatcher) Run(ctx context.Context) status.Error {
 				ignoredEventCount++
 			}
 			if err != nil {
+				if apierrors.IsForbidden(err) {
+					w.Stop()
+					return w.permissionError(err)
+				}
 				if isExpiredError(err) {
 					klog.Infof("Watch for %s at resource version %q closed with: %v", w.gvk, resourceVersion, err)
 					// `w.handle` may fail because we try to watch an old resource version, setting
@@ -339,12 +357,21 @@ func (w *filteredWatcher) start(resourceVersion string) (bool, status.Error) {
 
 	base, err := w.startWatch(options)
 	if err != nil {
+		if apierrors.IsForbidden(err) {
+			return false, w.permissionError(err)
+		}
 		return false, status.APIServerErrorf(err, "failed to start watch for %s", w.gvk)
 	}
 	w.base = base
 	return true, nil
 }
 
+// permissionError reports that the reconciler is not allowed to watch the
+// objects of the GVK, e.g. because the roles it is bound to are too narrow.
+func (w *filteredWatcher) permissionError(err error) status.Error {
+	return status.InsufficientPermissionError(err, "watch "+w.gvk, w.groupKind)
+}
+
 func errorID(err error) string {
 	errTypeName := reflect.TypeOf(err).String()
 
@@ -21,10 +21,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff/difftest"
 	"kpt.dev/configsync/pkg/remediator/queue"
+	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/syncer/syncertest"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -181,7 +183,7 @@ func TestFilteredWatcher(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			dr := &declared.Resources{}
 			ctx := context.Background()
-			if _, err := dr.Update(ctx, tc.declared); err != nil {
+			if _, err := dr.Update(ctx, tc.declared, ""); err != nil {
 				t.Fatalf("unexpected error %v", err)
 			}
 
@@ -228,3 +230,45 @@ func TestFilteredWatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestFilteredWatcher_SetManagementConflict(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	var conflicts []status.ManagementConflictError
+	cfg := watcherConfig{
+		scope:    declared.RootReconciler,
+		syncName: "rs",
+		addConflictErrorFunc: func(err status.ManagementConflictError) {
+			conflicts = append(conflicts, err)
+		},
+		recorder: recorder,
+	}
+	w := NewFiltered(context.Background(), cfg)
+
+	obj := fake.DeploymentObject(core.Name("hello"), syncertest.ManagementEnabled,
+		difftest.ManagedBy(declared.RootReconciler, "other-rs"))
+	// The conflict is reported on every watch event of the object, but only
+	// gets an Event the first time.
+	w.SetManagementConflict(obj)
+	w.SetManagementConflict(obj)
+
+	if !w.ManagementConflict() {
+		t.Error("got ManagementConflict() = false, want true")
+	}
+	if len(conflicts) != 2 {
+		t.Errorf("got %d conflict errors, want 2", len(conflicts))
+	}
+	want := "Warning ManagementConflict RootSync config-management-system/rs cannot remediate the object because it is managed by another repository"
+	select {
+	case got := <-recorder.Events:
+		if got != want {
+			t.Errorf("got Event %q, want %q", got, want)
+		}
+	default:
+		t.Fatal("got no Event, want a ManagementConflict Event")
+	}
+	select {
+	case got := <-recorder.Events:
+		t.Errorf("got unexpected Event %q", got)
+	default:
+	}
+}
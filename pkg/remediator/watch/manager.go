@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/remediator/queue"
@@ -58,10 +59,16 @@ type Manager struct {
 	watcherMap map[schema.GroupVersionKind]Runnable
 	// needsUpdate indicates if the Manager's watches need to be updated.
 	needsUpdate bool
+	// watcherErrs are the errors of the watchers which stopped since the last
+	// update of the watches, e.g. because the reconciler is not allowed to
+	// watch their GVK.
+	watcherErrs map[schema.GroupVersionKind]status.Error
 	// addConflictErrorFunc is a function that adds the conflict error detected by the remediator.
 	addConflictErrorFunc func(status.ManagementConflictError)
 	// removeConflictErrorFunc is a function that removes the conflict error detected by the remediator.
 	removeConflictErrorFunc func(status.ManagementConflictError)
+	// recorder records the Events on the objects in management conflicts.
+	recorder record.EventRecorder
 }
 
 // Options contains options for creating a watch manager.
@@ -91,7 +98,8 @@ func DefaultOptions(cfg *rest.Config) (*Options, error) {
 func NewManager(scope declared.Scope, syncName string, cfg *rest.Config,
 	q *queue.ObjectQueue, decls *declared.Resources, options *Options,
 	addConflictErrorFunc func(status.ManagementConflictError),
-	removeConflictErrorFunc func(status.ManagementConflictError)) (*Manager, error) {
+	removeConflictErrorFunc func(status.ManagementConflictError),
+	recorder record.EventRecorder) (*Manager, error) {
 	if options == nil {
 		var err error
 		options, err = DefaultOptions(cfg)
@@ -106,11 +114,13 @@ func NewManager(scope declared.Scope, syncName string, cfg *rest.Config,
 		cfg:                     cfg,
 		resources:               decls,
 		watcherMap:              make(map[schema.GroupVersionKind]Runnable),
+		watcherErrs:             make(map[schema.GroupVersionKind]status.Error),
 		createWatcherFunc:       options.watcherFunc,
 		mapper:                  options.Mapper,
 		queue:                   q,
 		addConflictErrorFunc:    addConflictErrorFunc,
 		removeConflictErrorFunc: removeConflictErrorFunc,
+		recorder:                recorder,
 	}, nil
 }
 
@@ -165,8 +175,17 @@ func (m *Manager) UpdateWatches(ctx context.Context, gvkMap map[schema.GroupVers
 		}
 	}
 
-	// Start new watchers
+	// Report the errors of the watchers which stopped, so that they are
+	// restarted below and shown in the status until they can run again.
 	var errs status.MultiError
+	for gvk, err := range m.watcherErrs {
+		if _, keepWatching := gvkMap[gvk]; keepWatching {
+			errs = status.Append(errs, err)
+		}
+		delete(m.watcherErrs, gvk)
+	}
+
+	// Start new watchers
 	for gvk := range gvkMap {
 		if _, isWatched := m.watcherMap[gvk]; !isWatched {
 			// We don't have a watcher for this type, so add a watcher for it.
@@ -212,6 +231,7 @@ func (m *Manager) startWatcher(ctx context.Context, gvk schema.GroupVersionKind)
 		syncName:                m.syncName,
 		addConflictErrorFunc:    m.addConflictErrorFunc,
 		removeConflictErrorFunc: m.removeConflictErrorFunc,
+		recorder:                m.recorder,
 	}
 	w, err := m.createWatcherFunc(ctx, cfg)
 	if err != nil {
@@ -230,6 +250,7 @@ func (m *Manager) runWatcher(ctx context.Context, r Runnable, gvk schema.GroupVe
 		klog.Warningf("Error running watcher for %s: %v", gvk.String(), status.FormatSingleLine(err))
 		m.mux.Lock()
 		delete(m.watcherMap, gvk)
+		m.watcherErrs[gvk] = err
 		m.needsUpdate = true
 		m.mux.Unlock()
 	}
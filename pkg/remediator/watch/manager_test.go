@@ -17,13 +17,17 @@ package watch
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/record"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/status"
@@ -143,7 +147,7 @@ func TestManager_Update(t *testing.T) {
 			options := &Options{
 				watcherFunc: testRunnables(ctx, tc.failedWatchers),
 			}
-			m, err := NewManager(":test", "rs", nil, nil, &declared.Resources{}, options, fakeNoOpFnc, fakeNoOpFnc)
+			m, err := NewManager(":test", "rs", nil, nil, &declared.Resources{}, options, fakeNoOpFnc, fakeNoOpFnc, record.NewFakeRecorder(10))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -165,3 +169,34 @@ func TestManager_Update(t *testing.T) {
 func sortGVKs(l, r schema.GroupVersionKind) bool {
 	return l.String() < r.String()
 }
+
+func TestManager_ReportsForbiddenWatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	forbiddenWatcher := func(ctx context.Context, cfg watcherConfig) (Runnable, status.Error) {
+		cfg.startWatch = func(options metav1.ListOptions) (watch.Interface, error) {
+			return nil, apierrors.NewForbidden(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"}, "", errors.New("denied"))
+		}
+		return NewFiltered(ctx, cfg), nil
+	}
+	m, err := NewManager(":test", "rs", nil, nil, &declared.Resources{}, &Options{watcherFunc: forbiddenWatcher}, fakeNoOpFnc, fakeNoOpFnc, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvks := map[schema.GroupVersionKind]struct{}{kinds.Role(): {}}
+
+	if errs := m.UpdateWatches(ctx, gvks); errs != nil {
+		t.Fatalf("got UpdateWatches() error = %v, want nil", errs)
+	}
+	// The watcher fails to start the watch in the background.
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return m.NeedsUpdate(), nil
+	}); err != nil {
+		t.Fatal("the watcher did not stop")
+	}
+
+	errs := m.UpdateWatches(ctx, gvks)
+	if errs == nil || len(errs.Errors()) != 1 || errs.Errors()[0].Code() != status.InsufficientPermissionErrorCode {
+		t.Errorf("got UpdateWatches() error = %v, want an insufficient permission error", errs)
+	}
+}
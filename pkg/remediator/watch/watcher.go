@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/remediator/queue"
 	"kpt.dev/configsync/pkg/status"
@@ -43,6 +44,7 @@ type watcherConfig struct {
 	startWatch              startWatchFunc
 	addConflictErrorFunc    func(status.ManagementConflictError)
 	removeConflictErrorFunc func(status.ManagementConflictError)
+	recorder                record.EventRecorder
 }
 
 // createWatcherFunc is the type of functions to create watchers
@@ -34,6 +34,12 @@ func IsStalled(rs *v1beta1.RepoSync) bool {
 	return cond != nil && cond.Status == metav1.ConditionTrue
 }
 
+// IsSuspended returns true if the given RepoSync has a True Suspended condition.
+func IsSuspended(rs *v1beta1.RepoSync) bool {
+	cond := GetCondition(rs.Status.Conditions, v1beta1.RepoSyncSuspended)
+	return cond != nil && cond.Status == metav1.ConditionTrue
+}
+
 // ReconcilingMessage returns the message from a True Reconciling condition or
 // an empty string if no True Reconciling condition was found.
 func ReconcilingMessage(rs *v1beta1.RepoSync) string {
@@ -109,6 +115,12 @@ func SetSyncing(rs *v1beta1.RepoSync, status bool, reason, message, commit strin
 	setCondition(rs, v1beta1.RepoSyncSyncing, conditionStatus, reason, message, commit, errorSources, errorSummary, lastUpdate)
 }
 
+// SetSuspended sets the Suspended condition to True, with the commit which has
+// not been applied yet.
+func SetSuspended(rs *v1beta1.RepoSync, reason, message, commit string, lastUpdate metav1.Time) {
+	setCondition(rs, v1beta1.RepoSyncSuspended, metav1.ConditionTrue, reason, message, commit, nil, &v1beta1.ErrorSummary{}, lastUpdate)
+}
+
 // setCondition adds or updates the specified condition with a True status.
 // It returns a boolean indicating if the condition status is transited.
 func setCondition(rs *v1beta1.RepoSync, condType v1beta1.RepoSyncConditionType, status metav1.ConditionStatus, reason, message, commit string, errorSources []v1beta1.ErrorSource, errorSummary *v1beta1.ErrorSummary, lastUpdate metav1.Time) bool {
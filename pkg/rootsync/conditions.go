@@ -57,6 +57,12 @@ func IsStalled(rs *v1beta1.RootSync) bool {
 	return cond != nil && cond.Status == metav1.ConditionTrue
 }
 
+// IsSuspended returns true if the given RootSync has a True Suspended condition.
+func IsSuspended(rs *v1beta1.RootSync) bool {
+	cond := GetCondition(rs.Status.Conditions, v1beta1.RootSyncSuspended)
+	return cond != nil && cond.Status == metav1.ConditionTrue
+}
+
 // ReconcilingMessage returns the message from a True Reconciling condition or
 // an empty string if no True Reconciling condition was found.
 func ReconcilingMessage(rs *v1beta1.RootSync) string {
@@ -110,6 +116,12 @@ func SetSyncing(rs *v1beta1.RootSync, status bool, reason, message, commit strin
 	setCondition(rs, v1beta1.RootSyncSyncing, conditionStatus, reason, message, commit, errorSources, errorSummary, lastUpdate)
 }
 
+// SetSuspended sets the Suspended condition to True, with the commit which has
+// not been applied yet.
+func SetSuspended(rs *v1beta1.RootSync, reason, message, commit string, lastUpdate metav1.Time) {
+	setCondition(rs, v1beta1.RootSyncSuspended, metav1.ConditionTrue, reason, message, commit, nil, &v1beta1.ErrorSummary{}, lastUpdate)
+}
+
 // setCondition adds or updates the specified condition.
 // It returns a boolean indicating if the condition status is transited.
 func setCondition(rs *v1beta1.RootSync, condType v1beta1.RootSyncConditionType, status metav1.ConditionStatus, reason, message, commit string, errorSources []v1beta1.ErrorSource, errorSummary *v1beta1.ErrorSummary, lastUpdate metav1.Time) bool {
@@ -16,6 +16,7 @@ package status
 
 import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -33,6 +34,17 @@ const InsufficientPermissionErrorCode = "2013"
 var InsufficientPermissionErrorBuilder = NewErrorBuilder(InsufficientPermissionErrorCode).
 	Sprint("Insufficient permission. To fix, make sure the reconciler has sufficient permissions.")
 
+// InsufficientPermissionError reports that the reconciler is not allowed to
+// perform the action on the objects of the GroupKind, e.g. "apply Role
+// foo/bar" or "watch Role".
+func InsufficientPermissionError(err error, action string, gk schema.GroupKind) Error {
+	return InsufficientPermissionErrorBuilder.
+		Sprintf("The reconciler is not allowed to %s. To fix, bind a role granting access to %v to the reconciler: "+
+			"list it in spec.roleRefs of the RootSync, or bind it to the RepoSync reconciler with a RoleBinding in the RepoSync namespace.",
+			action, gk).
+		Wrap(err).Build()
+}
+
 // APIServerError wraps an error returned by the APIServer.
 func APIServerError(err error, message string, resources ...client.Object) Error {
 	var errorBuilder ErrorBuilder
@@ -20,21 +20,50 @@ const InternalHydrationErrorCode = "2015"
 // ActionableHydrationErrorCode is the error code for a user actionable Error related to the hydration process.
 const ActionableHydrationErrorCode = "1068"
 
+// KustomizeMissingResourceErrorCode is the error code for a kustomization
+// which references a resource, base, component, patch or file which does not exist.
+const KustomizeMissingResourceErrorCode = "1069"
+
+// KustomizeInvalidKustomizationErrorCode is the error code for a kustomization
+// file which cannot be read.
+const KustomizeInvalidKustomizationErrorCode = "1070"
+
+// KustomizePluginErrorCode is the error code for a generator or transformer
+// plugin of a kustomization, or a Helm chart, which fails.
+const KustomizePluginErrorCode = "1071"
+
 // internalHydrationErrorBuilder is an ErrorBuilder for internal errors related to the hydration process.
 var internalHydrationErrorBuilder = NewErrorBuilder(InternalHydrationErrorCode)
 
 // actionableHydrationErrorBuilder is an ErrorBuilder for user actionable errors related to the hydration process.
 var actionableHydrationErrorBuilder = NewErrorBuilder(ActionableHydrationErrorCode)
 
+// hydrationErrorBuilders are the ErrorBuilders of the hydration error codes.
+var hydrationErrorBuilders = map[string]ErrorBuilder{
+	InternalHydrationErrorCode:             internalHydrationErrorBuilder,
+	ActionableHydrationErrorCode:           actionableHydrationErrorBuilder,
+	KustomizeMissingResourceErrorCode:      NewErrorBuilder(KustomizeMissingResourceErrorCode),
+	KustomizeInvalidKustomizationErrorCode: NewErrorBuilder(KustomizeInvalidKustomizationErrorCode),
+	KustomizePluginErrorCode:               NewErrorBuilder(KustomizePluginErrorCode),
+}
+
 // InternalHydrationError returns an internal error related to the hydration process.
 func InternalHydrationError(err error, format string, a ...interface{}) Error {
 	return internalHydrationErrorBuilder.Wrap(err).Sprintf(format, a...).Build()
 }
 
-// HydrationError returns a hydration error.
+// IsActionableHydrationErrorCode returns true if the code is the code of a user
+// actionable hydration error.
+func IsActionableHydrationErrorCode(code string) bool {
+	_, found := hydrationErrorBuilders[code]
+	return found && code != InternalHydrationErrorCode
+}
+
+// HydrationError returns a hydration error. Unknown codes are reported as
+// internal errors.
 func HydrationError(code string, err error) Error {
-	if code == ActionableHydrationErrorCode {
-		return actionableHydrationErrorBuilder.Wrap(err).Build()
+	if builder, found := hydrationErrorBuilders[code]; found {
+		return builder.Wrap(err).Build()
 	}
 	return internalHydrationErrorBuilder.Wrap(err).Build()
 }
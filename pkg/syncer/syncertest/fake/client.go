@@ -24,6 +24,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -186,8 +187,14 @@ func (c *Client) List(_ context.Context, list client.ObjectList, opts ...client.
 		return c.listRepoSyncs(l, options)
 	case *corev1.SecretList:
 		return c.listSecrets(l, options)
+	case *corev1.ConfigMapList:
+		return c.listConfigMaps(l, options)
 	case *corev1.NodeList:
 		return c.listNodes(l, options)
+	case *rbacv1.ClusterRoleBindingList:
+		return c.listClusterRoleBindings(l, options)
+	case *rbacv1.RoleBindingList:
+		return c.listRoleBindings(l, options)
 	}
 	return errors.Errorf("fake.Client does not support List(%T)", list)
 }
@@ -739,6 +746,75 @@ func (c *Client) listSecrets(list *corev1.SecretList, options client.ListOptions
 	return nil
 }
 
+func (c *Client) listClusterRoleBindings(list *rbacv1.ClusterRoleBindingList, options client.ListOptions) error {
+	if options.FieldSelector != nil {
+		return errors.Errorf("fake.Client.List for ClusterRoleBindingList does not yet support the FieldSelector option, but got: %+v", options)
+	}
+	objs := c.list(kinds.ClusterRoleBinding().GroupKind())
+	for _, obj := range objs {
+		if options.LabelSelector != nil {
+			l := labels.Set(obj.GetLabels())
+			if !options.LabelSelector.Matches(l) {
+				continue
+			}
+		}
+		crb, ok := obj.(*rbacv1.ClusterRoleBinding)
+		if !ok {
+			return errors.Errorf("non-ClusterRoleBinding stored as ClusterRoleBinding: %v", obj)
+		}
+		list.Items = append(list.Items, *crb)
+	}
+	return nil
+}
+
+func (c *Client) listRoleBindings(list *rbacv1.RoleBindingList, options client.ListOptions) error {
+	if options.FieldSelector != nil {
+		return errors.Errorf("fake.Client.List for RoleBindingList does not yet support the FieldSelector option, but got: %+v", options)
+	}
+	objs := c.list(kinds.RoleBinding().GroupKind())
+	for _, obj := range objs {
+		if options.Namespace != "" && obj.GetNamespace() != options.Namespace {
+			continue
+		}
+		if options.LabelSelector != nil {
+			l := labels.Set(obj.GetLabels())
+			if !options.LabelSelector.Matches(l) {
+				continue
+			}
+		}
+		rb, ok := obj.(*rbacv1.RoleBinding)
+		if !ok {
+			return errors.Errorf("non-RoleBinding stored as RoleBinding: %v", obj)
+		}
+		list.Items = append(list.Items, *rb)
+	}
+	return nil
+}
+
+func (c *Client) listConfigMaps(list *corev1.ConfigMapList, options client.ListOptions) error {
+	if options.FieldSelector != nil {
+		return errors.Errorf("fake.Client.List for ConfigMapList does not yet support the FieldSelector option, but got: %+v", options)
+	}
+	objs := c.list(kinds.ConfigMap().GroupKind())
+	for _, obj := range objs {
+		if options.Namespace != "" && obj.GetNamespace() != options.Namespace {
+			continue
+		}
+		if options.LabelSelector != nil {
+			l := labels.Set(obj.GetLabels())
+			if !options.LabelSelector.Matches(l) {
+				continue
+			}
+		}
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return errors.Errorf("non-ConfigMap stored as ConfigMap: %v", obj)
+		}
+		list.Items = append(list.Items, *cm)
+	}
+	return nil
+}
+
 func (c *Client) listUnstructured(list *unstructured.UnstructuredList, options client.ListOptions) error {
 	if options.FieldSelector != nil {
 		return errors.Errorf("fake.Client.List for UnstructuredList does not yet support the FieldSelector option, but got: %+v", options)
@@ -0,0 +1,153 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncwindow
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestParseSchedule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		timeZone string
+		wantErr  bool
+	}{
+		{name: "every minute", spec: "* * * * *"},
+		{name: "lists, ranges and steps", spec: "0,30 9-17/2 1-15 */3 1-5"},
+		{name: "names", spec: "0 0 * jan-mar MON-fri"},
+		{name: "sunday as 7", spec: "0 0 * * 7"},
+		{name: "macro", spec: "@daily"},
+		{name: "time zone", spec: "0 22 * * *", timeZone: "America/New_York"},
+		{name: "too few fields", spec: "0 22 * *", wantErr: true},
+		{name: "too many fields", spec: "0 0 22 * * *", wantErr: true},
+		{name: "minute out of range", spec: "60 * * * *", wantErr: true},
+		{name: "day of month out of range", spec: "0 0 0 * *", wantErr: true},
+		{name: "inverted range", spec: "0 17-9 * * *", wantErr: true},
+		{name: "invalid step", spec: "*/0 * * * *", wantErr: true},
+		{name: "unknown name", spec: "0 0 * * funday", wantErr: true},
+		{name: "unknown time zone", spec: "0 0 * * *", timeZone: "Mars/Olympus_Mons", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSchedule(tc.spec, tc.timeZone)
+			if tc.wantErr && err == nil {
+				t.Errorf("ParseSchedule(%q, %q) got no error, want an error", tc.spec, tc.timeZone)
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("ParseSchedule(%q, %q) got unexpected error %v", tc.spec, tc.timeZone, err)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		timeZone string
+		after    string
+		want     string
+	}{
+		{
+			name:  "next minute",
+			spec:  "* * * * *",
+			after: "2022-03-01T10:00:00Z",
+			want:  "2022-03-01T10:01:00Z",
+		},
+		{
+			name:  "strictly after",
+			spec:  "0 22 * * *",
+			after: "2022-03-01T22:00:00Z",
+			want:  "2022-03-02T22:00:00Z",
+		},
+		{
+			name:  "later the same day",
+			spec:  "30 9-17/2 * * *",
+			after: "2022-03-01T10:12:34Z",
+			want:  "2022-03-01T11:30:00Z",
+		},
+		{
+			name:  "next weekday",
+			spec:  "0 9 * * mon-fri",
+			after: "2022-03-04T10:00:00Z", // Friday
+			want:  "2022-03-07T09:00:00Z",
+		},
+		{
+			name:  "day of month or day of week",
+			spec:  "0 0 15 * sun",
+			after: "2022-03-07T00:00:00Z", // Monday
+			want:  "2022-03-13T00:00:00Z",
+		},
+		{
+			name:  "next year",
+			spec:  "@yearly",
+			after: "2022-03-01T00:00:00Z",
+			want:  "2023-01-01T00:00:00Z",
+		},
+		{
+			name:  "leap day",
+			spec:  "0 0 29 2 *",
+			after: "2022-03-01T00:00:00Z",
+			want:  "2024-02-29T00:00:00Z",
+		},
+		{
+			name:     "time zone",
+			spec:     "0 22 * * *",
+			timeZone: "America/New_York",
+			after:    "2022-03-01T00:00:00Z",
+			want:     "2022-03-01T03:00:00Z",
+		},
+		{
+			name:     "daylight saving time",
+			spec:     "0 22 * * *",
+			timeZone: "America/New_York",
+			after:    "2022-03-13T04:00:00Z",
+			want:     "2022-03-14T02:00:00Z",
+		},
+		{
+			name:  "never",
+			spec:  "0 0 30 2 *",
+			after: "2022-03-01T00:00:00Z",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseSchedule(tc.spec, tc.timeZone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Next(mustParseTime(t, tc.after))
+			if tc.want == "" {
+				if !got.IsZero() {
+					t.Errorf("Next(%s) = %s, want the zero time", tc.after, got)
+				}
+				return
+			}
+			if want := mustParseTime(t, tc.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tc.after, got.UTC(), want)
+			}
+		})
+	}
+}
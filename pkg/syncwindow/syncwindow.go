@@ -0,0 +1,216 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package syncwindow restricts when the changes of the source of truth of a
+// RootSync or RepoSync are applied.
+package syncwindow
+
+import (
+	"context"
+	"time"
+
+	// The reconciler image does not ship the time zone database.
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/api/configsync"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxWait bounds the search for the next time the sync windows open.
+const maxWait = 366 * 24 * time.Hour
+
+type window struct {
+	block    bool
+	schedule *Schedule
+	duration time.Duration
+}
+
+// active returns true if the window started at or before t, and has not ended
+// yet.
+func (w window) active(t time.Time) bool {
+	start := w.schedule.Next(t.Add(-w.duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+// Gate decides whether the changes of the source of truth may be applied,
+// according to the sync windows of a RootSync or RepoSync. The changes may be
+// applied if no Block window is active and, if there are Allow windows, one of
+// them is active. The commit which is already synced, e.g. when the reconciler
+// restarts, may always be applied. A commit may also bypass the windows with
+// the BypassSyncWindowAnnotationKey annotation on the RootSync or RepoSync.
+//
+// A nil Gate is always open.
+type Gate struct {
+	windows  []window
+	reader   client.Reader
+	scope    declared.Scope
+	syncName string
+}
+
+// NewGate returns a Gate enforcing the sync windows, which reads the bypass
+// annotation from the RootSync or RepoSync with reader. It returns nil if there
+// are no sync windows.
+func NewGate(windows []v1beta1.SyncWindow, reader client.Reader, scope declared.Scope, syncName string) (*Gate, error) {
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	g := &Gate{reader: reader, scope: scope, syncName: syncName}
+	for i, w := range windows {
+		parsed, err := parseWindow(w)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid sync window %d", i)
+		}
+		g.windows = append(g.windows, parsed)
+	}
+	return g, nil
+}
+
+// Validate returns an error if one of the sync windows is invalid.
+func Validate(windows []v1beta1.SyncWindow) error {
+	for i, w := range windows {
+		if _, err := parseWindow(w); err != nil {
+			return errors.Wrapf(err, "invalid sync window %d", i)
+		}
+	}
+	return nil
+}
+
+func parseWindow(w v1beta1.SyncWindow) (window, error) {
+	var block bool
+	switch w.Type {
+	case "", v1beta1.AllowSyncWindow:
+	case v1beta1.BlockSyncWindow:
+		block = true
+	default:
+		return window{}, errors.Errorf("type must be one of %s, %s, got %q", v1beta1.AllowSyncWindow, v1beta1.BlockSyncWindow, w.Type)
+	}
+	if w.Duration.Duration <= 0 {
+		return window{}, errors.Errorf("duration must be positive, got %s", w.Duration.Duration)
+	}
+	schedule, err := ParseSchedule(w.Schedule, w.TimeZone)
+	if err != nil {
+		return window{}, err
+	}
+	return window{block: block, schedule: schedule, duration: w.Duration.Duration}, nil
+}
+
+// Allows returns true if commit may be applied at now, either because the sync
+// windows are open, or because the commit is exempt from them. Otherwise, it also
+// returns when the sync windows open next, or the zero time if they do not
+// open within a year.
+func (g *Gate) Allows(ctx context.Context, commit string, now time.Time) (bool, time.Time) {
+	if g == nil || g.open(now) {
+		return true, time.Time{}
+	}
+	if g.exempt(ctx, commit) {
+		return true, time.Time{}
+	}
+	return false, g.NextOpen(now)
+}
+
+// open returns true if the changes may be applied at t.
+func (g *Gate) open(t time.Time) bool {
+	allowed, hasAllow := false, false
+	for _, w := range g.windows {
+		if w.block {
+			if w.active(t) {
+				return false
+			}
+			continue
+		}
+		hasAllow = true
+		allowed = allowed || w.active(t)
+	}
+	return allowed || !hasAllow
+}
+
+// NextOpen returns the first time at or after t at which the changes may be
+// applied, or the zero time if there is none within a year.
+func (g *Gate) NextOpen(t time.Time) time.Time {
+	if g == nil {
+		return t
+	}
+	limit := t.Add(maxWait)
+	for !t.After(limit) {
+		if g.open(t) {
+			return t
+		}
+		// Jump to the next time at which the state of a window changes: the
+		// end of an active Block window, or the start of an Allow window.
+		var next time.Time
+		for _, w := range g.windows {
+			var change time.Time
+			if w.block {
+				start := w.schedule.Next(t.Add(-w.duration))
+				if start.IsZero() || start.After(t) {
+					continue
+				}
+				change = start.Add(w.duration)
+			} else {
+				change = w.schedule.Next(t)
+			}
+			if !change.IsZero() && (next.IsZero() || change.Before(next)) {
+				next = change
+			}
+		}
+		if next.IsZero() {
+			return time.Time{}
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// exempt returns true if commit is the commit which the RootSync or RepoSync
+// already synced, or which it allows to bypass the sync windows with the
+// BypassSyncWindowAnnotationKey annotation.
+func (g *Gate) exempt(ctx context.Context, commit string) bool {
+	if commit == "" {
+		return false
+	}
+	var obj client.Object
+	key := client.ObjectKey{Name: g.syncName}
+	if g.scope == declared.RootReconciler {
+		obj = &v1beta1.RootSync{}
+		key.Namespace = configsync.ControllerNamespace
+	} else {
+		obj = &v1beta1.RepoSync{}
+		key.Namespace = string(g.scope)
+	}
+	if err := g.reader.Get(ctx, key, obj); err != nil {
+		klog.Warningf("Failed to read the sync window exemptions of %s: %v", key, err)
+		return false
+	}
+	var syncedCommit string
+	switch rs := obj.(type) {
+	case *v1beta1.RootSync:
+		syncedCommit = rs.Status.Sync.Commit
+	case *v1beta1.RepoSync:
+		syncedCommit = rs.Status.Sync.Commit
+	}
+	if syncedCommit == commit {
+		return true
+	}
+	if core.GetAnnotation(obj, metadata.BypassSyncWindowAnnotationKey) != commit {
+		return false
+	}
+	klog.Infof("Commit %s bypasses the sync windows of %s", commit, key)
+	return true
+}
@@ -0,0 +1,240 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncwindow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/metadata"
+	syncertestfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+const commit = "abc123"
+
+func syncWindow(windowType v1beta1.SyncWindowType, schedule string, duration time.Duration) v1beta1.SyncWindow {
+	return v1beta1.SyncWindow{
+		Type:     windowType,
+		Schedule: schedule,
+		Duration: metav1.Duration{Duration: duration},
+	}
+}
+
+func newTestGate(t *testing.T, windows []v1beta1.SyncWindow, bypassedCommit, syncedCommit string) *Gate {
+	t.Helper()
+	rs := fake.RootSyncObjectV1Beta1("root-sync")
+	if bypassedCommit != "" {
+		core.SetAnnotation(rs, metadata.BypassSyncWindowAnnotationKey, bypassedCommit)
+	}
+	rs.Status.Sync.Commit = syncedCommit
+	g, err := NewGate(windows, syncertestfake.NewClient(t, runtime.NewScheme(), rs), declared.RootReconciler, "root-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGate_Allows(t *testing.T) {
+	// Every weekday from 9am to 5pm.
+	businessHours := syncWindow(v1beta1.AllowSyncWindow, "0 9 * * 1-5", 8*time.Hour)
+	// Every day from 10pm to 2am.
+	nightly := syncWindow("", "0 22 * * *", 4*time.Hour)
+	// Every day from noon to 1pm.
+	lunchFreeze := syncWindow(v1beta1.BlockSyncWindow, "0 12 * * *", time.Hour)
+	// Whole days of December.
+	decemberFreeze := syncWindow(v1beta1.BlockSyncWindow, "0 0 * 12 *", 24*time.Hour)
+
+	testCases := []struct {
+		name           string
+		windows        []v1beta1.SyncWindow
+		bypassedCommit string
+		syncedCommit   string
+		now            string
+		wantAllowed    bool
+		wantNext       string
+	}{
+		{
+			name:        "no windows",
+			now:         "2022-03-01T10:00:00Z",
+			wantAllowed: true,
+		},
+		{
+			name:        "in an allow window",
+			windows:     []v1beta1.SyncWindow{businessHours},
+			now:         "2022-03-01T10:00:00Z",
+			wantAllowed: true,
+		},
+		{
+			name:        "at the start of an allow window",
+			windows:     []v1beta1.SyncWindow{businessHours},
+			now:         "2022-03-01T09:00:00Z",
+			wantAllowed: true,
+		},
+		{
+			name:     "at the end of an allow window",
+			windows:  []v1beta1.SyncWindow{businessHours},
+			now:      "2022-03-01T17:00:00Z",
+			wantNext: "2022-03-02T09:00:00Z",
+		},
+		{
+			name:     "over the weekend",
+			windows:  []v1beta1.SyncWindow{businessHours},
+			now:      "2022-03-05T10:00:00Z",
+			wantNext: "2022-03-07T09:00:00Z",
+		},
+		{
+			name:        "in the second allow window",
+			windows:     []v1beta1.SyncWindow{businessHours, nightly},
+			now:         "2022-03-06T01:00:00Z",
+			wantAllowed: true,
+		},
+		{
+			name:     "between allow windows",
+			windows:  []v1beta1.SyncWindow{businessHours, nightly},
+			now:      "2022-03-01T18:00:00Z",
+			wantNext: "2022-03-01T22:00:00Z",
+		},
+		{
+			name:     "in a block window",
+			windows:  []v1beta1.SyncWindow{lunchFreeze},
+			now:      "2022-03-01T12:30:00Z",
+			wantNext: "2022-03-01T13:00:00Z",
+		},
+		{
+			name:        "outside of a block window",
+			windows:     []v1beta1.SyncWindow{lunchFreeze},
+			now:         "2022-03-01T13:00:00Z",
+			wantAllowed: true,
+		},
+		{
+			name:     "block window overrides allow window",
+			windows:  []v1beta1.SyncWindow{businessHours, lunchFreeze},
+			now:      "2022-03-01T12:30:00Z",
+			wantNext: "2022-03-01T13:00:00Z",
+		},
+		{
+			name:     "consecutive block windows",
+			windows:  []v1beta1.SyncWindow{decemberFreeze},
+			now:      "2022-12-24T10:00:00Z",
+			wantNext: "2023-01-01T00:00:00Z",
+		},
+		{
+			name:     "block window and allow window",
+			windows:  []v1beta1.SyncWindow{businessHours, decemberFreeze},
+			now:      "2022-12-24T10:00:00Z",
+			wantNext: "2023-01-02T09:00:00Z",
+		},
+		{
+			name:     "never open",
+			windows:  []v1beta1.SyncWindow{syncWindow(v1beta1.BlockSyncWindow, "* * * * *", time.Hour)},
+			now:      "2022-03-01T10:00:00Z",
+			wantNext: "",
+		},
+		{
+			name:           "bypassed",
+			windows:        []v1beta1.SyncWindow{lunchFreeze},
+			bypassedCommit: commit,
+			now:            "2022-03-01T12:30:00Z",
+			wantAllowed:    true,
+		},
+		{
+			name:           "another commit bypassed",
+			windows:        []v1beta1.SyncWindow{lunchFreeze},
+			bypassedCommit: "def456",
+			now:            "2022-03-01T12:30:00Z",
+			wantNext:       "2022-03-01T13:00:00Z",
+		},
+		{
+			name:         "already synced",
+			windows:      []v1beta1.SyncWindow{lunchFreeze},
+			syncedCommit: commit,
+			now:          "2022-03-01T12:30:00Z",
+			wantAllowed:  true,
+		},
+		{
+			name:         "another commit synced",
+			windows:      []v1beta1.SyncWindow{lunchFreeze},
+			syncedCommit: "def456",
+			now:          "2022-03-01T12:30:00Z",
+			wantNext:     "2022-03-01T13:00:00Z",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newTestGate(t, tc.windows, tc.bypassedCommit, tc.syncedCommit)
+			allowed, next := g.Allows(context.Background(), commit, mustParseTime(t, tc.now))
+			if allowed != tc.wantAllowed {
+				t.Errorf("Allows() = %t, want %t", allowed, tc.wantAllowed)
+			}
+			if tc.wantNext == "" {
+				if !next.IsZero() {
+					t.Errorf("Allows() got next window %s, want none", next.UTC())
+				}
+				return
+			}
+			if want := mustParseTime(t, tc.wantNext); !next.Equal(want) {
+				t.Errorf("Allows() got next window %s, want %s", next.UTC(), want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		windows []v1beta1.SyncWindow
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			windows: []v1beta1.SyncWindow{
+				syncWindow(v1beta1.AllowSyncWindow, "0 9 * * 1-5", 8*time.Hour),
+				syncWindow(v1beta1.BlockSyncWindow, "0 0 * 12 *", 24*time.Hour),
+			},
+		},
+		{
+			name:    "invalid type",
+			windows: []v1beta1.SyncWindow{syncWindow("Deny", "0 9 * * *", time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "missing duration",
+			windows: []v1beta1.SyncWindow{syncWindow(v1beta1.AllowSyncWindow, "0 9 * * *", 0)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule",
+			windows: []v1beta1.SyncWindow{syncWindow(v1beta1.AllowSyncWindow, "every day", time.Hour)},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.windows)
+			if tc.wantErr && err == nil {
+				t.Error("Validate() got no error, want an error")
+			} else if !tc.wantErr && err != nil {
+				t.Errorf("Validate() got unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// GitSyncWakeFile is the file which requests a restart of the git-sync
+	// container. It is held in an emptyDir volume shared by the reconciler and
+	// git-sync containers, and consumed by the liveness probe of git-sync.
+	GitSyncWakeFile = "/git-sync-wake/restart"
+
+	// GitSyncWakeMinInterval is the minimum interval between two restarts of
+	// git-sync. The kubelet delays the restart of a container which failed
+	// within the last few minutes, by up to five minutes, so restarts which are
+	// at least five minutes apart are never delayed.
+	GitSyncWakeMinInterval = 5 * time.Minute
+)
+
+// GitSyncWaker wakes git-sync by having the kubelet restart it: git-sync
+// fetches right away when it starts, and reuses the repository it has already
+// cloned. The wake is requested by creating a file which the liveness probe of
+// git-sync removes, failing the probe, so that the reconciler needs neither
+// the credentials of git-sync nor to share its process namespace.
+//
+// Wakes requested within GitSyncWakeMinInterval of the last restart are
+// coalesced and delayed until the interval has elapsed.
+type GitSyncWaker struct {
+	path        string
+	minInterval time.Duration
+
+	mu sync.Mutex
+	// last is the time at which the last restart was requested.
+	last time.Time
+	// timer requests the pending restart, if any.
+	timer *time.Timer
+}
+
+// NewGitSyncWaker returns a GitSyncWaker which requests the restarts of
+// git-sync by creating the file at path.
+func NewGitSyncWaker(path string, minInterval time.Duration) *GitSyncWaker {
+	return &GitSyncWaker{
+		path:        path,
+		minInterval: minInterval,
+	}
+}
+
+// Wake requests a restart of git-sync, or schedules one if git-sync was
+// restarted less than the minimum interval ago.
+func (w *GitSyncWaker) Wake(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		// A restart is already scheduled.
+		return nil
+	}
+	wait := w.minInterval - time.Since(w.last)
+	if w.last.IsZero() || wait <= 0 {
+		return w.restart()
+	}
+	klog.Infof("git-sync was restarted less than %v ago, restarting it again in %v", w.minInterval, wait.Round(time.Second))
+	w.timer = time.AfterFunc(wait, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.timer = nil
+		if err := w.restart(); err != nil {
+			klog.Warningf("Failed to wake the source container: %v", err)
+		}
+	})
+	return nil
+}
+
+// restart creates the file which requests the restart of git-sync. It must be
+// called with w.mu held.
+func (w *GitSyncWaker) restart() error {
+	w.last = time.Now()
+	if err := os.WriteFile(w.path, nil, 0666); err != nil {
+		return fmt.Errorf("failed to request a restart of git-sync: %w", err)
+	}
+	klog.Infof("Requested a restart of git-sync to fetch the latest commit")
+	return nil
+}
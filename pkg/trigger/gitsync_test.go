@@ -0,0 +1,69 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGitSyncWaker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restart")
+	minInterval := 300 * time.Millisecond
+	w := NewGitSyncWaker(path, minInterval)
+	requested := func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	// consume removes the file, as the liveness probe of git-sync does.
+	consume := func() {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	if err := w.Wake(context.Background()); err != nil {
+		t.Fatalf("Wake() got unexpected error: %v", err)
+	}
+	if !requested() {
+		t.Fatal("the first Wake() did not request a restart of git-sync")
+	}
+	consume()
+
+	// The restarts requested within the minimum interval are coalesced and
+	// delayed.
+	for i := 0; i < 2; i++ {
+		if err := w.Wake(context.Background()); err != nil {
+			t.Fatalf("Wake() got unexpected error: %v", err)
+		}
+	}
+	if requested() {
+		t.Fatal("Wake() requested a restart of git-sync within the minimum interval")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !requested() {
+		if time.Now().After(deadline) {
+			t.Fatal("the delayed restart of git-sync was never requested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < minInterval {
+		t.Errorf("the delayed restart was requested after %v, want at least %v", elapsed, minInterval)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trigger implements the sync trigger endpoint, which lets a GitHub or
+// GitLab push webhook, or a generic "sync now" call, start a sync without
+// waiting for the next polling period.
+//
+// The reconciler container serves the endpoint on ReconcilerAddr, where every
+// request must be authenticated with the sync trigger token, and on
+// ReconcilerLocalAddr, which is only reachable from within the Pod and requires
+// no token. When an external request is accepted, the reconciler wakes the
+// oci-sync or helm-sync container through the endpoint it serves on
+// SidecarAddr, or has the kubelet restart the git-sync container, see
+// GitSyncWaker. Once a source container has fetched a new commit, it notifies
+// the reconciler on ReconcilerURL. git-sync does so through its own webhook.
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Path is the path of the sync trigger endpoint.
+	Path = "/sync"
+
+	// ReconcilerAddr is the address on which the reconciler container serves
+	// the authenticated sync trigger endpoint.
+	ReconcilerAddr = ":8090"
+
+	// ReconcilerLocalAddr is the address on which the reconciler container
+	// serves the unauthenticated sync trigger endpoint. It is only reachable
+	// from within the Pod.
+	ReconcilerLocalAddr = "127.0.0.1:8092"
+
+	// SidecarAddr is the address on which the oci-sync and helm-sync containers
+	// serve the sync trigger endpoint. It is only reachable from within the Pod.
+	SidecarAddr = "127.0.0.1:8091"
+
+	// ReconcilerURL is the URL the source containers call to notify the
+	// reconciler that a new commit has been fetched.
+	ReconcilerURL = "http://" + ReconcilerLocalAddr + Path
+
+	// SidecarURL is the URL the reconciler calls to wake the oci-sync or
+	// helm-sync container.
+	SidecarURL = "http://" + SidecarAddr + Path
+
+	// DefaultTokenFile is the default path of the file holding the sync
+	// trigger token in the reconciler container.
+	DefaultTokenFile = "/etc/sync-trigger/token"
+
+	// DefaultMinInterval is the default minimum interval between two triggers.
+	DefaultMinInterval = 5 * time.Second
+
+	// maxBodySize is the maximum size of a webhook payload. GitHub caps its
+	// payloads at 25MB, but push payloads are far smaller in practice.
+	maxBodySize = 10 << 20
+
+	// notifyTimeout is the timeout of the requests sent by Notify.
+	notifyTimeout = 10 * time.Second
+)
+
+// Options configures a Handler.
+type Options struct {
+	// TokenFile is the path of the file holding the token which authenticates
+	// the requests served by ServeHTTP. The token is compared with the bearer
+	// token of a generic call, with the X-Gitlab-Token header of a GitLab
+	// webhook, and used as the secret of the X-Hub-Signature-256 HMAC of a
+	// GitHub webhook. The file is read on every request, so that a rotated
+	// token applies without a restart. If the file is missing or empty, every
+	// request is rejected.
+	TokenFile string
+	// Branch, if set, makes the Handler ignore the push webhooks of other
+	// branches.
+	Branch string
+	// MinInterval is the minimum interval between two triggers. Requests
+	// received in between are coalesced into a single trigger which fires once
+	// the interval has elapsed.
+	MinInterval time.Duration
+	// Wake, if set, is called when a trigger caused by a request served by
+	// ServeHTTP fires, to wake the source container so that it fetches the new
+	// commit without waiting for its own polling period.
+	Wake func(context.Context) error
+}
+
+// Handler serves the sync trigger endpoint. Accepted requests are rate-limited
+// and coalesced, and the resulting triggers are delivered on C.
+type Handler struct {
+	opts Options
+	c    chan struct{}
+
+	mu sync.Mutex
+	// last is the time at which the last trigger fired.
+	last time.Time
+	// timer fires the pending trigger, if any.
+	timer *time.Timer
+	// wake is true if the pending trigger must wake the source container.
+	wake bool
+}
+
+// NewHandler returns a Handler configured with opts.
+func NewHandler(opts Options) *Handler {
+	return &Handler{
+		opts: opts,
+		c:    make(chan struct{}, 1),
+	}
+}
+
+// C returns the channel on which the triggers are delivered. Triggers which are
+// not consumed yet are coalesced. It returns nil, which blocks forever, for a
+// nil Handler so that the endpoint can be disabled.
+func (h *Handler) C() <-chan struct{} {
+	if h == nil {
+		return nil
+	}
+	return h.c
+}
+
+// ServeHTTP implements http.Handler. It serves the requests from outside of the
+// Pod, which must be authenticated with the token.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, false)
+}
+
+// Local returns the http.Handler of the requests from within the Pod, which
+// are trusted. It must only be served on a loopback address.
+func (h *Handler) Local() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, true)
+	})
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, local bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, "failed to read the request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxBodySize {
+		http.Error(w, "the request body is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !local && !h.authenticate(r, body) {
+		klog.Warningf("Rejected an unauthenticated sync trigger from %s", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		fmt.Fprintln(w, "pong")
+		return
+	}
+	if ref := pushedRef(body); h.opts.Branch != "" && strings.HasPrefix(ref, "refs/heads/") && ref != "refs/heads/"+h.opts.Branch {
+		fmt.Fprintf(w, "ignored the push to %s\n", ref)
+		return
+	}
+
+	h.Trigger(!local)
+	fmt.Fprintln(w, "sync triggered")
+}
+
+// authenticate returns true if the request carries the token, either as a
+// bearer token, a GitLab webhook token or a GitHub webhook signature.
+func (h *Handler) authenticate(r *http.Request, body []byte) bool {
+	want := h.token()
+	if want == "" {
+		return false
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return equal(strings.TrimPrefix(auth, "Bearer "), want)
+	}
+	if token := r.Header.Get("X-Gitlab-Token"); token != "" {
+		return equal(token, want)
+	}
+	if signature := r.Header.Get("X-Hub-Signature-256"); strings.HasPrefix(signature, "sha256=") {
+		return equal(strings.TrimPrefix(signature, "sha256="), Signature(want, body))
+	}
+	return false
+}
+
+// token returns the current token, or an empty string if it can not be read.
+func (h *Handler) token() string {
+	if h.opts.TokenFile == "" {
+		return ""
+	}
+	data, err := os.ReadFile(h.opts.TokenFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			klog.Warningf("Failed to read the sync trigger token: %v", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Signature returns the hex-encoded HMAC-SHA256 of body with the token as the
+// key, as sent by GitHub in the X-Hub-Signature-256 header.
+func Signature(token string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// pushedRef returns the ref of a GitHub or GitLab push webhook payload, or an
+// empty string if the payload has none.
+func pushedRef(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	payload := struct {
+		Ref string `json:"ref"`
+	}{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.Ref
+}
+
+// Trigger requests a sync. It fires immediately unless the previous trigger
+// fired less than MinInterval ago, in which case it is coalesced with the other
+// requests received in the meantime into a single trigger. If wake is true, the
+// source container is woken as well.
+func (h *Handler) Trigger(wake bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.wake = h.wake || wake
+	if h.timer != nil {
+		// A trigger is already pending.
+		return
+	}
+	wait := h.opts.MinInterval - time.Since(h.last)
+	if wait <= 0 {
+		h.fire()
+		return
+	}
+	h.timer = time.AfterFunc(wait, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.timer = nil
+		h.fire()
+	})
+}
+
+// fire delivers a trigger. It must be called with h.mu held.
+func (h *Handler) fire() {
+	h.last = time.Now()
+	if h.wake && h.opts.Wake != nil {
+		go func(wake func(context.Context) error) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := wake(ctx); err != nil {
+				klog.Warningf("Failed to wake the source container: %v", err)
+			}
+		}(h.opts.Wake)
+	}
+	h.wake = false
+	select {
+	case h.c <- struct{}{}:
+	default:
+		// The previous trigger is not consumed yet.
+	}
+}
+
+// Notify calls the sync trigger endpoint at url from within the Pod.
+func Notify(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// Start serves the sync trigger endpoint of h until ctx is done: the
+// authenticated one on addr and the unauthenticated one on localAddr. Either
+// address may be empty to not serve the corresponding endpoint.
+func Start(ctx context.Context, addr, localAddr string, h *Handler) {
+	if addr != "" {
+		serve(ctx, addr, h)
+	}
+	if localAddr != "" {
+		serve(ctx, localAddr, h.Local())
+	}
+}
+
+func serve(ctx context.Context, addr string, h http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(Path, h)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		klog.Infof("Serving the sync trigger endpoint on %s%s", addr, Path)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("The sync trigger endpoint failed: %v", err)
+		}
+	}()
+}
+
+// Sleep waits for d to elapse or for a trigger to be delivered on c, whichever
+// comes first.
+func Sleep(d time.Duration, c <-chan struct{}) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c:
+		klog.Info("Sync triggered before the end of the wait period")
+	}
+}
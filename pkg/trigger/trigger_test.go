@@ -0,0 +1,331 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trigger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	token      = "s3cr3t"
+	remoteAddr = "203.0.113.7:40000"
+	localAddr  = "127.0.0.1:40000"
+)
+
+// writeTokenFile writes the token, followed by a newline as kubectl does, to a
+// file in a temporary directory and returns the path of the file.
+func writeTokenFile(t *testing.T, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServeHTTP(t *testing.T) {
+	pushMain := `{"ref":"refs/heads/main","after":"abc123"}`
+	pushOther := `{"ref":"refs/heads/feature","after":"abc123"}`
+
+	testCases := []struct {
+		name        string
+		method      string
+		remoteAddr  string
+		local       bool
+		headers     map[string]string
+		body        string
+		wantStatus  int
+		wantTrigger bool
+	}{
+		{
+			name:        "generic call with a bearer token",
+			remoteAddr:  remoteAddr,
+			headers:     map[string]string{"Authorization": "Bearer " + token},
+			wantStatus:  http.StatusOK,
+			wantTrigger: true,
+		},
+		{
+			name:       "generic call with a wrong bearer token",
+			remoteAddr: remoteAddr,
+			headers:    map[string]string{"Authorization": "Bearer wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "generic call without a token",
+			remoteAddr: remoteAddr,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "call from within the Pod",
+			remoteAddr:  localAddr,
+			local:       true,
+			wantStatus:  http.StatusOK,
+			wantTrigger: true,
+		},
+		{
+			name:       "loopback call to the authenticated endpoint without a token",
+			remoteAddr: localAddr,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "GET request",
+			method:     http.MethodGet,
+			remoteAddr: localAddr,
+			local:      true,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:        "GitLab push webhook",
+			remoteAddr:  remoteAddr,
+			headers:     map[string]string{"X-Gitlab-Token": token, "X-Gitlab-Event": "Push Hook"},
+			body:        pushMain,
+			wantStatus:  http.StatusOK,
+			wantTrigger: true,
+		},
+		{
+			name:       "GitLab push webhook with a wrong token",
+			remoteAddr: remoteAddr,
+			headers:    map[string]string{"X-Gitlab-Token": "wrong", "X-Gitlab-Event": "Push Hook"},
+			body:       pushMain,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "GitHub push webhook",
+			remoteAddr:  remoteAddr,
+			headers:     map[string]string{"X-Hub-Signature-256": "sha256=" + Signature(token, []byte(pushMain)), "X-GitHub-Event": "push"},
+			body:        pushMain,
+			wantStatus:  http.StatusOK,
+			wantTrigger: true,
+		},
+		{
+			name:       "GitHub push webhook signed with another secret",
+			remoteAddr: remoteAddr,
+			headers:    map[string]string{"X-Hub-Signature-256": "sha256=" + Signature("wrong", []byte(pushMain)), "X-GitHub-Event": "push"},
+			body:       pushMain,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "GitHub ping webhook",
+			remoteAddr: remoteAddr,
+			headers:    map[string]string{"X-Hub-Signature-256": "sha256=" + Signature(token, []byte(`{"zen":"Keep it simple."}`)), "X-GitHub-Event": "ping"},
+			body:       `{"zen":"Keep it simple."}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "push webhook of another branch",
+			remoteAddr: remoteAddr,
+			headers:    map[string]string{"X-Gitlab-Token": token},
+			body:       pushOther,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "push webhook of a tag",
+			remoteAddr:  remoteAddr,
+			headers:     map[string]string{"X-Gitlab-Token": token},
+			body:        `{"ref":"refs/tags/v1.0.0"}`,
+			wantStatus:  http.StatusOK,
+			wantTrigger: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(Options{TokenFile: writeTokenFile(t, token), Branch: "main"})
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, Path, strings.NewReader(tc.body))
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			if tc.local {
+				h.Local().ServeHTTP(w, req)
+			} else {
+				h.ServeHTTP(w, req)
+			}
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", w.Code, tc.wantStatus)
+			}
+			select {
+			case <-h.C():
+				if !tc.wantTrigger {
+					t.Error("ServeHTTP() triggered a sync, want none")
+				}
+			default:
+				if tc.wantTrigger {
+					t.Error("ServeHTTP() did not trigger a sync")
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTPWithoutToken(t *testing.T) {
+	for name, tokenFile := range map[string]string{
+		"no token file":      "",
+		"missing token file": filepath.Join(t.TempDir(), "token"),
+		"empty token file":   writeTokenFile(t, ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHandler(Options{TokenFile: tokenFile})
+			req := httptest.NewRequest(http.MethodPost, Path, nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("Authorization", "Bearer ")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("ServeHTTP() status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestServeHTTPTokenRotation(t *testing.T) {
+	tokenFile := writeTokenFile(t, token)
+	h := NewHandler(Options{TokenFile: tokenFile})
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, Path, nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	if got := call(token); got != http.StatusOK {
+		t.Fatalf("ServeHTTP() status = %d, want %d", got, http.StatusOK)
+	}
+
+	if err := os.WriteFile(tokenFile, []byte("rotated"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := call(token); got != http.StatusUnauthorized {
+		t.Errorf("ServeHTTP() status with the old token = %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := call("rotated"); got != http.StatusOK {
+		t.Errorf("ServeHTTP() status with the rotated token = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestTriggerCoalescing(t *testing.T) {
+	h := NewHandler(Options{MinInterval: 100 * time.Millisecond})
+
+	h.Trigger(false)
+	select {
+	case <-h.C():
+	case <-time.After(time.Second):
+		t.Fatal("the first trigger did not fire immediately")
+	}
+
+	// The following triggers are received within the minimum interval, so they
+	// must be coalesced into a single trigger once the interval has elapsed.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		h.Trigger(false)
+	}
+	select {
+	case <-h.C():
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("the coalesced trigger fired after %v, want it rate-limited", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the coalesced trigger did not fire")
+	}
+	select {
+	case <-h.C():
+		t.Error("got more than one coalesced trigger")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestTriggerWake(t *testing.T) {
+	woken := make(chan struct{}, 2)
+	h := NewHandler(Options{TokenFile: writeTokenFile(t, token), Wake: func(context.Context) error {
+		woken <- struct{}{}
+		return nil
+	}})
+
+	// A trigger from within the Pod, e.g. git-sync, must not wake the source
+	// container, which has just fetched the commit.
+	local := httptest.NewRequest(http.MethodPost, Path, nil)
+	local.RemoteAddr = localAddr
+	h.Local().ServeHTTP(httptest.NewRecorder(), local)
+	<-h.C()
+	select {
+	case <-woken:
+		t.Error("a local trigger woke the source container")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	req := httptest.NewRequest(http.MethodPost, Path, nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Authorization", "Bearer "+token)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	<-h.C()
+	select {
+	case <-woken:
+	case <-time.After(time.Second):
+		t.Error("an external trigger did not wake the source container")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	h := NewHandler(Options{})
+	server := httptest.NewServer(h.Local())
+	defer server.Close()
+
+	if err := Notify(context.Background(), server.URL+Path); err != nil {
+		t.Fatalf("Notify() got unexpected error: %v", err)
+	}
+	select {
+	case <-h.C():
+	case <-time.After(time.Second):
+		t.Error("Notify() did not trigger a sync")
+	}
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer rejecting.Close()
+	if err := Notify(context.Background(), rejecting.URL); err == nil {
+		t.Error("Notify() got nil error, want error")
+	}
+}
+
+func TestSleep(t *testing.T) {
+	c := make(chan struct{}, 1)
+	c <- struct{}{}
+	start := time.Now()
+	Sleep(time.Minute, c)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Sleep() returned after %v, want it woken by the trigger", elapsed)
+	}
+
+	start = time.Now()
+	Sleep(10*time.Millisecond, nil)
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Sleep() returned after %v, want at least 10ms", elapsed)
+	}
+}
@@ -89,3 +89,13 @@ func UpdateSymlink(helmRoot, linkAbsPath, packageDir, oldPackageDir string) erro
 	klog.Infof("symlink %q updates to %q", linkAbsPath, packageDir)
 	return nil
 }
+
+// SymlinkTarget returns the package directory that the symbolic link dest
+// under root points to, or an empty string if the link doesn't exist yet.
+func SymlinkTarget(root, dest string) string {
+	target, err := filepath.EvalSymlinks(filepath.Join(root, dest))
+	if err != nil {
+		return ""
+	}
+	return target
+}
@@ -35,6 +35,8 @@ type ObjectVisitor func(obj ast.FileObject) status.Error
 // Git repo for a cluster.
 type Raw struct {
 	ClusterName       string
+	ClusterLabels     map[string]string
+	ClusterValues     map[string]string
 	PolicyDir         cmpath.Relative
 	Objects           []ast.FileObject
 	PreviousCRDs      []*v1beta1.CustomResourceDefinition
@@ -15,6 +15,7 @@
 package objects
 
 import (
+	"k8s.io/apimachinery/pkg/labels"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/status"
 )
@@ -30,6 +31,33 @@ type Scoped struct {
 	Unknown               []ast.FileObject
 	DefaultNamespace      string
 	IsNamespaceReconciler bool
+	// LiveNamespaces provides the Namespaces on the cluster to NamespaceSelectors
+	// in dynamic mode. It is nil when the repo is not synced to a cluster, in
+	// which case they only select the declared Namespaces.
+	LiveNamespaces LiveNamespaces
+}
+
+// LiveNamespaces provides the Namespaces on the cluster to the
+// NamespaceSelectors in dynamic mode.
+type LiveNamespaces interface {
+	// Labels returns the labels of the Namespaces on the cluster, keyed by
+	// Namespace name, or an error if they cannot be listed.
+	Labels() (map[string]labels.Set, error)
+	// SetSelection records the Namespaces selected by each NamespaceSelector in
+	// dynamic mode, so that creating, relabelling or deleting a Namespace which
+	// changes the selection triggers a new sync.
+	SetSelection(selection []NamespaceSelection)
+}
+
+// NamespaceSelection is the set of Namespaces selected by a NamespaceSelector
+// in dynamic mode.
+type NamespaceSelection struct {
+	// Name is the name of the NamespaceSelector.
+	Name string
+	// Selector is the label selector of the NamespaceSelector.
+	Selector labels.Selector
+	// Namespaces are the sorted names of the selected Namespaces.
+	Namespaces []string
 }
 
 // Objects returns all FileObjects in the Scoped collection.
@@ -37,7 +37,7 @@ func ClusterSelectors(objs *objects.Raw) status.MultiError {
 	if errs != nil {
 		return errs
 	}
-	activeSelectors, errs := set.activeSelectors()
+	activeSelectors, errs := set.activeSelectors(objs.ClusterLabels)
 	if errs != nil {
 		return errs
 	}
@@ -184,12 +184,18 @@ func (h *hydratorSet) clusterSelectorObject(object ast.FileObject) status.Error
 	return nil
 }
 
-func (h *hydratorSet) activeSelectors() (map[string]bool, status.MultiError) {
+// activeSelectors returns whether each ClusterSelector selects the cluster.
+// The labels of the cluster are those of its Cluster object, overridden by the
+// given labels read from the live cluster.
+func (h *hydratorSet) activeSelectors(liveLabels map[string]string) (map[string]bool, status.MultiError) {
 	activeSels := make(map[string]bool)
 	clusterLabels := labels.Set{}
 	if h.cluster != nil {
 		clusterLabels = h.cluster.Labels
 	}
+	if len(liveLabels) > 0 {
+		clusterLabels = labels.Merge(clusterLabels, liveLabels)
+	}
 
 	var errs status.MultiError
 	for _, s := range h.selectors {
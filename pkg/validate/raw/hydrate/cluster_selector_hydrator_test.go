@@ -347,6 +347,47 @@ func TestClusterSelectors(t *testing.T) {
 			},
 			wantErrs: selectors.EmptySelectorError(fake.ClusterSelector()),
 		},
+		{
+			name: "Keep object selected by the live cluster labels without a Cluster object",
+			objs: &objects.Raw{
+				ClusterName:   unknownClusterName,
+				ClusterLabels: map[string]string{"environment": "prod"},
+				Objects: []ast.FileObject{
+					fake.Namespace("namespaces/foo", withProdLegacyClusterSelector),
+					fake.Namespace("namespaces/bar", withDevLegacyClusterSelector),
+					prodSelector,
+					devSelector,
+				},
+			},
+			want: &objects.Raw{
+				ClusterName:   unknownClusterName,
+				ClusterLabels: map[string]string{"environment": "prod"},
+				Objects: []ast.FileObject{
+					fake.Namespace("namespaces/foo", withProdLegacyClusterSelector),
+				},
+			},
+		},
+		{
+			name: "Live cluster labels override the labels of the Cluster object",
+			objs: &objects.Raw{
+				ClusterName:   prodClusterName,
+				ClusterLabels: map[string]string{"environment": "dev"},
+				Objects: []ast.FileObject{
+					fake.Namespace("namespaces/foo", withProdLegacyClusterSelector),
+					fake.Namespace("namespaces/bar", withDevLegacyClusterSelector),
+					prodCluster,
+					prodSelector,
+					devSelector,
+				},
+			},
+			want: &objects.Raw{
+				ClusterName:   prodClusterName,
+				ClusterLabels: map[string]string{"environment": "dev"},
+				Objects: []ast.FileObject{
+					fake.Namespace("namespaces/bar", withDevLegacyClusterSelector),
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
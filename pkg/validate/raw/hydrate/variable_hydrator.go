@@ -0,0 +1,38 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hydrate
+
+import (
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/validate/objects"
+	"kpt.dev/configsync/pkg/validate/variables"
+)
+
+// Variables returns a hydrator which substitutes the per-cluster variables in
+// the given Raw objects. It runs after cluster selection, so that the objects
+// selected away from the cluster do not need values for their variables. Since
+// cluster selection also filters out the Cluster objects, the labels of the
+// cluster are read from the Raw objects as they are now.
+func Variables(objs *objects.Raw) objects.RawVisitor {
+	values := variables.Values{
+		ClusterName:   objs.ClusterName,
+		ClusterLabels: objs.ClusterLabels,
+		ClusterValues: objs.ClusterValues,
+	}
+	values.ClusterLabels = variables.ClusterLabels(objs.Objects, values)
+	return func(objs *objects.Raw) status.MultiError {
+		return variables.Substitute(objs.Objects, values)
+	}
+}
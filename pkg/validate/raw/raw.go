@@ -60,18 +60,20 @@ func Hierarchical(objs *objects.Raw) status.MultiError {
 		return errs
 	}
 
-	// First we annotate all objects with their declared fields. It is crucial
-	// that we do this step before any other hydration so that we capture the
-	// object exactly as it is declared in Git. Next we set missing namespaces on
-	// objects in namespace directories since cluster selection relies on
-	// namespace if a namespace gets filtered out. Then we perform cluster
-	// selection so that we can filter out irrelevant objects before trying to
-	// modify them.
+	// First we set missing namespaces on objects in namespace directories since
+	// cluster selection relies on namespace if a namespace gets filtered out.
+	// Then we perform cluster selection so that we can filter out irrelevant
+	// objects before trying to modify them, and substitute the per-cluster
+	// variables in the remaining objects. Next we annotate all objects with their
+	// declared fields. It is crucial that we do this step before any other
+	// hydration so that we capture the object exactly as it is declared in Git
+	// for this cluster. The namespace is not part of the declared fields.
 	hydrators := []objects.RawVisitor{
-		hydrate.DeclaredFields,
-		hydrate.DeclaredVersion,
 		hydrate.ObjectNamespaces,
 		hydrate.ClusterSelectors,
+		hydrate.Variables(objs),
+		hydrate.DeclaredFields,
+		hydrate.DeclaredVersion,
 		hydrate.ClusterName,
 		hydrate.Filepath,
 		hydrate.HNCDepth,
@@ -112,14 +114,17 @@ func Unstructured(objs *objects.Raw) status.MultiError {
 		return errs
 	}
 
-	// First we annotate all objects with their declared fields. It is crucial
-	// that we do this step before any other hydration so that we capture the
-	// object exactly as it is declared in Git. Then we perform cluster selection
-	// so that we can filter out irrelevant objects before trying to modify them.
+	// First we perform cluster selection so that we can filter out irrelevant
+	// objects before trying to modify them, and substitute the per-cluster
+	// variables in the remaining objects. Then we annotate all objects with
+	// their declared fields. It is crucial that we do this step before any other
+	// hydration so that we capture the object exactly as it is declared in Git
+	// for this cluster.
 	hydrators := []objects.RawVisitor{
+		hydrate.ClusterSelectors,
+		hydrate.Variables(objs),
 		hydrate.DeclaredFields,
 		hydrate.DeclaredVersion,
-		hydrate.ClusterSelectors,
 		hydrate.ClusterName,
 		hydrate.Filepath,
 		hydrate.PreventDeletion,
@@ -0,0 +1,566 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/reconcilermanager"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// overrideContainers are the containers of a reconciler pod which the
+// environment variables can be added to.
+var overrideContainers = sets.NewString(
+	reconcilermanager.Reconciler,
+	reconcilermanager.GitSync,
+	reconcilermanager.HydrationController,
+	reconcilermanager.OciSync,
+	reconcilermanager.HelmSync,
+)
+
+// reservedEnvPrefixes are the prefixes of the environment variables which
+// configure the sync containers. Config Sync sets some of them only for some
+// auth types, so none of them can be overridden.
+var reservedEnvPrefixes = []string{"GIT_SYNC_", "HELM_SYNC_", "OCI_SYNC_"}
+
+// reservedEnvNames are the environment variables which Config Sync may set in
+// any container of a reconciler pod, including the ones it only sets for some
+// RootSyncs/RepoSyncs.
+var reservedEnvNames = sets.NewString(
+	reconcilermanager.ClusterNameKey,
+	reconcilermanager.ClusterLabelsKey,
+	reconcilermanager.ClusterLabelsSourceKey,
+	reconcilermanager.ClusterValuesKey,
+	reconcilermanager.ScopeKey,
+	reconcilermanager.SyncNameKey,
+	reconcilermanager.ReconcilerNameKey,
+	reconcilermanager.NamespaceNameKey,
+	reconcilermanager.SyncDirKey,
+	reconcilermanager.ReconcileTimeout,
+	reconcilermanager.DryRun,
+	reconcilermanager.AuditOnly,
+	reconcilermanager.DeletionBudgets,
+	reconcilermanager.SyncWindows,
+	reconcilermanager.Suspend,
+	reconcilermanager.StatusMode,
+	reconcilermanager.SourceTypeKey,
+	reconcilermanager.SourceRepoKey,
+	reconcilermanager.SourceBranchKey,
+	reconcilermanager.SourceRevKey,
+	reconcilermanager.GitVerificationKeysDir,
+	reconcilermanager.ReconcilerPollingPeriod,
+	reconcilermanager.HydrationPollingPeriod,
+	reconcilermanager.HelmRepo,
+	reconcilermanager.HelmChart,
+	reconcilermanager.HelmChartVersion,
+	reconcilermanager.HelmReleaseName,
+	reconcilermanager.HelmReleaseNamespace,
+	reconcilermanager.HelmAuthType,
+	reconcilermanager.HelmValuesYAML,
+	reconcilermanager.HelmValuesFiles,
+	reconcilermanager.HelmIncludeCRDs,
+	// Set for the gcenode and gcpserviceaccount auth types.
+	"GOOGLE_APPLICATION_CREDENTIALS",
+	"GSA_EMAIL",
+)
+
+// reservedContainerEnvNames are the environment variables which Config Sync
+// may set in a single container of a reconciler pod.
+var reservedContainerEnvNames = map[string]sets.String{
+	// Set from the Secret of the RootSync/RepoSync if it has an https_proxy key.
+	reconcilermanager.GitSync: sets.NewString("HTTPS_PROXY"),
+}
+
+// tenantAllowedEnvNames are the only environment variables which a RepoSync
+// can set. They tune the Go runtime of the containers. Any other variable may
+// redirect the traffic or the TLS trust of the reconciler pod, which runs in
+// the config-management-system namespace with the credentials of its
+// ServiceAccount, or alter the programs it runs.
+var tenantAllowedEnvNames = sets.NewString("GOGC", "GOMAXPROCS", "GOMEMLIMIT", "TZ")
+
+// tenantReservedAnnotationPrefixes are the prefixes of the pod annotations
+// which configure the AppArmor and seccomp profiles of the containers, and
+// which a RepoSync can not set.
+var tenantReservedAnnotationPrefixes = []string{
+	corev1.AppArmorBetaContainerAnnotationKeyPrefix,
+	corev1.SeccompContainerAnnotationKeyPrefix,
+	corev1.SeccompPodAnnotationKey,
+}
+
+// systemPriorityClassPrefix is the prefix of the PriorityClasses reserved for
+// the critical system components, which a RepoSync can not use.
+const systemPriorityClassPrefix = "system-"
+
+// PodTemplateOverride validates the pod template override of a
+// RootSync/RepoSync. The reconciler-manager merges the override into the
+// reconciler Deployment, so it must be rejected before the Deployment is
+// updated rather than by the API server. A RepoSync is written by the tenant of
+// a namespace, while its reconciler pod runs in the config-management-system
+// namespace, so its override is further restricted by validateTenantOverride.
+func PodTemplateOverride(override *v1beta1.PodTemplateOverride, rs client.Object) status.Error {
+	if override == nil {
+		return nil
+	}
+	fldPath := field.NewPath("spec", "override", "podTemplate")
+	var errs field.ErrorList
+	errs = append(errs, validateOverrideMetadata(override.Labels, true, fldPath.Child("labels"))...)
+	errs = append(errs, validateOverrideMetadata(override.Annotations, false, fldPath.Child("annotations"))...)
+	errs = append(errs, validateNodeSelector(override.NodeSelector, fldPath.Child("nodeSelector"))...)
+	errs = append(errs, validateTolerations(override.Tolerations, fldPath.Child("tolerations"))...)
+	errs = append(errs, validateAffinity(override.Affinity, fldPath.Child("affinity"))...)
+	if override.PriorityClassName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(override.PriorityClassName) {
+			errs = append(errs, field.Invalid(fldPath.Child("priorityClassName"), override.PriorityClassName, msg))
+		}
+	}
+	errs = append(errs, validatePodSecurityContext(override.SecurityContext, fldPath.Child("securityContext"))...)
+	// The reconciler pods of RepoSyncs run in the config-management-system
+	// namespace, where their Secret and ConfigMap references would resolve.
+	_, isRepoSync := rs.(*v1beta1.RepoSync)
+	errs = append(errs, validateContainerEnvs(override.Env, !isRepoSync, fldPath.Child("env"))...)
+	if isRepoSync {
+		errs = append(errs, validateTenantOverride(override, fldPath)...)
+	}
+	if len(errs) > 0 {
+		return InvalidPodTemplateOverride(rs, errs.ToAggregate())
+	}
+	return nil
+}
+
+// validateTenantOverride restricts the pod template override of a RepoSync to
+// the settings which can not let its tenant redirect the traffic of the
+// reconciler pod, raise its privileges, schedule it onto dedicated nodes, or
+// preempt the critical system pods. The settings which are not allow-listed
+// are rejected, rather than the ones known to be harmful.
+func validateTenantOverride(override *v1beta1.PodTemplateOverride, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for k := range override.Annotations {
+		if hasAnyPrefix(k, tenantReservedAnnotationPrefixes) {
+			errs = append(errs, field.Forbidden(fldPath.Child("annotations").Key(k), "RepoSyncs can not set the AppArmor or seccomp profiles"))
+		}
+	}
+	if strings.HasPrefix(override.PriorityClassName, systemPriorityClassPrefix) {
+		errs = append(errs, field.Forbidden(fldPath.Child("priorityClassName"), "RepoSyncs can not use the system-* PriorityClasses"))
+	}
+	// Without tolerations, nodeSelector and affinity can only pick among the
+	// untainted nodes.
+	if len(override.Tolerations) > 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("tolerations"), "RepoSyncs can not tolerate taints, which would schedule the reconciler onto dedicated nodes"))
+	}
+	errs = append(errs, validateTenantSecurityContext(override.SecurityContext, fldPath.Child("securityContext"))...)
+	for i, spec := range override.Env {
+		for j, env := range spec.Env {
+			if !tenantAllowedEnvNames.Has(env.Name) {
+				errs = append(errs, field.NotSupported(fldPath.Child("env").Index(i).Child("env").Index(j).Child("name"),
+					env.Name, tenantAllowedEnvNames.List()))
+			}
+		}
+	}
+	return errs
+}
+
+// validateTenantSecurityContext restricts the security context of the
+// reconciler pod of a RepoSync to a non-root user and groups, and to the
+// default seccomp profile.
+func validateTenantSecurityContext(sc *corev1.PodSecurityContext, fldPath *field.Path) field.ErrorList {
+	if sc == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("runAsUser"), "RepoSyncs can not run the reconciler as root"))
+	}
+	if sc.RunAsGroup != nil && *sc.RunAsGroup == 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("runAsGroup"), "RepoSyncs can not run the reconciler as root"))
+	}
+	if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+		errs = append(errs, field.Forbidden(fldPath.Child("runAsNonRoot"), "RepoSyncs can not run the reconciler as root"))
+	}
+	if sc.FSGroup != nil && *sc.FSGroup == 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("fsGroup"), "RepoSyncs can not run the reconciler as root"))
+	}
+	for i, gid := range sc.SupplementalGroups {
+		if gid == 0 {
+			errs = append(errs, field.Forbidden(fldPath.Child("supplementalGroups").Index(i), "RepoSyncs can not run the reconciler as root"))
+		}
+	}
+	if sc.SeccompProfile != nil && sc.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		errs = append(errs, field.NotSupported(fldPath.Child("seccompProfile", "type"), sc.SeccompProfile.Type,
+			[]string{string(corev1.SeccompProfileTypeRuntimeDefault)}))
+	}
+	if sc.SELinuxOptions != nil {
+		errs = append(errs, field.Forbidden(fldPath.Child("seLinuxOptions"), "RepoSyncs can not set the SELinux context"))
+	}
+	if sc.WindowsOptions != nil {
+		errs = append(errs, field.Forbidden(fldPath.Child("windowsOptions"), "RepoSyncs can not set the Windows options"))
+	}
+	if len(sc.Sysctls) > 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("sysctls"), "RepoSyncs can not set sysctls"))
+	}
+	return errs
+}
+
+// hasAnyPrefix returns whether s starts with any of the prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOverrideMetadata validates the labels or annotations added to the
+// reconciler pod, which must not use the Config Sync prefixes.
+func validateOverrideMetadata(m map[string]string, isLabels bool, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for k, v := range m {
+		if metadata.HasConfigSyncPrefix(k) {
+			errs = append(errs, field.Forbidden(fldPath.Key(k), "the Config Sync prefixes are reserved"))
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(k) {
+			errs = append(errs, field.Invalid(fldPath, k, msg))
+		}
+		if isLabels {
+			for _, msg := range validation.IsValidLabelValue(v) {
+				errs = append(errs, field.Invalid(fldPath.Key(k), v, msg))
+			}
+		}
+	}
+	return errs
+}
+
+func validateNodeSelector(selector map[string]string, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for k, v := range selector {
+		for _, msg := range validation.IsQualifiedName(k) {
+			errs = append(errs, field.Invalid(fldPath, k, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(v) {
+			errs = append(errs, field.Invalid(fldPath.Key(k), v, msg))
+		}
+	}
+	return errs
+}
+
+func validateTolerations(tolerations []corev1.Toleration, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, t := range tolerations {
+		idxPath := fldPath.Index(i)
+		if t.Key != "" {
+			for _, msg := range validation.IsQualifiedName(t.Key) {
+				errs = append(errs, field.Invalid(idxPath.Child("key"), t.Key, msg))
+			}
+		}
+		switch t.Operator {
+		case corev1.TolerationOpEqual, "":
+			if t.Key == "" {
+				errs = append(errs, field.Invalid(idxPath.Child("operator"), t.Operator, "operator must be Exists when key is empty"))
+			}
+			for _, msg := range validation.IsValidLabelValue(t.Value) {
+				errs = append(errs, field.Invalid(idxPath.Child("value"), t.Value, msg))
+			}
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				errs = append(errs, field.Invalid(idxPath.Child("value"), t.Value, "value must be empty when operator is Exists"))
+			}
+		default:
+			errs = append(errs, field.NotSupported(idxPath.Child("operator"), t.Operator,
+				[]string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+		switch t.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, field.NotSupported(idxPath.Child("effect"), t.Effect,
+				[]string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}))
+		}
+		if t.TolerationSeconds != nil && t.Effect != corev1.TaintEffectNoExecute {
+			errs = append(errs, field.Invalid(idxPath.Child("effect"), t.Effect, "effect must be NoExecute when tolerationSeconds is set"))
+		}
+	}
+	return errs
+}
+
+func validateAffinity(affinity *corev1.Affinity, fldPath *field.Path) field.ErrorList {
+	if affinity == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	if na := affinity.NodeAffinity; na != nil {
+		naPath := fldPath.Child("nodeAffinity")
+		if required := na.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			reqPath := naPath.Child("requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+			if len(required.NodeSelectorTerms) == 0 {
+				errs = append(errs, field.Required(reqPath, "must have at least one node selector term"))
+			}
+			for i, term := range required.NodeSelectorTerms {
+				errs = append(errs, validateNodeSelectorTerm(term, reqPath.Index(i))...)
+			}
+		}
+		for i, term := range na.PreferredDuringSchedulingIgnoredDuringExecution {
+			idxPath := naPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+			errs = append(errs, validateWeight(term.Weight, idxPath.Child("weight"))...)
+			errs = append(errs, validateNodeSelectorTerm(term.Preference, idxPath.Child("preference"))...)
+		}
+	}
+	if pa := affinity.PodAffinity; pa != nil {
+		errs = append(errs, validatePodAffinityTerms(pa.RequiredDuringSchedulingIgnoredDuringExecution, pa.PreferredDuringSchedulingIgnoredDuringExecution, fldPath.Child("podAffinity"))...)
+	}
+	if paa := affinity.PodAntiAffinity; paa != nil {
+		errs = append(errs, validatePodAffinityTerms(paa.RequiredDuringSchedulingIgnoredDuringExecution, paa.PreferredDuringSchedulingIgnoredDuringExecution, fldPath.Child("podAntiAffinity"))...)
+	}
+	return errs
+}
+
+func validateNodeSelectorTerm(term corev1.NodeSelectorTerm, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, req := range term.MatchExpressions {
+		idxPath := fldPath.Child("matchExpressions").Index(i)
+		for _, msg := range validation.IsQualifiedName(req.Key) {
+			errs = append(errs, field.Invalid(idxPath.Child("key"), req.Key, msg))
+		}
+		errs = append(errs, validateNodeSelectorRequirement(req, idxPath)...)
+	}
+	for i, req := range term.MatchFields {
+		errs = append(errs, validateNodeSelectorRequirement(req, fldPath.Child("matchFields").Index(i))...)
+	}
+	return errs
+}
+
+func validateNodeSelectorRequirement(req corev1.NodeSelectorRequirement, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn, corev1.NodeSelectorOpNotIn:
+		if len(req.Values) == 0 {
+			errs = append(errs, field.Required(fldPath.Child("values"), "must be specified when operator is In or NotIn"))
+		}
+	case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+		if len(req.Values) > 0 {
+			errs = append(errs, field.Forbidden(fldPath.Child("values"), "may not be specified when operator is Exists or DoesNotExist"))
+		}
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if len(req.Values) != 1 {
+			errs = append(errs, field.Required(fldPath.Child("values"), "must be specified single value when operator is Gt or Lt"))
+		} else if _, err := strconv.ParseInt(req.Values[0], 10, 64); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("values"), req.Values[0], "must be an integer when operator is Gt or Lt"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("operator"), req.Operator,
+			[]string{string(corev1.NodeSelectorOpIn), string(corev1.NodeSelectorOpNotIn), string(corev1.NodeSelectorOpExists),
+				string(corev1.NodeSelectorOpDoesNotExist), string(corev1.NodeSelectorOpGt), string(corev1.NodeSelectorOpLt)}))
+	}
+	return errs
+}
+
+func validatePodAffinityTerms(required []corev1.PodAffinityTerm, preferred []corev1.WeightedPodAffinityTerm, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, term := range required {
+		errs = append(errs, validatePodAffinityTerm(term, fldPath.Child("requiredDuringSchedulingIgnoredDuringExecution").Index(i))...)
+	}
+	for i, term := range preferred {
+		idxPath := fldPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+		errs = append(errs, validateWeight(term.Weight, idxPath.Child("weight"))...)
+		errs = append(errs, validatePodAffinityTerm(term.PodAffinityTerm, idxPath.Child("podAffinityTerm"))...)
+	}
+	return errs
+}
+
+func validatePodAffinityTerm(term corev1.PodAffinityTerm, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if term.TopologyKey == "" {
+		errs = append(errs, field.Required(fldPath.Child("topologyKey"), "can not be empty"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(term.TopologyKey) {
+			errs = append(errs, field.Invalid(fldPath.Child("topologyKey"), term.TopologyKey, msg))
+		}
+	}
+	if _, err := metav1.LabelSelectorAsSelector(term.LabelSelector); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("labelSelector"), term.LabelSelector, err.Error()))
+	}
+	if _, err := metav1.LabelSelectorAsSelector(term.NamespaceSelector); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("namespaceSelector"), term.NamespaceSelector, err.Error()))
+	}
+	return errs
+}
+
+func validateWeight(weight int32, fldPath *field.Path) field.ErrorList {
+	if weight < 1 || weight > 100 {
+		return field.ErrorList{field.Invalid(fldPath, weight, "must be in the range 1-100")}
+	}
+	return nil
+}
+
+func validatePodSecurityContext(sc *corev1.PodSecurityContext, fldPath *field.Path) field.ErrorList {
+	if sc == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	if sc.RunAsUser != nil {
+		for _, msg := range validation.IsValidUserID(*sc.RunAsUser) {
+			errs = append(errs, field.Invalid(fldPath.Child("runAsUser"), *sc.RunAsUser, msg))
+		}
+	}
+	if sc.RunAsGroup != nil {
+		for _, msg := range validation.IsValidGroupID(*sc.RunAsGroup) {
+			errs = append(errs, field.Invalid(fldPath.Child("runAsGroup"), *sc.RunAsGroup, msg))
+		}
+	}
+	if sc.FSGroup != nil {
+		for _, msg := range validation.IsValidGroupID(*sc.FSGroup) {
+			errs = append(errs, field.Invalid(fldPath.Child("fsGroup"), *sc.FSGroup, msg))
+		}
+	}
+	for i, gid := range sc.SupplementalGroups {
+		for _, msg := range validation.IsValidGroupID(gid) {
+			errs = append(errs, field.Invalid(fldPath.Child("supplementalGroups").Index(i), gid, msg))
+		}
+	}
+	if sc.FSGroupChangePolicy != nil {
+		switch *sc.FSGroupChangePolicy {
+		case corev1.FSGroupChangeOnRootMismatch, corev1.FSGroupChangeAlways:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("fsGroupChangePolicy"), *sc.FSGroupChangePolicy,
+				[]string{string(corev1.FSGroupChangeOnRootMismatch), string(corev1.FSGroupChangeAlways)}))
+		}
+	}
+	if sp := sc.SeccompProfile; sp != nil {
+		switch sp.Type {
+		case corev1.SeccompProfileTypeLocalhost:
+			if sp.LocalhostProfile == nil || *sp.LocalhostProfile == "" {
+				errs = append(errs, field.Required(fldPath.Child("seccompProfile", "localhostProfile"), "must be set when seccomp type is Localhost"))
+			}
+		case corev1.SeccompProfileTypeRuntimeDefault, corev1.SeccompProfileTypeUnconfined:
+			if sp.LocalhostProfile != nil {
+				errs = append(errs, field.Invalid(fldPath.Child("seccompProfile", "localhostProfile"), *sp.LocalhostProfile, "can only be set when seccomp type is Localhost"))
+			}
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("seccompProfile", "type"), sp.Type,
+				[]string{string(corev1.SeccompProfileTypeLocalhost), string(corev1.SeccompProfileTypeRuntimeDefault), string(corev1.SeccompProfileTypeUnconfined)}))
+		}
+	}
+	for i, s := range sc.Sysctls {
+		if s.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("sysctls").Index(i).Child("name"), ""))
+		}
+	}
+	return errs
+}
+
+func validateContainerEnvs(envs []v1beta1.ContainerEnvSpec, allowObjectRefs bool, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	containers := sets.NewString()
+	for i, spec := range envs {
+		idxPath := fldPath.Index(i)
+		switch {
+		case !overrideContainers.Has(spec.ContainerName):
+			errs = append(errs, field.NotSupported(idxPath.Child("containerName"), spec.ContainerName, overrideContainers.List()))
+		case containers.Has(spec.ContainerName):
+			errs = append(errs, field.Duplicate(idxPath.Child("containerName"), spec.ContainerName))
+		}
+		containers.Insert(spec.ContainerName)
+
+		names := sets.NewString()
+		for j, env := range spec.Env {
+			envPath := idxPath.Child("env").Index(j)
+			for _, msg := range validation.IsEnvVarName(env.Name) {
+				errs = append(errs, field.Invalid(envPath.Child("name"), env.Name, msg))
+			}
+			if isReservedEnvName(spec.ContainerName, env.Name) {
+				errs = append(errs, field.Forbidden(envPath.Child("name"), "the environment variable may be set by Config Sync"))
+			}
+			if names.Has(env.Name) {
+				errs = append(errs, field.Duplicate(envPath.Child("name"), env.Name))
+			}
+			names.Insert(env.Name)
+			errs = append(errs, validateEnvVarSource(env, allowObjectRefs, envPath)...)
+		}
+	}
+	return errs
+}
+
+// isReservedEnvName returns whether Config Sync may set the environment
+// variable in the container.
+func isReservedEnvName(container, name string) bool {
+	if reservedEnvNames.Has(name) || reservedContainerEnvNames[container].Has(name) {
+		return true
+	}
+	return hasAnyPrefix(name, reservedEnvPrefixes)
+}
+
+func validateEnvVarSource(env corev1.EnvVar, allowObjectRefs bool, fldPath *field.Path) field.ErrorList {
+	if env.ValueFrom == nil {
+		return nil
+	}
+	if env.Value != "" {
+		return field.ErrorList{field.Invalid(fldPath.Child("valueFrom"), "", "may not be specified when `value` is not empty")}
+	}
+	sources := 0
+	from := env.ValueFrom
+	if from.FieldRef != nil {
+		sources++
+		if from.FieldRef.FieldPath == "" {
+			return field.ErrorList{field.Required(fldPath.Child("valueFrom", "fieldRef", "fieldPath"), "")}
+		}
+	}
+	if from.ResourceFieldRef != nil {
+		sources++
+		if from.ResourceFieldRef.Resource == "" {
+			return field.ErrorList{field.Required(fldPath.Child("valueFrom", "resourceFieldRef", "resource"), "")}
+		}
+	}
+	if from.ConfigMapKeyRef != nil {
+		if !allowObjectRefs {
+			return field.ErrorList{field.Forbidden(fldPath.Child("valueFrom", "configMapKeyRef"), "RepoSyncs can not reference ConfigMaps, which would be read from the namespace of the reconciler")}
+		}
+		sources++
+		if from.ConfigMapKeyRef.Key == "" {
+			return field.ErrorList{field.Required(fldPath.Child("valueFrom", "configMapKeyRef", "key"), "")}
+		}
+	}
+	if from.SecretKeyRef != nil {
+		if !allowObjectRefs {
+			return field.ErrorList{field.Forbidden(fldPath.Child("valueFrom", "secretKeyRef"), "RepoSyncs can not reference Secrets, which would be read from the namespace of the reconciler")}
+		}
+		sources++
+		if from.SecretKeyRef.Key == "" {
+			return field.ErrorList{field.Required(fldPath.Child("valueFrom", "secretKeyRef", "key"), "")}
+		}
+	}
+	if sources != 1 {
+		return field.ErrorList{field.Invalid(fldPath.Child("valueFrom"), "", "must specify exactly one of `fieldRef`, `resourceFieldRef`, `configMapKeyRef` or `secretKeyRef`")}
+	}
+	return nil
+}
+
+// InvalidPodTemplateOverride reports that a RootSync/RepoSync declares an
+// invalid spec.override.podTemplate.
+func InvalidPodTemplateOverride(o client.Object, err error) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Wrap(err).
+		Sprintf("%ss must specify a valid spec.override.podTemplate", kind).
+		BuildWithResources(o)
+}
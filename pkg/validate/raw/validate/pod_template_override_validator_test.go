@@ -0,0 +1,382 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
+	"kpt.dev/configsync/pkg/testing/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPodTemplateOverride(t *testing.T) {
+	testCases := []struct {
+		name     string
+		override *v1beta1.PodTemplateOverride
+		rootSync bool
+		wantErr  bool
+	}{
+		{
+			name: "no override",
+		},
+		{
+			name: "valid override",
+			override: &v1beta1.PodTemplateOverride{
+				Labels:       map[string]string{"team": "platform"},
+				Annotations:  map[string]string{"cluster-autoscaler.kubernetes.io/safe-to-evict": "false"},
+				NodeSelector: map[string]string{"cloud.google.com/gke-nodepool": "system"},
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "system", Effect: corev1.TaintEffectNoSchedule},
+					{Operator: corev1.TolerationOpExists},
+				},
+				Affinity: &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+								MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"}}},
+							}},
+						},
+					},
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+							Weight: 50,
+							PodAffinityTerm: corev1.PodAffinityTerm{
+								TopologyKey:   "kubernetes.io/hostname",
+								LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "reconciler"}},
+							},
+						}},
+					},
+				},
+				PriorityClassName: "system-cluster-critical",
+				SecurityContext:   &corev1.PodSecurityContext{RunAsUser: pointer.Int64(2000)},
+				Env: []v1beta1.ContainerEnvSpec{{
+					ContainerName: "reconciler",
+					Env: []corev1.EnvVar{
+						{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+						{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+					},
+				}},
+			},
+			rootSync: true,
+		},
+		{
+			name: "valid RepoSync override",
+			override: &v1beta1.PodTemplateOverride{
+				Labels:            map[string]string{"team": "bookstore"},
+				NodeSelector:      map[string]string{"cloud.google.com/gke-nodepool": "tenants"},
+				PriorityClassName: "tenant-high",
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsUser:      pointer.Int64(2000),
+					FSGroup:        pointer.Int64(2000),
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				Env: []v1beta1.ContainerEnvSpec{{
+					ContainerName: "reconciler",
+					Env:           []corev1.EnvVar{{Name: "GOMAXPROCS", Value: "2"}},
+				}},
+			},
+		},
+		{
+			name:     "reserved label",
+			override: &v1beta1.PodTemplateOverride{Labels: map[string]string{"configsync.gke.io/reconciler": "other"}},
+			wantErr:  true,
+		},
+		{
+			name:     "reserved annotation",
+			override: &v1beta1.PodTemplateOverride{Annotations: map[string]string{"configmanagement.gke.io/managed": "disabled"}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid label value",
+			override: &v1beta1.PodTemplateOverride{Labels: map[string]string{"team": "not a label value"}},
+			wantErr:  true,
+		},
+		{
+			name:     "toleration with value and operator Exists",
+			override: &v1beta1.PodTemplateOverride{Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists, Value: "system"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "toleration seconds without effect NoExecute",
+			override: &v1beta1.PodTemplateOverride{Tolerations: []corev1.Toleration{{Key: "dedicated", Value: "system", TolerationSeconds: pointer.Int64(30)}}},
+			wantErr:  true,
+		},
+		{
+			name: "pod affinity without topology key",
+			override: &v1beta1.PodTemplateOverride{Affinity: &corev1.Affinity{PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "node affinity with an unsupported operator",
+			override: &v1beta1.PodTemplateOverride{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+					Weight:     1,
+					Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: "Near"}}},
+				}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "invalid priority class name",
+			override: &v1beta1.PodTemplateOverride{PriorityClassName: "Not_Valid"},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid security context",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost}}},
+			wantErr:  true,
+		},
+		{
+			name:     "env of an unknown container",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "otel-agent"}}},
+			wantErr:  true,
+		},
+		{
+			name: "duplicate env",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "git-sync",
+				Env:           []corev1.EnvVar{{Name: "NO_PROXY", Value: "a"}, {Name: "NO_PROXY", Value: "b"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "env with both value and valueFrom",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "reconciler",
+				Env: []corev1.EnvVar{{Name: "TOKEN", Value: "a", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{Key: "token"},
+				}}},
+			}}},
+			rootSync: true,
+			wantErr:  true,
+		},
+		{
+			name: "RootSync referencing a Secret",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "reconciler",
+				Env: []corev1.EnvVar{{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"},
+				}}},
+			}}},
+			rootSync: true,
+		},
+		{
+			name: "RepoSync referencing a Secret in config-management-system",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "git-sync",
+				Env: []corev1.EnvVar{{Name: "PASSWORD", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ns-reconciler-other-git-creds"}, Key: "password"},
+				}}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "RepoSync referencing a ConfigMap",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "reconciler",
+				Env: []corev1.EnvVar{{Name: "SETTING", ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}, Key: "setting"},
+				}}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "git-sync credentials",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "git-sync",
+				Env:           []corev1.EnvVar{{Name: "GIT_SYNC_USERNAME", Value: "alice"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "git-sync variable not set by Config Sync",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "git-sync",
+				Env:           []corev1.EnvVar{{Name: "GIT_SYNC_ASKPASS_URL", Value: "http://askpass.example.com"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "git-sync proxy set by Config Sync from the Secret",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "git-sync",
+				Env:           []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "reconciler variable set conditionally by Config Sync",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{
+				ContainerName: "reconciler",
+				Env:           []corev1.EnvVar{{Name: "SYNC_WINDOWS", Value: "[]"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "RepoSync setting the API server host",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "reconciler", Env: []corev1.EnvVar{{Name: "KUBERNETES_SERVICE_HOST", Value: "attacker.example.com"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting the API server port",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "reconciler", Env: []corev1.EnvVar{{Name: "KUBERNETES_SERVICE_PORT", Value: "8443"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting a proxy",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "reconciler", Env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting a lower case proxy",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "oci-sync", Env: []corev1.EnvVar{{Name: "http_proxy", Value: "http://proxy:3128"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting the proxy exceptions",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "helm-sync", Env: []corev1.EnvVar{{Name: "NO_PROXY", Value: "*"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync preloading a library",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "hydration-controller", Env: []corev1.EnvVar{{Name: "LD_PRELOAD", Value: "/repo/source/rev/evil.so"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting the git configuration",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "git-sync", Env: []corev1.EnvVar{{Name: "GIT_CONFIG_COUNT", Value: "1"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync using a system PriorityClass",
+			override: &v1beta1.PodTemplateOverride{PriorityClassName: "system-cluster-critical"},
+			wantErr:  true,
+		},
+		{
+			name: "RepoSync tolerating the control plane taint",
+			override: &v1beta1.PodTemplateOverride{
+				NodeSelector: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+				Tolerations: []corev1.Toleration{
+					{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "RepoSync tolerating a dedicated taint",
+			override: &v1beta1.PodTemplateOverride{Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "tenants", Effect: corev1.TaintEffectNoSchedule},
+			}},
+			wantErr: true,
+		},
+		{
+			name:     "RepoSync redirecting the TLS trust to a file",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "reconciler", Env: []corev1.EnvVar{{Name: "SSL_CERT_FILE", Value: "/repo/source/rev/ca.pem"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync redirecting the TLS trust to a directory",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "oci-sync", Env: []corev1.EnvVar{{Name: "SSL_CERT_DIR", Value: "/repo/source/rev"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting the Go runtime debug options",
+			override: &v1beta1.PodTemplateOverride{Env: []v1beta1.ContainerEnvSpec{{ContainerName: "reconciler", Env: []corev1.EnvVar{{Name: "GODEBUG", Value: "x509sha1=1"}}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync running with the root file system group",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{FSGroup: pointer.Int64(0)}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync using a localhost seccomp profile",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: pointer.String("permissive.json")}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync tolerating every taint",
+			override: &v1beta1.PodTemplateOverride{Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync running as root",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{RunAsUser: pointer.Int64(0)}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync running as the root group",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{RunAsGroup: pointer.Int64(0)}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync allowing root",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(false)}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting the SELinux context",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{SELinuxOptions: &corev1.SELinuxOptions{Type: "spc_t"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync running unconfined",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting sysctls",
+			override: &v1beta1.PodTemplateOverride{SecurityContext: &corev1.PodSecurityContext{Sysctls: []corev1.Sysctl{{Name: "kernel.shm_rmid_forced", Value: "0"}}}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting an AppArmor annotation",
+			override: &v1beta1.PodTemplateOverride{Annotations: map[string]string{"container.apparmor.security.beta.kubernetes.io/reconciler": "unconfined"}},
+			wantErr:  true,
+		},
+		{
+			name:     "RepoSync setting a seccomp annotation",
+			override: &v1beta1.PodTemplateOverride{Annotations: map[string]string{"seccomp.security.alpha.kubernetes.io/pod": "unconfined"}},
+			wantErr:  true,
+		},
+		{
+			name: "RootSync running as root with a system PriorityClass",
+			override: &v1beta1.PodTemplateOverride{
+				PriorityClassName: "system-cluster-critical",
+				SecurityContext:   &corev1.PodSecurityContext{RunAsUser: pointer.Int64(0)},
+			},
+			rootSync: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var rs client.Object = fake.RepoSyncObjectV1Beta1("bookstore", "repo-sync")
+			if tc.rootSync {
+				rs = fake.RootSyncObjectV1Beta1("root-sync")
+			}
+			err := PodTemplateOverride(tc.override, rs)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got PodTemplateOverride() error %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -45,7 +45,13 @@ func RepoSync(obj ast.FileObject) status.Error {
 	if rs.Spec.SourceType == "" {
 		rs.Spec.SourceType = string(v1beta1.GitSource)
 	}
-	return SourceSpec(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs)
+	if err := SourceSpec(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm, rs); err != nil {
+		return err
+	}
+	if err := SyncWindows(rs.Spec.SyncWindows, rs); err != nil {
+		return err
+	}
+	return PodTemplateOverride(rs.Spec.Override.PodTemplate, rs)
 }
 
 func toRepoSyncV1Beta1(rs *v1alpha1.RepoSync) (*v1beta1.RepoSync, status.Error) {
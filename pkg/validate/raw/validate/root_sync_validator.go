@@ -20,6 +20,7 @@ import (
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
 
@@ -45,7 +46,36 @@ func RootSync(obj ast.FileObject) status.Error {
 	if rs.Spec.SourceType == "" {
 		rs.Spec.SourceType = string(v1beta1.GitSource)
 	}
-	return SourceSpec(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs)
+	if err := SourceSpec(rs.Spec.SourceType, rs.Spec.Git, rs.Spec.Oci, rs.Spec.Helm, rs); err != nil {
+		return err
+	}
+	if err := SyncWindows(rs.Spec.SyncWindows, rs); err != nil {
+		return err
+	}
+	if err := PodTemplateOverride(rs.Spec.Override.PodTemplate, rs); err != nil {
+		return err
+	}
+	return RoleRefs(rs.Spec.RoleRefs, rs)
+}
+
+// RoleRefs verifies that the roles a RootSync binds to its reconciler are
+// fully specified.
+func RoleRefs(refs []v1beta1.RootSyncRoleRef, rs client.Object) status.Error {
+	for _, ref := range refs {
+		if ref.Name == "" {
+			return MissingRoleRefName(rs)
+		}
+		switch ref.Kind {
+		case kinds.Role().Kind:
+			if ref.Namespace == "" {
+				return MissingRoleRefNamespace(rs, ref.Name)
+			}
+		case kinds.ClusterRole().Kind:
+		default:
+			return InvalidRoleRefKind(rs, ref.Kind)
+		}
+	}
+	return nil
 }
 
 func toRootSyncV1Beta1(rs *v1alpha1.RootSync) (*v1beta1.RootSync, status.Error) {
@@ -15,11 +15,13 @@
 package validate
 
 import (
+	"path"
 	"strings"
 
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/api/configsync/v1beta1"
 	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/syncwindow"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -28,7 +30,7 @@ import (
 const gcpSASuffix = ".iam.gserviceaccount.com"
 
 // SourceSpec validates the source specification for any obvious problems.
-func SourceSpec(sourceType string, git *v1beta1.Git, oci *v1beta1.Oci, rs client.Object) status.Error {
+func SourceSpec(sourceType string, git *v1beta1.Git, oci *v1beta1.Oci, helm *v1beta1.Helm, rs client.Object) status.Error {
 	switch v1beta1.SourceType(sourceType) {
 	case v1beta1.GitSource:
 		if oci != nil {
@@ -40,6 +42,8 @@ func SourceSpec(sourceType string, git *v1beta1.Git, oci *v1beta1.Oci, rs client
 			return RedundantGitSpec(rs)
 		}
 		return OciSpec(oci, rs)
+	case v1beta1.HelmSource:
+		return HelmSpec(helm, rs)
 	default:
 		return InvalidSourceType(rs)
 	}
@@ -89,6 +93,10 @@ func GitSpec(git *v1beta1.Git, rs client.Object) status.Error {
 		}
 	}
 
+	if git.Verification != nil && git.Verification.SecretRef.Name == "" {
+		return MissingGitVerificationSecretRef(rs)
+	}
+
 	return nil
 }
 
@@ -108,6 +116,10 @@ func OciSpec(oci *v1beta1.Oci, rs client.Object) status.Error {
 	// will fail to apply.
 	switch oci.Auth {
 	case configsync.AuthGCENode, configsync.AuthNone:
+	case configsync.AuthToken, configsync.AuthDockerConfigJSON:
+		if oci.SecretRef.Name == "" {
+			return MissingOciSecretRef(rs)
+		}
 	case configsync.AuthGCPServiceAccount:
 		if oci.GCPServiceAccountEmail == "" {
 			return MissingGCPSAEmail(rs)
@@ -118,6 +130,65 @@ func OciSpec(oci *v1beta1.Oci, rs client.Object) status.Error {
 	default:
 		return InvalidOciAuthType(rs)
 	}
+
+	if v := oci.Verification; v != nil {
+		hasSecret := v.SecretRef != nil && v.SecretRef.Name != ""
+		hasConfigMap := v.ConfigMapRef != nil && v.ConfigMapRef.Name != ""
+		if hasSecret == hasConfigMap {
+			return InvalidOciVerification(rs)
+		}
+	}
+	return nil
+}
+
+// HelmSpec validates the Helm specification for any obvious problems.
+func HelmSpec(helm *v1beta1.Helm, rs client.Object) status.Error {
+	if helm == nil {
+		return MissingHelmSpec(rs)
+	}
+
+	// We can't locate the chart if we don't have the repo URL and chart name.
+	if helm.Repo == "" {
+		return MissingHelmRepo(rs)
+	}
+	if helm.Chart == "" {
+		return MissingHelmChart(rs)
+	}
+
+	// Ensure auth is a valid value.
+	// Note that Auth is a case-sensitive field, so ones with arbitrary capitalization
+	// will fail to apply.
+	switch helm.Auth {
+	case configsync.AuthGCENode, configsync.AuthNone:
+	case configsync.AuthToken:
+		if helm.SecretRef.Name == "" {
+			return MissingHelmSecretRef(rs)
+		}
+	case configsync.AuthGCPServiceAccount:
+		if helm.GCPServiceAccountEmail == "" {
+			return MissingGCPSAEmail(rs)
+		}
+		if !validGCPServiceAccountEmail(helm.GCPServiceAccountEmail) {
+			return InvalidGCPSAEmail(rs)
+		}
+	default:
+		return InvalidHelmAuthType(rs)
+	}
+
+	// Values files are read from inside the chart, so they must not escape it.
+	for _, f := range helm.ValuesFiles {
+		if f == "" || strings.HasPrefix(path.Clean(strings.TrimPrefix(f, "/")), "..") {
+			return InvalidHelmValuesFile(rs, f)
+		}
+	}
+	return nil
+}
+
+// SyncWindows validates the sync windows of a RootSync/RepoSync.
+func SyncWindows(windows []v1beta1.SyncWindow, rs client.Object) status.Error {
+	if err := syncwindow.Validate(windows); err != nil {
+		return InvalidSyncWindow(rs, err)
+	}
 	return nil
 }
 
@@ -227,7 +298,7 @@ func validGCPServiceAccountEmail(email string) bool {
 func InvalidSourceType(o client.Object) status.Error {
 	kind := o.GetObjectKind().GroupVersionKind().Kind
 	return invalidSyncBuilder.
-		Sprintf("%ss must specify spec.sourceType to be either %q or %q", kind, v1beta1.GitSource, v1beta1.OciSource).
+		Sprintf("%ss must specify spec.sourceType to be one of %q, %q or %q", kind, v1beta1.GitSource, v1beta1.OciSource, v1beta1.HelmSource).
 		BuildWithResources(o)
 }
 
@@ -252,7 +323,8 @@ func MissingOciImage(o client.Object) status.Error {
 // InvalidOciAuthType reports that a RootSync/RepoSync doesn't use one of the known auth
 // methods for OCI image.
 func InvalidOciAuthType(o client.Object) status.Error {
-	types := []string{string(configsync.AuthGCENode), string(configsync.AuthGCPServiceAccount), string(configsync.AuthNone)}
+	types := []string{string(configsync.AuthGCENode), string(configsync.AuthGCPServiceAccount), string(configsync.AuthToken),
+		string(configsync.AuthDockerConfigJSON), string(configsync.AuthNone)}
 	kind := o.GetObjectKind().GroupVersionKind().Kind
 	return invalidSyncBuilder.
 		Sprintf("%ss must specify spec.oci.auth to be one of %s", kind,
@@ -260,6 +332,35 @@ func InvalidOciAuthType(o client.Object) status.Error {
 		BuildWithResources(o)
 }
 
+// MissingOciSecretRef reports that a RootSync/RepoSync declares an OCI auth
+// mode that requires a SecretRef, but does not do so.
+func MissingOciSecretRef(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss which specify spec.oci.auth as %q or %q must also specify spec.oci.secretRef",
+			kind, configsync.AuthToken, configsync.AuthDockerConfigJSON).
+		BuildWithResources(o)
+}
+
+// InvalidOciVerification reports that a RootSync/RepoSync doesn't reference
+// exactly one Secret or ConfigMap holding the public keys to verify the OCI
+// image with.
+func InvalidOciVerification(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify exactly one of spec.oci.verification.secretRef.name or spec.oci.verification.configMapRef.name when spec.oci.verification is set", kind).
+		BuildWithResources(o)
+}
+
+// MissingGitVerificationSecretRef reports that a RootSync/RepoSync doesn't
+// reference the Secret holding the keys to verify the git commits with.
+func MissingGitVerificationSecretRef(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.git.verification.secretRef.name when spec.git.verification is set", kind).
+		BuildWithResources(o)
+}
+
 // RedundantGitSpec reports that a RootSync/RepoSync declares the Git spec
 // when spec.sourceType is set to `oci`.
 func RedundantGitSpec(o client.Object) status.Error {
@@ -277,3 +378,97 @@ func RedundantOciSpec(o client.Object) status.Error {
 		Sprintf("%ss must not specify spec.oci when spec.sourceType is %q", kind, v1beta1.GitSource).
 		BuildWithResources(o)
 }
+
+// MissingHelmSpec reports that a RootSync/RepoSync doesn't declare the Helm spec
+// when spec.sourceType is set to `helm`.
+func MissingHelmSpec(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.helm when spec.sourceType is %q", kind, v1beta1.HelmSource).
+		BuildWithResources(o)
+}
+
+// MissingHelmRepo reports that a RootSync/RepoSync doesn't declare the Helm
+// repository it is supposed to connect to.
+func MissingHelmRepo(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.helm.repo when spec.sourceType is %q", kind, v1beta1.HelmSource).
+		BuildWithResources(o)
+}
+
+// MissingHelmChart reports that a RootSync/RepoSync doesn't declare the Helm
+// chart it is supposed to render.
+func MissingHelmChart(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.helm.chart when spec.sourceType is %q", kind, v1beta1.HelmSource).
+		BuildWithResources(o)
+}
+
+// InvalidHelmAuthType reports that a RootSync/RepoSync doesn't use one of the
+// known auth methods for Helm repositories.
+func InvalidHelmAuthType(o client.Object) status.Error {
+	types := []string{string(configsync.AuthGCENode), string(configsync.AuthToken), string(configsync.AuthGCPServiceAccount), string(configsync.AuthNone)}
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.helm.auth to be one of %s", kind,
+			strings.Join(types, ",")).
+		BuildWithResources(o)
+}
+
+// MissingHelmSecretRef reports that a RootSync/RepoSync declares a Helm auth
+// mode that requires a SecretRef, but does not do so.
+func MissingHelmSecretRef(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss which specify spec.helm.auth as %q must also specify spec.helm.secretRef",
+			kind, configsync.AuthToken).
+		BuildWithResources(o)
+}
+
+// InvalidHelmValuesFile reports that a RootSync/RepoSync declares a values
+// file that does not point inside the Helm chart.
+func InvalidHelmValuesFile(o client.Object, file string) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.helm.valuesFiles as paths inside the Helm chart, got %q", kind, file).
+		BuildWithResources(o)
+}
+
+// MissingRoleRefName reports that a RootSync declares a role reference without
+// the name of the role.
+func MissingRoleRefName(o client.Object) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.roleRefs.name", kind).
+		BuildWithResources(o)
+}
+
+// InvalidRoleRefKind reports that a RootSync declares a role reference which is
+// neither a Role nor a ClusterRole.
+func InvalidRoleRefKind(o client.Object, refKind string) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.roleRefs.kind to be one of Role,ClusterRole, got %q", kind, refKind).
+		BuildWithResources(o)
+}
+
+// MissingRoleRefNamespace reports that a RootSync declares a reference to a
+// Role without the namespace of the Role.
+func MissingRoleRefNamespace(o client.Object, name string) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Sprintf("%ss must specify spec.roleRefs.namespace for the Role %q", kind, name).
+		BuildWithResources(o)
+}
+
+// InvalidSyncWindow reports that a RootSync/RepoSync declares an invalid sync
+// window.
+func InvalidSyncWindow(o client.Object, err error) status.Error {
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	return invalidSyncBuilder.
+		Wrap(err).
+		Sprintf("%ss must specify valid spec.syncWindows", kind).
+		BuildWithResources(o)
+}
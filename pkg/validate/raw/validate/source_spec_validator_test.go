@@ -36,6 +36,24 @@ func ociAuth(authType configsync.AuthType) func(*v1beta1.RepoSync) {
 	}
 }
 
+func ociSecret(secretName string) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Oci.SecretRef.Name = secretName
+	}
+}
+
+func ociVerification(verification *v1beta1.OciVerification) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Oci.Verification = verification
+	}
+}
+
+func gitVerification(secretName string) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Git.Verification = &v1beta1.GitVerification{SecretRef: v1beta1.SecretReference{Name: secretName}}
+	}
+}
+
 func named(name string) func(*v1beta1.RepoSync) {
 	return func(sync *v1beta1.RepoSync) {
 		sync.Name = name
@@ -92,6 +110,41 @@ func repoSyncWithOci(opts ...func(*v1beta1.RepoSync)) *v1beta1.RepoSync {
 	return rs
 }
 
+func helmAuth(authType configsync.AuthType) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Helm.Auth = authType
+	}
+}
+
+func helmSecret(secretName string) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Helm.SecretRef.Name = secretName
+	}
+}
+
+func helmValuesFiles(files ...string) func(*v1beta1.RepoSync) {
+	return func(sync *v1beta1.RepoSync) {
+		sync.Spec.Helm.ValuesFiles = files
+	}
+}
+
+func missingChart(rs *v1beta1.RepoSync) {
+	rs.Spec.Helm.Chart = ""
+}
+
+func repoSyncWithHelm(opts ...func(*v1beta1.RepoSync)) *v1beta1.RepoSync {
+	rs := fake.RepoSyncObjectV1Beta1("test-ns", configsync.RepoSyncName)
+	rs.Spec.SourceType = string(v1beta1.HelmSource)
+	rs.Spec.Helm = &v1beta1.Helm{
+		Repo:  "fake repo",
+		Chart: "fake chart",
+	}
+	for _, opt := range opts {
+		opt(rs)
+	}
+	return rs
+}
+
 func withGit() func(*v1beta1.RepoSync) {
 	return func(sync *v1beta1.RepoSync) {
 		sync.Spec.Git = &v1beta1.Git{}
@@ -176,6 +229,15 @@ func TestValidateGitSpec(t *testing.T) {
 			obj:     repoSyncWithGit(auth(configsync.AuthGCPServiceAccount)),
 			wantErr: fake.Error(InvalidSyncCode),
 		},
+		{
+			name: "valid git verification",
+			obj:  repoSyncWithGit(auth(configsync.AuthNone), gitVerification("maintainers")),
+		},
+		{
+			name:    "missing git verification secret",
+			obj:     repoSyncWithGit(auth(configsync.AuthNone), gitVerification("")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
 		// Validate OCI spec
 		{
 			name: "valid oci",
@@ -196,6 +258,82 @@ func TestValidateGitSpec(t *testing.T) {
 			obj:     repoSyncWithOci(ociAuth(configsync.AuthGCPServiceAccount)),
 			wantErr: fake.Error(InvalidSyncCode),
 		},
+		{
+			name: "valid oci token auth",
+			obj:  repoSyncWithOci(ociAuth(configsync.AuthToken), ociSecret("registry-creds")),
+		},
+		{
+			name: "valid oci dockerconfigjson auth",
+			obj:  repoSyncWithOci(ociAuth(configsync.AuthDockerConfigJSON), ociSecret("registry-creds")),
+		},
+		{
+			name:    "missing secret for oci token auth",
+			obj:     repoSyncWithOci(ociAuth(configsync.AuthToken)),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "missing secret for oci dockerconfigjson auth",
+			obj:     repoSyncWithOci(ociAuth(configsync.AuthDockerConfigJSON)),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name: "valid oci verification with Secret",
+			obj: repoSyncWithOci(ociAuth(configsync.AuthNone), ociVerification(&v1beta1.OciVerification{
+				SecretRef: &v1beta1.SecretReference{Name: "cosign-keys"},
+			})),
+		},
+		{
+			name: "valid oci verification with ConfigMap",
+			obj: repoSyncWithOci(ociAuth(configsync.AuthNone), ociVerification(&v1beta1.OciVerification{
+				ConfigMapRef: &v1beta1.ConfigMapReference{Name: "cosign-keys"},
+			})),
+		},
+		{
+			name:    "oci verification without keys",
+			obj:     repoSyncWithOci(ociAuth(configsync.AuthNone), ociVerification(&v1beta1.OciVerification{})),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name: "oci verification with both Secret and ConfigMap",
+			obj: repoSyncWithOci(ociAuth(configsync.AuthNone), ociVerification(&v1beta1.OciVerification{
+				SecretRef:    &v1beta1.SecretReference{Name: "cosign-keys"},
+				ConfigMapRef: &v1beta1.ConfigMapReference{Name: "cosign-keys"},
+			})),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		// Validate Helm spec
+		{
+			name: "valid helm",
+			obj:  repoSyncWithHelm(helmAuth(configsync.AuthNone)),
+		},
+		{
+			name: "valid helm values files",
+			obj:  repoSyncWithHelm(helmAuth(configsync.AuthNone), helmValuesFiles("values-prod.yaml", "/env/values.yaml")),
+		},
+		{
+			name:    "missing helm chart",
+			obj:     repoSyncWithHelm(helmAuth(configsync.AuthNone), missingChart),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "invalid helm auth type",
+			obj:     repoSyncWithHelm(helmAuth(configsync.AuthSSH)),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name: "valid helm token auth",
+			obj:  repoSyncWithHelm(helmAuth(configsync.AuthToken), helmSecret("helm-creds")),
+		},
+		{
+			name:    "helm token auth missing secret",
+			obj:     repoSyncWithHelm(helmAuth(configsync.AuthToken)),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
+		{
+			name:    "helm values file outside the chart",
+			obj:     repoSyncWithHelm(helmAuth(configsync.AuthNone), helmValuesFiles("../values.yaml")),
+			wantErr: fake.Error(InvalidSyncCode),
+		},
 		{
 			name:    "invalid source type",
 			obj:     fake.RepoSyncObjectV1Beta1("test-ns", configsync.RepoSyncName, fake.WithRepoSyncSourceType("invalid")),
@@ -215,7 +353,7 @@ func TestValidateGitSpec(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := SourceSpec(tc.obj.Spec.SourceType, tc.obj.Spec.Git, tc.obj.Spec.Oci, tc.obj)
+			err := SourceSpec(tc.obj.Spec.SourceType, tc.obj.Spec.Git, tc.obj.Spec.Oci, tc.obj.Spec.Helm, tc.obj)
 			if !errors.Is(err, tc.wantErr) {
 				t.Errorf("Got SourceSpec() error %v, want %v", err, tc.wantErr)
 			}
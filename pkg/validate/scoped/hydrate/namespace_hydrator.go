@@ -15,6 +15,8 @@
 package hydrate
 
 import (
+	"sort"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
@@ -61,6 +63,8 @@ func NamespaceSelectors(objs *objects.Scoped) status.MultiError {
 
 // buildSelectorMap processes the given cluster-scoped objects to return a map
 // of NamespaceSelector names to the namespaces that are selected by each one.
+// NamespaceSelectors in dynamic mode also select the live Namespaces on the
+// cluster, and their selection is reported back to the LiveNamespaces.
 // Note that this modifies the Scoped objects to filter out the
 // NamespaceSelectors since they are no longer needed after this point.
 func buildSelectorMap(objs *objects.Scoped) (map[string][]string, status.MultiError) {
@@ -78,18 +82,33 @@ func buildSelectorMap(objs *objects.Scoped) (map[string][]string, status.MultiEr
 
 	var errs status.MultiError
 	selectorMap := make(map[string][]string)
+	var selection []objects.NamespaceSelection
 
 	for _, obj := range nsSelectors {
 		var selected []string
-		selector, err := labelSelector(obj)
+		selector, mode, err := labelSelector(obj)
 		if err != nil {
 			errs = status.Append(errs, err)
 			continue
 		}
 
-		for _, namespace := range namespaces {
-			if selector.Matches(labels.Set(namespace.GetLabels())) {
-				selected = append(selected, namespace.GetName())
+		if mode == v1.NSSelectorDynamicMode {
+			all, err := allNamespaces(namespaces, objs.LiveNamespaces)
+			if err != nil {
+				errs = status.Append(errs, err)
+				continue
+			}
+			selected = selectNamespaces(selector, all)
+			selection = append(selection, objects.NamespaceSelection{
+				Name:       obj.GetName(),
+				Selector:   selector,
+				Namespaces: selected,
+			})
+		} else {
+			for _, namespace := range namespaces {
+				if selector.Matches(labels.Set(namespace.GetLabels())) {
+					selected = append(selected, namespace.GetName())
+				}
 			}
 		}
 
@@ -99,27 +118,63 @@ func buildSelectorMap(objs *objects.Scoped) (map[string][]string, status.MultiEr
 	if errs != nil {
 		return nil, errs
 	}
+	if objs.LiveNamespaces != nil {
+		objs.LiveNamespaces.SetSelection(selection)
+	}
 
 	// We are done with NamespaceSelectors so we can filter them out now.
 	objs.Cluster = append(namespaces, others...)
 	return selectorMap, nil
 }
 
-func labelSelector(obj ast.FileObject) (labels.Selector, status.Error) {
+// allNamespaces returns the labels of the live Namespaces and of the declared
+// Namespaces, keyed by name. The declared labels take precedence since they
+// are applied along with the selected objects.
+func allNamespaces(declared []ast.FileObject, live objects.LiveNamespaces) (map[string]labels.Set, status.Error) {
+	result := make(map[string]labels.Set)
+	if live != nil {
+		liveLabels, err := live.Labels()
+		if err != nil {
+			return nil, status.APIServerError(err, "failed to list the Namespaces for the NamespaceSelectors in dynamic mode")
+		}
+		for name, l := range liveLabels {
+			result[name] = l
+		}
+	}
+	for _, namespace := range declared {
+		result[namespace.GetName()] = namespace.GetLabels()
+	}
+	return result, nil
+}
+
+// selectNamespaces returns the sorted names of the Namespaces whose labels
+// match the selector.
+func selectNamespaces(selector labels.Selector, namespaces map[string]labels.Set) []string {
+	var selected []string
+	for name, l := range namespaces {
+		if selector.Matches(l) {
+			selected = append(selected, name)
+		}
+	}
+	sort.Strings(selected)
+	return selected
+}
+
+func labelSelector(obj ast.FileObject) (labels.Selector, v1.NamespaceSelectorMode, status.Error) {
 	s, sErr := obj.Structured()
 	if sErr != nil {
-		return nil, sErr
+		return nil, "", sErr
 	}
 	nss := s.(*v1.NamespaceSelector)
 
 	selector, err := metav1.LabelSelectorAsSelector(&nss.Spec.Selector)
 	if err != nil {
-		return nil, selectors.InvalidSelectorError(obj, err)
+		return nil, "", selectors.InvalidSelectorError(obj, err)
 	}
 	if selector.Empty() {
-		return nil, selectors.EmptySelectorError(obj)
+		return nil, "", selectors.EmptySelectorError(obj)
 	}
-	return selector, nil
+	return selector, nss.Spec.Mode, nil
 }
 
 // makeNamespaceCopies uses the given object's namespace selector to make a copy
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/labels"
 	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
@@ -227,3 +228,111 @@ func TestNamespaceSelectors(t *testing.T) {
 		})
 	}
 }
+
+type fakeLiveNamespaces struct {
+	labels    map[string]labels.Set
+	err       error
+	selection []objects.NamespaceSelection
+}
+
+func (f *fakeLiveNamespaces) Labels() (map[string]labels.Set, error) {
+	return f.labels, f.err
+}
+
+func (f *fakeLiveNamespaces) SetSelection(selection []objects.NamespaceSelection) {
+	f.selection = selection
+}
+
+func TestNamespaceSelectors_Dynamic(t *testing.T) {
+	dynamicNSS := fake.FileObject(fake.NamespaceSelectorObject(core.Name("dev-only"),
+		func(o client.Object) {
+			nss := o.(*v1.NamespaceSelector)
+			nss.Spec.Selector.MatchLabels = map[string]string{"environment": "dev"}
+			nss.Spec.Mode = v1.NSSelectorDynamicMode
+		}), "dev-only-nss.yaml")
+	role := fake.Role(core.Annotation(metadata.NamespaceSelectorAnnotationKey, "dev-only"))
+	roleIn := func(ns string) ast.FileObject {
+		return fake.Role(core.Namespace(ns), core.Annotation(metadata.NamespaceSelectorAnnotationKey, "dev-only"))
+	}
+
+	testCases := []struct {
+		name          string
+		live          *fakeLiveNamespaces
+		wantNamespace []ast.FileObject
+		wantSelected  []string
+	}{
+		{
+			name: "select live and declared Namespaces",
+			live: &fakeLiveNamespaces{labels: map[string]labels.Set{
+				"dev1":  {"environment": "dev"},
+				"dev2":  {"environment": "prod"},
+				"prod1": {"environment": "prod"},
+				"prod2": {"environment": "dev"},
+			}},
+			// The labels of the declared Namespaces take precedence.
+			wantNamespace: []ast.FileObject{roleIn("dev1"), roleIn("dev2"), roleIn("dev3")},
+			wantSelected:  []string{"dev1", "dev2", "dev3"},
+		},
+		{
+			name:          "select declared Namespaces without a cluster",
+			wantNamespace: []ast.FileObject{roleIn("dev2"), roleIn("dev3")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := &objects.Scoped{
+				Cluster: []ast.FileObject{
+					dynamicNSS,
+					fake.Namespace("namespaces/dev2", core.Label("environment", "dev")),
+					fake.Namespace("namespaces/dev3", core.Label("environment", "dev")),
+					fake.Namespace("namespaces/prod2", core.Label("environment", "prod")),
+				},
+				Namespace: []ast.FileObject{role},
+			}
+			if tc.live != nil {
+				objs.LiveNamespaces = tc.live
+			}
+			if errs := NamespaceSelectors(objs); errs != nil {
+				t.Fatalf("Got NamespaceSelectors() error %v, want nil", errs)
+			}
+			if diff := cmp.Diff(tc.wantNamespace, objs.Namespace, ast.CompareFileObject); diff != "" {
+				t.Error(diff)
+			}
+			if tc.live == nil {
+				return
+			}
+			if len(tc.live.selection) != 1 {
+				t.Fatalf("Got selection %v, want one NamespaceSelector", tc.live.selection)
+			}
+			got := tc.live.selection[0]
+			if got.Name != "dev-only" || got.Selector.String() != "environment=dev" {
+				t.Errorf("Got selection of NamespaceSelector %q with selector %q, want %q with %q", got.Name, got.Selector, "dev-only", "environment=dev")
+			}
+			if diff := cmp.Diff(tc.wantSelected, got.Namespaces); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNamespaceSelectors_DynamicListError(t *testing.T) {
+	dynamicNSS := fake.FileObject(fake.NamespaceSelectorObject(core.Name("dev-only"),
+		func(o client.Object) {
+			nss := o.(*v1.NamespaceSelector)
+			nss.Spec.Selector.MatchLabels = map[string]string{"environment": "dev"}
+			nss.Spec.Mode = v1.NSSelectorDynamicMode
+		}), "dev-only-nss.yaml")
+	live := &fakeLiveNamespaces{err: errors.New("namespaces is forbidden")}
+	objs := &objects.Scoped{
+		Cluster:        []ast.FileObject{dynamicNSS},
+		Namespace:      []ast.FileObject{fake.Role(core.Annotation(metadata.NamespaceSelectorAnnotationKey, "dev-only"))},
+		LiveNamespaces: live,
+	}
+	if errs := NamespaceSelectors(objs); errs == nil {
+		t.Error("Got NamespaceSelectors() error nil, want the Namespaces listing error")
+	}
+	if live.selection != nil {
+		t.Errorf("Got selection %v, want none", live.selection)
+	}
+}
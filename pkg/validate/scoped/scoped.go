@@ -30,7 +30,7 @@ func Hierarchical(objs *objects.Scoped) status.MultiError {
 	validators := []objects.ScopedVisitor{
 		objects.VisitClusterScoped(validate.ClusterScoped),
 		objects.VisitNamespaceScoped(validate.NamespaceScoped),
-		validate.NamespaceSelectors,
+		validate.HierarchicalNamespaceSelectors,
 	}
 	for _, validator := range validators {
 		errs = status.Append(errs, validator(objs))
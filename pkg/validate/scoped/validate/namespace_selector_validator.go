@@ -15,6 +15,9 @@
 package validate
 
 import (
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/analyzer/transform/selectors"
 	"kpt.dev/configsync/pkg/importer/analyzer/validation/nonhierarchical"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/status"
@@ -22,8 +25,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// NamespaceSelectors validates that all NamespaceSelectors have a unique name.
+// NamespaceSelectors validates that all NamespaceSelectors have a unique name
+// and a known mode. Namespace reconcilers may not use the dynamic mode.
 func NamespaceSelectors(objs *objects.Scoped) status.MultiError {
+	return namespaceSelectors(objs, !objs.IsNamespaceReconciler)
+}
+
+// HierarchicalNamespaceSelectors validates that all NamespaceSelectors have a
+// unique name and a known mode other than the dynamic mode, which hierarchical
+// repos do not support.
+func HierarchicalNamespaceSelectors(objs *objects.Scoped) status.MultiError {
+	return namespaceSelectors(objs, false)
+}
+
+func namespaceSelectors(objs *objects.Scoped, allowDynamic bool) status.MultiError {
 	var errs status.MultiError
 	gk := kinds.NamespaceSelector().GroupKind()
 	matches := make(map[string][]client.Object)
@@ -31,6 +46,7 @@ func NamespaceSelectors(objs *objects.Scoped) status.MultiError {
 	for _, obj := range objs.Cluster {
 		if obj.GetObjectKind().GroupVersionKind().GroupKind() == gk {
 			matches[obj.GetName()] = append(matches[obj.GetName()], obj)
+			errs = status.Append(errs, namespaceSelectorMode(obj, allowDynamic))
 		}
 	}
 
@@ -42,3 +58,21 @@ func NamespaceSelectors(objs *objects.Scoped) status.MultiError {
 
 	return errs
 }
+
+func namespaceSelectorMode(obj ast.FileObject, allowDynamic bool) status.Error {
+	s, err := obj.Structured()
+	if err != nil {
+		return err
+	}
+	switch mode := s.(*v1.NamespaceSelector).Spec.Mode; mode {
+	case "", v1.NSSelectorStaticMode:
+		return nil
+	case v1.NSSelectorDynamicMode:
+		if allowDynamic {
+			return nil
+		}
+		return selectors.UnsupportedDynamicNamespaceSelectorError(obj)
+	default:
+		return selectors.UnknownNamespaceSelectorModeError(obj, string(mode))
+	}
+}
@@ -18,13 +18,16 @@ import (
 	"errors"
 	"testing"
 
+	v1 "kpt.dev/configsync/pkg/api/configmanagement/v1"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/importer/analyzer/transform/selectors"
 	"kpt.dev/configsync/pkg/importer/analyzer/validation/nonhierarchical"
 	"kpt.dev/configsync/pkg/kinds"
 	"kpt.dev/configsync/pkg/status"
 	"kpt.dev/configsync/pkg/testing/fake"
 	"kpt.dev/configsync/pkg/validate/objects"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestNamespaceSelectors(t *testing.T) {
@@ -75,3 +78,67 @@ func TestNamespaceSelectors(t *testing.T) {
 		})
 	}
 }
+
+func namespaceSelectorWithMode(mode v1.NamespaceSelectorMode) ast.FileObject {
+	return fake.FileObject(fake.NamespaceSelectorObject(core.Name("first"), func(o client.Object) {
+		o.(*v1.NamespaceSelector).Spec.Mode = mode
+	}), "namespaces/ns.yaml")
+}
+
+func TestNamespaceSelectors_Mode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mode         v1.NamespaceSelectorMode
+		validate     objects.ScopedVisitor
+		nsReconciler bool
+		wantErrs     status.MultiError
+	}{
+		{
+			name:     "static mode",
+			mode:     v1.NSSelectorStaticMode,
+			validate: NamespaceSelectors,
+		},
+		{
+			name:     "dynamic mode",
+			mode:     v1.NSSelectorDynamicMode,
+			validate: NamespaceSelectors,
+		},
+		{
+			name:     "unknown mode",
+			mode:     "live",
+			validate: NamespaceSelectors,
+			wantErrs: selectors.UnknownNamespaceSelectorModeError(fake.NamespaceSelector(), "live"),
+		},
+		{
+			name:         "dynamic mode in a namespace repo",
+			mode:         v1.NSSelectorDynamicMode,
+			validate:     NamespaceSelectors,
+			nsReconciler: true,
+			wantErrs:     selectors.UnsupportedDynamicNamespaceSelectorError(fake.NamespaceSelector()),
+		},
+		{
+			name:     "static mode in a hierarchical repo",
+			mode:     v1.NSSelectorStaticMode,
+			validate: HierarchicalNamespaceSelectors,
+		},
+		{
+			name:     "dynamic mode in a hierarchical repo",
+			mode:     v1.NSSelectorDynamicMode,
+			validate: HierarchicalNamespaceSelectors,
+			wantErrs: selectors.UnsupportedDynamicNamespaceSelectorError(fake.NamespaceSelector()),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := &objects.Scoped{
+				Cluster:               []ast.FileObject{namespaceSelectorWithMode(tc.mode)},
+				IsNamespaceReconciler: tc.nsReconciler,
+			}
+			errs := tc.validate(objs)
+			if !errors.Is(errs, tc.wantErrs) {
+				t.Errorf("got error %v, want %v", errs, tc.wantErrs)
+			}
+		})
+	}
+}
@@ -39,6 +39,13 @@ type Options struct {
 	// ClusterName is the spec.clusterName of the cluster's ConfigManagement. This
 	// is used when hydrating cluster selectors.
 	ClusterName string
+	// ClusterLabels are the labels of the cluster read from the live cluster or
+	// passed to nomos. They override the labels of the Cluster object named
+	// ClusterName, if any, when hydrating cluster selectors.
+	ClusterLabels map[string]string
+	// ClusterValues are the values of the ${cluster.values.KEY} variables, read
+	// from the live cluster or from a local file.
+	ClusterValues map[string]string
 	// PolicyDir is the relative path of the root policy directory within the
 	// repo.
 	PolicyDir cmpath.Relative
@@ -66,6 +73,10 @@ type Options struct {
 	// IsNamespaceReconciler is a flag to indicate if the caller is a namespace
 	// reconciler which adds some additional validation logic.
 	IsNamespaceReconciler bool
+	// LiveNamespaces provides the Namespaces on the cluster, which
+	// NamespaceSelectors in dynamic mode select from in an unstructured repo. It
+	// is nil if the caller does not watch the cluster.
+	LiveNamespaces objects.LiveNamespaces
 	// Visitors is a list of optional visitor functions which can be used to
 	// inject additional validation or hydration steps on the final objects.
 	Visitors []VisitorFunc
@@ -83,6 +94,8 @@ func Hierarchical(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 	//   - adding metadata to resources (such as their filepath in the repo)
 	rawObjects := &objects.Raw{
 		ClusterName:       opts.ClusterName,
+		ClusterLabels:     opts.ClusterLabels,
+		ClusterValues:     opts.ClusterValues,
 		PolicyDir:         opts.PolicyDir,
 		Objects:           objs,
 		PreviousCRDs:      opts.PreviousCRDs,
@@ -162,6 +175,8 @@ func Unstructured(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 	//   - adding metadata to resources (such as their filepath in the repo)
 	rawObjects := &objects.Raw{
 		ClusterName:       opts.ClusterName,
+		ClusterLabels:     opts.ClusterLabels,
+		ClusterValues:     opts.ClusterValues,
 		PolicyDir:         opts.PolicyDir,
 		Objects:           objs,
 		PreviousCRDs:      opts.PreviousCRDs,
@@ -194,6 +209,7 @@ func Unstructured(objs []ast.FileObject, opts Options) ([]ast.FileObject, status
 
 	scopedObjects.DefaultNamespace = opts.DefaultNamespace
 	scopedObjects.IsNamespaceReconciler = opts.IsNamespaceReconciler
+	scopedObjects.LiveNamespaces = opts.LiveNamespaces
 	if errs := scoped.Unstructured(scopedObjects); errs != nil {
 		return nil, status.Append(nonBlockingErrs, errs)
 	}
@@ -1143,6 +1143,36 @@ func TestUnstructured(t *testing.T) {
 					core.Annotation(csmetadata.SourcePathAnnotationKey, dir+"/foo/validator.yaml")),
 			},
 		},
+		{
+			name: "variables in objects selected away",
+			options: Options{
+				ClusterName: "prod",
+			},
+			objs: []ast.FileObject{
+				fake.ClusterRoleAtPath("cluster/prod-owner_cr.yaml",
+					core.Name("prod-owner"),
+					core.Label("cluster", "${cluster.name}"),
+					core.Annotation(csmetadata.ClusterVariablesAnnotationKey, csmetadata.ClusterVariablesEnabled),
+					core.Annotation(csmetadata.ClusterNameSelectorAnnotationKey, "prod")),
+				// The variable has no value, but the object is not selected.
+				fake.ClusterRoleAtPath("cluster/dev-owner_cr.yaml",
+					core.Name("dev-owner"),
+					core.Label("team", "${cluster.values.team}"),
+					core.Annotation(csmetadata.ClusterVariablesAnnotationKey, csmetadata.ClusterVariablesEnabled),
+					core.Annotation(csmetadata.ClusterNameSelectorAnnotationKey, "dev")),
+			},
+			want: []ast.FileObject{
+				fake.ClusterRoleAtPath("cluster/prod-owner_cr.yaml",
+					core.Name("prod-owner"),
+					core.Label("cluster", "prod"),
+					core.Label(csmetadata.DeclaredVersionLabel, "v1"),
+					core.Annotation(csmetadata.DeclaredFieldsKey, `{"f:metadata":{"f:annotations":{"f:configsync.gke.io/cluster-name-selector":{},"f:configsync.gke.io/cluster-variables":{}},"f:labels":{"f:cluster":{}}},"f:rules":{}}`),
+					core.Annotation(csmetadata.ClusterNameAnnotationKey, "prod"),
+					core.Annotation(csmetadata.ClusterVariablesAnnotationKey, csmetadata.ClusterVariablesEnabled),
+					core.Annotation(csmetadata.ClusterNameSelectorAnnotationKey, "prod"),
+					core.Annotation(csmetadata.SourcePathAnnotationKey, dir+"/cluster/prod-owner_cr.yaml")),
+			},
+		},
 		{
 			name: "duplicate objects fails",
 			objs: []ast.FileObject{
@@ -0,0 +1,174 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package variables substitutes the per-cluster variables in the declared
+// configs.
+//
+// The variables are:
+//   - ${cluster.name}, the name of the cluster.
+//   - ${cluster.labels.KEY}, the label KEY of the cluster, read from its
+//     Cluster object in the repo or from the live cluster.
+//   - ${cluster.values.KEY}, the key KEY of the cluster values, read from the
+//     cluster-values ConfigMap on the live cluster or from a local file.
+//
+// The variables are only substituted in the objects which opt in with the
+// configsync.gke.io/cluster-variables: enabled annotation, so that the literal
+// ${cluster...} strings of other objects, such as scripts, are left untouched.
+// Only the string values of the objects are substituted, never the keys of
+// their fields. Other ${...} expressions are left untouched, and $${cluster.name}
+// is replaced by the literal ${cluster.name}.
+package variables
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/kinds"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UnresolvedVariableErrorCode is the error code for UnresolvedVariableError.
+const UnresolvedVariableErrorCode = "2018"
+
+var unresolvedVariableErrorBuilder = status.NewErrorBuilder(UnresolvedVariableErrorCode)
+
+// UnresolvedVariableError reports that an object declares variables which
+// have no value for the cluster.
+func UnresolvedVariableError(obj ast.FileObject, vars []string) status.Error {
+	return unresolvedVariableErrorBuilder.
+		Sprintf("The variables %s have no value for this cluster. "+
+			"Set them on the cluster, or escape them as $${...} to keep them literally in:", strings.Join(vars, ", ")).
+		BuildWithResources(obj)
+}
+
+const (
+	namePath   = "name"
+	labelsPath = "labels."
+	valuesPath = "values."
+)
+
+// variable matches the cluster variables, with an optional leading $ to
+// escape them.
+var variable = regexp.MustCompile(`\$?\$\{cluster\.([^}]*)\}`)
+
+// Values are the values of the variables for a cluster.
+type Values struct {
+	// ClusterName is the value of ${cluster.name}.
+	ClusterName string
+	// ClusterLabels are the labels of the cluster read from the live cluster,
+	// which override the labels of the Cluster object named ClusterName.
+	ClusterLabels map[string]string
+	// ClusterValues are the values of ${cluster.values.KEY}.
+	ClusterValues map[string]string
+}
+
+// Substitute replaces the variables in the string values of the given objects
+// which enable them with the ClusterVariablesAnnotationKey annotation, in
+// place. It returns an UnresolvedVariableError for each object which
+// declares variables without a value.
+func Substitute(objs []ast.FileObject, values Values) status.MultiError {
+	labels := ClusterLabels(objs, values)
+	resolve := func(path string) (string, bool) {
+		switch {
+		case path == namePath:
+			return values.ClusterName, values.ClusterName != ""
+		case strings.HasPrefix(path, labelsPath):
+			v, found := labels[strings.TrimPrefix(path, labelsPath)]
+			return v, found
+		case strings.HasPrefix(path, valuesPath):
+			v, found := values.ClusterValues[strings.TrimPrefix(path, valuesPath)]
+			return v, found
+		default:
+			return "", false
+		}
+	}
+
+	var errs status.MultiError
+	for _, obj := range objs {
+		if !enabled(obj) {
+			continue
+		}
+		unresolved := make(map[string]bool)
+		obj.Object = substituteValue(obj.Object, resolve, unresolved).(map[string]interface{})
+		if len(unresolved) > 0 {
+			var vars []string
+			for v := range unresolved {
+				vars = append(vars, v)
+			}
+			sort.Strings(vars)
+			errs = status.Append(errs, UnresolvedVariableError(obj, vars))
+		}
+	}
+	return errs
+}
+
+// enabled returns true if the object enables the substitution of the
+// variables in its string values.
+func enabled(obj client.Object) bool {
+	return core.GetAnnotation(obj, metadata.ClusterVariablesAnnotationKey) == metadata.ClusterVariablesEnabled
+}
+
+// ClusterLabels returns the labels of the Cluster object named ClusterName, if
+// any, overridden by the labels read from the live cluster.
+func ClusterLabels(objs []ast.FileObject, values Values) map[string]string {
+	result := make(map[string]string)
+	for _, obj := range objs {
+		if obj.GetObjectKind().GroupVersionKind() == kinds.Cluster() && obj.GetName() == values.ClusterName {
+			for k, v := range obj.GetLabels() {
+				result[k] = v
+			}
+		}
+	}
+	for k, v := range values.ClusterLabels {
+		result[k] = v
+	}
+	return result
+}
+
+// substituteValue returns the value with the variables in its strings
+// replaced, recording the variables which resolve does not know.
+func substituteValue(value interface{}, resolve func(string) (string, bool), unresolved map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = substituteValue(field, resolve, unresolved)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = substituteValue(item, resolve, unresolved)
+		}
+		return v
+	case string:
+		return variable.ReplaceAllStringFunc(v, func(match string) string {
+			if strings.HasPrefix(match, "$$") {
+				return match[1:]
+			}
+			path := variable.FindStringSubmatch(match)[1]
+			result, found := resolve(path)
+			if !found {
+				unresolved[match] = true
+				return match
+			}
+			return result
+		})
+	default:
+		return v
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variables
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"kpt.dev/configsync/pkg/core"
+	"kpt.dev/configsync/pkg/importer/analyzer/ast"
+	"kpt.dev/configsync/pkg/metadata"
+	"kpt.dev/configsync/pkg/status"
+	"kpt.dev/configsync/pkg/testing/fake"
+)
+
+// configMap returns a ConfigMap which enables the variables.
+func configMap(data map[string]interface{}, opts ...core.MetaMutator) ast.FileObject {
+	opts = append(opts, core.Annotation(metadata.ClusterVariablesAnnotationKey, metadata.ClusterVariablesEnabled))
+	return plainConfigMap(data, opts...)
+}
+
+// plainConfigMap returns a ConfigMap which does not enable the variables.
+func plainConfigMap(data map[string]interface{}, opts ...core.MetaMutator) ast.FileObject {
+	obj := fake.UnstructuredObject(fake.ConfigMapObject().GroupVersionKind(), opts...)
+	obj.Object["data"] = data
+	return fake.FileObject(obj, "namespaces/foo/configmap.yaml")
+}
+
+func TestSubstitute(t *testing.T) {
+	values := Values{
+		ClusterName:   "prod-1",
+		ClusterLabels: map[string]string{"region": "us-east1"},
+		ClusterValues: map[string]string{"replicas": "3"},
+	}
+
+	testCases := []struct {
+		name     string
+		values   Values
+		objs     []ast.FileObject
+		want     []ast.FileObject
+		wantErrs status.MultiError
+	}{
+		{
+			name:   "substitute the cluster name, labels and values",
+			values: values,
+			objs: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"name":     "${cluster.name}",
+					"endpoint": "https://${cluster.labels.region}.example.com/${cluster.name}",
+					"replicas": "${cluster.values.replicas}",
+				}, core.Name("${cluster.name}-config")),
+			},
+			want: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"name":     "prod-1",
+					"endpoint": "https://us-east1.example.com/prod-1",
+					"replicas": "3",
+				}, core.Name("prod-1-config")),
+			},
+		},
+		{
+			name:   "read the labels from the Cluster object",
+			values: Values{ClusterName: "prod-1"},
+			objs: []ast.FileObject{
+				fake.Cluster(core.Name("prod-1"), core.Label("region", "europe-west1")),
+				fake.Cluster(core.Name("prod-2"), core.Label("region", "us-west1")),
+				configMap(map[string]interface{}{"region": "${cluster.labels.region}"}),
+			},
+			want: []ast.FileObject{
+				fake.Cluster(core.Name("prod-1"), core.Label("region", "europe-west1")),
+				fake.Cluster(core.Name("prod-2"), core.Label("region", "us-west1")),
+				configMap(map[string]interface{}{"region": "europe-west1"}),
+			},
+		},
+		{
+			name:   "live labels override the labels of the Cluster object",
+			values: values,
+			objs: []ast.FileObject{
+				fake.Cluster(core.Name("prod-1"), core.Label("region", "europe-west1")),
+				configMap(map[string]interface{}{"region": "${cluster.labels.region}"}),
+			},
+			want: []ast.FileObject{
+				fake.Cluster(core.Name("prod-1"), core.Label("region", "europe-west1")),
+				configMap(map[string]interface{}{"region": "us-east1"}),
+			},
+		},
+		{
+			name:   "keep escaped variables and other expressions",
+			values: values,
+			objs: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"script": "echo ${HOME} $${cluster.name}",
+					"list":   []interface{}{"${cluster.name}", int64(1)},
+				}),
+			},
+			want: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"script": "echo ${HOME} ${cluster.name}",
+					"list":   []interface{}{"prod-1", int64(1)},
+				}),
+			},
+		},
+		{
+			name:   "error on unresolved variables",
+			values: values,
+			objs: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"zone":    "${cluster.labels.zone}",
+					"unknown": "${cluster.project}",
+				}),
+			},
+			want: []ast.FileObject{
+				configMap(map[string]interface{}{
+					"zone":    "${cluster.labels.zone}",
+					"unknown": "${cluster.project}",
+				}),
+			},
+			wantErrs: UnresolvedVariableError(configMap(nil), []string{"${cluster.labels.zone}", "${cluster.project}"}),
+		},
+		{
+			name: "leave the objects which do not enable the variables unchanged",
+			objs: []ast.FileObject{
+				plainConfigMap(map[string]interface{}{
+					"script": "echo ${cluster.name} ${cluster.labels.region} ${cluster.values.replicas}",
+				}, core.Name("${cluster.name}-config")),
+			},
+			want: []ast.FileObject{
+				plainConfigMap(map[string]interface{}{
+					"script": "echo ${cluster.name} ${cluster.labels.region} ${cluster.values.replicas}",
+				}, core.Name("${cluster.name}-config")),
+			},
+		},
+		{
+			name:   "leave the objects which do not enable the variables unchanged with values",
+			values: values,
+			objs: []ast.FileObject{
+				plainConfigMap(map[string]interface{}{"name": "${cluster.name}", "zone": "${cluster.labels.zone}"}),
+				configMap(map[string]interface{}{"name": "${cluster.name}"}),
+			},
+			want: []ast.FileObject{
+				plainConfigMap(map[string]interface{}{"name": "${cluster.name}", "zone": "${cluster.labels.zone}"}),
+				configMap(map[string]interface{}{"name": "prod-1"}),
+			},
+		},
+		{
+			name: "error on the cluster name without a cluster name",
+			objs: []ast.FileObject{
+				configMap(map[string]interface{}{"name": "${cluster.name}"}),
+			},
+			want: []ast.FileObject{
+				configMap(map[string]interface{}{"name": "${cluster.name}"}),
+			},
+			wantErrs: UnresolvedVariableError(configMap(nil), []string{"${cluster.name}"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := Substitute(tc.objs, tc.values)
+			if !errors.Is(errs, tc.wantErrs) {
+				t.Errorf("got Substitute() error %v, want %v", errs, tc.wantErrs)
+			}
+			if diff := cmp.Diff(tc.want, tc.objs, ast.CompareFileObject); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 	"kpt.dev/configsync/pkg/applier"
+	csmetadata "kpt.dev/configsync/pkg/metadata"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -72,6 +73,10 @@ func fromConfigSync(req admission.Request) (bool, error) {
 		return false, err
 	}
 
+	// The shards of an inventory have the inventory id of the inventory.
+	if inventory := labels[csmetadata.InventoryShardOfLabel]; applier.IsInventoryShard(inventory, name) {
+		name = inventory
+	}
 	hasInventoryLabel := labels[common.InventoryLabel] == applier.InventoryID(name, namespace)
 	return hasInventoryLabel, nil
 }
@@ -17,17 +17,26 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
 	"kpt.dev/configsync/pkg/diff"
+	"kpt.dev/configsync/pkg/events"
 	csmetadata "kpt.dev/configsync/pkg/metadata"
 	"kpt.dev/configsync/pkg/syncer/differ"
 	"kpt.dev/configsync/pkg/webhook/configuration"
@@ -37,9 +46,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// breakGlassAuditAnnotation is the key of the audit annotation recording a
+// break-glass exemption. The API server prefixes it with the webhook name.
+const breakGlassAuditAnnotation = "break-glass-exemption"
+
+// exemptionAnnotationTimeout is how long the webhook waits for an exempted
+// request to be persisted, before it gives up annotating the object.
+const exemptionAnnotationTimeout = 30 * time.Second
+
 // AddValidator adds the admission webhook validator to the passed manager.
 func AddValidator(mgr manager.Manager) error {
-	handler, err := handler(mgr.GetConfig())
+	handler, err := handler(mgr.GetConfig(), mgr.GetAPIReader(), mgr.GetClient(), mgr.GetScheme())
 	if err != nil {
 		return err
 	}
@@ -53,12 +70,21 @@ func AddValidator(mgr manager.Manager) error {
 // requests and admits or denies them.
 type Validator struct {
 	differ *ObjectDiffer
+	// exemptions holds the break-glass policies which exempt users from the
+	// denials of the Validator.
+	exemptions *breakglass.Cache
+	// recorder records the Events of the exempted requests.
+	recorder record.EventRecorder
+	// writer annotates the objects of the exempted requests.
+	writer client.Writer
+	// reader reads the objects of the exempted requests before annotating them.
+	reader client.Reader
 }
 
 var _ admission.Handler = &Validator{}
 
 // Handler returns a Validator which satisfies the admission.Handler interface.
-func handler(cfg *rest.Config) (*Validator, error) {
+func handler(cfg *rest.Config, reader client.Reader, writer client.Writer, scheme *runtime.Scheme) (*Validator, error) {
 	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -67,11 +93,21 @@ func handler(cfg *rest.Config) (*Validator, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Validator{&ObjectDiffer{vc}}, nil
+	recorder, err := events.NewRecorder(cfg, scheme, "admission-webhook")
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{
+		differ:     &ObjectDiffer{vc},
+		exemptions: breakglass.NewCache(reader, breakglass.DefaultRefreshPeriod),
+		recorder:   recorder,
+		writer:     writer,
+		reader:     reader,
+	}, nil
 }
 
 // Handle implements admission.Handler
-func (v *Validator) Handle(_ context.Context, req admission.Request) admission.Response {
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
 	// An admission request for a sub-resource (such as a Scale) will not include
 	// the full parent for us to validate until the admission chain is fixed:
 	// https://github.com/kubernetes/enhancements/pull/1600
@@ -92,6 +128,10 @@ func (v *Validator) Handle(_ context.Context, req admission.Request) admission.R
 	// Check UserInfo for Config Sync service account and handle if found.
 	if isConfigSyncSA(req.UserInfo) {
 		username := configSyncSAName(req.UserInfo)
+		if username == configuration.ShortName {
+			// The webhook itself only annotates the objects of exempted requests.
+			return allow()
+		}
 		manager := objectManager(oldObj, newObj)
 		id := objectID(oldObj, newObj)
 		// TODO: validate managed=enabled?
@@ -109,17 +149,85 @@ func (v *Validator) Handle(_ context.Context, req admission.Request) admission.R
 	}
 
 	username := req.UserInfo.Username
+	var resp admission.Response
 	switch req.Operation {
 	case admissionv1.Create:
-		return v.handleCreate(newObj, username)
+		resp = v.handleCreate(newObj, username)
 	case admissionv1.Delete:
-		return v.handleDelete(oldObj, username)
+		resp = v.handleDelete(oldObj, username)
 	case admissionv1.Update:
-		return v.handleUpdate(oldObj, newObj, username)
+		resp = v.handleUpdate(oldObj, newObj, username)
 	default:
 		klog.Errorf("Unsupported operation: %v from %s", req.Operation, username)
 		return allow()
 	}
+	if !resp.Allowed {
+		return v.exempt(ctx, req, oldObj, newObj, resp)
+	}
+	return resp
+}
+
+// exempt admits a denied request if a break-glass policy exempts the user.
+// The exemption is recorded in an audit annotation of the request, in an Event
+// on the object, and in an annotation of the object.
+func (v *Validator) exempt(ctx context.Context, req admission.Request, oldObj, newObj client.Object, denied admission.Response) admission.Response {
+	obj := objectOf(oldObj, newObj)
+	gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+	now := v.exemptions.Now()
+	p := breakglass.Exemption(v.exemptions.Policies(ctx), req.UserInfo, gk, obj.GetNamespace(), now)
+	if p == nil {
+		return denied
+	}
+	operation := strings.ToLower(string(req.Operation))
+	msg := fmt.Sprintf("%s was allowed to %s the managed object by the %s, overriding: %s",
+		req.UserInfo.Username, operation, p, denied.Result.Message)
+	klog.Warningf("Break-glass exemption for %q: %s", core.GKNN(obj), msg)
+	v.recorder.Event(obj, corev1.EventTypeWarning, events.ReasonBreakGlassExemption, msg)
+
+	// The object of a deletion is gone once the request is persisted.
+	if req.Operation != admissionv1.Delete {
+		var oldVersion string
+		if oldObj != nil {
+			oldVersion = oldObj.GetResourceVersion()
+		}
+		exemption := fmt.Sprintf("%s by %s at %s under break-glass policy %s",
+			operation, req.UserInfo.Username, now.UTC().Format(time.RFC3339), p.Name)
+		go v.annotateExemption(context.Background(), obj, oldVersion, exemption)
+	}
+
+	resp := allow()
+	resp.AuditAnnotations = map[string]string{breakGlassAuditAnnotation: msg}
+	return resp
+}
+
+// annotateExemption records the exemption in an annotation of obj, so that the
+// audit trail outlives the Event. It waits until the exempted request has been
+// persisted, i.e. obj exists with a resourceVersion other than oldVersion, so
+// that the annotation does not make the request fail with a conflict.
+func (v *Validator) annotateExemption(ctx context.Context, obj client.Object, oldVersion, exemption string) {
+	if v.writer == nil {
+		return
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	key := client.ObjectKeyFromObject(obj)
+	err := wait.PollImmediate(time.Second, exemptionAnnotationTimeout, func() (bool, error) {
+		if err := v.reader.Get(ctx, key, u); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if u.GetResourceVersion() == oldVersion {
+			return false, nil
+		}
+		patch := client.MergeFrom(u.DeepCopy())
+		core.SetAnnotation(u, csmetadata.BreakGlassExemptionAnnotationKey, exemption)
+		return true, v.writer.Patch(ctx, u, patch)
+	})
+	if err != nil {
+		klog.Warningf("Failed to record the break-glass exemption in an annotation of %q: %v", core.GKNN(obj), err)
+	}
 }
 
 func (v *Validator) handleCreate(newObj client.Object, username string) admission.Response {
@@ -237,6 +345,15 @@ func objectManager(oldObj, newObj client.Object) string {
 	return mgr
 }
 
+// objectOf returns the object of the request, which is the old object of a
+// Delete request.
+func objectOf(oldObj, newObj client.Object) client.Object {
+	if newObj != nil {
+		return newObj
+	}
+	return oldObj
+}
+
 func objectID(oldObj, newObj client.Object) core.ID {
 	if oldObj != nil {
 		return core.IDOf(oldObj)
@@ -18,20 +18,26 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"kpt.dev/configsync/pkg/api/configsync"
 	"kpt.dev/configsync/pkg/applier"
+	"kpt.dev/configsync/pkg/breakglass"
 	"kpt.dev/configsync/pkg/core"
 	"kpt.dev/configsync/pkg/declared"
+	"kpt.dev/configsync/pkg/events"
 	"kpt.dev/configsync/pkg/importer"
 	csmetadata "kpt.dev/configsync/pkg/metadata"
+	syncertestfake "kpt.dev/configsync/pkg/syncer/syncertest/fake"
 	"kpt.dev/configsync/pkg/testing/fake"
+	"kpt.dev/configsync/pkg/webhook/configuration"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -542,6 +548,33 @@ func TestValidator_Handle(t *testing.T) {
 			user: bob(),
 			deny: metav1.StatusReasonUnauthorized,
 		},
+		{
+			name: "Bob updates a ResourceGroup inventory shard generated by ConfigSync",
+			oldObj: fake.ResourceGroupObject(
+				core.Name("repo-sync-shard-1"),
+				core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Label(csmetadata.InventoryShardOfLabel, "repo-sync"),
+				core.Label(common.InventoryLabel, applier.InventoryID("repo-sync", "bookstore"))),
+			newObj: fake.ResourceGroupObject(
+				core.Name("repo-sync-shard-1"),
+				core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Label(csmetadata.InventoryShardOfLabel, "repo-sync"),
+				core.Label(common.InventoryLabel, applier.InventoryID("repo-sync", "bookstore")),
+				core.Label("acme.com/foo", "bar")),
+			user: bob(),
+			deny: metav1.StatusReasonUnauthorized,
+		},
+		{
+			name: "Bob creates a ResourceGroup claiming to shard another inventory",
+			newObj: fake.ResourceGroupObject(
+				core.Name("user-created"),
+				core.Namespace("bookstore"),
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Label(csmetadata.InventoryShardOfLabel, "repo-sync")),
+			user: bob(),
+		},
 		{
 			name: "Bob creates an independent ResourceGroup",
 			newObj: fake.ResourceGroupObject(
@@ -603,6 +636,19 @@ func TestValidator_Handle(t *testing.T) {
 				core.Annotation(csmetadata.LifecycleMutationAnnotation, "other")),
 			deny: metav1.StatusReasonForbidden,
 		},
+		{
+			name: "Admission webhook annotates a managed object after a break-glass exemption",
+			oldObj: fake.RoleObject(
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Annotation(csmetadata.ResourceIDKey, "rbac.authorization.k8s.io_role_default-name"),
+				core.Annotation(csmetadata.ResourceManagerKey, rootSyncManagerAnnotation(rootSyncName))),
+			newObj: fake.RoleObject(
+				core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+				core.Annotation(csmetadata.ResourceIDKey, "rbac.authorization.k8s.io_role_default-name"),
+				core.Annotation(csmetadata.ResourceManagerKey, rootSyncManagerAnnotation(rootSyncName)),
+				core.Annotation(csmetadata.BreakGlassExemptionAnnotationKey, "update by bob@acme.com")),
+			user: admissionWebhook(),
+		},
 		{
 			name: "Bob manually adds lifecycle annotation",
 			oldObj: fake.RoleObject(
@@ -637,6 +683,97 @@ func TestValidator_Handle(t *testing.T) {
 	}
 }
 
+func TestValidator_BreakGlass(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := corev1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	policies := fake.ConfigMapObject(core.Name(breakglass.PolicyConfigMapName), core.Namespace(configsync.ControllerNamespace))
+	policies.Data = map[string]string{
+		"incident": fmt.Sprintf(`
+groups: devs@acme.com
+kinds: Role.rbac.authorization.k8s.io
+expiresAt: %q
+`, time.Now().Add(time.Hour).Format(time.RFC3339)),
+		"expired": fmt.Sprintf(`
+users: alice@acme.com
+kinds: "*"
+expiresAt: %q
+`, time.Now().Add(-time.Hour).Format(time.RFC3339)),
+	}
+	managedRole := fake.RoleObject(
+		core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+		core.Annotation(csmetadata.ResourceIDKey, "rbac.authorization.k8s.io_role_default-name"))
+	managedClusterRole := fake.ClusterRoleObject(
+		core.Annotation(csmetadata.ResourceManagementKey, csmetadata.ResourceManagementEnabled),
+		core.Annotation(csmetadata.ResourceIDKey, "rbac.authorization.k8s.io_clusterrole_default-name"))
+
+	testCases := []struct {
+		name      string
+		oldObj    client.Object
+		user      authenticationv1.UserInfo
+		wantAllow bool
+	}{
+		{
+			name:      "Bob deletes a managed Role with an exemption for his group",
+			oldObj:    managedRole,
+			user:      bob(),
+			wantAllow: true,
+		},
+		{
+			name:   "Bob deletes a managed ClusterRole which the exemption does not cover",
+			oldObj: managedClusterRole,
+			user:   bob(),
+		},
+		{
+			name:   "Alice deletes a managed Role with an expired exemption",
+			oldObj: managedRole,
+			user:   authenticationv1.UserInfo{Username: "alice@acme.com"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := syncertestfake.NewEventRecorder(t)
+			v := validatorForTest(t)
+			v.exemptions = breakglass.NewCache(syncertestfake.NewClient(t, s, policies), breakglass.DefaultRefreshPeriod)
+			v.recorder = recorder
+
+			req := request(tc.oldObj, nil)
+			req.UserInfo = tc.user
+			resp := v.Handle(context.Background(), req)
+			if resp.Allowed != tc.wantAllow {
+				t.Fatalf("got Handle() response allowed %t, want %t", resp.Allowed, tc.wantAllow)
+			}
+			if !tc.wantAllow {
+				recorder.Check(t)
+				return
+			}
+			if resp.AuditAnnotations[breakGlassAuditAnnotation] == "" {
+				t.Errorf("got Handle() response without the %q audit annotation", breakGlassAuditAnnotation)
+			}
+			recorder.Check(t, *syncertestfake.NewEvent(tc.oldObj, corev1.EventTypeWarning, events.ReasonBreakGlassExemption))
+		})
+	}
+}
+
+func TestValidator_AnnotateExemption(t *testing.T) {
+	obj := fake.ConfigMapObject(core.Name("cm"), core.Namespace("bookstore"))
+	obj.SetResourceVersion("2")
+	c := syncertestfake.NewClient(t, runtime.NewScheme(), obj)
+	v := &Validator{reader: c, writer: c}
+
+	exemption := "update by bob@acme.com at 2022-03-01T12:00:00Z under break-glass policy incident"
+	v.annotateExemption(context.Background(), fake.ConfigMapObject(core.Name("cm"), core.Namespace("bookstore")), "1", exemption)
+
+	got := &corev1.ConfigMap{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GetAnnotations()[csmetadata.BreakGlassExemptionAnnotationKey] != exemption {
+		t.Errorf("got annotations %v, want %s: %s", got.GetAnnotations(), csmetadata.BreakGlassExemptionAnnotationKey, exemption)
+	}
+}
+
 func validatorForTest(t *testing.T) *Validator {
 	vc, err := declared.ValueConverterForTest()
 	if err != nil {
@@ -667,6 +804,13 @@ func configSyncNamespaceReconciler(ns, rsName string) authenticationv1.UserInfo
 	}
 }
 
+func admissionWebhook() authenticationv1.UserInfo {
+	return authenticationv1.UserInfo{
+		Groups:   []string{saGroup, saNamespaceGroup},
+		Username: saNamespaceGroupPrefix + configuration.ShortName,
+	}
+}
+
 func bob() authenticationv1.UserInfo {
 	return authenticationv1.UserInfo{
 		Groups:   []string{"devs@acme.com"},